@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/coreos/matchbox/matchbox/bench"
+)
+
+// runBench runs the bench subcommand, which simulates machines booting from
+// a matchbox server and reports latency percentiles.
+func runBench(args []string) {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	target := flags.String("url", "http://127.0.0.1:8080", "matchbox HTTP server URL")
+	machines := flags.Int("machines", 100, "Number of machines to simulate")
+	concurrency := flags.Int("concurrency", 0, "Number of machines booting at once (0 boots all at once)")
+	assets := flags.Bool("assets", true, "Download the kernel and initrds served by the matchbox server")
+	timeout := flags.Duration("timeout", time.Minute, "Timeout of each request, including reading the response")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: matchbox bench [flags]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if *machines < 1 || *concurrency < 0 || *timeout <= 0 {
+		log.Fatal("Provide a positive -machines and -timeout and a non-negative -concurrency")
+	}
+
+	// stop early on interrupt, still reporting booted machines
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-interrupts
+		cancel()
+	}()
+
+	report, err := bench.Run(ctx, &bench.Config{
+		URL:         *target,
+		Machines:    *machines,
+		Concurrency: *concurrency,
+		Assets:      *assets,
+		Client: &http.Client{
+			Timeout: *timeout,
+			Transport: &http.Transport{
+				Proxy:               http.ProxyFromEnvironment,
+				MaxIdleConnsPerHost: *machines,
+			},
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := report.Write(os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,50 +1,219 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/coreos/pkg/flagutil"
+	"golang.org/x/crypto/openpgp"
 
+	"github.com/coreos/matchbox/matchbox/acl"
+	"github.com/coreos/matchbox/matchbox/acme"
+	"github.com/coreos/matchbox/matchbox/assets"
+	"github.com/coreos/matchbox/matchbox/attest"
+	"github.com/coreos/matchbox/matchbox/audit"
+	"github.com/coreos/matchbox/matchbox/defaults"
+	"github.com/coreos/matchbox/matchbox/events"
+	"github.com/coreos/matchbox/matchbox/extension"
+	"github.com/coreos/matchbox/matchbox/flagfile"
+	"github.com/coreos/matchbox/matchbox/gitops"
 	web "github.com/coreos/matchbox/matchbox/http"
+	"github.com/coreos/matchbox/matchbox/inventory"
+	"github.com/coreos/matchbox/matchbox/kubeadm"
+	"github.com/coreos/matchbox/matchbox/leader"
+	"github.com/coreos/matchbox/matchbox/leases"
+	"github.com/coreos/matchbox/matchbox/matcher"
+	"github.com/coreos/matchbox/matchbox/netbox"
+	"github.com/coreos/matchbox/matchbox/oidc"
+	"github.com/coreos/matchbox/matchbox/power"
+	"github.com/coreos/matchbox/matchbox/redact"
+	"github.com/coreos/matchbox/matchbox/reload"
+	"github.com/coreos/matchbox/matchbox/remote"
 	"github.com/coreos/matchbox/matchbox/rpc"
+	"github.com/coreos/matchbox/matchbox/sealed"
+	"github.com/coreos/matchbox/matchbox/selectors"
 	"github.com/coreos/matchbox/matchbox/server"
 	"github.com/coreos/matchbox/matchbox/sign"
+	"github.com/coreos/matchbox/matchbox/socket"
+	"github.com/coreos/matchbox/matchbox/sources"
 	"github.com/coreos/matchbox/matchbox/storage"
 	"github.com/coreos/matchbox/matchbox/tlsutil"
+	"github.com/coreos/matchbox/matchbox/trace"
+	"github.com/coreos/matchbox/matchbox/ui"
+	"github.com/coreos/matchbox/matchbox/vault"
 	"github.com/coreos/matchbox/matchbox/version"
+	"github.com/coreos/matchbox/matchbox/webhook"
 )
 
 var (
-	// Defaults to info logging
-	log = logrus.New()
+	// Defaults to info logging. The standard logger's level may be set
+	// while logging (logrus.SetLevel), as when the config file is reloaded.
+	log = logrus.StandardLogger()
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		runBench(os.Args[2:])
+		return
+	}
+
 	flags := struct {
+		configFile  string
 		address     string
+		httpsAddr   string
 		rpcAddress  string
 		dataPath    string
+		storeCache  int
+		drain       time.Duration
+		fallback    bool
 		assetsPath  string
+		mirror      bool
+		registry    string
 		logLevel    string
+		logFormat   string
 		certFile    string
 		keyFile     string
 		caFile      string
 		keyRingPath string
+		reloadEvery time.Duration
+		imgCert     string
+		imgKey      string
+		rateLimit   float64
+		rateBurst   int
+		globalLimit float64
+		globalBurst int
+		acmeDomains string
+		acmeEmail   string
+		acmeDir     string
+		acmeCache   string
+		acmeChal    string
+		acmeDNSHook string
+		webhook     string
+		localBoot   string
+		chainURL    string
+		chainLabels string
+		attempts    int
+		rescue      string
+		holding     string
+		enrollGroup string
+		enrollName  string
+		maintenance string
+		webhooks    string
+		failures    int
+		bootLoops   int
+		completeTTL time.Duration
+		installLim  int
+		installTTL  time.Duration
+		bootHist    int
+		eventTTL    time.Duration
+		ignTokens   bool
+		httpAllow   string
+		rpcAllow    string
+		rpcGzip     bool
+		uiAddress   string
+		uiEdit      bool
+		rpcMaxMsg   int
+		traceURL    string
+		auditSinks  string
+		leasesPath  string
+		bmcUser     string
+		bmcInsecure bool
+		inventory   string
+		invInterval time.Duration
+		netboxURL   string
+		netboxEvery time.Duration
+		netboxPush  bool
+		selectors   string
+		matcherURL  string
+		matcherTime time.Duration
+		matcherTTL  time.Duration
+		defaults    string
+		sourceTime  time.Duration
+		sourceTTL   time.Duration
+		remoteTime  time.Duration
+		remoteTTL   time.Duration
+		electMode   string
+		electName   string
+		electNS     string
+		electTTL    time.Duration
+		gitRepo     string
+		gitRef      string
+		gitPath     string
+		gitInterval time.Duration
+		gitKeyring  string
+		gitPrune    bool
+		gitCache    string
+		vaultAddr   string
+		vaultMount  string
+		vaultRole   string
+		vaultTTL    time.Duration
+		transit     string
+		ageIdentity string
+		sensitive   string
+		redactAPI   bool
+		tpmEKPath   string
+		tpmTTL      time.Duration
+		httpsCAFile string
+		kubeconfig  string
+		kubeTTL     time.Duration
+		oidcIssuer  string
+		oidcClient  string
+		oidcGroups  string
+		oidcRoles   string
+		slowRender  time.Duration
+		renderCache int
+		groupStats  bool
+		headerTTL   time.Duration
+		readTTL     time.Duration
+		writeTTL    time.Duration
+		idleTTL     time.Duration
+		pathTTLs    string
+		http2       bool
+		traceName   string
 		version     bool
 		help        bool
 	}{}
-	flag.StringVar(&flags.address, "address", "127.0.0.1:8080", "HTTP listen address")
-	flag.StringVar(&flags.rpcAddress, "rpc-address", "", "RPC listen address")
-	flag.StringVar(&flags.dataPath, "data-path", "/var/lib/matchbox", "Path to data directory")
+	flag.StringVar(&flags.configFile, "config", "", "Path to a YAML configuration file of flag values, which flags and environment variables override")
+	flag.StringVar(&flags.address, "address", "127.0.0.1:8080", "HTTP listen address (host:port, unix:PATH, or systemd:NAME), or comma separated addresses (e.g. an IPv4 and an IPv6 address)")
+	flag.StringVar(&flags.httpsAddr, "https-address", "", "HTTPS listen address, or comma separated addresses (requires ACME)")
+	flag.DurationVar(&flags.headerTTL, "http-read-header-timeout", web.DefaultReadHeaderTimeout, "Time to read HTTP request headers (0 disables)")
+	flag.DurationVar(&flags.readTTL, "http-read-timeout", web.DefaultReadTimeout, "Time to read HTTP requests, including bodies (0 disables)")
+	flag.DurationVar(&flags.writeTTL, "http-write-timeout", web.DefaultWriteTimeout, "Time to write HTTP responses (0 disables)")
+	flag.DurationVar(&flags.idleTTL, "http-idle-timeout", web.DefaultIdleTimeout, "Time to keep idle HTTP connections open (0 disables)")
+	flag.DurationVar(&flags.drain, "drain-timeout", web.DefaultDrainTimeout, "Time in-flight requests may finish on shutdown (SIGTERM) before connections are closed")
+	flag.StringVar(&flags.pathTTLs, "http-path-timeouts", "/assets/=0", "Comma separated path prefixes and write timeouts overriding -http-write-timeout (e.g. /assets/=0,/ignition=30s)")
+	flag.BoolVar(&flags.http2, "http2", true, "Serve HTTP/2 over TLS and cleartext (h2c) alongside HTTP/1.1")
+	flag.StringVar(&flags.httpsCAFile, "https-client-ca-file", "", "Path to a CA to verify optional HTTPS client certificates of machines")
+	flag.StringVar(&flags.rpcAddress, "rpc-address", "", "RPC listen address (host:port, unix:PATH, or systemd:NAME)")
+	flag.StringVar(&flags.uiAddress, "ui-address", "", "Web dashboard HTTPS listen address, requiring client certificates signed by -ca-file (disabled if empty)")
+	flag.BoolVar(&flags.uiEdit, "ui-edit", false, "Allow saving templates from the web dashboard's template editor")
+	flag.BoolVar(&flags.rpcGzip, "rpc-gzip", false, "Compress gRPC responses with gzip (all clients must accept gzip)")
+	flag.IntVar(&flags.rpcMaxMsg, "rpc-max-message-size", rpc.DefaultMaxMessageSize, "Largest gRPC request accepted, in bytes")
+	flag.StringVar(&flags.dataPath, "data-path", "/var/lib/matchbox", "Path to data directory, or comma separated paths of data directories layered in order, each overriding the ones before it (writes go to the last)")
+	flag.IntVar(&flags.storeCache, "store-cache-size", storage.DefaultCacheSize, "Parsed groups, profiles, profile versions, and machines cached in memory (0 disables)")
+	flag.BoolVar(&flags.fallback, "store-fallback", true, "Serve reads from the last known good snapshot while the store fails")
 	flag.StringVar(&flags.assetsPath, "assets-path", "/var/lib/matchbox/assets", "Path to static assets")
+	flag.BoolVar(&flags.mirror, "assets-mirror", false, "Fetch missing Profile assets from their upstream URLs")
+	flag.StringVar(&flags.registry, "assets-registry-username", "", "Username for OCI registries of mirrored assets (password via MATCHBOX_REGISTRY_PASSWORD)")
 
 	// Log levels https://github.com/Sirupsen/logrus/blob/master/logrus.go#L36
 	flag.StringVar(&flags.logLevel, "log-level", "info", "Set the logging level")
+	flag.StringVar(&flags.logFormat, "log-format", "text", "Set the logging format (text or json)")
+	flag.DurationVar(&flags.slowRender, "slow-render-threshold", time.Second, "Log a warning for template renders slower than this (0 disables)")
+	flag.BoolVar(&flags.groupStats, "group-match-metrics", false, "Count machine requests by matched group in matchbox_matched_group_total (one series per group)")
+	flag.IntVar(&flags.renderCache, "render-cache-size", 0, "Rendered Ignition configs and iPXE scripts cached for reuse by identical machines until the next store write (0 disables)")
 
 	// gRPC Server TLS
 	flag.StringVar(&flags.certFile, "cert-file", "/etc/matchbox/server.crt", "Path to the server TLS certificate file")
@@ -53,7 +222,138 @@ func main() {
 	flag.StringVar(&flags.caFile, "ca-file", "/etc/matchbox/ca.crt", "Path to the CA verify and authenticate client certificates")
 
 	// Signing
-	flag.StringVar(&flags.keyRingPath, "key-ring-path", "", "Path to a private keyring file")
+	flag.StringVar(&flags.keyRingPath, "key-ring-path", "", "Path to a private keyring file, or comma separated paths to sign with the keys of each")
+
+	// iPXE image trust
+	flag.StringVar(&flags.imgCert, "imgtrust-cert-file", "", "Path to a code signing certificate (PEM) to sign assets and iPXE scripts with, enables iPXE imgtrust")
+	flag.StringVar(&flags.imgKey, "imgtrust-key-file", "", "Path to the private key (PEM) of the -imgtrust-cert-file")
+
+	// Credential reloading
+	flag.DurationVar(&flags.reloadEvery, "reload-interval", reload.DefaultInterval, "Interval to check the configuration, TLS, and signing key files for changes (also reloaded on SIGHUP)")
+
+	// Rate limits
+	flag.Float64Var(&flags.rateLimit, "rate-limit", 0, "Requests per second allowed per client IP on boot endpoints (0 disables)")
+	flag.IntVar(&flags.rateBurst, "rate-limit-burst", 10, "Requests a client IP may burst on boot endpoints")
+	flag.Float64Var(&flags.globalLimit, "global-rate-limit", 0, "Requests per second allowed across all clients on boot endpoints (0 disables)")
+	flag.IntVar(&flags.globalBurst, "global-rate-limit-burst", 100, "Requests which may burst across all clients on boot endpoints")
+
+	// Network allowlists
+	flag.StringVar(&flags.httpAllow, "allow-http", "", "Comma separated CIDRs allowed to use HTTP boot endpoints (all if empty)")
+	flag.StringVar(&flags.rpcAllow, "allow-rpc", "", "Comma separated CIDRs allowed to use the gRPC API (all if empty)")
+
+	// Ignition tokens
+	flag.BoolVar(&flags.ignTokens, "ignition-tokens", false, "Require single-use tokens to fetch Ignition configs (tokens are held in memory, so they are lost on restart and only valid on the replica which minted them)")
+
+	// Provisioning completion
+	flag.StringVar(&flags.webhook, "complete-webhook", "", "URL to POST machine.complete events to when machines complete provisioning")
+	flag.IntVar(&flags.attempts, "install-attempts", 0, "Network boots without completing after which machines are served the -rescue-profile (0 disables)")
+	flag.IntVar(&flags.installLim, "install-limit", 0, "Machines which may install at once, across profiles, before others are served an iPXE retry script (0 disables)")
+	flag.DurationVar(&flags.installTTL, "install-timeout", web.DefaultInstallTimeout, "Time without an Ignition fetch or completion after which an installing machine no longer counts toward install limits")
+	flag.IntVar(&flags.bootHist, "boot-history", 0, "Config requests recorded per known machine (e.g. enrolled or provisioned), with the group, profile, and a checksum of the config served (0 disables)")
+	flag.DurationVar(&flags.eventTTL, "event-retention", 0, "Time boot and provisioning events are kept in the store's event history (0 disables recording)")
+	flag.StringVar(&flags.rescue, "rescue-profile", "", "Profile to serve machines which exceed -install-attempts, such as a rescue or diagnostic image")
+	flag.StringVar(&flags.holding, "holding-profile", "", "Profile to serve machines which match no group, which are recorded as pending until they are adopted")
+	flag.StringVar(&flags.enrollGroup, "enroll-group", "", "Group to enroll machines which match no group into, with a generated hostname")
+	flag.StringVar(&flags.enrollName, "enroll-hostname", server.DefaultEnrollHostname, "Hostname template of enrolled machines, with the {{.index}}, {{.hash}}, {{.uuid}}, and {{.mac}} of the machine")
+	flag.StringVar(&flags.maintenance, "maintenance", "", "Start in maintenance mode, in which iPXE requests boot from local disk (local) or wait and retry (hold) regardless of matching")
+	flag.StringVar(&flags.chainURL, "ipxe-chain-url", web.DefaultChainURL, "Template of the URL the /boot.ipxe script chainloads, with the forwarded iPXE settings {{.Query}} and /boot.ipxe request {{.Labels}}")
+	flag.StringVar(&flags.chainLabels, "ipxe-chain-labels", "", "Comma separated LABEL=SETTING iPXE settings /boot.ipxe forwards as labels, besides the defaults (e.g. site=net0/user-class)")
+	flag.StringVar(&flags.localBoot, "local-boot", "", "When installed machines are served a local boot iPXE script instead of their profile (complete or ignition)")
+
+	// Provisioning event webhooks
+	flag.StringVar(&flags.webhooks, "webhooks-path", "", "Path to a JSON file listing webhooks to notify of provisioning events")
+	flag.IntVar(&flags.failures, "webhook-failure-threshold", webhook.DefaultFailureThreshold, "Consecutive failed boot requests before a machine.failed event")
+	flag.IntVar(&flags.bootLoops, "webhook-boot-loop-threshold", webhook.DefaultBootLoopThreshold, "Network boots without fetching Ignition before a machine.boot_loop event")
+	flag.DurationVar(&flags.completeTTL, "webhook-complete-timeout", 0, "Time from boot to /v1/complete before a machine.timeout event (0 disables)")
+
+	// Machine facts
+	flag.StringVar(&flags.leasesPath, "dnsmasq-leases", "", "Path to a dnsmasq lease file to record machine IPs and hostnames from")
+
+	// Machine power control
+	flag.StringVar(&flags.bmcUser, "bmc-username", "", "Username for Redfish BMCs, enables power control (password via MATCHBOX_BMC_PASSWORD)")
+	flag.BoolVar(&flags.bmcInsecure, "bmc-insecure-skip-verify", false, "Skip verification of BMC TLS certificates")
+	flag.StringVar(&flags.inventory, "bmc-inventory", "", "Comma separated Redfish URLs or CIDRs of BMCs to record machine hardware inventory from")
+	flag.DurationVar(&flags.invInterval, "bmc-inventory-interval", 15*time.Minute, "Interval between reads of BMC hardware inventory")
+
+	// NetBox synchronization
+	flag.StringVar(&flags.netboxURL, "netbox-url", "", "NetBox URL to record devices as machine facts from (token via MATCHBOX_NETBOX_TOKEN)")
+	flag.DurationVar(&flags.netboxEvery, "netbox-interval", 5*time.Minute, "Interval between syncs with NetBox")
+	flag.BoolVar(&flags.netboxPush, "netbox-push-state", false, "Report machine provisioning states to the NetBox matchbox_state custom field")
+
+	// Group selectors
+	flag.StringVar(&flags.selectors, "selector-keys", "", "Path to a YAML or JSON file declaring custom selector keys, which rejects groups with undeclared selector keys or invalid values (any allowed if empty)")
+
+	// External matching
+	flag.StringVar(&flags.matcherURL, "matcher-url", "", "URL of an external service to match machine labels to groups, falls back to group selectors")
+	flag.DurationVar(&flags.matcherTime, "matcher-timeout", matcher.DefaultTimeout, "Timeout of external matcher requests")
+	flag.DurationVar(&flags.matcherTTL, "matcher-cache-ttl", matcher.DefaultCacheTTL, "Duration external matcher results are cached")
+
+	// Group metadata sources
+	flag.StringVar(&flags.defaults, "metadata-defaults", "", "Path to a YAML or JSON file of default metadata merged beneath the metadata of every group (e.g. /etc/matchbox/defaults.yaml)")
+	flag.DurationVar(&flags.sourceTime, "metadata-source-timeout", sources.DefaultTimeout, "Timeout of group metadata source fetches (Consul token via MATCHBOX_CONSUL_TOKEN)")
+	flag.DurationVar(&flags.sourceTTL, "metadata-source-cache-ttl", sources.DefaultCacheTTL, "Duration fetched group metadata source values are reused")
+
+	// Remote Ignition configs
+	flag.DurationVar(&flags.remoteTime, "remote-ignition-timeout", remote.DefaultTimeout, "Timeout of fetches of remote Ignition configs referenced by profiles")
+	flag.DurationVar(&flags.remoteTTL, "remote-ignition-cache-ttl", remote.DefaultCacheTTL, "Duration fetched remote Ignition configs are reused before revalidating")
+
+	// Leader election of replicas
+	flag.StringVar(&flags.electMode, "leader-election", "", "Elect a leader among replicas sharing a store to run Git sync, BMC inventory, and NetBox sync: store or kubernetes (disabled if empty)")
+	flag.StringVar(&flags.electName, "leader-election-name", "matchbox", "Name of the leader election lease (a file in the last -data-path or a Kubernetes Lease)")
+	flag.StringVar(&flags.electNS, "leader-election-namespace", "", "Namespace of the Kubernetes Lease (defaults to the pod's namespace)")
+	flag.DurationVar(&flags.electTTL, "leader-election-lease-duration", leader.DefaultLeaseDuration, "Duration a leader holds the lease without renewing it")
+
+	// GitOps reconciliation
+	flag.StringVar(&flags.gitRepo, "git-repository", "", "Git repository URL to continuously reconcile groups, profiles, and templates from")
+	flag.StringVar(&flags.gitRef, "git-ref", gitops.DefaultRef, "Branch, tag, or commit of the Git repository to apply")
+	flag.StringVar(&flags.gitPath, "git-path", "", "Directory in the Git repository with groups, profiles, ignition, cloud, and generic directories")
+	flag.DurationVar(&flags.gitInterval, "git-interval", gitops.DefaultInterval, "Interval between reconciliations with the Git repository")
+	flag.StringVar(&flags.gitKeyring, "git-keyring", "", "Path to an OpenPGP public keyring which must have signed applied commits")
+	flag.BoolVar(&flags.gitPrune, "git-prune", false, "Delete groups, profiles, and templates which are not in the Git repository")
+	flag.StringVar(&flags.gitCache, "git-cache-path", "", "Path to keep a mirror of the Git repository (defaults to gitops in the last -data-path)")
+
+	// Vault PKI machine certificates
+	flag.StringVar(&flags.vaultAddr, "vault-address", "", "Vault address to issue machine certificates from (token via MATCHBOX_VAULT_TOKEN)")
+	flag.StringVar(&flags.vaultMount, "vault-pki-mount", "pki", "Path of the Vault PKI secrets engine")
+	flag.StringVar(&flags.vaultRole, "vault-pki-role", "", "Vault PKI role to issue machine certificates from")
+	flag.DurationVar(&flags.vaultTTL, "vault-pki-ttl", 0, "TTL of issued machine certificates (0 for the role's TTL)")
+
+	// Encrypted group metadata
+	flag.StringVar(&flags.transit, "vault-transit-mount", "", "Path of the Vault Transit secrets engine to decrypt transit: group metadata values with")
+	flag.StringVar(&flags.ageIdentity, "age-identity", "", "Path to an age identity file to decrypt age: group metadata values with")
+
+	// Sensitive metadata redaction
+	flag.StringVar(&flags.sensitive, "sensitive-keys", "", "Comma separated metadata and label keys (globs allowed) whose values are redacted in logs, audit entries, and events")
+	flag.BoolVar(&flags.redactAPI, "redact-api", false, "Redact sensitive metadata values in read API responses, so they are write-only")
+
+	// TPM attestation
+	flag.StringVar(&flags.tpmEKPath, "tpm-ek-path", "", "Path to a directory of enrolled TPM EKs (<uuid>.pem), requires TPM attestation before serving Ignition")
+	flag.DurationVar(&flags.tpmTTL, "tpm-attestation-ttl", attest.DefaultTTL, "How long the credential of a TPM attestation may be used to fetch a machine's Ignition config")
+
+	// Kubernetes bootstrap tokens
+	flag.StringVar(&flags.kubeconfig, "kubeadm-kubeconfig", "", "Path to a kubeconfig to mint bootstrap tokens with, enables the kubeadmToken template function")
+	flag.DurationVar(&flags.kubeTTL, "kubeadm-token-ttl", 24*time.Hour, "TTL of minted bootstrap tokens")
+
+	// gRPC API OIDC authentication
+	flag.StringVar(&flags.oidcIssuer, "oidc-issuer", "", "OIDC issuer URL, requires gRPC calls and dashboard saves to present an ID token")
+	flag.StringVar(&flags.oidcClient, "oidc-client-id", "", "OIDC client ID which ID tokens must be issued to")
+	flag.StringVar(&flags.oidcGroups, "oidc-groups-claim", "groups", "ID token claim which lists the user's groups")
+	flag.StringVar(&flags.oidcRoles, "oidc-roles", "", "Comma separated GROUP=ROLE mappings of groups to viewer or editor roles")
+
+	// Audit
+	flag.StringVar(&flags.auditSinks, "audit-sinks", "", "Comma separated syslog, HTTP, or Kafka REST proxy URLs to export audit entries to")
+
+	// Tracing
+	flag.StringVar(&flags.traceURL, "trace-endpoint", "", "OTLP/HTTP endpoint to export traces to (disabled if empty)")
+	flag.StringVar(&flags.traceName, "trace-service-name", "matchbox", "Service name reported in exported traces")
+
+	// ACME certificates
+	flag.StringVar(&flags.acmeDomains, "acme-domains", "", "Comma separated domains to obtain an ACME certificate for (disabled if empty)")
+	flag.StringVar(&flags.acmeEmail, "acme-email", "", "Contact email for the ACME account")
+	flag.StringVar(&flags.acmeDir, "acme-directory", acme.LetsEncryptURL, "ACME directory URL")
+	flag.StringVar(&flags.acmeCache, "acme-cache-path", "/var/lib/matchbox/acme", "Path to cache the ACME account key and certificates")
+	flag.StringVar(&flags.acmeChal, "acme-challenge", acme.ChallengeHTTP01, "ACME challenge type (http-01 or dns-01)")
+	flag.StringVar(&flags.acmeDNSHook, "acme-dns-hook", "", "Executable to present and clean up dns-01 TXT records")
 
 	// subcommands
 	flag.BoolVar(&flags.version, "version", false, "print version and exit")
@@ -64,8 +364,24 @@ func main() {
 	if err := flagutil.SetFlagsFromEnv(flag.CommandLine, "MATCHBOX"); err != nil {
 		log.Fatal(err.Error())
 	}
+	var configFile *flagfile.File
+	if flags.configFile != "" {
+		var err error
+		configFile, err = flagfile.Load(flag.CommandLine, flags.configFile)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}
 	// restrict OpenPGP passphrase to pass via environment variable only
 	passphrase := os.Getenv("MATCHBOX_PASSPHRASE")
+	// restrict BMC password to pass via environment variable only
+	bmcPassword := os.Getenv("MATCHBOX_BMC_PASSWORD")
+	// restrict NetBox API token to pass via environment variable only
+	netboxToken := os.Getenv("MATCHBOX_NETBOX_TOKEN")
+	// restrict Vault token to pass via environment variable only
+	vaultToken := os.Getenv("MATCHBOX_VAULT_TOKEN")
+	// restrict Consul ACL token to pass via environment variable only
+	consulToken := os.Getenv("MATCHBOX_CONSUL_TOKEN")
 
 	if flags.version {
 		fmt.Println(version.Version)
@@ -78,21 +394,148 @@ func main() {
 	}
 
 	// validate arguments
-	if finfo, err := os.Stat(flags.dataPath); err != nil || !finfo.IsDir() {
-		log.Fatal("A valid -data-path is required")
+	var dataPaths []string
+	for _, path := range strings.Split(flags.dataPath, ",") {
+		path = strings.TrimSpace(path)
+		if finfo, err := os.Stat(path); err != nil || !finfo.IsDir() {
+			log.Fatalf("A valid -data-path is required: %s", path)
+		}
+		dataPaths = append(dataPaths, path)
 	}
+	// writable data directory, the top layer
+	dataPath := dataPaths[len(dataPaths)-1]
 	if flags.assetsPath != "" {
 		if finfo, err := os.Stat(flags.assetsPath); err != nil || !finfo.IsDir() {
 			log.Fatalf("Provide a valid -assets-path or '' to disable asset serving: %s", flags.assetsPath)
 		}
 	}
-	if flags.rpcAddress != "" {
+	switch flags.electMode {
+	case "", "store", "kubernetes":
+	default:
+		log.Fatalf("Invalid -leader-election %q, must be store or kubernetes", flags.electMode)
+	}
+	if flags.electMode != "" && (flags.electName == "" || strings.ContainsAny(flags.electName, `/\`)) {
+		log.Fatalf("Invalid -leader-election-name %q", flags.electName)
+	}
+	if flags.inventory != "" && flags.bmcUser == "" {
+		log.Fatal("Provide a -bmc-username to read BMC inventory")
+	}
+	if flags.netboxURL != "" && netboxToken == "" {
+		log.Fatal("Provide a MATCHBOX_NETBOX_TOKEN to sync with NetBox")
+	}
+	if flags.vaultAddr != "" && vaultToken == "" {
+		log.Fatal("Provide a MATCHBOX_VAULT_TOKEN to use Vault")
+	}
+	if flags.vaultAddr != "" && flags.vaultRole == "" && flags.transit == "" {
+		log.Fatal("Provide a -vault-pki-role to issue machine certificates or a -vault-transit-mount to decrypt metadata")
+	}
+	if flags.transit != "" && flags.vaultAddr == "" {
+		log.Fatal("Provide a -vault-address to decrypt metadata with Vault Transit")
+	}
+	if flags.ageIdentity != "" {
+		if _, err := os.Stat(flags.ageIdentity); err != nil {
+			log.Fatalf("Provide a valid -age-identity: %v", err)
+		}
+	}
+	if (flags.imgCert == "") != (flags.imgKey == "") {
+		log.Fatal("Provide both an -imgtrust-cert-file and -imgtrust-key-file to sign images")
+	}
+	if flags.attempts < 0 || (flags.attempts > 0) != (flags.rescue != "") {
+		log.Fatal("Provide both a positive -install-attempts and a -rescue-profile to rescue machines")
+	}
+	if flags.installLim < 0 || flags.installTTL <= 0 {
+		log.Fatal("Provide a non-negative -install-limit and a positive -install-timeout")
+	}
+	if flags.bootHist < 0 {
+		log.Fatal("Provide a non-negative -boot-history")
+	}
+	if flags.eventTTL < 0 {
+		log.Fatal("Provide a non-negative -event-retention")
+	}
+	if flags.renderCache < 0 {
+		log.Fatal("Provide a non-negative -render-cache-size")
+	}
+	if flags.drain < 0 {
+		log.Fatal("Provide a non-negative -drain-timeout")
+	}
+	if flags.headerTTL < 0 || flags.readTTL < 0 || flags.writeTTL < 0 || flags.idleTTL < 0 {
+		log.Fatal("Provide non-negative -http-read-header-timeout, -http-read-timeout, -http-write-timeout, and -http-idle-timeout")
+	}
+	chain, err := web.ParseChain(flags.chainURL, flags.chainLabels)
+	if err != nil {
+		log.Fatalf("Invalid -ipxe-chain-url or -ipxe-chain-labels: %v", err)
+	}
+	pathTTLs, err := web.ParsePathTimeouts(flags.pathTTLs)
+	if err != nil {
+		log.Fatalf("Invalid -http-path-timeouts: %v", err)
+	}
+	timeouts := &web.Timeouts{
+		ReadHeader: flags.headerTTL,
+		Read:       flags.readTTL,
+		Write:      flags.writeTTL,
+		Idle:       flags.idleTTL,
+		Paths:      pathTTLs,
+	}
+	if flags.storeCache < 0 {
+		log.Fatal("Provide a non-negative -store-cache-size")
+	}
+	if err := web.ValidateLocalBoot(flags.localBoot); err != nil {
+		log.Fatal("Provide a valid -local-boot of complete or ignition")
+	}
+	if flags.holding != "" && flags.enrollGroup != "" {
+		log.Fatal("Provide only one of -holding-profile or -enroll-group for machines which match no group")
+	}
+	enrollName, err := server.ParseEnrollHostname(flags.enrollName)
+	if err != nil {
+		log.Fatalf("Provide a valid -enroll-hostname template: %v", err)
+	}
+	if err := server.ValidateMaintenance(flags.maintenance); err != nil {
+		log.Fatal("Provide a valid -maintenance of local or hold")
+	}
+	if flags.redactAPI && flags.sensitive == "" {
+		log.Fatal("Provide -sensitive-keys to redact in API responses")
+	}
+	if flags.tpmEKPath != "" {
+		if finfo, err := os.Stat(flags.tpmEKPath); err != nil || !finfo.IsDir() {
+			log.Fatal("Provide a valid -tpm-ek-path directory of enrolled EKs")
+		}
+	}
+	if flags.httpsCAFile != "" && flags.httpsAddr == "" {
+		log.Fatal("Provide an -https-address to verify client certificates on")
+	}
+	if flags.mirror && flags.assetsPath == "" {
+		log.Fatal("Provide a valid -assets-path to mirror assets into")
+	}
+	if flags.rateLimit < 0 || flags.globalLimit < 0 {
+		log.Fatal("Rate limits must not be negative")
+	}
+	httpAllowlist, err := acl.Parse(flags.httpAllow)
+	if err != nil {
+		log.Fatalf("Invalid -allow-http: %v", err)
+	}
+	rpcAllowlist, err := acl.Parse(flags.rpcAllow)
+	if err != nil {
+		log.Fatalf("Invalid -allow-rpc: %v", err)
+	}
+	oidcRoles, err := oidc.ParseRoles(flags.oidcRoles)
+	if err != nil {
+		log.Fatalf("Invalid -oidc-roles: %v", err)
+	}
+	if flags.oidcIssuer != "" && (flags.oidcClient == "" || len(oidcRoles) == 0) {
+		log.Fatal("Provide an -oidc-client-id and -oidc-roles to authenticate with OIDC")
+	}
+	if flags.httpsAddr != "" && flags.acmeDomains == "" {
+		log.Fatal("Provide -acme-domains to serve HTTPS")
+	}
+	if flags.rpcAddress != "" && flags.acmeDomains == "" {
 		if _, err := os.Stat(flags.certFile); err != nil {
 			log.Fatalf("Provide a valid TLS server certificate with -cert-file: %v", err)
 		}
 		if _, err := os.Stat(flags.keyFile); err != nil {
 			log.Fatalf("Provide a valid TLS server key with -key-file: %v", err)
 		}
+	}
+	if flags.rpcAddress != "" {
 		if _, err := os.Stat(flags.caFile); err != nil {
 			log.Fatalf("Provide a valid TLS certificate authority for authorizing client certificates: %v", err)
 		}
@@ -103,66 +546,692 @@ func main() {
 	if err != nil {
 		log.Fatalf("invalid log-level: %v", err)
 	}
-	log.Level = lvl
+	logrus.SetLevel(lvl)
+	switch flags.logFormat {
+	case "text":
+	case "json":
+		log.Formatter = &logrus.JSONFormatter{}
+	default:
+		log.Fatalf("invalid log-format: %s", flags.logFormat)
+	}
+
+	// reload credentials and the configuration file when their files change
+	watcher := reload.NewWatcher(&reload.Config{
+		Interval: flags.reloadEvery,
+		Logger:   log,
+	})
+	if configFile != nil {
+		// only the log level is applied, other changes need a restart (see
+		// Documentation/config.md)
+		watcher.Add("configuration file", func() error {
+			changed, err := configFile.Reload()
+			if err != nil {
+				return err
+			}
+			for name, value := range changed {
+				if name != "log-level" {
+					log.Warnf("Flag %s changed in %s, restart matchbox to apply it", name, configFile.Path())
+					continue
+				}
+				lvl, err := logrus.ParseLevel(value)
+				if err != nil {
+					return fmt.Errorf("invalid log-level: %v", err)
+				}
+				logrus.SetLevel(lvl)
+			}
+			return nil
+		}, configFile.Path())
+	}
 
 	// (optional) signing
 	var signer, armoredSigner sign.Signer
 	if flags.keyRingPath != "" {
-		entity, err := sign.LoadGPGEntity(flags.keyRingPath, passphrase)
+		// sign with the keys of each key ring, to rotate keys
+		var paths []string
+		for _, path := range strings.Split(flags.keyRingPath, ",") {
+			paths = append(paths, strings.TrimSpace(path))
+		}
+		keyring, err := sign.NewKeyring(paths, passphrase)
+		if err != nil {
+			log.Fatal(err)
+		}
+		watcher.Add("signing keys", keyring.Reload, keyring.Paths()...)
+		signer = keyring.Signer()
+		armoredSigner = keyring.ArmoredSigner()
+	}
+
+	// (optional) iPXE image trust
+	var imageSigner sign.Signer
+	if flags.imgCert != "" {
+		keyPair, err := sign.NewCMSKeyPair(flags.imgCert, flags.imgKey)
+		if err != nil {
+			log.Fatalf("Invalid image signing credentials: %v", err)
+		}
+		watcher.Add("image signing credentials", keyPair.Reload, keyPair.Paths()...)
+		imageSigner = keyPair.Signer()
+	}
+
+	// (optional) asset mirroring
+	var mirror *assets.Mirror
+	if flags.mirror {
+		mirror = assets.NewMirror(&assets.Config{
+			Root:             flags.assetsPath,
+			RegistryUsername: flags.registry,
+			RegistryPassword: os.Getenv("MATCHBOX_REGISTRY_PASSWORD"),
+			Logger:           log,
+		})
+	}
+
+	// (optional) tracing
+	if flags.traceURL != "" {
+		exporter := trace.NewOTLPExporter(&trace.OTLPConfig{
+			Endpoint:    flags.traceURL,
+			ServiceName: flags.traceName,
+			Logger:      log,
+		})
+		defer exporter.Shutdown()
+		trace.SetTracer(trace.NewTracer(exporter))
+	}
+
+	// (optional) audit sinks
+	var auditor *audit.Auditor
+	if flags.auditSinks != "" {
+		var sinks []audit.Sink
+		for _, rawurl := range strings.Split(flags.auditSinks, ",") {
+			sink, err := audit.ParseSink(strings.TrimSpace(rawurl), nil)
+			if err != nil {
+				log.Fatalf("invalid audit sink %s: %v", rawurl, err)
+			}
+			sinks = append(sinks, sink)
+		}
+		auditor = audit.NewAuditor(&audit.Config{
+			Sinks:  sinks,
+			Logger: log,
+		})
+		defer auditor.Close()
+	}
+
+	// (optional) ACME certificates
+	var certManager *acme.Manager
+	if flags.acmeDomains != "" {
+		certManager, err = acme.NewManager(&acme.Config{
+			DirectoryURL: flags.acmeDir,
+			Email:        flags.acmeEmail,
+			Domains:      strings.Split(flags.acmeDomains, ","),
+			CacheDir:     flags.acmeCache,
+			Challenge:    flags.acmeChal,
+			DNSHook:      flags.acmeDNSHook,
+			Logger:       log,
+		})
 		if err != nil {
 			log.Fatal(err)
 		}
-		signer = sign.NewGPGSigner(entity)
-		armoredSigner = sign.NewArmoredGPGSigner(entity)
+		stop := make(chan struct{})
+		defer close(stop)
+		go certManager.Run(stop)
+	}
+
+	// (optional) provisioning event webhooks
+	var hooks []webhook.Hook
+	if flags.webhooks != "" {
+		hooks, err = webhook.LoadHooks(flags.webhooks)
+		if err != nil {
+			log.Fatalf("invalid webhooks: %v", err)
+		}
+	}
+	if flags.webhook != "" {
+		hooks = append(hooks, webhook.Hook{URL: flags.webhook, Events: []string{webhook.EventComplete}})
+	}
+	var notifier *webhook.Notifier
+	if len(hooks) > 0 {
+		notifier = webhook.NewNotifier(&webhook.Config{
+			Hooks:             hooks,
+			FailureThreshold:  flags.failures,
+			BootLoopThreshold: flags.bootLoops,
+			CompleteTimeout:   flags.completeTTL,
+			Logger:            log,
+		})
 	}
 
 	// storage
-	store := storage.NewFileStore(&storage.Config{
-		Root:   flags.dataPath,
-		Logger: log,
-	})
+	layers := make([]storage.Store, len(dataPaths))
+	for i, path := range dataPaths {
+		layers[i] = storage.NewFileStore(&storage.Config{
+			Root:      path,
+			Logger:    log,
+			CacheSize: flags.storeCache,
+		})
+	}
+	// (optional) extensions registered by imported packages
+	extensions := extension.Default()
+	for _, name := range extensions.Names() {
+		log.Infof("Using extension %s", name)
+	}
+	extStore, err := extensions.Store(storage.NewOverlayStore(layers...))
+	if err != nil {
+		log.Fatalf("Invalid extension: %v", err)
+	}
+	store := storage.Instrument(extStore)
+	if flags.fallback {
+		store = storage.Fallback(store, log)
+	}
+
+	// (optional) default metadata of every Group
+	var metadataDefaults *defaults.Defaults
+	if flags.defaults != "" {
+		metadataDefaults, err = defaults.Load(flags.defaults)
+		if err != nil {
+			log.Fatalf("Invalid -metadata-defaults: %v", err)
+		}
+		watcher.Add("metadata defaults", metadataDefaults.Reload, metadataDefaults.Path())
+	}
+
+	// (optional) registry of Group selector keys
+	var selectorKeys *selectors.Registry
+	if flags.selectors != "" {
+		selectorKeys, err = selectors.Load(flags.selectors)
+		if err != nil {
+			log.Fatalf("Invalid -selector-keys: %v", err)
+		}
+		watcher.Add("selector keys", selectorKeys.Reload, selectorKeys.Path())
+	}
 
 	// core logic
-	server := server.NewServer(&server.Config{
-		Store: store,
+	serverConfig := &server.Config{
+		Store:           store,
+		InstallAttempts: flags.attempts,
+		RescueProfile:   flags.rescue,
+		HoldingProfile:  flags.holding,
+		EnrollGroup:     flags.enrollGroup,
+		EnrollHostname:  enrollName,
+		Maintenance:     flags.maintenance,
+		Defaults:        metadataDefaults,
+		Selectors:       selectorKeys,
+	}
+	// (optional) external matching, by a matching service or an extension
+	serverConfig.Matcher = extensions.Matcher()
+	if flags.matcherURL != "" {
+		if serverConfig.Matcher != nil {
+			log.Fatal("Provide -matcher-url or an extension Matcher, not both")
+		}
+		serverConfig.Matcher = matcher.NewMatcher(&matcher.Config{
+			URL:      flags.matcherURL,
+			Timeout:  flags.matcherTime,
+			CacheTTL: flags.matcherTTL,
+			Logger:   log,
+		})
+	}
+	server := server.NewServer(serverConfig)
+	// re-read Groups, Profiles, and templates edited in the data directory
+	// on SIGHUP
+	watcher.Add("data directory", func() error {
+		server.Reload()
+		return nil
 	})
 
+	// (optional) leader election, so background controllers which write
+	// the shared store run on one replica
+	var elector *leader.Elector
+	switch flags.electMode {
+	case "store":
+		elector = leader.NewElector(&leader.Config{
+			Lock:          leader.NewFileLock(filepath.Join(dataPath, "leader", flags.electName+".json")),
+			LeaseDuration: flags.electTTL,
+			Logger:        log,
+		})
+	case "kubernetes":
+		lock, err := leader.NewKubernetesLock(&leader.KubernetesConfig{
+			Namespace: flags.electNS,
+			Name:      flags.electName,
+		})
+		if err != nil {
+			log.Fatalf("Invalid -leader-election: %v", err)
+		}
+		elector = leader.NewElector(&leader.Config{
+			Lock:          lock,
+			LeaseDuration: flags.electTTL,
+			Logger:        log,
+		})
+	}
+	controllers := make(chan struct{})
+	defer close(controllers)
+	runController := func(name string, run func(stop <-chan struct{})) {
+		if elector != nil {
+			elector.Add(name, run)
+			return
+		}
+		go run(controllers)
+	}
+
+	// (optional) machine.timeout webhook events
+	if notifier != nil {
+		runController("webhooks", notifier.Run)
+	}
+
+	// (optional) DHCP lease facts
+	if flags.leasesPath != "" {
+		syncer := leases.NewSyncer(&leases.Config{
+			Path:   flags.leasesPath,
+			Server: server,
+			Logger: log,
+		})
+		stop := make(chan struct{})
+		defer close(stop)
+		go syncer.Run(stop)
+	}
+
+	// live boot events for gRPC watchers
+	hub := events.NewHub()
+
+	// (optional) machine power control
+	var powerController power.Controller
+	redfishConfig := &power.RedfishConfig{
+		Username:           flags.bmcUser,
+		Password:           bmcPassword,
+		InsecureSkipVerify: flags.bmcInsecure,
+	}
+	if flags.bmcUser != "" {
+		powerController = power.NewRedfish(redfishConfig)
+	}
+
+	// (optional) BMC hardware inventory facts
+	if flags.inventory != "" {
+		syncer := inventory.NewSyncer(&inventory.Config{
+			Endpoints: strings.Split(flags.inventory, ","),
+			Interval:  flags.invInterval,
+			Inventory: power.NewRedfishInventory(redfishConfig),
+			Server:    server,
+			Logger:    log,
+		})
+		runController("BMC inventory", syncer.Run)
+	}
+
+	// (optional) NetBox device facts
+	if flags.netboxURL != "" {
+		syncer := netbox.NewSyncer(&netbox.Config{
+			Client: netbox.NewClient(&netbox.ClientConfig{
+				URL:   flags.netboxURL,
+				Token: netboxToken,
+			}),
+			Interval:  flags.netboxEvery,
+			PushState: flags.netboxPush,
+			Server:    server,
+			Logger:    log,
+		})
+		runController("NetBox sync", syncer.Run)
+	}
+
+	// (optional) GitOps reconciliation from a Git repository
+	var reconciler *gitops.Reconciler
+	if flags.gitRepo != "" {
+		var keyring openpgp.EntityList
+		if flags.gitKeyring != "" {
+			keyring, err = gitops.LoadKeyring(flags.gitKeyring)
+			if err != nil {
+				log.Fatalf("Invalid -git-keyring: %v", err)
+			}
+		}
+		cacheDir := flags.gitCache
+		if cacheDir == "" {
+			cacheDir = filepath.Join(dataPath, "gitops", "repository.git")
+		}
+		reconciler = gitops.NewReconciler(&gitops.Config{
+			Repository: flags.gitRepo,
+			Ref:        flags.gitRef,
+			Path:       flags.gitPath,
+			CacheDir:   cacheDir,
+			Keyring:    keyring,
+			Prune:      flags.gitPrune,
+			Interval:   flags.gitInterval,
+			Server:     server,
+			Logger:     log,
+		})
+		runController("Git sync", reconciler.Run)
+	}
+	if elector != nil {
+		go elector.Run(controllers)
+	}
+
+	// (optional) Vault PKI machine certificates
+	var pki *vault.PKI
+	if flags.vaultAddr != "" && flags.vaultRole != "" {
+		pki = vault.NewPKI(&vault.PKIConfig{
+			Address: flags.vaultAddr,
+			Token:   vaultToken,
+			Mount:   flags.vaultMount,
+			Role:    flags.vaultRole,
+			TTL:     flags.vaultTTL,
+		})
+	}
+
+	// (optional) decryption of encrypted group metadata
+	var decrypter *sealed.Decrypter
+	if flags.transit != "" || flags.ageIdentity != "" {
+		var transit *vault.Transit
+		if flags.transit != "" {
+			transit = vault.NewTransit(&vault.TransitConfig{
+				Address: flags.vaultAddr,
+				Token:   vaultToken,
+				Mount:   flags.transit,
+			})
+		}
+		decrypter = sealed.NewDecrypter(&sealed.Config{
+			AgeIdentity: flags.ageIdentity,
+			Transit:     transit,
+		})
+	}
+
+	// (optional) redaction of sensitive metadata and labels
+	var redactor *redact.Redactor
+	if flags.sensitive != "" {
+		redactor, err = redact.NewRedactor(strings.Split(flags.sensitive, ","))
+		if err != nil {
+			log.Fatalf("Invalid -sensitive-keys: %v", err)
+		}
+	}
+
+	// (optional) TPM attestation before serving Ignition
+	var attestor *attest.Verifier
+	if flags.tpmEKPath != "" {
+		attestor = attest.NewVerifier(&attest.Config{
+			EKPath: flags.tpmEKPath,
+			TTL:    flags.tpmTTL,
+			Logger: log,
+		})
+	}
+
+	// (optional) Kubernetes bootstrap tokens
+	var bootstrapTokens *kubeadm.Tokens
+	if flags.kubeconfig != "" {
+		bootstrapTokens, err = kubeadm.NewTokens(&kubeadm.Config{
+			Kubeconfig: flags.kubeconfig,
+			TTL:        flags.kubeTTL,
+		})
+		if err != nil {
+			log.Fatalf("Invalid -kubeadm-kubeconfig: %v", err)
+		}
+	}
+
+	// HTTP Server
+	config := &web.Config{
+		Core:          server,
+		Logger:        log,
+		AssetsPath:    flags.assetsPath,
+		Mirror:        mirror,
+		Signer:        signer,
+		ArmoredSigner: armoredSigner,
+		ImageSigner:   imageSigner,
+		RateLimit: &web.RateLimit{
+			PerIP:       flags.rateLimit,
+			PerIPBurst:  flags.rateBurst,
+			Global:      flags.globalLimit,
+			GlobalBurst: flags.globalBurst,
+		},
+		Webhooks:            notifier,
+		Auditor:             auditor,
+		Events:              hub,
+		SlowRenderThreshold: flags.slowRender,
+		GroupMatchMetrics:   flags.groupStats,
+		RenderCacheSize:     flags.renderCache,
+		IgnitionTokens:      flags.ignTokens,
+		LocalBoot:           flags.localBoot,
+		Chain:               chain,
+		InstallAttempts:     flags.attempts,
+		InstallLimit:        flags.installLim,
+		InstallTimeout:      flags.installTTL,
+		BootHistory:         flags.bootHist,
+		EventRetention:      flags.eventTTL,
+		Allowlist:           httpAllowlist,
+		PKI:                 pki,
+		BootstrapTokens:     bootstrapTokens,
+		Defaults:            metadataDefaults,
+		MetadataSources: sources.NewFetcher(&sources.Config{
+			Timeout:     flags.sourceTime,
+			CacheTTL:    flags.sourceTTL,
+			ConsulToken: consulToken,
+			Logger:      log,
+		}),
+		RemoteIgnition: remote.NewFetcher(&remote.Config{
+			Timeout:  flags.remoteTime,
+			CacheTTL: flags.remoteTTL,
+			Logger:   log,
+		}),
+		GitOps:          reconciler,
+		Sealed:          decrypter,
+		Attestation:     attestor,
+		Redactor:        redactor,
+		RedactResponses: flags.redactAPI,
+		TemplateFuncs:   extensions.TemplateFuncs(),
+		Handlers:        extensions.Handlers(),
+	}
+	httpServer := web.NewServer(config)
+	handler := httpServer.HTTPHandler()
+
+	// servers to gracefully shut down
+	var (
+		servers     []*http.Server
+		stopServers []func(context.Context)
+	)
+
+	// OIDC ID tokens of gRPC calls and dashboard saves
+	var verifier rpc.TokenVerifier
+	if flags.oidcIssuer != "" {
+		log.Infof("Requiring ID tokens from OIDC issuer %s", flags.oidcIssuer)
+		verifier = oidc.NewVerifier(&oidc.Config{
+			Issuer:      flags.oidcIssuer,
+			ClientID:    flags.oidcClient,
+			GroupsClaim: flags.oidcGroups,
+		})
+	}
+
 	// gRPC Server (feature disabled by default)
 	if flags.rpcAddress != "" {
 		log.Infof("Starting matchbox gRPC server on %s", flags.rpcAddress)
-		log.Infof("Using TLS server certificate: %s", flags.certFile)
-		log.Infof("Using TLS server key: %s", flags.keyFile)
+		if certManager != nil {
+			log.Infof("Using ACME certificate for %s", flags.acmeDomains)
+		} else {
+			log.Infof("Using TLS server certificate: %s", flags.certFile)
+			log.Infof("Using TLS server key: %s", flags.keyFile)
+		}
 		log.Infof("Using CA certificate: %s to authenticate client certificates", flags.caFile)
-		lis, err := net.Listen("tcp", flags.rpcAddress)
+		lis, err := rpc.Listen(flags.rpcAddress)
 		if err != nil {
 			log.Fatalf("failed to start listening: %v", err)
 		}
-		tlsinfo := tlsutil.TLSInfo{
+		tlsinfo := &tlsutil.TLSInfo{
 			CertFile: flags.certFile,
 			KeyFile:  flags.keyFile,
 			CAFile:   flags.caFile,
 		}
-		tlscfg, err := tlsinfo.ServerConfig()
+		if certManager != nil {
+			tlsinfo.GetCertificate = certManager.GetCertificate
+		}
+		credentials, err := tlsutil.NewReloader(tlsinfo)
+		if err != nil {
+			log.Fatalf("Invalid TLS credentials: %v", err)
+		}
+		watcher.Add("gRPC TLS credentials", credentials.Reload, credentials.Paths()...)
+		tlscfg, err := credentials.ServerConfig()
 		if err != nil {
 			log.Fatalf("Invalid TLS credentials: %v", err)
 		}
-		grpcServer := rpc.NewServer(server, tlscfg)
+		if len(rpcAllowlist) > 0 {
+			log.Infof("Allowing gRPC clients from %s", rpcAllowlist)
+		}
+		var apiRedactor *redact.Redactor
+		if flags.redactAPI {
+			apiRedactor = redactor
+		}
+		grpcConfig := &rpc.Config{
+			TLS:            tlscfg,
+			Compress:       flags.rpcGzip,
+			MaxMessageSize: flags.rpcMaxMsg,
+		}
+		grpcServer := rpc.NewServer(server, grpcConfig, rpc.RequestID(), rpc.Allowlist(rpcAllowlist), rpc.Tracing(), rpc.Authenticate(verifier, oidcRoles), rpc.Audit(auditor), rpc.Redact(apiRedactor, server))
+		rpc.RegisterRenderer(grpcServer, httpServer)
+		rpc.RegisterEvents(grpcServer, server, hub)
+		rpc.RegisterPower(grpcServer, server, powerController)
+		if flags.assetsPath != "" {
+			rpc.RegisterAssets(grpcServer, assets.NewStore(&assets.Config{
+				Root:   flags.assetsPath,
+				Logger: log,
+			}))
+		}
 		go grpcServer.Serve(lis)
-		defer grpcServer.Stop()
+		stopServers = append(stopServers, func(ctx context.Context) {
+			rpc.GracefulStop(ctx, grpcServer)
+		})
 	}
 
-	// HTTP Server
-	config := &web.Config{
-		Core:          server,
-		Logger:        log,
-		AssetsPath:    flags.assetsPath,
-		Signer:        signer,
-		ArmoredSigner: armoredSigner,
+	// HTTPS Server (requires ACME)
+	if flags.httpsAddr != "" {
+		log.Infof("Starting matchbox HTTPS server on %s", flags.httpsAddr)
+		httpsServer := &http.Server{
+			Handler: handler,
+			TLSConfig: &tls.Config{
+				MinVersion:     tls.VersionTLS12,
+				GetCertificate: certManager.GetCertificate,
+			},
+		}
+		if err := web.ConfigureServer(httpsServer, timeouts, flags.http2); err != nil {
+			log.Fatalf("Invalid HTTP/2 configuration: %v", err)
+		}
+		if flags.httpsCAFile != "" {
+			log.Infof("Using CA certificate: %s to verify machine client certificates", flags.httpsCAFile)
+			clientCAs, err := tlsutil.NewReloader(&tlsutil.TLSInfo{
+				CAFile:         flags.httpsCAFile,
+				GetCertificate: certManager.GetCertificate,
+			})
+			if err != nil {
+				log.Fatalf("Invalid -https-client-ca-file: %v", err)
+			}
+			watcher.Add("HTTPS client CA", clientCAs.Reload, clientCAs.Paths()...)
+			httpsServer.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+			httpsServer.TLSConfig.ClientCAs = clientCAs.ClientCAs()
+			clientCAs.WithClientCAs(httpsServer.TLSConfig)
+		}
+		listeners, err := web.Listen(flags.httpsAddr)
+		if err != nil {
+			log.Fatalf("failed to start listening: %v", err)
+		}
+		for _, lis := range listeners {
+			go func(lis net.Listener) {
+				if err := httpsServer.Serve(tls.NewListener(lis, httpsServer.TLSConfig)); err != http.ErrServerClosed {
+					log.Fatalf("failed to serve: %v", err)
+				}
+			}(lis)
+		}
+		servers = append(servers, httpsServer)
+	}
+
+	// reload changed credentials and configuration, or all of them and the
+	// data directory on SIGHUP, without dropping connections
+	stop := make(chan struct{})
+	defer close(stop)
+	go watcher.Run(stop)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			log.Info("Reloading on SIGHUP")
+			watcher.Reload()
+		}
+	}()
+
+	// Web dashboard (requires gRPC client certificates)
+	if flags.uiAddress != "" {
+		log.Infof("Starting matchbox web dashboard on %s", flags.uiAddress)
+		credentials, err := tlsutil.NewReloader(&tlsutil.TLSInfo{
+			CertFile: flags.certFile,
+			KeyFile:  flags.keyFile,
+			CAFile:   flags.caFile,
+		})
+		if err != nil {
+			log.Fatalf("Invalid TLS credentials: %v", err)
+		}
+		watcher.Add("dashboard TLS credentials", credentials.Reload, credentials.Paths()...)
+		tlscfg, err := credentials.ServerConfig()
+		if err != nil {
+			log.Fatalf("Invalid TLS credentials: %v", err)
+		}
+		var uiRedactor *redact.Redactor
+		if flags.redactAPI {
+			uiRedactor = redactor
+		}
+		dashboard := ui.New(&ui.Config{
+			Core:     server,
+			Logger:   log,
+			Renderer: httpServer,
+			Events:   hub,
+			Redactor: uiRedactor,
+			Edit:     flags.uiEdit,
+			Verifier: verifier,
+			Roles:    oidcRoles,
+			Auditor:  auditor,
+		})
+		go dashboard.Run(stop)
+		uiServer := &http.Server{
+			Handler:   dashboard.Handler(),
+			TLSConfig: tlscfg,
+		}
+		if err := web.ConfigureServer(uiServer, timeouts, flags.http2); err != nil {
+			log.Fatalf("Invalid HTTP/2 configuration: %v", err)
+		}
+		lis, err := socket.Listen(flags.uiAddress, 0)
+		if err != nil {
+			log.Fatalf("failed to start listening: %v", err)
+		}
+		go func() {
+			if err := uiServer.Serve(tls.NewListener(lis, tlscfg)); err != http.ErrServerClosed {
+				log.Fatalf("failed to serve: %v", err)
+			}
+		}()
+		servers = append(servers, uiServer)
+	}
+
+	// answer ACME http-01 challenges on the HTTP listener
+	if certManager != nil {
+		handler = certManager.HTTPHandler(handler)
 	}
-	httpServer := web.NewServer(config)
 	log.Infof("Starting matchbox HTTP server on %s", flags.address)
-	err = http.ListenAndServe(flags.address, httpServer.HTTPHandler())
+	srv := &http.Server{
+		Handler: handler,
+	}
+	if err := web.ConfigureServer(srv, timeouts, flags.http2); err != nil {
+		log.Fatalf("Invalid HTTP/2 configuration: %v", err)
+	}
+	listeners, err := web.Listen(flags.address)
 	if err != nil {
 		log.Fatalf("failed to start listening: %v", err)
 	}
+	for _, lis := range listeners {
+		go func(lis net.Listener) {
+			if err := srv.Serve(lis); err != http.ErrServerClosed {
+				log.Fatalf("failed to serve: %v", err)
+			}
+		}(lis)
+	}
+	servers = append(servers, srv)
+
+	// on SIGTERM or SIGINT, fail readiness and stop accepting connections,
+	// but let in-flight renders and asset transfers finish
+	term := make(chan os.Signal, 1)
+	signal.Notify(term, syscall.SIGTERM, os.Interrupt)
+	sig := <-term
+	log.Infof("Shutting down on %v, draining connections for up to %v", sig, flags.drain)
+	httpServer.Drain()
+	ctx, cancel := context.WithTimeout(context.Background(), flags.drain)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, stopServer := range stopServers {
+		wg.Add(1)
+		go func(stopServer func(context.Context)) {
+			defer wg.Done()
+			stopServer(ctx)
+		}(stopServer)
+	}
+	if err := web.Shutdown(ctx, servers...); err != nil {
+		log.Warningf("Closed connections which didn't finish within the drain timeout: %v", err)
+	}
+	wg.Wait()
 }
@@ -0,0 +1,93 @@
+package acl
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// List is an allowlist of IP networks. An empty List allows all addresses.
+type List []*net.IPNet
+
+// Parse parses a comma separated list of CIDRs or IP addresses into a List.
+func Parse(s string) (List, error) {
+	var list List
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !strings.Contains(field, "/") {
+			// single addresses are host networks
+			ip := net.ParseIP(field)
+			if ip == nil {
+				return nil, fmt.Errorf("acl: invalid IP address %q", field)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			list = append(list, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(field)
+		if err != nil {
+			return nil, fmt.Errorf("acl: invalid CIDR %q", field)
+		}
+		list = append(list, network)
+	}
+	return list, nil
+}
+
+// Allows returns true if the List is empty or contains the IP.
+func (l List) Allows(ip net.IP) bool {
+	if len(l) == 0 {
+		return true
+	}
+	if ip == nil {
+		return false
+	}
+	for _, network := range l {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsAddr returns true if the List allows the host of a "host:port"
+// network address.
+func (l List) AllowsAddr(addr string) bool {
+	if len(l) == 0 {
+		return true
+	}
+	return l.Allows(HostIP(addr))
+}
+
+// HostIP returns the IP address of the host of a "host:port" network
+// address or of a bare host, or nil if the host isn't an IP address. IPv6
+// zones (e.g. "fe80::1%eth0") are dropped and IPv4-mapped IPv6 addresses
+// (e.g. from dual-stack listeners) are returned as IPv4 addresses.
+func HostIP(addr string) net.IP {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = strings.Trim(addr, "[]")
+	}
+	if i := strings.LastIndex(host, "%"); i >= 0 {
+		host = host[:i]
+	}
+	ip := net.ParseIP(host)
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip
+}
+
+// String returns the comma separated networks.
+func (l List) String() string {
+	networks := make([]string, len(l))
+	for i, network := range l {
+		networks[i] = network.String()
+	}
+	return strings.Join(networks, ",")
+}
@@ -0,0 +1,79 @@
+package acl
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	list, err := Parse("10.0.0.0/8, 192.168.1.5,fd00::/8")
+	assert.Nil(t, err)
+	assert.Equal(t, "10.0.0.0/8,192.168.1.5/32,fd00::/8", list.String())
+
+	list, err = Parse("")
+	assert.Nil(t, err)
+	assert.Empty(t, list)
+
+	_, err = Parse("10.0.0.0/33")
+	assert.Error(t, err)
+	_, err = Parse("not-an-ip")
+	assert.Error(t, err)
+}
+
+func TestAllows(t *testing.T) {
+	list, err := Parse("10.0.0.0/8,192.168.1.5,fd00::/8")
+	assert.Nil(t, err)
+	cases := []struct {
+		addr    string
+		allowed bool
+	}{
+		{"10.1.2.3:8080", true},
+		{"192.168.1.5:1234", true},
+		{"192.168.1.6:1234", false},
+		{"[fd00::1]:8080", true},
+		{"[2001:db8::1]:8080", false},
+		{"[fe80::1%eth0]:8080", false},
+		{"[fd00::1%eth0]:8080", true},
+		{"[::ffff:10.1.2.3]:8080", true},
+		{"10.1.2.3", true},
+		{"fd00::1", true},
+		{"garbage", false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.allowed, list.AllowsAddr(c.addr), c.addr)
+	}
+	// assert that:
+	// - an empty List allows all addresses
+	assert.True(t, List(nil).Allows(net.ParseIP("203.0.113.1")))
+	assert.True(t, List(nil).AllowsAddr("garbage"))
+}
+
+func TestHostIP(t *testing.T) {
+	cases := []struct {
+		addr     string
+		expected string
+	}{
+		{"10.1.2.3:8080", "10.1.2.3"},
+		{"10.1.2.3", "10.1.2.3"},
+		{"[2001:db8::1]:8080", "2001:db8::1"},
+		{"[2001:DB8:0::1]", "2001:db8::1"},
+		{"2001:db8::1", "2001:db8::1"},
+		{"[fe80::1%eth0]:8080", "fe80::1"},
+		{"[::ffff:10.1.2.3]:8080", "10.1.2.3"},
+	}
+	// assert that:
+	// - hosts of addresses with and without ports are parsed
+	// - IPv6 zones are dropped and IPv4-mapped addresses are IPv4
+	for _, c := range cases {
+		ip := HostIP(c.addr)
+		if assert.NotNil(t, ip, c.addr) {
+			assert.Equal(t, c.expected, ip.String(), c.addr)
+			assert.Equal(t, ip.To4() != nil, len(ip) == net.IPv4len, c.addr)
+		}
+	}
+	// - hosts which aren't IP addresses are nil
+	assert.Nil(t, HostIP("matchbox.example.com:8080"))
+	assert.Nil(t, HostIP(""))
+}
@@ -0,0 +1,2 @@
+// Package acl provides CIDR allowlists for restricting client addresses.
+package acl
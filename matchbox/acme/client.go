@@ -0,0 +1,256 @@
+package acme
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+const joseContentType = "application/jose+json"
+
+// pollInterval is how often pending authorizations and orders are polled.
+var pollInterval = 2 * time.Second
+
+var errNoNonce = errors.New("acme: server did not return a Replay-Nonce")
+
+// directory lists the ACME resource URLs.
+type directory struct {
+	NewNonce   string `json:"newNonce"`
+	NewAccount string `json:"newAccount"`
+	NewOrder   string `json:"newOrder"`
+}
+
+// order is an ACME order resource.
+type order struct {
+	Status         string   `json:"status"`
+	Authorizations []string `json:"authorizations"`
+	Finalize       string   `json:"finalize"`
+	Certificate    string   `json:"certificate"`
+}
+
+// authorization is an ACME authorization resource.
+type authorization struct {
+	Status     string `json:"status"`
+	Identifier struct {
+		Value string `json:"value"`
+	} `json:"identifier"`
+	Challenges []challenge `json:"challenges"`
+}
+
+// challenge is an ACME challenge resource.
+type challenge struct {
+	Type   string `json:"type"`
+	URL    string `json:"url"`
+	Token  string `json:"token"`
+	Status string `json:"status"`
+}
+
+// problem is an ACME error document (RFC 7807).
+type problem struct {
+	Type   string `json:"type"`
+	Detail string `json:"detail"`
+}
+
+func (p *problem) Error() string {
+	return fmt.Sprintf("acme: %s: %s", p.Type, p.Detail)
+}
+
+// client speaks the ACME protocol on behalf of an account key.
+type client struct {
+	http  *http.Client
+	key   *ecdsa.PrivateKey
+	dir   *directory
+	kid   string
+	nonce string
+}
+
+// discover fetches the ACME directory.
+func (c *client) discover(ctx context.Context, url string) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("acme: directory %s returned %s", url, resp.Status)
+	}
+	c.dir = new(directory)
+	return json.NewDecoder(resp.Body).Decode(c.dir)
+}
+
+// fetchNonce returns a fresh anti-replay nonce.
+func (c *client) fetchNonce(ctx context.Context) (string, error) {
+	if c.nonce != "" {
+		nonce := c.nonce
+		c.nonce = ""
+		return nonce, nil
+	}
+	req, err := http.NewRequest("HEAD", c.dir.NewNonce, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	nonce := resp.Header.Get("Replay-Nonce")
+	if nonce == "" {
+		return "", errNoNonce
+	}
+	return nonce, nil
+}
+
+// post sends a signed request and decodes a JSON response into v (if not
+// nil). A nil payload sends a POST-as-GET request. The response is returned
+// with its body consumed.
+func (c *client) post(ctx context.Context, url string, payload, v interface{}) (*http.Response, []byte, error) {
+	nonce, err := c.fetchNonce(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := signJWS(c.key, c.kid, nonce, url, payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", joseContentType)
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	c.nonce = resp.Header.Get("Replay-Nonce")
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode >= 400 {
+		p := new(problem)
+		if err := json.Unmarshal(data, p); err != nil || p.Type == "" {
+			return nil, nil, fmt.Errorf("acme: %s returned %s", url, resp.Status)
+		}
+		return nil, nil, p
+	}
+	if v != nil {
+		if err := json.Unmarshal(data, v); err != nil {
+			return nil, nil, err
+		}
+	}
+	return resp, data, nil
+}
+
+// register creates (or finds) the ACME account for the key.
+func (c *client) register(ctx context.Context, email string) error {
+	payload := map[string]interface{}{
+		"termsOfServiceAgreed": true,
+	}
+	if email != "" {
+		payload["contact"] = []string{"mailto:" + email}
+	}
+	resp, _, err := c.post(ctx, c.dir.NewAccount, payload, nil)
+	if err != nil {
+		return err
+	}
+	c.kid = resp.Header.Get("Location")
+	if c.kid == "" {
+		return errors.New("acme: account response missing Location")
+	}
+	return nil
+}
+
+// newOrder creates an order for the given domains and returns it with its
+// URL.
+func (c *client) newOrder(ctx context.Context, domains []string) (*order, string, error) {
+	ids := make([]map[string]string, len(domains))
+	for i, domain := range domains {
+		ids[i] = map[string]string{"type": "dns", "value": domain}
+	}
+	o := new(order)
+	resp, _, err := c.post(ctx, c.dir.NewOrder, map[string]interface{}{"identifiers": ids}, o)
+	if err != nil {
+		return nil, "", err
+	}
+	return o, resp.Header.Get("Location"), nil
+}
+
+// getAuthorization fetches an authorization resource.
+func (c *client) getAuthorization(ctx context.Context, url string) (*authorization, error) {
+	authz := new(authorization)
+	_, _, err := c.post(ctx, url, nil, authz)
+	return authz, err
+}
+
+// accept notifies the server that a challenge is ready for validation.
+func (c *client) accept(ctx context.Context, chal *challenge) error {
+	_, _, err := c.post(ctx, chal.URL, struct{}{}, nil)
+	return err
+}
+
+// waitAuthorization polls an authorization until it is valid or fails.
+func (c *client) waitAuthorization(ctx context.Context, url string) error {
+	for {
+		authz, err := c.getAuthorization(ctx, url)
+		if err != nil {
+			return err
+		}
+		switch authz.Status {
+		case "valid":
+			return nil
+		case "pending", "processing":
+		default:
+			return fmt.Errorf("acme: authorization for %s is %s", authz.Identifier.Value, authz.Status)
+		}
+		if err := sleep(ctx, pollInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// finalize submits the CSR and polls the order until the certificate is
+// issued, returning the PEM encoded certificate chain.
+func (c *client) finalize(ctx context.Context, o *order, orderURL string, csr []byte) ([]byte, error) {
+	if _, _, err := c.post(ctx, o.Finalize, map[string]string{"csr": b64(csr)}, o); err != nil {
+		return nil, err
+	}
+	for o.Status != "valid" {
+		switch o.Status {
+		case "pending", "ready", "processing":
+		default:
+			return nil, fmt.Errorf("acme: order is %s", o.Status)
+		}
+		if err := sleep(ctx, pollInterval); err != nil {
+			return nil, err
+		}
+		if _, _, err := c.post(ctx, orderURL, nil, o); err != nil {
+			return nil, err
+		}
+	}
+	_, chain, err := c.post(ctx, o.Certificate, nil, nil)
+	return chain, err
+}
+
+// sleep waits for the duration or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
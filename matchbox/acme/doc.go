@@ -0,0 +1,3 @@
+// Package acme obtains and renews TLS certificates from an ACME (RFC 8555)
+// certificate authority such as Let's Encrypt.
+package acme
@@ -0,0 +1,128 @@
+package acme
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var errInvalidAccountKey = errors.New("acme: invalid account key")
+
+// jwk returns the JSON Web Key of an ECDSA P-256 public key with members in
+// lexicographic order, as required for thumbprints (RFC 7638).
+func jwk(pub *ecdsa.PublicKey) string {
+	size := (pub.Curve.Params().BitSize + 7) / 8
+	return fmt.Sprintf(`{"crv":"P-256","kty":"EC","x":"%s","y":"%s"}`,
+		b64(padBytes(pub.X, size)), b64(padBytes(pub.Y, size)))
+}
+
+// thumbprint returns the base64url encoded SHA-256 JWK thumbprint.
+func thumbprint(pub *ecdsa.PublicKey) string {
+	sum := sha256.Sum256([]byte(jwk(pub)))
+	return b64(sum[:])
+}
+
+// keyAuthorization returns the key authorization for a challenge token.
+func keyAuthorization(key *ecdsa.PrivateKey, token string) string {
+	return token + "." + thumbprint(&key.PublicKey)
+}
+
+// dnsRecordValue returns the TXT record value for a DNS-01 challenge.
+func dnsRecordValue(keyAuth string) string {
+	sum := sha256.Sum256([]byte(keyAuth))
+	return b64(sum[:])
+}
+
+// signJWS returns a flattened JWS serialization of the payload. The account
+// JWK is embedded if kid is empty. A nil payload encodes a POST-as-GET.
+func signJWS(key *ecdsa.PrivateKey, kid, nonce, url string, payload interface{}) ([]byte, error) {
+	protected := map[string]interface{}{
+		"alg":   "ES256",
+		"nonce": nonce,
+		"url":   url,
+	}
+	if kid != "" {
+		protected["kid"] = kid
+	} else {
+		protected["jwk"] = json.RawMessage(jwk(&key.PublicKey))
+	}
+	header, err := json.Marshal(protected)
+	if err != nil {
+		return nil, err
+	}
+	var body string
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		body = b64(data)
+	}
+	input := b64(header) + "." + body
+	digest := sha256.Sum256([]byte(input))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	size := (key.Curve.Params().BitSize + 7) / 8
+	sig := append(padBytes(r, size), padBytes(s, size)...)
+	return json.Marshal(map[string]string{
+		"protected": b64(header),
+		"payload":   body,
+		"signature": b64(sig),
+	})
+}
+
+// newAccountKey generates an ACME account key.
+func newAccountKey() (*ecdsa.PrivateKey, error) {
+	return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+}
+
+// encodeECKey PEM encodes an ECDSA private key.
+func encodeECKey(key *ecdsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
+
+// decodeECKey decodes a PEM encoded ECDSA private key.
+func decodeECKey(data []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errInvalidAccountKey
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if key.Curve != elliptic.P256() {
+		return nil, errInvalidAccountKey
+	}
+	return key, nil
+}
+
+// b64 encodes data as unpadded base64url.
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// padBytes returns the big-endian bytes of n left padded to size.
+func padBytes(n *big.Int, size int) []byte {
+	b := n.Bytes()
+	if len(b) >= size {
+		return b
+	}
+	out := make([]byte, size)
+	copy(out[size-len(b):], b)
+	return out
+}
@@ -0,0 +1,77 @@
+package acme
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignJWS(t *testing.T) {
+	key, err := newAccountKey()
+	assert.Nil(t, err)
+
+	data, err := signJWS(key, "", "nonce", "https://example.com/acme", map[string]string{"a": "b"})
+	assert.Nil(t, err)
+	var jws map[string]string
+	assert.Nil(t, json.Unmarshal(data, &jws))
+
+	header, err := base64.RawURLEncoding.DecodeString(jws["protected"])
+	assert.Nil(t, err)
+	var protected map[string]interface{}
+	assert.Nil(t, json.Unmarshal(header, &protected))
+	// assert that:
+	// - the JWK is embedded when no kid is given
+	// - the signature verifies with the account key
+	assert.Equal(t, "ES256", protected["alg"])
+	assert.Equal(t, "nonce", protected["nonce"])
+	assert.Contains(t, protected, "jwk")
+	assert.NotContains(t, protected, "kid")
+	assert.True(t, verifyJWS(&key.PublicKey, jws))
+
+	// POST-as-GET has an empty payload
+	data, err = signJWS(key, "https://example.com/acct/1", "nonce", "https://example.com/acme", nil)
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(data, &jws))
+	assert.Equal(t, "", jws["payload"])
+	assert.True(t, verifyJWS(&key.PublicKey, jws))
+}
+
+func TestKeyRoundTrip(t *testing.T) {
+	key, err := newAccountKey()
+	assert.Nil(t, err)
+	data, err := encodeECKey(key)
+	assert.Nil(t, err)
+	decoded, err := decodeECKey(data)
+	assert.Nil(t, err)
+	assert.Equal(t, thumbprint(&key.PublicKey), thumbprint(&decoded.PublicKey))
+
+	_, err = decodeECKey([]byte("not a key"))
+	assert.Equal(t, errInvalidAccountKey, err)
+}
+
+func TestKeyAuthorization(t *testing.T) {
+	key, err := newAccountKey()
+	assert.Nil(t, err)
+	keyAuth := keyAuthorization(key, "token")
+	assert.True(t, strings.HasPrefix(keyAuth, "token."))
+	assert.Equal(t, thumbprint(&key.PublicKey), strings.TrimPrefix(keyAuth, "token."))
+	assert.Len(t, dnsRecordValue(keyAuth), 43)
+}
+
+// verifyJWS verifies a flattened ES256 JWS.
+func verifyJWS(pub *ecdsa.PublicKey, jws map[string]string) bool {
+	sig, err := base64.RawURLEncoding.DecodeString(jws["signature"])
+	if err != nil || len(sig) != 64 {
+		return false
+	}
+	digest := sha256.Sum256([]byte(jws["protected"] + "." + jws["payload"]))
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	return ecdsa.Verify(pub, digest[:], r, s)
+}
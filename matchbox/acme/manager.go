@@ -0,0 +1,380 @@
+package acme
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+const (
+	// LetsEncryptURL is the Let's Encrypt production directory URL.
+	LetsEncryptURL = "https://acme-v02.api.letsencrypt.org/directory"
+
+	// ChallengeHTTP01 validates domains by serving a token over HTTP.
+	ChallengeHTTP01 = "http-01"
+	// ChallengeDNS01 validates domains with a DNS TXT record.
+	ChallengeDNS01 = "dns-01"
+
+	challengePathPrefix = "/.well-known/acme-challenge/"
+	accountKeyFile      = "account.key"
+	certFile            = "cert.pem"
+	keyFile             = "key.pem"
+	defaultRenewBefore  = 30 * 24 * time.Hour
+	retryInterval       = time.Hour
+)
+
+var (
+	// ErrNoDomains is returned when no domains are configured.
+	ErrNoDomains = errors.New("acme: at least one domain is required")
+	// ErrInvalidChallenge is returned for unsupported challenge types.
+	ErrInvalidChallenge = errors.New("acme: challenge must be http-01 or dns-01")
+	// ErrDNSHookRequired is returned when dns-01 is used without a hook.
+	ErrDNSHookRequired = errors.New("acme: dns-01 challenge requires a DNS hook")
+	errNoCertificate   = errors.New("acme: no certificate available")
+	errNoChallenge     = errors.New("acme: authorization offers no supported challenge")
+)
+
+// Config configures a Manager.
+type Config struct {
+	// ACME directory URL (defaults to Let's Encrypt)
+	DirectoryURL string
+	// Contact email for the ACME account
+	Email string
+	// Domains to include in the certificate
+	Domains []string
+	// Directory to cache the account key and certificate
+	CacheDir string
+	// Challenge type, http-01 (default) or dns-01
+	Challenge string
+	// Executable invoked to manage dns-01 TXT records as
+	// "hook present|cleanup _acme-challenge.<domain> <value>"
+	DNSHook string
+	// Renew certificates this long before they expire (defaults to 30 days)
+	RenewBefore time.Duration
+	// HTTP client for ACME requests (defaults to http.DefaultClient)
+	Client *http.Client
+	Logger *logrus.Logger
+}
+
+// Manager obtains a certificate from an ACME certificate authority, serves
+// it to TLS listeners, and renews it before it expires.
+type Manager struct {
+	config Config
+	client *http.Client
+	logger *logrus.Logger
+	key    *ecdsa.PrivateKey
+
+	// pending http-01 key authorizations by token
+	tokensMu sync.RWMutex
+	tokens   map[string]string
+
+	certMu sync.RWMutex
+	cert   *tls.Certificate
+
+	// serializes Obtain calls
+	obtainMu sync.Mutex
+}
+
+// NewManager returns a new Manager. The account key is loaded from or
+// created in the cache directory and any cached certificate is loaded.
+func NewManager(config *Config) (*Manager, error) {
+	if len(config.Domains) == 0 {
+		return nil, ErrNoDomains
+	}
+	c := *config
+	if c.DirectoryURL == "" {
+		c.DirectoryURL = LetsEncryptURL
+	}
+	if c.Challenge == "" {
+		c.Challenge = ChallengeHTTP01
+	}
+	switch c.Challenge {
+	case ChallengeHTTP01:
+	case ChallengeDNS01:
+		if c.DNSHook == "" {
+			return nil, ErrDNSHookRequired
+		}
+	default:
+		return nil, ErrInvalidChallenge
+	}
+	if c.RenewBefore <= 0 {
+		c.RenewBefore = defaultRenewBefore
+	}
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	logger := c.Logger
+	if logger == nil {
+		logger = logrus.New()
+	}
+	if err := os.MkdirAll(c.CacheDir, 0700); err != nil {
+		return nil, err
+	}
+	m := &Manager{
+		config: c,
+		client: client,
+		logger: logger,
+		tokens: make(map[string]string),
+	}
+	key, err := m.loadAccountKey()
+	if err != nil {
+		return nil, err
+	}
+	m.key = key
+	if cert, err := tls.LoadX509KeyPair(m.cachePath(certFile), m.cachePath(keyFile)); err == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err == nil {
+			m.cert = &cert
+		}
+	}
+	return m, nil
+}
+
+// GetCertificate returns the current certificate. It is suitable for use as
+// tls.Config.GetCertificate.
+func (m *Manager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.certMu.RLock()
+	defer m.certMu.RUnlock()
+	if m.cert == nil {
+		return nil, errNoCertificate
+	}
+	return m.cert, nil
+}
+
+// HTTPHandler responds to http-01 challenge requests and passes other
+// requests to the fallback handler.
+func (m *Manager) HTTPHandler(fallback http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		if !strings.HasPrefix(req.URL.Path, challengePathPrefix) {
+			fallback.ServeHTTP(w, req)
+			return
+		}
+		token := strings.TrimPrefix(req.URL.Path, challengePathPrefix)
+		m.tokensMu.RLock()
+		keyAuth, ok := m.tokens[token]
+		m.tokensMu.RUnlock()
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(keyAuth))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// NeedsRenewal returns true if there is no certificate or the certificate
+// expires within the renewal window.
+func (m *Manager) NeedsRenewal() bool {
+	m.certMu.RLock()
+	defer m.certMu.RUnlock()
+	if m.cert == nil || m.cert.Leaf == nil {
+		return true
+	}
+	return time.Now().Add(m.config.RenewBefore).After(m.cert.Leaf.NotAfter)
+}
+
+// Run obtains a certificate if needed and renews it before it expires until
+// the stop channel is closed. Failed attempts are retried hourly.
+func (m *Manager) Run(stop <-chan struct{}) {
+	for {
+		wait := retryInterval
+		if m.NeedsRenewal() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			if err := m.Obtain(ctx); err != nil {
+				m.logger.Errorf("acme: error obtaining certificate: %v", err)
+			}
+			cancel()
+		}
+		if !m.NeedsRenewal() {
+			wait = m.renewalWait()
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// renewalWait returns the duration until the certificate should be renewed.
+func (m *Manager) renewalWait() time.Duration {
+	m.certMu.RLock()
+	defer m.certMu.RUnlock()
+	wait := m.cert.Leaf.NotAfter.Add(-m.config.RenewBefore).Sub(time.Now())
+	if wait < time.Minute {
+		wait = time.Minute
+	}
+	return wait
+}
+
+// Obtain requests a new certificate for the configured domains, caches it,
+// and begins serving it.
+func (m *Manager) Obtain(ctx context.Context) error {
+	m.obtainMu.Lock()
+	defer m.obtainMu.Unlock()
+
+	c := &client{http: m.client, key: m.key}
+	if err := c.discover(ctx, m.config.DirectoryURL); err != nil {
+		return err
+	}
+	if err := c.register(ctx, m.config.Email); err != nil {
+		return err
+	}
+	o, orderURL, err := c.newOrder(ctx, m.config.Domains)
+	if err != nil {
+		return err
+	}
+	for _, url := range o.Authorizations {
+		if err := m.authorize(ctx, c, url); err != nil {
+			return err
+		}
+	}
+
+	certKey, err := newAccountKey()
+	if err != nil {
+		return err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: m.config.Domains[0]},
+		DNSNames: m.config.Domains,
+	}, certKey)
+	if err != nil {
+		return err
+	}
+	chain, err := c.finalize(ctx, o, orderURL, csr)
+	if err != nil {
+		return err
+	}
+	keyPEM, err := encodeECKey(certKey)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.X509KeyPair(chain, keyPEM)
+	if err != nil {
+		return err
+	}
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return err
+	}
+	if err := writeFile(m.cachePath(keyFile), keyPEM); err != nil {
+		return err
+	}
+	if err := writeFile(m.cachePath(certFile), chain); err != nil {
+		return err
+	}
+	m.certMu.Lock()
+	m.cert = &cert
+	m.certMu.Unlock()
+	m.logger.Infof("acme: obtained certificate for %s valid until %s", strings.Join(m.config.Domains, ","), cert.Leaf.NotAfter)
+	return nil
+}
+
+// authorize completes the configured challenge for an authorization.
+func (m *Manager) authorize(ctx context.Context, c *client, url string) error {
+	authz, err := c.getAuthorization(ctx, url)
+	if err != nil {
+		return err
+	}
+	if authz.Status == "valid" {
+		return nil
+	}
+	var chal *challenge
+	for i := range authz.Challenges {
+		if authz.Challenges[i].Type == m.config.Challenge {
+			chal = &authz.Challenges[i]
+			break
+		}
+	}
+	if chal == nil {
+		return errNoChallenge
+	}
+	keyAuth := keyAuthorization(m.key, chal.Token)
+	domain := authz.Identifier.Value
+
+	switch m.config.Challenge {
+	case ChallengeHTTP01:
+		m.tokensMu.Lock()
+		m.tokens[chal.Token] = keyAuth
+		m.tokensMu.Unlock()
+		defer func() {
+			m.tokensMu.Lock()
+			delete(m.tokens, chal.Token)
+			m.tokensMu.Unlock()
+		}()
+	case ChallengeDNS01:
+		record := "_acme-challenge." + domain
+		value := dnsRecordValue(keyAuth)
+		if err := m.dnsHook(ctx, "present", record, value); err != nil {
+			return err
+		}
+		defer func() {
+			if err := m.dnsHook(context.Background(), "cleanup", record, value); err != nil {
+				m.logger.Warningf("acme: dns hook cleanup for %s: %v", domain, err)
+			}
+		}()
+	}
+	m.logger.Debugf("acme: accepting %s challenge for %s", chal.Type, domain)
+	if err := c.accept(ctx, chal); err != nil {
+		return err
+	}
+	return c.waitAuthorization(ctx, url)
+}
+
+// dnsHook invokes the DNS hook to present or clean up a TXT record.
+func (m *Manager) dnsHook(ctx context.Context, action, record, value string) error {
+	out, err := exec.CommandContext(ctx, m.config.DNSHook, action, record, value).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("acme: dns hook %s %s: %v: %s", action, record, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// loadAccountKey reads the cached account key or generates and caches one.
+func (m *Manager) loadAccountKey() (*ecdsa.PrivateKey, error) {
+	path := m.cachePath(accountKeyFile)
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		return decodeECKey(data)
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	key, err := newAccountKey()
+	if err != nil {
+		return nil, err
+	}
+	data, err = encodeECKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return key, writeFile(path, data)
+}
+
+func (m *Manager) cachePath(name string) string {
+	return filepath.Join(m.config.CacheDir, name)
+}
+
+// writeFile atomically writes a private file.
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,275 @@
+package acme
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCA is a minimal ACME server which validates http-01 challenges by
+// requesting the token from a challenge handler.
+type fakeCA struct {
+	t         *testing.T
+	server    *httptest.Server
+	challenge http.Handler
+	caKey     *ecdsa.PrivateKey
+	caCert    *x509.Certificate
+
+	mu      sync.Mutex
+	nonce   int
+	domains []string
+	valid   bool
+	cert    []byte
+}
+
+func newFakeCA(t *testing.T) *fakeCA {
+	ca := &fakeCA{t: t}
+	key, err := newAccountKey()
+	assert.Nil(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fake CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.Nil(t, err)
+	ca.caKey = key
+	ca.caCert, err = x509.ParseCertificate(der)
+	assert.Nil(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/directory", ca.directory)
+	mux.HandleFunc("/nonce", ca.withNonce(nil))
+	mux.HandleFunc("/account", ca.withNonce(ca.account))
+	mux.HandleFunc("/order", ca.withNonce(ca.newOrder))
+	mux.HandleFunc("/order/1", ca.withNonce(ca.order))
+	mux.HandleFunc("/authz/", ca.withNonce(ca.authz))
+	mux.HandleFunc("/challenge/", ca.withNonce(ca.accept))
+	mux.HandleFunc("/finalize", ca.withNonce(ca.finalize))
+	mux.HandleFunc("/cert", ca.withNonce(ca.certificate))
+	ca.server = httptest.NewServer(mux)
+	return ca
+}
+
+func (ca *fakeCA) url(path string) string {
+	return ca.server.URL + path
+}
+
+func (ca *fakeCA) directory(w http.ResponseWriter, req *http.Request) {
+	json.NewEncoder(w).Encode(map[string]string{
+		"newNonce":   ca.url("/nonce"),
+		"newAccount": ca.url("/account"),
+		"newOrder":   ca.url("/order"),
+	})
+}
+
+// withNonce issues a Replay-Nonce and decodes the JWS payload of POSTs.
+func (ca *fakeCA) withNonce(fn func(http.ResponseWriter, []byte)) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ca.mu.Lock()
+		ca.nonce++
+		w.Header().Set("Replay-Nonce", fmt.Sprintf("nonce-%d", ca.nonce))
+		ca.mu.Unlock()
+		if fn == nil {
+			return
+		}
+		assert.Equal(ca.t, joseContentType, req.Header.Get("Content-Type"))
+		var jws map[string]string
+		assert.Nil(ca.t, json.NewDecoder(req.Body).Decode(&jws))
+		payload, err := base64.RawURLEncoding.DecodeString(jws["payload"])
+		assert.Nil(ca.t, err)
+		fn(w, payload)
+	}
+}
+
+func (ca *fakeCA) account(w http.ResponseWriter, payload []byte) {
+	w.Header().Set("Location", ca.url("/account/1"))
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(`{"status":"valid"}`))
+}
+
+func (ca *fakeCA) newOrder(w http.ResponseWriter, payload []byte) {
+	var req struct {
+		Identifiers []struct {
+			Value string `json:"value"`
+		} `json:"identifiers"`
+	}
+	assert.Nil(ca.t, json.Unmarshal(payload, &req))
+	ca.mu.Lock()
+	ca.domains = nil
+	for _, id := range req.Identifiers {
+		ca.domains = append(ca.domains, id.Value)
+	}
+	ca.mu.Unlock()
+	w.Header().Set("Location", ca.url("/order/1"))
+	w.WriteHeader(http.StatusCreated)
+	ca.order(w, nil)
+}
+
+func (ca *fakeCA) order(w http.ResponseWriter, payload []byte) {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	status := "pending"
+	if ca.cert != nil {
+		status = "valid"
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":         status,
+		"authorizations": []string{ca.url("/authz/1")},
+		"finalize":       ca.url("/finalize"),
+		"certificate":    ca.url("/cert"),
+	})
+}
+
+func (ca *fakeCA) authz(w http.ResponseWriter, payload []byte) {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	status := "pending"
+	if ca.valid {
+		status = "valid"
+	}
+	fmt.Fprintf(w, `{"status":%q,"identifier":{"type":"dns","value":%q},"challenges":[{"type":"http-01","url":%q,"token":"tok3n","status":"pending"}]}`,
+		status, ca.domains[0], ca.url("/challenge/1"))
+}
+
+func (ca *fakeCA) accept(w http.ResponseWriter, payload []byte) {
+	// validate the challenge by requesting the token
+	req := httptest.NewRequest("GET", "http://"+ca.domains[0]+challengePathPrefix+"tok3n", nil)
+	rec := httptest.NewRecorder()
+	ca.challenge.ServeHTTP(rec, req)
+	ca.mu.Lock()
+	ca.valid = rec.Code == http.StatusOK && strings.HasPrefix(rec.Body.String(), "tok3n.")
+	ca.mu.Unlock()
+	w.Write([]byte(`{"status":"processing"}`))
+}
+
+func (ca *fakeCA) finalize(w http.ResponseWriter, payload []byte) {
+	var req struct {
+		CSR string `json:"csr"`
+	}
+	assert.Nil(ca.t, json.Unmarshal(payload, &req))
+	der, err := base64.RawURLEncoding.DecodeString(req.CSR)
+	assert.Nil(ca.t, err)
+	csr, err := x509.ParseCertificateRequest(der)
+	assert.Nil(ca.t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      csr.Subject,
+		DNSNames:     csr.DNSNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, tmpl, ca.caCert, csr.PublicKey, ca.caKey)
+	assert.Nil(ca.t, err)
+	ca.mu.Lock()
+	ca.cert = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})
+	ca.mu.Unlock()
+	ca.order(w, nil)
+}
+
+func (ca *fakeCA) certificate(w http.ResponseWriter, payload []byte) {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	w.Header().Set("Content-Type", "application/pem-certificate-chain")
+	w.Write(ca.cert)
+}
+
+func TestManagerObtain(t *testing.T) {
+	pollInterval = time.Millisecond
+	ca := newFakeCA(t)
+	defer ca.server.Close()
+	dir, err := ioutil.TempDir("", "matchbox")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	logger, _ := logtest.NewNullLogger()
+	config := &Config{
+		DirectoryURL: ca.url("/directory"),
+		Domains:      []string{"matchbox.example.com"},
+		CacheDir:     dir,
+		Logger:       logger,
+	}
+	manager, err := NewManager(config)
+	assert.Nil(t, err)
+	ca.challenge = manager.HTTPHandler(http.NotFoundHandler())
+
+	_, err = manager.GetCertificate(nil)
+	assert.Equal(t, errNoCertificate, err)
+	assert.True(t, manager.NeedsRenewal())
+
+	// assert that:
+	// - the http-01 challenge is served and validated
+	// - the issued certificate is served and cached
+	assert.Nil(t, manager.Obtain(context.Background()))
+	cert, err := manager.GetCertificate(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"matchbox.example.com"}, cert.Leaf.DNSNames)
+	assert.False(t, manager.NeedsRenewal())
+
+	// challenge tokens are removed after validation
+	rec := httptest.NewRecorder()
+	manager.HTTPHandler(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest("GET", challengePathPrefix+"tok3n", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// a new Manager loads the cached account key and certificate
+	reloaded, err := NewManager(config)
+	assert.Nil(t, err)
+	assert.Equal(t, thumbprint(&manager.key.PublicKey), thumbprint(&reloaded.key.PublicKey))
+	cached, err := reloaded.GetCertificate(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, cert.Certificate, cached.Certificate)
+}
+
+func TestNewManagerErrors(t *testing.T) {
+	cases := []struct {
+		config *Config
+		err    error
+	}{
+		{&Config{}, ErrNoDomains},
+		{&Config{Domains: []string{"a"}, Challenge: "tls-alpn-01"}, ErrInvalidChallenge},
+		{&Config{Domains: []string{"a"}, Challenge: ChallengeDNS01}, ErrDNSHookRequired},
+	}
+	for _, c := range cases {
+		_, err := NewManager(c.config)
+		assert.Equal(t, c.err, err)
+	}
+}
+
+func TestHTTPHandlerFallback(t *testing.T) {
+	manager := &Manager{tokens: map[string]string{"abc": "abc.thumb"}}
+	fallback := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "fallback")
+	})
+	h := manager.HTTPHandler(fallback)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/boot.ipxe", nil))
+	assert.Equal(t, "fallback", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", challengePathPrefix+"abc", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "abc.thumb", rec.Body.String())
+}
@@ -0,0 +1,3 @@
+// Package assets mirrors upstream boot assets into the assets directory and
+// manages assets uploaded by clients.
+package assets
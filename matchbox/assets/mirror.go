@@ -0,0 +1,169 @@
+package assets
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/coalesce"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// ErrChecksumMismatch is returned when an asset's contents do not match its
+// checksum.
+var ErrChecksumMismatch = errors.New("assets: checksum does not match file")
+
+var defaultDirMode os.FileMode = 0755
+
+// defaultTimeout bounds upstream fetches, which may be large kernel and
+// initrd images, so a stalled upstream can't hang the fetches coalesced
+// behind it.
+const defaultTimeout = 10 * time.Minute
+
+// Config configures a Mirror.
+type Config struct {
+	// Path to the assets directory
+	Root string
+	// HTTP client for upstream requests (defaults to a client with a timeout)
+	Client *http.Client
+	// (optional) credentials for OCI registries
+	RegistryUsername string
+	RegistryPassword string
+	Logger           *logrus.Logger
+}
+
+// Mirror fetches upstream Assets into a local assets directory.
+type Mirror struct {
+	root             string
+	client           *http.Client
+	registryUsername string
+	registryPassword string
+	logger           *logrus.Logger
+
+	// concurrent fetches of an asset path share a download
+	fetches coalesce.Group
+}
+
+// NewMirror returns a new Mirror.
+func NewMirror(config *Config) *Mirror {
+	client := config.Client
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+	return &Mirror{
+		root:             config.Root,
+		client:           client,
+		registryUsername: config.RegistryUsername,
+		registryPassword: config.RegistryPassword,
+		logger:           config.Logger,
+	}
+}
+
+// Path returns the local filesystem path of an asset path.
+func (m *Mirror) Path(name string) string {
+	return filepath.Join(m.root, filepath.FromSlash(path.Clean("/"+name)))
+}
+
+// Exists returns true if the asset path is present locally.
+func (m *Mirror) Exists(name string) bool {
+	_, err := os.Stat(m.Path(name))
+	return err == nil
+}
+
+// Fetch downloads the Asset from its upstream URL or OCI artifact, verifies
+// its checksum, and stores it in the assets directory. Concurrent calls for
+// the same path share a single download.
+func (m *Mirror) Fetch(asset *storagepb.Asset) error {
+	if err := asset.AssertValid(); err != nil {
+		return err
+	}
+	_, err, _ := m.fetches.Do(path.Clean(asset.Path), func() (interface{}, error) {
+		return nil, m.fetch(asset)
+	})
+	return err
+}
+
+// fetch downloads an asset unless it is present locally.
+func (m *Mirror) fetch(asset *storagepb.Asset) error {
+	if m.Exists(asset.Path) {
+		return nil
+	}
+	return m.download(asset)
+}
+
+// download downloads an asset and writes it to the assets directory once
+// the checksum is verified.
+func (m *Mirror) download(asset *storagepb.Asset) error {
+	algorithm, digest, err := asset.ParseChecksum()
+	if err != nil {
+		return err
+	}
+	if m.logger != nil {
+		m.logger.Infof("Mirroring asset %s from %s", asset.Path, asset.Url)
+	}
+	if isOCI(asset.Url) {
+		return m.downloadOCI(asset.Url, m.Path(asset.Path), algorithm, digest)
+	}
+
+	resp, err := m.client.Get(asset.Url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("assets: upstream %s returned %s", asset.Url, resp.Status)
+	}
+
+	_, err = writeVerified(m.Path(asset.Path), resp.Body, algorithm, digest)
+	return err
+}
+
+// writeVerified writes r to a temporary file and renames it to dest once the
+// digest is verified. A nil digest is not verified. Returns the number of
+// bytes written.
+func writeVerified(dest string, r io.Reader, algorithm string, digest []byte) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(dest), defaultDirMode); err != nil {
+		return 0, err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(dest), ".mirror-")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := newHash(algorithm)
+	n, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return n, err
+	}
+	if digest != nil && !bytes.Equal(h.Sum(nil), digest) {
+		return n, ErrChecksumMismatch
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return n, err
+	}
+	return n, os.Rename(tmp.Name(), dest)
+}
+
+// newHash returns a hash.Hash for a validated checksum algorithm.
+func newHash(algorithm string) hash.Hash {
+	if algorithm == "sha512" {
+		return sha512.New()
+	}
+	return sha256.New()
+}
@@ -0,0 +1,132 @@
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+const content = "kernel image contents"
+
+func sha256Checksum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func TestMirrorFetch(t *testing.T) {
+	var requests int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		fmt.Fprint(w, content)
+	}))
+	defer upstream.Close()
+
+	dir, err := ioutil.TempDir("", "matchbox")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	mirror := NewMirror(&Config{Root: dir})
+	asset := &storagepb.Asset{
+		Path:     "coreos/1298.7.0/vmlinuz",
+		Url:      upstream.URL + "/vmlinuz",
+		Checksum: sha256Checksum(content),
+	}
+	// assert that:
+	// - concurrent fetches download the asset once
+	// - the asset is written to the assets directory
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, mirror.Fetch(asset))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	assert.True(t, mirror.Exists(asset.Path))
+	data, err := ioutil.ReadFile(filepath.Join(dir, "coreos", "1298.7.0", "vmlinuz"))
+	assert.Nil(t, err)
+	assert.Equal(t, content, string(data))
+
+	// already mirrored assets are not fetched again
+	assert.Nil(t, mirror.Fetch(asset))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestMirrorFetch_ChecksumMismatch(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "tampered")
+	}))
+	defer upstream.Close()
+
+	dir, err := ioutil.TempDir("", "matchbox")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	mirror := NewMirror(&Config{Root: dir})
+	asset := &storagepb.Asset{
+		Path:     "vmlinuz",
+		Url:      upstream.URL,
+		Checksum: sha256Checksum(content),
+	}
+	assert.Equal(t, ErrChecksumMismatch, mirror.Fetch(asset))
+	assert.False(t, mirror.Exists(asset.Path))
+}
+
+func TestMirrorFetch_UpstreamError(t *testing.T) {
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	defer upstream.Close()
+
+	dir, err := ioutil.TempDir("", "matchbox")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	mirror := NewMirror(&Config{Root: dir})
+	asset := &storagepb.Asset{
+		Path:     "vmlinuz",
+		Url:      upstream.URL,
+		Checksum: sha256Checksum(content),
+	}
+	assert.NotNil(t, mirror.Fetch(asset))
+	assert.False(t, mirror.Exists(asset.Path))
+}
+
+func TestMirrorFetch_Timeout(t *testing.T) {
+	stalled := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-stalled
+	}))
+	defer upstream.Close()
+	defer close(stalled)
+
+	dir, err := ioutil.TempDir("", "matchbox")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// assert that:
+	// - mirrors have a client with a timeout by default
+	// - fetches from a stalled upstream fail once the timeout passes
+	assert.Equal(t, defaultTimeout, NewMirror(&Config{Root: dir}).client.Timeout)
+	mirror := NewMirror(&Config{Root: dir, Client: &http.Client{Timeout: 50 * time.Millisecond}})
+	asset := &storagepb.Asset{
+		Path:     "vmlinuz",
+		Url:      upstream.URL,
+		Checksum: sha256Checksum(content),
+	}
+	assert.NotNil(t, mirror.Fetch(asset))
+	assert.False(t, mirror.Exists(asset.Path))
+}
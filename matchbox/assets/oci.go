@@ -0,0 +1,281 @@
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// OCI artifact URL schemes. Plain HTTP registries use oci+http.
+const (
+	ociScheme     = "oci://"
+	ociHTTPScheme = "oci+http://"
+)
+
+// ociTitleAnnotation names the file a layer holds (as set by ORAS).
+const ociTitleAnnotation = "org.opencontainers.image.title"
+
+// manifest media types accepted from registries
+var ociManifestTypes = []string{
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Possible OCI artifact errors
+var (
+	ErrInvalidOCIReference = errors.New("assets: OCI url must be oci://registry/repository[:tag|@digest][#file]")
+	ErrManifestMismatch    = errors.New("assets: OCI manifest does not match its digest")
+	ErrLayerNotFound       = errors.New("assets: OCI artifact has no layer with the file name")
+	ErrAmbiguousLayer      = errors.New("assets: OCI artifact has several layers, name a file")
+)
+
+// ociReference identifies a file in an OCI artifact.
+type ociReference struct {
+	// registry base URL (e.g. https://ghcr.io)
+	registry   string
+	repository string
+	// tag or digest
+	reference string
+	// (optional) layer title
+	file string
+}
+
+// ociDescriptor describes an OCI manifest layer.
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// ociManifest is an OCI image manifest.
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+// isOCI returns true if the URL refers to an OCI artifact.
+func isOCI(rawURL string) bool {
+	return strings.HasPrefix(rawURL, ociScheme) || strings.HasPrefix(rawURL, ociHTTPScheme)
+}
+
+// parseOCIReference parses oci://registry/repository[:tag|@digest][#file].
+// Artifacts without a tag or digest use the "latest" tag.
+func parseOCIReference(rawURL string) (*ociReference, error) {
+	scheme := "https://"
+	rest := strings.TrimPrefix(rawURL, ociScheme)
+	if strings.HasPrefix(rawURL, ociHTTPScheme) {
+		scheme = "http://"
+		rest = strings.TrimPrefix(rawURL, ociHTTPScheme)
+	}
+	ref := &ociReference{}
+	if i := strings.Index(rest, "#"); i >= 0 {
+		rest, ref.file = rest[:i], rest[i+1:]
+	}
+	i := strings.Index(rest, "/")
+	if i <= 0 {
+		return nil, ErrInvalidOCIReference
+	}
+	ref.registry, rest = scheme+rest[:i], rest[i+1:]
+	switch {
+	case strings.Contains(rest, "@"):
+		i = strings.Index(rest, "@")
+		ref.repository, ref.reference = rest[:i], rest[i+1:]
+	case strings.LastIndex(rest, ":") > strings.LastIndex(rest, "/"):
+		i = strings.LastIndex(rest, ":")
+		ref.repository, ref.reference = rest[:i], rest[i+1:]
+	default:
+		ref.repository, ref.reference = rest, "latest"
+	}
+	if ref.repository == "" || ref.reference == "" {
+		return nil, ErrInvalidOCIReference
+	}
+	return ref, nil
+}
+
+// pinned returns true if the reference is a manifest digest.
+func (r *ociReference) pinned() bool {
+	return strings.Contains(r.reference, ":")
+}
+
+// downloadOCI downloads a file from an OCI artifact. Digest references are
+// verified against the manifest and the file's layer digest must match a
+// sha256 checksum.
+func (m *Mirror) downloadOCI(rawURL string, dest string, algorithm string, digest []byte) error {
+	ref, err := parseOCIReference(rawURL)
+	if err != nil {
+		return err
+	}
+	layer, err := m.ociLayer(ref)
+	if err != nil {
+		return err
+	}
+	if algorithm == "sha256" && layer.Digest != "sha256:"+hex.EncodeToString(digest) {
+		return ErrChecksumMismatch
+	}
+	resp, err := m.registryGet(ref, "/blobs/"+layer.Digest, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = writeVerified(dest, resp.Body, algorithm, digest)
+	return err
+}
+
+// ociLayer fetches the artifact manifest and returns the layer holding the
+// referenced file.
+func (m *Mirror) ociLayer(ref *ociReference) (*ociDescriptor, error) {
+	resp, err := m.registryGet(ref, "/manifests/"+ref.reference, strings.Join(ociManifestTypes, ", "))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if ref.pinned() {
+		sum := sha256.Sum256(body)
+		if ref.reference != "sha256:"+hex.EncodeToString(sum[:]) {
+			return nil, ErrManifestMismatch
+		}
+	}
+	manifest := new(ociManifest)
+	if err := json.Unmarshal(body, manifest); err != nil {
+		return nil, err
+	}
+	if ref.file == "" {
+		if len(manifest.Layers) != 1 {
+			return nil, ErrAmbiguousLayer
+		}
+		return &manifest.Layers[0], nil
+	}
+	for i, layer := range manifest.Layers {
+		if layer.Annotations[ociTitleAnnotation] == ref.file {
+			return &manifest.Layers[i], nil
+		}
+	}
+	return nil, ErrLayerNotFound
+}
+
+// registryGet GETs a registry API path of the repository, authenticating
+// with a bearer token if the registry requests one.
+func (m *Mirror) registryGet(ref *ociReference, apiPath, accept string) (*http.Response, error) {
+	endpoint := fmt.Sprintf("%s/v2/%s%s", ref.registry, ref.repository, apiPath)
+	resp, err := m.registryDo(endpoint, accept, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		token, err := m.registryToken(challenge)
+		if err != nil {
+			return nil, err
+		}
+		if resp, err = m.registryDo(endpoint, accept, "Bearer "+token); err != nil {
+			return nil, err
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("assets: registry %s returned %s", endpoint, resp.Status)
+	}
+	return resp, nil
+}
+
+func (m *Mirror) registryDo(endpoint, accept, authorization string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return m.client.Do(req)
+}
+
+// registryToken requests a bearer token from the realm of a registry's
+// WWW-Authenticate challenge, with the configured credentials if any.
+func (m *Mirror) registryToken(challenge string) (string, error) {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return "", fmt.Errorf("assets: unsupported registry authentication %q", challenge)
+	}
+	params := parseChallenge(strings.TrimPrefix(challenge, "Bearer "))
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("assets: invalid registry authentication realm %q", params["realm"])
+	}
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if value, ok := params[key]; ok {
+			query.Set(key, value)
+		}
+	}
+	realm.RawQuery = query.Encode()
+	req, err := http.NewRequest("GET", realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if m.registryUsername != "" {
+		req.SetBasicAuth(m.registryUsername, m.registryPassword)
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("assets: registry token realm returned %s", resp.Status)
+	}
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// parseChallenge parses the comma separated key="value" parameters of a
+// WWW-Authenticate challenge.
+func parseChallenge(s string) map[string]string {
+	params := make(map[string]string)
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " ,")
+		i := strings.Index(s, "=")
+		if i < 0 {
+			break
+		}
+		key := strings.TrimSpace(s[:i])
+		s = s[i+1:]
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := strings.Index(s[1:], `"`)
+			if end < 0 {
+				value, s = s[1:], ""
+			} else {
+				value, s = s[1:end+1], s[end+2:]
+			}
+		} else if end := strings.Index(s, ","); end >= 0 {
+			value, s = s[:end], s[end:]
+		} else {
+			value, s = s, ""
+		}
+		params[key] = value
+	}
+	return params
+}
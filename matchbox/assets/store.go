@@ -0,0 +1,99 @@
+package assets
+
+import (
+	"encoding/hex"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// Store manages the files in an assets directory on behalf of clients, which
+// may upload assets or have them fetched from upstream URLs.
+type Store struct {
+	mirror *Mirror
+}
+
+// NewStore returns a new Store.
+func NewStore(config *Config) *Store {
+	return &Store{
+		mirror: NewMirror(config),
+	}
+}
+
+// Put writes the contents of r to the asset path. If checksum is non-empty
+// ("sha256:hex" or "sha512:hex"), the asset is only written if its contents
+// match. Returns the number of bytes written.
+func (s *Store) Put(name, checksum string, r io.Reader) (int64, error) {
+	if err := assertValidPath(name); err != nil {
+		return 0, err
+	}
+	var algorithm string
+	var digest []byte
+	if checksum != "" {
+		var err error
+		algorithm, digest, err = (&storagepb.Asset{Checksum: checksum}).ParseChecksum()
+		if err != nil {
+			return 0, err
+		}
+	}
+	if s.mirror.logger != nil {
+		s.mirror.logger.Infof("Writing uploaded asset %s", name)
+	}
+	return writeVerified(s.mirror.Path(name), r, algorithm, digest)
+}
+
+// Fetch downloads the Asset from its upstream URL, unless the local asset
+// already matches the Asset checksum.
+func (s *Store) Fetch(asset *storagepb.Asset) error {
+	if err := asset.AssertValid(); err != nil {
+		return err
+	}
+	algorithm, digest, _ := asset.ParseChecksum()
+	if _, checksum, err := s.Stat(asset.Path, algorithm); err == nil && checksum == algorithm+":"+hex.EncodeToString(digest) {
+		return nil
+	}
+	return s.mirror.download(asset)
+}
+
+// Stat returns the size and checksum of an asset, using the given checksum
+// algorithm (sha256 or sha512).
+func (s *Store) Stat(name, algorithm string) (int64, string, error) {
+	if err := assertValidPath(name); err != nil {
+		return 0, "", err
+	}
+	algorithm = strings.ToLower(algorithm)
+	if algorithm != "sha256" && algorithm != "sha512" {
+		return 0, "", storagepb.ErrInvalidChecksum
+	}
+	f, err := os.Open(s.mirror.Path(name))
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+	h := newHash(algorithm)
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, algorithm + ":" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Delete removes an asset.
+func (s *Store) Delete(name string) error {
+	if err := assertValidPath(name); err != nil {
+		return err
+	}
+	return os.Remove(s.mirror.Path(name))
+}
+
+// assertValidPath returns an error if name is not a relative path within
+// the assets directory.
+func assertValidPath(name string) error {
+	if name == "" || path.IsAbs(name) || strings.HasPrefix(path.Clean(name), "..") {
+		return storagepb.ErrAssetPathRequired
+	}
+	return nil
+}
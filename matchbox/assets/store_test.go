@@ -0,0 +1,81 @@
+package assets
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+func TestStorePut(t *testing.T) {
+	dir, err := ioutil.TempDir("", "matchbox")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store := NewStore(&Config{Root: dir})
+	// assert that:
+	// - assets with a matching checksum are written
+	// - assets without a checksum are written
+	// - assets with a mismatched checksum are not written
+	// - paths outside the assets directory are rejected
+	size, err := store.Put("coreos/vmlinuz", sha256Checksum(content), strings.NewReader(content))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(content)), size)
+	_, err = store.Put("initrd", "", strings.NewReader(content))
+	assert.Nil(t, err)
+	_, err = store.Put("tampered", sha256Checksum(content), strings.NewReader("tampered"))
+	assert.Equal(t, ErrChecksumMismatch, err)
+	assert.False(t, store.mirror.Exists("tampered"))
+	_, err = store.Put("../escape", "", strings.NewReader(content))
+	assert.Equal(t, storagepb.ErrAssetPathRequired, err)
+
+	size, checksum, err := store.Stat("coreos/vmlinuz", "sha256")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(content)), size)
+	assert.Equal(t, sha256Checksum(content), checksum)
+	_, _, err = store.Stat("coreos/vmlinuz", "md5")
+	assert.Equal(t, storagepb.ErrInvalidChecksum, err)
+
+	assert.Nil(t, store.Delete("coreos/vmlinuz"))
+	_, _, err = store.Stat("coreos/vmlinuz", "sha256")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestStoreFetch(t *testing.T) {
+	var requests int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		fmt.Fprint(w, content)
+	}))
+	defer upstream.Close()
+
+	dir, err := ioutil.TempDir("", "matchbox")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store := NewStore(&Config{Root: dir})
+	_, err = store.Put("vmlinuz", "", strings.NewReader("outdated"))
+	assert.Nil(t, err)
+	asset := &storagepb.Asset{
+		Path:     "vmlinuz",
+		Url:      upstream.URL,
+		Checksum: sha256Checksum(content),
+	}
+	// assert that:
+	// - an outdated local asset is replaced from upstream
+	// - a matching local asset is not fetched again
+	assert.Nil(t, store.Fetch(asset))
+	assert.Nil(t, store.Fetch(asset))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	_, checksum, err := store.Stat("vmlinuz", "sha256")
+	assert.Nil(t, err)
+	assert.Equal(t, asset.Checksum, checksum)
+}
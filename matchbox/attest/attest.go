@@ -0,0 +1,285 @@
+package attest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// Possible attestation errors
+var (
+	ErrNotEnrolled      = errors.New("attest: machine has no enrolled EK")
+	ErrUnsupportedEK    = errors.New("attest: enrolled EK must be an RSA public key or certificate")
+	ErrNoChallenge      = errors.New("attest: no outstanding challenge for the machine")
+	ErrChallengePending = errors.New("attest: a challenge for the machine is pending")
+	ErrNotAttested      = errors.New("attest: invalid, used, or expired attestation credential")
+	ErrNonceMismatch    = errors.New("attest: quote is not qualified by the challenge secret")
+	ErrInvalidMachineID = errors.New("attest: invalid machine uuid")
+)
+
+// Defaults for attestation.
+const (
+	// DefaultTTL is how long a verified attestation's credential may be
+	// used to fetch a config.
+	DefaultTTL = 10 * time.Minute
+	// challengeTTL is how long a challenge may be answered.
+	challengeTTL = 2 * time.Minute
+	// secretSize is the size of challenge secrets, which qualify quotes.
+	secretSize = 32
+	// credentialSize is the size of attestation credentials.
+	credentialSize = 16
+)
+
+// Config configures a Verifier.
+type Config struct {
+	// Directory of enrolled EK public keys or certificates (PEM), named by
+	// machine UUID (e.g. a1b2c3d4.pem)
+	EKPath string
+	// How long a verified attestation allows config fetches (defaults to 10m)
+	TTL    time.Duration
+	Logger *logrus.Logger
+}
+
+// Challenge is sent to a machine to activate with its TPM
+// (TPM2_ActivateCredential). The recovered secret qualifies its quote.
+type Challenge struct {
+	CredentialBlob  []byte `json:"credential_blob"`
+	EncryptedSecret []byte `json:"encrypted_secret"`
+}
+
+// attestation is the credential of a verified machine, which may be
+// redeemed once to fetch a config.
+type attestation struct {
+	credential string
+	expires    time.Time
+	redeemed   bool
+}
+
+// session is an outstanding challenge.
+type session struct {
+	ak      *akPublic
+	secret  []byte
+	expires time.Time
+}
+
+// Verifier challenges machines to prove their TPM holds an enrolled EK and
+// verifies their quotes. Machines first request a challenge for a TPM
+// resident attestation key (AK), which only the TPM with the enrolled EK
+// can activate, then return a quote by the AK qualified by the activated
+// secret, in exchange for a single-use credential.
+type Verifier struct {
+	ekPath string
+	ttl    time.Duration
+	logger *logrus.Logger
+
+	mu       sync.Mutex
+	sessions map[string]*session
+	// verified machines, by uuid, until their attestation expires
+	attested map[string]*attestation
+	now      func() time.Time
+}
+
+// NewVerifier returns a new Verifier.
+func NewVerifier(config *Config) *Verifier {
+	v := &Verifier{
+		ekPath:   config.EKPath,
+		ttl:      config.TTL,
+		logger:   config.Logger,
+		sessions: make(map[string]*session),
+		attested: make(map[string]*attestation),
+		now:      time.Now,
+	}
+	if v.ttl <= 0 {
+		v.ttl = DefaultTTL
+	}
+	if v.logger == nil {
+		v.logger = logrus.New()
+	}
+	return v
+}
+
+// Challenge returns a challenge for the machine's attestation key, given as
+// a TPMT_PUBLIC. A machine may have one outstanding challenge at a time.
+func (v *Verifier) Challenge(uuid string, akPub []byte) (*Challenge, error) {
+	ek, err := v.enrolledEK(uuid)
+	if err != nil {
+		return nil, err
+	}
+	ak, err := parseAKPublic(akPub)
+	if err != nil {
+		return nil, err
+	}
+	secret := make([]byte, secretSize)
+	if _, err := io.ReadFull(rand.Reader, secret); err != nil {
+		return nil, err
+	}
+	blob, encSecret, err := makeCredential(ek, ak.name, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	now := v.now()
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.sweep(now)
+	if _, ok := v.sessions[uuid]; ok {
+		return nil, ErrChallengePending
+	}
+	v.sessions[uuid] = &session{ak: ak, secret: secret, expires: now.Add(challengeTTL)}
+	return &Challenge{CredentialBlob: blob, EncryptedSecret: encSecret}, nil
+}
+
+// Verify verifies the machine's quote (TPMS_ATTEST) and its signature
+// (TPMT_SIGNATURE) answer the outstanding challenge, and returns a credential
+// the machine may redeem once within the TTL. Verifying again replaces the
+// machine's credential.
+func (v *Verifier) Verify(uuid string, quote, signature []byte) (string, error) {
+	now := v.now()
+	v.mu.Lock()
+	s, ok := v.sessions[uuid]
+	if ok {
+		// each challenge may be answered once
+		delete(v.sessions, uuid)
+	}
+	v.mu.Unlock()
+	if !ok || now.After(s.expires) {
+		return "", ErrNoChallenge
+	}
+
+	extraData, err := parseQuote(quote)
+	if err != nil {
+		return "", err
+	}
+	if subtle.ConstantTimeCompare(extraData, s.secret) != 1 {
+		return "", ErrNonceMismatch
+	}
+	if err := s.ak.verifySignature(quote, signature); err != nil {
+		return "", err
+	}
+	b := make([]byte, credentialSize)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", err
+	}
+	credential := hex.EncodeToString(b)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.attested[uuid] = &attestation{credential: credential, expires: now.Add(v.ttl)}
+	return credential, nil
+}
+
+// Attested returns true if the credential is the machine's unexpired
+// attestation credential, whether or not it was redeemed.
+func (v *Verifier) Attested(uuid, credential string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.lookup(uuid, credential) != nil
+}
+
+// Redeem uses up the machine's attestation credential if it is valid and
+// has not been redeemed.
+func (v *Verifier) Redeem(uuid, credential string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	a := v.lookup(uuid, credential)
+	if a == nil || a.redeemed {
+		return ErrNotAttested
+	}
+	a.redeemed = true
+	return nil
+}
+
+// Restore makes a redeemed attestation credential valid again until it
+// expires, such as when the request it was redeemed for failed.
+func (v *Verifier) Restore(uuid, credential string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if a := v.lookup(uuid, credential); a != nil {
+		a.redeemed = false
+	}
+}
+
+// lookup returns the machine's attestation if the credential matches and it
+// is unexpired. Callers must hold the lock.
+func (v *Verifier) lookup(uuid, credential string) *attestation {
+	a, ok := v.attested[uuid]
+	if !ok || credential == "" || !v.now().Before(a.expires) {
+		return nil
+	}
+	if subtle.ConstantTimeCompare([]byte(credential), []byte(a.credential)) != 1 {
+		return nil
+	}
+	return a
+}
+
+// sweep discards expired challenges and attestations. Callers must hold
+// the lock.
+func (v *Verifier) sweep(now time.Time) {
+	for uuid, s := range v.sessions {
+		if now.After(s.expires) {
+			delete(v.sessions, uuid)
+		}
+	}
+	for uuid, a := range v.attested {
+		if now.After(a.expires) {
+			delete(v.attested, uuid)
+		}
+	}
+}
+
+// enrolledEK reads the enrolled EK of a machine.
+func (v *Verifier) enrolledEK(uuid string) (*rsa.PublicKey, error) {
+	if uuid == "" || uuid != filepath.Base(uuid) || uuid[0] == '.' {
+		return nil, ErrInvalidMachineID
+	}
+	data, err := ioutil.ReadFile(filepath.Join(v.ekPath, uuid+".pem"))
+	if os.IsNotExist(err) {
+		return nil, ErrNotEnrolled
+	} else if err != nil {
+		return nil, err
+	}
+	return parseEK(data)
+}
+
+// parseEK parses a PEM encoded RSA public key or EK certificate.
+func parseEK(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrUnsupportedEK
+	}
+	var pub interface{}
+	var err error
+	switch block.Type {
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			pub = cert.PublicKey
+		}
+	case "PUBLIC KEY":
+		pub, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		pub, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, ErrUnsupportedEK
+	}
+	if err != nil {
+		return nil, err
+	}
+	ek, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, ErrUnsupportedEK
+	}
+	return ek, nil
+}
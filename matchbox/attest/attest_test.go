@@ -0,0 +1,184 @@
+package attest
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTPM holds an EK and a restricted RSA signing AK.
+type fakeTPM struct {
+	ek *rsa.PrivateKey
+	ak *rsa.PrivateKey
+	// ak TPMT_PUBLIC
+	akPub []byte
+}
+
+func newFakeTPM(t *testing.T, attributes uint32) *fakeTPM {
+	ek, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	ak, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	var buf bytes.Buffer
+	put(&buf, algRSA, algSHA256, attributes)
+	buf.Write(tpm2b(nil))
+	put(&buf, algNull, algRSASSA, algSHA256, uint16(2048), uint32(0))
+	buf.Write(tpm2b(ak.N.Bytes()))
+	return &fakeTPM{ek: ek, ak: ak, akPub: buf.Bytes()}
+}
+
+// enroll writes the EK public key as the machine's enrolled EK.
+func (tpm *fakeTPM) enroll(t *testing.T, dir, uuid string) {
+	der, err := x509.MarshalPKIXPublicKey(&tpm.ek.PublicKey)
+	assert.Nil(t, err)
+	data := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, uuid+".pem"), data, 0644))
+}
+
+// quote activates the challenge and returns a quote qualified by the secret
+// and its signature.
+func (tpm *fakeTPM) quote(t *testing.T, challenge *Challenge) ([]byte, []byte) {
+	ak, err := parseAKPublic(tpm.akPub)
+	assert.Nil(t, err)
+	secret, err := activateCredential(tpm.ek, ak.name, challenge.CredentialBlob, challenge.EncryptedSecret)
+	assert.Nil(t, err)
+	return tpm.sign(t, secret)
+}
+
+func (tpm *fakeTPM) sign(t *testing.T, extraData []byte) ([]byte, []byte) {
+	var quote bytes.Buffer
+	put(&quote, generatedValue, stAttestQuote)
+	quote.Write(tpm2b([]byte("signer")))
+	quote.Write(tpm2b(extraData))
+	quote.Write(make([]byte, 17+8))
+	put(&quote, uint32(1), algSHA256, uint8(3), []byte{0x01, 0x00, 0x00})
+	quote.Write(tpm2b(make([]byte, sha256.Size)))
+
+	digest := sha256.Sum256(quote.Bytes())
+	sig, err := rsa.SignPKCS1v15(rand.Reader, tpm.ak, crypto.SHA256, digest[:])
+	assert.Nil(t, err)
+	var signature bytes.Buffer
+	put(&signature, algRSASSA, algSHA256)
+	signature.Write(tpm2b(sig))
+	return quote.Bytes(), signature.Bytes()
+}
+
+// put writes big-endian values.
+func put(w io.Writer, values ...interface{}) {
+	for _, v := range values {
+		binary.Write(w, binary.BigEndian, v)
+	}
+}
+
+const akAttributes = attrFixedTPM | attrRestricted | attrSign | 0x00000070
+
+func TestAttestation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "matchbox-attest")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	tpm := newFakeTPM(t, akAttributes)
+	tpm.enroll(t, dir, "a1b2c3d4")
+	logger, _ := logtest.NewNullLogger()
+	v := NewVerifier(&Config{EKPath: dir, Logger: logger})
+	now := time.Now()
+	v.now = func() time.Time { return now }
+
+	// assert that:
+	// - a machine may not be challenged again while a challenge is pending
+	// - a quote qualified by the activated secret returns a credential
+	// - credentials may be redeemed once, unless restored
+	// - credentials are bound to the machine and expire after the TTL
+	challenge, err := v.Challenge("a1b2c3d4", tpm.akPub)
+	assert.Nil(t, err)
+	_, err = v.Challenge("a1b2c3d4", tpm.akPub)
+	assert.Equal(t, ErrChallengePending, err)
+	quote, signature := tpm.quote(t, challenge)
+	credential, err := v.Verify("a1b2c3d4", quote, signature)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, credential)
+	assert.True(t, v.Attested("a1b2c3d4", credential))
+	assert.False(t, v.Attested("a1b2c3d4", ""))
+	assert.False(t, v.Attested("a1b2c3d4", "guess"))
+	assert.Equal(t, ErrNotAttested, v.Redeem("e5f6a7b8", credential))
+	assert.Nil(t, v.Redeem("a1b2c3d4", credential))
+	assert.Equal(t, ErrNotAttested, v.Redeem("a1b2c3d4", credential))
+	assert.True(t, v.Attested("a1b2c3d4", credential))
+	v.Restore("a1b2c3d4", credential)
+	assert.Nil(t, v.Redeem("a1b2c3d4", credential))
+	v.Restore("a1b2c3d4", credential)
+	now = now.Add(DefaultTTL)
+	assert.False(t, v.Attested("a1b2c3d4", credential))
+	assert.Equal(t, ErrNotAttested, v.Redeem("a1b2c3d4", credential))
+	// - challenges may be answered once
+	_, err = v.Verify("a1b2c3d4", quote, signature)
+	assert.Equal(t, ErrNoChallenge, err)
+}
+
+func TestAttestation_Rejected(t *testing.T) {
+	dir, err := ioutil.TempDir("", "matchbox-attest")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	tpm := newFakeTPM(t, akAttributes)
+	tpm.enroll(t, dir, "a1b2c3d4")
+	logger, _ := logtest.NewNullLogger()
+	v := NewVerifier(&Config{EKPath: dir, Logger: logger})
+
+	// assert that:
+	// - machines without an enrolled EK are not challenged
+	// - keys which are not TPM resident restricted signing keys are refused
+	_, err = v.Challenge("e5f6a7b8", tpm.akPub)
+	assert.Equal(t, ErrNotEnrolled, err)
+	_, err = v.Challenge("../a1b2c3d4", tpm.akPub)
+	assert.Equal(t, ErrInvalidMachineID, err)
+	_, err = v.Challenge("a1b2c3d4", newFakeTPM(t, attrSign).akPub)
+	assert.Equal(t, ErrNotAttestationKey, err)
+
+	// - quotes without a challenge are refused
+	quote, signature := tpm.sign(t, []byte("nonce"))
+	_, err = v.Verify("a1b2c3d4", quote, signature)
+	assert.Equal(t, ErrNoChallenge, err)
+	// - quotes not qualified by the secret (another TPM could not activate
+	//   the challenge) are refused
+	_, err = v.Challenge("a1b2c3d4", tpm.akPub)
+	assert.Nil(t, err)
+	_, err = v.Verify("a1b2c3d4", quote, signature)
+	assert.Equal(t, ErrNonceMismatch, err)
+	// - quotes with a bad signature are refused
+	challenge, err := v.Challenge("a1b2c3d4", tpm.akPub)
+	assert.Nil(t, err)
+	quote, signature = tpm.quote(t, challenge)
+	quote[len(quote)-1] ^= 0xff
+	credential, err := v.Verify("a1b2c3d4", quote, signature)
+	assert.Equal(t, ErrBadSignature, err)
+	assert.Empty(t, credential)
+}
+
+func TestParseQuote(t *testing.T) {
+	tpm := &fakeTPM{}
+	tpm.ak, _ = rsa.GenerateKey(rand.Reader, 1024)
+	quote, _ := tpm.sign(t, []byte("nonce"))
+	// assert that:
+	// - extraData is parsed
+	// - truncated or non-quote attestations are refused
+	extraData, err := parseQuote(quote)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("nonce"), extraData)
+	_, err = parseQuote(quote[:len(quote)-1])
+	assert.Equal(t, ErrMalformed, err)
+	_, err = parseQuote(append([]byte{0, 0, 0, 0}, quote[4:]...))
+	assert.Equal(t, ErrNotQuote, err)
+}
@@ -0,0 +1,82 @@
+package attest
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+)
+
+// Labels used by credential activation (TPM 2.0 Part 1, 24).
+var (
+	labelIdentity  = []byte("IDENTITY\x00")
+	labelStorage   = []byte("STORAGE")
+	labelIntegrity = []byte("INTEGRITY")
+)
+
+// aesKeyBits is the symmetric key size of the default EK template
+// (AES-128-CFB).
+const aesKeyBits = 128
+
+// makeCredential protects a secret so only the TPM with the EK can recover
+// it with TPM2_ActivateCredential, and only for the key with the given
+// name. It returns the TPM2B_ID_OBJECT credential blob and the
+// TPM2B_ENCRYPTED_SECRET, for an RSA EK with the default (SHA-256,
+// AES-128-CFB) template.
+func makeCredential(ek *rsa.PublicKey, name, secret []byte) (credentialBlob, encryptedSecret []byte, err error) {
+	seed := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(rand.Reader, seed); err != nil {
+		return nil, nil, err
+	}
+	encSeed, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, ek, seed, labelIdentity)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// encrypt the secret (as a TPM2B_DIGEST) with a key derived from the seed
+	// and the name
+	symKey := kdfa(crypto.SHA256, seed, labelStorage, name, nil, aesKeyBits)
+	block, err := aes.NewCipher(symKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintext := tpm2b(secret)
+	encIdentity := make([]byte, len(plaintext))
+	cipher.NewCFBEncrypter(block, make([]byte, aes.BlockSize)).XORKeyStream(encIdentity, plaintext)
+
+	// outer integrity HMAC over the encrypted secret and the name
+	hmacKey := kdfa(crypto.SHA256, seed, labelIntegrity, nil, nil, 8*sha256.Size)
+	mac := hmac.New(sha256.New, hmacKey)
+	mac.Write(encIdentity)
+	mac.Write(name)
+	idObject := append(tpm2b(mac.Sum(nil)), encIdentity...)
+	return tpm2b(idObject), tpm2b(encSeed), nil
+}
+
+// kdfa is the TPM key derivation function (SP800-108 counter mode with
+// HMAC, TPM 2.0 Part 1, 11.4.10.2).
+func kdfa(hash crypto.Hash, key, label, contextU, contextV []byte, bits int) []byte {
+	size := (bits + 7) / 8
+	var out []byte
+	var buf [4]byte
+	for counter := uint32(1); len(out) < size; counter++ {
+		mac := hmac.New(hash.New, key)
+		binary.BigEndian.PutUint32(buf[:], counter)
+		mac.Write(buf[:])
+		mac.Write(label)
+		if len(label) == 0 || label[len(label)-1] != 0 {
+			mac.Write([]byte{0})
+		}
+		mac.Write(contextU)
+		mac.Write(contextV)
+		binary.BigEndian.PutUint32(buf[:], uint32(bits))
+		mac.Write(buf[:])
+		out = mac.Sum(out)
+	}
+	return out[:size]
+}
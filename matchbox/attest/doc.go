@@ -0,0 +1,3 @@
+// Package attest verifies TPM 2.0 quotes from booting machines against their
+// enrolled endorsement keys (EKs), to gate configs which carry secrets.
+package attest
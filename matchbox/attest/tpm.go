@@ -0,0 +1,301 @@
+package attest
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// TPM 2.0 algorithm, structure, and attribute constants (TPM 2.0 Part 2).
+const (
+	algRSA    uint16 = 0x0001
+	algSHA1   uint16 = 0x0004
+	algSHA256 uint16 = 0x000B
+	algSHA384 uint16 = 0x000C
+	algNull   uint16 = 0x0010
+	algRSASSA uint16 = 0x0014
+	algRSAPSS uint16 = 0x0016
+	algECDSA  uint16 = 0x0018
+	algECC    uint16 = 0x0023
+
+	curveP256 uint16 = 0x0003
+	curveP384 uint16 = 0x0004
+
+	generatedValue uint32 = 0xff544347
+	stAttestQuote  uint16 = 0x8018
+
+	attrFixedTPM   uint32 = 0x00000002
+	attrRestricted uint32 = 0x00010000
+	attrSign       uint32 = 0x00040000
+)
+
+// Possible TPM structure errors
+var (
+	ErrMalformed         = errors.New("attest: malformed TPM structure")
+	ErrUnsupportedAlg    = errors.New("attest: unsupported TPM algorithm")
+	ErrNotAttestationKey = errors.New("attest: key is not a restricted, fixedTPM signing key")
+	ErrNotQuote          = errors.New("attest: attestation is not a TPM generated quote")
+	ErrBadSignature      = errors.New("attest: quote signature does not verify")
+)
+
+// hashes maps TPM hash algorithms to hashes.
+var hashes = map[uint16]crypto.Hash{
+	algSHA1:   crypto.SHA1,
+	algSHA256: crypto.SHA256,
+	algSHA384: crypto.SHA384,
+}
+
+// reader decodes big-endian TPM structures.
+type reader struct {
+	*bytes.Reader
+}
+
+func newReader(data []byte) *reader {
+	return &reader{bytes.NewReader(data)}
+}
+
+func (r *reader) u8() (uint8, error) {
+	var v uint8
+	err := binary.Read(r, binary.BigEndian, &v)
+	return v, err
+}
+
+func (r *reader) u16() (uint16, error) {
+	var v uint16
+	err := binary.Read(r, binary.BigEndian, &v)
+	return v, err
+}
+
+func (r *reader) u32() (uint32, error) {
+	var v uint32
+	err := binary.Read(r, binary.BigEndian, &v)
+	return v, err
+}
+
+// tpm2b reads a size prefixed buffer.
+func (r *reader) tpm2b() ([]byte, error) {
+	size, err := r.u16()
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// tpm2b returns a size prefixed buffer.
+func tpm2b(data []byte) []byte {
+	buf := make([]byte, 2, 2+len(data))
+	binary.BigEndian.PutUint16(buf, uint16(len(data)))
+	return append(buf, data...)
+}
+
+// akPublic is a parsed TPMT_PUBLIC attestation key.
+type akPublic struct {
+	// Name of the key, nameAlg followed by the digest of the TPMT_PUBLIC
+	name []byte
+	// signing scheme and its hash, or algNull if unrestricted by the key
+	scheme uint16
+	hash   uint16
+	public crypto.PublicKey
+}
+
+// parseAKPublic parses a TPMT_PUBLIC which must be a restricted signing key
+// resident in the TPM.
+func parseAKPublic(data []byte) (*akPublic, error) {
+	r := newReader(data)
+	keyType, err := r.u16()
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	nameAlg, err := r.u16()
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	attributes, err := r.u32()
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	if _, err := r.tpm2b(); err != nil { // authPolicy
+		return nil, ErrMalformed
+	}
+	required := attrFixedTPM | attrRestricted | attrSign
+	if attributes&required != required {
+		return nil, ErrNotAttestationKey
+	}
+	nameHash, ok := hashes[nameAlg]
+	if !ok {
+		return nil, ErrUnsupportedAlg
+	}
+
+	// symmetric must be null for signing keys
+	if symmetric, err := r.u16(); err != nil || symmetric != algNull {
+		return nil, ErrNotAttestationKey
+	}
+	ak := &akPublic{}
+	if ak.scheme, err = r.u16(); err != nil {
+		return nil, ErrMalformed
+	}
+	if ak.scheme != algNull {
+		if ak.hash, err = r.u16(); err != nil {
+			return nil, ErrMalformed
+		}
+	}
+
+	switch keyType {
+	case algRSA:
+		bits, err1 := r.u16()
+		exponent, err2 := r.u32()
+		modulus, err3 := r.tpm2b()
+		if err1 != nil || err2 != nil || err3 != nil || int(bits) != 8*len(modulus) {
+			return nil, ErrMalformed
+		}
+		if exponent == 0 {
+			exponent = 65537
+		}
+		ak.public = &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: int(exponent)}
+	case algECC:
+		curveID, err1 := r.u16()
+		kdf, err2 := r.u16()
+		if err1 != nil || err2 != nil || kdf != algNull {
+			return nil, ErrMalformed
+		}
+		var curve elliptic.Curve
+		switch curveID {
+		case curveP256:
+			curve = elliptic.P256()
+		case curveP384:
+			curve = elliptic.P384()
+		default:
+			return nil, ErrUnsupportedAlg
+		}
+		x, err1 := r.tpm2b()
+		y, err2 := r.tpm2b()
+		if err1 != nil || err2 != nil {
+			return nil, ErrMalformed
+		}
+		ak.public = &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	default:
+		return nil, ErrUnsupportedAlg
+	}
+	if r.Len() != 0 {
+		return nil, ErrMalformed
+	}
+
+	h := nameHash.New()
+	h.Write(data)
+	ak.name = make([]byte, 2, 2+h.Size())
+	binary.BigEndian.PutUint16(ak.name, nameAlg)
+	ak.name = h.Sum(ak.name)
+	return ak, nil
+}
+
+// parseQuote parses a TPMS_ATTEST quote and returns its extraData
+// (qualifying data).
+func parseQuote(data []byte) ([]byte, error) {
+	r := newReader(data)
+	magic, err1 := r.u32()
+	kind, err2 := r.u16()
+	if err1 != nil || err2 != nil {
+		return nil, ErrMalformed
+	}
+	if magic != generatedValue || kind != stAttestQuote {
+		return nil, ErrNotQuote
+	}
+	if _, err := r.tpm2b(); err != nil { // qualifiedSigner
+		return nil, ErrMalformed
+	}
+	extraData, err := r.tpm2b()
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	// clockInfo (17 bytes) and firmwareVersion (8 bytes)
+	if _, err := r.Seek(17+8, io.SeekCurrent); err != nil || r.Len() == 0 {
+		return nil, ErrMalformed
+	}
+	// TPMS_QUOTE_INFO pcrSelect
+	count, err := r.u32()
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	for i := uint32(0); i < count; i++ {
+		if _, err := r.u16(); err != nil {
+			return nil, ErrMalformed
+		}
+		size, err := r.u8()
+		if err != nil {
+			return nil, ErrMalformed
+		}
+		if _, err := r.Seek(int64(size), io.SeekCurrent); err != nil {
+			return nil, ErrMalformed
+		}
+	}
+	if _, err := r.tpm2b(); err != nil { // pcrDigest
+		return nil, ErrMalformed
+	}
+	if r.Len() != 0 {
+		return nil, ErrMalformed
+	}
+	return extraData, nil
+}
+
+// verifySignature verifies a TPMT_SIGNATURE of the quote by the key.
+func (ak *akPublic) verifySignature(quote, signature []byte) error {
+	r := newReader(signature)
+	sigAlg, err1 := r.u16()
+	hashAlg, err2 := r.u16()
+	if err1 != nil || err2 != nil {
+		return ErrMalformed
+	}
+	if ak.scheme != algNull && (sigAlg != ak.scheme || hashAlg != ak.hash) {
+		return fmt.Errorf("attest: signature scheme does not match the key's scheme")
+	}
+	hash, ok := hashes[hashAlg]
+	if !ok {
+		return ErrUnsupportedAlg
+	}
+	h := hash.New()
+	h.Write(quote)
+	digest := h.Sum(nil)
+
+	switch pub := ak.public.(type) {
+	case *rsa.PublicKey:
+		sig, err := r.tpm2b()
+		if err != nil {
+			return ErrMalformed
+		}
+		switch sigAlg {
+		case algRSASSA:
+			err = rsa.VerifyPKCS1v15(pub, hash, digest, sig)
+		case algRSAPSS:
+			err = rsa.VerifyPSS(pub, hash, digest, sig, nil)
+		default:
+			return ErrUnsupportedAlg
+		}
+		if err != nil {
+			return ErrBadSignature
+		}
+	case *ecdsa.PublicKey:
+		if sigAlg != algECDSA {
+			return ErrUnsupportedAlg
+		}
+		rb, err1 := r.tpm2b()
+		sb, err2 := r.tpm2b()
+		if err1 != nil || err2 != nil {
+			return ErrMalformed
+		}
+		if !ecdsa.Verify(pub, digest, new(big.Int).SetBytes(rb), new(big.Int).SetBytes(sb)) {
+			return ErrBadSignature
+		}
+	}
+	return nil
+}
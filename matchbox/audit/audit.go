@@ -0,0 +1,111 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// Audit entry types
+const (
+	// TypeAPI entries record gRPC API calls.
+	TypeAPI = "api"
+	// TypeBoot entries record machine requests to boot endpoints.
+	TypeBoot = "boot"
+	// TypeUI entries record changes made in the web dashboard.
+	TypeUI = "ui"
+)
+
+// queueSize is the number of entries buffered for export. Entries are
+// dropped if sinks fall behind, rather than block requests.
+const queueSize = 1024
+
+// Entry is an audit record of an action.
+type Entry struct {
+	Time string `json:"time"`
+	Type string `json:"type"`
+	// endpoint, gRPC method, or dashboard path
+	Action string `json:"action"`
+	// client IP address or certificate common name
+	Actor string `json:"actor"`
+	// HTTP status or gRPC code
+	Result string `json:"result"`
+	// (optional) action details (e.g. machine, group, profile)
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// A Sink exports audit entries.
+type Sink interface {
+	Write(entry *Entry) error
+	Close() error
+}
+
+// Config configures an Auditor.
+type Config struct {
+	Sinks  []Sink
+	Logger *logrus.Logger
+}
+
+// Auditor exports entries to sinks in the background. All methods are safe
+// to call on a nil Auditor, which records nothing.
+type Auditor struct {
+	sinks  []Sink
+	logger *logrus.Logger
+	queue  chan *Entry
+	done   chan struct{}
+}
+
+// NewAuditor returns a new Auditor.
+func NewAuditor(config *Config) *Auditor {
+	a := &Auditor{
+		sinks:  config.Sinks,
+		logger: config.Logger,
+		queue:  make(chan *Entry, queueSize),
+		done:   make(chan struct{}),
+	}
+	if a.logger == nil {
+		a.logger = logrus.New()
+	}
+	go a.run()
+	return a
+}
+
+// Record queues an entry for export, setting its time if unset.
+func (a *Auditor) Record(entry *Entry) {
+	if a == nil {
+		return
+	}
+	if entry.Time == "" {
+		entry.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	select {
+	case a.queue <- entry:
+	default:
+		a.logger.Warningf("audit: queue full, dropping %s entry %s", entry.Type, entry.Action)
+	}
+}
+
+// Close exports queued entries and closes the sinks.
+func (a *Auditor) Close() {
+	if a == nil {
+		return
+	}
+	close(a.queue)
+	<-a.done
+	for _, sink := range a.sinks {
+		if err := sink.Close(); err != nil {
+			a.logger.Warningf("audit: error closing sink: %v", err)
+		}
+	}
+}
+
+func (a *Auditor) run() {
+	defer close(a.done)
+	for entry := range a.queue {
+		for _, sink := range a.sinks {
+			if err := sink.Write(entry); err != nil {
+				a.logger.Warningf("audit: error writing %s entry: %v", entry.Type, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package audit
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// memorySink records entries.
+type memorySink struct {
+	mu      sync.Mutex
+	entries []*Entry
+	closed  bool
+}
+
+func (s *memorySink) Write(entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func (s *memorySink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestAuditor(t *testing.T) {
+	sink := &memorySink{}
+	logger, _ := logtest.NewNullLogger()
+	a := NewAuditor(&Config{Sinks: []Sink{sink}, Logger: logger})
+	a.Record(&Entry{Type: TypeBoot, Action: "/ipxe", Actor: "10.0.0.5", Result: "200"})
+	a.Close()
+	// assert that:
+	// - queued entries are written and timestamped before sinks are closed
+	if assert.Len(t, sink.entries, 1) {
+		assert.Equal(t, "/ipxe", sink.entries[0].Action)
+		assert.NotEmpty(t, sink.entries[0].Time)
+	}
+	assert.True(t, sink.closed)
+
+	// nil Auditors record nothing
+	var none *Auditor
+	none.Record(&Entry{})
+	none.Close()
+}
+
+func TestParseSink(t *testing.T) {
+	cases := []struct {
+		url string
+		err error
+	}{
+		{"https://siem.example.com/ingest", nil},
+		{"kafka+http://proxy:8082/topics/matchbox", nil},
+		{"kafka+http://proxy:8082/matchbox", ErrKafkaTopic},
+		{"ftp://example.com", ErrUnknownSink},
+	}
+	for _, c := range cases {
+		_, err := ParseSink(c.url, nil)
+		assert.Equal(t, c.err, err, c.url)
+	}
+}
+
+func TestHTTPSinks(t *testing.T) {
+	type request struct {
+		contentType string
+		body        map[string]interface{}
+	}
+	received := make(chan request, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		data, _ := ioutil.ReadAll(req.Body)
+		body := make(map[string]interface{})
+		json.Unmarshal(data, &body)
+		received <- request{req.Header.Get("Content-Type"), body}
+	}))
+	defer srv.Close()
+	entry := &Entry{Type: TypeAPI, Action: "/rpcpb.Groups/GroupPut", Actor: "admin", Result: "OK"}
+
+	// assert that:
+	// - HTTP sinks POST the JSON entry
+	sink, err := ParseSink(srv.URL, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, sink.Write(entry))
+	r := <-received
+	assert.Equal(t, "application/json", r.contentType)
+	assert.Equal(t, "admin", r.body["actor"])
+
+	// - Kafka sinks POST REST proxy records
+	sink, err = ParseSink("kafka+"+srv.URL+"/topics/matchbox", nil)
+	assert.Nil(t, err)
+	assert.Nil(t, sink.Write(entry))
+	r = <-received
+	assert.Equal(t, "application/vnd.kafka.json.v2+json", r.contentType)
+	records := r.body["records"].([]interface{})
+	if assert.Len(t, records, 1) {
+		value := records[0].(map[string]interface{})["value"].(map[string]interface{})
+		assert.Equal(t, "/rpcpb.Groups/GroupPut", value["action"])
+	}
+}
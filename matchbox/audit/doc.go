@@ -0,0 +1,3 @@
+// Package audit records API calls and boot activity as audit entries and
+// exports them to syslog, HTTP, and Kafka sinks.
+package audit
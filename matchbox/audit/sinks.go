@@ -0,0 +1,139 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/syslog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Possible sink errors
+var (
+	ErrUnknownSink = errors.New("audit: unknown sink scheme (use syslog, http(s), or kafka+http(s))")
+	ErrKafkaTopic  = errors.New("audit: kafka sink requires a /topics/<topic> path")
+)
+
+// ParseSink returns the Sink described by a URL. Use "syslog:" for the
+// local syslog, "syslog://host:514" or "syslog+tcp://host:601" for a remote
+// syslog server, an http(s) URL to POST JSON entries, or
+// "kafka+http://proxy:8082/topics/<topic>" to produce to Kafka via a REST
+// proxy.
+func ParseSink(rawurl string, client *http.Client) (Sink, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "syslog", "syslog+udp", "syslog+tcp":
+		network := strings.TrimPrefix(u.Scheme, "syslog+")
+		if network == "syslog" {
+			network = "udp"
+		}
+		if u.Host == "" {
+			network = ""
+		}
+		return NewSyslogSink(network, u.Host)
+	case "http", "https":
+		return NewHTTPSink(u.String(), client), nil
+	case "kafka+http", "kafka+https":
+		if !strings.HasPrefix(u.Path, "/topics/") {
+			return nil, ErrKafkaTopic
+		}
+		u.Scheme = strings.TrimPrefix(u.Scheme, "kafka+")
+		return NewKafkaSink(u.String(), client), nil
+	}
+	return nil, ErrUnknownSink
+}
+
+// syslogSink writes entries as JSON syslog messages.
+type syslogSink struct {
+	writer *syslog.Writer
+}
+
+// NewSyslogSink returns a Sink which writes to the syslog server at raddr,
+// or the local syslog if network is empty.
+func NewSyslogSink(network, raddr string) (Sink, error) {
+	writer, err := syslog.Dial(network, raddr, syslog.LOG_INFO|syslog.LOG_AUTH, "matchbox")
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{writer: writer}, nil
+}
+
+func (s *syslogSink) Write(entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return s.writer.Info(string(data))
+}
+
+func (s *syslogSink) Close() error {
+	return s.writer.Close()
+}
+
+// httpSink POSTs entries as JSON.
+type httpSink struct {
+	url         string
+	contentType string
+	client      *http.Client
+	// encode wraps entries in the body expected by the endpoint
+	encode func(*Entry) interface{}
+}
+
+// NewHTTPSink returns a Sink which POSTs each entry as JSON to the URL.
+func NewHTTPSink(url string, client *http.Client) Sink {
+	return newHTTPSink(url, "application/json", client, func(entry *Entry) interface{} {
+		return entry
+	})
+}
+
+// NewKafkaSink returns a Sink which produces entries as JSON records to a
+// Kafka topic via a REST proxy topic URL (e.g. /topics/matchbox).
+func NewKafkaSink(topicURL string, client *http.Client) Sink {
+	type record struct {
+		Value *Entry `json:"value"`
+	}
+	type records struct {
+		Records []record `json:"records"`
+	}
+	return newHTTPSink(topicURL, "application/vnd.kafka.json.v2+json", client, func(entry *Entry) interface{} {
+		return &records{Records: []record{{Value: entry}}}
+	})
+}
+
+func newHTTPSink(url, contentType string, client *http.Client, encode func(*Entry) interface{}) Sink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &httpSink{
+		url:         url,
+		contentType: contentType,
+		client:      client,
+		encode:      encode,
+	}
+}
+
+func (s *httpSink) Write(entry *Entry) error {
+	data, err := json.Marshal(s.encode(entry))
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Post(s.url, s.contentType, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s", s.url, resp.Status)
+	}
+	return nil
+}
+
+func (s *httpSink) Close() error {
+	return nil
+}
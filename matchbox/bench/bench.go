@@ -0,0 +1,206 @@
+package bench
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Steps of a simulated boot.
+const (
+	StepIPXE     = "ipxe"
+	StepIgnition = "ignition"
+	StepAssets   = "assets"
+	StepBoot     = "boot"
+)
+
+// ErrURLRequired is returned when no target server URL is configured.
+var ErrURLRequired = errors.New("bench: A matchbox server URL is required")
+
+// Config configures a benchmark.
+type Config struct {
+	// matchbox HTTP server URL (e.g. http://matchbox.example.com:8080)
+	URL string
+	// number of machines to simulate
+	Machines int
+	// number of machines booting at once, all machines if zero
+	Concurrency int
+	// download the kernel and initrds served by the target
+	Assets bool
+	// (optional) HTTP client, http.DefaultClient if nil
+	Client *http.Client
+}
+
+// Run simulates machines performing the boot sequence against the target
+// server: fetching their iPXE script, their Ignition config, and (if
+// enabled) the kernel and initrd images the script references. Each
+// machine has a unique UUID and MAC address. Run returns the latencies of
+// each step once all machines have booted or the ctx is cancelled.
+func Run(ctx context.Context, config *Config) (*Report, error) {
+	target, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, err
+	}
+	if config.URL == "" || target.Host == "" {
+		return nil, ErrURLRequired
+	}
+	client := config.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	concurrency := config.Concurrency
+	if concurrency <= 0 || concurrency > config.Machines {
+		concurrency = config.Machines
+	}
+
+	report := newReport()
+	start := time.Now()
+	machines := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range machines {
+				m := &machine{
+					client: client,
+					target: target,
+					assets: config.Assets,
+					uuid:   machineUUID(n),
+					mac:    machineMAC(n),
+					report: report,
+				}
+				m.boot(ctx)
+			}
+		}()
+	}
+	for n := 0; n < config.Machines && ctx.Err() == nil; n++ {
+		machines <- n
+	}
+	close(machines)
+	wg.Wait()
+	report.Elapsed = time.Since(start)
+	return report, nil
+}
+
+// machine is a simulated machine.
+type machine struct {
+	client *http.Client
+	target *url.URL
+	assets bool
+	uuid   string
+	mac    string
+	report *Report
+}
+
+// boot performs the boot sequence of a machine, recording the latency of
+// each step and of the whole boot, which ends at the first failed step.
+func (m *machine) boot(ctx context.Context) {
+	start := time.Now()
+	query := url.Values{"uuid": {m.uuid}, "mac": {m.mac}}.Encode()
+	script, err := m.fetch(ctx, StepIPXE, "/ipxe?"+query)
+	if err != nil {
+		m.report.fail(StepBoot)
+		return
+	}
+	if _, err := m.fetch(ctx, StepIgnition, "/ignition?"+query); err != nil {
+		m.report.fail(StepBoot)
+		return
+	}
+	if m.assets {
+		for _, image := range ipxeImages(script) {
+			u, err := m.target.Parse(image)
+			if err != nil || u.Host != m.target.Host {
+				// only assets served by the target are measured
+				continue
+			}
+			if _, err := m.fetch(ctx, StepAssets, u.RequestURI()); err != nil {
+				m.report.fail(StepBoot)
+				return
+			}
+		}
+	}
+	m.report.observe(StepBoot, time.Since(start))
+}
+
+// fetch requests a path from the target and records the latency of the
+// step, including reading the response body. iPXE and Ignition responses
+// are returned, while assets are discarded as they are read.
+func (m *machine) fetch(ctx context.Context, step, path string) ([]byte, error) {
+	start := time.Now()
+	req, err := http.NewRequest("GET", m.target.Scheme+"://"+m.target.Host+path, nil)
+	if err != nil {
+		m.report.fail(step)
+		return nil, err
+	}
+	resp, err := m.client.Do(req.WithContext(ctx))
+	if err != nil {
+		m.report.fail(step)
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var body []byte
+	if step == StepAssets {
+		_, err = io.Copy(ioutil.Discard, resp.Body)
+	} else {
+		body, err = ioutil.ReadAll(resp.Body)
+	}
+	if err == nil && resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("bench: %s returned %s", path, resp.Status)
+	}
+	if err != nil {
+		m.report.fail(step)
+		return nil, err
+	}
+	m.report.observe(step, time.Since(start))
+	return body, nil
+}
+
+// ipxeImages returns the kernel and initrd URIs of an iPXE script.
+func ipxeImages(script []byte) []string {
+	var images []string
+	scanner := bufio.NewScanner(bytes.NewReader(script))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || (fields[0] != "kernel" && fields[0] != "initrd") {
+			continue
+		}
+		for i := 1; i < len(fields); i++ {
+			field := fields[i]
+			if field == "--name" || field == "-n" {
+				// skip the image name
+				i++
+				continue
+			}
+			if strings.HasPrefix(field, "-") {
+				continue
+			}
+			images = append(images, field)
+			if fields[0] == "kernel" {
+				// later fields are kernel arguments
+				break
+			}
+		}
+	}
+	return images
+}
+
+// machineUUID returns the UUID of the nth simulated machine.
+func machineUUID(n int) string {
+	return fmt.Sprintf("00000000-0000-4000-8000-%012x", n)
+}
+
+// machineMAC returns the locally administered MAC address of the nth
+// simulated machine.
+func machineMAC(n int) string {
+	return fmt.Sprintf("02:00:%02x:%02x:%02x:%02x", byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
@@ -0,0 +1,94 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	var mu sync.Mutex
+	uuids := make(map[string]bool)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ipxe", func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		uuids[req.URL.Query().Get("uuid")] = true
+		mu.Unlock()
+		fmt.Fprint(w, "#!ipxe\nkernel /assets/vmlinuz coreos.first_boot=1\ninitrd /assets/initrd.img http://mirror.example.com/other.img\nboot\n")
+	})
+	mux.HandleFunc("/ignition", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "{}")
+	})
+	mux.HandleFunc("/assets/", func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/assets/initrd.img" {
+			http.NotFound(w, req)
+			return
+		}
+		fmt.Fprint(w, "image")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	report, err := Run(context.Background(), &Config{URL: srv.URL, Machines: 10, Concurrency: 4, Assets: true})
+	assert.Nil(t, err)
+	// assert that:
+	// - each machine boots with a unique UUID
+	// - steps are measured until a step fails
+	// - assets not served by the target are skipped
+	assert.Len(t, uuids, 10)
+	assert.Equal(t, 10, report.Count(StepIPXE))
+	assert.Equal(t, 10, report.Count(StepIgnition))
+	assert.Equal(t, 10, report.Count(StepAssets))
+	assert.Equal(t, 10, report.Errors(StepAssets))
+	assert.Equal(t, 0, report.Count(StepBoot))
+	assert.Equal(t, 10, report.Errors(StepBoot))
+
+	var buf bytes.Buffer
+	assert.Nil(t, report.Write(&buf))
+	assert.Contains(t, buf.String(), "ignition")
+	assert.Contains(t, buf.String(), "0 machines booted")
+}
+
+func TestRun_URLRequired(t *testing.T) {
+	_, err := Run(context.Background(), &Config{Machines: 1})
+	assert.Equal(t, ErrURLRequired, err)
+}
+
+func TestIPXEImages(t *testing.T) {
+	script := []byte(`#!ipxe
+imgtrust --permanent
+kernel --name main /assets/vmlinuz initrd=main console=ttyS0
+imgverify vmlinuz /assets/vmlinuz.p7s
+initrd /assets/a.img /assets/b.img
+initrd -n extra http://matchbox.example.com/assets/c.img
+boot
+`)
+	// assert that:
+	// - kernel and initrd URIs are found, without names or kernel arguments
+	assert.Equal(t, []string{"/assets/vmlinuz", "/assets/a.img", "/assets/b.img", "http://matchbox.example.com/assets/c.img"}, ipxeImages(script))
+}
+
+func TestReport_Percentile(t *testing.T) {
+	report := newReport()
+	for i := 1; i <= 100; i++ {
+		report.observe(StepIPXE, time.Duration(i)*time.Millisecond)
+	}
+	// assert that:
+	// - percentiles are the nearest ranked latency
+	assert.Equal(t, 50*time.Millisecond, report.Percentile(StepIPXE, 50))
+	assert.Equal(t, 99*time.Millisecond, report.Percentile(StepIPXE, 99))
+	assert.Equal(t, 100*time.Millisecond, report.Percentile(StepIPXE, 100))
+	assert.Equal(t, time.Duration(0), report.Percentile(StepIgnition, 50))
+}
+
+func TestMachineMAC(t *testing.T) {
+	assert.Equal(t, "02:00:00:00:01:02", machineMAC(258))
+	assert.Equal(t, "00000000-0000-4000-8000-000000000102", machineUUID(258))
+}
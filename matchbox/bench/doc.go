@@ -0,0 +1,3 @@
+// Package bench simulates machines booting from a matchbox server, to
+// measure request latencies under load before a rollout.
+package bench
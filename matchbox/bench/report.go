@@ -0,0 +1,108 @@
+package bench
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// steps in report order
+var steps = []string{StepIPXE, StepIgnition, StepAssets, StepBoot}
+
+// Report is the result of a benchmark.
+type Report struct {
+	// time taken by the benchmark
+	Elapsed time.Duration
+
+	mu        sync.Mutex
+	latencies map[string][]time.Duration
+	errors    map[string]int
+}
+
+func newReport() *Report {
+	return &Report{
+		latencies: make(map[string][]time.Duration),
+		errors:    make(map[string]int),
+	}
+}
+
+// observe records the latency of a successful step.
+func (r *Report) observe(step string, latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latencies[step] = append(r.latencies[step], latency)
+}
+
+// fail records a failed step.
+func (r *Report) fail(step string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors[step]++
+}
+
+// Count returns the number of successful requests of a step (or boots).
+func (r *Report) Count(step string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.latencies[step])
+}
+
+// Errors returns the number of failed requests of a step (or boots).
+func (r *Report) Errors(step string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.errors[step]
+}
+
+// Percentile returns the latency of a step which the given percentage
+// (0-100) of successful requests were no slower than, or zero if there
+// were none.
+func (r *Report) Percentile(step string, percent float64) time.Duration {
+	r.mu.Lock()
+	latencies := make([]time.Duration, len(r.latencies[step]))
+	copy(latencies, r.latencies[step])
+	r.mu.Unlock()
+	if len(latencies) == 0 {
+		return 0
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	// nearest rank
+	rank := int(percent/100*float64(len(latencies)) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(latencies) {
+		rank = len(latencies)
+	}
+	return latencies[rank-1]
+}
+
+// Write writes the report as a table of request counts, errors, and
+// latency percentiles by step.
+func (r *Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tOK\tERRORS\tP50\tP90\tP99\tMAX")
+	for _, step := range steps {
+		if r.Count(step) == 0 && r.Errors(step) == 0 {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%v\t%v\t%v\t%v\n", step, r.Count(step), r.Errors(step),
+			round(r.Percentile(step, 50)), round(r.Percentile(step, 90)), round(r.Percentile(step, 99)), round(r.Percentile(step, 100)))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d machines booted in %v\n", r.Count(StepBoot), round(r.Elapsed))
+	return err
+}
+
+// round rounds latencies for display.
+func round(d time.Duration) time.Duration {
+	if d > time.Second {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(10 * time.Microsecond)
+}
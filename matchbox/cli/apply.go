@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"context"
+	"github.com/golang/protobuf/proto"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/coreos/matchbox/matchbox/client"
+	"github.com/coreos/matchbox/matchbox/manifest"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// applyCmd syncs a manifest directory tree to the server.
+var applyCmd = &cobra.Command{
+	Use:   "apply --filename DIR",
+	Short: "Create or update resources from a directory",
+	Long: `Create or update groups, profiles, and templates from a directory
+
+The directory uses the matchbox data directory layout: groups/*.json,
+profiles/*.json (or *.yaml), and templates in ignition/, cloud/, and
+generic/.
+Templates are applied first, then profiles, then groups. Resources of
+each kind are applied concurrently (--parallel).`,
+	Run: runApplyCmd,
+}
+
+// Apply results
+const (
+	applyCreated   = "created"
+	applyUpdated   = "updated"
+	applyUnchanged = "unchanged"
+)
+
+func init() {
+	RootCmd.AddCommand(applyCmd)
+	applyCmd.Flags().StringVarP(&flagFilename, "filename", "f", "", "directory of resources to apply")
+	applyCmd.Flags().IntVar(&flagParallel, "parallel", defaultParallel, "number of resources to apply concurrently")
+	applyCmd.MarkFlagRequired("filename")
+}
+
+func runApplyCmd(cmd *cobra.Command, args []string) {
+	if len(flagFilename) == 0 {
+		cmd.Help()
+		return
+	}
+	if err := validateArgs(cmd, args); err != nil {
+		return
+	}
+
+	m, err := manifest.Load(flagFilename)
+	if err != nil {
+		exitWithError(ExitBadArgs, err)
+	}
+	client := mustClientFromCmd(cmd)
+	ctx := context.TODO()
+
+	names := make([]string, len(m.Templates))
+	for i, tmpl := range m.Templates {
+		names[i] = tmpl.Kind + "/" + tmpl.Name
+	}
+	applyAll(names, func(i int) (string, error) {
+		return applyTemplate(ctx, client, m.Templates[i])
+	})
+	names = make([]string, len(m.Profiles))
+	for i, profile := range m.Profiles {
+		names[i] = "profile/" + profile.Id
+	}
+	applyAll(names, func(i int) (string, error) {
+		return applyProfile(ctx, client, m.Profiles[i])
+	})
+	names = make([]string, len(m.Groups))
+	for i, group := range m.Groups {
+		names[i] = "group/" + group.Id
+	}
+	applyAll(names, func(i int) (string, error) {
+		return applyGroup(ctx, client, m.Groups[i])
+	})
+}
+
+// applyAll applies the named resources concurrently and prints their
+// results in order, exiting at the first resource which failed.
+func applyAll(names []string, apply func(i int) (string, error)) {
+	results := make([]string, len(names))
+	errs := make([]error, len(names))
+	parallel(len(names), func(i int) {
+		results[i], errs[i] = apply(i)
+	})
+	for i, name := range names {
+		if errs[i] != nil {
+			exitWithError(ExitError, fmt.Errorf("%s: %v", name, errs[i]))
+		}
+		fmt.Fprintf(os.Stdout, "%s %s\n", name, results[i])
+	}
+}
+
+// applyProfile creates or updates a profile and returns the result.
+func applyProfile(ctx context.Context, client *client.Client, profile *storagepb.Profile) (string, error) {
+	result := applyCreated
+	resp, err := client.Profiles.ProfileGet(ctx, &pb.ProfileGetRequest{Id: profile.Id})
+	if err == nil {
+		result = changed(proto.Equal(resp.Profile, profile))
+	} else if grpc.Code(err) != codes.NotFound {
+		return "", err
+	}
+	if result != applyUnchanged {
+		resp, err := client.Profiles.ProfilePut(ctx, &pb.ProfilePutRequest{Profile: profile})
+		if err != nil {
+			return "", err
+		}
+		printWarnings(resp.Warnings)
+	}
+	return result, nil
+}
+
+// applyGroup creates or updates a group and returns the result.
+func applyGroup(ctx context.Context, client *client.Client, group *storagepb.Group) (string, error) {
+	result := applyCreated
+	resp, err := client.Groups.GroupGet(ctx, &pb.GroupGetRequest{Id: group.Id})
+	if err == nil {
+		result = changed(proto.Equal(resp.Group, group))
+	} else if grpc.Code(err) != codes.NotFound {
+		return "", err
+	}
+	if result != applyUnchanged {
+		resp, err := client.Groups.GroupPut(ctx, &pb.GroupPutRequest{Group: group})
+		if err != nil {
+			return "", err
+		}
+		printWarnings(resp.Warnings)
+	}
+	return result, nil
+}
+
+// applyTemplate creates or updates a template and returns the result.
+func applyTemplate(ctx context.Context, client *client.Client, tmpl *manifest.Template) (string, error) {
+	current, err := templateGet(ctx, client, tmpl.Kind, tmpl.Name)
+	result := applyCreated
+	if err == nil {
+		result = changed(bytes.Equal(current, tmpl.Contents))
+	} else if grpc.Code(err) != codes.NotFound {
+		return "", err
+	}
+	if result == applyUnchanged {
+		return result, nil
+	}
+
+	switch tmpl.Kind {
+	case manifest.KindIgnition:
+		_, err = client.Ignition.IgnitionPut(ctx, &pb.IgnitionPutRequest{Name: tmpl.Name, Config: tmpl.Contents})
+	case manifest.KindCloud:
+		_, err = client.Cloud.CloudPut(ctx, &pb.CloudPutRequest{Name: tmpl.Name, Config: tmpl.Contents})
+	case manifest.KindGeneric:
+		_, err = client.Generic.GenericPut(ctx, &pb.GenericPutRequest{Name: tmpl.Name, Config: tmpl.Contents})
+	}
+	return result, err
+}
+
+// templateGet gets the contents of a template of the given kind by name.
+func templateGet(ctx context.Context, client *client.Client, kind, name string) ([]byte, error) {
+	switch kind {
+	case manifest.KindIgnition:
+		resp, err := client.Ignition.IgnitionGet(ctx, &pb.IgnitionGetRequest{Name: name})
+		if err != nil {
+			return nil, err
+		}
+		return resp.Config, nil
+	case manifest.KindCloud:
+		resp, err := client.Cloud.CloudGet(ctx, &pb.CloudGetRequest{Name: name})
+		if err != nil {
+			return nil, err
+		}
+		return resp.Config, nil
+	case manifest.KindGeneric:
+		resp, err := client.Generic.GenericGet(ctx, &pb.GenericGetRequest{Name: name})
+		if err != nil {
+			return nil, err
+		}
+		return resp.Config, nil
+	}
+	return nil, fmt.Errorf("unknown template kind %q", kind)
+}
+
+// changed returns the apply result for an existing resource.
+func changed(equal bool) string {
+	if equal {
+		return applyUnchanged
+	}
+	return applyUpdated
+}
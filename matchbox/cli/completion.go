@@ -0,0 +1,309 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"context"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+var (
+	// completionCmd outputs shell completion scripts.
+	completionCmd = &cobra.Command{
+		Use:   "completion SHELL",
+		Short: "Output shell completion code (bash, zsh, or fish)",
+		Long: `Output shell completion code (bash, zsh, or fish)
+
+Completes commands, flags, and the names of groups, profiles, and machines,
+which are queried from the server using the endpoint and TLS flags on the
+command line.
+
+  # bash, add to ~/.bashrc
+  source <(bootcmd completion bash)
+
+  # zsh, add to ~/.zshrc after compinit
+  source <(bootcmd completion zsh)
+
+  # fish
+  bootcmd completion fish > ~/.config/fish/completions/bootcmd.fish`,
+		Run: runCompletionCmd,
+	}
+
+	// completeCmd prints candidates for the last of the given command line
+	// words. It is called by the completion scripts.
+	completeCmd = &cobra.Command{
+		Use:    "__complete -- WORDS...",
+		Hidden: true,
+		Run:    runCompleteCmd,
+	}
+
+	// argCompletions maps commands to how each positional argument is
+	// completed.
+	argCompletions = make(map[*cobra.Command][]argCompletion)
+)
+
+// argCompletion completes an argument with the names of a kind of resource
+// or from fixed values.
+type argCompletion struct {
+	kind   string
+	values []string
+}
+
+// Flag annotations which describe how flag values are completed.
+const (
+	completeValuesAnnotation = "bootcmd_complete_values"
+	completeNamesAnnotation  = "bootcmd_complete_names"
+)
+
+// completionTimeout bounds server queries for resource names.
+const completionTimeout = 3 * time.Second
+
+// completionScripts are the completion scripts for each shell. Each script
+// calls "__complete" with the words of the command line and falls back to
+// completing file paths when there are no candidates.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for %[1]s
+
+_%[1]s()
+{
+    local IFS=$'\n'
+    COMPREPLY=( $(%[1]s __complete -- "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null) )
+}
+
+complete -o default -F _%[1]s %[1]s
+`,
+	"zsh": `#compdef %[1]s
+
+_%[1]s()
+{
+    local -a completions
+    completions=("${(@f)$(%[1]s __complete -- "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    if [[ -n "${completions[1]}" ]]; then
+        compadd -a completions
+    else
+        _files
+    fi
+}
+
+if [ "$funcstack[1]" = "_%[1]s" ]; then
+    _%[1]s
+else
+    compdef _%[1]s %[1]s
+fi
+`,
+	"fish": `# fish completion for %[1]s
+
+function __%[1]s_complete
+    set -l args (commandline -opc)[2..-1] (commandline -ct)
+    set -g __%[1]s_completions (%[1]s __complete -- $args 2>/dev/null)
+    test -n "$__%[1]s_completions"
+end
+
+complete -c %[1]s -f -n __%[1]s_complete -a '$__%[1]s_completions'
+`,
+}
+
+func init() {
+	RootCmd.AddCommand(completionCmd)
+	RootCmd.AddCommand(completeCmd)
+	completionCmd.ValidArgs = []string{"bash", "fish", "zsh"}
+}
+
+func runCompletionCmd(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		exitWithError(ExitBadArgs, usageError(cmd, "expected a shell, one of bash, zsh, or fish"))
+	}
+	script, ok := completionScripts[args[0]]
+	if !ok {
+		exitWithError(ExitBadArgs, usageError(cmd, "unsupported shell %q, must be one of bash, zsh, or fish", args[0]))
+	}
+	fmt.Fprintf(os.Stdout, script, RootCmd.Name())
+}
+
+func runCompleteCmd(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		return
+	}
+	for _, candidate := range completions(args[:len(args)-1], args[len(args)-1]) {
+		fmt.Fprintln(os.Stdout, candidate)
+	}
+}
+
+// completeArgNames completes the next positional argument of a command with
+// the names of a kind of resource.
+func completeArgNames(cmd *cobra.Command, kind string) {
+	argCompletions[cmd] = append(argCompletions[cmd], argCompletion{kind: kind})
+}
+
+// completeArgValues completes the next positional argument of a command from
+// a fixed list.
+func completeArgValues(cmd *cobra.Command, values ...string) {
+	argCompletions[cmd] = append(argCompletions[cmd], argCompletion{values: values})
+}
+
+// completeFlagValues completes the values of a flag from a fixed list.
+func completeFlagValues(cmd *cobra.Command, name string, values ...string) {
+	cmd.Flags().SetAnnotation(name, completeValuesAnnotation, values)
+}
+
+// completeFlagNames completes the values of a flag with the names of a kind
+// of resource.
+func completeFlagNames(cmd *cobra.Command, name, kind string) {
+	cmd.Flags().SetAnnotation(name, completeNamesAnnotation, []string{kind})
+}
+
+// completions returns the candidates for the word being completed, given
+// the preceding command line words.
+func completions(words []string, toComplete string) []string {
+	cmd, args, err := RootCmd.Find(words)
+	if err != nil {
+		return nil
+	}
+	// the previous word is a flag which takes a value
+	if len(args) > 0 {
+		if flag := lookupFlag(cmd, args[len(args)-1]); flag != nil && flag.Value.Type() != "bool" {
+			return withPrefix(flagValues(cmd, args, flag), toComplete)
+		}
+	}
+	if strings.HasPrefix(toComplete, "-") {
+		return withPrefix(flagNames(cmd), toComplete)
+	}
+	if cmd.HasSubCommands() {
+		var names []string
+		for _, sub := range cmd.Commands() {
+			if sub.IsAvailableCommand() {
+				names = append(names, sub.Name())
+			}
+		}
+		return withPrefix(names, toComplete)
+	}
+	if len(cmd.ValidArgs) > 0 {
+		return withPrefix(cmd.ValidArgs, toComplete)
+	}
+	position := len(positionalArgs(cmd, args))
+	if position < len(argCompletions[cmd]) {
+		completion := argCompletions[cmd][position]
+		if completion.kind != "" {
+			return withPrefix(resourceNames(cmd, args, completion.kind), toComplete)
+		}
+		return withPrefix(completion.values, toComplete)
+	}
+	return nil
+}
+
+// lookupFlag returns the flag named by a "--name" or "-n" word, or nil.
+// Words which already include a value (e.g. "--name=value") return nil.
+func lookupFlag(cmd *cobra.Command, word string) *pflag.Flag {
+	if !strings.HasPrefix(word, "-") || strings.Contains(word, "=") {
+		return nil
+	}
+	var found *pflag.Flag
+	match := func(flag *pflag.Flag) {
+		if word == "--"+flag.Name || (flag.Shorthand != "" && word == "-"+flag.Shorthand) {
+			found = flag
+		}
+	}
+	cmd.Flags().VisitAll(match)
+	cmd.InheritedFlags().VisitAll(match)
+	return found
+}
+
+// flagNames returns the "--name" of each flag of a command.
+func flagNames(cmd *cobra.Command) []string {
+	var names []string
+	add := func(flag *pflag.Flag) {
+		if !flag.Hidden {
+			names = append(names, "--"+flag.Name)
+		}
+	}
+	cmd.Flags().VisitAll(add)
+	cmd.InheritedFlags().VisitAll(add)
+	sort.Strings(names)
+	return names
+}
+
+// flagValues returns the candidate values of a flag.
+func flagValues(cmd *cobra.Command, args []string, flag *pflag.Flag) []string {
+	if values, ok := flag.Annotations[completeValuesAnnotation]; ok {
+		return values
+	}
+	if kinds, ok := flag.Annotations[completeNamesAnnotation]; ok && len(kinds) == 1 {
+		return resourceNames(cmd, args, kinds[0])
+	}
+	return nil
+}
+
+// positionalArgs returns args which are not flags or flag values.
+func positionalArgs(cmd *cobra.Command, args []string) []string {
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "-") {
+			positional = append(positional, args[i])
+			continue
+		}
+		if flag := lookupFlag(cmd, args[i]); flag != nil && flag.Value.Type() != "bool" {
+			// skip the flag's value
+			i++
+		}
+	}
+	return positional
+}
+
+// resourceNames queries the server for the names of a kind of resource.
+// The endpoint and TLS flags among the command line args are respected.
+func resourceNames(cmd *cobra.Command, args []string, kind string) []string {
+	// best effort, the incomplete command line may not parse
+	cmd.ParseFlags(args)
+	client := mustClientFromCmd(cmd)
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+
+	var names []string
+	switch kind {
+	case "group":
+		resp, err := client.Groups.GroupList(ctx, &pb.GroupListRequest{})
+		if err != nil {
+			return nil
+		}
+		for _, group := range resp.Groups {
+			names = append(names, group.Id)
+		}
+	case "profile":
+		resp, err := client.Profiles.ProfileList(ctx, &pb.ProfileListRequest{})
+		if err != nil {
+			return nil
+		}
+		for _, profile := range resp.Profiles {
+			names = append(names, profile.Id)
+		}
+	case "machine":
+		resp, err := client.Machines.MachineList(ctx, &pb.MachineListRequest{})
+		if err != nil {
+			return nil
+		}
+		for _, machine := range resp.Machines {
+			names = append(names, machine.Id)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// withPrefix returns the candidates which start with prefix.
+func withPrefix(candidates []string, prefix string) []string {
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			matches = append(matches, candidate)
+		}
+	}
+	return matches
+}
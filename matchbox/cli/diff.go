@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"context"
+	"github.com/spf13/cobra"
+
+	"github.com/coreos/matchbox/matchbox/manifest"
+)
+
+// diffCmd compares a manifest directory tree to the server.
+var (
+	diffCmd = &cobra.Command{
+		Use:   "diff --filename DIR",
+		Short: "Compare resources in a directory to the server",
+		Long: `Compare groups, profiles, and templates in a directory to the server
+
+Shows the changes "bootcmd apply" would make. Groups and profiles are
+compared field by field and templates line by line. Resources which are
+only on the server are listed as unmanaged. Exits with status 1 if apply
+would make changes.`,
+		Run: runDiffCmd,
+	}
+	flagColor string
+)
+
+// ANSI terminal colors
+const (
+	colorReset  = "\x1b[0m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorCyan   = "\x1b[36m"
+)
+
+func init() {
+	RootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().StringVarP(&flagFilename, "filename", "f", "", "directory of resources to compare")
+	diffCmd.Flags().StringVar(&flagColor, "color", "auto", "colorize output (auto, always, never)")
+	diffCmd.Flags().IntVar(&flagParallel, "parallel", defaultParallel, "number of templates to fetch concurrently")
+	completeFlagValues(diffCmd, "color", "auto", "always", "never")
+	diffCmd.MarkFlagRequired("filename")
+}
+
+func runDiffCmd(cmd *cobra.Command, args []string) {
+	if len(flagFilename) == 0 {
+		cmd.Help()
+		return
+	}
+	if err := validateArgs(cmd, args); err != nil {
+		return
+	}
+	color, err := useColor(flagColor, os.Stdout)
+	if err != nil {
+		exitWithError(ExitBadArgs, err)
+	}
+
+	local, err := manifest.Load(flagFilename)
+	if err != nil {
+		exitWithError(ExitBadArgs, err)
+	}
+	client := mustClientFromCmd(cmd)
+	remote, err := fetchManifest(context.TODO(), client)
+	if err != nil {
+		exitWithError(ExitError, err)
+	}
+	changes, err := manifest.Diff(local, remote)
+	if err != nil {
+		exitWithError(ExitError, err)
+	}
+
+	pending := false
+	for _, change := range changes {
+		switch change.Op {
+		case manifest.DiffAdded:
+			pending = true
+			printLine(color, colorGreen, fmt.Sprintf("+ %s (%s)", change.Resource, change.Op))
+		case manifest.DiffModified:
+			pending = true
+			printLine(color, colorYellow, fmt.Sprintf("~ %s (%s)", change.Resource, change.Op))
+		case manifest.DiffUnmanaged:
+			printLine(color, colorCyan, fmt.Sprintf("  %s (%s)", change.Resource, change.Op))
+		}
+		for _, line := range change.Lines {
+			lineColor := colorGreen
+			if line[0] == '-' {
+				lineColor = colorRed
+			}
+			printLine(color, lineColor, "    "+line)
+		}
+	}
+	if pending {
+		os.Exit(ExitError)
+	}
+}
+
+// useColor returns whether to colorize output for the --color mode. In auto
+// mode, output to a terminal is colorized.
+func useColor(mode string, f *os.File) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		info, err := f.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0, nil
+	}
+	return false, fmt.Errorf("invalid --color %q, must be auto, always, or never", mode)
+}
+
+// printLine prints a line of output, in the given color if color is enabled.
+func printLine(color bool, code, line string) {
+	if color {
+		line = code + line + colorReset
+	}
+	fmt.Fprintln(os.Stdout, line)
+}
@@ -21,6 +21,14 @@ func exitWithError(code int, err error) {
 	os.Exit(code)
 }
 
+// printWarnings prints the server's warnings, such as uses of deprecated
+// fields, to stderr.
+func printWarnings(warnings []string) {
+	for _, warning := range warnings {
+		fmt.Fprintln(os.Stderr, "Warning:", warning)
+	}
+}
+
 func usageError(cmd *cobra.Command, format string, args ...interface{}) error {
 	msg := fmt.Sprintf(format, args...)
 	return fmt.Errorf("%s\nSee '%s -h' for help", msg, cmd.CommandPath())
@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"context"
+	"github.com/spf13/cobra"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// eventsCmd lists or watches boot and provisioning events.
+var (
+	eventsCmd = &cobra.Command{
+		Use:   "events",
+		Short: "List or watch machines request boot endpoints",
+		Long: `List or watch machines request boot endpoints
+
+Lists the requests machines made to the iPXE, GRUB, Ignition, Cloud-Config,
+generic, metadata, progress, and completion endpoints, from the event history
+the server keeps for -event-retention. Filter events by machine, type (boot,
+config, progress, or complete), and time, where times are RFC 3339 times or
+durations before now (e.g. --since 2026-10-13T20:00:00Z --until 12h).
+
+With --follow, prints a line for each request machines make to the iPXE,
+GRUB, Ignition, Cloud-Config, generic, and metadata endpoints, as they
+happen.`,
+		Run: runEventsCmd,
+	}
+	flagFollow       bool
+	flagEventMachine string
+	flagEventType    string
+	flagEventSince   string
+	flagEventUntil   string
+	flagEventLimit   int32
+)
+
+func init() {
+	RootCmd.AddCommand(eventsCmd)
+	addOutputFlag(eventsCmd)
+	eventsCmd.Flags().BoolVarP(&flagFollow, "follow", "w", false, "stream events as they happen")
+	eventsCmd.Flags().StringVar(&flagEventMachine, "machine", "", "only list events of the machine uuid (or mac)")
+	eventsCmd.Flags().StringVar(&flagEventType, "type", "", "only list events of the type (boot, config, progress, or complete)")
+	eventsCmd.Flags().StringVar(&flagEventSince, "since", "", "only list events at or after the time or duration ago")
+	eventsCmd.Flags().StringVar(&flagEventUntil, "until", "", "only list events before the time or duration ago")
+	eventsCmd.Flags().Int32Var(&flagEventLimit, "limit", 0, "only list the most recent events (0 for no limit)")
+	completeFlagValues(eventsCmd, "type", storagepb.EventBoot, storagepb.EventConfig, storagepb.EventProgress, storagepb.EventComplete)
+}
+
+func runEventsCmd(cmd *cobra.Command, args []string) {
+	if err := validateArgs(cmd, args); err != nil {
+		exitWithError(ExitBadArgs, err)
+	}
+	if !flagFollow {
+		runEventsListCmd(cmd)
+		return
+	}
+
+	client := mustClientFromCmd(cmd)
+	stream, err := client.Events.Watch(context.TODO(), &pb.BootEventsRequest{})
+	if err != nil {
+		exitWithError(ExitError, err)
+	}
+	fmt.Fprintf(os.Stdout, eventFormat, "TIME", "ENDPOINT", "STATUS", "MACHINE", "GROUP", "PROFILE", "REMOTE")
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			exitWithError(ExitError, err)
+		}
+		fmt.Fprintf(os.Stdout, eventFormat, event.Time, event.Endpoint, fmt.Sprint(event.Status), event.MachineId, event.Group, event.Profile, event.RemoteIp)
+	}
+}
+
+// runEventsListCmd lists the events recorded in the event history.
+func runEventsListCmd(cmd *cobra.Command) {
+	validateOutputFlag(cmd)
+	since, err := eventTime(flagEventSince)
+	if err != nil {
+		exitWithError(ExitBadArgs, usageError(cmd, "invalid --since: %v", err))
+	}
+	until, err := eventTime(flagEventUntil)
+	if err != nil {
+		exitWithError(ExitBadArgs, usageError(cmd, "invalid --until: %v", err))
+	}
+
+	client := mustClientFromCmd(cmd)
+	resp, err := client.Events.EventList(context.TODO(), &pb.EventListRequest{
+		MachineId: flagEventMachine,
+		Type:      flagEventType,
+		Since:     since,
+		Until:     until,
+		Limit:     flagEventLimit,
+	})
+	if err != nil {
+		exitWithError(ExitError, err)
+	}
+	resources := make([]resource, len(resp.Events))
+	for i, event := range resp.Events {
+		resources[i] = resource{kind: "event", id: event.RequestId, value: event}
+	}
+	mustPrint(resources, false, func(w io.Writer) {
+		tw := newTabWriter(w)
+		defer tw.Flush()
+		// legend
+		fmt.Fprintf(tw, "TIME\tTYPE\tENDPOINT\tSTATUS\tMACHINE\tGROUP\tPROFILE\tREMOTE\n")
+		for _, event := range resp.Events {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n", event.Time, event.Type, event.Endpoint, event.Status, event.MachineId, event.Group, event.Profile, event.RemoteIp)
+		}
+	})
+}
+
+// eventTime returns the RFC 3339 time of an event range flag, which is a
+// time or a duration before now.
+func eventTime(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().UTC().Add(-d).Format(time.RFC3339), nil
+	}
+	if _, err := time.Parse(time.RFC3339, value); err != nil {
+		return "", fmt.Errorf("%q is not an RFC 3339 time or a duration", value)
+	}
+	return value, nil
+}
+
+// eventFormat formats event columns. Events are printed as they arrive, so
+// columns have fixed widths rather than being aligned by a tabwriter.
+const eventFormat = "%-20s  %-9s  %-6s  %-36s  %-16s  %-16s  %s\n"
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"context"
+	"github.com/spf13/cobra"
+
+	"github.com/coreos/matchbox/matchbox/client"
+	"github.com/coreos/matchbox/matchbox/manifest"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// exportCmd writes the server's resources to a directory tree.
+var (
+	exportCmd = &cobra.Command{
+		Use:   "export --output DIR",
+		Short: "Export resources to a directory",
+		Long: `Export all groups, profiles, and templates to a directory
+
+The directory uses the matchbox data directory layout read by "bootcmd apply"
+and the matchbox -data-path, so exports can be used for backups and to
+migrate between store backends.`,
+		Run: runExportCmd,
+	}
+	flagOutput string
+)
+
+func init() {
+	RootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringVarP(&flagOutput, "output", "o", "", "directory to export resources into")
+	exportCmd.Flags().IntVar(&flagParallel, "parallel", defaultParallel, "number of templates to fetch concurrently")
+	exportCmd.MarkFlagRequired("output")
+}
+
+func runExportCmd(cmd *cobra.Command, args []string) {
+	if len(flagOutput) == 0 {
+		cmd.Help()
+		return
+	}
+	if err := validateArgs(cmd, args); err != nil {
+		return
+	}
+
+	client := mustClientFromCmd(cmd)
+	m, err := fetchManifest(context.TODO(), client)
+	if err != nil {
+		exitWithError(ExitError, err)
+	}
+	if err := manifest.Write(flagOutput, m); err != nil {
+		exitWithError(ExitError, err)
+	}
+	fmt.Fprintf(os.Stdout, "exported %d groups, %d profiles, and %d templates to %s\n", len(m.Groups), len(m.Profiles), len(m.Templates), flagOutput)
+}
+
+// fetchManifest reads all groups, profiles, and templates from the server.
+func fetchManifest(ctx context.Context, client *client.Client) (*manifest.Manifest, error) {
+	groups, err := client.Groups.GroupList(ctx, &pb.GroupListRequest{})
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := client.Profiles.ProfileList(ctx, &pb.ProfileListRequest{})
+	if err != nil {
+		return nil, err
+	}
+	m := &manifest.Manifest{
+		Groups:   groups.Groups,
+		Profiles: profiles.Profiles,
+	}
+	for _, kind := range manifest.Kinds {
+		names, err := templateList(ctx, client, kind)
+		if err != nil {
+			return nil, err
+		}
+		templates := make([]*manifest.Template, len(names))
+		errs := make([]error, len(names))
+		parallel(len(names), func(i int) {
+			contents, err := templateGet(ctx, client, kind, names[i])
+			templates[i] = &manifest.Template{Kind: kind, Name: names[i], Contents: contents}
+			errs[i] = err
+		})
+		for i, err := range errs {
+			if err != nil {
+				return nil, fmt.Errorf("%s/%s: %v", kind, names[i], err)
+			}
+		}
+		m.Templates = append(m.Templates, templates...)
+	}
+	return m, nil
+}
+
+// templateList lists the names of the templates of the given kind.
+func templateList(ctx context.Context, client *client.Client, kind string) ([]string, error) {
+	switch kind {
+	case manifest.KindIgnition:
+		resp, err := client.Ignition.IgnitionList(ctx, &pb.IgnitionListRequest{})
+		if err != nil {
+			return nil, err
+		}
+		return resp.Names, nil
+	case manifest.KindCloud:
+		resp, err := client.Cloud.CloudList(ctx, &pb.CloudListRequest{})
+		if err != nil {
+			return nil, err
+		}
+		return resp.Names, nil
+	case manifest.KindGeneric:
+		resp, err := client.Generic.GenericList(ctx, &pb.GenericListRequest{})
+		if err != nil {
+			return nil, err
+		}
+		return resp.Names, nil
+	}
+	return nil, fmt.Errorf("unknown template kind %q", kind)
+}
@@ -24,7 +24,7 @@ func init() {
 	groupCmd.AddCommand(groupPutCmd)
 	groupPutCmd.Flags().StringVarP(&flagFilename, "filename", "f", "", "filename to use to create a Group")
 	groupPutCmd.MarkFlagRequired("filename")
-	groupPutCmd.MarkFlagFilename("filename", "json")
+	groupPutCmd.MarkFlagFilename("filename", "json", "yaml", "yml")
 }
 
 func runGroupPutCmd(cmd *cobra.Command, args []string) {
@@ -42,10 +42,11 @@ func runGroupPutCmd(cmd *cobra.Command, args []string) {
 		exitWithError(ExitError, err)
 	}
 	req := &pb.GroupPutRequest{Group: group}
-	_, err = client.Groups.GroupPut(context.TODO(), req)
+	resp, err := client.Groups.GroupPut(context.TODO(), req)
 	if err != nil {
 		exitWithError(ExitError, err)
 	}
+	printWarnings(resp.Warnings)
 }
 
 func loadGroup(filename string) (*storagepb.Group, error) {
@@ -53,5 +54,5 @@ func loadGroup(filename string) (*storagepb.Group, error) {
 	if err != nil {
 		return nil, err
 	}
-	return storagepb.ParseGroup(data)
+	return storagepb.ParseGroupFile(filename, data)
 }
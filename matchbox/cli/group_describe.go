@@ -2,12 +2,13 @@ package cli
 
 import (
 	"fmt"
-	"os"
+	"io"
 
 	"context"
 	"github.com/spf13/cobra"
 
 	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
 )
 
 // groupDescribeCmd describes a Group.
@@ -20,6 +21,8 @@ var groupDescribeCmd = &cobra.Command{
 
 func init() {
 	groupCmd.AddCommand(groupDescribeCmd)
+	completeArgNames(groupDescribeCmd, "group")
+	addOutputFlag(groupDescribeCmd)
 }
 
 func runGroupDescribeCmd(cmd *cobra.Command, args []string) {
@@ -27,12 +30,7 @@ func runGroupDescribeCmd(cmd *cobra.Command, args []string) {
 		cmd.Help()
 		return
 	}
-
-	tw := newTabWriter(os.Stdout)
-	defer tw.Flush()
-
-	// legend
-	fmt.Fprintf(tw, "ID\tNAME\tSELECTORS\tPROFILE\tMETADATA\n")
+	validateOutputFlag(cmd)
 
 	client := mustClientFromCmd(cmd)
 	request := &pb.GroupGetRequest{
@@ -40,8 +38,32 @@ func runGroupDescribeCmd(cmd *cobra.Command, args []string) {
 	}
 	resp, err := client.Groups.GroupGet(context.TODO(), request)
 	if err != nil {
-		return
+		exitWithError(ExitError, err)
+	}
+	printGroup(resp.Group)
+}
+
+func printGroup(g *storagepb.Group) {
+	resources, err := groupResources([]*storagepb.Group{g})
+	if err != nil {
+		exitWithError(ExitError, err)
+	}
+	mustPrint(resources, true, func(w io.Writer) {
+		tw := newTabWriter(w)
+		defer tw.Flush()
+		// legend
+		fmt.Fprintf(tw, "ID\tNAME\tSELECTORS\tPROFILE\tROLLOUT\tMETADATA\n")
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%#v\t%s\t%s\n", g.Id, g.Name, g.Selector, g.Profile, rolloutString(g.Rollout), g.Metadata)
+	})
+}
+
+// rolloutString describes a Group's Rollout, or returns "" if it has none.
+func rolloutString(r *storagepb.Rollout) string {
+	if r == nil {
+		return ""
+	}
+	if r.BatchSize > 0 {
+		return fmt.Sprintf("%s from %s, %d every %s", r.Profile, r.Start, r.BatchSize, r.Interval)
 	}
-	g := resp.Group
-	fmt.Fprintf(tw, "%s\t%s\t%s\t%#v\t%s\n", g.Id, g.Name, g.Selector, g.Profile, g.Metadata)
+	return fmt.Sprintf("%s from %s", r.Profile, r.Start)
 }
@@ -2,12 +2,13 @@ package cli
 
 import (
 	"fmt"
-	"os"
+	"io"
 
 	"context"
 	"github.com/spf13/cobra"
 
 	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
 )
 
 // groupListCmd lists Groups.
@@ -20,20 +21,50 @@ var groupListCmd = &cobra.Command{
 
 func init() {
 	groupCmd.AddCommand(groupListCmd)
+	addOutputFlag(groupListCmd)
+	addSelectorFlag(groupListCmd)
 }
 
 func runGroupListCmd(cmd *cobra.Command, args []string) {
-	tw := newTabWriter(os.Stdout)
-	defer tw.Flush()
-	// legend
-	fmt.Fprintf(tw, "ID\tGROUP NAME\tSELECTORS\tPROFILE\n")
+	validateOutputFlag(cmd)
+	selector := mustSelector()
 
 	client := mustClientFromCmd(cmd)
 	resp, err := client.Groups.GroupList(context.TODO(), &pb.GroupListRequest{})
 	if err != nil {
-		return
+		exitWithError(ExitError, err)
 	}
+	var groups []*storagepb.Group
 	for _, group := range resp.Groups {
-		fmt.Fprintf(tw, "%s\t%s\t%#v\t%s\n", group.Id, group.Name, group.Selector, group.Profile)
+		if selectorMatches(selector, group.Selector) {
+			groups = append(groups, group)
+		}
 	}
+	resources, err := groupResources(groups)
+	if err != nil {
+		exitWithError(ExitError, err)
+	}
+	mustPrint(resources, false, func(w io.Writer) {
+		tw := newTabWriter(w)
+		defer tw.Flush()
+		// legend
+		fmt.Fprintf(tw, "ID\tGROUP NAME\tSELECTORS\tPROFILE\n")
+		for _, group := range groups {
+			fmt.Fprintf(tw, "%s\t%s\t%#v\t%s\n", group.Id, group.Name, group.Selector, group.Profile)
+		}
+	})
+}
+
+// groupResources returns printable resources for Groups, which are output
+// as RichGroups so metadata is readable.
+func groupResources(groups []*storagepb.Group) ([]resource, error) {
+	resources := make([]resource, len(groups))
+	for i, group := range groups {
+		richGroup, err := group.ToRichGroup()
+		if err != nil {
+			return nil, err
+		}
+		resources[i] = resource{kind: "group", id: group.Id, value: richGroup}
+	}
+	return resources, nil
 }
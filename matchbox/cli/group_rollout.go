@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"time"
+
+	"context"
+	"github.com/spf13/cobra"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// groupRolloutCmd stages a Group's change to another Profile.
+var (
+	groupRolloutCmd = &cobra.Command{
+		Use:   "rollout GROUP_ID PROFILE_ID",
+		Short: "Stage a rollout of a profile to a group",
+		Long: `Stage a rollout of a profile to a group
+
+From --start, machines matching the group are served PROFILE_ID instead of
+the group's profile on their next boot. With --batch-size, machines are
+admitted in waves of that many machines every --interval, so an upgrade
+rolls across a fleet in controlled waves. Once every machine is upgraded,
+set the group's profile and use --cancel to remove the rollout.
+
+  # upgrade 10 workers every 30 minutes from 22:00 UTC
+  bootcmd group rollout workers worker-next --start 2026-10-15T22:00:00Z --batch-size 10 --interval 30m`,
+		Run: runGroupRolloutCmd,
+	}
+	flagRolloutStart     string
+	flagRolloutBatchSize int32
+	flagRolloutInterval  time.Duration
+	flagRolloutCancel    bool
+)
+
+func init() {
+	groupCmd.AddCommand(groupRolloutCmd)
+	addOutputFlag(groupRolloutCmd)
+	groupRolloutCmd.Flags().StringVar(&flagRolloutStart, "start", "", "time the first wave starts (RFC 3339), defaults to now")
+	groupRolloutCmd.Flags().Int32Var(&flagRolloutBatchSize, "batch-size", 0, "machines admitted per wave, all machines if 0")
+	groupRolloutCmd.Flags().DurationVar(&flagRolloutInterval, "interval", 0, "duration between waves, required with --batch-size")
+	groupRolloutCmd.Flags().BoolVar(&flagRolloutCancel, "cancel", false, "remove the group's rollout")
+	completeArgNames(groupRolloutCmd, "group")
+	completeArgNames(groupRolloutCmd, "profile")
+}
+
+func runGroupRolloutCmd(cmd *cobra.Command, args []string) {
+	if (flagRolloutCancel && len(args) != 1) || (!flagRolloutCancel && len(args) != 2) {
+		cmd.Help()
+		return
+	}
+	validateOutputFlag(cmd)
+
+	client := mustClientFromCmd(cmd)
+	resp, err := client.Groups.GroupGet(context.TODO(), &pb.GroupGetRequest{Id: args[0]})
+	if err != nil {
+		exitWithError(ExitError, err)
+	}
+	group := resp.Group
+	if flagRolloutCancel {
+		group.Rollout = nil
+	} else {
+		start := flagRolloutStart
+		if start == "" {
+			start = time.Now().UTC().Format(time.RFC3339)
+		}
+		group.Rollout = &storagepb.Rollout{
+			Profile:   args[1],
+			Start:     start,
+			BatchSize: flagRolloutBatchSize,
+		}
+		if flagRolloutInterval > 0 {
+			group.Rollout.Interval = flagRolloutInterval.String()
+		}
+		if err := group.Rollout.AssertValid(); err != nil {
+			exitWithError(ExitBadArgs, err)
+		}
+	}
+	_, err = client.Groups.GroupPut(context.TODO(), &pb.GroupPutRequest{Group: group})
+	if err != nil {
+		exitWithError(ExitError, err)
+	}
+	printGroup(group)
+}
@@ -0,0 +1,17 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// machineCmd represents the machine command
+var machineCmd = &cobra.Command{
+	Use:     "machine",
+	Aliases: []string{"instance"},
+	Short:   "Manage observed machines",
+	Long:    `Manage observed machines`,
+}
+
+func init() {
+	RootCmd.AddCommand(machineCmd)
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"context"
+	"github.com/spf13/cobra"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// machineAdoptCmd adopts a pending Machine into a Group.
+var machineAdoptCmd = &cobra.Command{
+	Use:   "adopt MACHINE_ID|MAC GROUP_ID",
+	Short: "Adopt a pending machine into a group",
+	Long: `Adopt a pending machine into a group
+
+Machines which match no group are served the holding profile and recorded as
+pending (see machine list --state pending). The machine (found by id or by
+its MAC address) is pinned to the group, so it is served the group's profile
+on its next network boot.`,
+	Run: runMachineAdoptCmd,
+}
+
+func init() {
+	machineCmd.AddCommand(machineAdoptCmd)
+	addOutputFlag(machineAdoptCmd)
+	completeArgNames(machineAdoptCmd, "machine")
+	completeArgNames(machineAdoptCmd, "group")
+}
+
+func runMachineAdoptCmd(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		cmd.Help()
+		return
+	}
+	validateOutputFlag(cmd)
+
+	client := mustClientFromCmd(cmd)
+	req := &pb.MachineAdoptRequest{Id: args[0], Group: args[1]}
+	resp, err := client.Machines.MachineAdopt(context.TODO(), req)
+	if err != nil {
+		exitWithError(ExitError, err)
+	}
+	printMachine(resp.Machine)
+}
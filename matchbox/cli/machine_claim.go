@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"context"
+	"github.com/spf13/cobra"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// machineClaimCmd claims an unclaimed Machine.
+var (
+	machineClaimCmd = &cobra.Command{
+		Use:   "claim OWNER",
+		Short: "Claim an unclaimed machine",
+		Long: `Claim an unclaimed machine
+
+Claims the first unclaimed machine whose labels and facts match the
+--selector for OWNER (e.g. a Cluster API Machine) and pins it to --group.
+Claiming again with the same OWNER returns the machine already claimed.`,
+		Run: runMachineClaimCmd,
+	}
+	flagClaimGroup string
+)
+
+func init() {
+	machineCmd.AddCommand(machineClaimCmd)
+	addOutputFlag(machineClaimCmd)
+	machineClaimCmd.Flags().StringSliceVarP(&flagSelector, "selector", "l", nil, "only claim machines with the given KEY=VALUE labels or facts")
+	machineClaimCmd.Flags().StringVar(&flagClaimGroup, "group", "", "group to pin the claimed machine to")
+	completeFlagNames(machineClaimCmd, "group", "group")
+}
+
+func runMachineClaimCmd(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Help()
+		return
+	}
+	validateOutputFlag(cmd)
+
+	req := &pb.MachineClaimRequest{
+		Selector: mustSelector(),
+		Group:    flagClaimGroup,
+		Owner:    args[0],
+	}
+	client := mustClientFromCmd(cmd)
+	resp, err := client.Machines.MachineClaim(context.TODO(), req)
+	if err != nil {
+		exitWithError(ExitError, err)
+	}
+	printMachine(resp.Machine)
+}
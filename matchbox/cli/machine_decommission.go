@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"context"
+	"github.com/spf13/cobra"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// machineDecommissionCmd marks a Machine to be decommissioned.
+var machineDecommissionCmd = &cobra.Command{
+	Use:   "decommission MACHINE_ID|MAC PROFILE_ID",
+	Short: "Decommission a machine with a profile",
+	Long: `Decommission a machine with a profile
+
+The machine (found by id or by its MAC address) is served the profile, such
+as a disk wipe or secure erase, on its next network boot instead of its
+group's. When the machine reports it is complete, its record and completion
+payload are archived and it is removed from the machines.`,
+	Run: runMachineDecommissionCmd,
+}
+
+func init() {
+	machineCmd.AddCommand(machineDecommissionCmd)
+	addOutputFlag(machineDecommissionCmd)
+	completeArgNames(machineDecommissionCmd, "machine")
+	completeArgNames(machineDecommissionCmd, "profile")
+}
+
+func runMachineDecommissionCmd(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		cmd.Help()
+		return
+	}
+	validateOutputFlag(cmd)
+
+	client := mustClientFromCmd(cmd)
+	req := &pb.MachineDecommissionRequest{Id: args[0], Profile: args[1]}
+	resp, err := client.Machines.MachineDecommission(context.TODO(), req)
+	if err != nil {
+		exitWithError(ExitError, err)
+	}
+	printMachine(resp.Machine)
+}
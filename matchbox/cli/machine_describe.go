@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"context"
+	"github.com/spf13/cobra"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// machineDescribeCmd describes a Machine with the Group and Profile it
+// matches and its boot history.
+var machineDescribeCmd = &cobra.Command{
+	Use:   "describe MACHINE_ID",
+	Short: "Describe a machine, its matched group and profile, and its boots",
+	Long: `Describe a machine, its matched group and profile, and its boots
+
+Shows the machine's labels (with its facts), state, install attempts, the
+group and profile it matches now and how the group was selected, the
+provisioning steps it reported, and its recent config requests with a
+checksum of each config served. The machine may be identified by its UUID
+or MAC address.`,
+	Run: runMachineDescribeCmd,
+}
+
+func init() {
+	machineCmd.AddCommand(machineDescribeCmd)
+	addOutputFlag(machineDescribeCmd)
+	completeArgNames(machineDescribeCmd, "machine")
+}
+
+func runMachineDescribeCmd(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Help()
+		return
+	}
+	validateOutputFlag(cmd)
+
+	client := mustClientFromCmd(cmd)
+	resp, err := client.Machines.MachineDescribe(context.TODO(), &pb.MachineDescribeRequest{Id: args[0]})
+	if err != nil {
+		exitWithError(ExitError, err)
+	}
+	m := resp.Machine
+	resources := []resource{{kind: "machine", id: m.Id, value: resp}}
+	mustPrint(resources, true, func(w io.Writer) {
+		group, match := "-", "-"
+		if resp.Group != nil {
+			group, match = resp.Group.Id, resp.Match
+		}
+		tw := newTabWriter(w)
+		fmt.Fprintf(tw, "ID:\t%s\n", m.Id)
+		fmt.Fprintf(tw, "State:\t%s\n", m.State)
+		fmt.Fprintf(tw, "Labels:\t%v\n", resp.Labels)
+		fmt.Fprintf(tw, "Group:\t%s (%s)\n", group, match)
+		fmt.Fprintf(tw, "Profile:\t%s\n", resp.Profile)
+		fmt.Fprintf(tw, "Install attempts:\t%d\n", m.Attempts)
+		fmt.Fprintf(tw, "Completed:\t%s\n", m.Completed)
+		tw.Flush()
+
+		if len(m.Progress) > 0 {
+			fmt.Fprintf(w, "\nProgress:\n")
+			tw = newTabWriter(w)
+			fmt.Fprintf(tw, "STEP\tSTATUS\tTIME\tMESSAGE\n")
+			for _, step := range m.Progress {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", step.Name, step.Status, step.Time, step.Message)
+			}
+			tw.Flush()
+		}
+
+		fmt.Fprintf(w, "\nBoots:\n")
+		tw = newTabWriter(w)
+		fmt.Fprintf(tw, "TIME\tENDPOINT\tSTATUS\tGROUP\tPROFILE\tCONFIG HASH\n")
+		for _, boot := range m.Boots {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\n", boot.Time, boot.Endpoint, boot.Status, boot.Group, boot.Profile, boot.ConfigHash)
+		}
+		tw.Flush()
+	})
+}
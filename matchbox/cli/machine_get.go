@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"context"
+	"github.com/spf13/cobra"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// machineGetCmd gets a Machine.
+var machineGetCmd = &cobra.Command{
+	Use:   "get MACHINE_ID",
+	Short: "Get an observed machine",
+	Long:  `Get an observed machine`,
+	Run:   runMachineGetCmd,
+}
+
+func init() {
+	machineCmd.AddCommand(machineGetCmd)
+	addOutputFlag(machineGetCmd)
+	completeArgNames(machineGetCmd, "machine")
+}
+
+func runMachineGetCmd(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Help()
+		return
+	}
+	validateOutputFlag(cmd)
+
+	client := mustClientFromCmd(cmd)
+	resp, err := client.Machines.MachineGet(context.TODO(), &pb.MachineGetRequest{Id: args[0]})
+	if err != nil {
+		exitWithError(ExitError, err)
+	}
+	printMachine(resp.Machine)
+}
+
+// printMachine prints a Machine in the output format.
+func printMachine(m *storagepb.Machine) {
+	mustPrint(machineResources([]*storagepb.Machine{m}), true, func(w io.Writer) {
+		tw := newTabWriter(w)
+		defer tw.Flush()
+		// legend
+		fmt.Fprintf(tw, "ID\tSTATE\tPINNED GROUP\tOWNER\tCOMPLETED\tBMC\tLABELS\tFACTS\n")
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%#v\t%#v\n", m.Id, m.State, m.Group, m.Owner, m.Completed, m.Bmc, m.Labels, m.Facts)
+	})
+}
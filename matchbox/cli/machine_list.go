@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"context"
+	"github.com/spf13/cobra"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// machineListCmd lists Machines.
+var (
+	machineListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List observed machines",
+		Long: `List observed machines
+
+Machines are recorded when they first network boot or report provisioning is
+complete, or when they are pinned to a group. With --selector, only machines
+with the given labels are listed. With --state, only machines in the given
+provisioning state are listed, such as pending machines which match no group
+and wait to be adopted.`,
+		Run: runMachineListCmd,
+	}
+	flagMachineState string
+)
+
+func init() {
+	machineCmd.AddCommand(machineListCmd)
+	addOutputFlag(machineListCmd)
+	machineListCmd.Flags().StringVar(&flagMachineState, "state", "", "only list machines in the given state (e.g. pending)")
+	machineListCmd.Flags().StringSliceVarP(&flagSelector, "selector", "l", nil, "only list machines with the given KEY=VALUE labels")
+}
+
+func runMachineListCmd(cmd *cobra.Command, args []string) {
+	validateOutputFlag(cmd)
+	selector := mustSelector()
+
+	client := mustClientFromCmd(cmd)
+	resp, err := client.Machines.MachineList(context.TODO(), &pb.MachineListRequest{State: flagMachineState})
+	if err != nil {
+		exitWithError(ExitError, err)
+	}
+	var machines []*storagepb.Machine
+	for _, machine := range resp.Machines {
+		if selectorMatches(selector, machine.Labels) {
+			machines = append(machines, machine)
+		}
+	}
+	mustPrint(machineResources(machines), false, func(w io.Writer) {
+		tw := newTabWriter(w)
+		defer tw.Flush()
+		// legend
+		fmt.Fprintf(tw, "ID\tSTATE\tPINNED GROUP\tOWNER\tCOMPLETED\tLABELS\n")
+		for _, machine := range machines {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%#v\n", machine.Id, machine.State, machine.Group, machine.Owner, machine.Completed, machine.Labels)
+		}
+	})
+}
+
+// machineResources returns printable resources for Machines.
+func machineResources(machines []*storagepb.Machine) []resource {
+	resources := make([]resource, len(machines))
+	for i, machine := range machines {
+		resources[i] = resource{kind: "machine", id: machine.Id, value: machine}
+	}
+	return resources
+}
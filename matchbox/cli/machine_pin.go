@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"context"
+	"github.com/spf13/cobra"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// machinePinCmd pins a Machine to a Group.
+var (
+	machinePinCmd = &cobra.Command{
+		Use:   "pin MACHINE_ID GROUP_ID",
+		Short: "Pin a machine to a group",
+		Long: `Pin a machine to a group
+
+A pinned machine receives the group's profile and metadata regardless of
+group selectors. Machines may be pinned before they first boot. Use --unpin
+to match the machine by selectors again.`,
+		Run: runMachinePinCmd,
+	}
+	flagUnpin bool
+)
+
+func init() {
+	machineCmd.AddCommand(machinePinCmd)
+	addOutputFlag(machinePinCmd)
+	machinePinCmd.Flags().BoolVar(&flagUnpin, "unpin", false, "unpin the machine")
+	completeArgNames(machinePinCmd, "machine")
+	completeArgNames(machinePinCmd, "group")
+}
+
+func runMachinePinCmd(cmd *cobra.Command, args []string) {
+	if (flagUnpin && len(args) != 1) || (!flagUnpin && len(args) != 2) {
+		cmd.Help()
+		return
+	}
+	validateOutputFlag(cmd)
+
+	req := &pb.MachinePinRequest{Id: args[0]}
+	if !flagUnpin {
+		req.Group = args[1]
+	}
+	client := mustClientFromCmd(cmd)
+	resp, err := client.Machines.MachinePin(context.TODO(), req)
+	if err != nil {
+		exitWithError(ExitError, err)
+	}
+	printMachine(resp.Machine)
+}
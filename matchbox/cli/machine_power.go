@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"context"
+	"github.com/spf13/cobra"
+
+	"github.com/coreos/matchbox/matchbox/power"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// machinePowerCmd controls the power of a Machine.
+var machinePowerCmd = &cobra.Command{
+	Use:   "power MACHINE_ID on|off|cycle|status",
+	Short: "Control the power of a machine",
+	Long: `Control the power of a machine
+
+Powers a machine on, off, or cycles it through its Redfish BMC, or prints its
+power state. The matchbox server must be started with -bmc-username and the
+machine must have a BMC.`,
+	Run: runMachinePowerCmd,
+}
+
+func init() {
+	machineCmd.AddCommand(machinePowerCmd)
+	completeArgNames(machinePowerCmd, "machine")
+	completeArgValues(machinePowerCmd, power.ActionOn, power.ActionOff, power.ActionCycle, power.ActionStatus)
+}
+
+func runMachinePowerCmd(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		cmd.Help()
+		return
+	}
+
+	client := mustClientFromCmd(cmd)
+	req := &pb.PowerRequest{
+		Id:     args[0],
+		Action: args[1],
+	}
+	resp, err := client.Power.Power(context.TODO(), req)
+	if err != nil {
+		exitWithError(ExitError, err)
+	}
+	fmt.Fprintln(os.Stdout, resp.State)
+}
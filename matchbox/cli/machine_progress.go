@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"context"
+	"github.com/spf13/cobra"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// machineProgressCmd shows the provisioning steps a Machine reported.
+var (
+	machineProgressCmd = &cobra.Command{
+		Use:   "progress MACHINE_ID",
+		Short: "Show the provisioning steps a machine reported",
+		Long: `Show the provisioning steps a machine reported
+
+Lists the steps the machine's installer reported to /v1/progress, in the
+order they started, with their latest status. Use --watch to print steps as
+their status changes, until the machine reports it is complete.`,
+		Run: runMachineProgressCmd,
+	}
+	flagWatchProgress bool
+)
+
+// progressPollInterval is how often --watch polls the machine's progress.
+const progressPollInterval = 2 * time.Second
+
+func init() {
+	machineCmd.AddCommand(machineProgressCmd)
+	addOutputFlag(machineProgressCmd)
+	machineProgressCmd.Flags().BoolVarP(&flagWatchProgress, "watch", "w", false, "print steps as their status changes")
+	completeArgNames(machineProgressCmd, "machine")
+}
+
+func runMachineProgressCmd(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Help()
+		return
+	}
+	validateOutputFlag(cmd)
+
+	client := mustClientFromCmd(cmd)
+	req := &pb.MachineGetRequest{Id: args[0]}
+	if !flagWatchProgress {
+		resp, err := client.Machines.MachineGet(context.TODO(), req)
+		if err != nil {
+			exitWithError(ExitError, err)
+		}
+		m := resp.Machine
+		mustPrint(machineResources([]*storagepb.Machine{m}), true, func(w io.Writer) {
+			tw := newTabWriter(w)
+			defer tw.Flush()
+			// legend
+			fmt.Fprintf(tw, "STEP\tSTATUS\tTIME\tMESSAGE\n")
+			for _, step := range m.Progress {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", step.Name, step.Status, step.Time, step.Message)
+			}
+		})
+		return
+	}
+
+	// print each step status once, as it is first seen
+	seen := make(map[storagepb.MachineStep]bool)
+	fmt.Fprintf(os.Stdout, progressFormat, "TIME", "STEP", "STATUS", "MESSAGE")
+	for {
+		resp, err := client.Machines.MachineGet(context.TODO(), req)
+		if err != nil {
+			exitWithError(ExitError, err)
+		}
+		for _, step := range resp.Machine.Progress {
+			if !seen[*step] {
+				seen[*step] = true
+				fmt.Fprintf(os.Stdout, progressFormat, step.Time, step.Name, step.Status, step.Message)
+			}
+		}
+		if resp.Machine.State == storagepb.MachineProvisioned {
+			return
+		}
+		time.Sleep(progressPollInterval)
+	}
+}
+
+// progressFormat formats progress columns. Steps are printed as they are
+// reported, so columns have fixed widths rather than being aligned by a
+// tabwriter.
+const progressFormat = "%-20s  %-24s  %-9s  %s\n"
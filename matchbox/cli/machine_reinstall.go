@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"context"
+	"github.com/spf13/cobra"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// machineReinstallCmd marks a Machine to be reinstalled.
+var (
+	machineReinstallCmd = &cobra.Command{
+		Use:   "reinstall MACHINE_ID|MAC",
+		Short: "Mark a machine to be reinstalled",
+		Long: `Mark a machine to be reinstalled
+
+Sets the machine's state to reinstall and clears its reported completion. The
+machine is found by id or by its MAC address. The state becomes provisioned
+again when the machine reports it is complete. Use --wipe to render the
+request.wipe template variable as true until the machine is installed again,
+so its profile can wipe its disks.`,
+		Run: runMachineReinstallCmd,
+	}
+	flagWipe bool
+)
+
+func init() {
+	machineCmd.AddCommand(machineReinstallCmd)
+	addOutputFlag(machineReinstallCmd)
+	machineReinstallCmd.Flags().BoolVar(&flagWipe, "wipe", false, "wipe the machine's disks when it is reinstalled")
+	completeArgNames(machineReinstallCmd, "machine")
+}
+
+func runMachineReinstallCmd(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Help()
+		return
+	}
+	validateOutputFlag(cmd)
+
+	client := mustClientFromCmd(cmd)
+	resp, err := client.Machines.MachineReinstall(context.TODO(), &pb.MachineReinstallRequest{Id: args[0], Wipe: flagWipe})
+	if err != nil {
+		exitWithError(ExitError, err)
+	}
+	printMachine(resp.Machine)
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"context"
+	"github.com/spf13/cobra"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// machineReleaseCmd releases a claimed Machine.
+var (
+	machineReleaseCmd = &cobra.Command{
+		Use:   "release MACHINE_ID",
+		Short: "Release a claimed machine",
+		Long: `Release a claimed machine
+
+Clears the machine's owner and unpins it, so it may be claimed again. With
+--owner, the machine is only released if OWNER holds the claim.`,
+		Run: runMachineReleaseCmd,
+	}
+	flagReleaseOwner string
+)
+
+func init() {
+	machineCmd.AddCommand(machineReleaseCmd)
+	addOutputFlag(machineReleaseCmd)
+	machineReleaseCmd.Flags().StringVar(&flagReleaseOwner, "owner", "", "only release the machine if claimed by this owner")
+	completeArgNames(machineReleaseCmd, "machine")
+}
+
+func runMachineReleaseCmd(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Help()
+		return
+	}
+	validateOutputFlag(cmd)
+
+	req := &pb.MachineReleaseRequest{Id: args[0], Owner: flagReleaseOwner}
+	client := mustClientFromCmd(cmd)
+	resp, err := client.Machines.MachineRelease(context.TODO(), req)
+	if err != nil {
+		exitWithError(ExitError, err)
+	}
+	printMachine(resp.Machine)
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"context"
+	"github.com/spf13/cobra"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// Maintenance command arguments which aren't maintenance modes.
+const (
+	maintenanceOff    = "off"
+	maintenanceStatus = "status"
+)
+
+// maintenanceCmd enters or leaves maintenance mode.
+var maintenanceCmd = &cobra.Command{
+	Use:   "maintenance local|hold|off|status",
+	Short: "Enter or leave maintenance mode",
+	Long: `Enter or leave maintenance mode
+
+In maintenance mode, every /ipxe request is answered regardless of group
+matching, so machines which reboot during infrastructure changes aren't
+reinstalled by accident. In local mode, machines boot from local disk. In
+hold mode, machines wait and retry until maintenance ends, then boot as
+usual. Prints the maintenance mode, or "off".
+
+The mode is held in memory, so a restarted server returns to its
+-maintenance flag.`,
+	Run: runMaintenanceCmd,
+}
+
+func init() {
+	RootCmd.AddCommand(maintenanceCmd)
+	completeArgValues(maintenanceCmd, server.MaintenanceLocal, server.MaintenanceHold, maintenanceOff, maintenanceStatus)
+}
+
+func runMaintenanceCmd(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Help()
+		return
+	}
+
+	client := mustClientFromCmd(cmd)
+	var mode string
+	switch args[0] {
+	case maintenanceStatus:
+		resp, err := client.Maintenance.MaintenanceGet(context.TODO(), &pb.MaintenanceGetRequest{})
+		if err != nil {
+			exitWithError(ExitError, err)
+		}
+		mode = resp.Mode
+	default:
+		req := &pb.MaintenanceSetRequest{Mode: args[0]}
+		if args[0] == maintenanceOff {
+			req.Mode = ""
+		}
+		if err := server.ValidateMaintenance(req.Mode); err != nil {
+			exitWithError(ExitBadArgs, usageError(cmd, "unknown maintenance mode %q, must be one of local, hold, off, or status", args[0]))
+		}
+		resp, err := client.Maintenance.MaintenanceSet(context.TODO(), req)
+		if err != nil {
+			exitWithError(ExitError, err)
+		}
+		mode = resp.Mode
+	}
+	if mode == "" {
+		mode = maintenanceOff
+	}
+	fmt.Fprintln(os.Stdout, mode)
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// Output formats of list and describe commands.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputName  = "name"
+)
+
+var (
+	flagFormat   string
+	flagSelector []string
+)
+
+// addOutputFlag adds the output format flag to a list or describe command.
+func addOutputFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&flagFormat, "output", "o", outputTable, "output format (table, json, yaml, name)")
+	completeFlagValues(cmd, "output", outputTable, outputJSON, outputYAML, outputName)
+}
+
+// addSelectorFlag adds the label selector flag to a list command.
+func addSelectorFlag(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVarP(&flagSelector, "selector", "l", nil, "only list resources for groups with the given KEY=VALUE selectors")
+}
+
+// validateOutputFlag exits if the output format is unknown.
+func validateOutputFlag(cmd *cobra.Command) {
+	switch flagFormat {
+	case outputTable, outputJSON, outputYAML, outputName:
+	default:
+		exitWithError(ExitBadArgs, usageError(cmd, "unknown output format %q, must be one of table, json, yaml, or name", flagFormat))
+	}
+}
+
+// resource is an object printed by list and describe commands.
+type resource struct {
+	kind  string
+	id    string
+	value interface{}
+}
+
+// printResources prints resources in the output format. Table output is
+// delegated to the table func. Describe commands print a single resource,
+// which is not wrapped in a list for JSON and YAML output.
+func printResources(w io.Writer, resources []resource, single bool, table func(io.Writer)) error {
+	values := make([]interface{}, len(resources))
+	for i, r := range resources {
+		values[i] = r.value
+	}
+	var value interface{} = values
+	if single && len(values) == 1 {
+		value = values[0]
+	}
+
+	switch flagFormat {
+	case outputJSON:
+		data, err := json.MarshalIndent(value, "", "\t")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	case outputYAML:
+		data, err := toYAML(value)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	case outputName:
+		for _, r := range resources {
+			if _, err := fmt.Fprintf(w, "%s/%s\n", r.kind, r.id); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		table(w)
+		return nil
+	}
+}
+
+// toYAML marshals a value to YAML with the same field names as its JSON
+// representation.
+func toYAML(value interface{}) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(generic)
+}
+
+// mustSelector returns the parsed label selector flag or exits.
+func mustSelector() map[string]string {
+	selector, err := parseKeyValues(flagSelector)
+	if err != nil {
+		exitWithError(ExitBadArgs, err)
+	}
+	return selector
+}
+
+// selectorMatches returns true if a group's selectors include every
+// selector KEY=VALUE pair.
+func selectorMatches(selector, groupSelector map[string]string) bool {
+	for key, value := range selector {
+		if val, ok := groupSelector[key]; !ok || val != value {
+			return false
+		}
+	}
+	return true
+}
+
+// mustPrint prints resources to stdout or exits.
+func mustPrint(resources []resource, single bool, table func(io.Writer)) {
+	if err := printResources(os.Stdout, resources, single, table); err != nil {
+		exitWithError(ExitError, err)
+	}
+}
@@ -0,0 +1,37 @@
+package cli
+
+import "sync"
+
+// defaultParallel is the default number of resources applied or fetched
+// concurrently.
+const defaultParallel = 8
+
+var flagParallel int
+
+// parallel calls fn with the index of each of n resources, running up to
+// --parallel calls concurrently, and returns once all calls return.
+func parallel(n int, fn func(i int)) {
+	workers := flagParallel
+	if workers < 1 {
+		workers = 1
+	}
+	if n < workers {
+		workers = n
+	}
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+}
@@ -25,7 +25,7 @@ func init() {
 	profileCmd.AddCommand(profilePutCmd)
 	profilePutCmd.Flags().StringVarP(&flagFilename, "filename", "f", "", "filename to use to create a Profile")
 	profilePutCmd.MarkFlagRequired("filename")
-	profilePutCmd.MarkFlagFilename("filename", "json")
+	profilePutCmd.MarkFlagFilename("filename", "json", "yaml", "yml")
 }
 
 func runProfilePutCmd(cmd *cobra.Command, args []string) {
@@ -43,10 +43,11 @@ func runProfilePutCmd(cmd *cobra.Command, args []string) {
 		exitWithError(ExitError, err)
 	}
 	req := &pb.ProfilePutRequest{Profile: profile}
-	_, err = client.Profiles.ProfilePut(context.TODO(), req)
+	resp, err := client.Profiles.ProfilePut(context.TODO(), req)
 	if err != nil {
 		exitWithError(ExitError, err)
 	}
+	printWarnings(resp.Warnings)
 }
 
 func validateArgs(cmd *cobra.Command, args []string) error {
@@ -61,5 +62,5 @@ func loadProfile(filename string) (*storagepb.Profile, error) {
 	if err != nil {
 		return nil, err
 	}
-	return storagepb.ParseProfile(data)
+	return storagepb.ParseProfileFile(filename, data)
 }
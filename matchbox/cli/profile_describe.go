@@ -2,12 +2,13 @@ package cli
 
 import (
 	"fmt"
-	"os"
+	"io"
 
 	"context"
 	"github.com/spf13/cobra"
 
 	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
 )
 
 // profileDescribeCmd describes a Profile.
@@ -20,6 +21,8 @@ var profileDescribeCmd = &cobra.Command{
 
 func init() {
 	profileCmd.AddCommand(profileDescribeCmd)
+	completeArgNames(profileDescribeCmd, "profile")
+	addOutputFlag(profileDescribeCmd)
 }
 
 func runProfileDescribeCmd(cmd *cobra.Command, args []string) {
@@ -27,11 +30,7 @@ func runProfileDescribeCmd(cmd *cobra.Command, args []string) {
 		cmd.Help()
 		return
 	}
-
-	tw := newTabWriter(os.Stdout)
-	defer tw.Flush()
-	// legend
-	fmt.Fprintf(tw, "ID\tNAME\tIGNITION\tCLOUD\tKERNEL\tINITRD\tCMDLINE\n")
+	validateOutputFlag(cmd)
 
 	client := mustClientFromCmd(cmd)
 	request := &pb.ProfileGetRequest{
@@ -39,8 +38,18 @@ func runProfileDescribeCmd(cmd *cobra.Command, args []string) {
 	}
 	resp, err := client.Profiles.ProfileGet(context.TODO(), request)
 	if err != nil {
-		return
+		exitWithError(ExitError, err)
 	}
 	p := resp.Profile
-	fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%#v\n", p.Id, p.Name, p.IgnitionId, p.CloudId, p.Boot.Kernel, p.Boot.Initrd, p.Boot.Cmdline)
+	mustPrint(profileResources([]*storagepb.Profile{p}), true, func(w io.Writer) {
+		tw := newTabWriter(w)
+		defer tw.Flush()
+		// legend
+		fmt.Fprintf(tw, "ID\tNAME\tIGNITION\tCLOUD\tKERNEL\tINITRD\tCMDLINE\n")
+		boot := p.Boot
+		if boot == nil {
+			boot = &storagepb.NetBoot{}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%#v\n", p.Id, p.Name, p.IgnitionId, p.CloudId, boot.Kernel, boot.Initrd, boot.Cmdline)
+	})
 }
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"context"
+	"github.com/spf13/cobra"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// profileHistoryCmd lists the versions of a Profile.
+var profileHistoryCmd = &cobra.Command{
+	Use:   "history PROFILE_ID",
+	Short: "List the versions of a profile",
+	Long: `List the versions of a profile
+
+A version is recorded each time a profile, or a template it references, is
+changed. Groups may pin a profile version with "profile_version".`,
+	Run: runProfileHistoryCmd,
+}
+
+func init() {
+	profileCmd.AddCommand(profileHistoryCmd)
+	addOutputFlag(profileHistoryCmd)
+	completeArgNames(profileHistoryCmd, "profile")
+}
+
+func runProfileHistoryCmd(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Help()
+		return
+	}
+	validateOutputFlag(cmd)
+
+	client := mustClientFromCmd(cmd)
+	resp, err := client.Profiles.ProfileVersionList(context.TODO(), &pb.ProfileVersionListRequest{Id: args[0]})
+	if err != nil {
+		exitWithError(ExitError, err)
+	}
+	printProfileVersions(resp.Versions, false)
+}
+
+// printProfileVersions prints Profile versions, or a single version if
+// single is true.
+func printProfileVersions(versions []*storagepb.ProfileVersion, single bool) {
+	resources := make([]resource, len(versions))
+	for i, version := range versions {
+		id := version.Profile.Id + "@" + strconv.Itoa(int(version.Version))
+		resources[i] = resource{kind: "profileversion", id: id, value: version}
+	}
+	mustPrint(resources, single, func(w io.Writer) {
+		tw := newTabWriter(w)
+		defer tw.Flush()
+		// legend
+		fmt.Fprintf(tw, "VERSION\tCREATED\tIGNITION\tCLOUD\tGENERIC\n")
+		for _, version := range versions {
+			p := version.Profile
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", version.Version, version.Created, p.IgnitionId, p.CloudId, p.GenericId)
+		}
+	})
+}
@@ -2,38 +2,77 @@ package cli
 
 import (
 	"fmt"
-	"os"
+	"io"
 
 	"context"
 	"github.com/spf13/cobra"
 
 	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
 )
 
 // profileListCmd lists Profiles.
 var profileListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List machine profiles",
-	Long:  `List machine profiles`,
-	Run:   runProfileListCmd,
+	Long: `List machine profiles
+
+With --selector, only profiles referenced by groups with the given selectors
+are listed.`,
+	Run: runProfileListCmd,
 }
 
 func init() {
 	profileCmd.AddCommand(profileListCmd)
+	addOutputFlag(profileListCmd)
+	addSelectorFlag(profileListCmd)
 }
 
 func runProfileListCmd(cmd *cobra.Command, args []string) {
-	tw := newTabWriter(os.Stdout)
-	defer tw.Flush()
-	// legend
-	fmt.Fprintf(tw, "ID\tPROFILE NAME\tIGNITION\tCLOUD\n")
+	validateOutputFlag(cmd)
+	selector := mustSelector()
 
 	client := mustClientFromCmd(cmd)
-	resp, err := client.Profiles.ProfileList(context.TODO(), &pb.ProfileListRequest{})
+	ctx := context.TODO()
+	resp, err := client.Profiles.ProfileList(ctx, &pb.ProfileListRequest{})
 	if err != nil {
-		return
+		exitWithError(ExitError, err)
+	}
+	profiles := resp.Profiles
+	if len(selector) > 0 {
+		groupsResp, err := client.Groups.GroupList(ctx, &pb.GroupListRequest{})
+		if err != nil {
+			exitWithError(ExitError, err)
+		}
+		selected := make(map[string]bool)
+		for _, group := range groupsResp.Groups {
+			if selectorMatches(selector, group.Selector) {
+				selected[group.Profile] = true
+			}
+		}
+		profiles = nil
+		for _, profile := range resp.Profiles {
+			if selected[profile.Id] {
+				profiles = append(profiles, profile)
+			}
+		}
 	}
-	for _, profile := range resp.Profiles {
-		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", profile.Id, profile.Name, profile.IgnitionId, profile.CloudId)
+	mustPrint(profileResources(profiles), false, func(w io.Writer) {
+		tw := newTabWriter(w)
+		defer tw.Flush()
+		// legend
+		fmt.Fprintf(tw, "ID\tPROFILE NAME\tIGNITION\tCLOUD\n")
+		for _, profile := range profiles {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", profile.Id, profile.Name, profile.IgnitionId, profile.CloudId)
+		}
+	})
+}
+
+// profileResources returns printable resources for Profiles.
+func profileResources(profiles []*storagepb.Profile) []resource {
+	resources := make([]resource, len(profiles))
+	for i, profile := range profiles {
+		resources[i] = resource{kind: "profile", id: profile.Id, value: profile}
 	}
+	return resources
 }
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"context"
+	"github.com/spf13/cobra"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// profileRollbackCmd restores a version of a Profile.
+var (
+	profileRollbackCmd = &cobra.Command{
+		Use:   "rollback PROFILE_ID",
+		Short: "Roll back a profile to an earlier version",
+		Long: `Roll back a profile to an earlier version
+
+Restores the profile and the templates it references from the version
+before the latest, or from --version. Groups which serve the latest version
+serve the restored profile on each machine's next request. The rollback is
+recorded as a new version, so it may be rolled back in turn.`,
+		Run: runProfileRollbackCmd,
+	}
+	flagRollbackVersion int32
+)
+
+func init() {
+	profileCmd.AddCommand(profileRollbackCmd)
+	addOutputFlag(profileRollbackCmd)
+	profileRollbackCmd.Flags().Int32Var(&flagRollbackVersion, "version", 0, "version to restore (default the version before the latest)")
+	completeArgNames(profileRollbackCmd, "profile")
+}
+
+func runProfileRollbackCmd(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Help()
+		return
+	}
+	validateOutputFlag(cmd)
+
+	client := mustClientFromCmd(cmd)
+	req := &pb.ProfileRollbackRequest{Id: args[0], Version: flagRollbackVersion}
+	resp, err := client.Profiles.ProfileRollback(context.TODO(), req)
+	if err != nil {
+		exitWithError(ExitError, err)
+	}
+	printProfileVersions([]*storagepb.ProfileVersion{resp.Version}, true)
+}
@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"context"
+	"github.com/spf13/cobra"
+
+	"github.com/coreos/matchbox/matchbox/rpc/rpcpb"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// renderCmd previews the configs a machine would receive.
+var (
+	renderCmd = &cobra.Command{
+		Use:   "render --label KEY=VALUE",
+		Short: "Preview the configs a machine would receive",
+		Long: `Preview the configs a machine would receive
+
+Renders configs for a machine with the given labels, as the matchbox HTTP
+endpoints would, without recording a boot. Use --profile to render a
+Profile other than the one of the matching group and --var to override
+group metadata.
+
+Use --template to validate and render a local, unsaved template file as the
+single --config (ignition, cloud, or generic) with the metadata of --group.
+Problems are printed with their line and column, and the command exits with
+status 1 if there are errors.`,
+		Run: runRenderCmd,
+	}
+	renderFlags = struct {
+		profile  string
+		labels   []string
+		vars     []string
+		configs  []string
+		template string
+		group    string
+	}{}
+)
+
+func init() {
+	RootCmd.AddCommand(renderCmd)
+	renderCmd.Flags().StringVar(&renderFlags.profile, "profile", "", "profile to render instead of the matching group's profile")
+	renderCmd.Flags().StringSliceVar(&renderFlags.labels, "label", nil, "machine label KEY=VALUE (e.g. mac=52:54:00:a1:9c:ae)")
+	renderCmd.Flags().StringSliceVar(&renderFlags.vars, "var", nil, "metadata variable KEY=VALUE which overrides group metadata")
+	renderCmd.Flags().StringSliceVar(&renderFlags.configs, "config", []string{"ipxe", "ignition"}, "configs to render (ipxe, ignition, cloud, generic)")
+	renderCmd.Flags().StringVar(&renderFlags.template, "template", "", "template file to render instead of the profile's template")
+	renderCmd.Flags().StringVar(&renderFlags.group, "group", "", "group whose metadata --template is rendered with")
+	completeFlagNames(renderCmd, "profile", "profile")
+	completeFlagNames(renderCmd, "group", "group")
+	completeFlagValues(renderCmd, "config", "ipxe", "ignition", "cloud", "generic")
+}
+
+func runRenderCmd(cmd *cobra.Command, args []string) {
+	if err := validateArgs(cmd, args); err != nil {
+		exitWithError(ExitBadArgs, err)
+	}
+	labels, err := parseKeyValues(renderFlags.labels)
+	if err != nil {
+		exitWithError(ExitBadArgs, err)
+	}
+	vars, err := parseKeyValues(renderFlags.vars)
+	if err != nil {
+		exitWithError(ExitBadArgs, err)
+	}
+
+	client := mustClientFromCmd(cmd)
+	if renderFlags.template != "" {
+		renderTemplate(client.Render, labels, vars)
+		return
+	}
+	for i, config := range renderFlags.configs {
+		req := &pb.RenderRequest{
+			Config:  config,
+			Labels:  labels,
+			Profile: renderFlags.profile,
+			Vars:    vars,
+		}
+		resp, err := client.Render.Render(context.TODO(), req)
+		if err != nil {
+			exitWithError(ExitError, err)
+		}
+		// label each config when rendering several, like head(1)
+		if len(renderFlags.configs) > 1 {
+			if i > 0 {
+				fmt.Fprintln(os.Stdout)
+			}
+			fmt.Fprintf(os.Stdout, "==> %s (group %q, profile %q) <==\n", config, resp.Group, resp.Profile)
+		}
+		os.Stdout.Write(resp.Config)
+		if len(resp.Config) > 0 && resp.Config[len(resp.Config)-1] != '\n' {
+			fmt.Fprintln(os.Stdout)
+		}
+	}
+}
+
+// renderTemplate validates and renders the --template file, printing its
+// problems to stderr.
+func renderTemplate(renderer rpcpb.RenderClient, labels, vars map[string]string) {
+	if len(renderFlags.configs) != 1 {
+		exitWithError(ExitBadArgs, errors.New("--template requires a single --config"))
+	}
+	data, err := ioutil.ReadFile(renderFlags.template)
+	if err != nil {
+		exitWithError(ExitBadArgs, err)
+	}
+	resp, err := renderer.RenderTemplate(context.TODO(), &pb.RenderTemplateRequest{
+		Config:   renderFlags.configs[0],
+		Name:     filepath.Base(renderFlags.template),
+		Template: data,
+		Group:    renderFlags.group,
+		Labels:   labels,
+		Vars:     vars,
+	})
+	if err != nil {
+		exitWithError(ExitError, err)
+	}
+	errs := 0
+	for _, problem := range resp.Problems {
+		if problem.Kind == "error" {
+			errs++
+		}
+		pos := renderFlags.template
+		if problem.Line > 0 {
+			pos += fmt.Sprintf(":%d", problem.Line)
+			if problem.Column > 0 {
+				pos += fmt.Sprintf(":%d", problem.Column)
+			}
+		}
+		fmt.Fprintf(os.Stderr, "%s: %s: %s\n%s", pos, problem.Kind, problem.Message, problem.Highlight)
+	}
+	if errs > 0 {
+		os.Exit(ExitError)
+	}
+	os.Stdout.Write(resp.Config)
+	if len(resp.Config) > 0 && resp.Config[len(resp.Config)-1] != '\n' {
+		fmt.Fprintln(os.Stdout)
+	}
+}
+
+// parseKeyValues parses KEY=VALUE pairs into a map.
+func parseKeyValues(pairs []string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid KEY=VALUE pair %q", pair)
+		}
+		values[parts[0]] = parts[1]
+	}
+	return values, nil
+}
@@ -2,7 +2,9 @@ package cli
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -26,6 +28,8 @@ To get help about a resource or command, run "bootcmd help resource"`,
 		caFile    string
 		certFile  string
 		keyFile   string
+		tokenFile string
+		gzip      bool
 	}{}
 )
 
@@ -36,6 +40,9 @@ func init() {
 	// gRPC TLS Client Authentication
 	RootCmd.PersistentFlags().StringVar(&globalFlags.certFile, "cert-file", "/etc/matchbox/client.crt", "Path to the client TLS certificate file")
 	RootCmd.PersistentFlags().StringVar(&globalFlags.keyFile, "key-file", "/etc/matchbox/client.key", "Path to the client TLS key file")
+	// gRPC OIDC Authentication
+	RootCmd.PersistentFlags().StringVar(&globalFlags.tokenFile, "oidc-token-file", "", "Path to a file containing an OIDC ID token to authenticate with")
+	RootCmd.PersistentFlags().BoolVar(&globalFlags.gzip, "gzip", false, "Compress requests with gzip (requires a matchbox server which accepts gzip)")
 	cobra.EnablePrefixMatching = true
 }
 
@@ -61,6 +68,8 @@ func mustClientFromCmd(cmd *cobra.Command) *client.Client {
 	cfg := &client.Config{
 		Endpoints: endpoints,
 		TLS:       tlscfg,
+		Token:     tokenFromCmd(cmd),
+		Compress:  globalFlags.gzip,
 	}
 
 	// gRPC client
@@ -80,6 +89,22 @@ func endpointsFromCmd(cmd *cobra.Command) []string {
 	return endpoints
 }
 
+// tokenFromCmd returns the contents of the OIDC ID token file, if any.
+func tokenFromCmd(cmd *cobra.Command) string {
+	tokenFile, err := cmd.Flags().GetString("oidc-token-file")
+	if err != nil {
+		exitWithError(ExitBadArgs, err)
+	}
+	if tokenFile == "" {
+		return ""
+	}
+	token, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		exitWithError(ExitBadArgs, err)
+	}
+	return strings.TrimSpace(string(token))
+}
+
 // tlsInfoFromCmd collects TLS arguments and returns a TLSInfo struct.
 func tlsInfoFromCmd(cmd *cobra.Command) *tlsutil.TLSInfo {
 	caFile, err := cmd.Flags().GetString("ca-file")
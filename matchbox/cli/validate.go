@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/coreos/matchbox/matchbox/manifest"
+)
+
+// validateCmd lints a manifest directory tree.
+var validateCmd = &cobra.Command{
+	Use:   "validate --filename DIR",
+	Short: "Validate resources in a directory",
+	Long: `Validate groups, profiles, and templates in a directory
+
+Checks JSON fields, selectors, references to profiles and templates, and
+Ignition and Fuze configs, and prints each problem with its file and
+position. Does not contact the server. Exits with status 1 if there are
+errors.`,
+	Run: runValidateCmd,
+}
+
+func init() {
+	RootCmd.AddCommand(validateCmd)
+	validateCmd.Flags().StringVarP(&flagFilename, "filename", "f", "", "directory of resources to validate")
+	validateCmd.MarkFlagRequired("filename")
+}
+
+func runValidateCmd(cmd *cobra.Command, args []string) {
+	if len(flagFilename) == 0 {
+		cmd.Help()
+		return
+	}
+	if err := validateArgs(cmd, args); err != nil {
+		return
+	}
+
+	problems, err := manifest.Lint(flagFilename)
+	if err != nil {
+		exitWithError(ExitBadArgs, err)
+	}
+	errors := 0
+	for _, problem := range problems {
+		if problem.Kind == manifest.ProblemError {
+			errors++
+		}
+		fmt.Fprintln(os.Stdout, problem)
+	}
+	fmt.Fprintf(os.Stdout, "%d errors, %d warnings\n", errors, len(problems)-errors)
+	if errors > 0 {
+		os.Exit(ExitError)
+	}
+}
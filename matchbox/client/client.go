@@ -3,12 +3,16 @@ package client
 import (
 	"crypto/tls"
 	"errors"
+	"net"
+	"strings"
 	"time"
 
+	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 
 	"github.com/coreos/matchbox/matchbox/rpc/rpcpb"
+	"github.com/coreos/matchbox/matchbox/socket"
 )
 
 var (
@@ -18,20 +22,36 @@ var (
 
 // Config configures a Client.
 type Config struct {
-	// List of endpoint URLs
+	// List of endpoint addresses (host:port, or unix:PATH for unix sockets)
 	Endpoints []string
 	// DialTimeout is the timeout for dialing a client connection
 	DialTimeout time.Duration
 	// Client TLS credentials
 	TLS *tls.Config
+	// (optional) OIDC ID token sent with each call
+	Token string
+	// compress requests with gzip (the server must accept gzip)
+	Compress bool
 }
 
+// keepAlivePeriod is the TCP keepalive period of client connections.
+const keepAlivePeriod = 30 * time.Second
+
 // Client provides a matchbox client RPC session.
 type Client struct {
-	Groups   rpcpb.GroupsClient
-	Profiles rpcpb.ProfilesClient
-	Ignition rpcpb.IgnitionClient
-	conn     *grpc.ClientConn
+	Groups      rpcpb.GroupsClient
+	Profiles    rpcpb.ProfilesClient
+	Ignition    rpcpb.IgnitionClient
+	Cloud       rpcpb.CloudClient
+	Generic     rpcpb.GenericClient
+	Render      rpcpb.RenderClient
+	Events      rpcpb.EventsClient
+	Machines    rpcpb.MachinesClient
+	Power       rpcpb.PowerClient
+	Assets      rpcpb.AssetsClient
+	Tokens      rpcpb.TokensClient
+	Maintenance rpcpb.MaintenanceClient
+	conn        *grpc.ClientConn
 }
 
 // New creates a new Client from the given Config.
@@ -53,10 +73,19 @@ func newClient(config *Config) (*Client, error) {
 		return nil, err
 	}
 	client := &Client{
-		conn:     conn,
-		Groups:   rpcpb.NewGroupsClient(conn),
-		Profiles: rpcpb.NewProfilesClient(conn),
-		Ignition: rpcpb.NewIgnitionClient(conn),
+		conn:        conn,
+		Groups:      rpcpb.NewGroupsClient(conn),
+		Profiles:    rpcpb.NewProfilesClient(conn),
+		Ignition:    rpcpb.NewIgnitionClient(conn),
+		Cloud:       rpcpb.NewCloudClient(conn),
+		Generic:     rpcpb.NewGenericClient(conn),
+		Render:      rpcpb.NewRenderClient(conn),
+		Events:      rpcpb.NewEventsClient(conn),
+		Machines:    rpcpb.NewMachinesClient(conn),
+		Power:       rpcpb.NewPowerClient(conn),
+		Assets:      rpcpb.NewAssetsClient(conn),
+		Tokens:      rpcpb.NewTokensClient(conn),
+		Maintenance: rpcpb.NewMaintenanceClient(conn),
 	}
 	return client, nil
 }
@@ -67,19 +96,59 @@ func dialEndpoints(config *Config) (conn *grpc.ClientConn, err error) {
 	opts := []grpc.DialOption{
 		grpc.WithBlock(),
 		grpc.WithTimeout(config.DialTimeout),
+		grpc.WithDialer(dialKeepAlive),
+		// accept gzip compressed responses
+		grpc.WithDecompressor(grpc.NewGZIPDecompressor()),
+	}
+	if config.Compress {
+		opts = append(opts, grpc.WithCompressor(grpc.NewGZIPCompressor()))
 	}
-	if config.TLS != nil {
-		creds := credentials.NewTLS(config.TLS)
-		opts = append(opts, grpc.WithTransportCredentials(creds))
-	} else {
+	if config.TLS == nil {
 		return nil, errNoTLSConfig
 	}
+	if config.Token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(bearerToken(config.Token)))
+	}
 
 	for _, endpoint := range config.Endpoints {
-		conn, err = grpc.Dial(endpoint, opts...)
+		creds := credentials.NewTLS(endpointTLS(config.TLS, endpoint))
+		conn, err = grpc.Dial(endpoint, append(opts, grpc.WithTransportCredentials(creds))...)
 		if err == nil {
 			return conn, nil
 		}
 	}
 	return nil, err
 }
+
+// endpointTLS returns the TLS config for an endpoint. Servers on unix
+// sockets are verified as localhost, unless a server name is set.
+func endpointTLS(config *tls.Config, endpoint string) *tls.Config {
+	if !strings.HasPrefix(endpoint, socket.UnixPrefix) || config.ServerName != "" {
+		return config
+	}
+	config = config.Clone()
+	config.ServerName = "localhost"
+	return config
+}
+
+// dialKeepAlive dials a TCP connection with keepalives, so idle
+// connections through NATs and load balancers aren't silently dropped,
+// or a unix socket connection for addresses with the socket.UnixPrefix.
+func dialKeepAlive(address string, timeout time.Duration) (net.Conn, error) {
+	if strings.HasPrefix(address, socket.UnixPrefix) {
+		return net.DialTimeout("unix", strings.TrimPrefix(address, socket.UnixPrefix), timeout)
+	}
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: keepAlivePeriod}
+	return dialer.Dial("tcp", address)
+}
+
+// bearerToken sends an ID token as "authorization: Bearer TOKEN" metadata.
+type bearerToken string
+
+func (t bearerToken) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+func (t bearerToken) RequireTransportSecurity() bool {
+	return true
+}
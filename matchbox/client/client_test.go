@@ -1,6 +1,7 @@
 package client
 
 import (
+	"crypto/tls"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -14,3 +15,15 @@ func TestNew_MissingEndpoints(t *testing.T) {
 	assert.Nil(t, client)
 	assert.Equal(t, errNoEndpoints, err)
 }
+
+func TestEndpointTLS(t *testing.T) {
+	config := &tls.Config{}
+	// assert that:
+	// - servers on unix sockets are verified as localhost
+	// - TCP endpoints and set server names are unchanged
+	assert.Equal(t, "localhost", endpointTLS(config, "unix:/run/matchbox/rpc.sock").ServerName)
+	assert.Equal(t, "", config.ServerName)
+	assert.Equal(t, config, endpointTLS(config, "matchbox.example.com:8081"))
+	named := &tls.Config{ServerName: "matchbox.example.com"}
+	assert.Equal(t, named, endpointTLS(named, "unix:/run/matchbox/rpc.sock"))
+}
@@ -0,0 +1,45 @@
+package coalesce
+
+import (
+	"sync"
+)
+
+// call is an in-flight or completed call.
+type call struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// Group coalesces calls by key. The zero value is ready to use.
+type Group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// Do calls fn and returns its results, unless a call with the same key is
+// in flight, in which case Do waits for and returns that call's results.
+// shared is true if the results were returned to more than one caller.
+func (g *Group) Do(key string, fn func() (interface{}, error)) (value interface{}, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		<-c.done
+		return c.value, c.err, true
+	}
+	c := &call{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+	c.value, c.err = fn()
+	return c.value, c.err, false
+}
@@ -0,0 +1,60 @@
+package coalesce
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDo(t *testing.T) {
+	var g Group
+	value, err, shared := g.Do("key", func() (interface{}, error) {
+		return "value", nil
+	})
+	assert.Equal(t, "value", value)
+	assert.Nil(t, err)
+	assert.False(t, shared)
+
+	expectedErr := errors.New("error")
+	_, err, _ = g.Do("key", func() (interface{}, error) {
+		return nil, expectedErr
+	})
+	assert.Equal(t, expectedErr, err)
+}
+
+func TestDo_Concurrent(t *testing.T) {
+	var g Group
+	var calls int32
+	release := make(chan struct{})
+	fn := func() (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "rendered", nil
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan interface{}, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, _, _ := g.Do("key", fn)
+			results <- value
+		}()
+	}
+	// let callers block on the in-flight call
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+	// assert that:
+	// - concurrent calls with the same key share one call
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for value := range results {
+		assert.Equal(t, "rendered", value)
+	}
+}
@@ -0,0 +1,3 @@
+// Package coalesce suppresses duplicate concurrent calls, so identical
+// requests arriving together (e.g. a rack powering on) share one result.
+package coalesce
@@ -0,0 +1,125 @@
+package defaults
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// ErrNotObject is returned if a defaults file isn't a YAML or JSON object.
+var ErrNotObject = errors.New("defaults: metadata defaults must be an object")
+
+// Defaults is default metadata loaded from a YAML or JSON file, which can be
+// read again when it changes.
+type Defaults struct {
+	path string
+
+	mu       sync.RWMutex
+	metadata map[string]interface{}
+}
+
+// Load reads the defaults file at path.
+func Load(path string) (*Defaults, error) {
+	metadata, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Defaults{path: path, metadata: metadata}, nil
+}
+
+// New returns Defaults of the given metadata, which aren't backed by a file.
+func New(metadata map[string]interface{}) *Defaults {
+	return &Defaults{metadata: metadata}
+}
+
+// Path returns the path of the defaults file.
+func (d *Defaults) Path() string {
+	return d.path
+}
+
+// Reload reads the defaults file again. If it's invalid, the previous
+// defaults are kept.
+func (d *Defaults) Reload() error {
+	metadata, err := readFile(d.path)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.metadata = metadata
+	return nil
+}
+
+// Merge returns a copy of the Group with the default metadata merged beneath
+// its metadata. Group values take precedence, and nested objects are merged
+// key by key. The Group is returned as is if there are no defaults.
+func (d *Defaults) Merge(group *storagepb.Group) (*storagepb.Group, error) {
+	if d == nil {
+		return group, nil
+	}
+	d.mu.RLock()
+	defaults := d.metadata
+	d.mu.RUnlock()
+	if len(defaults) == 0 {
+		return group, nil
+	}
+	metadata := make(map[string]interface{})
+	if group.Metadata != nil {
+		if err := json.Unmarshal(group.Metadata, &metadata); err != nil {
+			return nil, err
+		}
+	}
+	data, err := json.Marshal(merge(defaults, metadata))
+	if err != nil {
+		return nil, err
+	}
+	merged := group.Copy()
+	merged.Metadata = data
+	return merged, nil
+}
+
+// merge returns the values of the override object merged over those of the
+// base object. Neither is modified.
+func merge(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		baseObject, ok := merged[key].(map[string]interface{})
+		object, isObject := value.(map[string]interface{})
+		if ok && isObject {
+			merged[key] = merge(baseObject, object)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// readFile reads and parses the defaults file at path, as YAML (of which
+// JSON is a subset).
+func readFile(path string) (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("defaults: %s: %v", path, err)
+	}
+	if value == nil {
+		return nil, nil
+	}
+	metadata, ok := storagepb.JSONValue(value).(map[string]interface{})
+	if !ok {
+		return nil, ErrNotObject
+	}
+	return metadata, nil
+}
@@ -0,0 +1,85 @@
+package defaults
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+func TestMerge(t *testing.T) {
+	defaults := New(map[string]interface{}{
+		"ntp_servers": []interface{}{"0.pool.ntp.org", "1.pool.ntp.org"},
+		"proxy":       map[string]interface{}{"http": "http://proxy:3128", "no_proxy": "localhost"},
+		"ssh_ca":      "ssh-ed25519 AAAA",
+	})
+	group := &storagepb.Group{
+		Id:       "node1",
+		Metadata: []byte(`{"proxy":{"no_proxy":"localhost,10.0.0.0/8"},"ssh_ca":"ssh-ed25519 BBBB","hostname":"node1"}`),
+	}
+	merged, err := defaults.Merge(group)
+	assert.Nil(t, err)
+	// assert that:
+	// - defaults are merged beneath Group metadata, nested objects key by key
+	// - the Group isn't modified
+	assert.JSONEq(t, `{
+		"ntp_servers": ["0.pool.ntp.org", "1.pool.ntp.org"],
+		"proxy": {"http": "http://proxy:3128", "no_proxy": "localhost,10.0.0.0/8"},
+		"ssh_ca": "ssh-ed25519 BBBB",
+		"hostname": "node1"
+	}`, string(merged.Metadata))
+	assert.Equal(t, "node1", merged.Id)
+	assert.JSONEq(t, `{"proxy":{"no_proxy":"localhost,10.0.0.0/8"},"ssh_ca":"ssh-ed25519 BBBB","hostname":"node1"}`, string(group.Metadata))
+
+	// - Groups without metadata are given the defaults
+	merged, err = defaults.Merge(&storagepb.Group{Id: "node2"})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"ntp_servers":["0.pool.ntp.org","1.pool.ntp.org"],"proxy":{"http":"http://proxy:3128","no_proxy":"localhost"},"ssh_ca":"ssh-ed25519 AAAA"}`, string(merged.Metadata))
+
+	// - Groups are unchanged without defaults
+	var none *Defaults
+	merged, err = none.Merge(group)
+	assert.Nil(t, err)
+	assert.Equal(t, group, merged)
+	merged, err = New(nil).Merge(group)
+	assert.Nil(t, err)
+	assert.Equal(t, group, merged)
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "matchbox-defaults")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "defaults.yaml")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("ntp_servers:\n- 0.pool.ntp.org\nproxy:\n  http: http://proxy:3128\n"), 0644))
+
+	defaults, err := Load(path)
+	assert.Nil(t, err)
+	assert.Equal(t, path, defaults.Path())
+	merged, err := defaults.Merge(&storagepb.Group{})
+	assert.Nil(t, err)
+	// assert that YAML defaults are loaded
+	assert.JSONEq(t, `{"ntp_servers":["0.pool.ntp.org"],"proxy":{"http":"http://proxy:3128"}}`, string(merged.Metadata))
+
+	// assert that:
+	// - invalid defaults aren't reloaded, the previous defaults are kept
+	// - valid defaults are reloaded
+	assert.Nil(t, ioutil.WriteFile(path, []byte("- not\n- an object\n"), 0644))
+	assert.Equal(t, ErrNotObject, defaults.Reload())
+	merged, err = defaults.Merge(&storagepb.Group{})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"ntp_servers":["0.pool.ntp.org"],"proxy":{"http":"http://proxy:3128"}}`, string(merged.Metadata))
+	assert.Nil(t, ioutil.WriteFile(path, []byte(`{"ntp_servers": ["1.pool.ntp.org"]}`), 0644))
+	assert.Nil(t, defaults.Reload())
+	merged, err = defaults.Merge(&storagepb.Group{})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"ntp_servers":["1.pool.ntp.org"]}`, string(merged.Metadata))
+
+	// - missing files are errors
+	_, err = Load(filepath.Join(dir, "missing.yaml"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,3 @@
+// Package defaults merges server-wide default metadata beneath the metadata
+// of every Group, so values constant across groups are defined once.
+package defaults
@@ -0,0 +1,267 @@
+package deprecation
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// Kinds of deprecated items
+const (
+	KindGroup    = "group"
+	KindProfile  = "profile"
+	KindEndpoint = "endpoint"
+	KindQuery    = "query"
+)
+
+// DefaultInterval is how often a Reporter logs each warning.
+const DefaultInterval = time.Hour
+
+// A Deprecation is a deprecated Group or Profile field, endpoint, or
+// endpoint query parameter.
+type Deprecation struct {
+	Kind string
+	// Name is the dotted JSON path of a field (e.g. boot.cmdline), the path
+	// of an endpoint (subtrees end in '/'), or a query parameter
+	Name string
+	// (optional) Endpoint of a query parameter, or any endpoint if empty
+	Endpoint string
+	// Since is the release which deprecated the item
+	Since string
+	// (optional) Removal is the release which will remove the item
+	Removal string
+	// Hint describes what to use instead
+	Hint string
+}
+
+// Deprecations lists the deprecated items. Deprecate an item by adding it
+// here, at least a release before it is removed.
+var Deprecations = []*Deprecation{
+	{Kind: KindProfile, Name: "boot.cmdline", Since: "v0.5.0", Hint: "list kernel args in boot.args"},
+	{Kind: KindEndpoint, Name: "/pixiecore/v1/boot/", Since: "v0.5.0", Hint: "use iPXE or GRUB network boot"},
+}
+
+// A Warning is a use of a deprecated item.
+type Warning struct {
+	*Deprecation
+	// Resource is the id of the Group or Profile which uses a field
+	Resource string
+}
+
+// String describes the warning (e.g. `profile "worker" field boot.cmdline
+// is deprecated since v0.5.0: list kernel args in boot.args`).
+func (w *Warning) String() string {
+	var item string
+	switch w.Kind {
+	case KindGroup, KindProfile:
+		item = fmt.Sprintf("%s %q field %s", w.Kind, w.Resource, w.Name)
+	case KindEndpoint:
+		item = "endpoint " + w.Name
+	default:
+		item = "query parameter " + w.Name
+		if w.Endpoint != "" {
+			item += " of " + w.Endpoint
+		}
+	}
+	msg := item + " is deprecated since " + w.Since
+	if w.Removal != "" {
+		msg += " and will be removed in " + w.Removal
+	}
+	if w.Hint != "" {
+		msg += ": " + w.Hint
+	}
+	return msg
+}
+
+// Strings returns the descriptions of warnings.
+func Strings(warnings []*Warning) []string {
+	if len(warnings) == 0 {
+		return nil
+	}
+	strs := make([]string, len(warnings))
+	for i, w := range warnings {
+		strs[i] = w.String()
+	}
+	return strs
+}
+
+// Group returns warnings for the deprecated fields a Group sets.
+func Group(group *storagepb.Group) []*Warning {
+	if !deprecates(KindGroup) {
+		return nil
+	}
+	richGroup, err := group.ToRichGroup()
+	if err != nil {
+		return nil
+	}
+	return fields(KindGroup, group.Id, richGroup)
+}
+
+// Profile returns warnings for the deprecated fields a Profile sets.
+func Profile(profile *storagepb.Profile) []*Warning {
+	if !deprecates(KindProfile) {
+		return nil
+	}
+	return fields(KindProfile, profile.Id, profile)
+}
+
+// Request returns warnings for a request to a deprecated endpoint or with
+// deprecated query parameters.
+func Request(req *http.Request) []*Warning {
+	var warnings []*Warning
+	query := req.URL.Query()
+	for _, d := range Deprecations {
+		switch d.Kind {
+		case KindEndpoint:
+			if matchPath(d.Name, req.URL.Path) {
+				warnings = append(warnings, &Warning{Deprecation: d})
+			}
+		case KindQuery:
+			if _, ok := query[d.Name]; ok && (d.Endpoint == "" || matchPath(d.Endpoint, req.URL.Path)) {
+				warnings = append(warnings, &Warning{Deprecation: d})
+			}
+		}
+	}
+	return warnings
+}
+
+// SetHeaders adds a Warning header (RFC 7234 code 299) to a response for
+// each warning.
+func SetHeaders(h http.Header, warnings []*Warning) {
+	for _, w := range warnings {
+		h.Add("Warning", fmt.Sprintf("299 - %q", w.String()))
+	}
+}
+
+// deprecates returns true if any item of a kind is deprecated.
+func deprecates(kind string) bool {
+	for _, d := range Deprecations {
+		if d.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// fields returns warnings for the deprecated fields of a kind which are set
+// in the JSON form of a resource.
+func fields(kind, id string, v interface{}) []*Warning {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil
+	}
+	var warnings []*Warning
+	for _, d := range Deprecations {
+		if d.Kind == kind && isSet(object, strings.Split(d.Name, ".")) {
+			warnings = append(warnings, &Warning{Deprecation: d, Resource: id})
+		}
+	}
+	return warnings
+}
+
+// isSet returns true if the value at a path of keys in a JSON object is
+// set to a non-empty value.
+func isSet(object map[string]interface{}, path []string) bool {
+	value, ok := object[path[0]]
+	if !ok {
+		return false
+	}
+	if len(path) > 1 {
+		nested, ok := value.(map[string]interface{})
+		return ok && isSet(nested, path[1:])
+	}
+	switch v := value.(type) {
+	case nil:
+		return false
+	case string:
+		return v != ""
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	}
+	return true
+}
+
+// matchPath returns true if a request path is an endpoint, or is below it
+// if the endpoint is a subtree.
+func matchPath(endpoint, path string) bool {
+	if strings.HasSuffix(endpoint, "/") {
+		return strings.HasPrefix(path, endpoint)
+	}
+	return path == endpoint
+}
+
+// Config configures a Reporter.
+type Config struct {
+	// Interval between logs of the same warning (0 uses the default)
+	Interval time.Duration
+	Logger   *logrus.Logger
+}
+
+// A Reporter logs warnings, at most once per interval for each item and
+// resource since machines repeat requests, and counts every use.
+type Reporter struct {
+	interval time.Duration
+	logger   *logrus.Logger
+	mu       sync.Mutex
+	logged   map[string]time.Time
+	now      func() time.Time
+}
+
+// NewReporter returns a new Reporter.
+func NewReporter(config *Config) *Reporter {
+	interval := config.Interval
+	if interval == 0 {
+		interval = DefaultInterval
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = logrus.New()
+	}
+	return &Reporter{
+		interval: interval,
+		logger:   logger,
+		logged:   make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// Report counts warnings and logs those not logged within the interval.
+func (r *Reporter) Report(warnings []*Warning) {
+	if len(warnings) == 0 {
+		return
+	}
+	now := r.now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, w := range warnings {
+		usesTotal.Inc(w.Kind, w.Name)
+		key := w.Kind + "/" + w.Name + "/" + w.Resource
+		if last, ok := r.logged[key]; ok && now.Sub(last) < r.interval {
+			continue
+		}
+		r.logged[key] = now
+		r.logger.WithFields(logrus.Fields{
+			"deprecated": w.Name,
+			"kind":       w.Kind,
+			"since":      w.Since,
+			"resource":   w.Resource,
+		}).Warning(w.String())
+	}
+}
@@ -0,0 +1,96 @@
+package deprecation
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+func TestProfile(t *testing.T) {
+	profile := &storagepb.Profile{
+		Id: "legacy",
+		Boot: &storagepb.NetBoot{
+			Kernel:  "/assets/vmlinuz",
+			Cmdline: map[string]string{"console": "ttyS0"},
+		},
+	}
+	// assert that:
+	// - deprecated fields which are set are warnings
+	// - empty deprecated fields are ignored
+	warnings := Profile(profile)
+	if assert.Len(t, warnings, 1) {
+		assert.Equal(t, "legacy", warnings[0].Resource)
+		assert.Equal(t, `profile "legacy" field boot.cmdline is deprecated since v0.5.0: list kernel args in boot.args`, warnings[0].String())
+	}
+	profile.Boot.Cmdline = map[string]string{}
+	assert.Empty(t, Profile(profile))
+	assert.Empty(t, Profile(&storagepb.Profile{Id: "empty"}))
+}
+
+func TestRequest(t *testing.T) {
+	defer func(deprecations []*Deprecation) { Deprecations = deprecations }(Deprecations)
+	Deprecations = append(Deprecations, &Deprecation{
+		Kind:     KindQuery,
+		Name:     "hostname",
+		Endpoint: "/ignition",
+		Since:    "v0.6.0",
+		Removal:  "v0.8.0",
+	})
+	cases := []struct {
+		url      string
+		expected []string
+	}{
+		{"/pixiecore/v1/boot/52:54:00:a1:9c:ae", []string{"endpoint /pixiecore/v1/boot/ is deprecated since v0.5.0: use iPXE or GRUB network boot"}},
+		{"/ignition?hostname=node1", []string{"query parameter hostname of /ignition is deprecated since v0.6.0 and will be removed in v0.8.0"}},
+		{"/ignition?uuid=a1b2c3d4", nil},
+		{"/generic?hostname=node1", nil},
+		{"/pixiecore", nil},
+	}
+	// assert that:
+	// - requests to deprecated endpoints (or subtrees) are warnings
+	// - deprecated query parameters are warnings only for their endpoint
+	for _, c := range cases {
+		req, _ := http.NewRequest("GET", c.url, nil)
+		assert.Equal(t, c.expected, Strings(Request(req)), c.url)
+	}
+}
+
+func TestSetHeaders(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/pixiecore/v1/boot/52:54:00:a1:9c:ae", nil)
+	h := http.Header{}
+	// assert that warnings are RFC 7234 Warning headers with code 299
+	SetHeaders(h, Request(req))
+	assert.Equal(t, []string{`299 - "endpoint /pixiecore/v1/boot/ is deprecated since v0.5.0: use iPXE or GRUB network boot"`}, h["Warning"])
+}
+
+func TestReporter(t *testing.T) {
+	logger, hook := logtest.NewNullLogger()
+	reporter := NewReporter(&Config{Interval: time.Minute, Logger: logger})
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	reporter.now = func() time.Time { return now }
+	warning := &Warning{Deprecation: Deprecations[0], Resource: "legacy"}
+	other := &Warning{Deprecation: Deprecations[0], Resource: "other"}
+
+	// assert that:
+	// - each warning is logged once per interval
+	// - warnings for different resources are logged separately
+	reporter.Report([]*Warning{warning, warning})
+	assert.Len(t, hook.Entries, 1)
+	reporter.Report([]*Warning{other})
+	assert.Len(t, hook.Entries, 2)
+	now = now.Add(30 * time.Second)
+	reporter.Report([]*Warning{warning})
+	assert.Len(t, hook.Entries, 2)
+	now = now.Add(time.Minute)
+	reporter.Report([]*Warning{warning})
+	if assert.Len(t, hook.Entries, 3) {
+		entry := hook.LastEntry()
+		assert.Equal(t, "boot.cmdline", entry.Data["deprecated"])
+		assert.Equal(t, "legacy", entry.Data["resource"])
+	}
+}
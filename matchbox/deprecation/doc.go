@@ -0,0 +1,5 @@
+// Package deprecation lists deprecated Group and Profile fields, endpoints,
+// and endpoint query parameters, and finds their use, so that warnings can
+// be shown in logs, API responses, and validation for a release or more
+// before a breaking change removes them.
+package deprecation
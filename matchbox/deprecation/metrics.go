@@ -0,0 +1,10 @@
+package deprecation
+
+import (
+	"github.com/coreos/matchbox/matchbox/metrics"
+)
+
+var usesTotal = metrics.NewCounterVec(
+	"matchbox_deprecated_uses_total",
+	"Uses of deprecated fields, endpoints, and query parameters by kind and name.",
+	"kind", "name")
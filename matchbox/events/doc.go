@@ -0,0 +1,3 @@
+// Package events fans out machine boot events to live subscribers, such as
+// gRPC clients watching machines boot during rack bring-up.
+package events
@@ -0,0 +1,93 @@
+package events
+
+import (
+	"sync"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// DefaultBuffer is the number of events buffered per subscriber.
+const DefaultBuffer = 64
+
+// Hub publishes boot events to subscribers. A nil Hub discards events.
+type Hub struct {
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+// NewHub returns a new Hub.
+func NewHub() *Hub {
+	return &Hub{
+		subs: make(map[*Subscription]struct{}),
+	}
+}
+
+// A Subscription receives published events on C until it is closed.
+type Subscription struct {
+	// C receives events
+	C <-chan *pb.BootEvent
+	c chan *pb.BootEvent
+	// dropped counts events discarded because C was full
+	dropped int
+	hub     *Hub
+}
+
+// Subscribe returns a Subscription which buffers up to buffer events (or
+// DefaultBuffer if buffer is not positive).
+func (h *Hub) Subscribe(buffer int) *Subscription {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	c := make(chan *pb.BootEvent, buffer)
+	sub := &Subscription{C: c, c: c, hub: h}
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+// Publish sends an event to each subscriber without blocking. Events are
+// dropped for subscribers which are not keeping up, so slow clients never
+// delay boot requests.
+func (h *Hub) Publish(event *pb.BootEvent) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs {
+		select {
+		case sub.c <- event:
+		default:
+			sub.dropped++
+		}
+	}
+}
+
+// Subscribers returns the number of current subscribers.
+func (h *Hub) Subscribers() int {
+	if h == nil {
+		return 0
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// Dropped returns the number of events dropped because the subscriber was
+// not keeping up.
+func (s *Subscription) Dropped() int {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.dropped
+}
+
+// Close unsubscribes and closes C. Close may be called more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	if _, ok := s.hub.subs[s]; ok {
+		delete(s.hub.subs, s)
+		close(s.c)
+	}
+}
@@ -0,0 +1,42 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+func TestHub(t *testing.T) {
+	hub := NewHub()
+	a := hub.Subscribe(1)
+	b := hub.Subscribe(1)
+	assert.Equal(t, 2, hub.Subscribers())
+
+	event := &pb.BootEvent{Endpoint: "/ipxe"}
+	hub.Publish(event)
+	// assert that:
+	// - each subscriber receives published events
+	assert.Equal(t, event, <-a.C)
+	assert.Equal(t, event, <-b.C)
+
+	// - events are dropped for full subscribers rather than blocking
+	hub.Publish(event)
+	hub.Publish(event)
+	assert.Equal(t, 1, a.Dropped())
+	assert.Equal(t, event, <-a.C)
+
+	// - closed subscriptions are removed and their channel is closed
+	a.Close()
+	a.Close()
+	_, ok := <-a.C
+	assert.False(t, ok)
+	assert.Equal(t, 1, hub.Subscribers())
+}
+
+func TestHub_Nil(t *testing.T) {
+	var hub *Hub
+	hub.Publish(&pb.BootEvent{})
+	assert.Equal(t, 0, hub.Subscribers())
+}
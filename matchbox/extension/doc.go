@@ -0,0 +1,14 @@
+// Package extension registers extensions to matchbox, such as custom Stores,
+// Matchers, template functions, and HTTP endpoints, so downstream builds can
+// extend matchbox without patching core files.
+//
+// An extension registers ServerOptions from the init function of its
+// package, which a build imports for its side effects, for example from a
+// new file in cmd/matchbox:
+//
+//	package main
+//
+//	import _ "example.com/matchbox-extensions/cmdb"
+//
+// The matchbox command applies the registered extensions at startup.
+package extension
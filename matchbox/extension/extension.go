@@ -0,0 +1,169 @@
+package extension
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"text/template"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage"
+)
+
+var (
+	ErrMissingName      = errors.New("extension: missing name")
+	ErrDuplicateName    = errors.New("extension: name is already registered")
+	ErrMultipleMatchers = errors.New("extension: a Matcher is already registered")
+)
+
+// reservedFuncs are the built-in template functions, which extensions may
+// not replace.
+var reservedFuncs = map[string]bool{
+	"include":      true,
+	"kubeadmToken": true,
+}
+
+// ServerOptions extend a matchbox server. Every field but Name is optional.
+type ServerOptions struct {
+	// Name identifies the extension in logs and errors
+	Name string
+	// Store returns the Store to use given the configured Store, which it
+	// may wrap (e.g. to validate writes) or replace with a custom backend.
+	// Store hooks are applied in the order extensions are registered.
+	Store func(store storage.Store) (storage.Store, error)
+	// Matcher matches machines to Groups before Group selectors, like
+	// -matcher-url. At most one extension may register a Matcher.
+	Matcher server.Matcher
+	// TemplateFuncs are added to the functions available to Ignition,
+	// Cloud-Config, generic, and kernel arg templates
+	TemplateFuncs template.FuncMap
+	// Handlers serve additional HTTP endpoints by ServeMux pattern
+	Handlers map[string]http.Handler
+}
+
+// A Registry holds registered extensions.
+type Registry struct {
+	mu         sync.Mutex
+	extensions []*ServerOptions
+}
+
+// defaultRegistry holds the extensions registered with Register.
+var defaultRegistry = &Registry{}
+
+// Register registers an extension, usually from an init function. It
+// panics if the extension conflicts with a registered extension or a
+// built-in template function, since that is a programming error.
+func Register(opts *ServerOptions) {
+	if err := defaultRegistry.Register(opts); err != nil {
+		panic(err)
+	}
+}
+
+// Default returns the Registry of extensions registered with Register.
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Register registers an extension, or returns an error if it conflicts with
+// a registered extension or a built-in template function.
+func (r *Registry) Register(opts *ServerOptions) error {
+	if opts.Name == "" {
+		return ErrMissingName
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, other := range r.extensions {
+		if other.Name == opts.Name {
+			return fmt.Errorf("%v: %s", ErrDuplicateName, opts.Name)
+		}
+		if opts.Matcher != nil && other.Matcher != nil {
+			return fmt.Errorf("%v by %s", ErrMultipleMatchers, other.Name)
+		}
+		for name := range opts.TemplateFuncs {
+			if _, ok := other.TemplateFuncs[name]; ok {
+				return fmt.Errorf("extension: %s template function %q is already registered by %s", opts.Name, name, other.Name)
+			}
+		}
+		for pattern := range opts.Handlers {
+			if _, ok := other.Handlers[pattern]; ok {
+				return fmt.Errorf("extension: %s endpoint %q is already registered by %s", opts.Name, pattern, other.Name)
+			}
+		}
+	}
+	for name := range opts.TemplateFuncs {
+		if reservedFuncs[name] {
+			return fmt.Errorf("extension: %s template function %q is built-in", opts.Name, name)
+		}
+	}
+	r.extensions = append(r.extensions, opts)
+	return nil
+}
+
+// Names returns the names of the registered extensions, in order.
+func (r *Registry) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, len(r.extensions))
+	for i, opts := range r.extensions {
+		names[i] = opts.Name
+	}
+	return names
+}
+
+// Store applies the Store hooks of the registered extensions to a Store.
+func (r *Registry) Store(store storage.Store) (storage.Store, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, opts := range r.extensions {
+		if opts.Store == nil {
+			continue
+		}
+		var err error
+		store, err = opts.Store(store)
+		if err != nil {
+			return nil, fmt.Errorf("extension: %s store: %v", opts.Name, err)
+		}
+	}
+	return store, nil
+}
+
+// Matcher returns the registered Matcher, or nil if there is none.
+func (r *Registry) Matcher() server.Matcher {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, opts := range r.extensions {
+		if opts.Matcher != nil {
+			return opts.Matcher
+		}
+	}
+	return nil
+}
+
+// TemplateFuncs returns the template functions of the registered
+// extensions.
+func (r *Registry) TemplateFuncs() template.FuncMap {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	funcs := template.FuncMap{}
+	for _, opts := range r.extensions {
+		for name, fn := range opts.TemplateFuncs {
+			funcs[name] = fn
+		}
+	}
+	return funcs
+}
+
+// Handlers returns the HTTP endpoints of the registered extensions by
+// pattern.
+func (r *Registry) Handlers() map[string]http.Handler {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	handlers := make(map[string]http.Handler)
+	for _, opts := range r.extensions {
+		for pattern, handler := range opts.Handlers {
+			handlers[pattern] = handler
+		}
+	}
+	return handlers
+}
@@ -0,0 +1,119 @@
+package extension
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"text/template"
+
+	"context"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/storage"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+// fakeMatcher matches every machine to a Group.
+type fakeMatcher struct{}
+
+func (fakeMatcher) Match(ctx context.Context, labels map[string]string) (*storagepb.Group, error) {
+	return fake.Group, nil
+}
+
+// readOnlyStore rejects Group writes.
+type readOnlyStore struct {
+	storage.Store
+}
+
+func (s *readOnlyStore) GroupPut(group *storagepb.Group) error {
+	return errors.New("read-only")
+}
+
+func TestRegistry_Register(t *testing.T) {
+	registry := &Registry{}
+	handler := http.NotFoundHandler()
+	assert.Nil(t, registry.Register(&ServerOptions{
+		Name:          "cmdb",
+		Matcher:       fakeMatcher{},
+		TemplateFuncs: template.FuncMap{"upper": strings.ToUpper},
+		Handlers:      map[string]http.Handler{"/cmdb/": handler},
+	}))
+	cases := []struct {
+		opts *ServerOptions
+		err  string
+	}{
+		{&ServerOptions{}, ErrMissingName.Error()},
+		{&ServerOptions{Name: "cmdb"}, "extension: name is already registered: cmdb"},
+		{&ServerOptions{Name: "other", Matcher: fakeMatcher{}}, "extension: a Matcher is already registered by cmdb"},
+		{&ServerOptions{Name: "other", TemplateFuncs: template.FuncMap{"upper": strings.ToUpper}}, `extension: other template function "upper" is already registered by cmdb`},
+		{&ServerOptions{Name: "other", TemplateFuncs: template.FuncMap{"include": strings.ToUpper}}, `extension: other template function "include" is built-in`},
+		{&ServerOptions{Name: "other", Handlers: map[string]http.Handler{"/cmdb/": handler}}, `extension: other endpoint "/cmdb/" is already registered by cmdb`},
+	}
+	// assert that:
+	// - extensions must be named
+	// - extensions may not register the same name, function, or endpoint,
+	// more than one Matcher, or replace built-in template functions
+	for _, c := range cases {
+		err := registry.Register(c.opts)
+		if assert.Error(t, err) {
+			assert.Equal(t, c.err, err.Error())
+		}
+	}
+	assert.Equal(t, []string{"cmdb"}, registry.Names())
+}
+
+func TestRegistry_Options(t *testing.T) {
+	registry := &Registry{}
+	var order []string
+	assert.Nil(t, registry.Register(&ServerOptions{
+		Name: "readonly",
+		Store: func(store storage.Store) (storage.Store, error) {
+			order = append(order, "readonly")
+			return &readOnlyStore{store}, nil
+		},
+		TemplateFuncs: template.FuncMap{"upper": strings.ToUpper},
+	}))
+	assert.Nil(t, registry.Register(&ServerOptions{
+		Name:          "cmdb",
+		Matcher:       fakeMatcher{},
+		TemplateFuncs: template.FuncMap{"lower": strings.ToLower},
+		Handlers:      map[string]http.Handler{"/cmdb/": http.NotFoundHandler()},
+	}))
+
+	// assert that:
+	// - Store hooks wrap the configured Store in order
+	// - the registered Matcher, template functions, and endpoints are
+	// returned
+	store, err := registry.Store(fake.NewFixedStore())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"readonly"}, order)
+	assert.Error(t, store.GroupPut(fake.Group))
+	assert.Equal(t, fakeMatcher{}, registry.Matcher())
+	funcs := registry.TemplateFuncs()
+	assert.Len(t, funcs, 2)
+	assert.Contains(t, funcs, "upper")
+	assert.Contains(t, funcs, "lower")
+	assert.Contains(t, registry.Handlers(), "/cmdb/")
+
+	// - Store hook errors name the extension
+	assert.Nil(t, registry.Register(&ServerOptions{
+		Name: "broken",
+		Store: func(store storage.Store) (storage.Store, error) {
+			return nil, errors.New("unreachable")
+		},
+	}))
+	_, err = registry.Store(fake.NewFixedStore())
+	assert.EqualError(t, err, "extension: broken store: unreachable")
+}
+
+func TestRegister(t *testing.T) {
+	defer func() { defaultRegistry = &Registry{} }()
+	// assert that:
+	// - Register adds to the default Registry
+	// - invalid registrations panic
+	Register(&ServerOptions{Name: "cmdb"})
+	assert.Equal(t, []string{"cmdb"}, Default().Names())
+	assert.Panics(t, func() { Register(&ServerOptions{Name: "cmdb"}) })
+}
@@ -0,0 +1,13 @@
+// Package fetch is a client for matchbox's machine-facing HTTP endpoints,
+// for custom installers and agents which fetch their configs, metadata, and
+// assets from matchbox. Requests are retried with exponential backoff,
+// configs may be verified against the OpenPGP signatures matchbox serves,
+// and assets are verified against their checksums.
+//
+//	client, err := fetch.New(&fetch.Config{
+//		URL:     "http://matchbox.example.com:8080",
+//		Labels:  map[string]string{"uuid": uuid, "mac": mac},
+//		Keyring: keyring,
+//	})
+//	config, err := client.Ignition(ctx)
+package fetch
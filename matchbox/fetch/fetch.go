@@ -0,0 +1,433 @@
+package fetch
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"context"
+	"github.com/Sirupsen/logrus"
+	"golang.org/x/crypto/openpgp"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// Defaults for retrying requests.
+const (
+	DefaultAttempts   = 5
+	DefaultMinBackoff = time.Second
+	DefaultMaxBackoff = 30 * time.Second
+	// DefaultMaxSize is the largest config or metadata response in bytes
+	DefaultMaxSize = 16 << 20
+)
+
+// Possible fetch errors
+var (
+	ErrMissingURL       = errors.New("fetch: matchbox URL is required")
+	ErrNotFound         = errors.New("fetch: no matching group or profile")
+	ErrTooLarge         = errors.New("fetch: response is too large")
+	ErrUnsigned         = errors.New("fetch: matchbox did not serve a config signature")
+	ErrInvalidSignature = errors.New("fetch: config signature is not from a trusted key")
+	ErrChecksumMismatch = errors.New("fetch: asset checksum does not match")
+)
+
+// StatusError is an unexpected HTTP response status.
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("fetch: unexpected status %d %s", e.Code, http.StatusText(e.Code))
+}
+
+// Config configures a Client.
+type Config struct {
+	// URL of matchbox's HTTP endpoints (e.g. http://matchbox.example.com:8080)
+	URL string
+	// Labels identifying the machine (e.g. uuid, mac), sent as query params
+	Labels map[string]string
+	// (optional) Keyring of keys trusted to sign configs and metadata
+	Keyring openpgp.EntityList
+	// Attempts of each request before giving up (0 uses the default)
+	Attempts int
+	// MinBackoff and MaxBackoff bound the delay between attempts
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// MaxSize is the largest config in bytes (0 uses the default)
+	MaxSize int64
+	// (optional) HTTP client
+	Client *http.Client
+	Logger *logrus.Logger
+}
+
+// Client fetches configs, metadata, and assets for a machine.
+type Client struct {
+	base       *url.URL
+	query      url.Values
+	keyring    openpgp.EntityList
+	attempts   int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	maxSize    int64
+	client     *http.Client
+	logger     *logrus.Logger
+	sleep      func(ctx context.Context, d time.Duration) error
+}
+
+// New returns a new Client.
+func New(config *Config) (*Client, error) {
+	if config.URL == "" {
+		return nil, ErrMissingURL
+	}
+	base, err := url.Parse(strings.TrimSuffix(config.URL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("fetch: invalid matchbox URL: %v", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return nil, fmt.Errorf("fetch: invalid matchbox URL scheme %q", base.Scheme)
+	}
+	query := url.Values{}
+	for key, value := range config.Labels {
+		query.Set(key, value)
+	}
+	attempts := config.Attempts
+	if attempts == 0 {
+		attempts = DefaultAttempts
+	}
+	minBackoff := config.MinBackoff
+	if minBackoff == 0 {
+		minBackoff = DefaultMinBackoff
+	}
+	maxBackoff := config.MaxBackoff
+	if maxBackoff == 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+	maxSize := config.MaxSize
+	if maxSize == 0 {
+		maxSize = DefaultMaxSize
+	}
+	client := config.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = logrus.New()
+	}
+	return &Client{
+		base:       base,
+		query:      query,
+		keyring:    config.Keyring,
+		attempts:   attempts,
+		minBackoff: minBackoff,
+		maxBackoff: maxBackoff,
+		maxSize:    maxSize,
+		client:     client,
+		logger:     logger,
+		sleep:      sleep,
+	}, nil
+}
+
+// Ignition returns the machine's Ignition config.
+func (c *Client) Ignition(ctx context.Context) ([]byte, error) {
+	return c.config(ctx, "/ignition")
+}
+
+// Cloud returns the machine's Cloud-Config.
+func (c *Client) Cloud(ctx context.Context) ([]byte, error) {
+	return c.config(ctx, "/cloud")
+}
+
+// Generic returns the machine's generic config.
+func (c *Client) Generic(ctx context.Context) ([]byte, error) {
+	return c.config(ctx, "/generic")
+}
+
+// Metadata returns the machine's metadata, keyed by the upper case names of
+// the env file matchbox serves (e.g. REQUEST_QUERY_MAC).
+func (c *Client) Metadata(ctx context.Context) (map[string]string, error) {
+	data, err := c.config(ctx, "/metadata")
+	if err != nil {
+		return nil, err
+	}
+	metadata := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), "=", 2)
+		if len(parts) == 2 {
+			metadata[parts[0]] = parts[1]
+		}
+	}
+	return metadata, scanner.Err()
+}
+
+// Asset downloads the asset at a path below /assets/ to the file dest. If
+// checksum is set (e.g. sha512:hex), the download is verified against it
+// before dest is replaced.
+func (c *Client) Asset(ctx context.Context, name, checksum, dest string) error {
+	var (
+		algorithm string
+		digest    []byte
+	)
+	if checksum != "" {
+		var err error
+		algorithm, digest, err = (&storagepb.Asset{Checksum: checksum}).ParseChecksum()
+		if err != nil {
+			return err
+		}
+	}
+	path := "/assets/" + strings.TrimPrefix(name, "/")
+	return c.retry(ctx, path, func() error {
+		resp, err := c.do(ctx, "GET", path, nil)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		return writeAsset(resp.Body, algorithm, digest, dest)
+	})
+}
+
+// Complete reports that the machine finished provisioning, with an optional
+// payload (e.g. an install log). Labels must include the machine's uuid.
+func (c *Client) Complete(ctx context.Context, payload []byte) error {
+	return c.post(ctx, "/v1/complete", payload)
+}
+
+// Progress reports a provisioning step of the machine (e.g. "partition",
+// "running"). Labels must include the machine's uuid.
+func (c *Client) Progress(ctx context.Context, step, status, message string) error {
+	body, err := json.Marshal(&storagepb.MachineStep{Name: step, Status: status, Message: message})
+	if err != nil {
+		return err
+	}
+	return c.post(ctx, "/v1/progress", body)
+}
+
+// config fetches a config endpoint and verifies its signature if a keyring
+// is configured.
+func (c *Client) config(ctx context.Context, path string) ([]byte, error) {
+	var data []byte
+	err := c.retry(ctx, path, func() (err error) {
+		data, err = c.get(ctx, path)
+		return err
+	})
+	if err != nil || c.keyring == nil {
+		return data, err
+	}
+	var signature []byte
+	err = c.retry(ctx, path+".sig", func() (err error) {
+		signature, err = c.get(ctx, path+".sig")
+		return err
+	})
+	if err == ErrNotFound {
+		return nil, ErrUnsigned
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := openpgp.CheckDetachedSignature(c.keyring, bytes.NewReader(data), bytes.NewReader(signature)); err != nil {
+		return nil, ErrInvalidSignature
+	}
+	return data, nil
+}
+
+// get returns the body of a GET request.
+func (c *Client) get(ctx context.Context, path string) ([]byte, error) {
+	resp, err := c.do(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, c.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > c.maxSize {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+// post sends a POST request, retrying failures.
+func (c *Client) post(ctx context.Context, path string, body []byte) error {
+	return c.retry(ctx, path, func() error {
+		resp, err := c.do(ctx, "POST", path, body)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	})
+}
+
+// do sends a request with the machine's labels and returns successful
+// responses. Unsuccessful statuses are returned as errors.
+func (c *Client) do(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	u := *c.base
+	u.Path += path
+	u.RawQuery = c.query.Encode()
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	return nil, &retryAfter{
+		StatusError: &StatusError{Code: resp.StatusCode},
+		wait:        parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+// retry calls fn until it succeeds, fails permanently, the attempts are
+// exhausted, or the context is done.
+func (c *Client) retry(ctx context.Context, path string, fn func() error) error {
+	var err error
+	for attempt := 0; attempt < c.attempts; attempt++ {
+		if attempt > 0 {
+			wait := c.backoff(attempt, err)
+			c.logger.WithFields(logrus.Fields{
+				"path":    path,
+				"attempt": attempt,
+			}).Warningf("fetch failed, retrying in %v: %v", wait, err)
+			if serr := c.sleep(ctx, wait); serr != nil {
+				return serr
+			}
+		}
+		if err = fn(); err == nil || !retryable(err) {
+			return unwrap(err)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return unwrap(err)
+}
+
+// backoff returns the delay before an attempt, doubling from the minimum
+// backoff with jitter, or the server's Retry-After delay.
+func (c *Client) backoff(attempt int, err error) time.Duration {
+	if ra, ok := err.(*retryAfter); ok && ra.wait > 0 {
+		if ra.wait > c.maxBackoff {
+			return c.maxBackoff
+		}
+		return ra.wait
+	}
+	backoff := c.maxBackoff
+	if attempt < 16 && c.minBackoff<<uint(attempt-1) < c.maxBackoff {
+		backoff = c.minBackoff << uint(attempt-1)
+	}
+	// spread retries of machines which failed together
+	if backoff >= 4 {
+		backoff += time.Duration(rand.Int63n(int64(backoff / 4)))
+	}
+	return backoff
+}
+
+// retryAfter is an unsuccessful status with the server's requested delay.
+type retryAfter struct {
+	*StatusError
+	wait time.Duration
+}
+
+// retryable returns true if a failed request may succeed if retried:
+// network errors, server errors, and rate limiting.
+func retryable(err error) bool {
+	switch err {
+	case ErrNotFound, ErrTooLarge, ErrChecksumMismatch, context.Canceled, context.DeadlineExceeded:
+		return false
+	}
+	if ra, ok := err.(*retryAfter); ok {
+		code := ra.Code
+		return code >= 500 || code == http.StatusTooManyRequests || code == http.StatusRequestTimeout
+	}
+	if _, ok := err.(*os.PathError); ok {
+		return false
+	}
+	return true
+}
+
+// unwrap returns the StatusError of unsuccessful statuses.
+func unwrap(err error) error {
+	if ra, ok := err.(*retryAfter); ok {
+		return ra.StatusError
+	}
+	return err
+}
+
+// parseRetryAfter parses a Retry-After header in seconds.
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// writeAsset writes an asset to a temporary file beside dest, verifies its
+// checksum, and renames it to dest.
+func writeAsset(r io.Reader, algorithm string, digest []byte, dest string) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(dest), "."+filepath.Base(dest))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	var h hash.Hash
+	switch algorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	}
+	w := io.Writer(tmp)
+	if h != nil {
+		w = io.MultiWriter(tmp, h)
+	}
+	_, err = io.Copy(w, r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if h != nil && !bytes.Equal(h.Sum(nil), digest) {
+		return ErrChecksumMismatch
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+// sleep waits for a duration or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,220 @@
+package fetch
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"context"
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// newTestClient returns a Client of a test server which records its sleeps.
+func newTestClient(t *testing.T, url string, keyring openpgp.EntityList) (*Client, *[]time.Duration) {
+	logger, _ := logtest.NewNullLogger()
+	client, err := New(&Config{
+		URL:     url,
+		Labels:  map[string]string{"uuid": "a1b2c3d4", "mac": "52:54:00:a1:9c:ae"},
+		Keyring: keyring,
+		Logger:  logger,
+	})
+	assert.Nil(t, err)
+	sleeps := new([]time.Duration)
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		*sleeps = append(*sleeps, d)
+		return ctx.Err()
+	}
+	return client, sleeps
+}
+
+func TestNew(t *testing.T) {
+	// assert that:
+	// - a matchbox URL is required
+	// - only http and https URLs are accepted
+	_, err := New(&Config{})
+	assert.Equal(t, ErrMissingURL, err)
+	_, err = New(&Config{URL: "ftp://matchbox.example.com"})
+	assert.Error(t, err)
+	client, err := New(&Config{URL: "http://matchbox.example.com:8080/"})
+	if assert.Nil(t, err) {
+		assert.Equal(t, DefaultAttempts, client.attempts)
+		assert.Equal(t, "http://matchbox.example.com:8080", client.base.String())
+	}
+}
+
+func TestClient_Ignition(t *testing.T) {
+	entity, err := openpgp.NewEntity("matchbox", "", "matchbox@example.com", nil)
+	assert.Nil(t, err)
+	other, err := openpgp.NewEntity("other", "", "other@example.com", nil)
+	assert.Nil(t, err)
+	config := `{"ignition":{"version":"2.0.0"}}`
+	failures := 2
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "a1b2c3d4", req.URL.Query().Get("uuid"))
+		if failures > 0 {
+			failures--
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		switch req.URL.Path {
+		case "/ignition", "/generic":
+			w.Write([]byte(config))
+		case "/ignition.sig":
+			openpgp.DetachSign(w, entity, strings.NewReader(config), nil)
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer srv.Close()
+
+	// assert that:
+	// - server errors are retried with exponential backoff
+	// - configs are verified against their signature by a trusted key
+	client, sleeps := newTestClient(t, srv.URL, openpgp.EntityList{entity})
+	data, err := client.Ignition(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, config, string(data))
+	if assert.Len(t, *sleeps, 2) {
+		assert.True(t, (*sleeps)[1] >= 2*DefaultMinBackoff)
+	}
+
+	// - signatures by untrusted keys are rejected
+	client, _ = newTestClient(t, srv.URL, openpgp.EntityList{other})
+	_, err = client.Ignition(context.Background())
+	assert.Equal(t, ErrInvalidSignature, err)
+
+	// - unsigned configs are rejected if a keyring is configured
+	_, err = client.Generic(context.Background())
+	assert.Equal(t, ErrUnsigned, err)
+}
+
+func TestClient_Retries(t *testing.T) {
+	status := http.StatusTooManyRequests
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "7")
+		http.Error(w, http.StatusText(status), status)
+	}))
+	defer srv.Close()
+
+	// assert that:
+	// - rate limited requests wait for the Retry-After delay
+	// - requests fail with the last status after all attempts
+	client, sleeps := newTestClient(t, srv.URL, nil)
+	_, err := client.Cloud(context.Background())
+	assert.Equal(t, &StatusError{Code: http.StatusTooManyRequests}, err)
+	assert.Equal(t, DefaultAttempts, requests)
+	assert.Equal(t, 7*time.Second, (*sleeps)[0])
+
+	// - client errors aren't retried
+	status, requests = http.StatusForbidden, 0
+	_, err = client.Cloud(context.Background())
+	assert.Equal(t, &StatusError{Code: http.StatusForbidden}, err)
+	assert.Equal(t, 1, requests)
+
+	// - cancelled contexts stop retrying
+	status, requests = http.StatusBadGateway, 0
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.Cloud(ctx)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestClient_Metadata(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("POD_NETWORK=10.2.0.0/16\nREQUEST_QUERY_UUID=a1b2c3d4\nARGS=a=b\n"))
+	}))
+	defer srv.Close()
+
+	// assert that:
+	// - metadata env files are parsed, values may contain '='
+	client, _ := newTestClient(t, srv.URL, nil)
+	metadata, err := client.Metadata(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"POD_NETWORK":        "10.2.0.0/16",
+		"REQUEST_QUERY_UUID": "a1b2c3d4",
+		"ARGS":               "a=b",
+	}, metadata)
+}
+
+func TestClient_Asset(t *testing.T) {
+	contents := []byte("kernel image")
+	sum := sha256.Sum256(contents)
+	checksum := "sha256:" + hex.EncodeToString(sum[:])
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/assets/fedora-coreos/kernel" {
+			http.NotFound(w, req)
+			return
+		}
+		w.Write(contents)
+	}))
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "fetch")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	dest := filepath.Join(dir, "kernel")
+
+	// assert that:
+	// - assets are downloaded and verified against their checksum
+	client, _ := newTestClient(t, srv.URL, nil)
+	err = client.Asset(context.Background(), "fedora-coreos/kernel", checksum, dest)
+	assert.Nil(t, err)
+	data, err := ioutil.ReadFile(dest)
+	assert.Nil(t, err)
+	assert.Equal(t, contents, data)
+
+	// - mismatched downloads don't replace dest
+	sum = sha256.Sum256([]byte("other"))
+	err = client.Asset(context.Background(), "fedora-coreos/kernel", "sha256:"+hex.EncodeToString(sum[:]), dest)
+	assert.Equal(t, ErrChecksumMismatch, err)
+	data, _ = ioutil.ReadFile(dest)
+	assert.Equal(t, contents, data)
+	files, _ := ioutil.ReadDir(dir)
+	assert.Len(t, files, 1)
+
+	// - invalid checksums and missing assets are errors
+	err = client.Asset(context.Background(), "fedora-coreos/kernel", "md5:abc", dest)
+	assert.Equal(t, storagepb.ErrInvalidChecksum, err)
+	err = client.Asset(context.Background(), "missing", "", dest)
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestClient_Report(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "POST", req.Method)
+		assert.Equal(t, "a1b2c3d4", req.URL.Query().Get("uuid"))
+		body, _ := ioutil.ReadAll(req.Body)
+		bodies = append(bodies, req.URL.Path+" "+string(body))
+	}))
+	defer srv.Close()
+
+	// assert that:
+	// - progress steps are posted as JSON
+	// - completions are posted with their payload
+	client, _ := newTestClient(t, srv.URL, nil)
+	assert.Nil(t, client.Progress(context.Background(), "partition", "running", ""))
+	assert.Nil(t, client.Complete(context.Background(), []byte("installed")))
+	if assert.Len(t, bodies, 2) {
+		step := new(storagepb.MachineStep)
+		assert.Nil(t, json.NewDecoder(bytes.NewReader([]byte(strings.TrimPrefix(bodies[0], "/v1/progress ")))).Decode(step))
+		assert.Equal(t, "partition", step.Name)
+		assert.Equal(t, "running", step.Status)
+		assert.Equal(t, "/v1/complete installed", bodies[1])
+	}
+}
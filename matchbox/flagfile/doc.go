@@ -0,0 +1,4 @@
+// Package flagfile sets command-line flags from a YAML configuration file,
+// so deployments can be reviewed and versioned as config rather than as a
+// long list of flags.
+package flagfile
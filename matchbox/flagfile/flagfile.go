@@ -0,0 +1,215 @@
+package flagfile
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// SetFlagsFromFile sets the flags of the FlagSet which are not already set
+// (e.g. on the command-line or from environment variables) from the YAML
+// configuration file at path.
+//
+// Keys are flag names. Keys of nested maps are joined to their parent's key
+// with a dash, so a "log" map with a "level" key sets the log-level flag.
+// Lists are joined with commas, as are the KEY=VALUE pairs of a map which is
+// the value of a flag (e.g. oidc-roles). Unknown flags are errors, so typos
+// aren't ignored.
+func SetFlagsFromFile(fs *flag.FlagSet, path string) error {
+	_, err := Load(fs, path)
+	return err
+}
+
+// A File is a YAML configuration file whose flag values have been set, which
+// can be read again to find the values which changed.
+type File struct {
+	fs   *flag.FlagSet
+	path string
+	// flags set before the file was loaded, which it doesn't override
+	overridden map[string]bool
+
+	mu     sync.Mutex
+	values map[string]string
+}
+
+// Load sets the flags of the FlagSet which are not already set from the
+// YAML configuration file at path, as SetFlagsFromFile does, and returns
+// the File.
+func Load(fs *flag.FlagSet, path string) (*File, error) {
+	values, err := readFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{
+		fs:         fs,
+		path:       path,
+		overridden: make(map[string]bool),
+		values:     values,
+	}
+	fs.Visit(func(fl *flag.Flag) {
+		f.overridden[fl.Name] = true
+	})
+	for _, name := range sortedNames(values) {
+		if f.overridden[name] {
+			continue
+		}
+		if err := fs.Set(name, values[name]); err != nil {
+			return nil, fmt.Errorf("flagfile: %s: invalid value %q for %s: %v", path, values[name], name, err)
+		}
+	}
+	return f, nil
+}
+
+// Path returns the path of the configuration file.
+func (f *File) Path() string {
+	return f.path
+}
+
+// Reload reads the configuration file again and returns the new values of
+// the flags which changed since it was last read, excluding flags which
+// were set on the command-line or from environment variables. Flags removed
+// from the file change to their default value. The FlagSet isn't modified,
+// callers apply the changes they support.
+func (f *File) Reload() (map[string]string, error) {
+	values, err := readFile(f.fs, f.path)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	changed := make(map[string]string)
+	for name, value := range values {
+		if previous, ok := f.values[name]; (!ok || previous != value) && !f.overridden[name] {
+			changed[name] = value
+		}
+	}
+	for name := range f.values {
+		if _, ok := values[name]; !ok && !f.overridden[name] {
+			changed[name] = f.fs.Lookup(name).DefValue
+		}
+	}
+	f.values = values
+	return changed, nil
+}
+
+// readFile reads and parses the YAML configuration file at path.
+func readFile(fs *flag.FlagSet, path string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values, err := parse(fs, data)
+	if err != nil {
+		return nil, fmt.Errorf("flagfile: %s: %v", path, err)
+	}
+	return values, nil
+}
+
+// sortedNames returns the flag names of values, sorted.
+func sortedNames(values map[string]string) []string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parse parses a YAML configuration into flag values by flag name.
+func parse(fs *flag.FlagSet, data []byte) (map[string]string, error) {
+	var config map[string]interface{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	for key, value := range config {
+		if err := flatten(fs, key, value, values); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// flatten adds the flag values of a configuration key, recursing into
+// nested maps which aren't flag values.
+func flatten(fs *flag.FlagSet, name string, value interface{}, values map[string]string) error {
+	isFlag := fs.Lookup(name) != nil
+	if m, ok := value.(map[interface{}]interface{}); ok && !isFlag {
+		for key, nested := range m {
+			if err := flatten(fs, name+"-"+fmt.Sprint(key), nested, values); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if !isFlag {
+		return fmt.Errorf("unknown flag %q", name)
+	}
+	if _, ok := values[name]; ok {
+		return fmt.Errorf("flag %q is set more than once", name)
+	}
+	formatted, err := format(value)
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %v", name, err)
+	}
+	values[name] = formatted
+	return nil
+}
+
+// format formats a YAML value as a flag value.
+func format(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			s, ok := scalar(item)
+			if !ok {
+				return "", fmt.Errorf("list items must be scalars")
+			}
+			items[i] = s
+		}
+		return strings.Join(items, ","), nil
+	case map[interface{}]interface{}:
+		pairs := make([]string, 0, len(v))
+		for key, item := range v {
+			s, ok := scalar(item)
+			if !ok {
+				return "", fmt.Errorf("map values must be scalars")
+			}
+			pairs = append(pairs, fmt.Sprint(key)+"="+s)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ","), nil
+	}
+	if s, ok := scalar(value); ok {
+		return s, nil
+	}
+	return "", fmt.Errorf("unsupported value %v", value)
+}
+
+// scalar formats a YAML scalar, returning false if the value isn't one.
+func scalar(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", true
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	}
+	return "", false
+}
@@ -0,0 +1,154 @@
+package flagfile
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testFlags returns a FlagSet with flags of each kind.
+func testFlags() *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("address", "127.0.0.1:8080", "")
+	fs.String("rpc-address", "", "")
+	fs.Bool("rpc-gzip", false, "")
+	fs.String("log-level", "info", "")
+	fs.Int("install-attempts", 0, "")
+	fs.Duration("http-read-timeout", time.Minute, "")
+	fs.String("allow-http", "", "")
+	fs.String("oidc-roles", "", "")
+	return fs
+}
+
+// writeConfig writes a configuration file to a temporary directory.
+func writeConfig(t *testing.T, contents string) string {
+	dir, err := ioutil.TempDir("", "flagfile")
+	assert.Nil(t, err)
+	path := filepath.Join(dir, "matchbox.yaml")
+	assert.Nil(t, ioutil.WriteFile(path, []byte(contents), 0644))
+	return path
+}
+
+func TestSetFlagsFromFile(t *testing.T) {
+	path := writeConfig(t, `
+address: 0.0.0.0:8080
+rpc:
+  address: 0.0.0.0:8081
+  gzip: true
+log:
+  level: debug
+install-attempts: 5
+http-read-timeout: 30s
+allow-http:
+  - 10.0.0.0/8
+  - 192.168.0.0/16
+oidc-roles:
+  sre: editor
+  eng: viewer
+`)
+	defer os.RemoveAll(filepath.Dir(path))
+	fs := testFlags()
+	assert.Nil(t, fs.Parse([]string{"-log-level", "warning"}))
+
+	// assert that:
+	// - flags are set from top-level and nested keys
+	// - lists and the pairs of flag maps are joined with commas
+	// - flags which are already set are not overridden
+	err := SetFlagsFromFile(fs, path)
+	assert.Nil(t, err)
+	expected := map[string]string{
+		"address":           "0.0.0.0:8080",
+		"rpc-address":       "0.0.0.0:8081",
+		"rpc-gzip":          "true",
+		"log-level":         "warning",
+		"install-attempts":  "5",
+		"http-read-timeout": "30s",
+		"allow-http":        "10.0.0.0/8,192.168.0.0/16",
+		"oidc-roles":        "eng=viewer,sre=editor",
+	}
+	for name, value := range expected {
+		assert.Equal(t, value, fs.Lookup(name).Value.String(), name)
+	}
+}
+
+func TestSetFlagsFromFile_Errors(t *testing.T) {
+	cases := []struct {
+		contents string
+		err      string
+	}{
+		{"adress: 0.0.0.0:8080", `unknown flag "adress"`},
+		{"rpc:\n  adress: 0.0.0.0:8081", `unknown flag "rpc-adress"`},
+		{"rpc-address: a\nrpc:\n  address: b", `flag "rpc-address" is set more than once`},
+		{"install-attempts: many", `invalid value "many" for install-attempts`},
+		{"allow-http:\n  - [10.0.0.0/8]", "list items must be scalars"},
+		{"address: [", "yaml:"},
+	}
+	// assert that:
+	// - unknown flags, duplicate flags, invalid values, and invalid YAML
+	// are errors
+	for _, c := range cases {
+		path := writeConfig(t, c.contents)
+		err := SetFlagsFromFile(testFlags(), path)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), c.err)
+		}
+		os.RemoveAll(filepath.Dir(path))
+	}
+	assert.Error(t, SetFlagsFromFile(testFlags(), "/does/not/exist.yaml"))
+}
+
+func TestFile_Reload(t *testing.T) {
+	path := writeConfig(t, `
+address: 0.0.0.0:8080
+log-level: info
+install-attempts: 5
+rpc-gzip: true
+`)
+	defer os.RemoveAll(filepath.Dir(path))
+	fs := testFlags()
+	assert.Nil(t, fs.Parse([]string{"-address", "127.0.0.1:8080"}))
+	f, err := Load(fs, path)
+	assert.Nil(t, err)
+	assert.Equal(t, path, f.Path())
+	assert.Equal(t, "info", fs.Lookup("log-level").Value.String())
+
+	// assert that:
+	// - unchanged files have no changes
+	changed, err := f.Reload()
+	assert.Nil(t, err)
+	assert.Empty(t, changed)
+
+	// - changed, added, and removed flags are changes, removed flags change
+	// to their default value
+	// - flags set before loading aren't changes
+	// - the FlagSet isn't modified
+	assert.Nil(t, ioutil.WriteFile(path, []byte(`
+address: 10.0.0.1:8080
+log-level: debug
+install-attempts: 5
+http-read-timeout: 30s
+`), 0644))
+	changed, err = f.Reload()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"log-level":         "debug",
+		"http-read-timeout": "30s",
+		"rpc-gzip":          "false",
+	}, changed)
+	assert.Equal(t, "info", fs.Lookup("log-level").Value.String())
+
+	// - changes are relative to the last read
+	changed, err = f.Reload()
+	assert.Nil(t, err)
+	assert.Empty(t, changed)
+
+	// - invalid files are errors
+	assert.Nil(t, ioutil.WriteFile(path, []byte("adress: 0.0.0.0:8080"), 0644))
+	_, err = f.Reload()
+	assert.Error(t, err)
+}
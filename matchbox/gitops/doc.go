@@ -0,0 +1,3 @@
+// Package gitops continuously reconciles Groups, Profiles, and templates in
+// the store from a Git repository.
+package gitops
@@ -0,0 +1,85 @@
+package gitops
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"context"
+)
+
+// repository is a local mirror of a remote Git repository, which is read
+// with the git command.
+type repository struct {
+	url string
+	dir string
+}
+
+// git runs a git command in the mirror and returns its output.
+func (r *repository) git(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"--git-dir", r.dir}, args...)...)
+	// never prompt for credentials
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// fetch clones the remote repository, or fetches its changes.
+func (r *repository) fetch(ctx context.Context) error {
+	if _, err := os.Stat(filepath.Join(r.dir, "HEAD")); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(r.dir), 0755); err != nil {
+			return err
+		}
+		cmd := exec.CommandContext(ctx, "git", "clone", "--mirror", "--quiet", r.url, r.dir)
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			os.RemoveAll(r.dir)
+			return fmt.Errorf("git clone: %v: %s", err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+	_, err := r.git(ctx, "fetch", "--quiet", "--prune", "--force", "--tags", "origin")
+	return err
+}
+
+// resolve returns the commit id of a branch, tag, or commit.
+func (r *repository) resolve(ctx context.Context, ref string) (string, error) {
+	out, err := r.git(ctx, "rev-parse", "--verify", "--end-of-options", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("gitops: unknown ref %q", ref)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// commit returns the raw commit object.
+func (r *repository) commit(ctx context.Context, rev string) ([]byte, error) {
+	return r.git(ctx, "cat-file", "commit", rev)
+}
+
+// files returns the paths of the files under dir in a commit.
+func (r *repository) files(ctx context.Context, rev, dir string) ([]string, error) {
+	out, err := r.git(ctx, "ls-tree", "-r", "-z", "--name-only", "--full-tree", rev, "--", dir)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, path := range strings.Split(string(out), "\x00") {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// read returns the contents of a file in a commit.
+func (r *repository) read(ctx context.Context, rev, path string) ([]byte, error) {
+	return r.git(ctx, "cat-file", "blob", rev+":"+path)
+}
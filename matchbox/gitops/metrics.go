@@ -0,0 +1,18 @@
+package gitops
+
+import (
+	"github.com/coreos/matchbox/matchbox/metrics"
+)
+
+var (
+	syncTotal = metrics.NewCounterVec(
+		"matchbox_gitops_reconcile_total",
+		"Git reconciliations by result (ok or error).",
+		"result")
+	syncState = metrics.NewGaugeVec(
+		"matchbox_gitops_synced",
+		"Whether the last Git reconciliation succeeded (1) or failed (0).")
+	lastSync = metrics.NewGaugeVec(
+		"matchbox_gitops_last_sync_timestamp_seconds",
+		"Unix time of the last successful Git reconciliation.")
+)
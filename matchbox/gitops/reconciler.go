@@ -0,0 +1,384 @@
+package gitops
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"context"
+	"github.com/Sirupsen/logrus"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/crypto/openpgp"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// Defaults for reconciliation.
+const (
+	DefaultRef      = "HEAD"
+	DefaultInterval = time.Minute
+)
+
+// Config configures a Reconciler.
+type Config struct {
+	// Repository URL to clone
+	Repository string
+	// Ref is the branch, tag, or commit to apply (defaults to the remote's
+	// default branch)
+	Ref string
+	// Path of the directory in the repository which holds groups, profiles,
+	// ignition, cloud, and generic directories (as in -data-path)
+	Path string
+	// CacheDir holds the local mirror of the repository
+	CacheDir string
+	// (optional) keys which must have signed applied commits
+	Keyring openpgp.EntityList
+	// Prune deletes Groups, Profiles, and templates which are not in the
+	// repository
+	Prune bool
+	// Interval between reconciliations
+	Interval time.Duration
+	Server   server.Server
+	Logger   *logrus.Logger
+}
+
+// Status is the state of reconciliation.
+type Status struct {
+	Repository string `json:"repository"`
+	Ref        string `json:"ref"`
+	// Revision is the last applied commit
+	Revision string `json:"revision,omitempty"`
+	// Synced is true if the last reconciliation succeeded
+	Synced bool `json:"synced"`
+	// LastSync is the time of the last successful reconciliation
+	LastSync time.Time `json:"last_sync,omitempty"`
+	// LastAttempt is the time of the last reconciliation
+	LastAttempt time.Time `json:"last_attempt,omitempty"`
+	// Error of the last reconciliation, if it failed
+	Error string `json:"error,omitempty"`
+}
+
+// resources are the desired resources in a commit.
+type resources struct {
+	groups    map[string]*storagepb.Group
+	profiles  map[string]*storagepb.Profile
+	templates map[string]map[string]string
+}
+
+// template kinds (directories)
+const (
+	kindIgnition = "ignition"
+	kindCloud    = "cloud"
+	kindGeneric  = "generic"
+)
+
+var templateKinds = []string{kindIgnition, kindCloud, kindGeneric}
+
+// Reconciler applies the Groups, Profiles, and templates in a Git
+// repository to the store, so the repository is the source of truth.
+type Reconciler struct {
+	config *Config
+	repo   *repository
+
+	mu     sync.Mutex
+	status Status
+	now    func() time.Time
+}
+
+// NewReconciler returns a new Reconciler.
+func NewReconciler(config *Config) *Reconciler {
+	if config.Ref == "" {
+		config.Ref = DefaultRef
+	}
+	if config.Interval == 0 {
+		config.Interval = DefaultInterval
+	}
+	return &Reconciler{
+		config: config,
+		repo:   &repository{url: config.Repository, dir: config.CacheDir},
+		status: Status{Repository: config.Repository, Ref: config.Ref},
+		now:    time.Now,
+	}
+}
+
+// Run reconciles at each interval until the stop channel is closed.
+func (r *Reconciler) Run(stop <-chan struct{}) {
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), r.config.Interval)
+		if err := r.Reconcile(ctx); err != nil {
+			r.config.Logger.Errorf("gitops: error reconciling %s: %v", r.config.Repository, err)
+		}
+		cancel()
+		select {
+		case <-stop:
+			return
+		case <-time.After(r.config.Interval):
+		}
+	}
+}
+
+// Status returns the state of reconciliation.
+func (r *Reconciler) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+// Reconcile fetches the repository, verifies the commit of the ref, and
+// applies its resources to the store.
+func (r *Reconciler) Reconcile(ctx context.Context) error {
+	rev, err := r.reconcile(ctx)
+	now := r.now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status.LastAttempt = now
+	r.status.Synced = err == nil
+	if err != nil {
+		r.status.Error = err.Error()
+		syncTotal.Inc("error")
+		syncState.Set(0)
+		return err
+	}
+	r.status.Error = ""
+	r.status.Revision = rev
+	r.status.LastSync = now
+	syncTotal.Inc("ok")
+	syncState.Set(1)
+	lastSync.Set(float64(now.Unix()))
+	return nil
+}
+
+func (r *Reconciler) reconcile(ctx context.Context) (string, error) {
+	if err := r.repo.fetch(ctx); err != nil {
+		return "", err
+	}
+	rev, err := r.repo.resolve(ctx, r.config.Ref)
+	if err != nil {
+		return "", err
+	}
+	if len(r.config.Keyring) > 0 {
+		raw, err := r.repo.commit(ctx, rev)
+		if err != nil {
+			return "", err
+		}
+		if err := verifyCommit(raw, r.config.Keyring); err != nil {
+			return "", fmt.Errorf("%v: %s", err, rev)
+		}
+	}
+	desired, err := r.load(ctx, rev)
+	if err != nil {
+		return "", fmt.Errorf("gitops: %s: %v", rev, err)
+	}
+	changes, err := r.apply(ctx, desired)
+	if changes > 0 {
+		r.config.Logger.Infof("gitops: applied %d changes from %s", changes, rev)
+	}
+	if err != nil {
+		return "", err
+	}
+	return rev, nil
+}
+
+// load reads and validates the resources in a commit.
+func (r *Reconciler) load(ctx context.Context, rev string) (*resources, error) {
+	desired := &resources{
+		groups:    make(map[string]*storagepb.Group),
+		profiles:  make(map[string]*storagepb.Profile),
+		templates: make(map[string]map[string]string),
+	}
+	read := func(dir string, fn func(name string, data []byte) error) error {
+		root := path.Join(r.config.Path, dir)
+		files, err := r.repo.files(ctx, rev, root)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			data, err := r.repo.read(ctx, rev, file)
+			if err != nil {
+				return err
+			}
+			if err := fn(strings.TrimPrefix(file, root+"/"), data); err != nil {
+				return storagepb.FileError(file, err)
+			}
+		}
+		return nil
+	}
+
+	err := read("groups", func(name string, data []byte) error {
+		group, err := storagepb.ParseGroupFile(name, data)
+		if err != nil {
+			return err
+		}
+		if group.Id == "" {
+			group.Id = strings.TrimSuffix(name, path.Ext(name))
+		}
+		if err := group.AssertValid(); err != nil {
+			return err
+		}
+		desired.groups[group.Id] = group
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = read("profiles", func(name string, data []byte) error {
+		profile, err := storagepb.ParseProfileFile(name, data)
+		if err != nil {
+			return err
+		}
+		if profile.Id == "" {
+			profile.Id = strings.TrimSuffix(name, path.Ext(name))
+		}
+		if err := profile.AssertValid(); err != nil {
+			return err
+		}
+		desired.profiles[profile.Id] = profile
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, kind := range templateKinds {
+		templates := make(map[string]string)
+		err := read(kind, func(name string, data []byte) error {
+			templates[name] = string(data)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		desired.templates[kind] = templates
+	}
+	return desired, nil
+}
+
+// apply puts changed resources into the store, templates first so Profiles
+// reference existing templates, and prunes resources which are not desired
+// in the reverse order. Returns the number of changes.
+func (r *Reconciler) apply(ctx context.Context, desired *resources) (int, error) {
+	srv := r.config.Server
+	changes := 0
+
+	for _, kind := range templateKinds {
+		for name, contents := range desired.templates[kind] {
+			current, err := r.templateGet(ctx, kind, name)
+			if err == nil && current == contents {
+				continue
+			}
+			if err := r.templatePut(ctx, kind, name, contents); err != nil {
+				return changes, err
+			}
+			changes++
+		}
+	}
+	for id, profile := range desired.profiles {
+		current, err := srv.ProfileGet(ctx, &pb.ProfileGetRequest{Id: id})
+		if err == nil && proto.Equal(current, profile) {
+			continue
+		}
+		if _, err := srv.ProfilePut(ctx, &pb.ProfilePutRequest{Profile: profile}); err != nil {
+			return changes, err
+		}
+		changes++
+	}
+	for id, group := range desired.groups {
+		current, err := srv.GroupGet(ctx, &pb.GroupGetRequest{Id: id})
+		if err == nil && proto.Equal(current, group) {
+			continue
+		}
+		if _, err := srv.GroupPut(ctx, &pb.GroupPutRequest{Group: group}); err != nil {
+			return changes, err
+		}
+		changes++
+	}
+	if !r.config.Prune {
+		return changes, nil
+	}
+
+	groups, err := srv.GroupList(ctx, &pb.GroupListRequest{})
+	if err != nil {
+		return changes, err
+	}
+	for _, group := range groups {
+		if _, ok := desired.groups[group.Id]; !ok {
+			if err := srv.GroupDelete(ctx, &pb.GroupDeleteRequest{Id: group.Id}); err != nil {
+				return changes, err
+			}
+			changes++
+		}
+	}
+	profiles, err := srv.ProfileList(ctx, &pb.ProfileListRequest{})
+	if err != nil {
+		return changes, err
+	}
+	for _, profile := range profiles {
+		if _, ok := desired.profiles[profile.Id]; !ok {
+			if err := srv.ProfileDelete(ctx, &pb.ProfileDeleteRequest{Id: profile.Id}); err != nil {
+				return changes, err
+			}
+			changes++
+		}
+	}
+	for _, kind := range templateKinds {
+		names, err := r.templateList(ctx, kind)
+		if err != nil {
+			return changes, err
+		}
+		for _, name := range names {
+			if _, ok := desired.templates[kind][name]; !ok {
+				if err := r.templateDelete(ctx, kind, name); err != nil {
+					return changes, err
+				}
+				changes++
+			}
+		}
+	}
+	return changes, nil
+}
+
+func (r *Reconciler) templateGet(ctx context.Context, kind, name string) (string, error) {
+	switch kind {
+	case kindIgnition:
+		return r.config.Server.IgnitionGet(ctx, name)
+	case kindCloud:
+		return r.config.Server.CloudGet(ctx, name)
+	}
+	return r.config.Server.GenericGet(ctx, name)
+}
+
+func (r *Reconciler) templatePut(ctx context.Context, kind, name, contents string) error {
+	var err error
+	switch kind {
+	case kindIgnition:
+		_, err = r.config.Server.IgnitionPut(ctx, &pb.IgnitionPutRequest{Name: name, Config: []byte(contents)})
+	case kindCloud:
+		_, err = r.config.Server.CloudPut(ctx, &pb.CloudPutRequest{Name: name, Config: []byte(contents)})
+	default:
+		_, err = r.config.Server.GenericPut(ctx, &pb.GenericPutRequest{Name: name, Config: []byte(contents)})
+	}
+	return err
+}
+
+func (r *Reconciler) templateList(ctx context.Context, kind string) ([]string, error) {
+	switch kind {
+	case kindIgnition:
+		return r.config.Server.IgnitionList(ctx, &pb.IgnitionListRequest{})
+	case kindCloud:
+		return r.config.Server.CloudList(ctx, &pb.CloudListRequest{})
+	}
+	return r.config.Server.GenericList(ctx, &pb.GenericListRequest{})
+}
+
+func (r *Reconciler) templateDelete(ctx context.Context, kind, name string) error {
+	switch kind {
+	case kindIgnition:
+		return r.config.Server.IgnitionDelete(ctx, &pb.IgnitionDeleteRequest{Name: name})
+	case kindCloud:
+		return r.config.Server.CloudDelete(ctx, &pb.CloudDeleteRequest{Name: name})
+	}
+	return r.config.Server.GenericDelete(ctx, &pb.GenericDeleteRequest{Name: name})
+}
@@ -0,0 +1,193 @@
+package gitops
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"context"
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+// testRepo is a Git repository in a temporary directory.
+type testRepo struct {
+	t   *testing.T
+	dir string
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "matchbox-gitops")
+	assert.Nil(t, err)
+	r := &testRepo{t: t, dir: dir}
+	r.git("init", "--quiet")
+	return r
+}
+
+func (r *testRepo) git(args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("git %v: %v: %s", args, err, out)
+	}
+	return string(out)
+}
+
+// commit writes the files (removing those with empty contents) and commits.
+func (r *testRepo) commit(files map[string]string) {
+	for name, contents := range files {
+		path := filepath.Join(r.dir, name)
+		if contents == "" {
+			os.Remove(path)
+			continue
+		}
+		assert.Nil(r.t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(r.t, ioutil.WriteFile(path, []byte(contents), 0644))
+	}
+	r.git("add", "-A")
+	r.git("commit", "--quiet", "--allow-empty", "-m", "update")
+}
+
+func newTestReconciler(t *testing.T, repo *testRepo, store *fake.FixedStore, prune bool) *Reconciler {
+	cache, err := ioutil.TempDir("", "matchbox-gitops-cache")
+	assert.Nil(t, err)
+	logger, _ := logtest.NewNullLogger()
+	return NewReconciler(&Config{
+		Repository: repo.dir,
+		CacheDir:   filepath.Join(cache, "mirror.git"),
+		Prune:      prune,
+		Server:     server.NewServer(&server.Config{Store: store}),
+		Logger:     logger,
+	})
+}
+
+const (
+	testGroup   = `{"id": "node1", "profile": "worker", "selector": {"mac": "52:54:00:a1:9c:ae"}}`
+	testProfile = `{"id": "worker", "ignition_id": "worker.yaml"}`
+)
+
+func TestReconcile(t *testing.T) {
+	repo := newTestRepo(t)
+	defer os.RemoveAll(repo.dir)
+	repo.commit(map[string]string{
+		"groups/node1.json":     testGroup,
+		"profiles/worker.json":  testProfile,
+		"ignition/worker.yaml":  "systemd: {}",
+		"generic/a/config.json": "{}",
+	})
+	store := fake.NewFixedStore()
+	store.Groups[fake.Group.Id] = fake.Group
+	r := newTestReconciler(t, repo, store, false)
+
+	// assert that:
+	// - Groups, Profiles, and templates are applied
+	// - resources not in the repository are kept without pruning
+	err := r.Reconcile(context.Background())
+	if assert.Nil(t, err) {
+		assert.Equal(t, "worker", store.Groups["node1"].Profile)
+		assert.Equal(t, "worker.yaml", store.Profiles["worker"].IgnitionId)
+		assert.Equal(t, "systemd: {}", store.IgnitionConfigs["worker.yaml"])
+		assert.Equal(t, "{}", store.GenericConfigs["a/config.json"])
+		assert.Contains(t, store.Groups, fake.Group.Id)
+	}
+	status := r.Status()
+	assert.True(t, status.Synced)
+	assert.Equal(t, repo.git("rev-parse", "HEAD")[:40], status.Revision)
+	assert.Empty(t, status.Error)
+}
+
+func TestReconcile_Prune(t *testing.T) {
+	repo := newTestRepo(t)
+	defer os.RemoveAll(repo.dir)
+	repo.commit(map[string]string{
+		"groups/node1.json":    testGroup,
+		"profiles/worker.json": testProfile,
+		"ignition/worker.yaml": "systemd: {}",
+	})
+	store := fake.NewFixedStore()
+	store.Groups[fake.Group.Id] = fake.Group
+	r := newTestReconciler(t, repo, store, true)
+
+	// assert that:
+	// - resources not in the repository are pruned
+	// - resources removed by a later commit are pruned
+	assert.Nil(t, r.Reconcile(context.Background()))
+	assert.NotContains(t, store.Groups, fake.Group.Id)
+	assert.Contains(t, store.Groups, "node1")
+
+	repo.commit(map[string]string{"groups/node1.json": ""})
+	assert.Nil(t, r.Reconcile(context.Background()))
+	assert.NotContains(t, store.Groups, "node1")
+	assert.Contains(t, store.Profiles, "worker")
+}
+
+func TestReconcile_Ref(t *testing.T) {
+	repo := newTestRepo(t)
+	defer os.RemoveAll(repo.dir)
+	repo.commit(map[string]string{"groups/node1.json": testGroup})
+	repo.git("tag", "v1")
+	repo.commit(map[string]string{"groups/node1.json": `{"id": "node1", "profile": "other"}`})
+	store := fake.NewFixedStore()
+	r := newTestReconciler(t, repo, store, false)
+
+	// assert that:
+	// - the pinned tag is applied rather than the latest commit
+	// - an unknown ref fails and is reported in the status
+	r.config.Ref = "v1"
+	assert.Nil(t, r.Reconcile(context.Background()))
+	assert.Equal(t, "worker", store.Groups["node1"].Profile)
+
+	r.config.Ref = "missing"
+	err := r.Reconcile(context.Background())
+	assert.Error(t, err)
+	status := r.Status()
+	assert.False(t, status.Synced)
+	assert.Equal(t, err.Error(), status.Error)
+	assert.Equal(t, repo.git("rev-parse", "v1")[:40], status.Revision)
+}
+
+func TestReconcile_Invalid(t *testing.T) {
+	repo := newTestRepo(t)
+	defer os.RemoveAll(repo.dir)
+	repo.commit(map[string]string{
+		"profiles/worker.json": testProfile,
+		"groups/bad.json":      `{"profile": "worker", `,
+	})
+	store := fake.NewFixedStore()
+	r := newTestReconciler(t, repo, store, false)
+
+	// assert that nothing is applied when any resource is invalid
+	assert.Error(t, r.Reconcile(context.Background()))
+	assert.Empty(t, store.Profiles)
+}
+
+func TestReconcile_Unsigned(t *testing.T) {
+	repo := newTestRepo(t)
+	defer os.RemoveAll(repo.dir)
+	repo.commit(map[string]string{"profiles/worker.json": testProfile})
+	store := fake.NewFixedStore()
+	r := newTestReconciler(t, repo, store, false)
+	entity, err := openpgp.NewEntity("trusted", "", "trusted@example.com", nil)
+	assert.Nil(t, err)
+	r.config.Keyring = openpgp.EntityList{entity}
+
+	// assert that unsigned commits are not applied when a keyring is set
+	err = r.Reconcile(context.Background())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), ErrUnsignedCommit.Error())
+	}
+	assert.Empty(t, store.Profiles)
+}
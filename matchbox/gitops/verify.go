@@ -0,0 +1,69 @@
+package gitops
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// Possible signature errors
+var (
+	ErrUnsignedCommit   = errors.New("gitops: commit is not signed")
+	ErrInvalidSignature = errors.New("gitops: commit signature is not from a trusted key")
+)
+
+// LoadKeyring reads an armored or binary OpenPGP public keyring.
+func LoadKeyring(path string) (openpgp.EntityList, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data)); err == nil {
+		return keyring, nil
+	}
+	return openpgp.ReadKeyRing(bytes.NewReader(data))
+}
+
+// verifyCommit verifies the OpenPGP signature of a raw commit object was
+// made by a key in the keyring.
+func verifyCommit(raw []byte, keyring openpgp.EntityList) error {
+	payload, signature := splitSignature(raw)
+	if signature == nil {
+		return ErrUnsignedCommit
+	}
+	if _, err := openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(payload), bytes.NewReader(signature)); err != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// splitSignature separates the gpgsig header (and its continuation lines)
+// from a commit object. The remainder is the signed payload.
+func splitSignature(raw []byte) (payload, signature []byte) {
+	var out, sig bytes.Buffer
+	lines := bytes.SplitAfter(raw, []byte("\n"))
+	inHeaders, inSig := true, false
+	for _, line := range lines {
+		switch {
+		case !inHeaders:
+			out.Write(line)
+		case inSig && bytes.HasPrefix(line, []byte(" ")):
+			sig.Write(line[1:])
+		case bytes.HasPrefix(line, []byte("gpgsig ")):
+			inSig = true
+			sig.Write(line[len("gpgsig "):])
+		default:
+			inSig = false
+			if len(bytes.TrimRight(line, "\n")) == 0 {
+				inHeaders = false
+			}
+			out.Write(line)
+		}
+	}
+	if sig.Len() == 0 {
+		return raw, nil
+	}
+	return out.Bytes(), sig.Bytes()
+}
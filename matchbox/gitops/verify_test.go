@@ -0,0 +1,79 @@
+package gitops
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+const testPayload = `tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904
+author test <test@example.com> 1500000000 +0000
+committer test <test@example.com> 1500000000 +0000
+
+update
+`
+
+// signCommit returns a commit object with a gpgsig header signed by the
+// entity.
+func signCommit(t *testing.T, entity *openpgp.Entity, payload string) []byte {
+	var sig bytes.Buffer
+	assert.Nil(t, openpgp.ArmoredDetachSign(&sig, entity, strings.NewReader(payload), nil))
+	header := "gpgsig " + strings.Replace(strings.TrimRight(sig.String(), "\n"), "\n", "\n ", -1) + "\n"
+	i := strings.Index(payload, "\n\n")
+	return []byte(payload[:i+1] + header + payload[i+1:])
+}
+
+func TestVerifyCommit(t *testing.T) {
+	trusted, err := openpgp.NewEntity("trusted", "", "trusted@example.com", nil)
+	assert.Nil(t, err)
+	other, err := openpgp.NewEntity("other", "", "other@example.com", nil)
+	assert.Nil(t, err)
+	keyring := openpgp.EntityList{trusted}
+
+	signed := signCommit(t, trusted, testPayload)
+	payload, sig := splitSignature(signed)
+	// assert that:
+	// - the payload excludes the gpgsig header
+	// - commits signed by a trusted key are verified
+	// - unsigned commits, commits signed by other keys, and modified commits
+	//   are rejected
+	assert.Equal(t, testPayload, string(payload))
+	assert.True(t, bytes.HasPrefix(sig, []byte("-----BEGIN PGP SIGNATURE-----")))
+	assert.Nil(t, verifyCommit(signed, keyring))
+	assert.Equal(t, ErrUnsignedCommit, verifyCommit([]byte(testPayload), keyring))
+	assert.Equal(t, ErrInvalidSignature, verifyCommit(signCommit(t, other, testPayload), keyring))
+	tampered := bytes.Replace(signed, []byte("update"), []byte("change"), 1)
+	assert.Equal(t, ErrInvalidSignature, verifyCommit(tampered, keyring))
+}
+
+func TestLoadKeyring(t *testing.T) {
+	entity, err := openpgp.NewEntity("trusted", "", "trusted@example.com", nil)
+	assert.Nil(t, err)
+	// self-sign the identities, which Serialize requires
+	assert.Nil(t, entity.SerializePrivate(ioutil.Discard, nil))
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, entity.Serialize(w))
+	assert.Nil(t, w.Close())
+
+	f, err := ioutil.TempFile("", "keyring")
+	assert.Nil(t, err)
+	defer os.Remove(f.Name())
+	f.Write(buf.Bytes())
+	f.Close()
+
+	// assert that an armored public keyring is read
+	keyring, err := LoadKeyring(f.Name())
+	if assert.Nil(t, err) && assert.Len(t, keyring, 1) {
+		assert.Equal(t, entity.PrimaryKey.KeyId, keyring[0].PrimaryKey.KeyId)
+	}
+	_, err = LoadKeyring(f.Name() + ".missing")
+	assert.Error(t, err)
+}
@@ -0,0 +1,36 @@
+package http
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/coreos/matchbox/matchbox/socket"
+)
+
+// allowlistExempt are the paths of health checks and metrics, which probes,
+// load balancers, and Prometheus outside the boot network must reach.
+var allowlistExempt = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
+// allowlist wraps an http.Handler and responds with 403 Forbidden to
+// clients whose address is not in the boot endpoint allowlist. Local
+// clients on unix sockets, which file permissions restrict, and health and
+// metrics endpoints are allowed.
+func (s *Server) allowlist(next http.Handler) http.Handler {
+	if len(s.allowed) == 0 {
+		return next
+	}
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		local, _ := req.Context().Value(http.LocalAddrContextKey).(net.Addr)
+		if !allowlistExempt[req.URL.Path] && !socket.IsUnix(local) && !s.allowed.AllowsAddr(req.RemoteAddr) {
+			s.logger.Warningf("denied %s %s from %s", req.Method, s.redactURL(req.URL), remoteIP(req))
+			writeProblem(w, http.StatusForbidden, CodeForbidden, "client address is not allowed")
+			return
+		}
+		next.ServeHTTP(w, req)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/acl"
+	"github.com/coreos/matchbox/matchbox/redact"
+	"github.com/coreos/matchbox/matchbox/server"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestAllowlist(t *testing.T) {
+	list, err := acl.Parse("10.0.0.0/8")
+	assert.Nil(t, err)
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger, Allowlist: list})
+	h := srv.HTTPHandler()
+
+	// assert that:
+	// - requests from the provisioning network are served
+	// - requests from other networks are forbidden
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.1.2.3:51234"
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req.RemoteAddr = "192.168.1.1:51234"
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// - requests to unix sockets are served
+	local := &net.UnixAddr{Name: "/run/matchbox/http.sock", Net: "unix"}
+	req = req.WithContext(context.WithValue(req.Context(), http.LocalAddrContextKey, local))
+	req.RemoteAddr = "@"
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAllowlist_Exempt(t *testing.T) {
+	list, err := acl.Parse("10.0.0.0/8")
+	assert.Nil(t, err)
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:      server.NewServer(&server.Config{Store: fake.NewFixedStore()}),
+		Logger:    logger,
+		Allowlist: list,
+	})
+	h := srv.HTTPHandler()
+
+	// assert that:
+	// - health checks and metrics are served to clients outside the allowlist
+	// - boot endpoints are forbidden to them
+	for _, path := range []string{"/healthz", "/readyz", "/metrics"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.RemoteAddr = "192.168.1.1:51234"
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, path)
+	}
+	req, _ := http.NewRequest("GET", "/ipxe?uuid=a1b2c3d4", nil)
+	req.RemoteAddr = "192.168.1.1:51234"
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestAllowlist_Redacted(t *testing.T) {
+	list, err := acl.Parse("10.0.0.0/8")
+	assert.Nil(t, err)
+	redactor, err := redact.NewRedactor([]string{"join_token"})
+	assert.Nil(t, err)
+	logger, hook := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger, Allowlist: list, Redactor: redactor})
+	req, _ := http.NewRequest("GET", "/ipxe?uuid=a1b2c3d4&join_token=s3cr3t", nil)
+	req.RemoteAddr = "192.168.1.1:51234"
+	srv.HTTPHandler().ServeHTTP(httptest.NewRecorder(), req)
+
+	// assert that:
+	// - sensitive query values are redacted when denied requests are logged
+	for _, entry := range hook.Entries {
+		assert.NotContains(t, entry.Message, "s3cr3t")
+	}
+	if assert.NotEmpty(t, hook.Entries) {
+		assert.Contains(t, hook.Entries[0].Message, "join_token=REDACTED")
+	}
+}
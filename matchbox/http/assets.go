@@ -0,0 +1,89 @@
+package http
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"context"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// assetsHandler returns a handler which serves files from the assets
+// directory. If a mirror is configured, assets declared by Profiles which
+// are missing locally are fetched from upstream before being served.
+func (s *Server) assetsHandler() http.Handler {
+	fileServer := s.serveFiles(http.StripPrefix("/assets/", http.FileServer(http.Dir(s.assetsPath))))
+	if s.mirror == nil && s.imageSigner == nil {
+		return fileServer
+	}
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		name := path.Clean(strings.TrimPrefix(req.URL.Path, "/assets/"))
+		// detached CMS signature of the asset
+		signature := s.isImageSignature(name)
+		if signature {
+			name = strings.TrimSuffix(name, imageSignatureExt)
+		}
+		if s.mirror != nil && !s.mirror.Exists(name) {
+			if asset := s.findAsset(req.Context(), name); asset != nil {
+				if err := s.mirror.Fetch(asset); err != nil {
+					s.logger.Errorf("error mirroring asset %s: %v", name, err)
+					writeProblem(w, http.StatusBadGateway, CodeUpstreamError, "error mirroring asset from upstream")
+					return
+				}
+			}
+		}
+		if signature {
+			s.serveImageSignature(w, req, name)
+			return
+		}
+		fileServer.ServeHTTP(w, req)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// findAsset returns the Asset declared by any Profile with the given path.
+func (s *Server) findAsset(ctx context.Context, name string) *storagepb.Asset {
+	profiles, err := s.core.ProfileList(ctx, &pb.ProfileListRequest{})
+	if err != nil {
+		return nil
+	}
+	for _, profile := range profiles {
+		for _, asset := range profile.Assets {
+			if path.Clean(asset.Path) == name {
+				return asset
+			}
+		}
+	}
+	return nil
+}
+
+// serveFiles returns a handler which serves regular asset files with
+// http.ServeContent from the open file, so responses are sent with
+// sendfile (where supported) rather than read into memory, and concurrent
+// downloads of the same image share the page cache. Directories and
+// missing files are served by the fallback.
+func (s *Server) serveFiles(fallback http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, "/") {
+			fallback.ServeHTTP(w, req)
+			return
+		}
+		file, err := os.Open(s.assetFilename(strings.TrimPrefix(req.URL.Path, "/assets/")))
+		if err != nil {
+			fallback.ServeHTTP(w, req)
+			return
+		}
+		defer file.Close()
+		info, err := file.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			fallback.ServeHTTP(w, req)
+			return
+		}
+		http.ServeContent(w, req, info.Name(), info.ModTime(), file)
+	}
+	return http.HandlerFunc(fn)
+}
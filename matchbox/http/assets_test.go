@@ -0,0 +1,105 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/assets"
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestAssetsHandler_Mirror(t *testing.T) {
+	content := "initrd contents"
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, content)
+	}))
+	defer upstream.Close()
+	dir, err := ioutil.TempDir("", "matchbox")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	sum := sha256.Sum256([]byte(content))
+	profile := &storagepb.Profile{
+		Id: "g1h2i3j4",
+		Assets: []*storagepb.Asset{
+			{
+				Path:     "coreos/initrd.cpio.gz",
+				Url:      upstream.URL,
+				Checksum: "sha256:" + hex.EncodeToString(sum[:]),
+			},
+		},
+	}
+	store := &fake.FixedStore{
+		Profiles: map[string]*storagepb.Profile{profile.Id: profile},
+	}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:       server.NewServer(&server.Config{Store: store}),
+		Logger:     logger,
+		AssetsPath: dir,
+		Mirror:     assets.NewMirror(&assets.Config{Root: dir}),
+	})
+	h := srv.assetsHandler()
+	// assert that:
+	// - declared assets are fetched from upstream and served
+	// - undeclared assets are not found
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/assets/coreos/initrd.cpio.gz", nil)
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, content, w.Body.String())
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/assets/coreos/other", nil)
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAssetsHandler_ServeFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "matchbox-assets")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "coreos"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "coreos", "vmlinuz"), []byte("kernel"), 0644))
+
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger, AssetsPath: dir})
+	h := srv.assetsHandler()
+	// assert that:
+	// - files are served with their modification time
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/assets/coreos/vmlinuz", nil)
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "kernel", w.Body.String())
+	assert.NotEmpty(t, w.HeaderMap.Get("Last-Modified"))
+	// - range requests are served from the file
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/assets/coreos/vmlinuz", nil)
+	req.Header.Set("Range", "bytes=2-")
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "rnel", w.Body.String())
+	// - directories and missing files are served by the file server
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/assets/coreos/", nil)
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "vmlinuz")
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/assets/../missing", nil)
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	"github.com/coreos/matchbox/matchbox/webhook"
+)
+
+// countInstallAttempt counts a network boot of a machine UUID which has not
+// been installed, if install attempts are limited. When a machine reaches
+// the limit, an alert is raised since it is served the rescue Profile from
+// then on.
+func (s *Server) countInstallAttempt(req *http.Request, status int, info *requestInfo) {
+	if s.maxAttempts <= 0 || status != http.StatusOK {
+		return
+	}
+	// only boots which were served a Profile count, not local boot or
+	// maintenance scripts, nor the holding Profile of pending machines
+	if (req.URL.Path != "/ipxe" && req.URL.Path != "/grub") || info.profile == "" || info.group == server.HoldingGroupID {
+		return
+	}
+	labels := labelsFromRequest(nil, req)
+	uuid := labels["uuid"]
+	if uuid == "" {
+		return
+	}
+	machine, err := s.core.MachineUpdate(req.Context(), uuid, func(machine *storagepb.Machine) (*storagepb.Machine, error) {
+		if machine == nil {
+			machine = &storagepb.Machine{Id: uuid, Labels: labels, State: storagepb.MachineBooted}
+		}
+		switch machine.State {
+		case storagepb.MachineProvisioned, storagepb.MachineInstalled, storagepb.MachineDecommission:
+			return nil, nil
+		}
+		machine.Attempts++
+		return machine, nil
+	})
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"uuid": uuid,
+		}).Errorf("error counting machine install attempt: %v", err)
+		return
+	}
+	if machine == nil || int(machine.Attempts) != s.maxAttempts {
+		return
+	}
+
+	installFailures.Inc()
+	s.logger.WithFields(logrus.Fields{
+		"labels":   s.redactor.Labels(labels),
+		"attempts": machine.Attempts,
+		"profile":  info.profile,
+	}).Warningf("Machine %s booted the installer %d times without completing, serving the rescue profile", uuid, machine.Attempts)
+	s.webhooks.Notify(&webhook.Event{
+		Type:      webhook.EventInstallFailed,
+		MachineID: uuid,
+		Labels:    s.redactor.Labels(labels),
+		Group:     info.group,
+		Profile:   info.profile,
+		RequestID: info.id,
+		Attempts:  int(machine.Attempts),
+	})
+}
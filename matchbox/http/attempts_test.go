@@ -0,0 +1,75 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+	"github.com/coreos/matchbox/matchbox/webhook"
+)
+
+func TestInstallAttempts(t *testing.T) {
+	events := make(chan *webhook.Event, 10)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		event := new(webhook.Event)
+		json.NewDecoder(req.Body).Decode(event)
+		events <- event
+	}))
+	defer hook.Close()
+
+	store := fake.NewFixedStore()
+	store.Groups[fake.Group.Id] = fake.Group
+	store.Profiles[fake.Profile.Id] = fake.Profile
+	store.Profiles["rescue"] = &storagepb.Profile{
+		Id:   "rescue",
+		Boot: &storagepb.NetBoot{Kernel: "/rescue/vmlinuz"},
+	}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:            server.NewServer(&server.Config{Store: store, InstallAttempts: 2, RescueProfile: "rescue"}),
+		Logger:          logger,
+		InstallAttempts: 2,
+		Webhooks: webhook.NewNotifier(&webhook.Config{
+			Hooks:  []webhook.Hook{{URL: hook.URL, Events: []string{webhook.EventInstallFailed}}},
+			Logger: logger,
+		}),
+	})
+	h := srv.HTTPHandler()
+	boot := func() string {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/ipxe?uuid=a1b2c3d4", nil)
+		h.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	// assert that:
+	// - machines are served their Profile until they reach the limit
+	// - an alert is sent when the limit is reached
+	// - machines are served the rescue Profile after the limit
+	assert.NotContains(t, boot(), "/rescue/vmlinuz")
+	assert.NotContains(t, boot(), "/rescue/vmlinuz")
+	event := <-events
+	assert.Equal(t, webhook.EventInstallFailed, event.Type)
+	assert.Equal(t, "a1b2c3d4", event.MachineID)
+	assert.Equal(t, 2, event.Attempts)
+	assert.Contains(t, boot(), "/rescue/vmlinuz")
+	assert.Equal(t, int32(3), store.Machines["a1b2c3d4"].Attempts)
+
+	// - completion resets the attempts
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/complete?uuid=a1b2c3d4", strings.NewReader(""))
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, int32(0), store.Machines["a1b2c3d4"].Attempts)
+	assert.NotContains(t, boot(), "/rescue/vmlinuz")
+	assert.Equal(t, int32(0), store.Machines["a1b2c3d4"].Attempts)
+}
@@ -0,0 +1,146 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/attest"
+)
+
+// maxAttestationBody limits the size of attestation requests (64 KiB).
+const maxAttestationBody = 64 << 10
+
+// challengeRequest requests a challenge for a TPM attestation key.
+type challengeRequest struct {
+	// TPMT_PUBLIC of the attestation key
+	AKPublic []byte `json:"ak_public"`
+}
+
+// quoteRequest answers a challenge with a TPM quote.
+type quoteRequest struct {
+	// TPMS_ATTEST quote, qualified by the activated challenge secret
+	Quote []byte `json:"quote"`
+	// TPMT_SIGNATURE of the quote by the attestation key
+	Signature []byte `json:"signature"`
+}
+
+// quoteResponse returns the credential of a verified quote.
+type quoteResponse struct {
+	// single-use credential to fetch the machine's Ignition config
+	Credential string `json:"credential"`
+}
+
+// attestRequest decodes the JSON body of a POST request by the machine with
+// the "uuid" query parameter. It responds with an error and returns an empty
+// uuid if the request is invalid.
+func (s *Server) attestRequest(w http.ResponseWriter, req *http.Request, v interface{}) string {
+	if req.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		writeProblem(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "")
+		return ""
+	}
+	uuid := labelsFromRequest(s.logger, req)["uuid"]
+	if uuid == "" {
+		writeProblem(w, http.StatusBadRequest, CodeInvalidRequest, "uuid query parameter is required")
+		return ""
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxAttestationBody)).Decode(v); err != nil {
+		writeProblem(w, http.StatusBadRequest, CodeInvalidRequest, "invalid JSON body")
+		return ""
+	}
+	return uuid
+}
+
+// attestChallengeHandler returns a handler which challenges the machine
+// with the "uuid" query parameter to activate a credential for its
+// attestation key with the TPM holding its enrolled EK.
+func (s *Server) attestChallengeHandler() ContextHandler {
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		body := new(challengeRequest)
+		uuid := s.attestRequest(w, req, body)
+		if uuid == "" {
+			return
+		}
+		challenge, err := s.attestor.Challenge(uuid, body.AKPublic)
+		if err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"uuid": uuid,
+			}).Warningf("Rejected attestation challenge: %v", err)
+			writeProblem(w, http.StatusForbidden, CodeForbidden, "attestation challenge rejected")
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		s.renderJSON(w, challenge)
+	}
+	return ContextHandlerFunc(fn)
+}
+
+// attestQuoteHandler returns a handler which verifies the quote of the
+// machine with the "uuid" query parameter answers its challenge, and
+// responds with its attestation credential.
+func (s *Server) attestQuoteHandler() ContextHandler {
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		body := new(quoteRequest)
+		uuid := s.attestRequest(w, req, body)
+		if uuid == "" {
+			return
+		}
+		credential, err := s.attestor.Verify(uuid, body.Quote, body.Signature)
+		if err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"uuid": uuid,
+			}).Warningf("Rejected attestation quote: %v", err)
+			writeProblem(w, http.StatusForbidden, CodeForbidden, "attestation quote rejected")
+			return
+		}
+		s.logger.WithFields(logrus.Fields{
+			"uuid": uuid,
+		}).Infof("Machine %s attested", uuid)
+		w.Header().Set("Cache-Control", "no-store")
+		s.renderJSON(w, &quoteResponse{Credential: credential})
+	}
+	return ContextHandlerFunc(fn)
+}
+
+// requireAttestation returns a handler which redeems the single-use
+// "attestation" credential of the machine with the "uuid" query parameter
+// before calling the next handler. Signature requests check the credential
+// without redeeming it. Other requests are forbidden. If the next handler
+// fails, the credential is restored so the machine can retry.
+func (s *Server) requireAttestation(next ContextHandler) ContextHandler {
+	if s.attestor == nil {
+		return next
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		uuid := labelsFromRequest(nil, req)["uuid"]
+		credential := req.URL.Query().Get("attestation")
+		var err error
+		if isSignature(ctx) {
+			if !s.attestor.Attested(uuid, credential) {
+				err = attest.ErrNotAttested
+			}
+		} else {
+			err = s.attestor.Redeem(uuid, credential)
+		}
+		if err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"uuid": uuid,
+			}).Warningf("Rejected request for %v: %v", req.URL.Path, err)
+			writeProblem(w, http.StatusForbidden, CodeForbidden, "machine is not attested")
+			return
+		}
+		if isSignature(ctx) {
+			next.ServeHTTP(ctx, w, req)
+			return
+		}
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(ctx, rec, req)
+		if rec.status >= http.StatusBadRequest {
+			s.attestor.Restore(uuid, credential)
+		}
+	}
+	return ContextHandlerFunc(fn)
+}
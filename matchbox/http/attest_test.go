@@ -0,0 +1,83 @@
+package http
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"context"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/attest"
+)
+
+func TestRequireAttestation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "matchbox-attest")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	logger, _ := logtest.NewNullLogger()
+	verifier := attest.NewVerifier(&attest.Config{EKPath: dir, Logger: logger})
+	srv := NewServer(&Config{Logger: logger, Attestation: verifier})
+	next := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "secret config")
+	}
+	h := srv.requireAttestation(ContextHandlerFunc(next))
+	// assert that:
+	// - configs and their signatures are not served to machines without a
+	//   valid attestation credential
+	urls := []string{"/ignition", "/ignition?uuid=a1b2c3d4", "/ignition?uuid=a1b2c3d4&attestation=guess"}
+	for _, ctx := range []context.Context{context.Background(), withSignature(context.Background())} {
+		for _, url := range urls {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", url, nil)
+			h.ServeHTTP(ctx, w, req)
+			assert.Equal(t, http.StatusForbidden, w.Code, url)
+			assert.NotContains(t, w.Body.String(), "secret config")
+		}
+	}
+}
+
+func TestRequireAttestation_Disabled(t *testing.T) {
+	srv := NewServer(&Config{})
+	next := ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, req *http.Request) {})
+	// assert that:
+	// - the next handler is used directly when attestation is not required
+	h := srv.requireAttestation(next)
+	assert.Equal(t, fmt.Sprintf("%p", next), fmt.Sprintf("%p", h))
+}
+
+func TestAttestChallengeHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "matchbox-attest")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	logger, _ := logtest.NewNullLogger()
+	verifier := attest.NewVerifier(&attest.Config{EKPath: dir, Logger: logger})
+	srv := NewServer(&Config{Logger: logger, Attestation: verifier})
+	h := srv.attestChallengeHandler()
+
+	cases := []struct {
+		method string
+		url    string
+		body   string
+		code   int
+	}{
+		{"GET", "/v1/attest/challenge?uuid=a1b2c3d4", "", http.StatusMethodNotAllowed},
+		// missing uuid
+		{"POST", "/v1/attest/challenge", `{"ak_public":""}`, http.StatusBadRequest},
+		{"POST", "/v1/attest/challenge?uuid=a1b2c3d4", `not json`, http.StatusBadRequest},
+		// machine without an enrolled EK
+		{"POST", "/v1/attest/challenge?uuid=a1b2c3d4", `{"ak_public":"AAE="}`, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(tc.method, tc.url, strings.NewReader(tc.body))
+		h.ServeHTTP(context.Background(), w, req)
+		assert.Equal(t, tc.code, w.Code, tc.url)
+	}
+}
@@ -0,0 +1,34 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/coreos/matchbox/matchbox/audit"
+)
+
+// auditRequest records a served boot or provisioning request to the
+// Auditor.
+func (s *Server) auditRequest(req *http.Request, status int, info *requestInfo) {
+	if s.auditor == nil {
+		return
+	}
+	if !bootEndpoints[req.URL.Path] && req.URL.Path != "/v1/complete" && req.URL.Path != "/v1/progress" {
+		return
+	}
+	fields := s.redactor.Labels(labelsFromRequest(nil, req))
+	fields["request_id"] = info.id
+	if info.group != "" {
+		fields["group"] = info.group
+	}
+	if info.profile != "" {
+		fields["profile"] = info.profile
+	}
+	s.auditor.Record(&audit.Entry{
+		Type:   audit.TypeBoot,
+		Action: req.URL.Path,
+		Actor:  remoteIP(req),
+		Result: strconv.Itoa(status),
+		Fields: fields,
+	})
+}
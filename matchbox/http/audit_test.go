@@ -0,0 +1,55 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/audit"
+	"github.com/coreos/matchbox/matchbox/server"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+// entrySink is an audit.Sink which sends entries to a channel.
+type entrySink chan *audit.Entry
+
+func (s entrySink) Write(entry *audit.Entry) error {
+	s <- entry
+	return nil
+}
+
+func (s entrySink) Close() error {
+	return nil
+}
+
+func TestAuditRequest(t *testing.T) {
+	sink := make(entrySink, 10)
+	logger, _ := logtest.NewNullLogger()
+	auditor := audit.NewAuditor(&audit.Config{Sinks: []audit.Sink{sink}, Logger: logger})
+	srv := NewServer(&Config{
+		Core:    server.NewServer(&server.Config{Store: fake.NewFixedStore()}),
+		Logger:  logger,
+		Auditor: auditor,
+	})
+	h := srv.HTTPHandler()
+	for _, url := range []string{"/", "/ipxe?uuid=a1b2c3d4"} {
+		req, _ := http.NewRequest("GET", url, nil)
+		req.RemoteAddr = "10.1.2.3:51234"
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	auditor.Close()
+	// assert that:
+	// - boot endpoint requests are recorded with the machine labels
+	// - other requests are not recorded
+	if assert.Len(t, sink, 1) {
+		entry := <-sink
+		assert.Equal(t, audit.TypeBoot, entry.Type)
+		assert.Equal(t, "/ipxe", entry.Action)
+		assert.Equal(t, "10.1.2.3", entry.Actor)
+		assert.Equal(t, "404", entry.Result)
+		assert.Equal(t, "a1b2c3d4", entry.Fields["uuid"])
+	}
+}
@@ -0,0 +1,164 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/coreos/ignition/config/validate/report"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// errArgNewline is returned if a rendered kernel arg would end a line of
+// the boot script.
+var errArgNewline = errors.New("rendered kernel arg must not contain a newline")
+
+// errInvalidImagePath is returned if a rendered kernel or initrd path is
+// empty or contains whitespace.
+var errInvalidImagePath = errors.New("rendered kernel and initrd paths must be non-empty and must not contain whitespace")
+
+// templatedBoot returns true if the kernel, any initrd, or any kernel arg of
+// the NetBoot is a template.
+func templatedBoot(boot *storagepb.NetBoot) bool {
+	if boot == nil {
+		return false
+	}
+	if isTemplate(boot.Kernel) {
+		return true
+	}
+	for _, initrd := range boot.Initrd {
+		if isTemplate(initrd) {
+			return true
+		}
+	}
+	for _, arg := range boot.Args {
+		if isTemplate(arg) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTemplate returns true if a value contains a template action.
+func isTemplate(value string) bool {
+	return strings.Contains(value, "{{")
+}
+
+// renderBoot returns a copy of the NetBoot with its kernel, initrd paths,
+// and kernel args rendered as templates with the request and Group data, or
+// the NetBoot itself if none are templates. Groups may pin the versions of
+// the images they boot (e.g. /assets/fedora-coreos/{{.os_version}}/kernel),
+// so rendered paths under /assets/ must be present or mirrored. Args which
+// render empty are dropped, so args may be conditional.
+func (s *Server) renderBoot(ctx context.Context, req *http.Request, group *storagepb.Group, boot *storagepb.NetBoot) (*storagepb.NetBoot, error) {
+	if !templatedBoot(boot) {
+		return boot, nil
+	}
+	data, err := collectVariables(ctx, req, group)
+	if err != nil {
+		return nil, err
+	}
+	render := func(value string) (string, error) {
+		var buf bytes.Buffer
+		if err := s.renderTemplate(&buf, data, value); err != nil {
+			return "", &reportError{templateReport(value, err)}
+		}
+		return strings.TrimSpace(buf.String()), nil
+	}
+	renderPath := func(value string) (string, error) {
+		if !isTemplate(value) {
+			return value, nil
+		}
+		rendered, err := render(value)
+		if err != nil {
+			return "", err
+		}
+		if rendered == "" || strings.IndexFunc(rendered, unicode.IsSpace) >= 0 {
+			return "", &reportError{report.ReportFromError(errInvalidImagePath, report.EntryError)}
+		}
+		if err := s.checkAsset(ctx, rendered); err != nil {
+			return "", &reportError{report.ReportFromError(err, report.EntryError)}
+		}
+		return rendered, nil
+	}
+
+	rendered := boot.Copy()
+	if rendered.Kernel, err = renderPath(boot.Kernel); err != nil {
+		return nil, err
+	}
+	for i, initrd := range boot.Initrd {
+		if rendered.Initrd[i], err = renderPath(initrd); err != nil {
+			return nil, err
+		}
+	}
+	rendered.Args = make([]string, 0, len(boot.Args))
+	for _, arg := range boot.Args {
+		value := arg
+		if isTemplate(arg) {
+			if value, err = render(arg); err != nil {
+				return nil, err
+			}
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, &reportError{report.ReportFromError(errArgNewline, report.EntryError)}
+		}
+		if value != "" {
+			rendered.Args = append(rendered.Args, value)
+		}
+	}
+	return rendered, nil
+}
+
+// checkAsset returns an error if a rendered image path is an asset which is
+// neither in the assets directory nor declared by a Profile to be mirrored.
+// Other paths and URLs aren't checked.
+func (s *Server) checkAsset(ctx context.Context, image string) error {
+	if s.assetsPath == "" || !strings.HasPrefix(image, "/assets/") {
+		return nil
+	}
+	name := path.Clean(strings.TrimPrefix(image, "/assets/"))
+	if info, err := os.Stat(s.assetFilename(name)); err == nil && info.Mode().IsRegular() {
+		return nil
+	}
+	if s.mirror != nil && s.findAsset(ctx, name) != nil {
+		return nil
+	}
+	return fmt.Errorf("asset %s not found", image)
+}
+
+// bootFromContext returns the NetBoot of the Profile in the ctx with its
+// templates rendered. If rendering fails, it responds with an error and
+// returns it.
+func (s *Server) bootFromContext(ctx context.Context, w http.ResponseWriter, req *http.Request, profile *storagepb.Profile) (*storagepb.NetBoot, error) {
+	if !templatedBoot(profile.Boot) {
+		return profile.Boot, nil
+	}
+	group, err := groupFromContext(ctx)
+	if err == nil {
+		if err = profile.ValidateMetadata(group); err != nil {
+			s.metadataFailed(w, "boot", err)
+			return nil, err
+		}
+		var boot *storagepb.NetBoot
+		if boot, err = s.renderBoot(ctx, req, group, profile.Boot); err == nil {
+			return boot, nil
+		}
+	}
+	if rerr, ok := err.(*reportError); ok {
+		s.renderFailed(w, "boot", profile.Id, rerr.report)
+		return nil, err
+	}
+	s.logger.WithFields(logrus.Fields{
+		"profile": profile.Id,
+	}).Errorf("error rendering boot config: %v", err)
+	writeProblem(w, http.StatusNotFound, CodeTemplateError, "error rendering boot config")
+	return nil, err
+}
@@ -0,0 +1,156 @@
+package http
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"context"
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestRenderBoot(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	group := &storagepb.Group{
+		Id:       "node1",
+		Selector: map[string]string{"mac": "52:54:00:a1:9c:ae"},
+		Metadata: []byte(`{"console": "ttyS1", "ip": "10.0.0.5"}`),
+	}
+	req, _ := http.NewRequest("GET", "/ipxe?serial=s1", nil)
+	boot := &storagepb.NetBoot{
+		Kernel: "/assets/kernel",
+		Args: []string{
+			"console={{.console}}",
+			"{{if .ip}}ip={{.ip}}::10.0.0.1:255.255.255.0{{end}}",
+			`{{with index . "hostname"}}hostname={{.}}{{end}}`,
+			"serial={{.request.query.serial}}",
+			"coreos.config.url=http://matchbox/ignition?mac=${mac:hexhyp}",
+		},
+	}
+
+	// assert that:
+	// - args are rendered with Group metadata, selectors, and the request
+	// - args which render empty are dropped
+	// - the NetBoot isn't modified
+	rendered, err := srv.renderBoot(context.Background(), req, group, boot)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"console=ttyS1",
+		"ip=10.0.0.5::10.0.0.1:255.255.255.0",
+		"serial=s1",
+		"coreos.config.url=http://matchbox/ignition?mac=${mac:hexhyp}",
+	}, rendered.Args)
+	assert.Equal(t, "console={{.console}}", boot.Args[0])
+
+	// - NetBoots without templates are returned as is
+	static := &storagepb.NetBoot{Args: []string{"console=ttyS0"}}
+	rendered, err = srv.renderBoot(context.Background(), req, group, static)
+	assert.Nil(t, err)
+	assert.True(t, static == rendered)
+
+	// - missing metadata and args with newlines are errors
+	for _, arg := range []string{"{{.missing}}", "{{printf \"a\\nboot\"}}"} {
+		_, err = srv.renderBoot(context.Background(), req, group, &storagepb.NetBoot{Args: []string{arg}})
+		assert.IsType(t, &reportError{}, err, arg)
+	}
+}
+
+func TestRenderBoot_Images(t *testing.T) {
+	dir, err := ioutil.TempDir("", "matchbox")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"fedora-coreos/36/kernel", "fedora-coreos/36/initramfs.img", "fedora-coreos/37/kernel"} {
+		assert.Nil(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755))
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0644))
+	}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger, AssetsPath: dir})
+	group := &storagepb.Group{
+		Id:       "canary",
+		Metadata: []byte(`{"os_version": "36"}`),
+	}
+	req, _ := http.NewRequest("GET", "/ipxe", nil)
+	boot := &storagepb.NetBoot{
+		Kernel: "/assets/fedora-coreos/{{.os_version}}/kernel",
+		Initrd: []string{"/assets/fedora-coreos/{{.os_version}}/initramfs.img", "http://example.com/extra.img"},
+		Args:   []string{"console=ttyS0"},
+	}
+
+	// assert that:
+	// - kernel and initrd paths are rendered with Group metadata, so Groups
+	// may pin the version of the images they boot
+	rendered, err := srv.renderBoot(context.Background(), req, group, boot)
+	assert.Nil(t, err)
+	assert.Equal(t, "/assets/fedora-coreos/36/kernel", rendered.Kernel)
+	assert.Equal(t, []string{"/assets/fedora-coreos/36/initramfs.img", "http://example.com/extra.img"}, rendered.Initrd)
+	assert.Equal(t, []string{"console=ttyS0"}, rendered.Args)
+
+	// - rendered assets which don't exist are errors
+	group.Metadata = []byte(`{"os_version": "37"}`)
+	_, err = srv.renderBoot(context.Background(), req, group, boot)
+	if assert.IsType(t, &reportError{}, err) {
+		assert.Contains(t, err.Error(), "asset /assets/fedora-coreos/37/initramfs.img not found")
+	}
+
+	// - empty paths are errors
+	group.Metadata = []byte(`{"os_version": ""}`)
+	_, err = srv.renderBoot(context.Background(), req, group, &storagepb.NetBoot{Kernel: "{{.os_version}}"})
+	assert.IsType(t, &reportError{}, err)
+}
+
+func TestBootArgs_Handlers(t *testing.T) {
+	group := fake.Group.Copy()
+	group.Metadata = []byte(`{"console": "ttyS1"}`)
+	store := &fake.FixedStore{
+		Groups: map[string]*storagepb.Group{group.Id: group},
+		Profiles: map[string]*storagepb.Profile{group.Profile: {
+			Id: group.Profile,
+			Boot: &storagepb.NetBoot{
+				Kernel: "/image/kernel",
+				Initrd: []string{"/image/initrd"},
+				Args:   []string{"console={{.console}}", "uuid={{.request.query.uuid}}"},
+			},
+		}},
+	}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:            server.NewServer(&server.Config{Store: store}),
+		Logger:          logger,
+		RenderCacheSize: 10,
+	})
+	h := srv.HTTPHandler()
+	get := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", url, nil)
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	// assert that:
+	// - iPXE scripts and GRUB configs render templated args
+	// - cached iPXE scripts are distinct per machine
+	w := get("/ipxe?uuid=a1b2c3d4")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "kernel /image/kernel console=ttyS1 uuid=a1b2c3d4\n")
+	w = get("/ipxe?uuid=a1b2c3d4&mac=52:54:00:a1:9c:ae")
+	assert.Contains(t, w.Body.String(), "kernel /image/kernel console=ttyS1 uuid=a1b2c3d4\n")
+	w = get("/grub?uuid=a1b2c3d4")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `linuxefi "/image/kernel" "console=ttyS1" "uuid=a1b2c3d4"`)
+
+	// - args which fail to render are reported
+	group.Metadata = []byte(`{}`)
+	store.Groups[group.Id] = group
+	w = get("/ipxe?uuid=a1b2c3d4&fresh=1")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "error rendering boot template "+group.Profile)
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// recordBoot records a served config request in the boot history of the
+// machine UUID, with a checksum of the config it was served. Only Machines
+// which are already recorded (e.g. enrolled, pending, or provisioned) keep
+// a boot history, so requests don't create Machines.
+func (s *Server) recordBoot(req *http.Request, rec *statusRecorder, info *requestInfo) {
+	if s.bootHistory <= 0 || !bootEndpoints[req.URL.Path] {
+		return
+	}
+	labels := labelsFromRequest(nil, req)
+	uuid := labels["uuid"]
+	if uuid == "" {
+		return
+	}
+	boot := &storagepb.MachineBoot{
+		Time:     time.Now().UTC().Format(time.RFC3339),
+		Endpoint: req.URL.Path,
+		Status:   int32(rec.status),
+		Group:    info.group,
+		Profile:  info.profile,
+	}
+	if rec.status == http.StatusOK && rec.hash != nil {
+		boot.ConfigHash = "sha256:" + hex.EncodeToString(rec.hash.Sum(nil))
+	}
+	_, err := s.core.MachineUpdate(req.Context(), uuid, func(machine *storagepb.Machine) (*storagepb.Machine, error) {
+		if machine == nil {
+			return nil, nil
+		}
+		machine.AddBoot(boot, s.bootHistory)
+		return machine, nil
+	})
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"uuid": uuid,
+		}).Errorf("error recording machine boot: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestRecordBoot(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups[fake.Group.Id] = fake.Group
+	store.Profiles[fake.Profile.Id] = fake.Profile
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:        server.NewServer(&server.Config{Store: store}),
+		Logger:      logger,
+		BootHistory: 2,
+	})
+	h := srv.HTTPHandler()
+	boot := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", url, nil)
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	// assert that:
+	// - requests of machines which aren't recorded don't create Machines
+	// - served configs are recorded in the machine's boot history, with
+	// the Group and Profile and a checksum of the config
+	// - the boot history is bounded, keeping the latest boots
+	// - requests without a UUID aren't recorded
+	w := boot("/ipxe?uuid=a1b2c3d4")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, store.Machines)
+
+	store.Machines["a1b2c3d4"] = &storagepb.Machine{Id: "a1b2c3d4", State: storagepb.MachineProvisioned}
+	w = boot("/ipxe?uuid=a1b2c3d4")
+	machine := store.Machines["a1b2c3d4"]
+	if assert.NotNil(t, machine) && assert.Len(t, machine.Boots, 1) {
+		sum := sha256.Sum256(w.Body.Bytes())
+		assert.Equal(t, "/ipxe", machine.Boots[0].Endpoint)
+		assert.Equal(t, int32(http.StatusOK), machine.Boots[0].Status)
+		assert.Equal(t, fake.Group.Id, machine.Boots[0].Group)
+		assert.Equal(t, fake.Profile.Id, machine.Boots[0].Profile)
+		assert.Equal(t, "sha256:"+hex.EncodeToString(sum[:]), machine.Boots[0].ConfigHash)
+		assert.Equal(t, storagepb.MachineProvisioned, machine.State)
+	}
+
+	boot("/ipxe?uuid=a1b2c3d4")
+	boot("/metadata?uuid=a1b2c3d4")
+	machine = store.Machines["a1b2c3d4"]
+	if assert.Len(t, machine.Boots, 2) {
+		assert.Equal(t, "/ipxe", machine.Boots[0].Endpoint)
+		assert.Equal(t, "/metadata", machine.Boots[1].Endpoint)
+	}
+
+	boot("/ipxe?mac=52:54:00:a1:9c:ae")
+	assert.Len(t, store.Machines, 1)
+}
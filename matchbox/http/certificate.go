@@ -0,0 +1,118 @@
+package http
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/vault"
+)
+
+const pemContentType = "application/x-pem-file"
+
+// Machine facts used to name issued certificates.
+const (
+	factHostname = "hostname"
+	factIP       = "ip"
+)
+
+// certificateHandler returns a handler which issues a certificate from
+// Vault PKI to the machine with the "uuid" or "mac" query parameter. The
+// machine must present a verified client certificate whose common name is
+// its uuid or mac, or redeem a single-use token. The certificate is named
+// by the machine's hostname and ip facts, so machines cannot request
+// certificates for arbitrary names. With "format=pem", the certificate, CA
+// chain, and key are returned as a PEM bundle (e.g. an Ignition file source)
+// instead of JSON.
+func (s *Server) certificateHandler(core server.Server) ContextHandler {
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		query := labelsFromRequest(s.logger, req)
+		identity := make(map[string]string)
+		for _, key := range []string{"uuid", "mac"} {
+			if value := query[key]; value != "" {
+				identity[key] = value
+			}
+		}
+		if len(identity) == 0 {
+			writeProblem(w, http.StatusBadRequest, CodeInvalidRequest, "uuid or mac query parameter is required")
+			return
+		}
+		if err := s.authenticateMachine(ctx, core, req, identity); err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"labels": identity,
+			}).Warningf("Rejected certificate request: %v", err)
+			writeProblem(w, http.StatusForbidden, CodeForbidden, "certificate request rejected")
+			return
+		}
+
+		// name the certificate by facts, not request labels
+		facts := core.MachineLabels(ctx, identity)
+		issue := &vault.IssueRequest{CommonName: facts[factHostname]}
+		if issue.CommonName == "" {
+			writeProblem(w, http.StatusConflict, CodeMissingHostname, "machine has no hostname fact")
+			return
+		}
+		if short := strings.SplitN(issue.CommonName, ".", 2)[0]; short != issue.CommonName {
+			issue.AltNames = []string{short}
+		}
+		if ip := facts[factIP]; ip != "" {
+			issue.IPSANs = []string{ip}
+		}
+		cert, err := s.pki.Issue(ctx, issue)
+		if err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"labels": identity,
+			}).Errorf("error issuing certificate: %v", err)
+			writeProblem(w, http.StatusBadGateway, CodeUpstreamError, "error issuing certificate")
+			return
+		}
+		s.logger.WithFields(logrus.Fields{
+			"labels": identity,
+			"serial": cert.SerialNumber,
+		}).Infof("Issued certificate for %s", issue.CommonName)
+
+		w.Header().Set("Cache-Control", "no-store")
+		if req.URL.Query().Get("format") == "pem" {
+			w.Header().Set(contentType, pemContentType)
+			pems := append([]string{cert.Certificate}, cert.CAChain...)
+			pems = append(pems, cert.PrivateKey)
+			for _, pem := range pems {
+				w.Write([]byte(strings.TrimSpace(pem) + "\n"))
+			}
+			return
+		}
+		s.renderJSON(w, cert)
+	}
+	return ContextHandlerFunc(fn)
+}
+
+// authenticateMachine returns nil if the request has a verified client
+// certificate whose common name is the machine's uuid or mac, or redeems
+// the request's single-use "token" for the machine.
+func (s *Server) authenticateMachine(ctx context.Context, core server.Server, req *http.Request, identity map[string]string) error {
+	if cert := clientCertificate(req); cert != nil {
+		for _, value := range identity {
+			if strings.EqualFold(cert.Subject.CommonName, value) {
+				return nil
+			}
+		}
+	}
+	return core.TokenRedeem(ctx, &pb.TokenRedeemRequest{
+		Token:  req.URL.Query().Get("token"),
+		Labels: identity,
+	})
+}
+
+// clientCertificate returns the verified client certificate of a request,
+// or nil.
+func clientCertificate(req *http.Request) *x509.Certificate {
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return req.TLS.VerifiedChains[0][0]
+}
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"context"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+	"github.com/coreos/matchbox/matchbox/vault"
+)
+
+// newFakeVault returns a Vault server which issues placeholder certificates
+// and records the parameters of the last request.
+func newFakeVault(params *map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		json.NewDecoder(req.Body).Decode(params)
+		w.Write([]byte(`{"data": {"certificate": "CERT", "private_key": "KEY", "ca_chain": ["CA"], "serial_number": "39:dd"}}`))
+	}))
+}
+
+func TestCertificateHandler(t *testing.T) {
+	var params map[string]string
+	ts := newFakeVault(&params)
+	defer ts.Close()
+	store := fake.NewFixedStore()
+	store.Machines["a1b2c3d4"] = &storagepb.Machine{
+		Id:    "a1b2c3d4",
+		Facts: map[string]string{"hostname": "node1.example.com", "ip": "10.0.0.21"},
+	}
+	logger, _ := logtest.NewNullLogger()
+	c := server.NewServer(&server.Config{Store: store})
+	srv := NewServer(&Config{Logger: logger, PKI: vault.NewPKI(&vault.PKIConfig{Address: ts.URL, Role: "node"})})
+	h := srv.certificateHandler(c)
+	token, err := c.TokenCreate(context.Background(), &pb.TokenCreateRequest{
+		Labels: map[string]string{"uuid": "a1b2c3d4"},
+	})
+	assert.Nil(t, err)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/certificate?uuid=a1b2c3d4&hostname=evil.example.com&token="+token, nil)
+	h.ServeHTTP(context.Background(), w, req)
+	// assert that:
+	// - a certificate is issued for the machine's hostname and ip facts
+	// - hostnames in the request are ignored
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, jsonContentType, w.HeaderMap.Get(contentType))
+	assert.Equal(t, map[string]string{
+		"common_name": "node1.example.com",
+		"alt_names":   "node1",
+		"ip_sans":     "10.0.0.21",
+	}, params)
+	var cert vault.Certificate
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &cert))
+	assert.Equal(t, "KEY", cert.PrivateKey)
+
+	// assert that:
+	// - a machine authenticated by a client certificate receives a PEM bundle
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/v1/certificate?uuid=a1b2c3d4&format=pem", nil)
+	req.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "a1b2c3d4"}}}},
+	}
+	h.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, pemContentType, w.HeaderMap.Get(contentType))
+	assert.Equal(t, "CERT\nCA\nKEY\n", w.Body.String())
+}
+
+func TestCertificateHandler_Rejected(t *testing.T) {
+	var params map[string]string
+	ts := newFakeVault(&params)
+	defer ts.Close()
+	store := fake.NewFixedStore()
+	store.Machines["e5f6a7b8"] = &storagepb.Machine{Id: "e5f6a7b8"}
+	logger, _ := logtest.NewNullLogger()
+	c := server.NewServer(&server.Config{Store: store})
+	srv := NewServer(&Config{Logger: logger, PKI: vault.NewPKI(&vault.PKIConfig{Address: ts.URL, Role: "node"})})
+	h := srv.certificateHandler(c)
+	token, err := c.TokenCreate(context.Background(), &pb.TokenCreateRequest{
+		Labels: map[string]string{"uuid": "a1b2c3d4"},
+	})
+	assert.Nil(t, err)
+	noHostname, err := c.TokenCreate(context.Background(), &pb.TokenCreateRequest{
+		Labels: map[string]string{"uuid": "e5f6a7b8"},
+	})
+	assert.Nil(t, err)
+
+	cases := []struct {
+		url        string
+		commonName string
+		code       int
+	}{
+		// missing machine identity
+		{"/v1/certificate?token=" + token, "", http.StatusBadRequest},
+		// missing token
+		{"/v1/certificate?uuid=a1b2c3d4", "", http.StatusForbidden},
+		// token bound to a different machine
+		{"/v1/certificate?uuid=e5f6a7b8&token=" + token, "", http.StatusForbidden},
+		// client certificate for a different machine
+		{"/v1/certificate?uuid=a1b2c3d4", "e5f6a7b8", http.StatusForbidden},
+		// machine without a hostname fact
+		{"/v1/certificate?uuid=e5f6a7b8&token=" + noHostname, "", http.StatusConflict},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", tc.url, nil)
+		if tc.commonName != "" {
+			req.TLS = &tls.ConnectionState{
+				VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: tc.commonName}}}},
+			}
+		}
+		h.ServeHTTP(context.Background(), w, req)
+		assert.Equal(t, tc.code, w.Code, tc.url)
+	}
+	assert.Nil(t, params)
+}
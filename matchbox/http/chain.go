@@ -0,0 +1,103 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// DefaultChainURL is the URL the /boot.ipxe script chainloads, relative to
+// the /boot.ipxe URL, with the forwarded iPXE settings as the query.
+const DefaultChainURL = "ipxe?{{.Query}}"
+
+var (
+	// ErrInvalidChainLabel is returned for chain labels which aren't
+	// LABEL=SETTING pairs.
+	ErrInvalidChainLabel = errors.New("http: chain labels must be LABEL=SETTING pairs (e.g. site=net0/user-class)")
+	// chainLabelRegexp matches label names which are safe in query strings.
+	chainLabelRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+)
+
+// A Chain is the chainload of the /boot.ipxe bootstrap script: the iPXE
+// settings forwarded as labels and the template of the chained URL.
+type Chain struct {
+	variables []ipxeVariable
+	url       *template.Template
+}
+
+// chainData is the data of chain URL templates.
+type chainData struct {
+	// query forwarding the iPXE settings as labels
+	Query string
+	// labels of the /boot.ipxe request (e.g. set by DHCP)
+	Labels map[string]string
+}
+
+// ParseChain parses a chain URL template, rendered with the .Query of the
+// forwarded iPXE settings and the .Labels of the /boot.ipxe request, and
+// comma separated LABEL=SETTING pairs of iPXE settings to forward as well
+// as (or instead of) the default settings. An empty URL uses the
+// DefaultChainURL.
+func ParseChain(rawURL, labels string) (*Chain, error) {
+	if rawURL == "" {
+		rawURL = DefaultChainURL
+	}
+	tmpl, err := template.New("chain").Option("missingkey=zero").Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("http: invalid chain URL template: %v", err)
+	}
+	variables := append([]ipxeVariable(nil), ipxeVariables...)
+	for _, field := range strings.Split(labels, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 || !chainLabelRegexp.MatchString(parts[0]) || parts[1] == "" || strings.ContainsAny(parts[1], " &${}") {
+			return nil, ErrInvalidChainLabel
+		}
+		variables = setVariable(variables, ipxeVariable{label: parts[0], setting: parts[1]})
+	}
+	return &Chain{variables: variables, url: tmpl}, nil
+}
+
+// setVariable replaces the setting of a label, or appends it.
+func setVariable(variables []ipxeVariable, v ipxeVariable) []ipxeVariable {
+	for i := range variables {
+		if variables[i].label == v.label {
+			variables[i] = v
+			return variables
+		}
+	}
+	return append(variables, v)
+}
+
+// query returns the query which forwards the iPXE settings as labels.
+func (c *Chain) query() string {
+	params := make([]string, len(c.variables))
+	for i, v := range c.variables {
+		params[i] = v.label + "=${" + v.setting + "}"
+	}
+	return strings.Join(params, "&")
+}
+
+// render renders the chained URL for a /boot.ipxe request's labels.
+func (c *Chain) render(labels map[string]string) (string, error) {
+	var buf bytes.Buffer
+	if err := c.url.Execute(&buf, &chainData{Query: c.query(), Labels: labels}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// signatureURL returns the URL of the CMS signature of a chained URL, which
+// has the imageSignatureExt appended to its path.
+func signatureURL(chainURL string) string {
+	if i := strings.Index(chainURL, "?"); i >= 0 {
+		return chainURL[:i] + imageSignatureExt + chainURL[i:]
+	}
+	return chainURL + imageSignatureExt
+}
@@ -0,0 +1,45 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseChain(t *testing.T) {
+	// assert that:
+	// - chain labels add iPXE settings, or replace those of default labels
+	// - invalid labels and templates are errors
+	chain, err := ParseChain("", "site=net0/user-class, hostname=net0/hostname")
+	if assert.Nil(t, err) {
+		query := chain.query()
+		assert.Contains(t, query, "&hostname=${net0/hostname}&")
+		assert.Contains(t, query, "&ip=${netX/ip}&site=${net0/user-class}")
+	}
+	for _, labels := range []string{"site", "=domain", "site=", "site=${domain}", "site id=domain"} {
+		_, err = ParseChain("", labels)
+		assert.Equal(t, ErrInvalidChainLabel, err, labels)
+	}
+	_, err = ParseChain("ipxe?{{.Query", "")
+	assert.Error(t, err)
+}
+
+func TestIPXEInspect_Chain(t *testing.T) {
+	chain, err := ParseChain("http://matchbox.{{.Labels.site}}.example.com/ipxe?{{.Query}}&site={{.Labels.site}}", "")
+	assert.Nil(t, err)
+	h := NewServer(&Config{Chain: chain}).ipxeInspect()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/boot.ipxe?site=dc1", nil)
+	h.ServeHTTP(context.Background(), w, req)
+	// assert that the chain URL is rendered with the request labels
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "#!ipxe\nchain http://matchbox.dc1.example.com/ipxe?"+defaultChain.query()+"&site=dc1\n", w.Body.String())
+}
+
+func TestSignatureURL(t *testing.T) {
+	assert.Equal(t, "ipxe.p7s?uuid=${uuid}", signatureURL("ipxe?uuid=${uuid}"))
+	assert.Equal(t, "http://matchbox.example.com/ipxe.p7s", signatureURL("http://matchbox.example.com/ipxe"))
+}
@@ -2,16 +2,18 @@ package http
 
 import (
 	"bytes"
+	"fmt"
 	"net/http"
-	"strings"
 	"time"
 
 	"context"
 	"github.com/Sirupsen/logrus"
 	cloudinit "github.com/coreos/coreos-cloudinit/config"
+	"github.com/coreos/ignition/config/validate/report"
 
 	"github.com/coreos/matchbox/matchbox/server"
 	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
 )
 
 // CloudConfig defines a cloud-init config.
@@ -26,73 +28,89 @@ func (s *Server) cloudHandler(core server.Server) ContextHandler {
 		group, err := groupFromContext(ctx)
 		if err != nil {
 			s.logger.WithFields(logrus.Fields{
-				"labels": labelsFromRequest(nil, req),
+				"labels": s.redactor.Labels(labelsFromRequest(nil, req)),
 			}).Infof("No matching group")
-			http.NotFound(w, req)
+			writeProblem(w, http.StatusNotFound, CodeNoMatchingGroup, "no group matches the machine")
 			return
 		}
 
 		profile, err := core.ProfileGet(ctx, &pb.ProfileGetRequest{Id: group.Profile})
 		if err != nil {
 			s.logger.WithFields(logrus.Fields{
-				"labels":     labelsFromRequest(nil, req),
+				"labels":     s.redactor.Labels(labelsFromRequest(nil, req)),
 				"group":      group.Id,
 				"group_name": group.Name,
 			}).Infof("No profile named: %s", group.Profile)
-			http.NotFound(w, req)
+			writeProblem(w, http.StatusNotFound, CodeProfileNotFound, fmt.Sprintf("profile %q not found", group.Profile))
 			return
 		}
 
 		contents, err := core.CloudGet(ctx, profile.CloudId)
 		if err != nil {
 			s.logger.WithFields(logrus.Fields{
-				"labels":     labelsFromRequest(nil, req),
+				"labels":     s.redactor.Labels(labelsFromRequest(nil, req)),
 				"group":      group.Id,
 				"group_name": group.Name,
 				"profile":    group.Profile,
 			}).Infof("No cloud-config template named: %s", profile.CloudId)
-			http.NotFound(w, req)
+			writeProblem(w, http.StatusNotFound, CodeTemplateNotFound, fmt.Sprintf("cloud-config template %q not found", profile.CloudId))
 			return
 		}
 
 		// match was successful
 		s.logger.WithFields(logrus.Fields{
-			"labels":  labelsFromRequest(nil, req),
+			"labels":  s.redactor.Labels(labelsFromRequest(nil, req)),
 			"group":   group.Id,
 			"profile": profile.Id,
 		}).Debug("Matched a cloud-config template")
-
-		// collect data for rendering
-		data, err := collectVariables(req, group)
-		if err != nil {
-			s.logger.Errorf("error collecting variables: %v", err)
-			http.NotFound(w, req)
+		requestInfoFromContext(ctx).profile = profile.Id
+		if err := profile.ValidateMetadata(group); err != nil {
+			s.metadataFailed(w, "cloud", err)
 			return
 		}
 
+		setProfileHeaders(w, profile)
+
 		// render the template of a cloud config with data
-		var buf bytes.Buffer
-		err = s.renderTemplate(&buf, data, contents)
-		if err != nil {
-			http.NotFound(w, req)
+		start := time.Now()
+		config, err := s.renderCloud(ctx, req, group, contents)
+		s.observeRender(ctx, "cloud", profile.CloudId, start)
+		if rerr, ok := err.(*reportError); ok {
+			s.renderFailed(w, "cloud", profile.CloudId, rerr.report)
 			return
-		}
-
-		config := buf.String()
-		if !cloudinit.IsCloudConfig(config) && !cloudinit.IsScript(config) {
-			s.logger.Error("error parsing user-data")
-			http.NotFound(w, req)
+		} else if err != nil {
+			s.logger.Errorf("error collecting variables: %v", err)
+			writeProblem(w, http.StatusNotFound, CodeInvalidMetadata, "error collecting template variables")
 			return
 		}
+		http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(config))
+	}
+	return ContextHandlerFunc(fn)
+}
+
+// renderCloud renders a cloud-config template with the request and Group
+// data and validates the result is a cloud-config or script.
+func (s *Server) renderCloud(ctx context.Context, req *http.Request, group *storagepb.Group, contents string) ([]byte, error) {
+	data, err := collectVariables(ctx, req, group)
+	if err != nil {
+		return nil, err
+	}
 
-		if cloudinit.IsCloudConfig(config) {
-			if _, err = cloudinit.NewCloudConfig(config); err != nil {
-				s.logger.Errorf("error parsing cloud config: %v", err)
-				http.NotFound(w, req)
-				return
-			}
+	var buf bytes.Buffer
+	err = s.renderTemplate(&buf, data, contents)
+	if err != nil {
+		return nil, &reportError{templateReport(contents, err)}
+	}
+
+	config := buf.String()
+	if !cloudinit.IsCloudConfig(config) && !cloudinit.IsScript(config) {
+		err = fmt.Errorf("rendered user-data is not a cloud-config or script")
+		return nil, &reportError{report.ReportFromError(err, report.EntryError)}
+	}
+	if cloudinit.IsCloudConfig(config) {
+		if _, err = cloudinit.NewCloudConfig(config); err != nil {
+			return nil, &reportError{report.ReportFromError(err, report.EntryError)}
 		}
-		http.ServeContent(w, req, "", time.Time{}, strings.NewReader(config))
 	}
-	return ContextHandlerFunc(fn)
+	return buf.Bytes(), nil
 }
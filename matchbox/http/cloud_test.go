@@ -93,5 +93,13 @@ coreos:
 	// assert that:
 	// - Cloud-config template rendering errors because "missing_key" is not
 	// present in the template variables
-	assert.Equal(t, http.StatusNotFound, w.Code)
+	// - the error is reported with its position in the template
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	problem := decodeProblem(t, w)
+	assert.Equal(t, CodeTemplateError, problem.Code)
+	assert.Equal(t, `error rendering cloud template cloud-config.tmpl:
+error at line 4, column 13
+   4 |     name: {{.missing_key}}
+     |             ^
+at <.missing_key>: map has no entry for key "missing_key"`, problem.Detail)
 }
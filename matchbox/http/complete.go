@@ -0,0 +1,132 @@
+package http
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/requestid"
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	"github.com/coreos/matchbox/matchbox/webhook"
+)
+
+// maxCompletionPayload limits the size of completion payloads (1 MiB).
+const maxCompletionPayload = 1 << 20
+
+// completeHandler returns a handler that records that the machine with the
+// "uuid" query parameter finished provisioning. The request body is stored
+// as the completion payload and the Machine state is set to provisioned.
+func (s *Server) completeHandler(core server.Server) ContextHandler {
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		if req.Method != "POST" {
+			w.Header().Set("Allow", "POST")
+			writeProblem(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "")
+			return
+		}
+		labels := labelsFromRequest(s.logger, req)
+		uuid := labels["uuid"]
+		if uuid == "" {
+			writeProblem(w, http.StatusBadRequest, CodeInvalidRequest, "uuid query parameter is required")
+			return
+		}
+		payload, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, maxCompletionPayload))
+		if err != nil {
+			writeProblem(w, http.StatusRequestEntityTooLarge, CodePayloadTooLarge, "payload too large")
+			return
+		}
+
+		var decommissioned *storagepb.Machine
+		machine, err := core.MachineUpdate(ctx, uuid, func(machine *storagepb.Machine) (*storagepb.Machine, error) {
+			if machine == nil {
+				machine = &storagepb.Machine{Id: uuid}
+			}
+			machine.Labels = labels
+			machine.Wipe = false
+			machine.Attempts = 0
+			machine.Completed = time.Now().UTC().Format(time.RFC3339)
+			machine.Payload = payload
+			if machine.State == storagepb.MachineDecommission {
+				decommissioned = machine
+				return nil, nil
+			}
+			machine.State = storagepb.MachineProvisioned
+			return machine, nil
+		})
+		if decommissioned != nil {
+			s.decommissioned(ctx, core, w, decommissioned)
+			return
+		}
+		if err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"uuid": uuid,
+			}).Errorf("error recording machine completion: %v", err)
+			writeProblem(w, http.StatusInternalServerError, CodeInternalError, "error recording machine completion")
+			return
+		}
+
+		s.logger.WithFields(logrus.Fields{
+			"labels": s.redactor.Labels(labels),
+		}).Infof("Machine %s completed provisioning", uuid)
+		s.webhooks.Notify(s.completeEvent(ctx, core, machine))
+		s.webhooks.Completed(uuid)
+		w.WriteHeader(http.StatusNoContent)
+	}
+	return ContextHandlerFunc(fn)
+}
+
+// decommissioned archives a machine being decommissioned which reported
+// completion, with the completion payload (e.g. a disk wipe report).
+func (s *Server) decommissioned(ctx context.Context, core server.Server, w http.ResponseWriter, machine *storagepb.Machine) {
+	machine.State = storagepb.MachineDecommissioned
+	if err := core.MachineArchive(ctx, machine); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"uuid": machine.Id,
+		}).Errorf("error archiving decommissioned machine: %v", err)
+		writeProblem(w, http.StatusInternalServerError, CodeInternalError, "error archiving decommissioned machine")
+		return
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"labels":  s.redactor.Labels(machine.Labels),
+		"profile": machine.Decommission,
+	}).Infof("Machine %s completed decommissioning and was archived", machine.Id)
+	s.webhooks.Notify(&webhook.Event{
+		Type:      webhook.EventDecommissioned,
+		MachineID: machine.Id,
+		Labels:    s.redactor.Labels(machine.Labels),
+		RequestID: requestid.FromContext(ctx),
+		Machine:   machine,
+	})
+	s.webhooks.Completed(machine.Id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// completeEvent returns the machine.complete event of a Machine, with the
+// Group it matches and the Group's metadata, so hooks may use it (e.g. to
+// add the machine to DNS).
+func (s *Server) completeEvent(ctx context.Context, core server.Server, machine *storagepb.Machine) *webhook.Event {
+	event := &webhook.Event{
+		Type:      webhook.EventComplete,
+		MachineID: machine.Id,
+		Labels:    s.redactor.Labels(machine.Labels),
+		RequestID: requestid.FromContext(ctx),
+		Machine:   machine,
+	}
+	if !s.webhooks.Wants(webhook.EventComplete) {
+		return event
+	}
+	group := machineGroup(ctx, core, machine)
+	if group == nil {
+		return event
+	}
+	event.Group = group.Id
+	event.Profile = group.Profile
+	if metadata, err := s.redactor.Metadata(group.Metadata); err == nil {
+		event.Metadata = metadata
+	}
+	return event
+}
@@ -0,0 +1,132 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"context"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+	"github.com/coreos/matchbox/matchbox/webhook"
+)
+
+func TestCompleteHandler(t *testing.T) {
+	notified := make(chan *webhook.Event, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		event := new(webhook.Event)
+		json.NewDecoder(req.Body).Decode(event)
+		notified <- event
+	}))
+	defer hook.Close()
+
+	store := fake.NewFixedStore()
+	store.Groups[fake.Group.Id] = fake.Group
+	logger, _ := logtest.NewNullLogger()
+	notifier := webhook.NewNotifier(&webhook.Config{
+		Hooks:  []webhook.Hook{{URL: hook.URL, Events: []string{webhook.EventComplete}}},
+		Logger: logger,
+	})
+	srv := NewServer(&Config{Logger: logger, Webhooks: notifier})
+	c := server.NewServer(&server.Config{Store: store})
+	h := srv.completeHandler(c)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/complete?uuid=a1b2c3d4&mac=52-54-00-a1-9c-ae", strings.NewReader(`{"os":"coreos"}`))
+	h.ServeHTTP(context.Background(), w, req)
+	// assert that:
+	// - the Machine is recorded as provisioned with the payload
+	// - the webhook is notified with the machine's Group and metadata
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	machine := store.Machines["a1b2c3d4"]
+	if assert.NotNil(t, machine) {
+		assert.Equal(t, storagepb.MachineProvisioned, machine.State)
+		assert.Equal(t, "52:54:00:a1:9c:ae", machine.Labels["mac"])
+		assert.Equal(t, `{"os":"coreos"}`, string(machine.Payload))
+		assert.NotEmpty(t, machine.Completed)
+	}
+	event := <-notified
+	assert.Equal(t, webhook.EventComplete, event.Type)
+	assert.Equal(t, "a1b2c3d4", event.MachineID)
+	if assert.NotNil(t, event.Machine) {
+		assert.Equal(t, storagepb.MachineProvisioned, event.Machine.State)
+	}
+	assert.Equal(t, fake.Group.Id, event.Group)
+	assert.Equal(t, fake.Group.Profile, event.Profile)
+	assert.JSONEq(t, string(fake.Group.Metadata), string(event.Metadata))
+}
+
+func TestCompleteHandler_BadRequests(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	c := server.NewServer(&server.Config{Store: fake.NewFixedStore()})
+	h := srv.completeHandler(c)
+	cases := []struct {
+		method string
+		url    string
+		code   int
+	}{
+		{"GET", "/v1/complete?uuid=a1b2c3d4", http.StatusMethodNotAllowed},
+		{"POST", "/v1/complete", http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(tc.method, tc.url, strings.NewReader(""))
+		h.ServeHTTP(context.Background(), w, req)
+		assert.Equal(t, tc.code, w.Code)
+	}
+}
+
+func TestCompleteHandler_StoreError(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	c := server.NewServer(&server.Config{Store: &fake.BrokenStore{}})
+	h := srv.completeHandler(c)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/complete?uuid=a1b2c3d4", strings.NewReader(""))
+	h.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestCompleteHandler_Decommissioned(t *testing.T) {
+	notified := make(chan *webhook.Event, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		event := new(webhook.Event)
+		json.NewDecoder(req.Body).Decode(event)
+		notified <- event
+	}))
+	defer hook.Close()
+
+	store := fake.NewFixedStore()
+	store.Machines["a1b2c3d4"] = &storagepb.Machine{Id: "a1b2c3d4", State: storagepb.MachineDecommission, Decommission: "wipe"}
+	logger, _ := logtest.NewNullLogger()
+	notifier := webhook.NewNotifier(&webhook.Config{
+		Hooks:  []webhook.Hook{{URL: hook.URL, Events: []string{webhook.EventDecommissioned}}},
+		Logger: logger,
+	})
+	srv := NewServer(&Config{Logger: logger, Webhooks: notifier})
+	c := server.NewServer(&server.Config{Store: store})
+	h := srv.completeHandler(c)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/complete?uuid=a1b2c3d4", strings.NewReader(`{"erased":"sda"}`))
+	h.ServeHTTP(context.Background(), w, req)
+	// assert that:
+	// - the Machine is archived with the payload and removed
+	// - the webhook is notified
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Nil(t, store.Machines["a1b2c3d4"])
+	if assert.Len(t, store.Archive, 1) {
+		assert.Equal(t, storagepb.MachineDecommissioned, store.Archive[0].State)
+		assert.Equal(t, `{"erased":"sda"}`, string(store.Archive[0].Payload))
+		assert.NotEmpty(t, store.Archive[0].Completed)
+	}
+	event := <-notified
+	assert.Equal(t, webhook.EventDecommissioned, event.Type)
+	assert.Equal(t, "a1b2c3d4", event.MachineID)
+}
@@ -14,6 +14,10 @@ type key int
 const (
 	profileKey key = iota
 	groupKey
+	labelsKey
+	previewKey
+	wipeKey
+	signatureKey
 )
 
 var (
@@ -48,3 +52,48 @@ func groupFromContext(ctx context.Context) (*storagepb.Group, error) {
 	}
 	return group, nil
 }
+
+// withLabels returns a copy of ctx that stores the machine's labels.
+func withLabels(ctx context.Context, labels map[string]string) context.Context {
+	return context.WithValue(ctx, labelsKey, labels)
+}
+
+// labelsFromContext returns the machine's labels from the ctx, or nil.
+func labelsFromContext(ctx context.Context) map[string]string {
+	labels, _ := ctx.Value(labelsKey).(map[string]string)
+	return labels
+}
+
+// withPreview returns a copy of ctx for rendering a preview.
+func withPreview(ctx context.Context) context.Context {
+	return context.WithValue(ctx, previewKey, true)
+}
+
+// isPreview returns true if the ctx renders a preview.
+func isPreview(ctx context.Context) bool {
+	preview, _ := ctx.Value(previewKey).(bool)
+	return preview
+}
+
+// withWipe returns a copy of ctx for a machine whose disks should be wiped.
+func withWipe(ctx context.Context) context.Context {
+	return context.WithValue(ctx, wipeKey, true)
+}
+
+// isWipe returns true if the ctx is for a machine whose disks should be
+// wiped.
+func isWipe(ctx context.Context) bool {
+	wipe, _ := ctx.Value(wipeKey).(bool)
+	return wipe
+}
+
+// withSignature returns a copy of ctx for rendering an artifact to sign.
+func withSignature(ctx context.Context) context.Context {
+	return context.WithValue(ctx, signatureKey, true)
+}
+
+// isSignature returns true if the ctx renders an artifact to sign.
+func isSignature(ctx context.Context) bool {
+	signature, _ := ctx.Value(signatureKey).(bool)
+	return signature
+}
@@ -28,20 +28,18 @@ func (f ContextHandlerFunc) ServeHTTP(ctx context.Context, w http.ResponseWriter
 // Middleswares which do not pass a ctx break the chain so place them before
 // or after chains of ContextHandlers.
 type handler struct {
-	ctx     context.Context
 	handler ContextHandler
 }
 
 // NewHandler returns an http.Handler which wraps the given ContextHandler
-// and creates a background context.Context.
+// and passes it the request's context.Context.
 func NewHandler(h ContextHandler) http.Handler {
 	return &handler{
-		ctx:     context.Background(),
 		handler: h,
 	}
 }
 
 // ServeHTTP lets handler implement the http.Handler interface.
 func (h *handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	h.handler.ServeHTTP(h.ctx, w, req)
+	h.handler.ServeHTTP(req.Context(), w, req)
 }
@@ -0,0 +1,27 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/coreos/matchbox/matchbox/deprecation"
+)
+
+// warnDeprecated warns about requests to deprecated endpoints or with
+// deprecated query parameters, then calls the next handler.
+func (s *Server) warnDeprecated(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		s.warn(w, deprecation.Request(req))
+		next.ServeHTTP(w, req)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// warn adds a Warning header to the response for each use of a deprecated
+// item and reports them in the logs and metrics.
+func (s *Server) warn(w http.ResponseWriter, warnings []*deprecation.Warning) {
+	if len(warnings) == 0 {
+		return
+	}
+	deprecation.SetHeaders(w.Header(), warnings)
+	s.deprecations.Report(warnings)
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestWarnDeprecated(t *testing.T) {
+	profile := &storagepb.Profile{
+		Id: testGroupWithMAC.Profile,
+		Boot: &storagepb.NetBoot{
+			Kernel:  "/image/kernel",
+			Cmdline: map[string]string{"console": "ttyS0"},
+		},
+	}
+	store := &fake.FixedStore{
+		Groups:   map[string]*storagepb.Group{testGroupWithMAC.Id: testGroupWithMAC},
+		Profiles: map[string]*storagepb.Profile{profile.Id: profile},
+	}
+	logger, hook := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:   server.NewServer(&server.Config{Store: store}),
+		Logger: logger,
+	})
+	h := srv.HTTPHandler()
+
+	// assert that:
+	// - requests to deprecated endpoints get a Warning header
+	// - Profiles with deprecated fields get a Warning header
+	// - warnings are logged
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/pixiecore/v1/boot/"+validMACStr, nil)
+	h.ServeHTTP(w, req)
+	assert.Equal(t, []string{
+		`299 - "endpoint /pixiecore/v1/boot/ is deprecated since v0.5.0: use iPXE or GRUB network boot"`,
+		`299 - "profile \"` + profile.Id + `\" field boot.cmdline is deprecated since v0.5.0: list kernel args in boot.args"`,
+	}, w.HeaderMap["Warning"])
+	assert.NotEmpty(t, hook.Entries)
+
+	// - Profiles are checked when rendering configs
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/ipxe?mac="+validMACStr, nil)
+	h.ServeHTTP(w, req)
+	assert.Len(t, w.HeaderMap["Warning"], 1)
+
+	// - requests without deprecated uses have no Warning header
+	store.Profiles[profile.Id] = &storagepb.Profile{Id: profile.Id, Boot: &storagepb.NetBoot{Kernel: "/image/kernel"}}
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/ipxe?mac="+validMACStr, nil)
+	h.ServeHTTP(w, req)
+	assert.Empty(t, w.HeaderMap["Warning"])
+}
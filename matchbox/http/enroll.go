@@ -0,0 +1,32 @@
+package http
+
+import (
+	"github.com/Sirupsen/logrus"
+
+	"context"
+
+	"github.com/coreos/matchbox/matchbox/server"
+)
+
+// enroll enrolls a machine which matches no Group into the enrollment
+// Group, if enrollment is enabled, and returns its labels with the facts
+// it was assigned (e.g. its hostname). Otherwise, the labels are returned
+// unchanged.
+func (s *Server) enroll(ctx context.Context, core server.Server, labels map[string]string) map[string]string {
+	machine, err := core.MachineEnroll(ctx, labels)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"labels": s.redactor.Labels(labels),
+		}).Errorf("error enrolling machine: %v", err)
+		return labels
+	}
+	if machine == nil {
+		return labels
+	}
+	s.logger.WithFields(logrus.Fields{
+		"labels":   s.redactor.Labels(labels),
+		"group":    machine.Group,
+		"hostname": machine.Facts["hostname"],
+	}).Infof("Machine %s matched no group, enrolled it", machine.Id)
+	return core.MachineLabels(ctx, labels)
+}
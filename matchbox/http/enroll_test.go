@@ -0,0 +1,51 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestEnroll(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups["default"] = &storagepb.Group{Id: "default", Profile: "worker"}
+	store.Profiles["worker"] = &storagepb.Profile{
+		Id:        "worker",
+		Boot:      &storagepb.NetBoot{Kernel: "/worker/vmlinuz"},
+		GenericId: "worker.tmpl",
+	}
+	store.GenericConfigs["worker.tmpl"] = "HOSTNAME={{.request.labels.hostname}}"
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:   server.NewServer(&server.Config{Store: store, EnrollGroup: "default"}),
+		Logger: logger,
+	})
+	h := srv.HTTPHandler()
+	get := func(path string) string {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		h.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	// assert that:
+	// - machines which match no Group are enrolled on their first boot
+	// - templates are rendered with the enrolled hostname
+	// - the hostname persists across requests
+	assert.Contains(t, get("/ipxe?uuid=e5f6a7b8"), "/worker/vmlinuz")
+	if machine, ok := store.Machines["e5f6a7b8"]; assert.True(t, ok) {
+		assert.Equal(t, "default", machine.Group)
+		assert.Equal(t, "node-1", machine.Facts["hostname"])
+	}
+	assert.Equal(t, "HOSTNAME=node-1", get("/generic?uuid=e5f6a7b8"))
+	assert.Equal(t, "HOSTNAME=node-2", get("/generic?uuid=c9d0e1f2"))
+	assert.Equal(t, "HOSTNAME=node-1", get("/generic?uuid=e5f6a7b8"))
+}
@@ -0,0 +1,70 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// renderETag returns a strong ETag identifying a rendered config by its
+// inputs: the Profile, the matched Group (selectors and metadata), the
+// template contents, and the request query parameters.
+func renderETag(profile *storagepb.Profile, group *storagepb.Group, contents string, req *http.Request) string {
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	enc.Encode(profile)
+	enc.Encode(group)
+	enc.Encode(contents)
+	enc.Encode(req.URL.RawQuery)
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// contentETag returns a strong ETag of rendered content.
+func contentETag(content []byte) string {
+	sum := sha256.Sum256(content)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified sets the ETag response header and returns true, after
+// responding with 304 Not Modified, if the request's If-None-Match header
+// matches the ETag.
+func notModified(w http.ResponseWriter, req *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	if req.Method != "GET" && req.Method != "HEAD" {
+		return false
+	}
+	inm := req.Header.Get("If-None-Match")
+	if inm == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(inm, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// usesIncludes returns true if a template may include other templates,
+// whose contents are not covered by a renderETag.
+func usesIncludes(contents string) bool {
+	return strings.Contains(contents, "include")
+}
+
+// mintsTokens returns true if a template mints bootstrap tokens, which
+// change each render.
+func mintsTokens(contents string) bool {
+	return strings.Contains(contents, "kubeadmToken")
+}
+
+// mayMintTokens returns true if a render of a template may mint bootstrap
+// tokens, in the template or in templates it includes.
+func (s *Server) mayMintTokens(contents string) bool {
+	return mintsTokens(contents) || (s.kubeTokens != nil && usesIncludes(contents))
+}
@@ -0,0 +1,111 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"context"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestRenderETag(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/?foo=bar", nil)
+	other, _ := http.NewRequest("GET", "/?foo=baz", nil)
+	etag := renderETag(fake.Profile, fake.Group, "template", req)
+	// assert that:
+	// - ETags are stable for the same inputs
+	// - ETags change with the template, metadata, or query
+	assert.Equal(t, etag, renderETag(fake.Profile, fake.Group, "template", req))
+	assert.NotEqual(t, etag, renderETag(fake.Profile, fake.Group, "changed", req))
+	assert.NotEqual(t, etag, renderETag(fake.Profile, fake.GroupNoMetadata, "template", req))
+	assert.NotEqual(t, etag, renderETag(fake.Profile, fake.Group, "template", other))
+}
+
+func TestNotModified(t *testing.T) {
+	cases := []struct {
+		ifNoneMatch string
+		expected    bool
+	}{
+		{"", false},
+		{`"other"`, false},
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"other", "abc"`, true},
+		{"*", true},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set("If-None-Match", c.ifNoneMatch)
+		assert.Equal(t, c.expected, notModified(w, req, `"abc"`))
+		assert.Equal(t, `"abc"`, w.HeaderMap.Get("ETag"))
+		if c.expected {
+			assert.Equal(t, http.StatusNotModified, w.Code)
+		}
+	}
+}
+
+func TestGenericHandler_NotModified(t *testing.T) {
+	store := &fake.FixedStore{
+		Profiles:       map[string]*storagepb.Profile{fake.Group.Profile: fake.Profile},
+		GenericConfigs: map[string]string{fake.Profile.GenericId: "UUID={{.uuid}}"},
+	}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	c := server.NewServer(&server.Config{Store: store})
+	h := srv.genericHandler(c)
+	ctx := withGroup(context.Background(), fake.Group)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	h.ServeHTTP(ctx, w, req)
+	etag := w.HeaderMap.Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	// assert that:
+	// - a conditional request with the ETag is not modified
+	// - changing the template changes the response
+	w = httptest.NewRecorder()
+	req.Header.Set("If-None-Match", etag)
+	h.ServeHTTP(ctx, w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	store.GenericConfigs[fake.Profile.GenericId] = "ID={{.uuid}}"
+	w = httptest.NewRecorder()
+	h.ServeHTTP(ctx, w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ID=a1b2c3d4", w.Body.String())
+}
+
+func TestIgnitionHandler_NotModified(t *testing.T) {
+	content := `{"ignition":{"version":"2.0.0","config":{}},"storage":{},"systemd":{},"networkd":{},"passwd":{}}`
+	profile := &storagepb.Profile{
+		Id:         fake.Group.Profile,
+		IgnitionId: "file.ign",
+	}
+	store := &fake.FixedStore{
+		Profiles:        map[string]*storagepb.Profile{fake.Group.Profile: profile},
+		IgnitionConfigs: map[string]string{"file.ign": content},
+	}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	c := server.NewServer(&server.Config{Store: store})
+	h := srv.ignitionHandler(c)
+	ctx := withGroup(context.Background(), fake.Group)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	h.ServeHTTP(ctx, w, req)
+	etag := w.HeaderMap.Get("ETag")
+
+	w = httptest.NewRecorder()
+	req.Header.Set("If-None-Match", etag)
+	h.ServeHTTP(ctx, w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+}
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// eventPruneInterval is how often Events older than the retention are
+// deleted from the event history.
+const eventPruneInterval = time.Hour
+
+// eventTypes are the types of the Events recorded for requests to each
+// boot and provisioning endpoint.
+var eventTypes = map[string]string{
+	"/ipxe":        storagepb.EventBoot,
+	"/grub":        storagepb.EventBoot,
+	"/ignition":    storagepb.EventConfig,
+	"/cloud":       storagepb.EventConfig,
+	"/generic":     storagepb.EventConfig,
+	"/metadata":    storagepb.EventConfig,
+	"/v1/progress": storagepb.EventProgress,
+	"/v1/complete": storagepb.EventComplete,
+}
+
+// publishEvent publishes a served boot request to live event subscribers.
+func (s *Server) publishEvent(req *http.Request, status int, info *requestInfo) {
+	if s.events.Subscribers() == 0 || !bootEndpoints[req.URL.Path] {
+		return
+	}
+	labels := labelsFromRequest(nil, req)
+	s.events.Publish(&pb.BootEvent{
+		Time:      time.Now().UTC().Format(time.RFC3339),
+		Endpoint:  req.URL.Path,
+		Status:    int32(status),
+		MachineId: machineID(labels),
+		Labels:    s.redactor.Labels(labels),
+		Group:     info.group,
+		Profile:   info.profile,
+		RemoteIp:  remoteIP(req),
+		RequestId: info.id,
+	})
+}
+
+// machineID identifies a machine by its uuid label, or its mac label if
+// there is no uuid.
+func machineID(labels map[string]string) string {
+	if id := labels["uuid"]; id != "" {
+		return id
+	}
+	return labels["mac"]
+}
+
+// recordEvent records a served boot or provisioning request in the event
+// history, and deletes Events older than the retention at most once per
+// eventPruneInterval.
+func (s *Server) recordEvent(req *http.Request, status int, info *requestInfo) {
+	eventType, ok := eventTypes[req.URL.Path]
+	if s.eventRetention <= 0 || !ok {
+		return
+	}
+	now := time.Now().UTC()
+	labels := labelsFromRequest(nil, req)
+	event := &storagepb.Event{
+		Time:      now.Format(time.RFC3339),
+		Type:      eventType,
+		MachineId: machineID(labels),
+		Endpoint:  req.URL.Path,
+		Status:    int32(status),
+		Labels:    s.redactor.Labels(labels),
+		Group:     info.group,
+		Profile:   info.profile,
+		RemoteIp:  remoteIP(req),
+		RequestId: info.id,
+	}
+	ctx := req.Context()
+	if err := s.core.EventPut(ctx, event); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"endpoint": req.URL.Path,
+		}).Errorf("error recording event: %v", err)
+	}
+	last := atomic.LoadInt64(&s.eventsPruned)
+	if now.Unix()-last < int64(eventPruneInterval/time.Second) || !atomic.CompareAndSwapInt64(&s.eventsPruned, last, now.Unix()) {
+		return
+	}
+	if err := s.core.EventPrune(ctx, now.Add(-s.eventRetention)); err != nil {
+		s.logger.Errorf("error pruning events: %v", err)
+	}
+}
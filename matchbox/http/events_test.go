@@ -0,0 +1,97 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/events"
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestPublishEvent(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	hub := events.NewHub()
+	sub := hub.Subscribe(10)
+	defer sub.Close()
+	srv := NewServer(&Config{
+		Core:   server.NewServer(&server.Config{Store: fake.NewFixedStore()}),
+		Logger: logger,
+		Events: hub,
+	})
+	h := srv.HTTPHandler()
+	for _, url := range []string{"/", "/ipxe?mac=52-54-00-89-d8-10"} {
+		req, _ := http.NewRequest("GET", url, nil)
+		req.RemoteAddr = "10.1.2.3:51234"
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	// assert that:
+	// - boot endpoint requests are published with the machine labels
+	// - other requests are not published
+	if assert.Len(t, sub.C, 1) {
+		event := <-sub.C
+		assert.Equal(t, "/ipxe", event.Endpoint)
+		assert.Equal(t, int32(http.StatusNotFound), event.Status)
+		assert.Equal(t, "52:54:00:89:d8:10", event.MachineId)
+		assert.Equal(t, "10.1.2.3", event.RemoteIp)
+		assert.NotEmpty(t, event.Time)
+	}
+}
+
+func TestMachineID(t *testing.T) {
+	assert.Equal(t, "a1b2c3d4", machineID(map[string]string{"uuid": "a1b2c3d4", "mac": "52:54:00:89:d8:10"}))
+	assert.Equal(t, "52:54:00:89:d8:10", machineID(map[string]string{"mac": "52:54:00:89:d8:10"}))
+	assert.Equal(t, "", machineID(map[string]string{}))
+}
+
+func TestRecordEvent(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	store := fake.NewFixedStore()
+	store.Events = []*storagepb.Event{{Time: "2026-01-01T00:00:00Z", Type: storagepb.EventBoot}}
+	srv := NewServer(&Config{
+		Core:           server.NewServer(&server.Config{Store: store}),
+		Logger:         logger,
+		EventRetention: 24 * time.Hour,
+	})
+	h := srv.HTTPHandler()
+	for _, url := range []string{"/", "/ipxe?uuid=a1b2c3d4", "/metadata?uuid=a1b2c3d4", "/v1/complete?uuid=a1b2c3d4"} {
+		req, _ := http.NewRequest("GET", url, nil)
+		req.RemoteAddr = "10.1.2.3:51234"
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	// assert that:
+	// - boot and provisioning requests are recorded with their event type
+	// - other requests are not recorded
+	// - Events older than the retention are pruned
+	if assert.Len(t, store.Events, 3) {
+		event := store.Events[0]
+		assert.Equal(t, storagepb.EventBoot, event.Type)
+		assert.Equal(t, "/ipxe", event.Endpoint)
+		assert.Equal(t, int32(http.StatusNotFound), event.Status)
+		assert.Equal(t, "a1b2c3d4", event.MachineId)
+		assert.Equal(t, "10.1.2.3", event.RemoteIp)
+		assert.NotEmpty(t, event.RequestId)
+		assert.Nil(t, event.AssertValid())
+		assert.Equal(t, storagepb.EventConfig, store.Events[1].Type)
+		assert.Equal(t, storagepb.EventComplete, store.Events[2].Type)
+	}
+}
+
+func TestRecordEvent_Disabled(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	store := fake.NewFixedStore()
+	srv := NewServer(&Config{
+		Core:   server.NewServer(&server.Config{Store: store}),
+		Logger: logger,
+	})
+	req, _ := http.NewRequest("GET", "/ipxe?uuid=a1b2c3d4", nil)
+	srv.HTTPHandler().ServeHTTP(httptest.NewRecorder(), req)
+	// assert that Events aren't recorded without a retention
+	assert.Empty(t, store.Events)
+}
@@ -0,0 +1,68 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"text/template"
+
+	"context"
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestExtensionHandlers(t *testing.T) {
+	logger, hook := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:   server.NewServer(&server.Config{Store: fake.NewFixedStore()}),
+		Logger: logger,
+		Handlers: map[string]http.Handler{
+			"/cmdb/": http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Write([]byte("cmdb " + req.URL.Path))
+			}),
+			"/ipxe": http.NotFoundHandler(),
+		},
+	})
+	h := srv.HTTPHandler()
+
+	// assert that:
+	// - extension endpoints are served
+	// - extension endpoints which conflict with matchbox endpoints are
+	// skipped and logged
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/cmdb/racks", nil)
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "cmdb /cmdb/racks", w.Body.String())
+	var skipped bool
+	for _, entry := range hook.Entries {
+		skipped = skipped || strings.HasPrefix(entry.Message, "Skipping extension endpoint /ipxe")
+	}
+	assert.True(t, skipped)
+}
+
+func TestExtensionTemplateFuncs(t *testing.T) {
+	store := &fake.FixedStore{
+		Profiles:       map[string]*storagepb.Profile{fake.Group.Profile: fake.Profile},
+		GenericConfigs: map[string]string{fake.Profile.GenericId: `SERVICE={{upper .service_name}}`},
+	}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Logger:        logger,
+		TemplateFuncs: template.FuncMap{"upper": strings.ToUpper},
+	})
+	c := server.NewServer(&server.Config{Store: store})
+	h := srv.genericHandler(c)
+	ctx := withGroup(context.Background(), fake.Group)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	h.ServeHTTP(ctx, w, req)
+	// assert that extension template functions are available to templates
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "SERVICE=ETCD2", w.Body.String())
+}
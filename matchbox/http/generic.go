@@ -2,8 +2,8 @@ package http
 
 import (
 	"bytes"
+	"fmt"
 	"net/http"
-	"strings"
 	"time"
 
 	"context"
@@ -11,6 +11,7 @@ import (
 
 	"github.com/coreos/matchbox/matchbox/server"
 	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
 )
 
 // genericHandler returns a handler that responds with the generic config
@@ -20,58 +21,80 @@ func (s *Server) genericHandler(core server.Server) ContextHandler {
 		group, err := groupFromContext(ctx)
 		if err != nil {
 			s.logger.WithFields(logrus.Fields{
-				"labels": labelsFromRequest(nil, req),
+				"labels": s.redactor.Labels(labelsFromRequest(nil, req)),
 			}).Infof("No matching group")
-			http.NotFound(w, req)
+			writeProblem(w, http.StatusNotFound, CodeNoMatchingGroup, "no group matches the machine")
 			return
 		}
 		profile, err := core.ProfileGet(ctx, &pb.ProfileGetRequest{Id: group.Profile})
 		if err != nil {
 			s.logger.WithFields(logrus.Fields{
-				"labels":     labelsFromRequest(nil, req),
+				"labels":     s.redactor.Labels(labelsFromRequest(nil, req)),
 				"group":      group.Id,
 				"group_name": group.Name,
 			}).Infof("No profile named: %s", group.Profile)
-			http.NotFound(w, req)
+			writeProblem(w, http.StatusNotFound, CodeProfileNotFound, fmt.Sprintf("profile %q not found", group.Profile))
 			return
 		}
 		contents, err := core.GenericGet(ctx, profile.GenericId)
 		if err != nil {
 			s.logger.WithFields(logrus.Fields{
-				"labels":     labelsFromRequest(nil, req),
+				"labels":     s.redactor.Labels(labelsFromRequest(nil, req)),
 				"group":      group.Id,
 				"group_name": group.Name,
 				"profile":    group.Profile,
 			}).Infof("No generic template named: %s", profile.GenericId)
-			http.NotFound(w, req)
+			writeProblem(w, http.StatusNotFound, CodeTemplateNotFound, fmt.Sprintf("generic template %q not found", profile.GenericId))
 			return
 		}
 
 		// match was successful
 		s.logger.WithFields(logrus.Fields{
-			"labels":  labelsFromRequest(nil, req),
+			"labels":  s.redactor.Labels(labelsFromRequest(nil, req)),
 			"group":   group.Id,
 			"profile": profile.Id,
 		}).Debug("Matched a generic template")
+		requestInfoFromContext(ctx).profile = profile.Id
+		if err := profile.ValidateMetadata(group); err != nil {
+			s.metadataFailed(w, "generic", err)
+			return
+		}
 
-		// collect data for rendering
-		data, err := collectVariables(req, group)
-		if err != nil {
-			s.logger.Errorf("error collecting variables: %v", err)
-			http.NotFound(w, req)
+		setProfileHeaders(w, profile)
+
+		// conditional requests skip rendering unchanged configs
+		if notModified(w, req, renderETag(profile, group, contents, req)) {
 			return
 		}
 
 		// render the template of a generic config with data
-		var buf bytes.Buffer
-		err = s.renderTemplate(&buf, data, contents)
-		if err != nil {
-			http.NotFound(w, req)
+		start := time.Now()
+		config, err := s.renderGeneric(ctx, req, group, contents)
+		s.observeRender(ctx, "generic", profile.GenericId, start)
+		if rerr, ok := err.(*reportError); ok {
+			s.renderFailed(w, "generic", profile.GenericId, rerr.report)
+			return
+		} else if err != nil {
+			s.logger.Errorf("error collecting variables: %v", err)
+			writeProblem(w, http.StatusNotFound, CodeInvalidMetadata, "error collecting template variables")
 			return
 		}
-
-		config := buf.String()
-		http.ServeContent(w, req, "", time.Time{}, strings.NewReader(config))
+		http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(config))
 	}
 	return ContextHandlerFunc(fn)
 }
+
+// renderGeneric renders a generic template with the request and Group data.
+func (s *Server) renderGeneric(ctx context.Context, req *http.Request, group *storagepb.Group, contents string) ([]byte, error) {
+	data, err := collectVariables(ctx, req, group)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = s.renderTemplate(&buf, data, contents)
+	if err != nil {
+		return nil, &reportError{templateReport(contents, err)}
+	}
+	return buf.Bytes(), nil
+}
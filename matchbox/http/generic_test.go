@@ -43,6 +43,61 @@ FOO=some-param
 	assert.Equal(t, expected, w.Body.String())
 }
 
+func TestGenericHandler_ProfileHeaders(t *testing.T) {
+	profile := fake.Profile.Copy()
+	profile.Headers = map[string]string{
+		"Content-Type":        "text/x-shellscript",
+		"Content-Disposition": `attachment; filename="install.sh"`,
+	}
+	store := &fake.FixedStore{
+		Profiles:       map[string]*storagepb.Profile{profile.Id: profile},
+		GenericConfigs: map[string]string{profile.GenericId: "SERVICE={{.service_name}}"},
+	}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	c := server.NewServer(&server.Config{Store: store})
+	h := srv.genericHandler(c)
+	ctx := withGroup(context.Background(), fake.Group)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	h.ServeHTTP(ctx, w, req)
+	// assert that:
+	// - the Profile's headers are set on the response, instead of a sniffed
+	// Content-Type
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/x-shellscript", w.HeaderMap.Get(contentType))
+	assert.Equal(t, `attachment; filename="install.sh"`, w.HeaderMap.Get("Content-Disposition"))
+	assert.Equal(t, "SERVICE=etcd2", w.Body.String())
+}
+
+func TestGenericHandler_InvalidMetadata(t *testing.T) {
+	profile := fake.Profile.Copy()
+	profile.MetadataSchema = []byte(`{"properties": {"service_name": {"enum": ["etcd3"]}}}`)
+	store := &fake.FixedStore{
+		Profiles:       map[string]*storagepb.Profile{profile.Id: profile},
+		GenericConfigs: map[string]string{profile.GenericId: "SERVICE={{.service_name}}"},
+	}
+	logger, hook := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	c := server.NewServer(&server.Config{Store: store})
+	h := srv.genericHandler(c)
+	ctx := withGroup(context.Background(), fake.Group)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	h.ServeHTTP(ctx, w, req)
+	// assert that:
+	// - configs aren't rendered with metadata the Profile schema rejects
+	// - the violations are logged and returned
+	expected := `group "test-group" metadata does not match profile "g1h2i3j4" metadata schema: service_name: must be one of "etcd3"`
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	problem := decodeProblem(t, w)
+	assert.Equal(t, CodeInvalidMetadata, problem.Code)
+	assert.Equal(t, expected, problem.Detail)
+	if assert.NotNil(t, hook.LastEntry()) {
+		assert.Equal(t, "error validating metadata: "+expected, hook.LastEntry().Message)
+	}
+}
+
 func TestGenericHandler_MissingCtxProfile(t *testing.T) {
 	logger, _ := logtest.NewNullLogger()
 	srv := NewServer(&Config{Logger: logger})
@@ -52,6 +107,7 @@ func TestGenericHandler_MissingCtxProfile(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/", nil)
 	h.ServeHTTP(context.Background(), w, req)
 	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, CodeNoMatchingGroup, decodeProblem(t, w).Code)
 }
 
 func TestGenericHandler_MissingCloudConfig(t *testing.T) {
@@ -66,6 +122,25 @@ func TestGenericHandler_MissingCloudConfig(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
+func TestGenericHandler_MissingTemplate(t *testing.T) {
+	store := &fake.FixedStore{
+		Profiles: map[string]*storagepb.Profile{fake.Group.Profile: fake.Profile},
+	}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	c := server.NewServer(&server.Config{Store: store})
+	h := srv.genericHandler(c)
+	ctx := withGroup(context.Background(), fake.Group)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	h.ServeHTTP(ctx, w, req)
+	// assert that a missing template is a template_not_found problem
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	problem := decodeProblem(t, w)
+	assert.Equal(t, CodeTemplateNotFound, problem.Code)
+	assert.Equal(t, `generic template "generic.tmpl" not found`, problem.Detail)
+}
+
 func TestGenericHandler_MissingTemplateMetadata(t *testing.T) {
 	content := `#foo-bar-baz template
 KEY={{.missing_key}}
@@ -0,0 +1,15 @@
+package http
+
+import (
+	"net/http"
+)
+
+// gitopsStatusHandler returns a handler which reports the state of GitOps
+// reconciliation: the applied revision and the result of the last sync.
+func (s *Server) gitopsStatusHandler() http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		status := s.gitops.Status()
+		s.renderJSON(w, &status)
+	}
+	return http.HandlerFunc(fn)
+}
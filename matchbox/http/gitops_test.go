@@ -0,0 +1,42 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/gitops"
+)
+
+func TestGitOpsStatusHandler(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	reconciler := gitops.NewReconciler(&gitops.Config{
+		Repository: "https://git.example.com/infra.git",
+		Ref:        "main",
+		Logger:     logger,
+	})
+	srv := NewServer(&Config{Logger: logger, GitOps: reconciler})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/gitops/status", nil)
+	srv.HTTPHandler().ServeHTTP(w, req)
+	// assert that:
+	// - the status of reconciliation is rendered as JSON
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, jsonContentType, w.HeaderMap.Get(contentType))
+	status := new(gitops.Status)
+	if assert.Nil(t, json.Unmarshal(w.Body.Bytes(), status)) {
+		assert.Equal(t, "https://git.example.com/infra.git", status.Repository)
+		assert.Equal(t, "main", status.Ref)
+		assert.False(t, status.Synced)
+	}
+
+	// assert that the endpoint is absent without GitOps
+	srv = NewServer(&Config{Logger: logger})
+	w = httptest.NewRecorder()
+	srv.HTTPHandler().ServeHTTP(w, req)
+	assert.NotEqual(t, http.StatusOK, w.Code)
+}
@@ -3,22 +3,92 @@ package http
 import (
 	"bytes"
 	"net/http"
+	"regexp"
+	"strings"
 	"text/template"
 
 	"context"
 	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// grubArchLabel is the label of a GRUB client's target (e.g. x86_64-efi,
+// arm64-efi, or i386-pc), set by /grub/{arch}/grub.cfg requests.
+const grubArchLabel = "arch"
+
+// grubArchRegexp matches GRUB targets, a CPU and a platform.
+var grubArchRegexp = regexp.MustCompile(`^[a-z0-9_]+-[a-z0-9]+$`)
+
+// grubLoader names the GRUB commands which load the kernel and initrd.
+type grubLoader struct {
+	Linux, Initrd string
+}
+
+// grubLoaders are the loaders of GRUB targets. linuxefi and initrdefi only
+// exist in x86 EFI builds of GRUB, so other targets use linux and initrd.
+var (
+	grubEFILoader     = grubLoader{Linux: "linuxefi", Initrd: "initrdefi"}
+	grubGenericLoader = grubLoader{Linux: "linux", Initrd: "initrd"}
+	grubLoaders       = map[string]grubLoader{
+		"x86_64-efi": grubEFILoader,
+		"i386-efi":   grubEFILoader,
+	}
 )
 
+// grubLoaderFor returns the loader of a GRUB target. Clients which don't
+// give a target get the x86 EFI loader, as before targets were supported.
+func grubLoaderFor(arch string) grubLoader {
+	if arch == "" {
+		return grubEFILoader
+	}
+	if loader, ok := grubLoaders[arch]; ok {
+		return loader
+	}
+	return grubGenericLoader
+}
+
+// grubConfig is a NetBoot config rendered with a GRUB target's loader.
+type grubConfig struct {
+	*storagepb.NetBoot
+	Loader grubLoader
+}
+
 var grubTemplate = template.Must(template.New("GRUB2 config").Parse(`default=0
 timeout=1
 menuentry "CoreOS" {
 echo "Loading kernel"
-linuxefi "{{.Kernel}}"{{range $key, $value := .Cmdline}} {{if $value}}"{{$key}}={{$value}}"{{else}}"{{$key}}"{{end}}{{end}}
+{{.Loader.Linux}} "{{.Kernel}}"{{range $arg := .Args}} "{{$arg}}"{{end}}{{range $key, $value := .Cmdline}} {{if $value}}"{{$key}}={{$value}}"{{else}}"{{$key}}"{{end}}{{end}}
 echo "Loading initrd"
-initrdefi {{ range $element := .Initrd }}"{{$element}}" {{end}}
+{{.Loader.Initrd}} {{ range $element := .Initrd }}"{{$element}}" {{end}}
 }
 `))
 
+// grubArchPath serves /grub/{arch}/grub.cfg (and its signatures) as /grub
+// with the arch label, so GRUB builds for each target can load a config
+// from their own path.
+func grubArchPath(mux http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(req.URL.Path, "/grub/"), "/")
+		var ext string
+		if len(parts) == 2 {
+			ext = strings.TrimPrefix(parts[1], "grub.cfg")
+		}
+		if len(parts) != 2 || !grubArchRegexp.MatchString(parts[0]) || ext == parts[1] || (ext != "" && ext != ".sig" && ext != ".asc") {
+			writeProblem(w, http.StatusNotFound, CodeNotFound, "GRUB configs are served at /grub/{arch}/grub.cfg")
+			return
+		}
+		archReq := req.Clone(req.Context())
+		archReq.URL.Path = "/grub" + ext
+		query := archReq.URL.Query()
+		query.Set(grubArchLabel, parts[0])
+		archReq.URL.RawQuery = query.Encode()
+		archReq.RequestURI = archReq.URL.RequestURI()
+		mux.ServeHTTP(w, archReq)
+	}
+	return http.HandlerFunc(fn)
+}
+
 // grubHandler returns a handler which renders a GRUB2 config for the
 // requester.
 func (s *Server) grubHandler() ContextHandler {
@@ -26,23 +96,30 @@ func (s *Server) grubHandler() ContextHandler {
 		profile, err := profileFromContext(ctx)
 		if err != nil {
 			s.logger.WithFields(logrus.Fields{
-				"labels": labelsFromRequest(nil, req),
+				"labels": s.redactor.Labels(labelsFromRequest(nil, req)),
 			}).Infof("No matching profile")
-			http.NotFound(w, req)
+			writeProblem(w, http.StatusNotFound, noProfileCode(ctx), "no profile matches the machine")
 			return
 		}
 
 		// match was successful
 		s.logger.WithFields(logrus.Fields{
-			"labels":  labelsFromRequest(nil, req),
+			"labels":  s.redactor.Labels(labelsFromRequest(nil, req)),
 			"profile": profile.Id,
 		}).Debug("Matched a GRUB config")
+		profileBoots.Inc(profile.Id)
+		setProfileHeaders(w, profile)
 
+		boot, err := s.bootFromContext(ctx, w, req, profile)
+		if err != nil {
+			return
+		}
 		var buf bytes.Buffer
-		err = grubTemplate.Execute(&buf, profile.Boot)
+		loader := grubLoaderFor(req.URL.Query().Get(grubArchLabel))
+		err = grubTemplate.Execute(&buf, &grubConfig{NetBoot: boot, Loader: loader})
 		if err != nil {
 			s.logger.Errorf("error rendering template: %v", err)
-			http.NotFound(w, req)
+			writeProblem(w, http.StatusNotFound, CodeTemplateError, "error rendering GRUB config")
 			return
 		}
 		if _, err := buf.WriteTo(w); err != nil {
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"context"
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestGrubHandler(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	h := srv.grubHandler()
+	ctx := withProfile(context.Background(), fake.Profile)
+	cases := []struct {
+		url, linux, initrd string
+	}{
+		{"/grub", "linuxefi", "initrdefi"},
+		{"/grub?arch=x86_64-efi", "linuxefi", "initrdefi"},
+		{"/grub?arch=arm64-efi", "linux", "initrd"},
+		{"/grub?arch=i386-pc", "linux", "initrd"},
+	}
+	// assert that:
+	// - x86 EFI targets (and clients without a target) use linuxefi
+	// - other targets use the linux and initrd commands
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", c.url, nil)
+		h.ServeHTTP(ctx, w, req)
+		expected := `default=0
+timeout=1
+menuentry "CoreOS" {
+echo "Loading kernel"
+` + c.linux + ` "/image/kernel" "a=b" "c"
+echo "Loading initrd"
+` + c.initrd + ` "/image/initrd_a" "/image/initrd_b" 
+}
+`
+		assert.Equal(t, http.StatusOK, w.Code, c.url)
+		assert.Equal(t, expected, w.Body.String(), c.url)
+	}
+}
+
+func TestGrubArchPath(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups[fake.Group.Id] = fake.Group
+	store.Profiles[fake.Profile.Id] = fake.Profile
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:   server.NewServer(&server.Config{Store: store}),
+		Logger: logger,
+	})
+	h := srv.HTTPHandler()
+
+	// assert that:
+	// - /grub/{arch}/grub.cfg is the GRUB config for the target
+	// - other paths under /grub/ are not found
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/grub/arm64-efi/grub.cfg?uuid=a1b2c3d4&mac=52-54-00-89-d8-10", nil)
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "\nlinux \"/image/kernel\"")
+
+	for _, path := range []string{"/grub/arm64-efi/", "/grub/arm64-efi/grub.cfg.bak", "/grub/ARM64/grub.cfg", "/grub/arm64-efi/x/grub.cfg"} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", path, nil)
+		h.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code, path)
+		assert.Equal(t, CodeNotFound, decodeProblem(t, w).Code, path)
+	}
+}
@@ -6,8 +6,12 @@ import (
 
 	"context"
 
+	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/deprecation"
 	"github.com/coreos/matchbox/matchbox/server"
 	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
 )
 
 // homeHandler shows the server name for rooted requests. Otherwise, a 404 is
@@ -15,7 +19,7 @@ import (
 func homeHandler() http.Handler {
 	fn := func(w http.ResponseWriter, req *http.Request) {
 		if req.URL.Path != "/" {
-			http.NotFound(w, req)
+			writeProblem(w, http.StatusNotFound, CodeNotFound, "")
 			return
 		}
 		fmt.Fprintf(w, "matchbox\n")
@@ -23,45 +27,102 @@ func homeHandler() http.Handler {
 	return http.HandlerFunc(fn)
 }
 
-// logRequest logs HTTP requests.
-func (s *Server) logRequest(next http.Handler) http.Handler {
-	fn := func(w http.ResponseWriter, req *http.Request) {
-		s.logger.Infof("HTTP %s %v", req.Method, req.URL)
-		next.ServeHTTP(w, req)
-	}
-	return http.HandlerFunc(fn)
-}
-
 // selectGroup selects the Group whose selectors match the query parameters,
-// adds the Group to the ctx, and calls the next handler. The next handler
-// should handle a missing Group.
+// the client's address, and the machine's facts, adds the Group and labels to the ctx, and calls
+// the next handler. The next handler should handle a missing Group.
 func (s *Server) selectGroup(core server.Server, next ContextHandler) ContextHandler {
 	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
-		attrs := labelsFromRequest(s.logger, req)
+		attrs := core.MachineLabels(ctx, matchLabels(s.logger, req))
+		attrs = s.enroll(ctx, core, attrs)
+		ctx = withLabels(ctx, attrs)
+		if wipeRequested(ctx, core, attrs) {
+			ctx = withWipe(ctx)
+		}
 		// match machine request
 		group, err := core.SelectGroup(ctx, &pb.SelectGroupRequest{Labels: attrs})
+		groupMatches.Inc(matchResult(err))
+		s.countMatch(req, attrs, group, err)
 		if err == nil {
-			// add the Group to the ctx for next handler
+			s.warn(w, deprecation.Group(group))
+			if group, err = s.mergeMetadata(ctx, w, group, attrs); err != nil {
+				return
+			}
+			// add the Group to the ctx for next handler, which is served
+			// the Profile version the Group pins
 			ctx = withGroup(ctx, group)
+			ctx = server.WithProfileVersion(ctx, group)
+			requestInfoFromContext(ctx).group = group.Id
 		}
 		next.ServeHTTP(ctx, w, req)
 	}
 	return ContextHandlerFunc(fn)
 }
 
-// selectProfile selects the Profile for the given query parameters, adds the
-// Profile to the ctx, and calls the next handler. The next handler should
-// handle a missing profile.
+// selectProfile selects the Profile for the given query parameters, the
+// client's address, and the machine's facts, adds the Profile to the ctx, and calls the next handler.
+// If the Profile's boot images or kernel args are templates, the Group and labels are added
+// too. The next handler should handle a missing profile.
 func (s *Server) selectProfile(core server.Server, next ContextHandler) ContextHandler {
 	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
-		attrs := labelsFromRequest(s.logger, req)
-		// match machine request
-		profile, err := core.SelectProfile(ctx, &pb.SelectProfileRequest{Labels: attrs})
+		attrs := core.MachineLabels(ctx, matchLabels(s.logger, req))
+		attrs = s.enroll(ctx, core, attrs)
+		// match machine request, then lookup the Profile at the version the
+		// Group pins
+		var profile *storagepb.Profile
+		group, err := core.SelectGroup(ctx, &pb.SelectGroupRequest{Labels: attrs})
+		s.countMatch(req, attrs, group, err)
 		if err == nil {
+			requestInfoFromContext(ctx).group = group.Id
+			profile, err = core.ProfileGet(server.WithProfileVersion(ctx, group), &pb.ProfileGetRequest{Id: group.Profile})
+		}
+		groupMatches.Inc(matchResult(err))
+		if err == nil {
+			s.warn(w, append(deprecation.Group(group), deprecation.Profile(profile)...))
+			// templated boot images and kernel args are rendered with the Group's metadata
+			if templatedBoot(profile.Boot) {
+				if group, err = s.mergeMetadata(ctx, w, group, attrs); err != nil {
+					return
+				}
+				ctx = withLabels(ctx, attrs)
+				ctx = withGroup(ctx, group)
+			}
 			// add the Profile to the ctx for the next handler
 			ctx = withProfile(ctx, profile)
+			requestInfoFromContext(ctx).profile = profile.Id
 		}
 		next.ServeHTTP(ctx, w, req)
 	}
 	return ContextHandlerFunc(fn)
 }
+
+// mergeMetadata merges the default metadata beneath the Group's metadata,
+// merges metadata from its external sources, and decrypts its encrypted
+// metadata values. If any fails, it responds with an error and returns it.
+func (s *Server) mergeMetadata(ctx context.Context, w http.ResponseWriter, group *storagepb.Group, attrs map[string]string) (*storagepb.Group, error) {
+	group, err := s.defaults.Merge(group)
+	if err != nil {
+		s.logger.Errorf("error merging default metadata: %v", err)
+		writeProblem(w, http.StatusInternalServerError, CodeInvalidMetadata, "error merging default metadata")
+		return nil, err
+	}
+	if group, err = s.sources.Merge(ctx, group, attrs); err != nil {
+		s.logger.Errorf("error fetching metadata sources: %v", err)
+		writeProblem(w, http.StatusBadGateway, CodeMetadataSource, "error fetching metadata sources")
+		return nil, err
+	}
+	if group, err = s.sealed.Unseal(ctx, group); err != nil {
+		s.logger.Errorf("error decrypting metadata: %v", err)
+		writeProblem(w, http.StatusInternalServerError, CodeDecryptionFailed, "error decrypting metadata")
+		return nil, err
+	}
+	return group, nil
+}
+
+// metadataFailed responds that the metadata of a Group doesn't satisfy the
+// metadata schema of its Profile, so a config can't be rendered.
+func (s *Server) metadataFailed(w http.ResponseWriter, config string, err error) {
+	s.logger.WithFields(logrus.Fields{
+		"config": config,
+	}).Errorf("error validating metadata: %v", err)
+	writeProblem(w, http.StatusInternalServerError, CodeInvalidMetadata, err.Error())
+}
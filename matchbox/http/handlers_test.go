@@ -10,9 +10,12 @@ import (
 	logtest "github.com/Sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/coreos/matchbox/matchbox/defaults"
+	"github.com/coreos/matchbox/matchbox/sealed"
 	"github.com/coreos/matchbox/matchbox/server"
 	"github.com/coreos/matchbox/matchbox/storage/storagepb"
 	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+	"github.com/coreos/matchbox/matchbox/vault"
 )
 
 func TestSelectGroup(t *testing.T) {
@@ -39,6 +42,184 @@ func TestSelectGroup(t *testing.T) {
 	assert.Equal(t, "next handler called", w.Body.String())
 }
 
+func TestSelectGroup_SourceAddress(t *testing.T) {
+	site := &storagepb.Group{Id: "site", Profile: fake.Profile.Id, Selector: map[string]string{"source_ip": "2001:db8::/32"}}
+	store := &fake.FixedStore{
+		Groups: map[string]*storagepb.Group{site.Id: site},
+	}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	c := server.NewServer(&server.Config{Store: store})
+	next := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		group, err := groupFromContext(ctx)
+		if err != nil {
+			http.NotFound(w, req)
+			return
+		}
+		fmt.Fprintf(w, "%s %s", group.Id, labelsFromContext(ctx)["source_ip"])
+	}
+	h := srv.selectGroup(c, ContextHandlerFunc(next))
+	// assert that:
+	// - Groups select clients by an IPv6 CIDR of their source address
+	// - the source address is added to the labels of the context
+	// - clients outside the network don't match
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "?uuid=a1b2c3d4", nil)
+	req.RemoteAddr = "[2001:db8:1::5]:4567"
+	h.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, "site 2001:db8:1::5", w.Body.String())
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "?uuid=a1b2c3d4&source_ip=2001:db8::5", nil)
+	req.RemoteAddr = "10.1.2.3:4567"
+	h.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestSelectGroup_MetadataSources(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/nodes/a1b2c3d4" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"rack": "r1"}`))
+	}))
+	defer source.Close()
+	store := &fake.FixedStore{
+		Groups: map[string]*storagepb.Group{
+			"node": {
+				Id:              "node",
+				Profile:         "worker",
+				Metadata:        []byte(`{"pod":"p1"}`),
+				MetadataSources: []*storagepb.MetadataSource{{Url: source.URL + "/nodes/{{.uuid}}"}},
+			},
+		},
+	}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	c := server.NewServer(&server.Config{Store: store})
+	next := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		group, err := groupFromContext(ctx)
+		assert.Nil(t, err)
+		fmt.Fprintf(w, "%s", group.Metadata)
+	}
+	h := srv.selectGroup(c, ContextHandlerFunc(next))
+	// assert that:
+	// - source metadata is merged into the Group in the context
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "?uuid=a1b2c3d4", nil)
+	h.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"pod": "p1", "rack": "r1"}`, w.Body.String())
+	// - stored Group metadata is unchanged
+	assert.Equal(t, `{"pod":"p1"}`, string(store.Groups["node"].Metadata))
+
+	// assert that:
+	// - source failures are 502 Bad Gateway
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "?uuid=e5f6a7b8", nil)
+	h.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+}
+
+func TestSelectGroup_MetadataDefaults(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"ntp": "source"}`))
+	}))
+	defer source.Close()
+	store := &fake.FixedStore{
+		Groups: map[string]*storagepb.Group{
+			"node": {
+				Id:              "node",
+				Profile:         "worker",
+				Metadata:        []byte(`{"pod":"p1","proxy":{"no_proxy":"10.0.0.0/8"}}`),
+				MetadataSources: []*storagepb.MetadataSource{{Url: source.URL}},
+			},
+		},
+	}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Logger: logger,
+		Defaults: defaults.New(map[string]interface{}{
+			"ntp":   "default",
+			"pod":   "default",
+			"proxy": map[string]interface{}{"http": "http://proxy:3128"},
+		}),
+	})
+	c := server.NewServer(&server.Config{Store: store})
+	next := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		group, err := groupFromContext(ctx)
+		assert.Nil(t, err)
+		fmt.Fprintf(w, "%s", group.Metadata)
+	}
+	h := srv.selectGroup(c, ContextHandlerFunc(next))
+	// assert that:
+	// - default metadata is merged beneath Group and source metadata
+	// - stored Group metadata is unchanged
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "?uuid=a1b2c3d4", nil)
+	h.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"ntp": "source", "pod": "p1", "proxy": {"http": "http://proxy:3128", "no_proxy": "10.0.0.0/8"}}`, w.Body.String())
+	assert.Equal(t, `{"pod":"p1","proxy":{"no_proxy":"10.0.0.0/8"}}`, string(store.Groups["node"].Metadata))
+}
+
+func TestSelectGroup_Sealed(t *testing.T) {
+	kms := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v1/transit/decrypt/matchbox" {
+			http.Error(w, `{"errors":["no key"]}`, http.StatusBadRequest)
+			return
+		}
+		// base64 of "s3cret"
+		w.Write([]byte(`{"data": {"plaintext": "czNjcmV0"}}`))
+	}))
+	defer kms.Close()
+	store := &fake.FixedStore{
+		Groups: map[string]*storagepb.Group{
+			"node": {
+				Id:       "node",
+				Profile:  "worker",
+				Selector: map[string]string{"uuid": "a1b2c3d4"},
+				Metadata: []byte(`{"password":"transit:matchbox:vault:v1:abcd"}`),
+			},
+			"other": {
+				Id:       "other",
+				Profile:  "worker",
+				Selector: map[string]string{"uuid": "e5f6a7b8"},
+				Metadata: []byte(`{"password":"transit:missing:vault:v1:abcd"}`),
+			},
+		},
+	}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Logger: logger,
+		Sealed: sealed.NewDecrypter(&sealed.Config{
+			Transit: vault.NewTransit(&vault.TransitConfig{Address: kms.URL}),
+		}),
+	})
+	c := server.NewServer(&server.Config{Store: store})
+	next := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		group, err := groupFromContext(ctx)
+		assert.Nil(t, err)
+		fmt.Fprintf(w, "%s", group.Metadata)
+	}
+	h := srv.selectGroup(c, ContextHandlerFunc(next))
+	// assert that:
+	// - encrypted metadata values are decrypted for rendering
+	// - stored Group metadata is unchanged
+	// - decryption failures are 500 Internal Server Error
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "?uuid=a1b2c3d4", nil)
+	h.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"password": "s3cret"}`, w.Body.String())
+	assert.Equal(t, `{"password":"transit:matchbox:vault:v1:abcd"}`, string(store.Groups["node"].Metadata))
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "?uuid=e5f6a7b8", nil)
+	h.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
 func TestSelectProfile(t *testing.T) {
 	store := &fake.FixedStore{
 		Groups:   map[string]*storagepb.Group{fake.Group.Id: fake.Group},
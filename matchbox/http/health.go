@@ -0,0 +1,141 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/sign"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// Readiness check names
+const (
+	checkStore     = "store"
+	checkTemplates = "templates"
+	checkSigning   = "signing"
+	checkShutdown  = "shutdown"
+)
+
+// healthResponse reports the result of each check.
+type healthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// healthzHandler returns a handler which reports the server is alive.
+func (s *Server) healthzHandler() http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		s.renderJSON(w, &healthResponse{Status: "ok"})
+	}
+	return http.HandlerFunc(fn)
+}
+
+// readyzHandler returns a handler which reports whether the server can
+// serve machines: it isn't shutting down, the store is reachable, templates
+// referenced by Profiles parse, and the signers can sign. Failures respond
+// 503 Service Unavailable so load balancers stop sending traffic to the
+// instance.
+func (s *Server) readyzHandler(core server.Server) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		if s.isDraining() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			s.renderJSON(w, &healthResponse{
+				Status: "unavailable",
+				Checks: map[string]string{checkShutdown: "draining connections"},
+			})
+			return
+		}
+		ctx := req.Context()
+		errs := map[string]error{
+			checkStore:     nil,
+			checkTemplates: nil,
+		}
+		profiles, err := core.ProfileList(ctx, &pb.ProfileListRequest{})
+		if err == nil {
+			_, err = core.GroupList(ctx, &pb.GroupListRequest{})
+		}
+		if err != nil {
+			errs[checkStore] = err
+			errs[checkTemplates] = fmt.Errorf("store unavailable")
+		} else {
+			errs[checkTemplates] = s.checkTemplates(ctx, core, profiles)
+		}
+		if s.signer != nil || s.armoredSigner != nil {
+			errs[checkSigning] = checkSigners(s.signer, s.armoredSigner)
+		}
+
+		resp := &healthResponse{Status: "ok", Checks: make(map[string]string)}
+		for name, err := range errs {
+			if err != nil {
+				resp.Status = "unavailable"
+				resp.Checks[name] = err.Error()
+			} else {
+				resp.Checks[name] = "ok"
+			}
+		}
+		if resp.Status != "ok" {
+			s.logger.Warningf("readiness check failed: %v", resp.Checks)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		s.renderJSON(w, resp)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// checkTemplates parses the Ignition, Cloud-Config, and generic templates
+// referenced by Profiles.
+func (s *Server) checkTemplates(ctx context.Context, core server.Server, profiles []*storagepb.Profile) error {
+	// include and kubeadmToken are only resolved when rendering
+	funcs := s.extensionFuncs()
+	funcs["include"] = func(string, interface{}) (string, error) { return "", nil }
+	funcs["kubeadmToken"] = func() (string, error) { return "", nil }
+	var failed []string
+	check := func(kind, name string, get func(context.Context, string) (string, error)) {
+		if name == "" {
+			return
+		}
+		contents, err := get(ctx, name)
+		if err == nil {
+			_, err = template.New(name).Funcs(funcs).Parse(contents)
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s %s: %v", kind, name, err))
+		}
+	}
+	for _, profile := range profiles {
+		if !isIgnition(profile.IgnitionId) && !storagepb.IsIgnitionURL(profile.IgnitionId) {
+			check("ignition", profile.IgnitionId, core.IgnitionGet)
+		}
+		for _, snippet := range profile.IgnitionSnippets {
+			if !isIgnition(snippet) {
+				check("ignition", snippet, core.IgnitionGet)
+			}
+		}
+		check("cloud", profile.CloudId, core.CloudGet)
+		check("generic", profile.GenericId, core.GenericGet)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// checkSigners verifies the signers can sign a message.
+func checkSigners(signers ...sign.Signer) error {
+	for _, signer := range signers {
+		if signer == nil {
+			continue
+		}
+		if err := signer.Sign(ioutil.Discard, bytes.NewReader([]byte("readyz"))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
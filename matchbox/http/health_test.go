@@ -0,0 +1,83 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+// brokenSigner fails to sign.
+type brokenSigner struct{}
+
+func (brokenSigner) Sign(w io.Writer, message io.Reader) error {
+	return errors.New("key not loaded")
+}
+
+func readyz(t *testing.T, srv *Server) (int, *healthResponse) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+	srv.HTTPHandler().ServeHTTP(w, req)
+	resp := new(healthResponse)
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), resp))
+	return w.Code, resp
+}
+
+func TestHealthz(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	srv.HTTPHandler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"status":"ok"}`, w.Body.String())
+}
+
+func TestReadyz(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Profiles[fake.Profile.Id] = fake.Profile
+	store.IgnitionConfigs[fake.Profile.IgnitionId] = `{{ include "base.tmpl" . }}`
+	store.CloudConfigs[fake.Profile.CloudId] = "#cloud-config"
+	store.GenericConfigs[fake.Profile.GenericId] = "UUID={{.uuid}}"
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:   server.NewServer(&server.Config{Store: store}),
+		Logger: logger,
+	})
+	code, resp := readyz(t, srv)
+	// assert that:
+	// - the server is ready if the store is reachable and templates parse
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", resp.Status)
+	assert.Equal(t, map[string]string{"store": "ok", "templates": "ok"}, resp.Checks)
+
+	// - templates which don't parse make the server unavailable
+	store.GenericConfigs[fake.Profile.GenericId] = "UUID={{.uuid"
+	code, resp = readyz(t, srv)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, resp.Checks["templates"], "generic generic.tmpl")
+}
+
+func TestReadyz_Unavailable(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:   server.NewServer(&server.Config{Store: &fake.BrokenStore{}}),
+		Logger: logger,
+		Signer: brokenSigner{},
+	})
+	code, resp := readyz(t, srv)
+	// assert that:
+	// - store and signing failures make the server unavailable
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unavailable", resp.Status)
+	assert.NotEqual(t, "ok", resp.Checks["store"])
+	assert.Equal(t, "key not loaded", resp.Checks["signing"])
+}
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// the client preface remaining after its request line and headers
+const prefaceBody = "SM\r\n\r\n"
+
+// configureHTTP2 serves HTTP/2 on a server, negotiated via ALPN over TLS and
+// with prior knowledge (h2c) over cleartext.
+func configureHTTP2(srv *http.Server) error {
+	h2 := new(http2.Server)
+	if err := http2.ConfigureServer(srv, h2); err != nil {
+		return err
+	}
+	// the server's read and write deadlines are set before the TLS handshake
+	// and would close long lived HTTP/2 connections, clear them
+	for proto, serve := range srv.TLSNextProto {
+		serve := serve
+		srv.TLSNextProto[proto] = func(hs *http.Server, conn *tls.Conn, handler http.Handler) {
+			conn.SetDeadline(time.Time{})
+			serve(hs, conn, handler)
+		}
+	}
+	srv.Handler = h2c(srv, h2, srv.Handler)
+	return nil
+}
+
+// h2c returns a handler which serves HTTP/2 connections over cleartext which
+// begin with the client preface (prior knowledge), and passes other requests
+// to the next handler.
+func h2c(srv *http.Server, h2 *http2.Server, next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		if req.TLS != nil || req.Method != "PRI" || req.URL.Path != "*" || req.Proto != "HTTP/2.0" {
+			next.ServeHTTP(w, req)
+			return
+		}
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			http.Error(w, "h2c is not supported", http.StatusHTTPVersionNotSupported)
+			return
+		}
+		conn, rw, err := hijacker.Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// the preface's request line and headers were read as a request
+		body := make([]byte, len(prefaceBody))
+		if _, err := io.ReadFull(rw, body); err != nil || string(body) != prefaceBody {
+			return
+		}
+		conn.SetDeadline(time.Time{})
+		h2.ServeConn(&prefaceConn{
+			Conn:   conn,
+			reader: io.MultiReader(strings.NewReader(http2.ClientPreface), rw),
+		}, &http2.ServeConnOpts{
+			Handler:    next,
+			BaseConfig: srv,
+		})
+	}
+	return http.HandlerFunc(fn)
+}
+
+// prefaceConn is a connection which replays the client preface and any
+// buffered reads before reading from the connection.
+type prefaceConn struct {
+	net.Conn
+	reader io.Reader
+}
+
+func (c *prefaceConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
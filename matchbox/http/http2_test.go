@@ -0,0 +1,76 @@
+package http
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+)
+
+func TestConfigureServerHTTP2(t *testing.T) {
+	proto := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, req.Proto)
+	})
+	// speak HTTP/2 with prior knowledge over cleartext
+	h2c := &http.Client{Transport: &http2.Transport{
+		DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+	h2 := &http.Client{Transport: &http2.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}
+	get := func(client *http.Client, url string) (string, error) {
+		resp, err := client.Get(url)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	// assert that:
+	// - HTTP/2 is served with prior knowledge over cleartext
+	// - HTTP/1.1 is still served over cleartext
+	ts := httptest.NewUnstartedServer(proto)
+	assert.Nil(t, ConfigureServer(ts.Config, &Timeouts{}, true))
+	ts.Start()
+	body, err := get(h2c, "https://"+ts.Listener.Addr().String())
+	assert.Nil(t, err)
+	assert.Equal(t, "HTTP/2.0", body)
+	body, err = get(http.DefaultClient, ts.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, "HTTP/1.1", body)
+	ts.Close()
+
+	// - HTTP/2 is negotiated over TLS
+	ts = httptest.NewUnstartedServer(proto)
+	assert.Nil(t, ConfigureServer(ts.Config, &Timeouts{}, true))
+	ts.TLS = ts.Config.TLSConfig
+	ts.StartTLS()
+	body, err = get(h2, ts.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, "HTTP/2.0", body)
+	ts.Close()
+
+	// - HTTP/2 is not served if disabled
+	ts = httptest.NewUnstartedServer(proto)
+	assert.Nil(t, ConfigureServer(ts.Config, &Timeouts{}, false))
+	ts.StartTLS()
+	_, err = get(h2, ts.URL)
+	assert.NotNil(t, err)
+	ts.Close()
+	ts = httptest.NewUnstartedServer(proto)
+	assert.Nil(t, ConfigureServer(ts.Config, &Timeouts{}, false))
+	ts.Start()
+	_, err = get(h2c, "https://"+ts.Listener.Addr().String())
+	assert.NotNil(t, err)
+	ts.Close()
+}
@@ -3,8 +3,11 @@ package http
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	fuze "github.com/coreos/fuze/config"
@@ -12,53 +15,87 @@ import (
 
 	"github.com/coreos/matchbox/matchbox/server"
 	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
 )
 
 // ignitionHandler returns a handler that responds with the Ignition config
 // matching the request. The Ignition file referenced in the Profile is parsed
 // as raw Ignition (for .ign/.ignition) or rendered to a Fuze config (YAML)
-// and converted to Ignition. Ignition configs are served as HTTP JSON
+// and converted to Ignition. Profiles may instead reference a remote
+// Ignition config by its https URL. Ignition configs are served as HTTP JSON
 // responses.
 func (s *Server) ignitionHandler(core server.Server) ContextHandler {
 	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
 		group, err := groupFromContext(ctx)
 		if err != nil {
 			s.logger.WithFields(logrus.Fields{
-				"labels": labelsFromRequest(nil, req),
+				"labels": s.redactor.Labels(labelsFromRequest(nil, req)),
 			}).Infof("No matching group")
-			http.NotFound(w, req)
+			writeProblem(w, http.StatusNotFound, CodeNoMatchingGroup, "no group matches the machine")
 			return
 		}
 
 		profile, err := core.ProfileGet(ctx, &pb.ProfileGetRequest{Id: group.Profile})
 		if err != nil {
 			s.logger.WithFields(logrus.Fields{
-				"labels":     labelsFromRequest(nil, req),
+				"labels":     s.redactor.Labels(labelsFromRequest(nil, req)),
 				"group":      group.Id,
 				"group_name": group.Name,
 			}).Infof("No profile named: %s", group.Profile)
-			http.NotFound(w, req)
+			writeProblem(w, http.StatusNotFound, CodeProfileNotFound, fmt.Sprintf("profile %q not found", group.Profile))
+			return
+		}
+
+		if err := profile.ValidateMetadata(group); err != nil {
+			s.metadataFailed(w, "ignition", err)
+			return
+		}
+
+		setProfileHeaders(w, profile)
+
+		if storagepb.IsIgnitionURL(profile.IgnitionId) {
+			s.logger.WithFields(logrus.Fields{
+				"labels":  s.redactor.Labels(labelsFromRequest(nil, req)),
+				"group":   group.Id,
+				"profile": profile.Id,
+			}).Debug("Matched a remote Ignition config")
+			requestInfoFromContext(ctx).profile = profile.Id
+			s.serveRemoteIgnition(ctx, core, w, req, group, profile)
 			return
 		}
 
 		contents, err := core.IgnitionGet(ctx, profile.IgnitionId)
 		if err != nil {
 			s.logger.WithFields(logrus.Fields{
-				"labels":     labelsFromRequest(nil, req),
+				"labels":     s.redactor.Labels(labelsFromRequest(nil, req)),
 				"group":      group.Id,
 				"group_name": group.Name,
 				"profile":    group.Profile,
 			}).Infof("No Ignition or Fuze template named: %s", profile.IgnitionId)
-			http.NotFound(w, req)
+			writeProblem(w, http.StatusNotFound, CodeTemplateNotFound, fmt.Sprintf("Ignition or Fuze template %q not found", profile.IgnitionId))
 			return
 		}
 
 		// match was successful
 		s.logger.WithFields(logrus.Fields{
-			"labels":  labelsFromRequest(nil, req),
+			"labels":  s.redactor.Labels(labelsFromRequest(nil, req)),
 			"group":   group.Id,
 			"profile": profile.Id,
 		}).Debug("Matched an Ignition or Fuze template")
+		requestInfoFromContext(ctx).profile = profile.Id
+
+		// each render mints a new bootstrap token, so no signature matches
+		// the config a machine was served
+		if isSignature(ctx) && mintsTokens(contents) {
+			writeProblem(w, http.StatusNotFound, CodeNotFound, "Ignition configs which mint bootstrap tokens aren't signed")
+			return
+		}
+
+		// conditional requests skip rendering unchanged configs, unless the
+		// template includes others which may have changed or mints tokens
+		if !usesIncludes(contents) && !mintsTokens(contents) && notModified(w, req, renderETag(profile, group, contents, req)) {
+			return
+		}
 
 		// Skip rendering if raw Ignition JSON is provided
 		if isIgnition(profile.IgnitionId) {
@@ -72,46 +109,95 @@ func (s *Server) ignitionHandler(core server.Server) ContextHandler {
 
 		// Fuze Config template
 
-		// collect data for rendering
-		data, err := collectVariables(req, group)
-		if err != nil {
-			s.logger.Errorf("error collecting variables: %v", err)
-			http.NotFound(w, req)
-			return
+		// reuse the config rendered for identical machines, if cached
+		var cacheKey string
+		var generation uint64
+		cacheable := s.renderCache.enabled() && !s.mayMintTokens(contents)
+		if cacheable {
+			cacheKey = renderKey(ctx, "ignition", profile, group, contents, req)
+			generation = core.Generation(ctx)
+			js, ok := s.renderCache.get(cacheKey, generation)
+			renderCacheRequests.Inc("ignition", cacheResult(ok))
+			if ok {
+				s.writeIgnition(w, req, contents, js)
+				return
+			}
 		}
 
-		// render the template for an Ignition config with data
-		var buf bytes.Buffer
-		funcs := s.templateFuncMap(ctx, core)
-		err = s.renderTemplateWithFuncMap(&buf, funcs, data, contents)
-		if err != nil {
-			http.NotFound(w, req)
-			return
+		// render the template for an Ignition config with data, coalescing
+		// identical concurrent renders (e.g. during a boot storm)
+		start := time.Now()
+		key := renderETag(profile, group, contents, req)
+		if isSignature(ctx) {
+			// renders to sign don't mint tokens, so aren't shared with
+			// renders which may
+			key += ".sig"
 		}
-
-		// Parse bytes into a Fuze Config
-		config, report := fuze.Parse(buf.Bytes())
-		if report.IsFatal() {
-			s.logger.Errorf("error parsing Fuze config: %s", report.String())
-			http.NotFound(w, req)
-			return
+		value, err, shared := s.renders.Do(key, func() (interface{}, error) {
+			return s.renderIgnition(ctx, core, req, group, contents)
+		})
+		s.observeRender(ctx, "ignition", profile.IgnitionId, start)
+		if shared {
+			coalescedRequests.Inc("/ignition")
 		}
-
-		// Convert Fuze Config into an Ignition Config
-		ign, report := fuze.ConvertAs2_0_0(config)
-		if report.IsFatal() {
-			s.logger.Errorf("error converting Fuze config: %s", report.String())
-			http.NotFound(w, req)
+		if rerr, ok := err.(*reportError); ok {
+			s.renderFailed(w, "ignition", profile.IgnitionId, rerr.report)
+			return
+		} else if err != nil {
+			s.logger.Errorf("error collecting variables: %v", err)
+			writeProblem(w, http.StatusNotFound, CodeInvalidMetadata, "error collecting template variables")
 			return
 		}
+		js := value.([]byte)
+		if cacheable {
+			s.renderCache.put(cacheKey, generation, js)
+		}
+		s.writeIgnition(w, req, contents, js)
+	}
+	return ContextHandlerFunc(fn)
+}
 
-		s.renderJSON(w, ign)
+// writeIgnition writes a rendered Ignition config. Configs of templates
+// which include others are compared to conditional requests by content.
+func (s *Server) writeIgnition(w http.ResponseWriter, req *http.Request, contents string, js []byte) {
+	if usesIncludes(contents) && notModified(w, req, contentETag(js)) {
 		return
 	}
-	return ContextHandlerFunc(fn)
+	s.writeJSON(w, js)
 }
 
 // isIgnition returns true if the file should be treated as plain Ignition.
 func isIgnition(filename string) bool {
 	return strings.HasSuffix(filename, ".ign") || strings.HasSuffix(filename, ".ignition")
 }
+
+// renderIgnition renders a Fuze template with the request and Group data
+// and converts it to Ignition JSON.
+func (s *Server) renderIgnition(ctx context.Context, core server.Server, req *http.Request, group *storagepb.Group, contents string) ([]byte, error) {
+	// collect data for rendering
+	data, err := collectVariables(ctx, req, group)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	funcs := s.templateFuncMap(ctx, core)
+	err = s.renderTemplateWithFuncMap(&buf, funcs, data, contents)
+	if err != nil {
+		return nil, &reportError{templateReport(contents, err)}
+	}
+
+	// Parse bytes into a Fuze Config (positions refer to the rendered
+	// config, which is highlighted)
+	config, rpt := fuze.Parse(buf.Bytes())
+	if rpt.IsFatal() {
+		return nil, &reportError{addHighlights(fuzeReport(rpt), buf.String())}
+	}
+
+	// Convert Fuze Config into an Ignition Config
+	ign, rpt := fuze.ConvertAs2_0_0(config)
+	if rpt.IsFatal() {
+		return nil, &reportError{addHighlights(fuzeReport(rpt), buf.String())}
+	}
+	return json.Marshal(ign)
+}
@@ -1,8 +1,12 @@
 package http
 
 import (
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"context"
@@ -10,6 +14,7 @@ import (
 	logtest "github.com/Sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/coreos/matchbox/matchbox/kubeadm"
 	"github.com/coreos/matchbox/matchbox/server"
 	"github.com/coreos/matchbox/matchbox/storage/storagepb"
 	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
@@ -40,6 +45,37 @@ func TestIgnitionHandler_V2JSON(t *testing.T) {
 	assert.Equal(t, content, w.Body.String())
 }
 
+func TestIgnitionHandler_ProfileHeaders(t *testing.T) {
+	content := `{"ignition":{"version":"2.0.0","config":{}},"storage":{},"systemd":{},"networkd":{},"passwd":{}}`
+	profile := &storagepb.Profile{
+		Id:         fake.Group.Profile,
+		IgnitionId: "file.ign",
+		Headers: map[string]string{
+			"Cache-Control": "no-store",
+			"content-type":  "application/vnd.coreos.ignition+json",
+		},
+	}
+	store := &fake.FixedStore{
+		Profiles:        map[string]*storagepb.Profile{fake.Group.Profile: profile},
+		IgnitionConfigs: map[string]string{"file.ign": content},
+	}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	c := server.NewServer(&server.Config{Store: store})
+	h := srv.ignitionHandler(c)
+	ctx := withGroup(context.Background(), fake.Group)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	h.ServeHTTP(ctx, w, req)
+	// assert that:
+	// - the Profile's headers are set on the response
+	// - the Profile's Content-Type overrides the JSON Content-Type
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.HeaderMap.Get("Cache-Control"))
+	assert.Equal(t, "application/vnd.coreos.ignition+json", w.HeaderMap.Get(contentType))
+	assert.Equal(t, content, w.Body.String())
+}
+
 func TestIgnitionHandler_V2YAML(t *testing.T) {
 	// exercise templating features, not a realistic Fuze template
 	content := `
@@ -123,6 +159,114 @@ systemd:
 	assert.Equal(t, expectedIgnitionV2, w.Body.String())
 }
 
+func TestIgnitionHandler_KubeadmToken(t *testing.T) {
+	// exercise templating features, not a realistic Fuze template
+	partial := `
+    - name: join.service
+      contents: kubeadm join --token {{ kubeadmToken }}
+`
+	content := `
+systemd:
+  units:
+    - name: {{ kubeadmToken }}.service
+{{ include "partial.yaml" . }}
+`
+	var secrets int
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		secrets++
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer api.Close()
+	dir, err := ioutil.TempDir("", "matchbox")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	kubeconfig := filepath.Join(dir, "kubeconfig")
+	ioutil.WriteFile(kubeconfig, []byte(`current-context: c
+contexts: [{name: c, context: {cluster: c, user: u}}]
+clusters: [{name: c, cluster: {server: "`+api.URL+`"}}]
+users: [{name: u, user: {token: t}}]
+`), 0600)
+	tokens, err := kubeadm.NewTokens(&kubeadm.Config{Kubeconfig: kubeconfig})
+	assert.Nil(t, err)
+
+	store := &fake.FixedStore{
+		Profiles: map[string]*storagepb.Profile{fake.Group.Profile: testProfileIgnitionYAML},
+		IgnitionConfigs: map[string]string{
+			testProfileIgnitionYAML.IgnitionId: content,
+			"partial.yaml":                     partial,
+		},
+	}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger, BootstrapTokens: tokens, RenderCacheSize: 8})
+	c := server.NewServer(&server.Config{Store: store})
+	h := srv.ignitionHandler(c)
+	ctx := withGroup(context.Background(), fake.Group)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	h.ServeHTTP(ctx, w, req)
+	// assert that:
+	// - one bootstrap token is minted per render and used wherever the
+	//   template or its includes call kubeadmToken
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, secrets)
+	body := w.Body.String()
+	start := strings.Index(body, `"name":"`) + len(`"name":"`)
+	token := body[start : start+len("abcdef.0123456789abcdef")]
+	assert.Contains(t, body, "kubeadm join --token "+token)
+
+	// assert that:
+	// - conditional requests render a fresh token
+	etag := w.HeaderMap.Get("ETag")
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/", nil)
+	req.Header.Set("If-None-Match", etag)
+	h.ServeHTTP(ctx, w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, secrets)
+	assert.NotContains(t, w.Body.String(), token)
+
+	// assert that:
+	// - renders to sign don't mint tokens, whether the template or its
+	//   includes call kubeadmToken
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/", nil)
+	h.ServeHTTP(withSignature(ctx), w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	store.IgnitionConfigs[testProfileIgnitionYAML.IgnitionId] = "systemd:\n  units:\n{{ include \"partial.yaml\" . }}"
+	w = httptest.NewRecorder()
+	h.ServeHTTP(withSignature(ctx), w, req)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, 2, secrets)
+
+	// assert that:
+	// - renders of templates whose includes may mint tokens aren't cached
+	for i := 0; i < 2; i++ {
+		w = httptest.NewRecorder()
+		h.ServeHTTP(ctx, w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	assert.Equal(t, 4, secrets)
+}
+
+func TestIgnitionHandler_KubeadmTokenDisabled(t *testing.T) {
+	store := &fake.FixedStore{
+		Profiles:        map[string]*storagepb.Profile{fake.Group.Profile: testProfileIgnitionYAML},
+		IgnitionConfigs: map[string]string{testProfileIgnitionYAML.IgnitionId: "{{ kubeadmToken }}"},
+	}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	c := server.NewServer(&server.Config{Store: store})
+	h := srv.ignitionHandler(c)
+	ctx := withGroup(context.Background(), fake.Group)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	h.ServeHTTP(ctx, w, req)
+	// assert that:
+	// - templates which mint tokens fail to render without a kubeconfig
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "kubeadmToken requires -kubeadm-kubeconfig")
+}
+
 func TestIgnitionHandler_MissingCtxProfile(t *testing.T) {
 	logger, _ := logtest.NewNullLogger()
 	srv := NewServer(&Config{Logger: logger})
@@ -169,5 +313,30 @@ systemd:
 	// assert that:
 	// - Ignition template rendering errors because "missing_key" is not
 	// present in the template variables
-	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "error at line 5, column 15")
+}
+
+func TestIgnitionHandler_PinnedProfileVersion(t *testing.T) {
+	v1 := `{"ignition":{"version":"2.0.0","config":{}},"storage":{},"systemd":{"units":[{"name":"v1.service","enable":true}]},"networkd":{},"passwd":{}}`
+	v2 := `{"ignition":{"version":"2.0.0","config":{}},"storage":{},"systemd":{"units":[{"name":"v2.service","enable":true}]},"networkd":{},"passwd":{}}`
+	profile := &storagepb.Profile{Id: "worker", IgnitionId: "worker.ign"}
+	group := &storagepb.Group{Id: "workers", Profile: "worker", ProfileVersion: 1, Selector: map[string]string{"uuid": "a1b2c3d4"}}
+	store := fake.NewFixedStore()
+	store.Groups[group.Id] = group
+	store.Profiles[profile.Id] = profile
+	store.IgnitionConfigs["worker.ign"] = v2
+	store.Versions[profile.Id] = []*storagepb.ProfileVersion{{Version: 1, Profile: profile, Ignition: v1}}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	c := server.NewServer(&server.Config{Store: store})
+	h := srv.selectGroup(c, srv.ignitionHandler(c))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?uuid=a1b2c3d4", nil)
+	h.ServeHTTP(context.Background(), w, req)
+	// assert that:
+	// - a Group which pins a Profile version is served that version's
+	// Ignition, rather than the latest
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, v1, w.Body.String())
 }
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// imageSignatureExt is the extension of detached CMS signatures, which iPXE
+// verifies with imgverify.
+const imageSignatureExt = ".p7s"
+
+// imageSignature is the CMS signature of a version of an asset.
+type imageSignature struct {
+	modtime time.Time
+	size    int64
+	data    []byte
+}
+
+// isImageSignature returns true if the asset request is for a signature the
+// server should create, rather than a signature file in the assets
+// directory.
+func (s *Server) isImageSignature(name string) bool {
+	if s.imageSigner == nil || !strings.HasSuffix(name, imageSignatureExt) {
+		return false
+	}
+	_, err := os.Stat(s.assetFilename(name))
+	return os.IsNotExist(err)
+}
+
+// assetFilename returns the filename of an asset within the assets
+// directory.
+func (s *Server) assetFilename(name string) string {
+	return filepath.Join(s.assetsPath, filepath.FromSlash(path.Clean("/"+name)))
+}
+
+// serveImageSignature responds with the detached CMS signature of an asset.
+// Signatures are kept until the asset changes, and concurrent requests
+// (e.g. when a rack powers on) share a single signing.
+func (s *Server) serveImageSignature(w http.ResponseWriter, req *http.Request, name string) {
+	filename := s.assetFilename(name)
+	info, err := os.Stat(filename)
+	if err != nil || !info.Mode().IsRegular() {
+		writeProblem(w, http.StatusNotFound, CodeNotFound, "asset not found")
+		return
+	}
+	value, err, _ := s.signings.Do(filename+imageSignatureExt, func() (interface{}, error) {
+		s.imageSigsMu.Lock()
+		cached, ok := s.imageSigs[filename]
+		s.imageSigsMu.Unlock()
+		if ok && cached.modtime.Equal(info.ModTime()) && cached.size == info.Size() {
+			return cached, nil
+		}
+		file, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		var buf bytes.Buffer
+		if err := s.imageSigner.Sign(&buf, file); err != nil {
+			return nil, err
+		}
+		signature := &imageSignature{modtime: info.ModTime(), size: info.Size(), data: buf.Bytes()}
+		s.imageSigsMu.Lock()
+		s.imageSigs[filename] = signature
+		s.imageSigsMu.Unlock()
+		return signature, nil
+	})
+	if err != nil {
+		s.logger.Errorf("error signing asset %s: %v", name, err)
+		writeProblem(w, http.StatusInternalServerError, CodeInternalError, "error signing asset")
+		return
+	}
+	signature := value.(*imageSignature)
+	w.Header().Set("Content-Type", "application/pkcs7-signature")
+	http.ServeContent(w, req, path.Base(name)+imageSignatureExt, signature.modtime, bytes.NewReader(signature.data))
+}
@@ -0,0 +1,111 @@
+package http
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"context"
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+func TestIPXEInspect_Imgtrust(t *testing.T) {
+	h := NewServer(&Config{ImageSigner: fixedSigner("p7s")}).ipxeInspect()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	h.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	query := "uuid=${uuid}&mac=${mac:hexhyp}&domain=${domain}&hostname=${hostname}" +
+		"&serial=${serial:uristring}&asset=${asset:uristring}&manufacturer=${manufacturer:uristring}&product=${product:uristring}" +
+		"&platform=${platform}&buildarch=${buildarch}&ip=${netX/ip}"
+	expected := "#!ipxe\n" +
+		"imgtrust --permanent\n" +
+		"imgfetch --name matchbox ipxe?" + query + "\n" +
+		"imgverify matchbox ipxe.p7s?" + query + "\n" +
+		"chain matchbox\n"
+	// assert that the chained iPXE script is verified by its signature
+	assert.Equal(t, expected, w.Body.String())
+}
+
+func TestRenderIPXE_Imgtrust(t *testing.T) {
+	profile := &storagepb.Profile{
+		Id: "worker",
+		Boot: &storagepb.NetBoot{
+			Kernel: "/assets/coreos/vmlinuz",
+			Initrd: []string{"/assets/coreos/initrd.cpio.gz", "--name main http://mirror.example.com/initrd.img?arch=amd64", "http://[2001:db8::2]:8080/assets/coreos/oem.img"},
+			Args:   []string{"coreos.autologin", "initrd=main"},
+		},
+	}
+	config, err := renderIPXE(profile.Boot, true)
+	assert.Nil(t, err)
+	// assert that:
+	// - images must be trusted
+	// - the kernel and each initrd are verified by their signature, by the
+	//   name iPXE gives them
+	// - signature URLs keep IPv6 literal hosts
+	expected := `#!ipxe
+imgtrust --permanent
+kernel /assets/coreos/vmlinuz coreos.autologin initrd=main
+imgverify vmlinuz /assets/coreos/vmlinuz.p7s
+initrd /assets/coreos/initrd.cpio.gz
+imgverify initrd.cpio.gz /assets/coreos/initrd.cpio.gz.p7s
+initrd --name main http://mirror.example.com/initrd.img?arch=amd64
+imgverify main http://mirror.example.com/initrd.img.p7s?arch=amd64
+initrd http://[2001:db8::2]:8080/assets/coreos/oem.img
+imgverify oem.img http://[2001:db8::2]:8080/assets/coreos/oem.img.p7s
+boot
+`
+	assert.Equal(t, expected, string(config))
+}
+
+func TestImageSignatures(t *testing.T) {
+	dir, err := ioutil.TempDir("", "matchbox-assets")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "coreos"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "coreos", "vmlinuz"), []byte("kernel"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "coreos", "initrd.img"), []byte("initrd"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "coreos", "initrd.img.p7s"), []byte("offline"), 0644))
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger, AssetsPath: dir, ImageSigner: fixedSigner("p7s")})
+	h := srv.HTTPHandler()
+
+	cases := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/assets/coreos/vmlinuz", http.StatusOK, "kernel"},
+		// signed by the image signer
+		{"/assets/coreos/vmlinuz.p7s", http.StatusOK, "p7s"},
+		{"/assets/coreos/vmlinuz.p7s", http.StatusOK, "p7s"},
+		// signature files in the assets directory are served as is
+		{"/assets/coreos/initrd.img.p7s", http.StatusOK, "offline"},
+		{"/assets/coreos/missing.p7s", http.StatusNotFound, ""},
+	}
+	// assert that:
+	// - assets have detached CMS signatures
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", c.path, nil)
+		h.ServeHTTP(w, req)
+		assert.Equal(t, c.code, w.Code, c.path)
+		if c.code == http.StatusOK {
+			assert.Equal(t, c.body, w.Body.String(), c.path)
+		}
+	}
+
+	// assert that:
+	// - rendered iPXE scripts have detached CMS signatures
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/boot.ipxe.p7s", nil)
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "p7s", w.Body.String())
+}
@@ -0,0 +1,150 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+
+	"context"
+
+	"github.com/coreos/matchbox/matchbox/redact"
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// ungrouped is the Ansible group of hosts which match no Group.
+const ungrouped = "ungrouped"
+
+// invalidGroupChars are characters Ansible does not allow in group names.
+var invalidGroupChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// ansibleGroup is a group in an Ansible dynamic inventory.
+type ansibleGroup struct {
+	Hosts    []string               `json:"hosts,omitempty"`
+	Vars     map[string]interface{} `json:"vars,omitempty"`
+	Children []string               `json:"children,omitempty"`
+}
+
+// inventoryHandler returns a handler which responds with an Ansible dynamic
+// inventory of Machines. Each Group is an Ansible group whose vars are the
+// Group's metadata and selectors, like template variables. Machines are
+// hosts of their pinned or matching Group, with their labels and facts as
+// host vars.
+func (s *Server) inventoryHandler(core server.Server) ContextHandler {
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		var redactor *redact.Redactor
+		if s.redactAPI {
+			redactor = s.redactor
+		}
+		inventory, err := buildInventory(ctx, core, redactor)
+		if err != nil {
+			s.logger.Errorf("error building inventory: %v", err)
+			writeProblem(w, http.StatusInternalServerError, CodeInternalError, "error building inventory")
+			return
+		}
+		s.renderJSON(w, inventory)
+	}
+	return ContextHandlerFunc(fn)
+}
+
+// buildInventory returns an Ansible dynamic inventory of Machines and Groups.
+// Sensitive Group metadata values are redacted by the (optional) redactor.
+func buildInventory(ctx context.Context, core server.Server, redactor *redact.Redactor) (map[string]interface{}, error) {
+	groups, err := core.GroupList(ctx, &pb.GroupListRequest{})
+	if err != nil {
+		return nil, err
+	}
+	machines, err := core.MachineList(ctx, &pb.MachineListRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	inventory := make(map[string]interface{})
+	ansibleGroups := make(map[string]*ansibleGroup)
+	names := make(map[string]string)
+	for _, group := range groups {
+		name := invalidGroupChars.ReplaceAllString(group.Id, "_")
+		if name == "all" || name == ungrouped {
+			name = "matchbox_" + name
+		}
+		names[group.Id] = name
+		vars := make(map[string]interface{})
+		if len(group.Metadata) > 0 {
+			metadata, err := redactor.Metadata(group.Metadata)
+			if err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(metadata, &vars); err != nil {
+				return nil, err
+			}
+		}
+		for key, value := range group.Selector {
+			vars[key] = value
+		}
+		ansibleGroups[name] = &ansibleGroup{Vars: vars}
+	}
+	ansibleGroups[ungrouped] = &ansibleGroup{}
+
+	hostvars := make(map[string]interface{})
+	for _, machine := range machines {
+		host := machine.Id
+		vars := map[string]interface{}{
+			"matchbox_id":    machine.Id,
+			"matchbox_state": machine.State,
+		}
+		for key, value := range machine.Facts {
+			vars[key] = value
+		}
+		for key, value := range machine.Labels {
+			vars[key] = value
+		}
+		if hostname, ok := vars["hostname"].(string); ok && hostname != "" {
+			host = hostname
+		}
+		if ip, ok := vars["ip"].(string); ok && ip != "" {
+			vars["ansible_host"] = ip
+		}
+		name := ungrouped
+		if group := machineGroup(ctx, core, machine); group != nil {
+			name = names[group.Id]
+			vars["matchbox_group"] = group.Id
+		}
+		ansibleGroups[name].Hosts = append(ansibleGroups[name].Hosts, host)
+		hostvars[host] = vars
+	}
+
+	var children []string
+	for name, group := range ansibleGroups {
+		sort.Strings(group.Hosts)
+		inventory[name] = group
+		children = append(children, name)
+	}
+	sort.Strings(children)
+	inventory["all"] = &ansibleGroup{Children: children}
+	inventory["_meta"] = map[string]interface{}{"hostvars": hostvars}
+	return inventory, nil
+}
+
+// machineGroup returns the Group a Machine is pinned to or whose selectors
+// match its labels and facts, or nil.
+func machineGroup(ctx context.Context, core server.Server, machine *storagepb.Machine) *storagepb.Group {
+	if machine.Group != "" {
+		if group, err := core.GroupGet(ctx, &pb.GroupGetRequest{Id: machine.Group}); err == nil {
+			return group
+		}
+	}
+	labels := make(map[string]string)
+	for key, value := range machine.Facts {
+		labels[key] = value
+	}
+	for key, value := range machine.Labels {
+		labels[key] = value
+	}
+	group, err := core.SelectGroup(ctx, &pb.SelectGroupRequest{Labels: labels})
+	if err != nil {
+		return nil
+	}
+	return group
+}
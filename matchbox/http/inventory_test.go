@@ -0,0 +1,62 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"context"
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestInventoryHandler(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups["etcd-node"] = &storagepb.Group{
+		Id:       "etcd-node",
+		Selector: map[string]string{"role": "etcd"},
+		Metadata: []byte(`{"etcd_cluster": "node1=http://node1:2380"}`),
+	}
+	store.Groups["all"] = &storagepb.Group{Id: "all", Selector: map[string]string{"role": "none"}}
+	store.Machines["a1b2c3d4"] = &storagepb.Machine{
+		Id:     "a1b2c3d4",
+		Labels: map[string]string{"uuid": "a1b2c3d4", "role": "etcd"},
+		Facts:  map[string]string{"hostname": "node1", "ip": "10.0.0.21"},
+		State:  storagepb.MachineProvisioned,
+	}
+	store.Machines["52:54:00:b2:2f:86"] = &storagepb.Machine{Id: "52:54:00:b2:2f:86"}
+	logger, _ := logtest.NewNullLogger()
+	core := server.NewServer(&server.Config{Store: store})
+	srv := NewServer(&Config{Core: core, Logger: logger})
+	h := srv.inventoryHandler(core)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/inventory", nil)
+	h.ServeHTTP(context.Background(), w, req)
+
+	// assert that:
+	// - Groups are Ansible groups with their metadata and selectors as vars
+	// - Machines are hosts named by hostname, with ansible_host set to their IP
+	// - Machines which match no Group are ungrouped
+	// - Group names are valid and do not collide with reserved groups
+	expected := `{
+	"_meta": {"hostvars": {
+		"node1": {"matchbox_id": "a1b2c3d4", "matchbox_state": "provisioned", "matchbox_group": "etcd-node", "uuid": "a1b2c3d4", "role": "etcd", "hostname": "node1", "ip": "10.0.0.21", "ansible_host": "10.0.0.21"},
+		"52:54:00:b2:2f:86": {"matchbox_id": "52:54:00:b2:2f:86", "matchbox_state": ""}
+	}},
+	"all": {"children": ["etcd_node", "matchbox_all", "ungrouped"]},
+	"etcd_node": {"hosts": ["node1"], "vars": {"etcd_cluster": "node1=http://node1:2380", "role": "etcd"}},
+	"matchbox_all": {"vars": {"role": "none"}},
+	"ungrouped": {"hosts": ["52:54:00:b2:2f:86"]}
+}`
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, jsonContentType, w.HeaderMap.Get(contentType))
+	var want, got interface{}
+	assert.Nil(t, json.Unmarshal([]byte(expected), &want))
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, want, got)
+}
@@ -4,14 +4,54 @@ import (
 	"bytes"
 	"fmt"
 	"net/http"
+	"net/url"
+	"path"
+	"strings"
 	"text/template"
 
 	"context"
 	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
 )
 
+// ipxeVariable is an iPXE setting forwarded as a label.
+type ipxeVariable struct {
+	label, setting string
+}
+
+// ipxeVariables are the iPXE settings the bootstrap script forwards to the
+// iPXE endpoint as labels. Free-form settings are URI encoded since iPXE
+// splits commands on spaces.
+var ipxeVariables = []ipxeVariable{
+	{"uuid", "uuid"},
+	{"mac", "mac:hexhyp"},
+	{"domain", "domain"},
+	{"hostname", "hostname"},
+	{"serial", "serial:uristring"},
+	{"asset", "asset:uristring"},
+	{"manufacturer", "manufacturer:uristring"},
+	{"product", "product:uristring"},
+	{"platform", "platform"},
+	{"buildarch", "buildarch"},
+	{"ip", "netX/ip"},
+}
+
+// defaultChain chains to the iPXE endpoint with the default settings.
+var defaultChain, _ = ParseChain("", "")
+
+// ipxeBootstrap chainloads a URL.
 const ipxeBootstrap = `#!ipxe
-chain ipxe?uuid=${uuid}&mac=${mac:hexhyp}&domain=${domain}&hostname=${hostname}&serial=${serial}
+chain %s
+`
+
+// ipxeTrustedBootstrap requires images to be verified and verifies the iPXE
+// script at a URL, by its signature URL, before chainloading it.
+const ipxeTrustedBootstrap = `#!ipxe
+imgtrust --permanent
+imgfetch --name matchbox %s
+imgverify matchbox %s
+chain matchbox
 `
 
 var ipxeTemplate = template.Must(template.New("iPXE config").Parse(`#!ipxe
@@ -20,11 +60,35 @@ initrd {{ range $element := .Initrd }}{{$element}} {{end}}
 boot
 `))
 
+// ipxeTrustedTemplate verifies the kernel and each initrd with its detached
+// CMS signature before booting.
+var ipxeTrustedTemplate = template.Must(template.New("iPXE trusted config").Funcs(template.FuncMap{
+	"imageName":      ipxeImageName,
+	"imageSignature": ipxeImageSignature,
+}).Parse(`#!ipxe
+imgtrust --permanent
+kernel {{.Kernel}}{{range $arg := .Args}} {{$arg}}{{end}}{{range $key, $value := .Cmdline}} {{if $value}}{{$key}}={{$value}}{{else}}{{$key}}{{end}}{{end}}
+imgverify {{imageName .Kernel}} {{imageSignature .Kernel}}
+{{range $element := .Initrd}}initrd {{$element}}
+imgverify {{imageName $element}} {{imageSignature $element}}
+{{end}}boot
+`))
+
 // ipxeInspect returns a handler that responds with the iPXE script to gather
 // client machine data and chainload to the ipxeHandler.
-func ipxeInspect() ContextHandler {
+func (s *Server) ipxeInspect() ContextHandler {
 	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
-		fmt.Fprintf(w, ipxeBootstrap)
+		chainURL, err := s.chain.render(labelsFromRequest(nil, req))
+		if err != nil {
+			s.logger.Errorf("error rendering chain URL: %v", err)
+			writeProblem(w, http.StatusInternalServerError, CodeTemplateError, "error rendering iPXE chain URL")
+			return
+		}
+		if s.imageSigner != nil {
+			fmt.Fprintf(w, ipxeTrustedBootstrap, chainURL, signatureURL(chainURL))
+			return
+		}
+		fmt.Fprintf(w, ipxeBootstrap, chainURL)
 	}
 	return ContextHandlerFunc(fn)
 }
@@ -36,29 +100,112 @@ func (s *Server) ipxeHandler() ContextHandler {
 		profile, err := profileFromContext(ctx)
 		if err != nil {
 			s.logger.WithFields(logrus.Fields{
-				"labels": labelsFromRequest(nil, req),
+				"labels": s.redactor.Labels(labelsFromRequest(nil, req)),
 			}).Infof("No matching profile")
-			http.NotFound(w, req)
+			writeProblem(w, http.StatusNotFound, noProfileCode(ctx), "no profile matches the machine")
 			return
 		}
 
 		// match was successful
 		s.logger.WithFields(logrus.Fields{
-			"labels":  labelsFromRequest(nil, req),
+			"labels":  s.redactor.Labels(labelsFromRequest(nil, req)),
 			"profile": profile.Id,
 		}).Debug("Matched an iPXE config")
+		profileBoots.Inc(profile.Id)
+		setProfileHeaders(w, profile)
 
-		var buf bytes.Buffer
-		err = ipxeTemplate.Execute(&buf, profile.Boot)
-		if err != nil {
-			s.logger.Errorf("error rendering template: %v", err)
-			http.NotFound(w, req)
-			return
+		// the boot script depends only on the Profile, unless its kernel
+		// args are templates, so machines booting the same Profile version
+		// reuse a cached script
+		var config []byte
+		var key string
+		var generation uint64
+		var cached bool
+		if s.renderCache.enabled() {
+			group, _ := groupFromContext(ctx)
+			var args string
+			if group != nil {
+				args = strings.Join(profile.Boot.Args, " ")
+			}
+			key = renderKey(ctx, "ipxe", profile, group, args, req)
+			generation = s.core.Generation(ctx)
+			config, cached = s.renderCache.get(key, generation)
+			renderCacheRequests.Inc("ipxe", cacheResult(cached))
+		}
+		if !cached {
+			boot, err := s.bootFromContext(ctx, w, req, profile)
+			if err != nil {
+				return
+			}
+			config, err = renderIPXE(boot, s.imageSigner != nil)
+			if err != nil {
+				s.logger.Errorf("error rendering template: %v", err)
+				writeProblem(w, http.StatusNotFound, CodeTemplateError, "error rendering iPXE config")
+				return
+			}
+			if s.renderCache.enabled() {
+				s.renderCache.put(key, generation, config)
+			}
 		}
-		if _, err := buf.WriteTo(w); err != nil {
+		if _, err := w.Write(config); err != nil {
 			s.logger.Errorf("error writing to response: %v", err)
 			w.WriteHeader(http.StatusInternalServerError)
 		}
 	}
 	return ContextHandlerFunc(fn)
 }
+
+// renderIPXE renders the iPXE boot script for a Profile's NetBoot. With
+// imgtrust, the script verifies the signature of each image.
+func renderIPXE(boot *storagepb.NetBoot, imgtrust bool) ([]byte, error) {
+	tmpl := ipxeTemplate
+	if imgtrust {
+		tmpl = ipxeTrustedTemplate
+	}
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, boot)
+	return buf.Bytes(), err
+}
+
+// ipxeImage returns the URI of an iPXE image argument (e.g. "--name main
+// /assets/initrd.img") and the image name given by --name, if any.
+func ipxeImage(arg string) (uri, name string) {
+	fields := strings.Fields(arg)
+	for i := 0; i < len(fields); i++ {
+		switch field := fields[i]; {
+		case (field == "--name" || field == "-n") && i+1 < len(fields):
+			name = fields[i+1]
+			i++
+		case strings.HasPrefix(field, "--name="):
+			name = strings.TrimPrefix(field, "--name=")
+		case !strings.HasPrefix(field, "-") && uri == "":
+			uri = field
+		}
+	}
+	return uri, name
+}
+
+// ipxeImageName returns the name iPXE gives an image, the --name option or
+// else the last segment of the URI path.
+func ipxeImageName(arg string) string {
+	uri, name := ipxeImage(arg)
+	if name != "" {
+		return name
+	}
+	if u, err := url.Parse(uri); err == nil {
+		return path.Base(u.Path)
+	}
+	return path.Base(uri)
+}
+
+// ipxeImageSignature returns the URI of the detached CMS signature of an
+// image, which has the imageSignatureExt appended to the URI path.
+func ipxeImageSignature(arg string) string {
+	uri, _ := ipxeImage(arg)
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri + imageSignatureExt
+	}
+	u.Path += imageSignatureExt
+	return u.String()
+}
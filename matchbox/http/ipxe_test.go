@@ -14,12 +14,17 @@ import (
 )
 
 func TestIPXEInspect(t *testing.T) {
-	h := ipxeInspect()
+	h := NewServer(&Config{}).ipxeInspect()
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/", nil)
 	h.ServeHTTP(context.Background(), w, req)
+	expected := "#!ipxe\n" +
+		"chain ipxe?uuid=${uuid}&mac=${mac:hexhyp}&domain=${domain}&hostname=${hostname}" +
+		"&serial=${serial:uristring}&asset=${asset:uristring}&manufacturer=${manufacturer:uristring}&product=${product:uristring}" +
+		"&platform=${platform}&buildarch=${buildarch}&ip=${netX/ip}\n"
+	// assert that the iPXE variables are forwarded as labels
 	assert.Equal(t, http.StatusOK, w.Code)
-	assert.Equal(t, ipxeBootstrap, w.Body.String())
+	assert.Equal(t, expected, w.Body.String())
 }
 
 func TestIPXEHandler(t *testing.T) {
@@ -41,6 +46,21 @@ boot
 	assert.Equal(t, expectedScript, w.Body.String())
 }
 
+func TestIPXEHandler_ProfileHeaders(t *testing.T) {
+	profile := fake.Profile.Copy()
+	profile.Headers = map[string]string{"X-Vendor-Boot": "1"}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	h := srv.ipxeHandler()
+	ctx := withProfile(context.Background(), profile)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	h.ServeHTTP(ctx, w, req)
+	// assert that the Profile's headers are set on the iPXE script response
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.HeaderMap.Get("X-Vendor-Boot"))
+}
+
 func TestIPXEHandler_MissingCtxProfile(t *testing.T) {
 	logger, _ := logtest.NewNullLogger()
 	srv := NewServer(&Config{Logger: logger})
@@ -49,6 +69,7 @@ func TestIPXEHandler_MissingCtxProfile(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/", nil)
 	h.ServeHTTP(context.Background(), w, req)
 	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, CodeNoMatchingGroup, decodeProblem(t, w).Code)
 }
 
 func TestIPXEHandler_RenderTemplateError(t *testing.T) {
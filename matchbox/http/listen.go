@@ -0,0 +1,41 @@
+package http
+
+import (
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/coreos/matchbox/matchbox/socket"
+)
+
+// ErrNoListenAddress is returned when no listen address is given.
+var ErrNoListenAddress = errors.New("http: a listen address is required")
+
+// Listen listens on each of the comma separated TCP addresses, such as an
+// IPv4 and an IPv6 address (e.g. "10.0.0.2:8080,[2001:db8::2]:8080") to serve
+// both stacks from specific addresses. An address with an unspecified host
+// (e.g. ":8080" or "[::]:8080") already accepts IPv4 and IPv6 connections
+// on dual-stack hosts. Addresses may also be unix or systemd activated
+// sockets (see socket.Listen). If any address fails, listeners which were
+// opened are closed.
+func Listen(addresses string) ([]net.Listener, error) {
+	var listeners []net.Listener
+	for _, address := range strings.Split(addresses, ",") {
+		address = strings.TrimSpace(address)
+		if address == "" {
+			continue
+		}
+		lis, err := socket.Listen(address, 0)
+		if err != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, lis)
+	}
+	if len(listeners) == 0 {
+		return nil, ErrNoListenAddress
+	}
+	return listeners, nil
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListen(t *testing.T) {
+	listeners, err := Listen("127.0.0.1:0, [::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback is unavailable: %v", err)
+	}
+	// assert that:
+	// - each IPv4 and IPv6 address is listened on
+	if assert.Len(t, listeners, 2) {
+		assert.Equal(t, "127.0.0.1", listeners[0].Addr().(*net.TCPAddr).IP.String())
+		assert.Equal(t, "::1", listeners[1].Addr().(*net.TCPAddr).IP.String())
+	}
+	for _, lis := range listeners {
+		lis.Close()
+	}
+
+	// - if an address fails, opened listeners are closed
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer lis.Close()
+	_, err = Listen("127.0.0.1:0," + lis.Addr().String())
+	assert.Error(t, err)
+
+	// - an address is required
+	_, err = Listen(" , ")
+	assert.Equal(t, ErrNoListenAddress, err)
+}
+
+func TestListen_Unix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "listen")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	// assert that:
+	// - unix socket and TCP addresses may be mixed
+	listeners, err := Listen("127.0.0.1:0,unix:" + filepath.Join(dir, "http.sock"))
+	if assert.Nil(t, err) && assert.Len(t, listeners, 2) {
+		assert.Equal(t, "tcp", listeners[0].Addr().Network())
+		assert.Equal(t, "unix", listeners[1].Addr().Network())
+	}
+	for _, lis := range listeners {
+		lis.Close()
+	}
+}
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// Local boot modes, which determine when installed machines are switched
+// from their Profile to booting from local disk.
+const (
+	// LocalBootComplete boots machines from local disk once they report
+	// provisioning completion (phone-home)
+	LocalBootComplete = "complete"
+	// LocalBootIgnition boots machines from local disk once they have
+	// fetched their Ignition config
+	LocalBootIgnition = "ignition"
+)
+
+// ErrInvalidLocalBoot is returned for an unknown local boot mode.
+var ErrInvalidLocalBoot = errors.New("http: local boot must be complete or ignition")
+
+// ValidateLocalBoot returns an error if the local boot mode is unknown. The
+// empty mode disables local boot.
+func ValidateLocalBoot(mode string) error {
+	switch mode {
+	case "", LocalBootComplete, LocalBootIgnition:
+		return nil
+	}
+	return ErrInvalidLocalBoot
+}
+
+// ipxeLocalBoot exits iPXE so the firmware boots the next boot device, the
+// local disk.
+const ipxeLocalBoot = `#!ipxe
+echo Booting from local disk
+exit
+`
+
+// localBoot returns a handler which responds with a local boot iPXE script
+// to installed machines and otherwise calls the next handler.
+func (s *Server) localBoot(core server.Server, next ContextHandler) ContextHandler {
+	if s.localBootMode == "" {
+		return next
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		labels := labelsFromRequest(nil, req)
+		if !s.installed(ctx, core, labels) {
+			next.ServeHTTP(ctx, w, req)
+			return
+		}
+		s.logger.WithFields(logrus.Fields{
+			"labels": s.redactor.Labels(labels),
+		}).Debug("Machine is installed, booting from local disk")
+		localBoots.Inc()
+		fmt.Fprint(w, ipxeLocalBoot)
+	}
+	return ContextHandlerFunc(fn)
+}
+
+// installed returns true if the machine with the "uuid" label should boot
+// from local disk.
+func (s *Server) installed(ctx context.Context, core server.Server, labels map[string]string) bool {
+	uuid := labels["uuid"]
+	if uuid == "" {
+		return false
+	}
+	machine, err := core.MachineGet(ctx, &pb.MachineGetRequest{Id: uuid})
+	if err != nil {
+		return false
+	}
+	switch machine.State {
+	case storagepb.MachineProvisioned:
+		return true
+	case storagepb.MachineInstalled:
+		return s.localBootMode == LocalBootIgnition
+	}
+	return false
+}
+
+// recordIgnition marks the machine with the "uuid" label as installed once
+// it has fetched its Ignition config, if local boot follows Ignition.
+func (s *Server) recordIgnition(req *http.Request, status int) {
+	if s.localBootMode != LocalBootIgnition || req.URL.Path != "/ignition" || status != http.StatusOK {
+		return
+	}
+	labels := labelsFromRequest(nil, req)
+	uuid := labels["uuid"]
+	if uuid == "" {
+		return
+	}
+	machine, err := s.core.MachineUpdate(req.Context(), uuid, func(machine *storagepb.Machine) (*storagepb.Machine, error) {
+		if machine == nil {
+			machine = &storagepb.Machine{Id: uuid, Labels: labels}
+		}
+		if machine.State == storagepb.MachineProvisioned || machine.State == storagepb.MachineInstalled {
+			return nil, nil
+		}
+		machine.State = storagepb.MachineInstalled
+		machine.Wipe = false
+		machine.Attempts = 0
+		return machine, nil
+	})
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"uuid": uuid,
+		}).Errorf("error recording machine install: %v", err)
+		return
+	}
+	if machine == nil {
+		return
+	}
+	s.logger.WithFields(logrus.Fields{
+		"labels": s.redactor.Labels(labels),
+	}).Infof("Machine %s fetched its Ignition config, booting from local disk", uuid)
+}
@@ -0,0 +1,121 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"context"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestValidateLocalBoot(t *testing.T) {
+	assert.Nil(t, ValidateLocalBoot(""))
+	assert.Nil(t, ValidateLocalBoot(LocalBootComplete))
+	assert.Nil(t, ValidateLocalBoot(LocalBootIgnition))
+	assert.Equal(t, ErrInvalidLocalBoot, ValidateLocalBoot("always"))
+}
+
+func TestLocalBoot(t *testing.T) {
+	next := ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("installer"))
+	})
+	cases := []struct {
+		mode     string
+		state    string
+		expected string
+	}{
+		{LocalBootComplete, storagepb.MachineProvisioned, ipxeLocalBoot},
+		{LocalBootComplete, storagepb.MachineInstalled, "installer"},
+		{LocalBootComplete, storagepb.MachineReinstall, "installer"},
+		{LocalBootIgnition, storagepb.MachineProvisioned, ipxeLocalBoot},
+		{LocalBootIgnition, storagepb.MachineInstalled, ipxeLocalBoot},
+		{LocalBootIgnition, storagepb.MachineBooted, "installer"},
+		{"", storagepb.MachineProvisioned, "installer"},
+	}
+	for _, tc := range cases {
+		store := fake.NewFixedStore()
+		store.Machines["a1b2c3d4"] = &storagepb.Machine{Id: "a1b2c3d4", State: tc.state}
+		logger, _ := logtest.NewNullLogger()
+		srv := NewServer(&Config{Logger: logger, LocalBoot: tc.mode})
+		c := server.NewServer(&server.Config{Store: store})
+		h := srv.localBoot(c, next)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/ipxe?uuid=a1b2c3d4", nil)
+		h.ServeHTTP(context.Background(), w, req)
+		// assert that:
+		// - installed machines are served a local boot script
+		// - other machines are served their Profile
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, tc.expected, w.Body.String(), "mode %q, state %q", tc.mode, tc.state)
+	}
+}
+
+func TestLocalBoot_UnknownMachine(t *testing.T) {
+	next := ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("installer"))
+	})
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger, LocalBoot: LocalBootIgnition})
+	c := server.NewServer(&server.Config{Store: fake.NewFixedStore()})
+	h := srv.localBoot(c, next)
+	for _, url := range []string{"/ipxe", "/ipxe?uuid=unknown"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", url, nil)
+		h.ServeHTTP(context.Background(), w, req)
+		assert.Equal(t, "installer", w.Body.String())
+	}
+}
+
+func TestRecordIgnition(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Machines["reinstall"] = &storagepb.Machine{Id: "reinstall", State: storagepb.MachineReinstall}
+	store.Machines["provisioned"] = &storagepb.Machine{Id: "provisioned", State: storagepb.MachineProvisioned}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Logger:    logger,
+		Core:      server.NewServer(&server.Config{Store: store}),
+		LocalBoot: LocalBootIgnition,
+	})
+	record := func(url string, status int) {
+		req, _ := http.NewRequest("GET", url, nil)
+		srv.recordIgnition(req, status)
+	}
+	record("/ignition?uuid=a1b2c3d4&mac=52-54-00-a1-9c-ae", http.StatusOK)
+	record("/ignition?uuid=reinstall", http.StatusOK)
+	record("/ignition?uuid=provisioned", http.StatusOK)
+	record("/ignition?uuid=failed", http.StatusNotFound)
+	record("/ipxe?uuid=ipxe", http.StatusOK)
+	// assert that:
+	// - machines which fetch Ignition are marked installed
+	// - provisioned machines are unchanged
+	// - failed fetches and other endpoints are ignored
+	if assert.NotNil(t, store.Machines["a1b2c3d4"]) {
+		assert.Equal(t, storagepb.MachineInstalled, store.Machines["a1b2c3d4"].State)
+		assert.Equal(t, "52:54:00:a1:9c:ae", store.Machines["a1b2c3d4"].Labels["mac"])
+	}
+	assert.Equal(t, storagepb.MachineInstalled, store.Machines["reinstall"].State)
+	assert.Equal(t, storagepb.MachineProvisioned, store.Machines["provisioned"].State)
+	assert.Nil(t, store.Machines["failed"])
+	assert.Nil(t, store.Machines["ipxe"])
+}
+
+func TestRecordIgnition_CompleteMode(t *testing.T) {
+	store := fake.NewFixedStore()
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Logger:    logger,
+		Core:      server.NewServer(&server.Config{Store: store}),
+		LocalBoot: LocalBootComplete,
+	})
+	req, _ := http.NewRequest("GET", "/ignition?uuid=a1b2c3d4", nil)
+	srv.recordIgnition(req, http.StatusOK)
+	// assert that machines are only switched on completion
+	assert.Nil(t, store.Machines["a1b2c3d4"])
+}
@@ -0,0 +1,200 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/requestid"
+	"github.com/coreos/matchbox/matchbox/trace"
+)
+
+// requestInfo collects request-scoped fields for the request log line.
+// Handlers record the matched Group and Profile and the render duration.
+type requestInfo struct {
+	id      string
+	group   string
+	profile string
+	render  time.Duration
+}
+
+// unexported key prevents collisions
+const requestInfoKey key = 100
+
+// withRequestInfo returns a copy of ctx that stores the requestInfo.
+func withRequestInfo(ctx context.Context, info *requestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey, info)
+}
+
+// requestInfoFromContext returns the requestInfo from the ctx, or a
+// throwaway requestInfo if there is none.
+func requestInfoFromContext(ctx context.Context) *requestInfo {
+	if info, ok := ctx.Value(requestInfoKey).(*requestInfo); ok {
+		return info
+	}
+	return &requestInfo{}
+}
+
+// observeRender records the time spent rendering a config template since
+// start, per Profile and template, and logs a warning if the render was
+// slower than the slow render threshold.
+func (s *Server) observeRender(ctx context.Context, config, template string, start time.Time) {
+	elapsed := time.Since(start)
+	info := requestInfoFromContext(ctx)
+	info.render = elapsed
+	renderDuration.Observe(elapsed.Seconds(), config, info.profile, template)
+	trace.Record(ctx, "render."+config, start, nil)
+	if s.slowRender > 0 && elapsed > s.slowRender {
+		slowRenders.Inc(config, info.profile, template)
+		s.logger.WithFields(logrus.Fields{
+			"request_id": info.id,
+			"config":     config,
+			"group":      info.group,
+			"profile":    info.profile,
+			"template":   template,
+			"duration":   elapsed.Seconds(),
+			"threshold":  s.slowRender.Seconds(),
+		}).Warning("slow template render")
+	}
+}
+
+// statusRecorder records the status code and bytes written to a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+	// (optional) hash of the response body
+	hash hash.Hash
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(p)
+	r.bytes += int64(n)
+	if r.hash != nil {
+		r.hash.Write(p[:n])
+	}
+	return n, err
+}
+
+// copyBufferSize is the size of buffers copying responses (e.g. assets)
+// to connections which can't use sendfile, such as TLS connections.
+const copyBufferSize = 256 << 10
+
+var copyBuffers = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, copyBufferSize)
+		return &buf
+	},
+}
+
+// ReadFrom copies the response body from src, letting the underlying
+// ResponseWriter send files with sendfile where supported.
+func (r *statusRecorder) ReadFrom(src io.Reader) (n int64, err error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	if rf, ok := r.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(src)
+	} else {
+		buf := copyBuffers.Get().(*[]byte)
+		n, err = io.CopyBuffer(struct{ io.Writer }{r.ResponseWriter}, src, *buf)
+		copyBuffers.Put(buf)
+	}
+	r.bytes += n
+	return n, err
+}
+
+// logRequest logs HTTP requests once they are served, with the request ID,
+// remote IP, status, matched Group and Profile, and durations as fields.
+// Requests keep a request ID propagated by the client (e.g. a proxy), or are
+// given one, which is returned in the X-Request-Id response header. Requests
+// are traced, continuing any trace propagated by the client.
+func (s *Server) logRequest(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		info := &requestInfo{id: requestid.Or(req.Header.Get(requestid.Header))}
+		w.Header().Set(requestid.Header, info.id)
+		rec := &statusRecorder{ResponseWriter: w}
+		if s.bootHistory > 0 && bootEndpoints[req.URL.Path] {
+			rec.hash = sha256.New()
+		}
+		ctx := withRequestInfo(req.Context(), info)
+		ctx = requestid.NewContext(ctx, info.id)
+		if sc, ok := trace.ParseTraceparent(req.Header.Get(trace.TraceparentHeader)); ok {
+			ctx = trace.WithRemoteParent(ctx, sc)
+		}
+		ctx, span := trace.Start(ctx, req.Method+" "+endpointName(req.URL.Path), trace.KindServer)
+		next.ServeHTTP(rec, req.WithContext(ctx))
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		span.SetAttribute("http.method", req.Method)
+		span.SetAttribute("http.target", req.URL.Path)
+		span.SetAttribute("http.status_code", rec.status)
+		span.SetAttribute("matchbox.request_id", info.id)
+		if info.group != "" {
+			span.SetAttribute("matchbox.group", info.group)
+		}
+		if info.profile != "" {
+			span.SetAttribute("matchbox.profile", info.profile)
+		}
+		span.End()
+		s.notifyEvents(req, rec.status, info)
+		s.recordPending(req, rec.status, info)
+		s.recordIgnition(req, rec.status)
+		s.countInstallAttempt(req, rec.status, info)
+		s.recordBoot(req, rec, info)
+		s.trackInstall(req, rec.status)
+		s.publishEvent(req, rec.status, info)
+		s.recordEvent(req, rec.status, info)
+		s.auditRequest(req, rec.status, info)
+		elapsed := time.Since(start)
+		endpoint := endpointName(req.URL.Path)
+		requestsTotal.Inc(endpoint, strconv.Itoa(rec.status))
+		requestDuration.Observe(elapsed.Seconds(), endpoint)
+		if endpoint == "/assets" {
+			assetBytes.Add(float64(rec.bytes))
+		}
+
+		fields := logrus.Fields{
+			"request_id": info.id,
+			"method":     req.Method,
+			"path":       req.URL.Path,
+			"remote_ip":  remoteIP(req),
+			"status":     rec.status,
+			"bytes":      rec.bytes,
+			"duration":   elapsed.Seconds(),
+		}
+		if info.group != "" {
+			fields["group"] = info.group
+		}
+		if info.profile != "" {
+			fields["profile"] = info.profile
+		}
+		if info.render > 0 {
+			fields["render_duration"] = info.render.Seconds()
+		}
+		if span != nil {
+			fields["trace_id"] = span.TraceID()
+		}
+		s.logger.WithFields(fields).Infof("HTTP %s %v", req.Method, s.redactURL(req.URL))
+	}
+	return http.HandlerFunc(fn)
+}
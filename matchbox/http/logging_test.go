@@ -0,0 +1,221 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/requestid"
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+	"github.com/coreos/matchbox/matchbox/trace"
+)
+
+func TestLogRequest(t *testing.T) {
+	store := &fake.FixedStore{
+		Groups:         map[string]*storagepb.Group{fake.Group.Id: fake.Group},
+		Profiles:       map[string]*storagepb.Profile{fake.Group.Profile: fake.Profile},
+		GenericConfigs: map[string]string{fake.Profile.GenericId: "UUID={{.uuid}}"},
+	}
+	logger, hook := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:   server.NewServer(&server.Config{Store: store}),
+		Logger: logger,
+	})
+	h := srv.HTTPHandler()
+	req, _ := http.NewRequest("GET", "/generic?uuid=a1b2c3d4", nil)
+	req.RemoteAddr = "10.1.2.3:51234"
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// assert that:
+	// - the request is logged once served with request fields
+	entry := hook.LastEntry()
+	if assert.NotNil(t, entry) {
+		assert.Equal(t, "HTTP GET /generic?uuid=a1b2c3d4", entry.Message)
+		assert.NotEmpty(t, entry.Data["request_id"])
+		assert.Equal(t, entry.Data["request_id"], w.HeaderMap.Get(requestid.Header))
+		assert.Equal(t, "10.1.2.3", entry.Data["remote_ip"])
+		assert.Equal(t, http.StatusOK, entry.Data["status"])
+		assert.Equal(t, fake.Group.Id, entry.Data["group"])
+		assert.Equal(t, fake.Profile.Id, entry.Data["profile"])
+		assert.Contains(t, entry.Data, "render_duration")
+		assert.Contains(t, entry.Data, "duration")
+	}
+}
+
+func TestLogRequest_NotFound(t *testing.T) {
+	logger, hook := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	h := srv.HTTPHandler()
+	req, _ := http.NewRequest("GET", "/missing", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	entry := hook.LastEntry()
+	if assert.NotNil(t, entry) {
+		assert.Equal(t, http.StatusNotFound, entry.Data["status"])
+		assert.NotContains(t, entry.Data, "group")
+	}
+}
+
+func TestLogRequest_RequestID(t *testing.T) {
+	logger, hook := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	h := srv.HTTPHandler()
+	cases := []struct {
+		header   string
+		expected string
+	}{
+		{"lb-7f3a9c", "lb-7f3a9c"},
+		{"bad id\n", ""},
+	}
+	// assert that:
+	// - request IDs propagated by clients are kept, invalid ones replaced
+	// - the request ID is returned in the header, problem, and log line
+	for _, c := range cases {
+		req, _ := http.NewRequest("GET", "/missing", nil)
+		req.Header.Set(requestid.Header, c.header)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		id := w.HeaderMap.Get(requestid.Header)
+		if c.expected != "" {
+			assert.Equal(t, c.expected, id)
+		} else {
+			assert.True(t, requestid.Valid(id))
+			assert.NotEqual(t, c.header, id)
+		}
+		assert.Equal(t, id, decodeProblem(t, w).RequestID)
+		assert.Equal(t, id, hook.LastEntry().Data["request_id"])
+	}
+}
+
+// spanRecorder is a trace.Exporter which records span names.
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*trace.Span
+}
+
+func (r *spanRecorder) ExportSpan(span *trace.Span) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, span)
+}
+
+func TestLogRequest_Traced(t *testing.T) {
+	rec := &spanRecorder{}
+	trace.SetTracer(trace.NewTracer(rec))
+	defer trace.SetTracer(nil)
+	store := &fake.FixedStore{
+		Groups:         map[string]*storagepb.Group{fake.Group.Id: fake.Group},
+		Profiles:       map[string]*storagepb.Profile{fake.Group.Profile: fake.Profile},
+		GenericConfigs: map[string]string{fake.Profile.GenericId: "UUID={{.uuid}}"},
+	}
+	logger, hook := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:   server.NewServer(&server.Config{Store: store}),
+		Logger: logger,
+	})
+	h := srv.HTTPHandler()
+	req, _ := http.NewRequest("GET", "/generic?uuid=a1b2c3d4", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	// assert that:
+	// - the request continues the client's trace and the log has the trace_id
+	// - matcher, store, and render spans are children in the trace
+	entry := hook.LastEntry()
+	if assert.NotNil(t, entry) {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", entry.Data["trace_id"])
+	}
+	var names []string
+	for _, span := range rec.spans {
+		names = append(names, span.Name)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.TraceID())
+	}
+	assert.Contains(t, names, "GET /generic")
+	assert.Contains(t, names, "matcher.SelectGroup")
+	assert.Contains(t, names, "store.GroupList")
+	assert.Contains(t, names, "store.GenericGet")
+	assert.Contains(t, names, "render.generic")
+}
+
+func TestObserveRender_Slow(t *testing.T) {
+	store := &fake.FixedStore{
+		Groups:         map[string]*storagepb.Group{fake.Group.Id: fake.Group},
+		Profiles:       map[string]*storagepb.Profile{fake.Group.Profile: fake.Profile},
+		GenericConfigs: map[string]string{fake.Profile.GenericId: "UUID={{.uuid}}"},
+	}
+	logger, hook := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:                server.NewServer(&server.Config{Store: store}),
+		Logger:              logger,
+		SlowRenderThreshold: time.Nanosecond,
+	})
+	slow := slowRenders.Value("generic", fake.Profile.Id, fake.Profile.GenericId)
+	renders := renderDuration.Count("generic", fake.Profile.Id, fake.Profile.GenericId)
+	req, _ := http.NewRequest("GET", "/generic?uuid=a1b2c3d4", nil)
+	srv.HTTPHandler().ServeHTTP(httptest.NewRecorder(), req)
+
+	// assert that:
+	// - render latency is observed per Profile and template
+	// - renders over the threshold are counted and logged with fields
+	assert.Equal(t, renders+1, renderDuration.Count("generic", fake.Profile.Id, fake.Profile.GenericId))
+	assert.Equal(t, slow+1, slowRenders.Value("generic", fake.Profile.Id, fake.Profile.GenericId))
+	var warning *logrus.Entry
+	for _, entry := range hook.Entries {
+		if entry.Message == "slow template render" {
+			warning = entry
+		}
+	}
+	if assert.NotNil(t, warning) {
+		assert.Equal(t, logrus.WarnLevel, warning.Level)
+		assert.Equal(t, fake.Profile.GenericId, warning.Data["template"])
+		assert.Equal(t, fake.Profile.Id, warning.Data["profile"])
+		assert.Equal(t, fake.Group.Id, warning.Data["group"])
+	}
+}
+
+// readerFromRecorder records whether a response was copied with ReadFrom.
+type readerFromRecorder struct {
+	*httptest.ResponseRecorder
+	readFrom bool
+}
+
+func (r *readerFromRecorder) ReadFrom(src io.Reader) (int64, error) {
+	r.readFrom = true
+	return io.Copy(r.ResponseRecorder, src)
+}
+
+func TestStatusRecorder_ReadFrom(t *testing.T) {
+	w := &readerFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	rec := &statusRecorder{ResponseWriter: w}
+	n, err := io.Copy(rec, io.LimitReader(strings.NewReader("kernel"), 6))
+	// assert that:
+	// - copies are passed to the ResponseWriter's ReadFrom (e.g. sendfile)
+	// - copied bytes and the status are recorded
+	assert.Nil(t, err)
+	assert.True(t, w.readFrom)
+	assert.Equal(t, int64(6), n)
+	assert.Equal(t, int64(6), rec.bytes)
+	assert.Equal(t, http.StatusOK, rec.status)
+	assert.Equal(t, "kernel", w.Body.String())
+
+	// - ResponseWriters without ReadFrom are copied to with a buffer
+	plain := httptest.NewRecorder()
+	rec = &statusRecorder{ResponseWriter: plain}
+	n, err = io.Copy(rec, io.LimitReader(strings.NewReader("initrd"), 6))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(6), rec.bytes)
+	assert.Equal(t, "initrd", plain.Body.String())
+}
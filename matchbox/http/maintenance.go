@@ -0,0 +1,48 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/server"
+)
+
+// maintenanceHoldInterval is how long (in seconds) machines held in
+// maintenance wait before retrying.
+const maintenanceHoldInterval = 30
+
+// ipxeMaintenanceHold waits, then chains the same iPXE request again, so
+// held machines continue booting once maintenance ends.
+const ipxeMaintenanceHold = `#!ipxe
+echo matchbox is in maintenance, retrying in %d seconds
+sleep %d
+chain --replace %s
+`
+
+// maintenance returns a handler which responds to iPXE requests with a
+// local boot or hold script while the server is in maintenance, regardless
+// of Group matching, and otherwise calls the next handler.
+func (s *Server) maintenance(core server.Server, next ContextHandler) ContextHandler {
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		mode := core.MaintenanceGet(ctx)
+		if mode == "" {
+			next.ServeHTTP(ctx, w, req)
+			return
+		}
+		s.logger.WithFields(logrus.Fields{
+			"labels": s.redactor.Labels(labelsFromRequest(nil, req)),
+			"mode":   mode,
+		}).Debug("Server is in maintenance")
+		maintenanceBoots.Inc(mode)
+		switch mode {
+		case server.MaintenanceHold:
+			fmt.Fprintf(w, ipxeMaintenanceHold, maintenanceHoldInterval, maintenanceHoldInterval, req.URL.RequestURI())
+		default:
+			fmt.Fprint(w, ipxeLocalBoot)
+		}
+	}
+	return ContextHandlerFunc(fn)
+}
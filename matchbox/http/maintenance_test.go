@@ -0,0 +1,51 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"context"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestMaintenance(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups[fake.Group.Id] = fake.Group
+	store.Profiles[fake.Profile.Id] = fake.Profile
+	logger, _ := logtest.NewNullLogger()
+	core := server.NewServer(&server.Config{Store: store, Maintenance: server.MaintenanceLocal})
+	srv := NewServer(&Config{Core: core, Logger: logger, InstallAttempts: 3})
+	h := srv.HTTPHandler()
+	boot := func() string {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/ipxe?uuid=a1b2c3d4&mac=52-54-00-89-d8-10", nil)
+		h.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	// assert that:
+	// - machines boot from local disk in local maintenance mode
+	// - machines are held with a script which retries the request in hold
+	// maintenance mode
+	// - maintenance boots aren't counted as install attempts
+	// - machines are served their Profile once maintenance ends
+	assert.Equal(t, ipxeLocalBoot, boot())
+	_, err := core.MaintenanceSet(context.Background(), &pb.MaintenanceSetRequest{Mode: server.MaintenanceHold})
+	assert.Nil(t, err)
+	script := boot()
+	assert.Contains(t, script, "sleep 30")
+	assert.Contains(t, script, "chain --replace /ipxe?uuid=a1b2c3d4&mac=52-54-00-89-d8-10\n")
+	assert.NotContains(t, store.Machines, "a1b2c3d4")
+
+	_, err = core.MaintenanceSet(context.Background(), &pb.MaintenanceSetRequest{})
+	assert.Nil(t, err)
+	assert.Contains(t, boot(), fake.Profile.Boot.Kernel)
+}
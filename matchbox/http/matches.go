@@ -0,0 +1,51 @@
+package http
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// Prefix lengths of the subnets unmatched requests are counted by, which
+// bound the cardinality of the no match metric.
+const (
+	noMatchIPv4Bits = 24
+	noMatchIPv6Bits = 64
+)
+
+// countMatch counts a Group match by Group if enabled, or a request which
+// matched no Group by the requester's subnet and logs its labels, so machines
+// which silently fail to match are visible.
+func (s *Server) countMatch(req *http.Request, attrs map[string]string, group *storagepb.Group, err error) {
+	if group != nil && s.groupMetrics {
+		groupMatchesByGroup.Inc(group.Id)
+	}
+	if err != server.ErrNoMatchingGroup {
+		return
+	}
+	subnet := requestSubnet(req)
+	noMatchingGroup.Inc(subnet)
+	s.logger.WithFields(logrus.Fields{
+		"labels":   s.redactor.Labels(attrs),
+		"endpoint": req.URL.Path,
+		"subnet":   subnet,
+	}).Warning("Machine matched no group")
+}
+
+// requestSubnet returns the subnet of the requester's address (a /24 for
+// IPv4 or a /64 for IPv6), or "unknown" for other addresses (e.g. unix
+// sockets).
+func requestSubnet(req *http.Request) string {
+	ip := net.ParseIP(remoteIP(req))
+	if ip == nil {
+		return "unknown"
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return (&net.IPNet{IP: ip4.Mask(net.CIDRMask(noMatchIPv4Bits, 32)), Mask: net.CIDRMask(noMatchIPv4Bits, 32)}).String()
+	}
+	return (&net.IPNet{IP: ip.Mask(net.CIDRMask(noMatchIPv6Bits, 128)), Mask: net.CIDRMask(noMatchIPv6Bits, 128)}).String()
+}
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Sirupsen/logrus"
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestCountMatch(t *testing.T) {
+	store := &fake.FixedStore{
+		Groups:   map[string]*storagepb.Group{fake.Group.Id: fake.Group},
+		Profiles: map[string]*storagepb.Profile{fake.Group.Profile: fake.Profile},
+	}
+	logger, hook := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:              server.NewServer(&server.Config{Store: store}),
+		Logger:            logger,
+		GroupMatchMetrics: true,
+	})
+	h := srv.HTTPHandler()
+	matched := groupMatchesByGroup.Value(fake.Group.Id)
+	unmatched := noMatchingGroup.Value("10.1.2.0/24")
+
+	for _, url := range []string{"/ipxe?uuid=a1b2c3d4", "/ipxe?uuid=unknown&mac=52-54-00-89-d8-10", "/metadata?uuid=unknown"} {
+		req, _ := http.NewRequest("GET", url, nil)
+		req.RemoteAddr = "10.1.2.3:51234"
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	// assert that:
+	// - matches are counted by Group
+	// - requests which match no Group are counted by subnet
+	// - the labels of requests which match no Group are logged
+	assert.Equal(t, matched+1, groupMatchesByGroup.Value(fake.Group.Id))
+	assert.Equal(t, unmatched+2, noMatchingGroup.Value("10.1.2.0/24"))
+	var warnings []*logrus.Entry
+	for _, entry := range hook.Entries {
+		if entry.Message == "Machine matched no group" {
+			warnings = append(warnings, entry)
+		}
+	}
+	if assert.Len(t, warnings, 2) {
+		assert.Equal(t, logrus.WarnLevel, warnings[0].Level)
+		assert.Equal(t, "/ipxe", warnings[0].Data["endpoint"])
+		assert.Equal(t, "10.1.2.0/24", warnings[0].Data["subnet"])
+		assert.Equal(t, map[string]string{"uuid": "unknown", "mac": "52:54:00:89:d8:10", "source_ip": "10.1.2.3"}, warnings[0].Data["labels"])
+	}
+}
+
+func TestRequestSubnet(t *testing.T) {
+	cases := []struct {
+		remoteAddr string
+		subnet     string
+	}{
+		{"10.1.2.3:51234", "10.1.2.0/24"},
+		{"[2001:db8:1:2:3::4]:51234", "2001:db8:1:2::/64"},
+		{"@", "unknown"},
+		{"", "unknown"},
+	}
+	for _, c := range cases {
+		req, _ := http.NewRequest("GET", "/ipxe", nil)
+		req.RemoteAddr = c.remoteAddr
+		assert.Equal(t, c.subnet, requestSubnet(req), c.remoteAddr)
+	}
+}
@@ -19,25 +19,29 @@ func (s *Server) metadataHandler() ContextHandler {
 		group, err := groupFromContext(ctx)
 		if err != nil {
 			s.logger.WithFields(logrus.Fields{
-				"labels": labelsFromRequest(nil, req),
+				"labels": s.redactor.Labels(labelsFromRequest(nil, req)),
 			}).Infof("No matching group")
-			http.NotFound(w, req)
+			writeProblem(w, http.StatusNotFound, CodeNoMatchingGroup, "no group matches the machine")
 			return
 		}
 
 		// match was successful
 		s.logger.WithFields(logrus.Fields{
-			"labels": labelsFromRequest(nil, req),
+			"labels": s.redactor.Labels(labelsFromRequest(nil, req)),
 			"group":  group.Id,
 		}).Debug("Matched group metadata")
 
 		// collect data for rendering
-		data, err := collectVariables(req, group)
+		data, err := collectVariables(ctx, req, group)
 		if err != nil {
 			s.logger.Errorf("error collecting variables: %v", err)
-			http.NotFound(w, req)
+			writeProblem(w, http.StatusNotFound, CodeInvalidMetadata, "error collecting template variables")
 			return
 		}
+		// labels, including the machine's facts
+		if labels := labelsFromContext(ctx); labels != nil {
+			data["request"].(map[string]interface{})["labels"] = labels
+		}
 
 		w.Header().Set(contentType, plainContentType)
 		renderAsEnvFile(w, "", data)
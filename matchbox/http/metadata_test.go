@@ -45,6 +45,7 @@ func TestMetadataHandler(t *testing.T) {
 		"REQUEST_QUERY_COUNT": "3",
 		"REQUEST_QUERY_GATE":  "true",
 		"REQUEST_RAW_QUERY":   "mac=52-54-00-a1-9c-ae&foo=bar&count=3&gate=true",
+		"REQUEST_WIPE":        "false",
 	}
 	assert.Equal(t, http.StatusOK, w.Code)
 	// convert response (random order) to map (tests compare in order)
@@ -52,6 +53,28 @@ func TestMetadataHandler(t *testing.T) {
 	assert.Equal(t, plainContentType, w.HeaderMap.Get(contentType))
 }
 
+func TestMetadataHandler_Labels(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	h := srv.metadataHandler()
+	ctx := withGroup(context.Background(), &storagepb.Group{})
+	ctx = withLabels(ctx, map[string]string{"mac": "52:54:00:a1:9c:ae", "ip": "10.0.0.21"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?mac=52-54-00-a1-9c-ae", nil)
+	h.ServeHTTP(ctx, w, req)
+	// assert that:
+	// - labels, including machine facts, are formatted
+	expectedLines := map[string]string{
+		"REQUEST_QUERY_MAC":  "52:54:00:a1:9c:ae",
+		"REQUEST_RAW_QUERY":  "mac=52-54-00-a1-9c-ae",
+		"REQUEST_WIPE":       "false",
+		"REQUEST_LABELS_MAC": "52:54:00:a1:9c:ae",
+		"REQUEST_LABELS_IP":  "10.0.0.21",
+	}
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, expectedLines, metadataToMap(w.Body.String()))
+}
+
 func TestMetadataHandler_MetadataEdgeCases(t *testing.T) {
 	logger, _ := logtest.NewNullLogger()
 	srv := NewServer(&Config{Logger: logger})
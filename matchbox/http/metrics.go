@@ -0,0 +1,99 @@
+package http
+
+import (
+	"strings"
+
+	"github.com/coreos/matchbox/matchbox/metrics"
+)
+
+var (
+	requestsTotal = metrics.NewCounterVec(
+		"matchbox_http_requests_total",
+		"HTTP requests by endpoint and status code.",
+		"endpoint", "code")
+	requestDuration = metrics.NewHistogramVec(
+		"matchbox_http_request_duration_seconds",
+		"HTTP request latency by endpoint.",
+		nil, "endpoint")
+	renderDuration = metrics.NewHistogramVec(
+		"matchbox_render_duration_seconds",
+		"Config template render latency by config type, Profile, and template.",
+		nil, "config", "profile", "template")
+	groupMatches = metrics.NewCounterVec(
+		"matchbox_group_matches_total",
+		"Machine Group matches by result (hit or miss).",
+		"result")
+	groupMatchesByGroup = metrics.NewCounterVec(
+		"matchbox_matched_group_total",
+		"Machine requests matched to a Group, by Group (opt-in, one series per Group).",
+		"group")
+	noMatchingGroup = metrics.NewCounterVec(
+		"matchbox_no_matching_group_total",
+		"Machine requests which matched no Group, by requesting subnet.",
+		"subnet")
+	assetBytes = metrics.NewCounterVec(
+		"matchbox_asset_bytes_total",
+		"Bytes of assets served.")
+	profileBoots = metrics.NewCounterVec(
+		"matchbox_profile_boots_total",
+		"Network boots (iPXE and GRUB configs served) by Profile.",
+		"profile")
+	localBoots = metrics.NewCounterVec(
+		"matchbox_local_boots_total",
+		"iPXE requests by installed machines answered with a local boot script.")
+	maintenanceBoots = metrics.NewCounterVec(
+		"matchbox_maintenance_boots_total",
+		"iPXE requests answered with a maintenance script, by maintenance mode.",
+		"mode")
+	installFailures = metrics.NewCounterVec(
+		"matchbox_install_failures_total",
+		"Machines which reached the install attempt limit and were served the rescue profile.")
+	installsActive = metrics.NewGaugeVec(
+		"matchbox_installs_active",
+		"Machines holding an install slot, by Profile.",
+		"profile")
+	installsThrottled = metrics.NewCounterVec(
+		"matchbox_installs_throttled_total",
+		"iPXE requests answered with a retry script because the install limit was reached, by Profile.",
+		"profile")
+	progressReports = metrics.NewCounterVec(
+		"matchbox_progress_reports_total",
+		"Provisioning step reports by status.",
+		"status")
+	slowRenders = metrics.NewCounterVec(
+		"matchbox_slow_renders_total",
+		"Config template renders slower than the slow render threshold.",
+		"config", "profile", "template")
+	coalescedRequests = metrics.NewCounterVec(
+		"matchbox_coalesced_requests_total",
+		"Requests served by sharing a concurrent identical render.",
+		"endpoint")
+	renderCacheRequests = metrics.NewCounterVec(
+		"matchbox_render_cache_requests_total",
+		"Rendered config cache lookups by config type and result (hit or miss).",
+		"config", "result")
+)
+
+// endpointName returns the endpoint label of a request path: the first path
+// segment, which bounds the cardinality of per-endpoint metrics.
+func endpointName(path string) string {
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)
+	switch parts[0] {
+	case "":
+		return "/"
+	case "boot.ipxe", "boot.ipxe.0", "ipxe", "grub", "pixiecore", "ignition", "cloud", "generic", "metadata", "assets", "metrics", "v1",
+		"ipxe.sig", "grub.sig", "ignition.sig", "cloud.sig", "generic.sig", "metadata.sig", "boot.ipxe.sig", "boot.ipxe.0.sig",
+		"ipxe.asc", "grub.asc", "ignition.asc", "cloud.asc", "generic.asc", "metadata.asc", "boot.ipxe.asc", "boot.ipxe.0.asc",
+		"ipxe.p7s", "grub.p7s", "ignition.p7s", "cloud.p7s", "generic.p7s", "metadata.p7s", "boot.ipxe.p7s", "boot.ipxe.0.p7s":
+		return "/" + parts[0]
+	}
+	return "other"
+}
+
+// matchResult returns the group match metric label for a match error.
+func matchResult(err error) string {
+	if err != nil {
+		return "miss"
+	}
+	return "hit"
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestEndpointName(t *testing.T) {
+	cases := []struct {
+		path     string
+		endpoint string
+	}{
+		{"/", "/"},
+		{"/ipxe", "/ipxe"},
+		{"/ignition.sig", "/ignition.sig"},
+		{"/ipxe.p7s", "/ipxe.p7s"},
+		{"/assets/coreos/1298.7.0/vmlinuz", "/assets"},
+		{"/pixiecore/v1/boot/52:54:00:a1:9c:ae", "/pixiecore"},
+		{"/v1/complete", "/v1"},
+		{"/random/path", "other"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.endpoint, endpointName(c.path))
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	store := &fake.FixedStore{
+		Groups:   map[string]*storagepb.Group{fake.Group.Id: fake.Group},
+		Profiles: map[string]*storagepb.Profile{fake.Group.Profile: fake.Profile},
+	}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:   server.NewServer(&server.Config{Store: store}),
+		Logger: logger,
+	})
+	h := srv.HTTPHandler()
+	requests := requestsTotal.Value("/ipxe", "200")
+	boots := profileBoots.Value(fake.Profile.Id)
+	hits := groupMatches.Value("hit")
+	misses := groupMatches.Value("miss")
+
+	for _, url := range []string{"/ipxe?uuid=a1b2c3d4", "/ipxe?uuid=unknown"} {
+		req, _ := http.NewRequest("GET", url, nil)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	// assert that:
+	// - requests, group matches, and profile boots are counted
+	// - /metrics serves the metrics
+	assert.Equal(t, requests+1, requestsTotal.Value("/ipxe", "200"))
+	assert.Equal(t, boots+1, profileBoots.Value(fake.Profile.Id))
+	assert.Equal(t, hits+1, groupMatches.Value("hit"))
+	assert.Equal(t, misses+1, groupMatches.Value("miss"))
+
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.Contains(w.Body.String(), `matchbox_profile_boots_total{profile="g1h2i3j4"}`))
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"net"
 	"net/http"
@@ -8,13 +9,14 @@ import (
 
 	"github.com/Sirupsen/logrus"
 
+	"github.com/coreos/matchbox/matchbox/acl"
 	"github.com/coreos/matchbox/matchbox/storage/storagepb"
 )
 
-// collectVariables collects group selectors, metadata, and request-scoped
-// query parameters into a single structured map suitable for rendering
-// templates.
-func collectVariables(req *http.Request, group *storagepb.Group) (map[string]interface{}, error) {
+// collectVariables collects group selectors, metadata, request-scoped query
+// parameters, and the machine's labels (including its facts) into a single
+// structured map suitable for rendering templates.
+func collectVariables(ctx context.Context, req *http.Request, group *storagepb.Group) (map[string]interface{}, error) {
 	data := make(map[string]interface{})
 	data["request"] = make(map[string]interface{})
 	if group.Metadata != nil {
@@ -30,11 +32,14 @@ func collectVariables(req *http.Request, group *storagepb.Group) (map[string]int
 	data["request"] = map[string]interface{}{
 		"query":     labelsFromRequest(nil, req),
 		"raw_query": req.URL.RawQuery,
+		"labels":    labelsFromContext(ctx),
+		"wipe":      isWipe(ctx),
 	}
 	return data, nil
 }
 
-// labelsFromRequest returns request query parameters.
+// labelsFromRequest returns request query parameters, with reserved labels
+// (mac, ip, and SMBIOS strings) normalized.
 func labelsFromRequest(logger *logrus.Logger, req *http.Request) map[string]string {
 	values := req.URL.Query()
 	labels := map[string]string{}
@@ -51,6 +56,15 @@ func labelsFromRequest(logger *logrus.Logger, req *http.Request) map[string]stri
 					}).Warningf("ignoring unparseable MAC address: %v", err)
 				}
 			}
+		case "ip":
+			// set ip if and only if it parses, so an unset iPXE setting
+			// doesn't override a leased ip fact
+			if ip := net.ParseIP(values.Get(key)); ip != nil {
+				labels[key] = ip.String()
+			}
+		case "serial", "asset", "manufacturer", "product":
+			// SMBIOS strings are often padded with spaces
+			labels[key] = strings.TrimSpace(values.Get(key))
 		default:
 			// matchers don't use multi-value keys, drop later values
 			labels[key] = values.Get(key)
@@ -59,6 +73,22 @@ func labelsFromRequest(logger *logrus.Logger, req *http.Request) map[string]stri
 	return labels
 }
 
+// sourceLabel is the reserved label of the IP address a request was sent
+// from, which Groups may select by address or CIDR.
+const sourceLabel = "source_ip"
+
+// matchLabels returns the request's query parameter labels and the
+// sourceLabel of its client address, which a query parameter can't
+// override.
+func matchLabels(logger *logrus.Logger, req *http.Request) map[string]string {
+	labels := labelsFromRequest(logger, req)
+	delete(labels, sourceLabel)
+	if ip := acl.HostIP(req.RemoteAddr); ip != nil {
+		labels[sourceLabel] = ip.String()
+	}
+	return labels
+}
+
 // parseMAC wraps net.ParseMAC with logging.
 func parseMAC(s string) (net.HardwareAddr, error) {
 	macAddr, err := net.ParseMAC(s)
@@ -25,6 +25,11 @@ func TestLabelsFromRequest(t *testing.T) {
 		{"http://a.io?UUID=a1b2c3&MAC=52:DA:00:89:d8:10", map[string]string{"UUID": "a1b2c3", "MAC": validMACStr}},
 		// ignore MAC addresses which do not parse
 		{"http://a.io?mac=x:x:x:x:x:x", emptyMap},
+		// normalize IP addresses and ignore those which do not parse
+		{"http://a.io?ip=2001:DB8::5", map[string]string{"ip": "2001:db8::5"}},
+		{"http://a.io?ip=&platform=efi", map[string]string{"platform": "efi"}},
+		// trim SMBIOS strings
+		{"http://a.io?serial=+ABC123++&manufacturer=Dell%20Inc.", map[string]string{"serial": "ABC123", "manufacturer": "Dell Inc."}},
 	}
 	for _, c := range cases {
 		req, err := http.NewRequest("GET", c.urlString, nil)
@@ -32,3 +37,26 @@ func TestLabelsFromRequest(t *testing.T) {
 		assert.Equal(t, c.labels, labelsFromRequest(logger, req))
 	}
 }
+
+func TestMatchLabels(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	cases := []struct {
+		remoteAddr string
+		labels     map[string]string
+	}{
+		{"10.1.2.3:4567", map[string]string{"uuid": "a1b2c3", "source_ip": "10.1.2.3"}},
+		{"[2001:DB8::5]:4567", map[string]string{"uuid": "a1b2c3", "source_ip": "2001:db8::5"}},
+		{"[fe80::5%eth0]:4567", map[string]string{"uuid": "a1b2c3", "source_ip": "fe80::5"}},
+		{"[::ffff:10.1.2.3]:4567", map[string]string{"uuid": "a1b2c3", "source_ip": "10.1.2.3"}},
+		{"", map[string]string{"uuid": "a1b2c3"}},
+	}
+	// assert that:
+	// - the client's IPv4 or IPv6 address is the source_ip label
+	// - the source_ip query parameter can't spoof the label
+	for _, c := range cases {
+		req, err := http.NewRequest("GET", "http://a.io?uuid=a1b2c3&source_ip=192.168.1.1", nil)
+		assert.Nil(t, err)
+		req.RemoteAddr = c.remoteAddr
+		assert.Equal(t, c.labels, matchLabels(logger, req), c.remoteAddr)
+	}
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// recordPending records a machine UUID which was served the holding Profile
+// because it matched no Group as a pending Machine, with the labels it
+// reported, so operators can adopt it into a Group.
+func (s *Server) recordPending(req *http.Request, status int, info *requestInfo) {
+	if status != http.StatusOK || info.group != server.HoldingGroupID {
+		return
+	}
+	if req.URL.Path != "/ipxe" && req.URL.Path != "/grub" {
+		return
+	}
+	labels := labelsFromRequest(nil, req)
+	uuid := labels["uuid"]
+	if uuid == "" {
+		return
+	}
+	pending := false
+	machine, err := s.core.MachineUpdate(req.Context(), uuid, func(machine *storagepb.Machine) (*storagepb.Machine, error) {
+		if machine == nil {
+			machine = &storagepb.Machine{Id: uuid}
+		}
+		pending = machine.State == storagepb.MachinePending
+		if pending && labelsEqual(machine.Labels, labels) {
+			return nil, nil
+		}
+		machine.Labels = labels
+		machine.State = storagepb.MachinePending
+		return machine, nil
+	})
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"uuid": uuid,
+		}).Errorf("error recording pending machine: %v", err)
+		return
+	}
+	if machine != nil && !pending {
+		s.logger.WithFields(logrus.Fields{
+			"labels":  s.redactor.Labels(labels),
+			"profile": info.profile,
+		}).Infof("Machine %s matched no group, serving the holding profile until it is adopted", uuid)
+	}
+}
+
+// labelsEqual returns true if two label sets are identical.
+func labelsEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
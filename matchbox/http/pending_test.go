@@ -0,0 +1,54 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestPendingMachines(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups[fake.Group.Id] = fake.Group
+	store.Profiles[fake.Profile.Id] = fake.Profile
+	store.Profiles["holding"] = &storagepb.Profile{
+		Id:   "holding",
+		Boot: &storagepb.NetBoot{Kernel: "/holding/vmlinuz"},
+	}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:            server.NewServer(&server.Config{Store: store, InstallAttempts: 2, RescueProfile: "holding", HoldingProfile: "holding"}),
+		Logger:          logger,
+		InstallAttempts: 2,
+	})
+	h := srv.HTTPHandler()
+	boot := func(query string) string {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/ipxe?"+query, nil)
+		h.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	// assert that:
+	// - machines which match no Group are served the holding Profile and
+	// recorded as pending with their labels
+	// - holding boots are not install attempts
+	// - machines which match a Group are not recorded as pending
+	assert.Contains(t, boot("uuid=e5f6a7b8&serial=x1"), "/holding/vmlinuz")
+	assert.Contains(t, boot("uuid=e5f6a7b8&serial=x1"), "/holding/vmlinuz")
+	assert.Contains(t, boot("uuid=e5f6a7b8&serial=x1"), "/holding/vmlinuz")
+	if machine, ok := store.Machines["e5f6a7b8"]; assert.True(t, ok) {
+		assert.Equal(t, storagepb.MachinePending, machine.State)
+		assert.Equal(t, "x1", machine.Labels["serial"])
+		assert.Equal(t, int32(0), machine.Attempts)
+	}
+	assert.NotContains(t, boot("uuid=a1b2c3d4"), "/holding/vmlinuz")
+	assert.NotEqual(t, storagepb.MachinePending, store.Machines["a1b2c3d4"].State)
+}
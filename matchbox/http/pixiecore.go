@@ -1,12 +1,14 @@
 package http
 
 import (
+	"fmt"
 	"net/http"
 	"path/filepath"
 
 	"context"
 	"github.com/Sirupsen/logrus"
 
+	"github.com/coreos/matchbox/matchbox/deprecation"
 	"github.com/coreos/matchbox/matchbox/server"
 	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
 )
@@ -20,7 +22,7 @@ func (s *Server) pixiecoreHandler(core server.Server) ContextHandler {
 		macAddr, err := parseMAC(filepath.Base(req.URL.Path))
 		if err != nil {
 			s.logger.Errorf("unparseable MAC address: %v", err)
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			writeProblem(w, http.StatusBadRequest, CodeInvalidRequest, err.Error())
 			return
 		}
 		attrs := map[string]string{"mac": macAddr.String()}
@@ -30,17 +32,18 @@ func (s *Server) pixiecoreHandler(core server.Server) ContextHandler {
 			s.logger.WithFields(logrus.Fields{
 				"label": macAddr,
 			}).Infof("No matching group")
-			http.NotFound(w, req)
+			writeProblem(w, http.StatusNotFound, CodeNoMatchingGroup, "no group matches the machine")
 			return
 		}
 
+		ctx = server.WithProfileVersion(ctx, group)
 		profile, err := core.ProfileGet(ctx, &pb.ProfileGetRequest{Id: group.Profile})
 		if err != nil {
 			s.logger.WithFields(logrus.Fields{
 				"label": macAddr,
 				"group": group.Id,
 			}).Infof("No profile named: %s", group.Profile)
-			http.NotFound(w, req)
+			writeProblem(w, http.StatusNotFound, CodeProfileNotFound, fmt.Sprintf("profile %q not found", group.Profile))
 			return
 		}
 
@@ -51,6 +54,7 @@ func (s *Server) pixiecoreHandler(core server.Server) ContextHandler {
 			"profile": profile.Id,
 		}).Debug("Matched a Pixiecore config")
 
+		s.warn(w, append(deprecation.Group(group), deprecation.Profile(profile)...))
 		s.renderJSON(w, profile.Boot)
 	}
 	return ContextHandlerFunc(fn)
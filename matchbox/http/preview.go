@@ -0,0 +1,246 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/coreos/ignition/config/validate/report"
+
+	"github.com/coreos/matchbox/matchbox/manifest"
+	"github.com/coreos/matchbox/matchbox/sealed"
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// Possible preview errors
+var (
+	ErrUnknownConfig   = errors.New("http: config must be ipxe, ignition, cloud, or generic")
+	ErrUnknownTemplate = errors.New("http: template config must be ignition, cloud, or generic")
+)
+
+// Render renders the config a machine with the given labels would receive,
+// for previews. A named Profile is rendered in place of the matching Group's
+// Profile and vars override Group metadata. Unlike boot requests, Render does
+// not record boots, notify webhooks, or require Ignition tokens.
+func (s *Server) Render(ctx context.Context, req *pb.RenderRequest) (*pb.RenderResponse, error) {
+	// a request as the machine would make it, for request variables
+	query := url.Values{}
+	for key, value := range req.Labels {
+		query.Set(key, value)
+	}
+	httpReq := &http.Request{
+		Method: "GET",
+		URL:    &url.URL{Path: "/" + req.Config, RawQuery: query.Encode()},
+	}
+	labels := s.core.MachineLabels(ctx, labelsFromRequest(s.logger, httpReq))
+
+	resp := &pb.RenderResponse{}
+	group, err := s.core.SelectGroup(ctx, &pb.SelectGroupRequest{Labels: labels})
+	if err == nil {
+		resp.Group = group.Id
+		group = group.Copy()
+	} else if req.Profile != "" {
+		group = &storagepb.Group{}
+	} else {
+		return nil, err
+	}
+	if req.Profile != "" {
+		group.Profile = req.Profile
+		group.ProfileVersion = 0
+	}
+	if group, err = s.defaults.Merge(group); err != nil {
+		return nil, err
+	}
+	if group, err = s.sources.Merge(ctx, group, labels); err != nil {
+		return nil, err
+	}
+	if err := mergeVars(group, req.Vars); err != nil {
+		return nil, err
+	}
+
+	ctx = server.WithProfileVersion(ctx, group)
+	profile, err := s.core.ProfileGet(ctx, &pb.ProfileGetRequest{Id: group.Profile})
+	if err != nil {
+		return nil, server.ErrNoMatchingProfile
+	}
+	resp.Profile = profile.Id
+	// encrypted values are only known once decrypted when rendered
+	if !sealed.MayBeEncrypted(group.Metadata) {
+		if err := profile.ValidateMetadata(group); err != nil {
+			return nil, err
+		}
+	}
+
+	var name string
+	switch req.Config {
+	case "ipxe":
+		name = profile.Id
+		var boot *storagepb.NetBoot
+		if boot, err = s.renderBoot(withPreview(ctx), httpReq, group, profile.Boot); err != nil {
+			break
+		}
+		resp.Config, err = renderIPXE(boot, s.imageSigner != nil)
+	case "ignition":
+		name = profile.IgnitionId
+		if storagepb.IsIgnitionURL(name) {
+			resp.Config, err = s.remoteIgnition(withPreview(ctx), s.core, httpReq, group, profile)
+			break
+		}
+		var contents string
+		if contents, err = s.core.IgnitionGet(ctx, name); err != nil {
+			break
+		}
+		if isIgnition(name) {
+			resp.Config = []byte(contents)
+			break
+		}
+		resp.Config, err = s.renderIgnition(withPreview(ctx), s.core, httpReq, group, contents)
+	case "cloud":
+		name = profile.CloudId
+		var contents string
+		if contents, err = s.core.CloudGet(ctx, name); err != nil {
+			break
+		}
+		resp.Config, err = s.renderCloud(withPreview(ctx), httpReq, group, contents)
+	case "generic":
+		name = profile.GenericId
+		var contents string
+		if contents, err = s.core.GenericGet(ctx, name); err != nil {
+			break
+		}
+		resp.Config, err = s.renderGeneric(withPreview(ctx), httpReq, group, contents)
+	default:
+		return nil, ErrUnknownConfig
+	}
+	if rerr, ok := err.(*reportError); ok {
+		return nil, fmt.Errorf("error rendering %s template %s:\n%s", req.Config, name, rerr.report.String())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// RenderTemplate validates template contents, which need not be saved, and
+// renders them with the metadata of a Group, for template editors. Syntax
+// errors and invalid configs are returned as problems with their position,
+// rather than as errors. Like Render, it has no side effects.
+func (s *Server) RenderTemplate(ctx context.Context, req *pb.RenderTemplateRequest) (*pb.RenderTemplateResponse, error) {
+	if req.Config != manifest.KindIgnition && req.Config != manifest.KindCloud && req.Config != manifest.KindGeneric {
+		return nil, ErrUnknownTemplate
+	}
+	contents := string(req.Template)
+	resp := &pb.RenderTemplateResponse{}
+	fatal := false
+	for _, problem := range manifest.LintTemplate(req.Config, req.Name, req.Template) {
+		fatal = fatal || problem.Kind == manifest.ProblemError
+		resp.Problems = append(resp.Problems, &pb.TemplateProblem{
+			Kind:      problem.Kind,
+			Line:      int32(problem.Line),
+			Column:    int32(problem.Column),
+			Message:   problem.Message,
+			Highlight: highlight(contents, problem.Line, problem.Column),
+		})
+	}
+	if fatal {
+		return resp, nil
+	}
+
+	group := &storagepb.Group{}
+	if req.Group != "" {
+		stored, err := s.core.GroupGet(ctx, &pb.GroupGetRequest{Id: req.Group})
+		if err != nil {
+			return nil, err
+		}
+		group = stored.Copy()
+	}
+	// a request as a machine of the Group would make it
+	labels := req.Labels
+	if len(labels) == 0 {
+		labels = group.Selector
+	}
+	query := url.Values{}
+	for key, value := range labels {
+		query.Set(key, value)
+	}
+	httpReq := &http.Request{
+		Method: "GET",
+		URL:    &url.URL{Path: "/" + req.Config, RawQuery: query.Encode()},
+	}
+	labels = s.core.MachineLabels(ctx, labelsFromRequest(s.logger, httpReq))
+	group, err := s.defaults.Merge(group)
+	if err != nil {
+		return nil, err
+	}
+	if group, err = s.sources.Merge(ctx, group, labels); err != nil {
+		return nil, err
+	}
+	if err := mergeVars(group, req.Vars); err != nil {
+		return nil, err
+	}
+
+	ctx = withPreview(ctx)
+	switch req.Config {
+	case manifest.KindIgnition:
+		if isIgnition(req.Name) {
+			resp.Config = req.Template
+			break
+		}
+		resp.Config, err = s.renderIgnition(ctx, s.core, httpReq, group, contents)
+	case manifest.KindCloud:
+		resp.Config, err = s.renderCloud(ctx, httpReq, group, contents)
+	case manifest.KindGeneric:
+		resp.Config, err = s.renderGeneric(ctx, httpReq, group, contents)
+	}
+	if rerr, ok := err.(*reportError); ok {
+		resp.Problems = append(resp.Problems, reportProblems(rerr.report)...)
+		return resp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// reportProblems converts the entries of a render report to template
+// problems.
+func reportProblems(r report.Report) []*pb.TemplateProblem {
+	problems := make([]*pb.TemplateProblem, len(r.Entries))
+	for i, entry := range r.Entries {
+		problems[i] = &pb.TemplateProblem{
+			Kind:      entry.Kind.String(),
+			Line:      int32(entry.Line),
+			Column:    int32(entry.Column),
+			Message:   entry.Message,
+			Highlight: entry.Highlight,
+		}
+	}
+	return problems
+}
+
+// mergeVars sets the given vars in the Group's metadata.
+func mergeVars(group *storagepb.Group, vars map[string]string) error {
+	if len(vars) == 0 {
+		return nil
+	}
+	metadata := make(map[string]interface{})
+	if group.Metadata != nil {
+		if err := json.Unmarshal(group.Metadata, &metadata); err != nil {
+			return err
+		}
+	}
+	for key, value := range vars {
+		metadata[key] = value
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	group.Metadata = data
+	return nil
+}
@@ -0,0 +1,195 @@
+package http
+
+import (
+	"testing"
+
+	"context"
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func newPreviewServer(store *fake.FixedStore) *Server {
+	logger, _ := logtest.NewNullLogger()
+	core := server.NewServer(&server.Config{Store: store})
+	return NewServer(&Config{Core: core, Logger: logger})
+}
+
+func TestRender(t *testing.T) {
+	store := &fake.FixedStore{
+		Groups:         map[string]*storagepb.Group{fake.Group.Id: fake.Group},
+		Profiles:       map[string]*storagepb.Profile{fake.Group.Profile: fake.Profile},
+		GenericConfigs: map[string]string{fake.Profile.GenericId: "{{.uuid}} {{.service_name}} {{.request.query.mac}}"},
+	}
+	srv := newPreviewServer(store)
+	req := &pb.RenderRequest{
+		Config: "generic",
+		Labels: map[string]string{"uuid": "a1b2c3d4", "mac": "52-54-00-89-d8-10"},
+		Vars:   map[string]string{"service_name": "etcd3"},
+	}
+	resp, err := srv.Render(context.Background(), req)
+	// assert that:
+	// - the matching Group's Profile is rendered with labels as query variables
+	// - vars override Group metadata without modifying the stored Group
+	if assert.Nil(t, err) {
+		assert.Equal(t, fake.Group.Id, resp.Group)
+		assert.Equal(t, fake.Profile.Id, resp.Profile)
+		assert.Equal(t, "a1b2c3d4 etcd3 52:54:00:89:d8:10", string(resp.Config))
+	}
+	assert.Equal(t, `{"pod_network":"10.2.0.0/16","service_name":"etcd2"}`, string(fake.Group.Metadata))
+
+	resp, err = srv.Render(context.Background(), &pb.RenderRequest{Config: "ipxe", Labels: req.Labels})
+	if assert.Nil(t, err) {
+		expected := "#!ipxe\nkernel /image/kernel a=b c\ninitrd /image/initrd_a /image/initrd_b \nboot\n"
+		assert.Equal(t, expected, string(resp.Config))
+	}
+}
+
+func TestRender_KubeadmToken(t *testing.T) {
+	profile := &storagepb.Profile{Id: fake.Group.Profile, IgnitionId: "join.yaml"}
+	store := &fake.FixedStore{
+		Groups:          map[string]*storagepb.Group{fake.Group.Id: fake.Group},
+		Profiles:        map[string]*storagepb.Profile{profile.Id: profile},
+		IgnitionConfigs: map[string]string{"join.yaml": "systemd:\n  units:\n    - name: {{ kubeadmToken }}.service\n"},
+	}
+	srv := newPreviewServer(store)
+	resp, err := srv.Render(context.Background(), &pb.RenderRequest{
+		Config: "ignition",
+		Labels: map[string]string{"uuid": "a1b2c3d4"},
+	})
+	// assert that:
+	// - previews render a placeholder rather than minting bootstrap tokens
+	if assert.Nil(t, err) {
+		assert.Contains(t, string(resp.Config), previewBootstrapToken+".service")
+	}
+}
+
+func TestRender_Profile(t *testing.T) {
+	other := &storagepb.Profile{Id: "other", GenericId: "other.tmpl"}
+	store := &fake.FixedStore{
+		Profiles:       map[string]*storagepb.Profile{other.Id: other},
+		GenericConfigs: map[string]string{"other.tmpl": "hello {{.name}}"},
+	}
+	srv := newPreviewServer(store)
+	req := &pb.RenderRequest{
+		Config:  "generic",
+		Profile: "other",
+		Vars:    map[string]string{"name": "world"},
+	}
+	resp, err := srv.Render(context.Background(), req)
+	// assert that:
+	// - a named Profile is rendered even without a matching Group
+	if assert.Nil(t, err) {
+		assert.Equal(t, "", resp.Group)
+		assert.Equal(t, "other", resp.Profile)
+		assert.Equal(t, "hello world", string(resp.Config))
+	}
+}
+
+func TestRender_Errors(t *testing.T) {
+	store := &fake.FixedStore{
+		Groups:         map[string]*storagepb.Group{fake.Group.Id: fake.Group},
+		Profiles:       map[string]*storagepb.Profile{fake.Group.Profile: fake.Profile},
+		GenericConfigs: map[string]string{fake.Profile.GenericId: "{{.missing}}"},
+	}
+	srv := newPreviewServer(store)
+	labels := map[string]string{"uuid": "a1b2c3d4"}
+
+	_, err := srv.Render(context.Background(), &pb.RenderRequest{Config: "ipxe"})
+	assert.Equal(t, server.ErrNoMatchingGroup, err)
+	_, err = srv.Render(context.Background(), &pb.RenderRequest{Config: "ipxe", Profile: "missing"})
+	assert.Equal(t, server.ErrNoMatchingProfile, err)
+	_, err = srv.Render(context.Background(), &pb.RenderRequest{Config: "grub", Labels: labels})
+	assert.Equal(t, ErrUnknownConfig, err)
+	_, err = srv.Render(context.Background(), &pb.RenderRequest{Config: "generic", Labels: labels})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "error rendering generic template generic.tmpl:")
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	store := &fake.FixedStore{
+		Groups: map[string]*storagepb.Group{fake.Group.Id: fake.Group},
+	}
+	srv := newPreviewServer(store)
+	ctx := context.Background()
+
+	// assert that:
+	// - unsaved templates are rendered with the Group's metadata, and its
+	// selectors as labels
+	// - vars override Group metadata
+	resp, err := srv.RenderTemplate(ctx, &pb.RenderTemplateRequest{
+		Config:   "generic",
+		Name:     "new.tmpl",
+		Template: []byte("{{.uuid}} {{.service_name}} {{.request.query.uuid}}"),
+		Group:    fake.Group.Id,
+	})
+	if assert.Nil(t, err) {
+		assert.Empty(t, resp.Problems)
+		assert.Equal(t, "a1b2c3d4 etcd2 a1b2c3d4", string(resp.Config))
+	}
+	resp, err = srv.RenderTemplate(ctx, &pb.RenderTemplateRequest{
+		Config:   "ignition",
+		Name:     "new.yaml",
+		Template: []byte("systemd:\n  units:\n    - name: {{.service_name}}.service\n      enable: true\n"),
+		Group:    fake.Group.Id,
+		Vars:     map[string]string{"service_name": "etcd3"},
+	})
+	if assert.Nil(t, err) {
+		assert.Empty(t, resp.Problems)
+		assert.Contains(t, string(resp.Config), `"name":"etcd3.service"`)
+	}
+}
+
+func TestRenderTemplate_Problems(t *testing.T) {
+	store := &fake.FixedStore{
+		Groups: map[string]*storagepb.Group{fake.Group.Id: fake.Group},
+	}
+	srv := newPreviewServer(store)
+	ctx := context.Background()
+	render := func(config, name, template string) *pb.RenderTemplateResponse {
+		resp, err := srv.RenderTemplate(ctx, &pb.RenderTemplateRequest{
+			Config:   config,
+			Name:     name,
+			Template: []byte(template),
+			Group:    fake.Group.Id,
+		})
+		assert.Nil(t, err)
+		return resp
+	}
+
+	// assert that:
+	// - syntax errors are problems with their line and a highlight
+	// - execution errors are problems with their line and column
+	// - invalid rendered configs are problems
+	// - unknown configs and Groups are errors
+	resp := render("generic", "bad.tmpl", "line one\n{{if .uuid}}\n")
+	if assert.Len(t, resp.Problems, 1) {
+		assert.Equal(t, "error", resp.Problems[0].Kind)
+		assert.Equal(t, int32(3), resp.Problems[0].Line)
+		assert.Nil(t, resp.Config)
+	}
+	resp = render("generic", "missing.tmpl", "line one\nkey: {{.missing}}\n")
+	if assert.Len(t, resp.Problems, 1) {
+		assert.Equal(t, int32(2), resp.Problems[0].Line)
+		assert.Equal(t, int32(8), resp.Problems[0].Column)
+		assert.Contains(t, resp.Problems[0].Highlight, "key: {{.missing}}")
+	}
+	resp = render("ignition", "bad.yaml", "systemd:\n  units:\n    - name: {{.uuid}}.service\n      enable: 1\n")
+	if assert.NotEmpty(t, resp.Problems) {
+		assert.Equal(t, "error", resp.Problems[0].Kind)
+		assert.Contains(t, resp.Problems[0].Highlight, "enable: 1")
+		assert.Equal(t, int32(4), resp.Problems[0].Line)
+	}
+	resp = render("cloud", "bad.yaml", "not a cloud-config")
+	assert.NotEmpty(t, resp.Problems)
+
+	_, err := srv.RenderTemplate(ctx, &pb.RenderTemplateRequest{Config: "ipxe"})
+	assert.Equal(t, ErrUnknownTemplate, err)
+	_, err = srv.RenderTemplate(ctx, &pb.RenderTemplateRequest{Config: "generic", Group: "missing"})
+	assert.Error(t, err)
+}
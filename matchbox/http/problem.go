@@ -0,0 +1,73 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/coreos/matchbox/matchbox/requestid"
+)
+
+// Problem codes identify the error of a problem response, so clients can
+// branch on errors without parsing messages.
+const (
+	CodeNotFound         = "not_found"
+	CodeNoMatchingGroup  = "no_matching_group"
+	CodeProfileNotFound  = "profile_not_found"
+	CodeTemplateNotFound = "template_not_found"
+	CodeTemplateError    = "template_error"
+	CodeInvalidMetadata  = "invalid_metadata"
+	CodeMetadataSource   = "metadata_source_error"
+	CodeDecryptionFailed = "decryption_failed"
+	CodeInvalidRequest   = "invalid_request"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodePayloadTooLarge  = "payload_too_large"
+	CodeForbidden        = "forbidden"
+	CodeMissingHostname  = "missing_hostname"
+	CodeRateLimited      = "rate_limited"
+	CodeUpstreamError    = "upstream_error"
+	CodeInternalError    = "internal_error"
+)
+
+// A Problem is an RFC 7807 problem details response body. Problems have the
+// default "about:blank" type, so the title is the HTTP status text, and are
+// told apart by their code.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	// Detail explains this occurrence of the problem for humans
+	Detail string `json:"detail,omitempty"`
+	// Code is a machine-readable error code (e.g. no_matching_group)
+	Code string `json:"code"`
+	// RequestID identifies the request in matchbox's logs and events
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// writeProblem responds with an application/problem+json body describing an
+// error, with the request ID of the response. The detail may be empty.
+func writeProblem(w http.ResponseWriter, status int, code, detail string) {
+	w.Header().Set(contentType, problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	// details quote templates, which shouldn't be HTML escaped
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.Encode(&Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Code:      code,
+		RequestID: w.Header().Get(requestid.Header),
+	})
+}
+
+// noProfileCode returns the code of a request without a Profile in the ctx,
+// which is either because no Group matched or the Group's Profile is missing.
+func noProfileCode(ctx context.Context) string {
+	if requestInfoFromContext(ctx).group == "" {
+		return CodeNoMatchingGroup
+	}
+	return CodeProfileNotFound
+}
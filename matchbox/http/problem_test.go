@@ -0,0 +1,55 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/requestid"
+)
+
+// decodeProblem decodes the problem+json body of a recorded response.
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) *Problem {
+	assert.Equal(t, problemContentType, w.HeaderMap.Get(contentType))
+	problem := new(Problem)
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), problem))
+	return problem
+}
+
+func TestWriteProblem(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeProblem(w, http.StatusNotFound, CodeTemplateNotFound, `generic template "<missing>" not found`)
+	// assert that:
+	// - problems are RFC 7807 problem+json with a machine-readable code
+	// - details aren't HTML escaped
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "nosniff", w.HeaderMap.Get("X-Content-Type-Options"))
+	assert.Equal(t, `{"type":"about:blank","title":"Not Found","status":404,"detail":"generic template \"<missing>\" not found","code":"template_not_found"}`+"\n", w.Body.String())
+	assert.Equal(t, &Problem{
+		Type:   "about:blank",
+		Title:  "Not Found",
+		Status: http.StatusNotFound,
+		Detail: `generic template "<missing>" not found`,
+		Code:   CodeTemplateNotFound,
+	}, decodeProblem(t, w))
+}
+
+func TestWriteProblem_RequestID(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set(requestid.Header, "1a2b3c4d")
+	writeProblem(w, http.StatusNotFound, CodeNoMatchingGroup, "")
+	// assert that problems include the request ID of the response
+	assert.Equal(t, "1a2b3c4d", decodeProblem(t, w).RequestID)
+}
+
+func TestNoProfileCode(t *testing.T) {
+	// assert that requests are told apart by whether a Group matched
+	ctx := withRequestInfo(context.Background(), &requestInfo{})
+	assert.Equal(t, CodeNoMatchingGroup, noProfileCode(ctx))
+	requestInfoFromContext(ctx).group = "worker"
+	assert.Equal(t, CodeProfileNotFound, noProfileCode(ctx))
+}
@@ -0,0 +1,70 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// maxProgressReport limits the size of progress reports (64 KiB).
+const maxProgressReport = 64 << 10
+
+// progressHandler returns a handler that records a provisioning step
+// reported by the machine with the "uuid" query parameter. The request body
+// is a JSON step with a name, status, and optional message.
+func (s *Server) progressHandler(core server.Server) ContextHandler {
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		if req.Method != "POST" {
+			w.Header().Set("Allow", "POST")
+			writeProblem(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "")
+			return
+		}
+		labels := labelsFromRequest(s.logger, req)
+		uuid := labels["uuid"]
+		if uuid == "" {
+			writeProblem(w, http.StatusBadRequest, CodeInvalidRequest, "uuid query parameter is required")
+			return
+		}
+		step := new(storagepb.MachineStep)
+		if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxProgressReport)).Decode(step); err != nil {
+			writeProblem(w, http.StatusBadRequest, CodeInvalidRequest, "invalid progress report")
+			return
+		}
+		if err := step.AssertValid(); err != nil {
+			writeProblem(w, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+			return
+		}
+		step.Time = time.Now().UTC().Format(time.RFC3339)
+
+		_, err := core.MachineUpdate(ctx, uuid, func(machine *storagepb.Machine) (*storagepb.Machine, error) {
+			if machine == nil {
+				machine = &storagepb.Machine{Id: uuid, Labels: labels}
+			}
+			machine.SetProgress(step)
+			return machine, nil
+		})
+		if err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"uuid": uuid,
+			}).Errorf("error recording machine progress: %v", err)
+			writeProblem(w, http.StatusInternalServerError, CodeInternalError, "error recording machine progress")
+			return
+		}
+
+		progressReports.Inc(step.Status)
+		s.logger.WithFields(logrus.Fields{
+			"labels":  s.redactor.Labels(labels),
+			"step":    step.Name,
+			"status":  step.Status,
+			"message": step.Message,
+		}).Infof("Machine %s step %s %s", uuid, step.Name, step.Status)
+		w.WriteHeader(http.StatusNoContent)
+	}
+	return ContextHandlerFunc(fn)
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"context"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestProgressHandler(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Machines["a1b2c3d4"] = &storagepb.Machine{Id: "a1b2c3d4", State: storagepb.MachineBooted}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	c := server.NewServer(&server.Config{Store: store})
+	h := srv.progressHandler(c)
+	report := func(url, body string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", url, strings.NewReader(body))
+		h.ServeHTTP(context.Background(), w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusNoContent, report("/v1/progress?uuid=a1b2c3d4", `{"name":"partition","status":"started"}`))
+	assert.Equal(t, http.StatusNoContent, report("/v1/progress?uuid=a1b2c3d4", `{"name":"install-os","status":"started"}`))
+	assert.Equal(t, http.StatusNoContent, report("/v1/progress?uuid=a1b2c3d4", `{"name":"partition","status":"succeeded","message":"sda"}`))
+	assert.Equal(t, http.StatusNoContent, report("/v1/progress?uuid=b2c3d4e5&mac=52-54-00-a1-9c-ae", `{"name":"partition","status":"failed"}`))
+	// assert that:
+	// - steps are recorded in the order they started, with their latest status
+	// - the Machine state is unchanged
+	// - unknown Machines are recorded with their labels
+	machine := store.Machines["a1b2c3d4"]
+	assert.Equal(t, storagepb.MachineBooted, machine.State)
+	if assert.Len(t, machine.Progress, 2) {
+		assert.Equal(t, "partition", machine.Progress[0].Name)
+		assert.Equal(t, storagepb.StepSucceeded, machine.Progress[0].Status)
+		assert.Equal(t, "sda", machine.Progress[0].Message)
+		assert.NotEmpty(t, machine.Progress[0].Time)
+		assert.Equal(t, "install-os", machine.Progress[1].Name)
+	}
+	machine = store.Machines["b2c3d4e5"]
+	if assert.NotNil(t, machine) {
+		assert.Equal(t, "52:54:00:a1:9c:ae", machine.Labels["mac"])
+		assert.Equal(t, storagepb.StepFailed, machine.Progress[0].Status)
+	}
+}
+
+func TestProgressHandler_BadRequests(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	c := server.NewServer(&server.Config{Store: fake.NewFixedStore()})
+	h := srv.progressHandler(c)
+	cases := []struct {
+		method string
+		url    string
+		body   string
+		code   int
+	}{
+		{"GET", "/v1/progress?uuid=a1b2c3d4", "", http.StatusMethodNotAllowed},
+		{"POST", "/v1/progress", `{"name":"install-os","status":"started"}`, http.StatusBadRequest},
+		{"POST", "/v1/progress?uuid=a1b2c3d4", `not json`, http.StatusBadRequest},
+		{"POST", "/v1/progress?uuid=a1b2c3d4", `{"status":"started"}`, http.StatusBadRequest},
+		{"POST", "/v1/progress?uuid=a1b2c3d4", `{"name":"install-os","status":"done"}`, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(tc.method, tc.url, strings.NewReader(tc.body))
+		h.ServeHTTP(context.Background(), w, req)
+		assert.Equal(t, tc.code, w.Code, tc.body)
+	}
+}
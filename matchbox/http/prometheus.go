@@ -0,0 +1,90 @@
+package http
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"context"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// defaultExporterPort is the port of the Prometheus node exporter.
+const defaultExporterPort = 9100
+
+// invalidLabelChars are characters Prometheus does not allow in label names.
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// targetGroup is a Prometheus HTTP service discovery target group.
+type targetGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels"`
+}
+
+// prometheusSDHandler returns a handler which lists provisioned Machines
+// with an ip fact as Prometheus HTTP service discovery targets on the
+// "port" query parameter (default 9100). Target labels describe each
+// Machine's Group, Profile, and labels with a __meta_matchbox_ prefix, for
+// relabeling.
+func (s *Server) prometheusSDHandler(core server.Server) ContextHandler {
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		port := defaultExporterPort
+		if value := req.URL.Query().Get("port"); value != "" {
+			var err error
+			if port, err = strconv.Atoi(value); err != nil || port < 1 || port > 65535 {
+				writeProblem(w, http.StatusBadRequest, CodeInvalidRequest, "port must be a port number")
+				return
+			}
+		}
+		groups, err := prometheusTargets(ctx, core, port)
+		if err != nil {
+			s.logger.Errorf("error listing Prometheus targets: %v", err)
+			writeProblem(w, http.StatusInternalServerError, CodeInternalError, "error listing Prometheus targets")
+			return
+		}
+		s.renderJSON(w, groups)
+	}
+	return ContextHandlerFunc(fn)
+}
+
+// prometheusTargets returns a target group for each provisioned Machine.
+func prometheusTargets(ctx context.Context, core server.Server, port int) ([]*targetGroup, error) {
+	machines, err := core.MachineList(ctx, &pb.MachineListRequest{})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(machines, func(i, j int) bool { return machines[i].Id < machines[j].Id })
+	groups := []*targetGroup{}
+	for _, machine := range machines {
+		ip := machine.Facts["ip"]
+		if machine.State != storagepb.MachineProvisioned || ip == "" {
+			continue
+		}
+		labels := make(map[string]string)
+		for key, value := range machine.Facts {
+			labels["__meta_matchbox_label_"+invalidLabelChars.ReplaceAllString(key, "_")] = value
+		}
+		for key, value := range machine.Labels {
+			labels["__meta_matchbox_label_"+invalidLabelChars.ReplaceAllString(key, "_")] = value
+		}
+		labels["__meta_matchbox_machine_id"] = machine.Id
+		if hostname := machine.Facts["hostname"]; hostname != "" {
+			labels["__meta_matchbox_hostname"] = hostname
+		}
+		if group := machineGroup(ctx, core, machine); group != nil {
+			labels["__meta_matchbox_group"] = group.Id
+			labels["__meta_matchbox_profile"] = group.Profile
+		}
+		groups = append(groups, &targetGroup{
+			Targets: []string{net.JoinHostPort(ip, fmt.Sprint(port))},
+			Labels:  labels,
+		})
+	}
+	return groups, nil
+}
@@ -0,0 +1,75 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"context"
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestPrometheusSDHandler(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups["etcd"] = &storagepb.Group{
+		Id:       "etcd",
+		Profile:  "etcd-proxy",
+		Selector: map[string]string{"role": "etcd"},
+	}
+	store.Machines["a1b2c3d4"] = &storagepb.Machine{
+		Id:     "a1b2c3d4",
+		Labels: map[string]string{"uuid": "a1b2c3d4", "role": "etcd"},
+		Facts:  map[string]string{"hostname": "node1", "ip": "10.0.0.21", "memory-gib": "192"},
+		State:  storagepb.MachineProvisioned,
+	}
+	store.Machines["fd00::5"] = &storagepb.Machine{
+		Id:    "fd00::5",
+		Facts: map[string]string{"ip": "fd00::5"},
+		State: storagepb.MachineProvisioned,
+	}
+	// not yet provisioned
+	store.Machines["e5f6a7b8"] = &storagepb.Machine{
+		Id:    "e5f6a7b8",
+		Facts: map[string]string{"ip": "10.0.0.22"},
+		State: storagepb.MachineBooted,
+	}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	h := srv.prometheusSDHandler(server.NewServer(&server.Config{Store: store}))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/prometheus/http_sd?port=9101", nil)
+	h.ServeHTTP(context.Background(), w, req)
+	// assert that:
+	// - provisioned Machines with an IP are targets on the port
+	// - targets are labeled with their Group, Profile, labels, and facts
+	expected := `[{"targets":["10.0.0.21:9101"],"labels":{"__meta_matchbox_group":"etcd","__meta_matchbox_hostname":"node1","__meta_matchbox_label_hostname":"node1","__meta_matchbox_label_ip":"10.0.0.21","__meta_matchbox_label_memory_gib":"192","__meta_matchbox_label_role":"etcd","__meta_matchbox_label_uuid":"a1b2c3d4","__meta_matchbox_machine_id":"a1b2c3d4","__meta_matchbox_profile":"etcd-proxy"}},` +
+		`{"targets":["[fd00::5]:9101"],"labels":{"__meta_matchbox_label_ip":"fd00::5","__meta_matchbox_machine_id":"fd00::5"}}]`
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, jsonContentType, w.HeaderMap.Get(contentType))
+	assert.Equal(t, expected, w.Body.String())
+
+	// assert that:
+	// - invalid ports are rejected
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/prometheus/http_sd?port=http", nil)
+	h.ServeHTTP(context.Background(), w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestPrometheusSDHandler_Empty(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	h := srv.prometheusSDHandler(server.NewServer(&server.Config{Store: fake.NewFixedStore()}))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/prometheus/http_sd", nil)
+	h.ServeHTTP(context.Background(), w, req)
+	// assert that:
+	// - no targets is an empty list, not null
+	assert.Equal(t, "[]", w.Body.String())
+}
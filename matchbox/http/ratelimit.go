@@ -0,0 +1,181 @@
+package http
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimit configures token bucket rate limits on boot endpoints. A zero
+// rate disables the corresponding limit.
+type RateLimit struct {
+	// PerIP is the steady state rate (requests/sec) allowed per client IP
+	PerIP float64
+	// PerIPBurst is the number of requests a client IP may burst
+	PerIPBurst int
+	// Global is the steady state rate (requests/sec) allowed across clients
+	Global float64
+	// GlobalBurst is the number of requests which may burst across clients
+	GlobalBurst int
+}
+
+// enabled returns true if any limit is configured.
+func (r *RateLimit) enabled() bool {
+	return r != nil && (r.PerIP > 0 || r.Global > 0)
+}
+
+// tokenBucket holds up to burst tokens and refills at rate tokens per
+// second. Each allowed request takes a token.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int, now time.Time) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now,
+	}
+}
+
+// refill adds tokens accrued since the last refill.
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens += elapsed * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+}
+
+// allow takes a token if one is available.
+func (b *tokenBucket) allow(now time.Time) bool {
+	b.refill(now)
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// refund returns a token taken by a request which was rejected anyway.
+func (b *tokenBucket) refund() {
+	if b.tokens+1 <= b.burst {
+		b.tokens++
+	}
+}
+
+// full returns true if the bucket has refilled completely.
+func (b *tokenBucket) full(now time.Time) bool {
+	b.refill(now)
+	return b.tokens >= b.burst
+}
+
+// sweepInterval is how often idle per IP buckets are discarded.
+const sweepInterval = time.Minute
+
+// rateLimiter enforces a global token bucket and a token bucket per client
+// IP address.
+type rateLimiter struct {
+	mu        sync.Mutex
+	config    RateLimit
+	global    *tokenBucket
+	clients   map[string]*tokenBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func newRateLimiter(config *RateLimit) *rateLimiter {
+	l := &rateLimiter{
+		config:  *config,
+		clients: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+	l.lastSweep = l.now()
+	if config.Global > 0 {
+		l.global = newTokenBucket(config.Global, config.GlobalBurst, l.lastSweep)
+	}
+	return l
+}
+
+// allow returns true if a request from the given client IP is within the
+// configured limits. A request rejected by the global limit isn't charged
+// against the client's own limit.
+func (l *rateLimiter) allow(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.sweep(now)
+
+	var bucket *tokenBucket
+	if l.config.PerIP > 0 {
+		var ok bool
+		bucket, ok = l.clients[ip]
+		if !ok {
+			bucket = newTokenBucket(l.config.PerIP, l.config.PerIPBurst, now)
+			l.clients[ip] = bucket
+		}
+		if !bucket.allow(now) {
+			return false
+		}
+	}
+	if l.global != nil && !l.global.allow(now) {
+		if bucket != nil {
+			bucket.refund()
+		}
+		return false
+	}
+	return true
+}
+
+// sweep discards client buckets which have refilled completely, since they
+// are indistinguishable from new buckets.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	for ip, bucket := range l.clients {
+		if bucket.full(now) {
+			delete(l.clients, ip)
+		}
+	}
+	l.lastSweep = now
+}
+
+// rateLimit wraps an http.Handler and responds with 429 Too Many Requests
+// when a client exceeds the configured rate limits.
+func (s *Server) rateLimit(next http.Handler) http.Handler {
+	if s.limiter == nil {
+		return next
+	}
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ip := remoteIP(req)
+		if !s.limiter.allow(ip) {
+			s.logger.Warningf("rate limit exceeded for %s %v from %s", req.Method, s.redactURL(req.URL), ip)
+			w.Header().Set("Retry-After", "1")
+			writeProblem(w, http.StatusTooManyRequests, CodeRateLimited, "")
+			return
+		}
+		next.ServeHTTP(w, req)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// remoteIP returns the IP address of the client which made the request.
+func remoteIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
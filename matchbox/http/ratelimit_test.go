@@ -0,0 +1,90 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	b := newTokenBucket(1, 2, now)
+	assert.True(t, b.allow(now))
+	assert.True(t, b.allow(now))
+	assert.False(t, b.allow(now))
+	// refills at 1 token per second
+	now = now.Add(time.Second)
+	assert.True(t, b.allow(now))
+	assert.False(t, b.allow(now))
+	// never exceeds the burst size
+	now = now.Add(time.Hour)
+	assert.True(t, b.full(now))
+	assert.True(t, b.allow(now))
+	assert.True(t, b.allow(now))
+	assert.False(t, b.allow(now))
+}
+
+func TestRateLimiter_PerIP(t *testing.T) {
+	l := newRateLimiter(&RateLimit{PerIP: 1, PerIPBurst: 1})
+	now := time.Now()
+	l.now = func() time.Time { return now }
+	assert.True(t, l.allow("10.0.0.1"))
+	assert.False(t, l.allow("10.0.0.1"))
+	// other clients have their own bucket
+	assert.True(t, l.allow("10.0.0.2"))
+	// idle buckets are swept
+	now = now.Add(sweepInterval)
+	assert.True(t, l.allow("10.0.0.3"))
+	assert.Len(t, l.clients, 1)
+}
+
+func TestRateLimiter_Global(t *testing.T) {
+	l := newRateLimiter(&RateLimit{Global: 1, GlobalBurst: 2})
+	now := time.Now()
+	l.now = func() time.Time { return now }
+	assert.True(t, l.allow("10.0.0.1"))
+	assert.True(t, l.allow("10.0.0.2"))
+	assert.False(t, l.allow("10.0.0.3"))
+}
+
+func TestRateLimiter_GlobalRefund(t *testing.T) {
+	l := newRateLimiter(&RateLimit{PerIP: 0.1, PerIPBurst: 1, Global: 1, GlobalBurst: 1})
+	now := time.Now()
+	l.now = func() time.Time { return now }
+	assert.True(t, l.allow("10.0.0.1"))
+	assert.False(t, l.allow("10.0.0.2"))
+	// assert that a client rejected by the global limit keeps its own token
+	now = now.Add(time.Second)
+	assert.True(t, l.allow("10.0.0.2"))
+}
+
+func TestRateLimitHandler(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Logger:    logger,
+		RateLimit: &RateLimit{PerIP: 1, PerIPBurst: 1},
+	})
+	next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {})
+	h := srv.rateLimit(next)
+	req, _ := http.NewRequest("GET", "/ipxe", nil)
+	req.RemoteAddr = "10.0.0.1:4567"
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	// assert that:
+	// - requests beyond the limit receive 429 Too Many Requests
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.HeaderMap.Get("Retry-After"))
+}
+
+func TestRateLimitHandler_Disabled(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	assert.Nil(t, srv.limiter)
+}
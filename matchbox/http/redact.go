@@ -0,0 +1,15 @@
+package http
+
+import (
+	"net/url"
+)
+
+// redactURL returns the URL with sensitive query values redacted, for logs.
+func (s *Server) redactURL(u *url.URL) string {
+	if s.redactor == nil {
+		return u.String()
+	}
+	redacted := *u
+	redacted.RawQuery = s.redactor.Query(u.RawQuery)
+	return redacted.String()
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/audit"
+	"github.com/coreos/matchbox/matchbox/redact"
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestRedactRequest(t *testing.T) {
+	sink := make(entrySink, 10)
+	logger, hook := logtest.NewNullLogger()
+	auditor := audit.NewAuditor(&audit.Config{Sinks: []audit.Sink{sink}, Logger: logger})
+	redactor, err := redact.NewRedactor([]string{"join_token"})
+	assert.Nil(t, err)
+	srv := NewServer(&Config{
+		Core:     server.NewServer(&server.Config{Store: fake.NewFixedStore()}),
+		Logger:   logger,
+		Auditor:  auditor,
+		Redactor: redactor,
+	})
+	req, _ := http.NewRequest("GET", "/ipxe?uuid=a1b2c3d4&join_token=s3cr3t", nil)
+	srv.HTTPHandler().ServeHTTP(httptest.NewRecorder(), req)
+	auditor.Close()
+	// assert that:
+	// - sensitive query values are redacted in the request log
+	// - sensitive labels are redacted in audit entries
+	entry := hook.LastEntry()
+	if assert.NotNil(t, entry) {
+		assert.Equal(t, "HTTP GET /ipxe?uuid=a1b2c3d4&join_token=REDACTED", entry.Message)
+	}
+	for _, entry := range hook.Entries {
+		assert.NotContains(t, entry.Message, "s3cr3t")
+		if labels, ok := entry.Data["labels"].(map[string]string); ok {
+			assert.Equal(t, redact.Placeholder, labels["join_token"])
+		}
+	}
+	if assert.Len(t, sink, 1) {
+		entry := <-sink
+		assert.Equal(t, "a1b2c3d4", entry.Fields["uuid"])
+		assert.Equal(t, redact.Placeholder, entry.Fields["join_token"])
+	}
+}
+
+func TestInventoryHandler_Redacted(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups["workers"] = &storagepb.Group{
+		Id:       "workers",
+		Selector: map[string]string{"role": "worker"},
+		Metadata: []byte(`{"join_token":"abcdef.0123456789abcdef","region":"us"}`),
+	}
+	logger, _ := logtest.NewNullLogger()
+	core := server.NewServer(&server.Config{Store: store})
+	redactor, err := redact.NewRedactor([]string{"join_token"})
+	assert.Nil(t, err)
+	// assert that:
+	// - sensitive metadata is redacted in group vars only with RedactResponses
+	for _, redactResponses := range []bool{false, true} {
+		srv := NewServer(&Config{Core: core, Logger: logger, Redactor: redactor, RedactResponses: redactResponses})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/inventory", nil)
+		srv.inventoryHandler(core).ServeHTTP(context.Background(), w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, !redactResponses, strings.Contains(w.Body.String(), "abcdef.0123456789abcdef"))
+		assert.Equal(t, redactResponses, strings.Contains(w.Body.String(), redact.Placeholder))
+	}
+}
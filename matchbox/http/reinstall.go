@@ -0,0 +1,24 @@
+package http
+
+import (
+	"context"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// wipeRequested returns true if the machine with the "uuid" label is marked
+// to be reinstalled with its disks wiped. Templates see this as the
+// request.wipe variable until the machine is installed again.
+func wipeRequested(ctx context.Context, core server.Server, labels map[string]string) bool {
+	uuid := labels["uuid"]
+	if uuid == "" {
+		return false
+	}
+	machine, err := core.MachineGet(ctx, &pb.MachineGetRequest{Id: uuid})
+	if err != nil {
+		return false
+	}
+	return machine.State == storagepb.MachineReinstall && machine.Wipe
+}
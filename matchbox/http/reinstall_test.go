@@ -0,0 +1,67 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"context"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestSelectGroup_Wipe(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups[fake.Group.Id] = fake.Group
+	store.Machines["a1b2c3d4"] = &storagepb.Machine{Id: "a1b2c3d4", State: storagepb.MachineReinstall, Wipe: true}
+	store.Machines["b2c3d4e5"] = &storagepb.Machine{Id: "b2c3d4e5", State: storagepb.MachineProvisioned, Wipe: true}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	c := server.NewServer(&server.Config{Store: store})
+	next := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		data, err := collectVariables(ctx, req, fake.Group)
+		assert.Nil(t, err)
+		fmt.Fprint(w, data["request"].(map[string]interface{})["wipe"])
+	}
+	h := srv.selectGroup(c, ContextHandlerFunc(next))
+	cases := []struct {
+		url      string
+		expected string
+	}{
+		{"/?uuid=a1b2c3d4", "true"},
+		{"/?uuid=b2c3d4e5", "false"},
+		{"/?uuid=unknown", "false"},
+		{"/?wipe=true", "false"},
+	}
+	// assert that:
+	// - machines marked to be reinstalled with a wipe render request.wipe
+	// - the wipe flag doesn't apply once machines are installed again
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", tc.url, nil)
+		h.ServeHTTP(context.Background(), w, req)
+		assert.Equal(t, tc.expected, w.Body.String(), tc.url)
+	}
+}
+
+func TestCompleteHandler_ClearsWipe(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Machines["a1b2c3d4"] = &storagepb.Machine{Id: "a1b2c3d4", State: storagepb.MachineReinstall, Wipe: true}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger})
+	c := server.NewServer(&server.Config{Store: store})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/complete?uuid=a1b2c3d4", strings.NewReader(""))
+	srv.completeHandler(c).ServeHTTP(context.Background(), w, req)
+	// assert that the wipe flag clears once the machine is reinstalled
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, storagepb.MachineProvisioned, store.Machines["a1b2c3d4"].State)
+	assert.False(t, store.Machines["a1b2c3d4"].Wipe)
+}
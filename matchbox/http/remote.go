@@ -0,0 +1,81 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/Sirupsen/logrus"
+	ignition "github.com/coreos/ignition/config"
+	"github.com/coreos/ignition/config/validate/report"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// snippetError is a failure to render or parse an Ignition snippet.
+type snippetError struct {
+	name   string
+	report report.Report
+}
+
+func (e *snippetError) Error() string {
+	return "ignition snippet " + e.name + ": " + e.report.String()
+}
+
+// serveRemoteIgnition responds with the remote Ignition config of a Profile,
+// with its snippets appended. Configs are compared to conditional requests
+// by content.
+func (s *Server) serveRemoteIgnition(ctx context.Context, core server.Server, w http.ResponseWriter, req *http.Request, group *storagepb.Group, profile *storagepb.Profile) {
+	js, err := s.remoteIgnition(ctx, core, req, group, profile)
+	if serr, ok := err.(*snippetError); ok {
+		s.renderFailed(w, "ignition", serr.name, serr.report)
+		return
+	} else if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"group":   group.Id,
+			"profile": profile.Id,
+		}).Errorf("error fetching remote Ignition config: %v", err)
+		writeProblem(w, http.StatusBadGateway, CodeUpstreamError, "error fetching remote Ignition config")
+		return
+	}
+	if notModified(w, req, contentETag(js)) {
+		return
+	}
+	s.writeJSON(w, js)
+}
+
+// remoteIgnition fetches the remote Ignition config of a Profile and
+// appends its snippets, which are raw Ignition (for .ign/.ignition) or Fuze
+// templates rendered with the request and Group data, in order.
+func (s *Server) remoteIgnition(ctx context.Context, core server.Server, req *http.Request, group *storagepb.Group, profile *storagepb.Profile) ([]byte, error) {
+	js, err := s.remote.Fetch(ctx, profile.IgnitionId)
+	if err != nil || len(profile.IgnitionSnippets) == 0 {
+		return js, err
+	}
+	config, _, err := ignition.Parse(js)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range profile.IgnitionSnippets {
+		contents, err := core.IgnitionGet(ctx, name)
+		if err != nil {
+			return nil, &snippetError{name, report.ReportFromError(err, report.EntryError)}
+		}
+		snippet := []byte(contents)
+		if !isIgnition(name) {
+			snippet, err = s.renderIgnition(ctx, core, req, group, contents)
+			if rerr, ok := err.(*reportError); ok {
+				return nil, &snippetError{name, rerr.report}
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		parsed, rpt, err := ignition.Parse(snippet)
+		if err != nil {
+			return nil, &snippetError{name, rpt}
+		}
+		config = ignition.Append(config, parsed)
+	}
+	return json.Marshal(config)
+}
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"context"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/remote"
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestIgnitionHandler_Remote(t *testing.T) {
+	content := `{"ignition":{"version":"2.0.0"},"systemd":{"units":[{"name":"etcd2.service","enable":true}]}}`
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/node.ign" {
+			http.NotFound(w, req)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	defer upstream.Close()
+	store := &fake.FixedStore{
+		Profiles: map[string]*storagepb.Profile{
+			"remote":   {Id: "remote", IgnitionId: upstream.URL + "/node.ign"},
+			"snippets": {Id: "snippets", IgnitionId: upstream.URL + "/node.ign", IgnitionSnippets: []string{"ssh.yaml", "raw.ign"}},
+			"missing":  {Id: "missing", IgnitionId: upstream.URL + "/missing.ign"},
+			"broken":   {Id: "broken", IgnitionId: upstream.URL + "/node.ign", IgnitionSnippets: []string{"broken.yaml"}},
+		},
+		IgnitionConfigs: map[string]string{
+			"ssh.yaml":    "systemd:\n  units:\n    - name: {{.request.query.unit}}.service\n      enable: true\n",
+			"raw.ign":     `{"ignition":{"version":"2.0.0"},"systemd":{"units":[{"name":"fleet.service","enable":true}]}}`,
+			"broken.yaml": "systemd: {{.missing.key}}\n",
+		},
+	}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Logger:         logger,
+		RemoteIgnition: remote.NewFetcher(&remote.Config{Client: upstream.Client(), Logger: logger}),
+	})
+	h := srv.ignitionHandler(server.NewServer(&server.Config{Store: store}))
+	serve := func(profile, url, etag string) *httptest.ResponseRecorder {
+		group := &storagepb.Group{Id: "node", Profile: profile}
+		ctx := withGroup(context.Background(), group)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", url, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		h.ServeHTTP(ctx, w, req)
+		return w
+	}
+
+	// assert that:
+	// - remote Ignition configs are fetched and served directly
+	// - conditional requests for unchanged configs are not modified
+	w := serve("remote", "/", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, jsonContentType, w.HeaderMap.Get(contentType))
+	assert.Equal(t, content, w.Body.String())
+	w = serve("remote", "/", w.HeaderMap.Get("ETag"))
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	// - snippets are rendered and appended, in order
+	w = serve("snippets", "/?unit=docker", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `{"name":"etcd2.service","enable":true},{"name":"docker.service","enable":true},{"name":"fleet.service","enable":true}`)
+
+	// - remote configs which can't be fetched are bad gateways
+	w = serve("missing", "/", "")
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+
+	// - snippets which fail to render are reported
+	w = serve("broken", "/", "")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "error rendering ignition template broken.yaml")
+}
@@ -4,17 +4,30 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"text/template"
 
 	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
 )
 
 const (
 	contentType     = "Content-Type"
 	jsonContentType = "application/json"
+	// RFC 7807 problem details
+	problemContentType = "application/problem+json"
+)
+
+// previewBootstrapToken is rendered in previews in place of a minted
+// bootstrap token.
+const previewBootstrapToken = "abcdef.0123456789abcdef"
+
+var (
+	errBootstrapTokensDisabled = errors.New("kubeadmToken requires -kubeadm-kubeconfig")
+	errSignedBootstrapTokens   = errors.New("kubeadmToken configs aren't signed")
 )
 
 // renderJSON encodes structs to JSON, writes the response to the
@@ -23,15 +36,18 @@ func (s *Server) renderJSON(w http.ResponseWriter, v interface{}) {
 	js, err := json.Marshal(v)
 	if err != nil {
 		s.logger.Errorf("error JSON encoding: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeProblem(w, http.StatusInternalServerError, CodeInternalError, "")
 		return
 	}
 	s.writeJSON(w, js)
 }
 
-// writeJSON writes the given bytes with a JSON Content-Type.
+// writeJSON writes the given bytes with a JSON Content-Type, unless the
+// Content-Type is already set (e.g. by a Profile).
 func (s *Server) writeJSON(w http.ResponseWriter, data []byte) {
-	w.Header().Set(contentType, jsonContentType)
+	if w.Header().Get(contentType) == "" {
+		w.Header().Set(contentType, jsonContentType)
+	}
 	_, err := w.Write(data)
 	if err != nil {
 		s.logger.Errorf("error writing to response: %v", err)
@@ -39,8 +55,16 @@ func (s *Server) writeJSON(w http.ResponseWriter, data []byte) {
 	}
 }
 
+// setProfileHeaders sets the response headers a Profile declares for its
+// rendered configs, which take precedence over the defaults.
+func setProfileHeaders(w http.ResponseWriter, profile *storagepb.Profile) {
+	for name, value := range profile.Headers {
+		w.Header().Set(name, value)
+	}
+}
+
 func (s *Server) renderTemplate(w io.Writer, data interface{}, contents ...string) (err error) {
-	return s.renderTemplateWithFuncMap(w, template.FuncMap{}, data, contents...)
+	return s.renderTemplateWithFuncMap(w, s.extensionFuncs(), data, contents...)
 }
 
 func (s *Server) renderTemplateWithFuncMap(
@@ -50,30 +74,68 @@ func (s *Server) renderTemplateWithFuncMap(
 	for _, content := range contents {
 		tmpl, err = tmpl.Parse(content)
 		if err != nil {
-			s.logger.Errorf("error parsing template: %v", err)
 			return err
 		}
 	}
-	err = tmpl.Execute(w, data)
-	if err != nil {
-		s.logger.Errorf("error rendering template: %v", err)
-		return err
+	return tmpl.Execute(w, data)
+}
+
+// extensionFuncs returns a copy of the extension template functions, to
+// which built-in functions may be added.
+func (s *Server) extensionFuncs() template.FuncMap {
+	funcs := make(template.FuncMap, len(s.templateFuncs))
+	for name, fn := range s.templateFuncs {
+		funcs[name] = fn
 	}
-	return nil
+	return funcs
 }
 
+// templateFuncMap returns the functions available to Ignition templates
+// and the templates they include. A render mints at most one bootstrap
+// token, so kubeadmToken returns the same token wherever it is used.
 func (s *Server) templateFuncMap(ctx context.Context, core server.Server) template.FuncMap {
-	return template.FuncMap{
-		"include": func(name string, data interface{}) (string, error) {
-			contents, err := core.IgnitionGet(ctx, name)
-			if err != nil {
-				return "", fmt.Errorf("No include template named: %s", name)
-			}
+	var token string
+	funcs := s.extensionFuncs()
+	funcs["include"] = func(name string, data interface{}) (string, error) {
+		contents, err := core.IgnitionGet(ctx, name)
+		if err != nil {
+			return "", fmt.Errorf("No include template named: %s", name)
+		}
 
-			var buf bytes.Buffer
-			funcs := s.templateFuncMap(ctx, core)
-			err = s.renderTemplateWithFuncMap(&buf, funcs, data, contents)
-			return buf.String(), err
-		},
+		var buf bytes.Buffer
+		err = s.renderTemplateWithFuncMap(&buf, funcs, data, contents)
+		return buf.String(), err
+	}
+	funcs["kubeadmToken"] = func() (string, error) {
+		if token != "" {
+			return token, nil
+		}
+		var err error
+		token, err = s.kubeadmToken(ctx)
+		return token, err
+	}
+	return funcs
+}
+
+// kubeadmToken mints a Kubernetes bootstrap token for the requesting
+// machine. Previews receive a placeholder token instead. Renders to sign
+// (e.g. by included templates) don't mint tokens.
+func (s *Server) kubeadmToken(ctx context.Context) (string, error) {
+	if isPreview(ctx) {
+		return previewBootstrapToken, nil
+	}
+	if isSignature(ctx) {
+		return "", errSignedBootstrapTokens
+	}
+	if s.kubeTokens == nil {
+		return "", errBootstrapTokensDisabled
+	}
+	description := "matchbox"
+	labels := labelsFromContext(ctx)
+	for _, key := range []string{"uuid", "mac"} {
+		if value := labels[key]; value != "" {
+			description += fmt.Sprintf(" %s=%s", key, value)
+		}
 	}
+	return s.kubeTokens.Create(ctx, description)
 }
@@ -30,7 +30,7 @@ func TestRenderJSON_EncodingError(t *testing.T) {
 	// channels cannot be JSON encoded
 	srv.renderJSON(w, make(chan struct{}))
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
-	assert.Empty(t, w.Body.String())
+	assert.Equal(t, CodeInternalError, decodeProblem(t, w).Code)
 }
 
 func TestRenderJSON_EncodeError(t *testing.T) {
@@ -40,7 +40,7 @@ func TestRenderJSON_EncodeError(t *testing.T) {
 	// channels cannot be JSON encoded
 	srv.renderJSON(w, make(chan struct{}))
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
-	assert.Empty(t, w.Body.String())
+	assert.Equal(t, CodeInternalError, decodeProblem(t, w).Code)
 }
 
 func TestRenderJSON_WriteError(t *testing.T) {
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// renderCache caches rendered configs by the hash of their inputs, so
+// identical machines (e.g. a rack booting at once) reuse a single render.
+// Entries are discarded when the store generation changes, since Groups,
+// Profiles, or templates may have changed. Rendered configs are held in
+// memory.
+type renderCache struct {
+	size int
+
+	mu         sync.Mutex
+	generation uint64
+	entries    map[string][]byte
+}
+
+func newRenderCache(size int) *renderCache {
+	return &renderCache{
+		size:    size,
+		entries: make(map[string][]byte),
+	}
+}
+
+// enabled returns true if renders are cached.
+func (c *renderCache) enabled() bool {
+	return c.size > 0
+}
+
+// get returns the config rendered for the key in the store generation.
+func (c *renderCache) get(key string, generation uint64) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.advance(generation)
+	value, ok := c.entries[key]
+	return value, ok
+}
+
+// put caches the config rendered for the key in the store generation.
+// Renders of earlier generations are discarded.
+func (c *renderCache) put(key string, generation uint64, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.advance(generation)
+	if generation != c.generation {
+		return
+	}
+	if len(c.entries) >= c.size {
+		// evict an arbitrary entry
+		for k := range c.entries {
+			delete(c.entries, k)
+			break
+		}
+	}
+	c.entries[key] = value
+}
+
+// advance discards all entries if the store generation is newer than the
+// cached generation.
+func (c *renderCache) advance(generation uint64) {
+	if generation > c.generation {
+		c.generation = generation
+		c.entries = make(map[string][]byte)
+	}
+}
+
+// renderKey returns a key identifying a rendered config by its inputs: the
+// Profile (including its version), the matched Group (selectors and merged
+// metadata), and the template contents. Templates which use request
+// variables (or include templates, which may) are also keyed by the
+// request query and the machine's labels and facts, otherwise machines
+// matching the same Group share the key.
+func renderKey(ctx context.Context, config string, profile *storagepb.Profile, group *storagepb.Group, contents string, req *http.Request) string {
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	enc.Encode(config)
+	enc.Encode(profile)
+	enc.Encode(group)
+	enc.Encode(contents)
+	if usesRequest(contents) || usesIncludes(contents) {
+		enc.Encode(req.URL.RawQuery)
+		enc.Encode(labelsFromContext(ctx))
+		enc.Encode(isWipe(ctx))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// usesRequest returns true if a template may use request variables.
+func usesRequest(contents string) bool {
+	return strings.Contains(contents, "request")
+}
+
+// cacheResult returns the render cache metric label for a lookup.
+func cacheResult(ok bool) string {
+	if ok {
+		return "hit"
+	}
+	return "miss"
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"context"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestRenderCache(t *testing.T) {
+	c := newRenderCache(2)
+	c.put("a", 1, []byte("a"))
+	c.put("b", 1, []byte("b"))
+	value, ok := c.get("a", 1)
+	// assert that:
+	// - renders are cached within a generation
+	assert.True(t, ok)
+	assert.Equal(t, []byte("a"), value)
+
+	// - the cache holds at most size renders
+	c.put("c", 1, []byte("c"))
+	assert.Len(t, c.entries, 2)
+
+	// - renders of earlier generations are discarded
+	_, ok = c.get("c", 2)
+	assert.False(t, ok)
+	c.put("a", 1, []byte("a"))
+	_, ok = c.get("a", 2)
+	assert.False(t, ok)
+}
+
+func TestRenderKey(t *testing.T) {
+	ctx := withLabels(context.Background(), map[string]string{"uuid": "a1b2c3d4"})
+	other := withLabels(context.Background(), map[string]string{"uuid": "e5f6g7h8"})
+	reqA, _ := http.NewRequest("GET", "/ignition?uuid=a1b2c3d4", nil)
+	reqB, _ := http.NewRequest("GET", "/ignition?uuid=e5f6g7h8", nil)
+	static := "systemd: {}"
+	scoped := "hostname: {{.request.query.uuid}}"
+	// assert that:
+	// - machines matching the same Group share renders of static templates
+	assert.Equal(t, renderKey(ctx, "ignition", fake.Profile, fake.Group, static, reqA), renderKey(other, "ignition", fake.Profile, fake.Group, static, reqB))
+	// - but not of templates using request variables
+	assert.NotEqual(t, renderKey(ctx, "ignition", fake.Profile, fake.Group, scoped, reqA), renderKey(other, "ignition", fake.Profile, fake.Group, scoped, reqB))
+	// - renders differ by Group metadata
+	group := fake.Group.Copy()
+	group.Metadata = []byte(`{"pod_network":"10.3.0.0/16"}`)
+	assert.NotEqual(t, renderKey(ctx, "ignition", fake.Profile, fake.Group, static, reqA), renderKey(ctx, "ignition", fake.Profile, group, static, reqA))
+}
+
+func TestIgnitionHandler_RenderCache(t *testing.T) {
+	store := &fake.FixedStore{
+		Profiles: map[string]*storagepb.Profile{fake.Group.Profile: testProfileIgnitionYAML},
+		IgnitionConfigs: map[string]string{
+			testProfileIgnitionYAML.IgnitionId: "systemd:\n  units:\n{{ include \"units.yaml\" . }}",
+			"units.yaml":                       "    - name: a.service\n",
+		},
+	}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger, RenderCacheSize: 8})
+	c := server.NewServer(&server.Config{Store: store})
+	h := srv.ignitionHandler(c)
+	ctx := withGroup(context.Background(), fake.Group)
+	serve := func() string {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/ignition?uuid=a1b2c3d4", nil)
+		h.ServeHTTP(ctx, w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+	assert.Contains(t, serve(), "a.service")
+
+	// change the included template without a store write through the server
+	store.IgnitionConfigs["units.yaml"] = "    - name: b.service\n"
+	// assert that:
+	// - the cached render is served
+	assert.Contains(t, serve(), "a.service")
+
+	// - store writes invalidate cached renders
+	_, err := c.IgnitionPut(context.Background(), &pb.IgnitionPutRequest{
+		Name:   "units.yaml",
+		Config: []byte("    - name: c.service\n"),
+	})
+	assert.Nil(t, err)
+	assert.Contains(t, serve(), "c.service")
+}
+
+func TestIPXEHandler_RenderCache(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:            server.NewServer(&server.Config{Store: fake.NewFixedStore()}),
+		Logger:          logger,
+		RenderCacheSize: 8,
+	})
+	h := srv.ipxeHandler()
+	serve := func(profile *storagepb.Profile) string {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/ipxe?uuid=a1b2c3d4", nil)
+		h.ServeHTTP(withProfile(context.Background(), profile), w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+	profile := fake.Profile.Copy()
+	profile.Boot.Kernel = "/image/other"
+	// assert that:
+	// - Profiles are rendered once, then served from the cache
+	assert.Contains(t, serve(fake.Profile), "kernel /image/kernel")
+	assert.Contains(t, serve(fake.Profile), "kernel /image/kernel")
+	// - different Profiles (or Profile versions) are rendered separately
+	assert.Contains(t, serve(profile), "kernel /image/other")
+}
@@ -0,0 +1,99 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/coreos/ignition/config/validate/report"
+
+	"github.com/coreos/matchbox/matchbox/manifest"
+)
+
+// templateErrorRegexp matches the position of text/template parse errors
+// ("template: name:line: msg") and execution errors
+// ("template: name:line:col: msg").
+var templateErrorRegexp = regexp.MustCompile(`(?s)^template: .*?:(\d+)(?::(\d+))?: (?:executing ".*?" )?(.*)$`)
+
+// reportError is a render failure described by a report.
+type reportError struct {
+	report report.Report
+}
+
+func (e *reportError) Error() string {
+	return e.report.String()
+}
+
+// templateReport converts a template parse or execution error into a
+// report with the error position and a highlight of the template source.
+func templateReport(source string, err error) report.Report {
+	entry := report.Entry{Kind: report.EntryError, Message: err.Error()}
+	if m := templateErrorRegexp.FindStringSubmatch(err.Error()); m != nil {
+		entry.Line, _ = strconv.Atoi(m[1])
+		if m[2] != "" {
+			// execution error columns are 0-based byte offsets
+			col, _ := strconv.Atoi(m[2])
+			entry.Column = col + 1
+		}
+		entry.Message = m[3]
+	}
+	r := report.Report{Entries: []report.Entry{entry}}
+	return addHighlights(r, source)
+}
+
+// addHighlights sets a source highlight on report entries with a line.
+func addHighlights(r report.Report, source string) report.Report {
+	for i, entry := range r.Entries {
+		if entry.Line > 0 && entry.Highlight == "" {
+			r.Entries[i].Highlight = highlight(source, entry.Line, entry.Column)
+		}
+	}
+	return r
+}
+
+// fuzeReport converts the 0-based positions of Fuze report entries to the
+// 1-based positions used by template and Ignition reports. The line of a
+// YAML unmarshal error is parsed from its message.
+func fuzeReport(r report.Report) report.Report {
+	for i, entry := range r.Entries {
+		if entry.Line > 0 || entry.Column > 0 {
+			r.Entries[i].Line++
+			r.Entries[i].Column++
+		} else {
+			r.Entries[i].Line, r.Entries[i].Message = manifest.YAMLErrorLine(entry.Message)
+		}
+	}
+	return r
+}
+
+// highlight returns the source line with a marker under the column.
+func highlight(source string, line, column int) string {
+	lines := strings.Split(source, "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	prefix := fmt.Sprintf("%4d | ", line)
+	out := prefix + lines[line-1] + "\n"
+	if column > 0 {
+		out += strings.Repeat(" ", len(prefix)-2) + "| " + strings.Repeat(" ", column-1) + "^\n"
+	}
+	return out
+}
+
+// renderFailed logs a report of why a config template failed to render
+// and responds with it, so template errors are debuggable by clients.
+func (s *Server) renderFailed(w http.ResponseWriter, config, name string, r report.Report) {
+	fields := logrus.Fields{
+		"config":   config,
+		"template": name,
+	}
+	if len(r.Entries) > 0 && r.Entries[0].Line > 0 {
+		fields["line"] = r.Entries[0].Line
+		fields["column"] = r.Entries[0].Column
+	}
+	s.logger.WithFields(fields).Errorf("error rendering template: %s", r.String())
+	writeProblem(w, http.StatusInternalServerError, CodeTemplateError, fmt.Sprintf("error rendering %s template %s:\n%s", config, name, r.String()))
+}
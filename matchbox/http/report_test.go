@@ -0,0 +1,52 @@
+package http
+
+import (
+	"errors"
+	"testing"
+
+	fuze "github.com/coreos/fuze/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateReport(t *testing.T) {
+	source := "line one\nKEY={{.key\n"
+	r := templateReport(source, errors.New(`template: :2: unclosed action`))
+	// assert that:
+	// - parse errors report the line and highlight the source line
+	if assert.Len(t, r.Entries, 1) {
+		entry := r.Entries[0]
+		assert.Equal(t, 2, entry.Line)
+		assert.Equal(t, 0, entry.Column)
+		assert.Equal(t, "unclosed action", entry.Message)
+		assert.Equal(t, "   2 | KEY={{.key\n", entry.Highlight)
+	}
+	// - other errors are reported without a position
+	r = templateReport(source, errors.New("No include template named: base"))
+	if assert.Len(t, r.Entries, 1) {
+		assert.Equal(t, 0, r.Entries[0].Line)
+		assert.Equal(t, "No include template named: base", r.Entries[0].Message)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	assert.Equal(t, "   1 | abc\n     |   ^\n", highlight("abc", 1, 3))
+	assert.Equal(t, "", highlight("abc", 2, 1))
+}
+
+func TestFuzeReport(t *testing.T) {
+	_, rpt := fuze.Parse([]byte("systemd:\n  unitz: []\n"))
+	r := fuzeReport(rpt)
+	// assert that:
+	// - 0-based Fuze positions are converted to 1-based positions
+	// - the lines of YAML unmarshal errors are parsed from their message
+	if assert.Len(t, r.Entries, 1) {
+		assert.Equal(t, 2, r.Entries[0].Line)
+		assert.Equal(t, 3, r.Entries[0].Column)
+	}
+	_, rpt = fuze.Parse([]byte("systemd:\n  units:\n    - name: a.service\n      enable: 1\n"))
+	r = fuzeReport(rpt)
+	if assert.Len(t, r.Entries, 1) {
+		assert.Equal(t, 4, r.Entries[0].Line)
+		assert.Equal(t, "cannot unmarshal !!int `1` into bool", r.Entries[0].Message)
+	}
+}
@@ -1,12 +1,36 @@
 package http
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 
+	"github.com/coreos/matchbox/matchbox/acl"
+	"github.com/coreos/matchbox/matchbox/assets"
+	"github.com/coreos/matchbox/matchbox/attest"
+	"github.com/coreos/matchbox/matchbox/audit"
+	"github.com/coreos/matchbox/matchbox/coalesce"
+	"github.com/coreos/matchbox/matchbox/defaults"
+	"github.com/coreos/matchbox/matchbox/deprecation"
+	"github.com/coreos/matchbox/matchbox/events"
+	"github.com/coreos/matchbox/matchbox/gitops"
+	"github.com/coreos/matchbox/matchbox/kubeadm"
+	"github.com/coreos/matchbox/matchbox/metrics"
+	"github.com/coreos/matchbox/matchbox/redact"
+	"github.com/coreos/matchbox/matchbox/remote"
+	"github.com/coreos/matchbox/matchbox/sealed"
 	"github.com/coreos/matchbox/matchbox/server"
 	"github.com/coreos/matchbox/matchbox/sign"
+	"github.com/coreos/matchbox/matchbox/sources"
+	"github.com/coreos/matchbox/matchbox/vault"
+	"github.com/coreos/matchbox/matchbox/webhook"
 )
 
 // Config configures a Server.
@@ -15,90 +39,312 @@ type Config struct {
 	Logger *logrus.Logger
 	// Path to static assets
 	AssetsPath string
+	// (optional) mirror for fetching Profile assets from upstream
+	Mirror *assets.Mirror
 	// config signers (.sig and .asc)
 	Signer        sign.Signer
 	ArmoredSigner sign.Signer
+	// (optional) CMS signer of assets and iPXE scripts (.p7s), enables iPXE
+	// image trust (imgtrust and imgverify)
+	ImageSigner sign.Signer
+	// (optional) rate limits on boot endpoints
+	RateLimit *RateLimit
+	// (optional) chainload of the /boot.ipxe script, defaultChain if nil
+	Chain *Chain
+	// (optional) when installed machines boot from local disk instead of
+	// their Profile (LocalBootComplete or LocalBootIgnition)
+	LocalBoot string
+	// (optional) network boots without being installed after which
+	// machines are served the core's rescue Profile and an alert is raised
+	InstallAttempts int
+	// (optional) machines which may install at once, across Profiles
+	InstallLimit int
+	// (optional) time without an Ignition fetch or completion after which
+	// an installing machine's slot is freed, DefaultInstallTimeout if zero
+	InstallTimeout time.Duration
+	// maximum number of rendered configs cached for reuse by identical
+	// machines (0 disables)
+	RenderCacheSize int
+	// (optional) webhooks notified of provisioning events
+	Webhooks *webhook.Notifier
+	// (optional) auditor recording boot requests
+	Auditor *audit.Auditor
+	// (optional) hub publishing boot events to live subscribers
+	Events *events.Hub
+	// renders slower than this are logged as warnings (0 disables)
+	SlowRenderThreshold time.Duration
+	// count machine requests by matched Group, one metric series per Group
+	GroupMatchMetrics bool
+	// require single-use tokens to fetch Ignition configs
+	IgnitionTokens bool
+	// (optional) client networks allowed to use HTTP endpoints
+	Allowlist acl.List
+	// (optional) Vault PKI issuing machine certificates
+	PKI *vault.PKI
+	// (optional) bootstrap tokens minted by the kubeadmToken template function
+	BootstrapTokens *kubeadm.Tokens
+	// (optional) default metadata merged beneath the metadata of every Group
+	Defaults *defaults.Defaults
+	// (optional) fetcher of Group metadata sources, defaults are used if nil
+	MetadataSources *sources.Fetcher
+	// (optional) fetcher of remote Ignition configs, defaults are used if nil
+	RemoteIgnition *remote.Fetcher
+	// (optional) reconciler of the store from a Git repository
+	GitOps *gitops.Reconciler
+	// (optional) decrypter of encrypted Group metadata values
+	Sealed *sealed.Decrypter
+	// (optional) TPM attestation required before serving Ignition configs
+	Attestation *attest.Verifier
+	// (optional) redactor of sensitive labels and metadata values in logs,
+	// audit entries, and events
+	Redactor *redact.Redactor
+	// redact sensitive metadata values in read API responses (e.g. the
+	// Ansible inventory) too
+	RedactResponses bool
+	// config requests recorded in each machine's boot history (0 disables)
+	BootHistory int
+	// time boot and provisioning events are kept in the event history (0
+	// disables recording)
+	EventRetention time.Duration
+	// (optional) extension functions available to config and kernel arg
+	// templates
+	TemplateFuncs template.FuncMap
+	// (optional) extension endpoints by ServeMux pattern, which may not
+	// replace matchbox endpoints
+	Handlers map[string]http.Handler
 }
 
 // Server serves boot and provisioning configs to machines via HTTP.
 type Server struct {
-	core          server.Server
-	logger        *logrus.Logger
-	assetsPath    string
-	signer        sign.Signer
-	armoredSigner sign.Signer
+	core           server.Server
+	logger         *logrus.Logger
+	assetsPath     string
+	mirror         *assets.Mirror
+	signer         sign.Signer
+	armoredSigner  sign.Signer
+	imageSigner    sign.Signer
+	localBootMode  string
+	maxAttempts    int
+	bootHistory    int
+	eventRetention time.Duration
+	installs       *installThrottle
+	limiter        *rateLimiter
+	webhooks       *webhook.Notifier
+	auditor        *audit.Auditor
+	events         *events.Hub
+	slowRender     time.Duration
+	groupMetrics   bool
+	ignitionTokens bool
+	allowed        acl.List
+	pki            *vault.PKI
+	kubeTokens     *kubeadm.Tokens
+	defaults       *defaults.Defaults
+	sources        *sources.Fetcher
+	remote         *remote.Fetcher
+	deprecations   *deprecation.Reporter
+	gitops         *gitops.Reconciler
+	sealed         *sealed.Decrypter
+	attestor       *attest.Verifier
+	redactor       *redact.Redactor
+	redactAPI      bool
+	chain          *Chain
+	templateFuncs  template.FuncMap
+	handlers       map[string]http.Handler
+	// coalesce identical concurrent renders and asset signings
+	renders  coalesce.Group
+	signings coalesce.Group
+	// rendered configs reused by identical machines
+	renderCache *renderCache
+	// CMS signatures of assets by filename
+	imageSigsMu sync.Mutex
+	imageSigs   map[string]*imageSignature
+	// draining is 1 once the Server is shutting down
+	draining int32
+	// unix time events were last pruned from the event history
+	eventsPruned int64
 }
 
 // NewServer returns a new Server.
 func NewServer(config *Config) *Server {
-	return &Server{
-		core:          config.Core,
-		logger:        config.Logger,
-		assetsPath:    config.AssetsPath,
-		signer:        config.Signer,
-		armoredSigner: config.ArmoredSigner,
+	srv := &Server{
+		core:           config.Core,
+		logger:         config.Logger,
+		assetsPath:     config.AssetsPath,
+		mirror:         config.Mirror,
+		signer:         config.Signer,
+		armoredSigner:  config.ArmoredSigner,
+		imageSigner:    config.ImageSigner,
+		localBootMode:  config.LocalBoot,
+		maxAttempts:    config.InstallAttempts,
+		bootHistory:    config.BootHistory,
+		eventRetention: config.EventRetention,
+		installs:       newInstallThrottle(config.InstallLimit, config.InstallTimeout),
+		renderCache:    newRenderCache(config.RenderCacheSize),
+		imageSigs:      make(map[string]*imageSignature),
+		webhooks:       config.Webhooks,
+		auditor:        config.Auditor,
+		events:         config.Events,
+		slowRender:     config.SlowRenderThreshold,
+		groupMetrics:   config.GroupMatchMetrics,
+		ignitionTokens: config.IgnitionTokens,
+		allowed:        config.Allowlist,
+		pki:            config.PKI,
+		kubeTokens:     config.BootstrapTokens,
+		defaults:       config.Defaults,
+		sources:        config.MetadataSources,
+		remote:         config.RemoteIgnition,
+		gitops:         config.GitOps,
+		sealed:         config.Sealed,
+		attestor:       config.Attestation,
+		redactor:       config.Redactor,
+		redactAPI:      config.RedactResponses,
+		chain:          config.Chain,
+		templateFuncs:  config.TemplateFuncs,
+		handlers:       config.Handlers,
+		deprecations:   deprecation.NewReporter(&deprecation.Config{Logger: config.Logger}),
 	}
+	if srv.chain == nil {
+		srv.chain = defaultChain
+	}
+	if srv.sources == nil {
+		srv.sources = sources.NewFetcher(&sources.Config{Logger: config.Logger})
+	}
+	if srv.remote == nil {
+		srv.remote = remote.NewFetcher(&remote.Config{Logger: config.Logger})
+	}
+	if config.RateLimit.enabled() {
+		srv.limiter = newRateLimiter(config.RateLimit)
+	}
+	return srv
 }
 
 // HTTPHandler returns a HTTP handler for the server.
 func (s *Server) HTTPHandler() http.Handler {
 	mux := http.NewServeMux()
 
+	// logged and rate limited middleware for boot endpoints
+	logged := s.logRequest
+	limited := func(next http.Handler) http.Handler {
+		return s.logRequest(s.rateLimit(next))
+	}
 	chain := func(next ContextHandler) http.Handler {
-		return s.logRequest(NewHandler(next))
+		return logged(NewHandler(next))
+	}
+	limitChain := func(next ContextHandler) http.Handler {
+		return limited(NewHandler(next))
+	}
+	signers := map[string]sign.Signer{
+		".sig":            s.signer,
+		".asc":            s.armoredSigner,
+		imageSignatureExt: s.imageSigner,
+	}
+	// handleArtifact registers a rendered artifact and the endpoints of its
+	// detached signatures, served by the same handler behind the same
+	// middleware.
+	handleArtifact := func(path string, wrap func(http.Handler) http.Handler, handler ContextHandler) {
+		mux.Handle(path, wrap(NewHandler(handler)))
+		for ext, signer := range signers {
+			if signer != nil {
+				mux.Handle(signaturePath(path, ext), wrap(sign.SignatureHandler(signer, NewHandler(signed(handler)))))
+			}
+		}
 	}
 	// matchbox version
 	mux.Handle("/", s.logRequest(homeHandler()))
 	// Boot via GRUB
-	mux.Handle("/grub", chain(s.selectProfile(s.core, s.grubHandler())))
+	handleArtifact("/grub", logged, s.selectProfile(s.core, s.grubHandler()))
+	mux.Handle("/grub/", grubArchPath(mux))
 	// Boot via iPXE
-	mux.Handle("/boot.ipxe", chain(ipxeInspect()))
-	mux.Handle("/boot.ipxe.0", chain(ipxeInspect()))
-	mux.Handle("/ipxe", chain(s.selectProfile(s.core, s.ipxeHandler())))
+	handleArtifact("/boot.ipxe", logged, s.ipxeInspect())
+	handleArtifact("/boot.ipxe.0", logged, s.ipxeInspect())
+	handleArtifact("/ipxe", limited, s.maintenance(s.core, s.localBoot(s.core, s.selectProfile(s.core, s.throttleInstall(s.core, s.ipxeHandler())))))
 	// Boot via Pixiecore
-	mux.Handle("/pixiecore/v1/boot/", chain(s.pixiecoreHandler(s.core)))
+	handleArtifact("/pixiecore/v1/boot/", logged, s.pixiecoreHandler(s.core))
 	// Ignition Config
-	mux.Handle("/ignition", chain(s.selectGroup(s.core, s.ignitionHandler(s.core))))
+	handleArtifact("/ignition", limited, s.requireAttestation(s.requireToken(s.core, s.selectGroup(s.core, s.ignitionHandler(s.core)))))
 	// Cloud-Config
-	mux.Handle("/cloud", chain(s.selectGroup(s.core, s.cloudHandler(s.core))))
+	handleArtifact("/cloud", logged, s.selectGroup(s.core, s.cloudHandler(s.core)))
 	// Generic template
-	mux.Handle("/generic", chain(s.selectGroup(s.core, s.genericHandler(s.core))))
+	handleArtifact("/generic", logged, s.selectGroup(s.core, s.genericHandler(s.core)))
 	// Metadata
-	mux.Handle("/metadata", chain(s.selectGroup(s.core, s.metadataHandler())))
+	handleArtifact("/metadata", logged, s.selectGroup(s.core, s.metadataHandler()))
+	// Ansible dynamic inventory
+	mux.Handle("/inventory", chain(s.inventoryHandler(s.core)))
+	// Prometheus HTTP service discovery
+	mux.Handle("/prometheus/http_sd", chain(s.prometheusSDHandler(s.core)))
+	// Prometheus metrics
+	mux.Handle("/metrics", metrics.Handler())
+	// Health and readiness
+	mux.Handle("/healthz", s.healthzHandler())
+	mux.Handle("/readyz", s.readyzHandler(s.core))
+	// Phone-home provisioning completion
+	mux.Handle("/v1/complete", chain(s.completeHandler(s.core)))
+	// Provisioning progress
+	mux.Handle("/v1/progress", chain(s.progressHandler(s.core)))
+	// Machine certificates
+	if s.pki != nil {
+		mux.Handle("/v1/certificate", limitChain(s.certificateHandler(s.core)))
+	}
+	// TPM attestation
+	if s.attestor != nil {
+		mux.Handle("/v1/attest/challenge", limitChain(s.attestChallengeHandler()))
+		mux.Handle("/v1/attest/quote", limitChain(s.attestQuoteHandler()))
+	}
+	// GitOps reconciliation status
+	if s.gitops != nil {
+		mux.Handle("/gitops/status", s.logRequest(s.gitopsStatusHandler()))
+	}
+
+	// kernel, initrd, and TLS assets
+	if s.assetsPath != "" {
+		mux.Handle("/assets/", s.logRequest(s.rateLimit(s.assetsHandler())))
+	}
+	// extension endpoints
+	s.handleExtensions(mux)
+	return s.allowlist(s.warnDeprecated(mux))
+}
 
-	// Signatures
-	if s.signer != nil {
-		signerChain := func(next ContextHandler) http.Handler {
-			return s.logRequest(sign.SignatureHandler(s.signer, NewHandler(next)))
+// handleExtensions registers the extension endpoints, skipping (and
+// logging) those which conflict with matchbox endpoints.
+func (s *Server) handleExtensions(mux *http.ServeMux) {
+	patterns := make([]string, 0, len(s.handlers))
+	for pattern := range s.handlers {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if err := handle(mux, pattern, s.logRequest(s.handlers[pattern])); err != nil {
+			s.logger.Errorf("Skipping extension endpoint %s: %v", pattern, err)
 		}
-		mux.Handle("/grub.sig", signerChain(s.selectProfile(s.core, s.grubHandler())))
-		mux.Handle("/boot.ipxe.sig", signerChain(ipxeInspect()))
-		mux.Handle("/boot.ipxe.0.sig", signerChain(ipxeInspect()))
-		mux.Handle("/ipxe.sig", signerChain(s.selectProfile(s.core, s.ipxeHandler())))
-		mux.Handle("/pixiecore/v1/boot.sig/", signerChain(s.pixiecoreHandler(s.core)))
-		mux.Handle("/ignition.sig", signerChain(s.selectGroup(s.core, s.ignitionHandler(s.core))))
-		mux.Handle("/cloud.sig", signerChain(s.selectGroup(s.core, s.cloudHandler(s.core))))
-		mux.Handle("/generic.sig", signerChain(s.selectGroup(s.core, s.genericHandler(s.core))))
-		mux.Handle("/metadata.sig", signerChain(s.selectGroup(s.core, s.metadataHandler())))
-	}
-	if s.armoredSigner != nil {
-		signerChain := func(next ContextHandler) http.Handler {
-			return s.logRequest(sign.SignatureHandler(s.armoredSigner, NewHandler(next)))
+	}
+}
+
+// handle registers a handler for a pattern, returning an error instead of
+// panicking if the pattern is invalid or conflicts with a registered one.
+func handle(mux *http.ServeMux, pattern string, handler http.Handler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
 		}
-		mux.Handle("/grub.asc", signerChain(s.selectProfile(s.core, s.grubHandler())))
-		mux.Handle("/boot.ipxe.asc", signerChain(ipxeInspect()))
-		mux.Handle("/boot.ipxe.0.asc", signerChain(ipxeInspect()))
-		mux.Handle("/ipxe.asc", signerChain(s.selectProfile(s.core, s.ipxeHandler())))
-		mux.Handle("/pixiecore/v1/boot.asc/", signerChain(s.pixiecoreHandler(s.core)))
-		mux.Handle("/ignition.asc", signerChain(s.selectGroup(s.core, s.ignitionHandler(s.core))))
-		mux.Handle("/cloud.asc", signerChain(s.selectGroup(s.core, s.cloudHandler(s.core))))
-		mux.Handle("/generic.asc", signerChain(s.selectGroup(s.core, s.genericHandler(s.core))))
-		mux.Handle("/metadata.asc", signerChain(s.selectGroup(s.core, s.metadataHandler())))
+	}()
+	mux.Handle(pattern, handler)
+	return nil
+}
+
+// signed marks requests as renders of an artifact to sign.
+func signed(next ContextHandler) ContextHandler {
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		next.ServeHTTP(withSignature(ctx), w, req)
 	}
+	return ContextHandlerFunc(fn)
+}
 
-	// kernel, initrd, and TLS assets
-	if s.assetsPath != "" {
-		mux.Handle("/assets/", s.logRequest(http.StripPrefix("/assets/", http.FileServer(http.Dir(s.assetsPath)))))
+// signaturePath returns the path of the signature endpoint of an artifact.
+// Subtree paths have the extension before the trailing slash (e.g.
+// /pixiecore/v1/boot.sig/).
+func signaturePath(path, ext string) string {
+	if strings.HasSuffix(path, "/") {
+		return strings.TrimSuffix(path, "/") + ext + "/"
 	}
-	return mux
+	return path + ext
 }
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+// fixedSigner writes a fixed signature.
+type fixedSigner string
+
+func (s fixedSigner) Sign(w io.Writer, message io.Reader) error {
+	_, err := io.WriteString(w, string(s))
+	return err
+}
+
+func TestSignaturePath(t *testing.T) {
+	cases := []struct {
+		path     string
+		ext      string
+		expected string
+	}{
+		{"/ipxe", ".sig", "/ipxe.sig"},
+		{"/boot.ipxe.0", ".asc", "/boot.ipxe.0.asc"},
+		{"/pixiecore/v1/boot/", ".sig", "/pixiecore/v1/boot.sig/"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, signaturePath(c.path, c.ext))
+	}
+}
+
+func TestSignatureEndpoints(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups[fake.Group.Id] = fake.Group
+	store.Profiles[fake.Profile.Id] = fake.Profile
+	store.GenericConfigs[fake.Profile.GenericId] = "generic"
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:          server.NewServer(&server.Config{Store: store}),
+		Logger:        logger,
+		Signer:        fixedSigner("sig"),
+		ArmoredSigner: fixedSigner("asc"),
+	})
+	h := srv.HTTPHandler()
+	// assert that each rendered artifact has binary and armored signatures
+	for _, path := range []string{"/ipxe", "/grub", "/generic", "/metadata"} {
+		for _, ext := range []string{".sig", ".asc"} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", path+ext+"?uuid=a1b2c3d4", nil)
+			h.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code, path+ext)
+			assert.Equal(t, ext[1:], w.Body.String(), path+ext)
+		}
+	}
+}
+
+func TestSignatureEndpoints_Middleware(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:           server.NewServer(&server.Config{Store: fake.NewFixedStore()}),
+		Logger:         logger,
+		Signer:         fixedSigner("sig"),
+		IgnitionTokens: true,
+		RateLimit:      &RateLimit{PerIP: 0.1, PerIPBurst: 1},
+	})
+	h := srv.HTTPHandler()
+	// assert that:
+	// - signatures require the same token as their artifact
+	// - signatures are rate limited like their artifact
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/ignition.sig?uuid=a1b2c3d4", nil)
+	req.RemoteAddr = "10.1.2.3:51234"
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/ipxe.sig?uuid=a1b2c3d4", nil)
+	req.RemoteAddr = "10.1.2.3:51234"
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
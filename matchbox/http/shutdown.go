@@ -0,0 +1,47 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultDrainTimeout is how long in-flight requests may finish during a
+// graceful shutdown.
+const DefaultDrainTimeout = 30 * time.Second
+
+// Drain marks the Server as shutting down, so readiness checks fail and
+// load balancers stop sending it machines while in-flight renders and
+// asset transfers finish.
+func (s *Server) Drain() {
+	atomic.StoreInt32(&s.draining, 1)
+}
+
+// isDraining returns true if the Server is shutting down.
+func (s *Server) isDraining() bool {
+	return atomic.LoadInt32(&s.draining) == 1
+}
+
+// Shutdown gracefully shuts down the servers concurrently: each stops
+// accepting connections and waits for in-flight requests to finish, until
+// the ctx is done. Connections which remain are then closed and the ctx
+// error is returned.
+func Shutdown(ctx context.Context, servers ...*http.Server) error {
+	var wg sync.WaitGroup
+	errs := make(chan error, len(servers))
+	for _, srv := range servers {
+		wg.Add(1)
+		go func(srv *http.Server) {
+			defer wg.Done()
+			if err := srv.Shutdown(ctx); err != nil {
+				srv.Close()
+				errs <- err
+			}
+		}(srv)
+	}
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestDrain(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:   server.NewServer(&server.Config{Store: fake.NewFixedStore()}),
+		Logger: logger,
+	})
+	code, _ := readyz(t, srv)
+	assert.Equal(t, http.StatusOK, code)
+	// assert that readiness fails once the server is draining
+	srv.Drain()
+	code, resp := readyz(t, srv)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, map[string]string{"shutdown": "draining connections"}, resp.Checks)
+}
+
+func TestShutdown(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("kernel"))
+	})}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go srv.Serve(lis)
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + lis.Addr().String() + "/assets/vmlinuz")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		data, _ := ioutil.ReadAll(resp.Body)
+		body <- string(data)
+	}()
+	<-started
+
+	// assert that:
+	// - new connections are refused while in-flight requests finish
+	// - shutdown waits for in-flight requests
+	done := make(chan error, 1)
+	go func() { done <- Shutdown(context.Background(), srv) }()
+	time.Sleep(50 * time.Millisecond)
+	_, err = net.Dial("tcp", lis.Addr().String())
+	assert.Error(t, err)
+	close(release)
+	assert.Equal(t, "kernel", <-body)
+	assert.Nil(t, <-done)
+
+	// - requests which outlast the ctx are interrupted
+	started, release = make(chan struct{}), make(chan struct{})
+	defer close(release)
+	srv = &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+	})}
+	lis, err = net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go srv.Serve(lis)
+	go http.Get("http://" + lis.Addr().String() + "/assets/vmlinuz")
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, Shutdown(ctx, srv))
+}
@@ -0,0 +1,225 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// DefaultInstallTimeout is how long a machine holds an install slot after
+// it was admitted or last fetched its Ignition config, if it doesn't
+// report completion.
+const DefaultInstallTimeout = time.Hour
+
+// Backoff of machines waiting for an install slot.
+const (
+	installRetryMin = 15 * time.Second
+	installRetryMax = 5 * time.Minute
+)
+
+// ipxeInstallRetry waits, then chains the same iPXE request again, so
+// throttled machines install once a slot is free.
+const ipxeInstallRetry = `#!ipxe
+echo matchbox install limit reached, retrying in %d seconds
+sleep %d
+chain --replace %s
+`
+
+// installThrottle limits how many machines install at once, globally and
+// per Profile. Machines hold a slot from when they are admitted until they
+// report completion, or until the timeout passes without them fetching
+// their Ignition config. Slots are held in memory.
+type installThrottle struct {
+	limit   int
+	timeout time.Duration
+	now     func() time.Time
+
+	mu sync.Mutex
+	// installing machines by UUID
+	active map[string]*install
+	// machines waiting for a slot by UUID
+	waiting map[string]*install
+}
+
+// install is a machine installing (or waiting to install) a Profile.
+type install struct {
+	profile string
+	// time the machine was admitted or last seen
+	seen time.Time
+	// retries while waiting for a slot
+	retries uint
+}
+
+func newInstallThrottle(limit int, timeout time.Duration) *installThrottle {
+	if timeout <= 0 {
+		timeout = DefaultInstallTimeout
+	}
+	return &installThrottle{
+		limit:   limit,
+		timeout: timeout,
+		now:     time.Now,
+		active:  make(map[string]*install),
+		waiting: make(map[string]*install),
+	}
+}
+
+// admit returns true if the machine may install the Profile, reserving an
+// install slot for it, or true if it already holds one. Otherwise, it
+// returns false and the time the machine should wait before retrying,
+// which doubles with each retry.
+func (t *installThrottle) admit(uuid, profile string, profileLimit int) (bool, time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	t.expire(now)
+	if in, ok := t.active[uuid]; ok {
+		in.seen = now
+		if in.profile != profile {
+			previous := in.profile
+			in.profile = profile
+			t.observe(previous)
+			t.observe(profile)
+		}
+		return true, 0
+	}
+	if (t.limit <= 0 || len(t.active) < t.limit) && (profileLimit <= 0 || t.count(profile) < profileLimit) {
+		t.active[uuid] = &install{profile: profile, seen: now}
+		delete(t.waiting, uuid)
+		t.observe(profile)
+		return true, 0
+	}
+	wait, ok := t.waiting[uuid]
+	if !ok {
+		wait = &install{profile: profile}
+		t.waiting[uuid] = wait
+	}
+	wait.seen = now
+	backoff := installRetryMax
+	if wait.retries < 5 && installRetryMin<<wait.retries < installRetryMax {
+		backoff = installRetryMin << wait.retries
+	}
+	wait.retries++
+	// spread retries of machines which were throttled together
+	backoff += time.Duration(rand.Int63n(int64(backoff / 4)))
+	return false, backoff
+}
+
+// touch extends the install slot of a machine which is still installing.
+func (t *installThrottle) touch(uuid string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if in, ok := t.active[uuid]; ok {
+		in.seen = t.now()
+	}
+}
+
+// release frees the install slot of a machine which finished installing.
+func (t *installThrottle) release(uuid string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if in, ok := t.active[uuid]; ok {
+		delete(t.active, uuid)
+		t.observe(in.profile)
+	}
+}
+
+// expire frees the slots of machines which have not been seen within the
+// timeout, and forgets machines which stopped waiting.
+func (t *installThrottle) expire(now time.Time) {
+	for uuid, in := range t.active {
+		if now.Sub(in.seen) > t.timeout {
+			delete(t.active, uuid)
+			t.observe(in.profile)
+		}
+	}
+	for uuid, wait := range t.waiting {
+		if now.Sub(wait.seen) > t.timeout {
+			delete(t.waiting, uuid)
+		}
+	}
+}
+
+// count returns the number of machines installing a Profile.
+func (t *installThrottle) count(profile string) int {
+	n := 0
+	for _, in := range t.active {
+		if in.profile == profile {
+			n++
+		}
+	}
+	return n
+}
+
+// observe updates the installing machines metric of a Profile.
+func (t *installThrottle) observe(profile string) {
+	installsActive.Set(float64(t.count(profile)), profile)
+}
+
+// throttleInstall returns a handler which responds to iPXE requests of
+// machines which would install their Profile with a retry script while the
+// global or Profile install limit is reached, and otherwise calls the next
+// handler. Installed machines and machines served the holding Profile
+// aren't throttled.
+func (s *Server) throttleInstall(core server.Server, next ContextHandler) ContextHandler {
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		profile, err := profileFromContext(ctx)
+		uuid := labelsFromRequest(nil, req)["uuid"]
+		if err != nil || uuid == "" || (s.installs.limit <= 0 && profile.InstallLimit <= 0) ||
+			requestInfoFromContext(ctx).group == server.HoldingGroupID || installed(ctx, core, uuid) {
+			next.ServeHTTP(ctx, w, req)
+			return
+		}
+		admitted, backoff := s.installs.admit(uuid, profile.Id, int(profile.InstallLimit))
+		if admitted {
+			next.ServeHTTP(ctx, w, req)
+			return
+		}
+		installsThrottled.Inc(profile.Id)
+		seconds := int(backoff.Seconds())
+		s.logger.WithFields(logrus.Fields{
+			"labels":  s.redactor.Labels(labelsFromRequest(nil, req)),
+			"profile": profile.Id,
+			"retry":   seconds,
+		}).Debug("Install limit reached, machine will retry")
+		fmt.Fprintf(w, ipxeInstallRetry, seconds, seconds, req.URL.RequestURI())
+	}
+	return ContextHandlerFunc(fn)
+}
+
+// installed returns true if the machine UUID has been installed, so its
+// boots don't install its Profile again.
+func installed(ctx context.Context, core server.Server, uuid string) bool {
+	machine, err := core.MachineGet(ctx, &pb.MachineGetRequest{Id: uuid})
+	if err != nil {
+		return false
+	}
+	return machine.State == storagepb.MachineProvisioned || machine.State == storagepb.MachineInstalled
+}
+
+// trackInstall extends the install slot of a machine which fetched its
+// Ignition config and frees the slot of a machine which reported
+// completion.
+func (s *Server) trackInstall(req *http.Request, status int) {
+	if status < 200 || status > 299 {
+		return
+	}
+	uuid := labelsFromRequest(nil, req)["uuid"]
+	if uuid == "" {
+		return
+	}
+	switch req.URL.Path {
+	case "/ignition":
+		s.installs.touch(uuid)
+	case "/v1/complete":
+		s.installs.release(uuid)
+	}
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestThrottleInstall(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups["workers"] = &storagepb.Group{Id: "workers", Profile: "worker"}
+	store.Profiles["worker"] = &storagepb.Profile{
+		Id:           "worker",
+		Boot:         &storagepb.NetBoot{Kernel: "/worker/vmlinuz"},
+		InstallLimit: 1,
+	}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:   server.NewServer(&server.Config{Store: store}),
+		Logger: logger,
+	})
+	h := srv.HTTPHandler()
+	request := func(method, path string) string {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, strings.NewReader(""))
+		h.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	// assert that:
+	// - machines are admitted up to the Profile's install limit
+	// - machines holding a slot are admitted again
+	// - excess machines are served a retry script with backoff
+	// - completion frees the slot
+	assert.Contains(t, request("GET", "/ipxe?uuid=a1"), "/worker/vmlinuz")
+	assert.Contains(t, request("GET", "/ipxe?uuid=a1"), "/worker/vmlinuz")
+	script := request("GET", "/ipxe?uuid=b2")
+	assert.NotContains(t, script, "/worker/vmlinuz")
+	assert.Contains(t, script, "chain --replace /ipxe?uuid=b2")
+	assert.Equal(t, float64(1), installsActive.Value("worker"))
+	assert.Equal(t, float64(1), installsThrottled.Value("worker"))
+
+	request("POST", "/v1/complete?uuid=a1")
+	assert.Contains(t, request("GET", "/ipxe?uuid=b2"), "/worker/vmlinuz")
+	// - installed machines aren't throttled
+	assert.Contains(t, request("GET", "/ipxe?uuid=a1"), "/worker/vmlinuz")
+}
+
+func TestInstallThrottle(t *testing.T) {
+	now := time.Date(2017, 6, 12, 18, 0, 0, 0, time.UTC)
+	throttle := newInstallThrottle(2, time.Hour)
+	throttle.now = func() time.Time { return now }
+
+	// assert that:
+	// - the global limit applies across Profiles
+	// - retries back off, up to the maximum
+	// - slots are freed after the timeout, unless Ignition fetches extend them
+	ok, _ := throttle.admit("a1", "worker", 0)
+	assert.True(t, ok)
+	ok, _ = throttle.admit("b2", "etcd", 0)
+	assert.True(t, ok)
+	ok, first := throttle.admit("c3", "worker", 0)
+	assert.False(t, ok)
+	_, second := throttle.admit("c3", "worker", 0)
+	assert.True(t, second > first)
+	assert.True(t, first >= installRetryMin)
+	for i := 0; i < 10; i++ {
+		_, second = throttle.admit("c3", "worker", 0)
+	}
+	assert.True(t, second >= installRetryMax && second <= installRetryMax*5/4)
+
+	now = now.Add(40 * time.Minute)
+	throttle.touch("a1")
+	now = now.Add(40 * time.Minute)
+	ok, _ = throttle.admit("c3", "worker", 0)
+	assert.True(t, ok)
+	ok, _ = throttle.admit("d4", "worker", 0)
+	assert.False(t, ok)
+}
@@ -0,0 +1,167 @@
+package http
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default HTTP server timeouts.
+const (
+	DefaultReadHeaderTimeout = 10 * time.Second
+	DefaultReadTimeout       = time.Minute
+	DefaultWriteTimeout      = 2 * time.Minute
+	DefaultIdleTimeout       = 2 * time.Minute
+)
+
+// ErrInvalidPathTimeout is returned for a path timeout which isn't a path
+// prefix and duration separated by "=".
+var ErrInvalidPathTimeout = errors.New("http: path timeouts must be PATH=DURATION")
+
+// Timeouts configures the timeouts of a HTTP server. Zero disables a
+// timeout.
+type Timeouts struct {
+	// time to read request headers
+	ReadHeader time.Duration
+	// time to read a request, including its body
+	Read time.Duration
+	// time to write a response, from the end of the request headers
+	Write time.Duration
+	// time to wait for the next request on a keep-alive connection
+	Idle time.Duration
+	// write timeouts of paths starting with a prefix, overriding Write. The
+	// longest matching prefix applies.
+	Paths map[string]time.Duration
+}
+
+// ParsePathTimeouts parses comma separated path prefixes and write
+// timeouts (e.g. "/assets/=0,/ignition=30s").
+func ParsePathTimeouts(value string) (map[string]time.Duration, error) {
+	paths := make(map[string]time.Duration)
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], "/") {
+			return nil, ErrInvalidPathTimeout
+		}
+		timeout, err := time.ParseDuration(parts[1])
+		if err != nil || timeout < 0 {
+			return nil, ErrInvalidPathTimeout
+		}
+		paths[parts[0]] = timeout
+	}
+	return paths, nil
+}
+
+// ConfigureServer sets the timeouts of a HTTP server and whether it serves
+// HTTP/2, negotiated over TLS or with prior knowledge (h2c) over cleartext.
+// HTTP/1.1 clients such as iPXE are served either way. Configure a server
+// after setting its Handler and TLSConfig.
+func ConfigureServer(srv *http.Server, timeouts *Timeouts, h2 bool) error {
+	srv.ReadHeaderTimeout = timeouts.ReadHeader
+	srv.ReadTimeout = timeouts.Read
+	srv.WriteTimeout = timeouts.Write
+	srv.IdleTimeout = timeouts.Idle
+	if len(timeouts.Paths) > 0 {
+		conns := newConnTracker()
+		srv.ConnState = conns.track(srv.ConnState)
+		srv.Handler = writeTimeouts(timeouts, conns, srv.Handler)
+	}
+	if !h2 {
+		// a non-nil map disables HTTP/2 over TLS
+		srv.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+		return nil
+	}
+	return configureHTTP2(srv)
+}
+
+// pathTimeout returns the write timeout of the longest path prefix
+// matching the path, if any.
+func (t *Timeouts) pathTimeout(path string) (time.Duration, bool) {
+	var timeout time.Duration
+	longest := -1
+	for prefix, d := range t.Paths {
+		if strings.HasPrefix(path, prefix) && len(prefix) > longest {
+			timeout, longest = d, len(prefix)
+		}
+	}
+	return timeout, longest >= 0
+}
+
+// writeTimeouts returns a handler which replaces the server's write
+// deadline of the connection for HTTP/1.1 requests of paths with a write
+// timeout override, then calls the next handler. The server sets the
+// deadline after reading the request headers, before calling its handler.
+func writeTimeouts(timeouts *Timeouts, conns *connTracker, next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		if timeout, ok := timeouts.pathTimeout(req.URL.Path); ok && req.ProtoMajor == 1 {
+			if conn := conns.get(req.RemoteAddr); conn != nil {
+				var deadline time.Time
+				if timeout > 0 {
+					deadline = time.Now().Add(timeout)
+				}
+				conn.SetWriteDeadline(deadline)
+			}
+		}
+		next.ServeHTTP(w, req)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// connTracker tracks the open connections of a server by remote address, so
+// handlers can find the connection of a request.
+type connTracker struct {
+	mu    sync.Mutex
+	conns map[string][]net.Conn
+}
+
+func newConnTracker() *connTracker {
+	return &connTracker{conns: make(map[string][]net.Conn)}
+}
+
+// track returns a connection state hook which tracks connections, then
+// calls the next hook, if any.
+func (t *connTracker) track(next func(net.Conn, http.ConnState)) func(net.Conn, http.ConnState) {
+	return func(conn net.Conn, state http.ConnState) {
+		addr := conn.RemoteAddr().String()
+		t.mu.Lock()
+		switch state {
+		case http.StateNew:
+			t.conns[addr] = append(t.conns[addr], conn)
+		case http.StateHijacked, http.StateClosed:
+			conns := t.conns[addr][:0]
+			for _, c := range t.conns[addr] {
+				if c != conn {
+					conns = append(conns, c)
+				}
+			}
+			if len(conns) == 0 {
+				delete(t.conns, addr)
+			} else {
+				t.conns[addr] = conns
+			}
+		}
+		t.mu.Unlock()
+		if next != nil {
+			next(conn, state)
+		}
+	}
+}
+
+// get returns the open connection with a remote address, or nil if there is
+// none or several connections can't be told apart (e.g. unix sockets).
+func (t *connTracker) get(addr string) net.Conn {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if conns := t.conns[addr]; len(conns) == 1 {
+		return conns[0]
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePathTimeouts(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected map[string]time.Duration
+		err      error
+	}{
+		{"", map[string]time.Duration{}, nil},
+		{"/assets/=0", map[string]time.Duration{"/assets/": 0}, nil},
+		{"/assets/=0, /ignition=30s", map[string]time.Duration{"/assets/": 0, "/ignition": 30 * time.Second}, nil},
+		{"assets=0", nil, ErrInvalidPathTimeout},
+		{"/assets/", nil, ErrInvalidPathTimeout},
+		{"/assets/=forever", nil, ErrInvalidPathTimeout},
+		{"/assets/=-1s", nil, ErrInvalidPathTimeout},
+	}
+	for _, c := range cases {
+		paths, err := ParsePathTimeouts(c.value)
+		assert.Equal(t, c.err, err, c.value)
+		assert.Equal(t, c.expected, paths, c.value)
+	}
+}
+
+func TestTimeouts_PathTimeout(t *testing.T) {
+	timeouts := &Timeouts{Paths: map[string]time.Duration{
+		"/assets/":        0,
+		"/assets/images/": time.Hour,
+	}}
+	// assert that:
+	// - the longest matching prefix applies
+	// - paths without a matching prefix have no override
+	timeout, ok := timeouts.pathTimeout("/assets/images/disk.img")
+	assert.True(t, ok)
+	assert.Equal(t, time.Hour, timeout)
+	timeout, ok = timeouts.pathTimeout("/assets/vmlinuz")
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), timeout)
+	_, ok = timeouts.pathTimeout("/ignition")
+	assert.False(t, ok)
+}
+
+func TestWriteTimeouts(t *testing.T) {
+	slow := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+	timeouts := &Timeouts{
+		Write: 50 * time.Millisecond,
+		Paths: map[string]time.Duration{"/assets/": 0},
+	}
+	ts := httptest.NewUnstartedServer(slow)
+	assert.Nil(t, ConfigureServer(ts.Config, timeouts, true))
+	ts.Start()
+	defer ts.Close()
+
+	// assert that:
+	// - responses of paths without a write timeout complete
+	// - responses exceeding the server's write timeout are cut off
+	resp, err := http.Get(ts.URL + "/assets/vmlinuz")
+	if assert.Nil(t, err) {
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "done", string(body))
+	}
+	resp, err = http.Get(ts.URL + "/ignition")
+	if err == nil {
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NotEqual(t, "done", string(body))
+	}
+}
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// requireToken returns a handler which redeems the single-use "token" query
+// parameter before calling the next handler. Signature requests check the
+// token without redeeming it. Requests with a missing, invalid, or already
+// used token are forbidden. If the next handler fails (e.g. a config can't
+// be rendered), the token is restored so the machine can retry.
+func (s *Server) requireToken(core server.Server, next ContextHandler) ContextHandler {
+	if !s.ignitionTokens {
+		return next
+	}
+	fn := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		labels := labelsFromRequest(nil, req)
+		tokenReq := &pb.TokenRedeemRequest{
+			Token:  req.URL.Query().Get("token"),
+			Labels: labels,
+		}
+		var err error
+		if isSignature(ctx) {
+			err = core.TokenCheck(ctx, tokenReq)
+		} else {
+			err = core.TokenRedeem(ctx, tokenReq)
+		}
+		if err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"labels": s.redactor.Labels(labels),
+			}).Warningf("Rejected request for %v: %v", req.URL.Path, err)
+			writeProblem(w, http.StatusForbidden, CodeForbidden, "token rejected")
+			return
+		}
+		if isSignature(ctx) {
+			next.ServeHTTP(ctx, w, req)
+			return
+		}
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(ctx, rec, req)
+		if rec.status >= http.StatusBadRequest {
+			core.TokenRestore(ctx, tokenReq)
+		}
+	}
+	return ContextHandlerFunc(fn)
+}
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"context"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestRequireToken(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger, IgnitionTokens: true})
+	c := server.NewServer(&server.Config{Store: fake.NewFixedStore()})
+	next := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "secret config")
+	}
+	h := srv.requireToken(c, ContextHandlerFunc(next))
+	token, err := c.TokenCreate(context.Background(), &pb.TokenCreateRequest{
+		Labels: map[string]string{"uuid": "a1b2c3d4"},
+	})
+	assert.Nil(t, err)
+
+	cases := []struct {
+		url       string
+		signature bool
+		code      int
+	}{
+		// missing token
+		{"/ignition?uuid=a1b2c3d4", false, http.StatusForbidden},
+		{"/ignition?uuid=a1b2c3d4", true, http.StatusForbidden},
+		// token bound to a different machine
+		{"/ignition?uuid=e5f6&token=" + token, false, http.StatusForbidden},
+		// signatures don't use up the token
+		{"/ignition?uuid=a1b2c3d4&token=" + token, true, http.StatusOK},
+		{"/ignition?uuid=a1b2c3d4&token=" + token, false, http.StatusOK},
+		// token already used, its config may still be signed
+		{"/ignition?uuid=a1b2c3d4&token=" + token, false, http.StatusForbidden},
+		{"/ignition?uuid=a1b2c3d4&token=" + token, true, http.StatusOK},
+	}
+	for _, tc := range cases {
+		ctx := context.Background()
+		if tc.signature {
+			ctx = withSignature(ctx)
+		}
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", tc.url, nil)
+		h.ServeHTTP(ctx, w, req)
+		assert.Equal(t, tc.code, w.Code, tc.url)
+	}
+}
+
+func TestRequireToken_Failed(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{Logger: logger, IgnitionTokens: true})
+	c := server.NewServer(&server.Config{Store: fake.NewFixedStore()})
+	fail := true
+	next := func(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+		if fail {
+			http.Error(w, "render failed", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, "secret config")
+	}
+	h := srv.requireToken(c, ContextHandlerFunc(next))
+	token, err := c.TokenCreate(context.Background(), &pb.TokenCreateRequest{
+		Labels: map[string]string{"mac": "52:54:00:A1:9C:AE"},
+	})
+	assert.Nil(t, err)
+	url := "/ignition?mac=52-54-00-a1-9c-ae&token=" + token
+	// assert that:
+	// - tokens used by failed requests may be used again
+	// - tokens used by successful requests may not
+	for _, tc := range []struct {
+		fail bool
+		code int
+	}{
+		{true, http.StatusInternalServerError},
+		{false, http.StatusOK},
+		{false, http.StatusForbidden},
+	} {
+		fail = tc.fail
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", url, nil)
+		h.ServeHTTP(context.Background(), w, req)
+		assert.Equal(t, tc.code, w.Code)
+	}
+}
+
+func TestRequireToken_Disabled(t *testing.T) {
+	srv := NewServer(&Config{})
+	next := ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, req *http.Request) {})
+	// assert that:
+	// - the next handler is used directly when tokens are not required
+	h := srv.requireToken(nil, next)
+	assert.Equal(t, fmt.Sprintf("%p", next), fmt.Sprintf("%p", h))
+}
@@ -0,0 +1,101 @@
+package http
+
+import (
+	"net/http"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	"github.com/coreos/matchbox/matchbox/webhook"
+)
+
+// bootEndpoints are the endpoints machines request while provisioning,
+// whose failures count towards machine.failed events.
+var bootEndpoints = map[string]bool{
+	"/ipxe":     true,
+	"/grub":     true,
+	"/ignition": true,
+	"/cloud":    true,
+	"/generic":  true,
+	"/metadata": true,
+}
+
+// notifyEvents sends webhook events for a served boot request: first boots,
+// Ignition fetches, repeated failures, and boots of machines which fail to
+// progress.
+func (s *Server) notifyEvents(req *http.Request, status int, info *requestInfo) {
+	if s.webhooks == nil {
+		return
+	}
+	endpoint := req.URL.Path
+	if !bootEndpoints[endpoint] {
+		return
+	}
+	labels := labelsFromRequest(nil, req)
+	id := machineID(labels)
+	event := &webhook.Event{
+		MachineID: id,
+		Labels:    s.redactor.Labels(labels),
+		Group:     info.group,
+		Profile:   info.profile,
+		RequestID: info.id,
+	}
+	if status >= http.StatusBadRequest {
+		s.webhooks.Failure(event)
+		return
+	}
+	s.webhooks.Success(id)
+
+	switch endpoint {
+	case "/ipxe", "/grub":
+		if s.webhooks.Wants(webhook.EventBoot) && s.firstBoot(req, labels) {
+			event.Type = webhook.EventBoot
+			s.webhooks.Notify(event)
+		}
+		if s.webhooks.Watches() && !s.provisioned(req, labels) {
+			s.webhooks.Boot(event)
+		}
+	case "/ignition":
+		if status == http.StatusOK {
+			s.webhooks.Ignition(id)
+			event.Type = webhook.EventIgnition
+			s.webhooks.Notify(event)
+		}
+	}
+}
+
+// provisioned returns true if the machine UUID has completed provisioning,
+// so its boots are expected rather than a sign of a boot loop.
+func (s *Server) provisioned(req *http.Request, labels map[string]string) bool {
+	uuid := labels["uuid"]
+	if uuid == "" {
+		return false
+	}
+	machine, err := s.core.MachineGet(req.Context(), &pb.MachineGetRequest{Id: uuid})
+	return err == nil && machine.State == storagepb.MachineProvisioned
+}
+
+// firstBoot records a Machine for a machine UUID which has not been seen
+// before and returns true if the Machine was newly recorded. Machines pinned
+// before they first boot have no state yet and keep their pinned Group.
+func (s *Server) firstBoot(req *http.Request, labels map[string]string) bool {
+	uuid := labels["uuid"]
+	if uuid == "" {
+		return false
+	}
+	machine, err := s.core.MachineUpdate(req.Context(), uuid, func(machine *storagepb.Machine) (*storagepb.Machine, error) {
+		if machine == nil {
+			machine = &storagepb.Machine{Id: uuid}
+		}
+		if machine.State != "" {
+			return nil, nil
+		}
+		machine.Labels = labels
+		machine.State = storagepb.MachineBooted
+		return machine, nil
+	})
+	if err != nil {
+		s.logger.Errorf("error recording machine %s boot: %v", uuid, err)
+		return false
+	}
+	return machine != nil
+}
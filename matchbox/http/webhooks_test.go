@@ -0,0 +1,156 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+	"github.com/coreos/matchbox/matchbox/webhook"
+)
+
+func TestNotifyEvents(t *testing.T) {
+	events := make(chan *webhook.Event, 10)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		event := new(webhook.Event)
+		json.NewDecoder(req.Body).Decode(event)
+		events <- event
+	}))
+	defer hook.Close()
+
+	store := fake.NewFixedStore()
+	store.Groups[fake.Group.Id] = fake.Group
+	store.Profiles[fake.Profile.Id] = fake.Profile
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:   server.NewServer(&server.Config{Store: store}),
+		Logger: logger,
+		Webhooks: webhook.NewNotifier(&webhook.Config{
+			Hooks:            []webhook.Hook{{URL: hook.URL}},
+			FailureThreshold: 2,
+			Logger:           logger,
+		}),
+	})
+	h := srv.HTTPHandler()
+	get := func(url string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", url, nil)
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// assert that:
+	// - a machine's first boot is recorded and sent, but not later boots
+	assert.Equal(t, http.StatusOK, get("/ipxe?uuid=a1b2c3d4"))
+	event := <-events
+	assert.Equal(t, webhook.EventBoot, event.Type)
+	assert.Equal(t, "a1b2c3d4", event.MachineID)
+	assert.Equal(t, fake.Profile.Id, event.Profile)
+	if assert.NotNil(t, store.Machines["a1b2c3d4"]) {
+		assert.Equal(t, storagepb.MachineBooted, store.Machines["a1b2c3d4"].State)
+	}
+	assert.Equal(t, http.StatusOK, get("/ipxe?uuid=a1b2c3d4"))
+	// - repeated failures send one machine.failed event
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusNotFound, get("/cloud?uuid=a1b2c3d4"))
+	}
+	event = <-events
+	assert.Equal(t, webhook.EventFailed, event.Type)
+	assert.Equal(t, 2, event.Failures)
+	assert.Len(t, events, 0)
+}
+
+func TestFirstBootPinned(t *testing.T) {
+	events := make(chan *webhook.Event, 10)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		event := new(webhook.Event)
+		json.NewDecoder(req.Body).Decode(event)
+		events <- event
+	}))
+	defer hook.Close()
+
+	store := fake.NewFixedStore()
+	store.Groups[fake.Group.Id] = fake.Group
+	store.Profiles[fake.Profile.Id] = fake.Profile
+	store.Groups["default"] = &storagepb.Group{Id: "default", Profile: fake.Profile.Id}
+	store.Machines["e5f6a7b8"] = &storagepb.Machine{Id: "e5f6a7b8", Group: fake.Group.Id}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:   server.NewServer(&server.Config{Store: store}),
+		Logger: logger,
+		Webhooks: webhook.NewNotifier(&webhook.Config{
+			Hooks:  []webhook.Hook{{URL: hook.URL}},
+			Logger: logger,
+		}),
+	})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/ipxe?uuid=e5f6a7b8", nil)
+	srv.HTTPHandler().ServeHTTP(w, req)
+
+	// assert that:
+	// - a machine pinned before it boots receives the pinned Group
+	// - its first boot is recorded and sent, keeping the pinned Group
+	assert.Equal(t, http.StatusOK, w.Code)
+	event := <-events
+	assert.Equal(t, webhook.EventBoot, event.Type)
+	assert.Equal(t, "e5f6a7b8", event.MachineID)
+	machine := store.Machines["e5f6a7b8"]
+	assert.Equal(t, storagepb.MachineBooted, machine.State)
+	assert.Equal(t, fake.Group.Id, machine.Group)
+	assert.Equal(t, "e5f6a7b8", machine.Labels["uuid"])
+}
+
+func TestNotifyBootLoop(t *testing.T) {
+	events := make(chan *webhook.Event, 10)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		event := new(webhook.Event)
+		json.NewDecoder(req.Body).Decode(event)
+		events <- event
+	}))
+	defer hook.Close()
+
+	store := fake.NewFixedStore()
+	store.Groups[fake.Group.Id] = fake.Group
+	store.Profiles[fake.Profile.Id] = fake.Profile
+	store.Groups["default"] = &storagepb.Group{Id: "default", Profile: fake.Profile.Id}
+	store.Machines["e5f6a7b8"] = &storagepb.Machine{Id: "e5f6a7b8", State: storagepb.MachineProvisioned}
+	logger, _ := logtest.NewNullLogger()
+	srv := NewServer(&Config{
+		Core:   server.NewServer(&server.Config{Store: store}),
+		Logger: logger,
+		Webhooks: webhook.NewNotifier(&webhook.Config{
+			Hooks:             []webhook.Hook{{URL: hook.URL, Events: []string{webhook.EventBootLoop}}},
+			BootLoopThreshold: 2,
+			Logger:            logger,
+		}),
+	})
+	h := srv.HTTPHandler()
+	get := func(url string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", url, nil)
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// assert that:
+	// - provisioned machines which boot repeatedly are not reported
+	// - machines which boot repeatedly without fetching Ignition are
+	//   reported with their Profile
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, get("/ipxe?uuid=e5f6a7b8"))
+	}
+	assert.Equal(t, http.StatusOK, get("/ipxe?uuid=a1b2c3d4"))
+	assert.Len(t, events, 0)
+	assert.Equal(t, http.StatusOK, get("/ipxe?uuid=a1b2c3d4"))
+	event := <-events
+	assert.Equal(t, webhook.EventBootLoop, event.Type)
+	assert.Equal(t, "a1b2c3d4", event.MachineID)
+	assert.Equal(t, fake.Profile.Id, event.Profile)
+	assert.Equal(t, 2, event.Boots)
+}
@@ -0,0 +1,4 @@
+// Package inventory records the hardware inventory reported by machines'
+// BMCs as Machine facts, so Groups may select on hardware attributes before
+// machines first boot.
+package inventory
@@ -0,0 +1,236 @@
+package inventory
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"context"
+	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/power"
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+const (
+	// defaultInterval is how often inventory is read from BMCs.
+	defaultInterval = 15 * time.Minute
+	// syncTimeout bounds a sync of all BMCs.
+	syncTimeout = 5 * time.Minute
+	// scanWorkers is the number of BMCs read concurrently.
+	scanWorkers = 16
+)
+
+// Facts recorded from a BMC's inventory.
+const (
+	FactSerial       = "serial"
+	FactManufacturer = "manufacturer"
+	FactModel        = "model"
+	FactSKU          = "sku"
+	FactCPUs         = "cpus"
+	FactMemoryGiB    = "memory_gib"
+	FactNICs         = "nics"
+	FactDisks        = "disks"
+)
+
+// Config configures a Syncer.
+type Config struct {
+	// BMC URLs (ComputerSystem or base URLs) or CIDRs of BMCs to scan
+	Endpoints []string
+	// Interval between inventory reads
+	Interval  time.Duration
+	Inventory power.Inventory
+	Server    server.Server
+	Logger    *logrus.Logger
+}
+
+// Syncer periodically reads the hardware inventory of ComputerSystems from
+// BMCs and records it as facts on the Machines with their UUIDs, creating
+// Machines which have not booted yet. A Machine's BMC is set to its
+// ComputerSystem URL if it has none, which enables power control.
+type Syncer struct {
+	config *Config
+}
+
+// NewSyncer returns a new Syncer.
+func NewSyncer(config *Config) *Syncer {
+	if config.Interval == 0 {
+		config.Interval = defaultInterval
+	}
+	return &Syncer{
+		config: config,
+	}
+}
+
+// Run syncs inventory every interval until the stop channel is closed.
+func (s *Syncer) Run(stop <-chan struct{}) {
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+		if err := s.Sync(ctx); err != nil {
+			s.config.Logger.Errorf("inventory: %v", err)
+		}
+		cancel()
+		select {
+		case <-stop:
+			return
+		case <-time.After(s.config.Interval):
+		}
+	}
+}
+
+// Sync reads inventory from each BMC and records it on Machines. BMCs which
+// cannot be read are logged and skipped, except that unreachable addresses
+// within scanned CIDRs are expected and ignored.
+func (s *Syncer) Sync(ctx context.Context) error {
+	urls, scanned, err := expandEndpoints(s.config.Endpoints)
+	if err != nil {
+		return err
+	}
+	work := make(chan string)
+	var mu sync.Mutex
+	var systems []*power.System
+	var wg sync.WaitGroup
+	for i := 0; i < scanWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for url := range work {
+				found, err := s.config.Inventory.Systems(ctx, url)
+				if err != nil {
+					if !scanned[url] {
+						s.config.Logger.Warningf("inventory: error reading %s: %v", url, err)
+					}
+					continue
+				}
+				mu.Lock()
+				systems = append(systems, found...)
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, url := range urls {
+		work <- url
+	}
+	close(work)
+	wg.Wait()
+
+	for _, system := range systems {
+		if system.UUID == "" {
+			s.config.Logger.Warningf("inventory: ComputerSystem %s has no UUID", system.URL)
+			continue
+		}
+		if err := s.record(ctx, system.UUID, system); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// record sets the facts of a System on the Machine with an id.
+func (s *Syncer) record(ctx context.Context, id string, system *power.System) error {
+	facts := systemFacts(system)
+	machine, err := s.config.Server.MachineUpdate(ctx, id, func(machine *storagepb.Machine) (*storagepb.Machine, error) {
+		if machine == nil {
+			machine = &storagepb.Machine{Id: id}
+		}
+		changed := machine.SetFacts(facts)
+		if machine.Bmc == "" {
+			machine.Bmc = system.URL
+			changed = true
+		}
+		if !changed {
+			return nil, nil
+		}
+		return machine, nil
+	})
+	if machine != nil {
+		s.config.Logger.Debugf("inventory: recorded machine %s from %s", id, system.URL)
+	}
+	return err
+}
+
+// systemFacts returns the non-empty inventory attributes of a System.
+func systemFacts(system *power.System) map[string]string {
+	facts := map[string]string{
+		FactSerial:       system.SerialNumber,
+		FactManufacturer: system.Manufacturer,
+		FactModel:        system.Model,
+		FactSKU:          system.SKU,
+		FactCPUs:         strconv.Itoa(system.CPUs),
+		FactMemoryGiB:    strconv.FormatFloat(system.MemoryGiB, 'f', -1, 64),
+		FactNICs:         strconv.Itoa(system.NICs),
+		FactDisks:        strconv.Itoa(system.Disks),
+	}
+	for key, value := range facts {
+		if value == "" || value == "0" {
+			delete(facts, key)
+		}
+	}
+	return facts
+}
+
+// expandEndpoints returns the BMC URLs of endpoints, expanding CIDRs to the
+// https base URL of each host address. Scanned URLs are marked true.
+func expandEndpoints(endpoints []string) ([]string, map[string]bool, error) {
+	var urls []string
+	scanned := make(map[string]bool)
+	for _, endpoint := range endpoints {
+		endpoint = strings.TrimSpace(endpoint)
+		if endpoint == "" {
+			continue
+		}
+		if strings.Contains(endpoint, "://") {
+			urls = append(urls, endpoint)
+			continue
+		}
+		_, ipnet, err := net.ParseCIDR(endpoint)
+		if err != nil {
+			return nil, nil, fmt.Errorf("inventory: endpoint %q must be a URL or CIDR", endpoint)
+		}
+		ones, bits := ipnet.Mask.Size()
+		if bits-ones > 16 {
+			return nil, nil, fmt.Errorf("inventory: endpoint %q must be no larger than a /16", endpoint)
+		}
+		for _, host := range hosts(ipnet) {
+			url := "https://" + host.String()
+			if host.To4() == nil {
+				url = "https://[" + host.String() + "]"
+			}
+			urls = append(urls, url)
+			scanned[url] = true
+		}
+	}
+	return urls, scanned, nil
+}
+
+// hosts returns the host addresses of a network of at most 16 host bits,
+// excluding the network and broadcast addresses of IPv4 networks larger
+// than a /31 and the subnet-router anycast address of IPv6 networks larger
+// than a /127.
+func hosts(ipnet *net.IPNet) []net.IP {
+	ones, bits := ipnet.Mask.Size()
+	size := 1 << uint(bits-ones)
+	start := ipnet.IP.To4()
+	if start == nil {
+		start = ipnet.IP.To16()
+	}
+	last := len(start) - 1
+	var ips []net.IP
+	for i := 0; i < size; i++ {
+		if size > 2 && (i == 0 || (bits == 8*net.IPv4len && i == size-1)) {
+			continue
+		}
+		// host bits are within the last two bytes
+		ip := make(net.IP, len(start))
+		copy(ip, start)
+		n := int(ip[last-1])<<8 | int(ip[last])
+		n += i
+		ip[last-1], ip[last] = byte(n>>8), byte(n)
+		ips = append(ips, ip)
+	}
+	return ips
+}
@@ -0,0 +1,90 @@
+package inventory
+
+import (
+	"errors"
+	"testing"
+
+	"context"
+	"github.com/Sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/power"
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+// fakeInventory returns fixed Systems by URL.
+type fakeInventory map[string][]*power.System
+
+func (f fakeInventory) Systems(ctx context.Context, url string) ([]*power.System, error) {
+	if systems, ok := f[url]; ok {
+		return systems, nil
+	}
+	return nil, errors.New("unreachable")
+}
+
+func TestSync(t *testing.T) {
+	inventory := fakeInventory{
+		"https://10.0.0.5": {
+			{URL: "https://10.0.0.5/redfish/v1/Systems/1", UUID: "a1b2c3d4", SerialNumber: "SN1", Model: "PowerEdge R640", CPUs: 2},
+		},
+		"https://10.0.1.2": {
+			{URL: "https://10.0.1.2/redfish/v1/Systems/1", UUID: "e5f6a7b8", MemoryGiB: 192.5},
+		},
+	}
+	store := fake.NewFixedStore()
+	store.Machines["a1b2c3d4"] = &storagepb.Machine{
+		Id:    "a1b2c3d4",
+		State: storagepb.MachineProvisioned,
+		Bmc:   "https://bmc.example.com/redfish/v1/Systems/1",
+		Facts: map[string]string{"ip": "10.0.0.21"},
+	}
+	syncer := NewSyncer(&Config{
+		Endpoints: []string{"https://10.0.0.5", "10.0.1.0/30"},
+		Inventory: inventory,
+		Server:    server.NewServer(&server.Config{Store: store}),
+		Logger:    logrus.New(),
+	})
+	assert.Nil(t, syncer.Sync(context.Background()))
+
+	// assert that:
+	// - inventory is added to existing facts, keeping the Machine's BMC
+	// - Machines which have not booted are created with their BMC
+	// - CIDRs are scanned
+	known := store.Machines["a1b2c3d4"]
+	assert.Equal(t, map[string]string{"ip": "10.0.0.21", "serial": "SN1", "model": "PowerEdge R640", "cpus": "2"}, known.Facts)
+	assert.Equal(t, "https://bmc.example.com/redfish/v1/Systems/1", known.Bmc)
+	assert.Equal(t, storagepb.MachineProvisioned, known.State)
+	created := store.Machines["e5f6a7b8"]
+	if assert.NotNil(t, created) {
+		assert.Equal(t, map[string]string{"memory_gib": "192.5"}, created.Facts)
+		assert.Equal(t, "https://10.0.1.2/redfish/v1/Systems/1", created.Bmc)
+	}
+}
+
+func TestExpandEndpoints(t *testing.T) {
+	urls, scanned, err := expandEndpoints([]string{"https://10.0.0.5/redfish/v1/Systems/1", " 10.0.1.0/30", "10.0.2.8/31", "fd00::a:0/126"})
+	assert.Nil(t, err)
+	// assert that:
+	// - URLs are kept and IPv4 and IPv6 CIDRs are expanded to host
+	// addresses, with IPv6 addresses in brackets
+	expected := []string{
+		"https://10.0.0.5/redfish/v1/Systems/1",
+		"https://10.0.1.1",
+		"https://10.0.1.2",
+		"https://10.0.2.8",
+		"https://10.0.2.9",
+		"https://[fd00::a:1]",
+		"https://[fd00::a:2]",
+		"https://[fd00::a:3]",
+	}
+	assert.Equal(t, expected, urls)
+	assert.False(t, scanned["https://10.0.0.5/redfish/v1/Systems/1"])
+	assert.True(t, scanned["https://10.0.1.1"])
+
+	for _, invalid := range []string{"bmc.example.com", "fd00::/64", "10.0.0.0/8"} {
+		_, _, err = expandEndpoints([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,3 @@
+// Package kubeadm mints Kubernetes bootstrap tokens, which machines use to
+// join clusters with kubeadm.
+package kubeadm
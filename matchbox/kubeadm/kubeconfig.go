@@ -0,0 +1,140 @@
+package kubeadm
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// kubeconfig is the subset of a kubeconfig file needed to reach a cluster.
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			ClientCertificate     string `yaml:"client-certificate"`
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			ClientKey             string `yaml:"client-key"`
+			ClientKeyData         string `yaml:"client-key-data"`
+			Token                 string `yaml:"token"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster string `yaml:"cluster"`
+			User    string `yaml:"user"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+// endpoint is a Kubernetes API server and the credentials to use it.
+type endpoint struct {
+	server string
+	token  string
+	tls    *tls.Config
+}
+
+// loadKubeconfig returns the API server endpoint of the current context of
+// a kubeconfig file. Certificate and key paths are relative to the file.
+func loadKubeconfig(path string) (*endpoint, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := new(kubeconfig)
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("kubeadm: invalid kubeconfig %s: %v", path, err)
+	}
+	dir := filepath.Dir(path)
+
+	contextName := config.CurrentContext
+	if contextName == "" && len(config.Contexts) == 1 {
+		contextName = config.Contexts[0].Name
+	}
+	var clusterName, userName string
+	found := false
+	for _, c := range config.Contexts {
+		if c.Name == contextName {
+			clusterName, userName, found = c.Context.Cluster, c.Context.User, true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("kubeadm: kubeconfig %s has no context %q", path, contextName)
+	}
+
+	e := &endpoint{tls: &tls.Config{MinVersion: tls.VersionTLS12}}
+	for _, c := range config.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		e.server = strings.TrimSuffix(c.Cluster.Server, "/")
+		e.tls.InsecureSkipVerify = c.Cluster.InsecureSkipTLSVerify
+		ca, err := fileOrData(dir, c.Cluster.CertificateAuthority, c.Cluster.CertificateAuthorityData)
+		if err != nil {
+			return nil, err
+		}
+		if ca != nil {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(ca) {
+				return nil, fmt.Errorf("kubeadm: kubeconfig %s has an invalid certificate authority", path)
+			}
+			e.tls.RootCAs = pool
+		}
+	}
+	if e.server == "" {
+		return nil, fmt.Errorf("kubeadm: kubeconfig %s has no server for cluster %q", path, clusterName)
+	}
+	for _, u := range config.Users {
+		if u.Name != userName {
+			continue
+		}
+		e.token = u.User.Token
+		cert, err := fileOrData(dir, u.User.ClientCertificate, u.User.ClientCertificateData)
+		if err != nil {
+			return nil, err
+		}
+		key, err := fileOrData(dir, u.User.ClientKey, u.User.ClientKeyData)
+		if err != nil {
+			return nil, err
+		}
+		if cert != nil && key != nil {
+			pair, err := tls.X509KeyPair(cert, key)
+			if err != nil {
+				return nil, fmt.Errorf("kubeadm: kubeconfig %s has an invalid client certificate: %v", path, err)
+			}
+			e.tls.Certificates = []tls.Certificate{pair}
+		}
+	}
+	return e, nil
+}
+
+// fileOrData returns base64 encoded data or the contents of a file, or nil
+// if neither is set.
+func fileOrData(dir, file, data string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if file == "" {
+		return nil, nil
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+	return ioutil.ReadFile(file)
+}
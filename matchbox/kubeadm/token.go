@@ -0,0 +1,154 @@
+package kubeadm
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"context"
+)
+
+const (
+	// defaultTTL is how long minted tokens remain valid by default.
+	defaultTTL = 24 * time.Hour
+	// defaultTimeout bounds each request to the API server.
+	defaultTimeout = 30 * time.Second
+	// defaultGroup is the group kubeadm grants node bootstrap permissions.
+	defaultGroup = "system:bootstrappers:kubeadm:default-node-token"
+	// tokenChars are the characters of bootstrap token ids and secrets.
+	tokenChars = "abcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+// Config configures Tokens.
+type Config struct {
+	// Path to a kubeconfig with permission to create Secrets in kube-system
+	Kubeconfig string
+	// TTL of minted tokens (default 24h)
+	TTL time.Duration
+	// Extra groups tokens authenticate as (default kubeadm's node group)
+	Groups []string
+	// (optional) HTTP client, for testing
+	Client *http.Client
+}
+
+// Tokens mints bootstrap tokens by creating bootstrap token Secrets in the
+// kube-system namespace of a cluster.
+type Tokens struct {
+	endpoint *endpoint
+	ttl      time.Duration
+	groups   []string
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewTokens returns a new Tokens which uses the cluster of a kubeconfig.
+func NewTokens(config *Config) (*Tokens, error) {
+	endpoint, err := loadKubeconfig(config.Kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	client := config.Client
+	if client == nil {
+		client = &http.Client{
+			Timeout: defaultTimeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: endpoint.tls,
+			},
+		}
+	}
+	ttl := config.TTL
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	groups := config.Groups
+	if len(groups) == 0 {
+		groups = []string{defaultGroup}
+	}
+	return &Tokens{
+		endpoint: endpoint,
+		ttl:      ttl,
+		groups:   groups,
+		client:   client,
+		now:      time.Now,
+	}, nil
+}
+
+// Create mints a bootstrap token (e.g. "abcdef.0123456789abcdef") for
+// authenticating a node joining the cluster.
+func (t *Tokens) Create(ctx context.Context, description string) (string, error) {
+	id, err := randomString(6)
+	if err != nil {
+		return "", err
+	}
+	secret, err := randomString(16)
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]string{
+			"name":      "bootstrap-token-" + id,
+			"namespace": "kube-system",
+		},
+		"type": "bootstrap.kubernetes.io/token",
+		"stringData": map[string]string{
+			"description":                    description,
+			"token-id":                       id,
+			"token-secret":                   secret,
+			"expiration":                     t.now().Add(t.ttl).UTC().Format(time.RFC3339),
+			"usage-bootstrap-authentication": "true",
+			"usage-bootstrap-signing":        "true",
+			"auth-extra-groups":              strings.Join(t.groups, ","),
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	url := t.endpoint.server + "/api/v1/namespaces/kube-system/secrets"
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if t.endpoint.token != "" {
+		req.Header.Set("Authorization", "Bearer "+t.endpoint.token)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("kubeadm: POST %s: %s: %s", url, resp.Status, bytes.TrimSpace(msg))
+	}
+	return id + "." + secret, nil
+}
+
+// randomString returns n random token characters. Random bytes which would
+// bias the choice of characters are discarded.
+func randomString(n int) (string, error) {
+	limit := 256 - 256%len(tokenChars)
+	s := make([]byte, 0, n)
+	b := make([]byte, n)
+	for len(s) < n {
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		for _, c := range b {
+			if int(c) < limit && len(s) < n {
+				s = append(s, tokenChars[int(c)%len(tokenChars)])
+			}
+		}
+	}
+	return string(s), nil
+}
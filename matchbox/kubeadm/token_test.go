@@ -0,0 +1,125 @@
+package kubeadm
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"context"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeKubeconfig writes a kubeconfig for a server to a temporary directory.
+func writeKubeconfig(t *testing.T, server string) string {
+	dir, err := ioutil.TempDir("", "kubeadm")
+	assert.Nil(t, err)
+	path := filepath.Join(dir, "kubeconfig")
+	kubeconfig := `apiVersion: v1
+kind: Config
+current-context: admin@cluster
+clusters:
+- name: other
+  cluster:
+    server: https://other.example.com:6443
+- name: cluster
+  cluster:
+    server: ` + server + `
+contexts:
+- name: admin@cluster
+  context:
+    cluster: cluster
+    user: admin
+users:
+- name: admin
+  user:
+    token: s3cr3t
+`
+	assert.Nil(t, ioutil.WriteFile(path, []byte(kubeconfig), 0600))
+	return path
+}
+
+func TestCreate(t *testing.T) {
+	var secret map[string]interface{}
+	var auth, path string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		auth = req.Header.Get("Authorization")
+		path = req.URL.Path
+		json.NewDecoder(req.Body).Decode(&secret)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+	kubeconfig := writeKubeconfig(t, ts.URL+"/")
+	defer os.RemoveAll(filepath.Dir(kubeconfig))
+
+	tokens, err := NewTokens(&Config{Kubeconfig: kubeconfig, TTL: time.Hour})
+	assert.Nil(t, err)
+	tokens.now = func() time.Time { return time.Date(2017, 5, 1, 12, 0, 0, 0, time.UTC) }
+	token, err := tokens.Create(context.Background(), "node1")
+	assert.Nil(t, err)
+
+	// assert that:
+	// - tokens have the bootstrap token format
+	// - a bootstrap token Secret is created in kube-system with the
+	//   kubeconfig's credentials
+	assert.Regexp(t, regexp.MustCompile(`^[a-z0-9]{6}\.[a-z0-9]{16}$`), token)
+	assert.Equal(t, "Bearer s3cr3t", auth)
+	assert.Equal(t, "/api/v1/namespaces/kube-system/secrets", path)
+	assert.Equal(t, "bootstrap-token-"+token[:6], secret["metadata"].(map[string]interface{})["name"])
+	assert.Equal(t, "bootstrap.kubernetes.io/token", secret["type"])
+	assert.Equal(t, map[string]interface{}{
+		"description":                    "node1",
+		"token-id":                       token[:6],
+		"token-secret":                   token[7:],
+		"expiration":                     "2017-05-01T13:00:00Z",
+		"usage-bootstrap-authentication": "true",
+		"usage-bootstrap-signing":        "true",
+		"auth-extra-groups":              "system:bootstrappers:kubeadm:default-node-token",
+	}, secret["stringData"])
+}
+
+func TestCreate_Forbidden(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, `{"kind":"Status","reason":"Forbidden"}`, http.StatusForbidden)
+	}))
+	defer ts.Close()
+	kubeconfig := writeKubeconfig(t, ts.URL)
+	defer os.RemoveAll(filepath.Dir(kubeconfig))
+
+	tokens, err := NewTokens(&Config{Kubeconfig: kubeconfig})
+	assert.Nil(t, err)
+	_, err = tokens.Create(context.Background(), "node1")
+	assert.Contains(t, err.Error(), "403 Forbidden")
+}
+
+func TestLoadKubeconfig_Errors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeadm")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	noContext := filepath.Join(dir, "no-context")
+	ioutil.WriteFile(noContext, []byte("current-context: missing\n"), 0600)
+	badCA := filepath.Join(dir, "bad-ca")
+	ioutil.WriteFile(badCA, []byte(`current-context: c
+contexts:
+- name: c
+  context: {cluster: c}
+clusters:
+- name: c
+  cluster: {server: "https://10.0.0.1:6443", certificate-authority: ca.crt}
+`), 0600)
+	ioutil.WriteFile(filepath.Join(dir, "ca.crt"), []byte("not a certificate"), 0600)
+
+	_, err = loadKubeconfig(filepath.Join(dir, "missing"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = loadKubeconfig(noContext)
+	assert.Contains(t, err.Error(), `has no context "missing"`)
+	// assert that:
+	// - certificate paths are relative to the kubeconfig
+	_, err = loadKubeconfig(badCA)
+	assert.Contains(t, err.Error(), "invalid certificate authority")
+}
@@ -0,0 +1,4 @@
+// Package leader elects one of several matchbox replicas which share a store
+// as the leader, which runs the background controllers (e.g. Git sync and
+// BMC inventory polling) while every replica serves boot traffic.
+package leader
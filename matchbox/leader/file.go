@@ -0,0 +1,146 @@
+package leader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"context"
+)
+
+// staleMutex is the age after which a mutex file left by a replica which
+// exited while updating the lease is removed.
+const staleMutex = 10 * time.Second
+
+// fileRecord is the JSON file format of a FileLock.
+type fileRecord struct {
+	Record
+	Revision int64 `json:"revision"`
+}
+
+// FileLock is a Lock stored as a JSON file, in a directory shared by the
+// replicas (e.g. the data directory on a shared volume).
+type FileLock struct {
+	path string
+}
+
+// NewFileLock returns a FileLock at path.
+func NewFileLock(path string) *FileLock {
+	return &FileLock{path: path}
+}
+
+// Describe returns the path of the lock.
+func (l *FileLock) Describe() string {
+	return l.path
+}
+
+// Get returns the Record, or nil if the file doesn't exist.
+func (l *FileLock) Get(ctx context.Context) (*Record, error) {
+	rec, err := l.read()
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rec.Record, nil
+}
+
+// Create creates the file, or returns ErrConflict if it exists. The file is
+// linked into place, which fails atomically if it exists.
+func (l *FileLock) Create(ctx context.Context, record *Record) (*Record, error) {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return nil, err
+	}
+	rec := &fileRecord{Record: *record, Revision: 1}
+	tmp, err := l.writeTemp(rec)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp)
+	if err := os.Link(tmp, l.path); err != nil {
+		if os.IsExist(err) {
+			return nil, ErrConflict
+		}
+		return nil, err
+	}
+	rec.Version = strconv.FormatInt(rec.Revision, 10)
+	return &rec.Record, nil
+}
+
+// Update replaces the file if its revision matches the Record's Version, or
+// returns ErrConflict. Replicas serialize updates with an exclusively
+// created mutex file.
+func (l *FileLock) Update(ctx context.Context, record *Record) (*Record, error) {
+	mutex := l.path + ".lock"
+	f, err := os.OpenFile(mutex, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if os.IsExist(err) {
+		if info, serr := os.Stat(mutex); serr == nil && time.Since(info.ModTime()) > staleMutex {
+			os.Remove(mutex)
+		}
+		return nil, ErrConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	defer os.Remove(mutex)
+
+	current, err := l.read()
+	if err != nil {
+		return nil, err
+	}
+	if strconv.FormatInt(current.Revision, 10) != record.Version {
+		return nil, ErrConflict
+	}
+	rec := &fileRecord{Record: *record, Revision: current.Revision + 1}
+	tmp, err := l.writeTemp(rec)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, l.path); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+	rec.Version = strconv.FormatInt(rec.Revision, 10)
+	return &rec.Record, nil
+}
+
+// read reads the file.
+func (l *FileLock) read() (*fileRecord, error) {
+	data, err := ioutil.ReadFile(l.path)
+	if err != nil {
+		return nil, err
+	}
+	rec := new(fileRecord)
+	if err := json.Unmarshal(data, rec); err != nil {
+		return nil, fmt.Errorf("leader: invalid lock file %s: %v", l.path, err)
+	}
+	rec.Version = strconv.FormatInt(rec.Revision, 10)
+	return rec, nil
+}
+
+// writeTemp writes a record to a temporary file beside the lock file.
+func (l *FileLock) writeTemp(rec *fileRecord) (string, error) {
+	data, err := json.MarshalIndent(rec, "", "\t")
+	if err != nil {
+		return "", err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(l.path), "."+filepath.Base(l.path))
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,63 @@
+package leader
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"context"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "leader")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	lock := NewFileLock(filepath.Join(dir, "leader", "matchbox.json"))
+	ctx := context.Background()
+	now := time.Now().UTC().Round(time.Second)
+
+	// assert that:
+	// - missing files have no record
+	rec, err := lock.Get(ctx)
+	assert.Nil(t, err)
+	assert.Nil(t, rec)
+
+	// - records are created once
+	created, err := lock.Create(ctx, &Record{Holder: "a", Duration: time.Second, Renewed: now})
+	assert.Nil(t, err)
+	assert.Equal(t, "1", created.Version)
+	_, err = lock.Create(ctx, &Record{Holder: "b"})
+	assert.Equal(t, ErrConflict, err)
+	rec, err = lock.Get(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, created, rec)
+
+	// - updates of the current version succeed, stale versions conflict
+	update := *rec
+	update.Renewed = now.Add(time.Second)
+	updated, err := lock.Update(ctx, &update)
+	assert.Nil(t, err)
+	assert.Equal(t, "2", updated.Version)
+	_, err = lock.Update(ctx, rec)
+	assert.Equal(t, ErrConflict, err)
+
+	// - updates conflict while another replica holds the mutex, until it's
+	// stale
+	mutex := lock.path + ".lock"
+	assert.Nil(t, ioutil.WriteFile(mutex, nil, 0644))
+	_, err = lock.Update(ctx, updated)
+	assert.Equal(t, ErrConflict, err)
+	old := time.Now().Add(-time.Minute)
+	assert.Nil(t, os.Chtimes(mutex, old, old))
+	_, err = lock.Update(ctx, updated)
+	assert.Equal(t, ErrConflict, err)
+	_, err = lock.Update(ctx, updated)
+	assert.Nil(t, err)
+
+	// - temporary files are removed
+	files, _ := ioutil.ReadDir(filepath.Dir(lock.path))
+	assert.Len(t, files, 1)
+}
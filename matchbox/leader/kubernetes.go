@@ -0,0 +1,240 @@
+package leader
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"context"
+)
+
+// in-cluster service account paths
+const (
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	// Kubernetes MicroTime format
+	microTime = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+// Possible Kubernetes lock errors
+var (
+	ErrNotInCluster = errors.New("leader: Kubernetes API server unknown, not running in a cluster")
+)
+
+// KubernetesConfig configures a KubernetesLock. Unset fields default to the
+// in-cluster API server and the pod's service account.
+type KubernetesConfig struct {
+	// (optional) API server URL
+	Server string
+	// (optional) Namespace of the Lease
+	Namespace string
+	// Name of the Lease
+	Name string
+	// (optional) bearer token, otherwise the service account token is read
+	// before each request, since it's rotated
+	Token string
+	// (optional) HTTP client
+	Client *http.Client
+}
+
+// lease is a coordination.k8s.io/v1 Lease.
+type lease struct {
+	APIVersion string        `json:"apiVersion"`
+	Kind       string        `json:"kind"`
+	Metadata   leaseMetadata `json:"metadata"`
+	Spec       leaseSpec     `json:"spec"`
+}
+
+type leaseMetadata struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+type leaseSpec struct {
+	HolderIdentity       string `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds int32  `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          string `json:"acquireTime,omitempty"`
+	RenewTime            string `json:"renewTime,omitempty"`
+	LeaseTransitions     int32  `json:"leaseTransitions,omitempty"`
+}
+
+// KubernetesLock is a Lock stored as a Kubernetes Lease.
+type KubernetesLock struct {
+	server    string
+	namespace string
+	name      string
+	token     string
+	tokenPath string
+	client    *http.Client
+}
+
+// NewKubernetesLock returns a KubernetesLock.
+func NewKubernetesLock(config *KubernetesConfig) (*KubernetesLock, error) {
+	l := &KubernetesLock{
+		server:    strings.TrimSuffix(config.Server, "/"),
+		namespace: config.Namespace,
+		name:      config.Name,
+		token:     config.Token,
+		client:    config.Client,
+	}
+	if l.server == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return nil, ErrNotInCluster
+		}
+		l.server = "https://" + net.JoinHostPort(host, port)
+	}
+	if l.namespace == "" {
+		data, err := ioutil.ReadFile(serviceAccountDir + "/namespace")
+		if err != nil {
+			return nil, fmt.Errorf("leader: Lease namespace required: %v", err)
+		}
+		l.namespace = strings.TrimSpace(string(data))
+	}
+	if l.token == "" {
+		l.tokenPath = serviceAccountDir + "/token"
+	}
+	if l.client == nil {
+		pem, err := ioutil.ReadFile(serviceAccountDir + "/ca.crt")
+		if err != nil {
+			return nil, fmt.Errorf("leader: error reading service account CA: %v", err)
+		}
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(pem)
+		l.client = &http.Client{
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+			Timeout:   10 * time.Second,
+		}
+	}
+	return l, nil
+}
+
+// Describe returns the namespace and name of the Lease.
+func (l *KubernetesLock) Describe() string {
+	return "Lease " + l.namespace + "/" + l.name
+}
+
+// Get returns the Record of the Lease, or nil if it doesn't exist.
+func (l *KubernetesLock) Get(ctx context.Context) (*Record, error) {
+	var got lease
+	status, err := l.do(ctx, "GET", l.leaseURL(), nil, &got)
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toRecord(&got), nil
+}
+
+// Create creates the Lease, or returns ErrConflict if it exists.
+func (l *KubernetesLock) Create(ctx context.Context, record *Record) (*Record, error) {
+	var created lease
+	_, err := l.do(ctx, "POST", l.server+"/apis/coordination.k8s.io/v1/namespaces/"+l.namespace+"/leases", l.toLease(record), &created)
+	if err != nil {
+		return nil, err
+	}
+	return toRecord(&created), nil
+}
+
+// Update replaces the Lease if its resourceVersion is the Record's Version,
+// or returns ErrConflict.
+func (l *KubernetesLock) Update(ctx context.Context, record *Record) (*Record, error) {
+	var updated lease
+	_, err := l.do(ctx, "PUT", l.leaseURL(), l.toLease(record), &updated)
+	if err != nil {
+		return nil, err
+	}
+	return toRecord(&updated), nil
+}
+
+// leaseURL returns the API URL of the Lease.
+func (l *KubernetesLock) leaseURL() string {
+	return l.server + "/apis/coordination.k8s.io/v1/namespaces/" + l.namespace + "/leases/" + l.name
+}
+
+// do sends an API request, decoding the response into out. Conflicts are
+// ErrConflict.
+func (l *KubernetesLock) do(ctx context.Context, method, url string, in, out interface{}) (int, error) {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return 0, err
+		}
+	}
+	req, err := http.NewRequest(method, url, &body)
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	token := l.token
+	if l.tokenPath != "" {
+		data, err := ioutil.ReadFile(l.tokenPath)
+		if err != nil {
+			return 0, err
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusConflict:
+		return resp.StatusCode, ErrConflict
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return resp.StatusCode, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return resp.StatusCode, json.Unmarshal(data, out)
+}
+
+// toLease converts a Record to a Lease.
+func (l *KubernetesLock) toLease(record *Record) *lease {
+	return &lease{
+		APIVersion: "coordination.k8s.io/v1",
+		Kind:       "Lease",
+		Metadata: leaseMetadata{
+			Name:            l.name,
+			Namespace:       l.namespace,
+			ResourceVersion: record.Version,
+		},
+		Spec: leaseSpec{
+			HolderIdentity:       record.Holder,
+			LeaseDurationSeconds: int32((record.Duration + time.Second - 1) / time.Second),
+			AcquireTime:          record.Acquired.UTC().Format(microTime),
+			RenewTime:            record.Renewed.UTC().Format(microTime),
+			LeaseTransitions:     record.Transitions,
+		},
+	}
+}
+
+// toRecord converts a Lease to a Record. Unparseable times are zero.
+func toRecord(l *lease) *Record {
+	acquired, _ := time.Parse(time.RFC3339Nano, l.Spec.AcquireTime)
+	renewed, _ := time.Parse(time.RFC3339Nano, l.Spec.RenewTime)
+	return &Record{
+		Holder:      l.Spec.HolderIdentity,
+		Duration:    time.Duration(l.Spec.LeaseDurationSeconds) * time.Second,
+		Acquired:    acquired,
+		Renewed:     renewed,
+		Transitions: l.Spec.LeaseTransitions,
+		Version:     l.Metadata.ResourceVersion,
+	}
+}
@@ -0,0 +1,131 @@
+package leader
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"context"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeLeases serves a Kubernetes API with a single Lease.
+type fakeLeases struct {
+	mu      sync.Mutex
+	lease   *lease
+	version int
+}
+
+func (f *fakeLeases) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if req.Header.Get("Authorization") != "Bearer token" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	const leases = "/apis/coordination.k8s.io/v1/namespaces/metal/leases"
+	switch {
+	case req.Method == "GET" && req.URL.Path == leases+"/matchbox":
+		if f.lease == nil {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+	case req.Method == "POST" && req.URL.Path == leases:
+		if f.lease != nil {
+			http.Error(w, "already exists", http.StatusConflict)
+			return
+		}
+		f.lease = f.decode(req)
+		f.lease.Metadata.ResourceVersion = f.nextVersion()
+	case req.Method == "PUT" && req.URL.Path == leases+"/matchbox":
+		l := f.decode(req)
+		if f.lease == nil || l.Metadata.ResourceVersion != f.lease.Metadata.ResourceVersion {
+			http.Error(w, "conflict", http.StatusConflict)
+			return
+		}
+		l.Metadata.ResourceVersion = f.nextVersion()
+		f.lease = l
+	default:
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(f.lease)
+}
+
+func (f *fakeLeases) decode(req *http.Request) *lease {
+	l := new(lease)
+	json.NewDecoder(req.Body).Decode(l)
+	return l
+}
+
+func (f *fakeLeases) nextVersion() string {
+	f.version++
+	return strconv.Itoa(f.version)
+}
+
+func TestKubernetesLock(t *testing.T) {
+	api := &fakeLeases{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	lock, err := NewKubernetesLock(&KubernetesConfig{
+		Server:    srv.URL,
+		Namespace: "metal",
+		Name:      "matchbox",
+		Token:     "token",
+		Client:    srv.Client(),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "Lease metal/matchbox", lock.Describe())
+	ctx := context.Background()
+	now := time.Date(2026, 10, 15, 12, 0, 0, 500000000, time.UTC)
+
+	// assert that:
+	// - missing Leases have no record
+	rec, err := lock.Get(ctx)
+	assert.Nil(t, err)
+	assert.Nil(t, rec)
+
+	// - Leases are created once, converting Record fields
+	created, err := lock.Create(ctx, &Record{Holder: "a", Duration: 15 * time.Second, Acquired: now, Renewed: now})
+	assert.Nil(t, err)
+	assert.Equal(t, &Record{Holder: "a", Duration: 15 * time.Second, Acquired: now, Renewed: now, Version: "1"}, created)
+	assert.Equal(t, int32(15), api.lease.Spec.LeaseDurationSeconds)
+	assert.Equal(t, "2026-10-15T12:00:00.500000Z", api.lease.Spec.RenewTime)
+	_, err = lock.Create(ctx, &Record{Holder: "b"})
+	assert.Equal(t, ErrConflict, err)
+
+	// - updates of the current resourceVersion succeed, stale versions
+	// conflict
+	rec, err = lock.Get(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, created, rec)
+	update := *rec
+	update.Holder = "b"
+	update.Transitions = 1
+	updated, err := lock.Update(ctx, &update)
+	assert.Nil(t, err)
+	assert.Equal(t, "2", updated.Version)
+	assert.Equal(t, "b", api.lease.Spec.HolderIdentity)
+	_, err = lock.Update(ctx, rec)
+	assert.Equal(t, ErrConflict, err)
+
+	// - API errors are errors
+	lock.token = "wrong"
+	_, err = lock.Get(ctx)
+	assert.Error(t, err)
+}
+
+func TestNewKubernetesLock_NotInCluster(t *testing.T) {
+	// assert that:
+	// - the API server is required outside of a cluster
+	if _, ok := os.LookupEnv("KUBERNETES_SERVICE_HOST"); ok {
+		t.Skip("running in a cluster")
+	}
+	_, err := NewKubernetesLock(&KubernetesConfig{Name: "matchbox"})
+	assert.Equal(t, ErrNotInCluster, err)
+}
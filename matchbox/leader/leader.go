@@ -0,0 +1,335 @@
+package leader
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"context"
+	"github.com/Sirupsen/logrus"
+)
+
+// DefaultLeaseDuration is how long a leader holds the lease without renewing
+// it before another replica may acquire it.
+const DefaultLeaseDuration = 15 * time.Second
+
+// Possible lock errors
+var (
+	// ErrConflict is returned if a record was created or changed by another
+	// replica since it was read.
+	ErrConflict = errors.New("leader: lease record changed concurrently")
+)
+
+// Record is the state of a lease.
+type Record struct {
+	// Holder is the identity of the leader, or empty if released
+	Holder string `json:"holder"`
+	// Duration the lease is held without renewal
+	Duration time.Duration `json:"duration"`
+	// Acquired is when the Holder acquired the lease
+	Acquired time.Time `json:"acquired"`
+	// Renewed is when the Holder last renewed the lease
+	Renewed time.Time `json:"renewed"`
+	// Transitions counts changes of Holder
+	Transitions int32 `json:"transitions"`
+	// Version of the record in its Lock, which changes on each update
+	Version string `json:"-"`
+}
+
+// A Lock stores a lease Record shared by replicas.
+type Lock interface {
+	// Get returns the Record, or nil if none exists.
+	Get(ctx context.Context) (*Record, error)
+	// Create creates the Record, or returns ErrConflict if one exists.
+	Create(ctx context.Context, record *Record) (*Record, error)
+	// Update replaces the Record if its Version is unchanged, or returns
+	// ErrConflict.
+	Update(ctx context.Context, record *Record) (*Record, error)
+	// Describe returns a description of the Lock for logs.
+	Describe() string
+}
+
+// Config configures an Elector.
+type Config struct {
+	Lock Lock
+	// (optional) Identity of this replica, defaults to the hostname with a
+	// random suffix
+	Identity string
+	// LeaseDuration (0 uses the default)
+	LeaseDuration time.Duration
+	Logger        *logrus.Logger
+}
+
+// controller is a background task run by the leader.
+type controller struct {
+	name string
+	run  func(stop <-chan struct{})
+}
+
+// Elector competes for a lease and runs controllers while this replica holds
+// it.
+type Elector struct {
+	lock     Lock
+	identity string
+	duration time.Duration
+	// renewals are retried every period and the lease is given up if it
+	// can't be renewed within the deadline, before others may acquire it
+	period   time.Duration
+	deadline time.Duration
+	logger   *logrus.Logger
+
+	controllers []controller
+
+	mu       sync.Mutex
+	leader   bool
+	observed *Record
+	// observedTime is the local time the observed Record changed, so expiry
+	// doesn't depend on clocks agreeing across replicas
+	observedTime time.Time
+	now          func() time.Time
+}
+
+// NewElector returns a new Elector.
+func NewElector(config *Config) *Elector {
+	duration := config.LeaseDuration
+	if duration == 0 {
+		duration = DefaultLeaseDuration
+	}
+	identity := config.Identity
+	if identity == "" {
+		identity = defaultIdentity()
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = logrus.New()
+	}
+	return &Elector{
+		lock:     config.Lock,
+		identity: identity,
+		duration: duration,
+		period:   duration / 5,
+		deadline: duration * 2 / 3,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// defaultIdentity returns the hostname with a random suffix, so replicas on
+// the same host are distinct.
+func defaultIdentity() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "matchbox"
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return hostname + "_" + hex.EncodeToString(suffix)
+}
+
+// Add registers a controller which is run while this replica is the leader
+// and stopped when it loses the lease. Controllers must be added before Run.
+func (e *Elector) Add(name string, run func(stop <-chan struct{})) {
+	e.controllers = append(e.controllers, controller{name: name, run: run})
+}
+
+// Identity returns the identity of this replica.
+func (e *Elector) Identity() string {
+	return e.identity
+}
+
+// IsLeader returns true if this replica holds the lease.
+func (e *Elector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leader
+}
+
+// Leader returns the identity of the last observed leader, or an empty
+// string if there is none.
+func (e *Elector) Leader() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.observed == nil {
+		return ""
+	}
+	return e.observed.Holder
+}
+
+// Run competes for the lease until stop is closed, running the controllers
+// while this replica is the leader. The lease is released when stopped.
+func (e *Elector) Run(stop <-chan struct{}) {
+	isLeader.Set(0)
+	e.logger.Infof("leader: %s competing for %s", e.identity, e.lock.Describe())
+	for {
+		if !e.acquire(stop) {
+			return
+		}
+		e.lead(stop)
+		select {
+		case <-stop:
+			return
+		default:
+		}
+	}
+}
+
+// acquire retries acquiring the lease until it succeeds, returning false if
+// stopped first.
+func (e *Elector) acquire(stop <-chan struct{}) bool {
+	for {
+		if e.tryAcquireOrRenew() {
+			return true
+		}
+		select {
+		case <-stop:
+			return false
+		case <-time.After(e.period):
+		}
+	}
+}
+
+// lead runs the controllers and renews the lease until it can't be renewed
+// within the deadline or stop is closed.
+func (e *Elector) lead(stop <-chan struct{}) {
+	e.setLeader(true)
+	e.logger.Infof("leader: %s became the leader, starting %d controllers", e.identity, len(e.controllers))
+	stopControllers := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, c := range e.controllers {
+		wg.Add(1)
+		go func(c controller) {
+			defer wg.Done()
+			e.logger.Debugf("leader: starting %s", c.name)
+			c.run(stopControllers)
+		}(c)
+	}
+
+	renewed := e.now()
+	for {
+		select {
+		case <-stop:
+			e.setLeader(false)
+			close(stopControllers)
+			wg.Wait()
+			e.release()
+			return
+		case <-time.After(e.period):
+		}
+		if e.tryAcquireOrRenew() {
+			renewed = e.now()
+			continue
+		}
+		if e.now().Sub(renewed) >= e.deadline {
+			e.logger.Warningf("leader: %s lost the lease, stopping controllers", e.identity)
+			e.setLeader(false)
+			close(stopControllers)
+			wg.Wait()
+			return
+		}
+	}
+}
+
+// setLeader records whether this replica is the leader.
+func (e *Elector) setLeader(leader bool) {
+	e.mu.Lock()
+	e.leader = leader
+	e.mu.Unlock()
+	if leader {
+		isLeader.Set(1)
+		transitionsTotal.Inc("acquired")
+	} else {
+		isLeader.Set(0)
+		transitionsTotal.Inc("lost")
+	}
+}
+
+// tryAcquireOrRenew acquires the lease if it's free or expired, or renews it
+// if held by this replica. Returns true if this replica holds the lease.
+func (e *Elector) tryAcquireOrRenew() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), e.period)
+	defer cancel()
+	now := e.now()
+	desired := &Record{
+		Holder:   e.identity,
+		Duration: e.duration,
+		Acquired: now,
+		Renewed:  now,
+	}
+
+	current, err := e.lock.Get(ctx)
+	if err != nil {
+		e.logger.Errorf("leader: error reading %s: %v", e.lock.Describe(), err)
+		return false
+	}
+	if current == nil {
+		created, err := e.lock.Create(ctx, desired)
+		if err != nil {
+			if err != ErrConflict {
+				e.logger.Errorf("leader: error creating %s: %v", e.lock.Describe(), err)
+			}
+			return false
+		}
+		e.observe(created, now)
+		return true
+	}
+
+	e.mu.Lock()
+	if e.observed == nil || e.observed.Version != current.Version {
+		e.observed = current
+		e.observedTime = now
+	}
+	expires := e.observedTime.Add(current.Duration)
+	e.mu.Unlock()
+	if current.Holder != e.identity && current.Holder != "" && now.Before(expires) {
+		return false
+	}
+
+	desired.Version = current.Version
+	desired.Transitions = current.Transitions
+	if current.Holder == e.identity {
+		desired.Acquired = current.Acquired
+	} else {
+		desired.Transitions++
+	}
+	updated, err := e.lock.Update(ctx, desired)
+	if err != nil {
+		if err != ErrConflict {
+			e.logger.Errorf("leader: error updating %s: %v", e.lock.Describe(), err)
+		}
+		return false
+	}
+	e.observe(updated, now)
+	return true
+}
+
+// release gives up the lease, so another replica may acquire it without
+// waiting for it to expire.
+func (e *Elector) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), e.period)
+	defer cancel()
+	e.mu.Lock()
+	observed := e.observed
+	e.mu.Unlock()
+	if observed == nil || observed.Holder != e.identity {
+		return
+	}
+	released := *observed
+	released.Holder = ""
+	released.Renewed = e.now()
+	if _, err := e.lock.Update(ctx, &released); err != nil {
+		e.logger.Warningf("leader: error releasing %s: %v", e.lock.Describe(), err)
+		return
+	}
+	e.logger.Infof("leader: %s released the lease", e.identity)
+}
+
+// observe records a Record written by this replica.
+func (e *Elector) observe(record *Record, now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.observed = record
+	e.observedTime = now
+}
@@ -0,0 +1,179 @@
+package leader
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"context"
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// memLock is an in-memory Lock.
+type memLock struct {
+	mu     sync.Mutex
+	record *Record
+	err    error
+}
+
+func (l *memLock) Describe() string { return "memory" }
+
+func (l *memLock) Get(ctx context.Context) (*Record, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil || l.record == nil {
+		return nil, l.err
+	}
+	rec := *l.record
+	return &rec, nil
+}
+
+func (l *memLock) Create(ctx context.Context, record *Record) (*Record, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.record != nil {
+		return nil, ErrConflict
+	}
+	rec := *record
+	rec.Version = "1"
+	l.record = &rec
+	return &rec, nil
+}
+
+func (l *memLock) Update(ctx context.Context, record *Record) (*Record, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return nil, l.err
+	}
+	if l.record == nil || l.record.Version != record.Version {
+		return nil, ErrConflict
+	}
+	version, _ := strconv.Atoi(l.record.Version)
+	rec := *record
+	rec.Version = strconv.Itoa(version + 1)
+	l.record = &rec
+	return &rec, nil
+}
+
+func newTestElector(lock Lock, identity string, now *time.Time) *Elector {
+	logger, _ := logtest.NewNullLogger()
+	e := NewElector(&Config{Lock: lock, Identity: identity, LeaseDuration: 10 * time.Second, Logger: logger})
+	e.now = func() time.Time { return *now }
+	return e
+}
+
+func TestTryAcquireOrRenew(t *testing.T) {
+	lock := &memLock{}
+	now := time.Now()
+	a := newTestElector(lock, "a", &now)
+	b := newTestElector(lock, "b", &now)
+
+	// assert that:
+	// - the first replica acquires a free lease
+	// - other replicas can't acquire a held lease
+	// - the holder renews its lease, keeping its acquire time
+	assert.True(t, a.tryAcquireOrRenew())
+	assert.False(t, b.tryAcquireOrRenew())
+	assert.Equal(t, "a", b.Leader())
+	acquired := lock.record.Acquired
+	now = now.Add(5 * time.Second)
+	assert.True(t, a.tryAcquireOrRenew())
+	assert.Equal(t, acquired, lock.record.Acquired)
+	assert.Equal(t, now, lock.record.Renewed)
+
+	// - leases are expired by the time they were observed to change, not
+	// their renew time
+	now = now.Add(9 * time.Second)
+	assert.False(t, b.tryAcquireOrRenew())
+
+	// - other replicas acquire expired leases, counting the transition
+	now = now.Add(10 * time.Second)
+	assert.True(t, b.tryAcquireOrRenew())
+	assert.Equal(t, "b", lock.record.Holder)
+	assert.Equal(t, int32(1), lock.record.Transitions)
+	assert.False(t, a.tryAcquireOrRenew())
+
+	// - released leases are acquired immediately
+	b.release()
+	assert.Equal(t, "", lock.record.Holder)
+	assert.True(t, a.tryAcquireOrRenew())
+
+	// - lock errors don't acquire the lease
+	lock.err = context.DeadlineExceeded
+	assert.False(t, a.tryAcquireOrRenew())
+}
+
+func TestElector_Run(t *testing.T) {
+	lock := &memLock{}
+	logger, _ := logtest.NewNullLogger()
+	e := NewElector(&Config{Lock: lock, Identity: "a", LeaseDuration: 50 * time.Millisecond, Logger: logger})
+	started := make(chan struct{})
+	stopped := make(chan struct{})
+	e.Add("test", func(stop <-chan struct{}) {
+		close(started)
+		<-stop
+		close(stopped)
+	})
+
+	// assert that:
+	// - controllers run once the replica is the leader
+	// - controllers are stopped and the lease released when stopped
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		e.Run(stop)
+		close(done)
+	}()
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("controller wasn't started")
+	}
+	assert.True(t, e.IsLeader())
+	close(stop)
+	<-done
+	<-stopped
+	assert.False(t, e.IsLeader())
+	assert.Equal(t, "", lock.record.Holder)
+}
+
+func TestElector_Lost(t *testing.T) {
+	lock := &memLock{}
+	logger, _ := logtest.NewNullLogger()
+	e := NewElector(&Config{Lock: lock, Identity: "a", LeaseDuration: 50 * time.Millisecond, Logger: logger})
+	runs := make(chan struct{}, 2)
+	stopped := make(chan struct{}, 2)
+	e.Add("test", func(stop <-chan struct{}) {
+		runs <- struct{}{}
+		<-stop
+		stopped <- struct{}{}
+	})
+	stop := make(chan struct{})
+	defer close(stop)
+	go e.Run(stop)
+	<-runs
+
+	// assert that:
+	// - controllers are stopped if the lease can't be renewed
+	// - controllers run again when the lease is reacquired
+	lock.mu.Lock()
+	lock.err = context.DeadlineExceeded
+	lock.mu.Unlock()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("controller wasn't stopped")
+	}
+	assert.False(t, e.IsLeader())
+	lock.mu.Lock()
+	lock.err = nil
+	lock.mu.Unlock()
+	select {
+	case <-runs:
+	case <-time.After(time.Second):
+		t.Fatal("controller wasn't restarted")
+	}
+}
@@ -0,0 +1,15 @@
+package leader
+
+import (
+	"github.com/coreos/matchbox/matchbox/metrics"
+)
+
+var (
+	isLeader = metrics.NewGaugeVec(
+		"matchbox_leader",
+		"Whether this replica is the elected leader (1) or not (0).")
+	transitionsTotal = metrics.NewCounterVec(
+		"matchbox_leader_transitions_total",
+		"Leadership changes of this replica by kind (acquired or lost).",
+		"kind")
+)
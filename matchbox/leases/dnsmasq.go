@@ -0,0 +1,69 @@
+package leases
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Lease is a DHCP lease of an IP address to a MAC address.
+type Lease struct {
+	// Expiry is the zero Time for infinite leases
+	Expiry   time.Time
+	MAC      string
+	IP       string
+	Hostname string
+}
+
+// Expired returns true if the lease expired before now.
+func (l *Lease) Expired(now time.Time) bool {
+	return !l.Expiry.IsZero() && l.Expiry.Before(now)
+}
+
+// ParseDnsmasq parses a dnsmasq lease file (dhcp-leasefile). Each DHCPv4
+// lease is a line "<expiry> <mac> <ip> <hostname> <client-id>", where
+// unknown values are "*". DHCPv6 leases, which follow a "duid" line, are
+// skipped since they are not keyed by MAC address.
+func ParseDnsmasq(r io.Reader) ([]*Lease, error) {
+	var leases []*Lease
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "duid" {
+			break
+		}
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("leases: line %d: expected at least 4 fields", n)
+		}
+		expiry, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("leases: line %d: invalid expiry %q", n, fields[0])
+		}
+		mac, err := net.ParseMAC(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("leases: line %d: invalid MAC address %q", n, fields[1])
+		}
+		if net.ParseIP(fields[2]) == nil {
+			return nil, fmt.Errorf("leases: line %d: invalid IP address %q", n, fields[2])
+		}
+		lease := &Lease{
+			MAC: mac.String(),
+			IP:  fields[2],
+		}
+		if expiry != 0 {
+			lease.Expiry = time.Unix(expiry, 0)
+		}
+		if fields[3] != "*" {
+			lease.Hostname = fields[3]
+		}
+		leases = append(leases, lease)
+	}
+	return leases, scanner.Err()
+}
@@ -0,0 +1,44 @@
+package leases
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const leaseFile = `1491350400 52:54:00:A1:9C:AE 10.0.0.21 node1 01:52:54:00:a1:9c:ae
+0 52:54:00:b2:2f:86 10.0.0.22 * *
+duid 00:01:00:01:20:4b:14:6a:52:54:00:a1:9c:ae
+1491350400 1234 fd00::21 node1 00:01:00:01:20:4b:14:6a
+`
+
+func TestParseDnsmasq(t *testing.T) {
+	leases, err := ParseDnsmasq(strings.NewReader(leaseFile))
+	assert.Nil(t, err)
+	// assert that:
+	// - MAC addresses are normalized
+	// - unknown hostnames and infinite expiries are empty
+	// - DHCPv6 leases are skipped
+	expected := []*Lease{
+		{Expiry: time.Unix(1491350400, 0), MAC: "52:54:00:a1:9c:ae", IP: "10.0.0.21", Hostname: "node1"},
+		{MAC: "52:54:00:b2:2f:86", IP: "10.0.0.22"},
+	}
+	assert.Equal(t, expected, leases)
+	assert.True(t, leases[0].Expired(time.Unix(1491350401, 0)))
+	assert.False(t, leases[1].Expired(time.Unix(1491350401, 0)))
+}
+
+func TestParseDnsmasq_Invalid(t *testing.T) {
+	cases := []string{
+		"1491350400 52:54:00:a1:9c:ae 10.0.0.21",
+		"never 52:54:00:a1:9c:ae 10.0.0.21 node1 *",
+		"1491350400 not-a-mac 10.0.0.21 node1 *",
+		"1491350400 52:54:00:a1:9c:ae not-an-ip node1 *",
+	}
+	for _, c := range cases {
+		_, err := ParseDnsmasq(strings.NewReader(c))
+		assert.NotNil(t, err, c)
+	}
+}
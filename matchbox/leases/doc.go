@@ -0,0 +1,4 @@
+// Package leases records the IP addresses and hostnames of DHCP leases as
+// Machine facts, so Groups may select on them and the metadata endpoint can
+// report them.
+package leases
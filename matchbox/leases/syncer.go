@@ -0,0 +1,133 @@
+package leases
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// defaultInterval is how often the lease file is checked for changes.
+const defaultInterval = 10 * time.Second
+
+// Facts recorded from a lease.
+const (
+	FactIP       = "ip"
+	FactHostname = "hostname"
+)
+
+// Config configures a Syncer.
+type Config struct {
+	// Path to the dnsmasq lease file
+	Path string
+	// Interval between checks of the lease file for changes
+	Interval time.Duration
+	Server   server.Server
+	Logger   *logrus.Logger
+}
+
+// Syncer records the leases in a dnsmasq lease file as Machine facts.
+// Machines are matched by MAC address, either as their id or as their
+// reported mac label. Leases for unknown MAC addresses create Machines with
+// the MAC address as the id, which boot requests find by their mac label.
+type Syncer struct {
+	config  *Config
+	modTime time.Time
+	now     func() time.Time
+}
+
+// NewSyncer returns a new Syncer.
+func NewSyncer(config *Config) *Syncer {
+	if config.Interval == 0 {
+		config.Interval = defaultInterval
+	}
+	return &Syncer{
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// Run syncs leases whenever the lease file changes until the stop channel
+// is closed.
+func (s *Syncer) Run(stop <-chan struct{}) {
+	for {
+		if info, err := os.Stat(s.config.Path); err != nil {
+			s.config.Logger.Errorf("leases: %v", err)
+		} else if !info.ModTime().Equal(s.modTime) {
+			if err := s.Sync(context.Background()); err != nil {
+				s.config.Logger.Errorf("leases: error syncing %s: %v", s.config.Path, err)
+			} else {
+				s.modTime = info.ModTime()
+			}
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(s.config.Interval):
+		}
+	}
+}
+
+// Sync records the facts of each unexpired lease on the Machines with its
+// MAC address.
+func (s *Syncer) Sync(ctx context.Context) error {
+	f, err := os.Open(s.config.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	leases, err := ParseDnsmasq(f)
+	if err != nil {
+		return err
+	}
+	machines, err := s.config.Server.MachineList(ctx, &pb.MachineListRequest{})
+	if err != nil {
+		return err
+	}
+	byMAC := make(map[string][]*storagepb.Machine)
+	for _, machine := range machines {
+		if mac := machine.Labels["mac"]; mac != "" && mac != machine.Id {
+			byMAC[mac] = append(byMAC[mac], machine)
+		}
+		byMAC[machine.Id] = append(byMAC[machine.Id], machine)
+	}
+
+	now := s.now()
+	for _, lease := range leases {
+		if lease.Expired(now) {
+			continue
+		}
+		facts := map[string]string{FactIP: lease.IP}
+		if lease.Hostname != "" {
+			facts[FactHostname] = lease.Hostname
+		}
+		matches := byMAC[lease.MAC]
+		if len(matches) == 0 {
+			matches = []*storagepb.Machine{{Id: lease.MAC}}
+		}
+		for _, match := range matches {
+			id := match.Id
+			machine, err := s.config.Server.MachineUpdate(ctx, id, func(machine *storagepb.Machine) (*storagepb.Machine, error) {
+				if machine == nil {
+					machine = &storagepb.Machine{Id: id}
+				}
+				if !machine.SetFacts(facts) {
+					return nil, nil
+				}
+				return machine, nil
+			})
+			if err != nil {
+				return err
+			}
+			if machine != nil {
+				s.config.Logger.Debugf("leases: machine %s has IP %s", id, lease.IP)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package leases
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestSync(t *testing.T) {
+	f, err := ioutil.TempFile("", "dnsmasq.leases")
+	assert.Nil(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(leaseFile + "1491350400 52:54:00:c3:44:11 10.0.0.23 expired *\n")
+	assert.Nil(t, err)
+	f.Close()
+
+	store := fake.NewFixedStore()
+	store.Machines["a1b2c3d4"] = &storagepb.Machine{
+		Id:     "a1b2c3d4",
+		Labels: map[string]string{"uuid": "a1b2c3d4", "mac": "52:54:00:a1:9c:ae"},
+		State:  storagepb.MachineProvisioned,
+	}
+	syncer := NewSyncer(&Config{
+		Path:   f.Name(),
+		Server: server.NewServer(&server.Config{Store: store}),
+		Logger: logrus.New(),
+	})
+	syncer.now = func() time.Time { return time.Unix(1491350000, 0) }
+	assert.Nil(t, syncer.Sync(context.Background()))
+
+	// assert that:
+	// - known machines are matched by their mac label
+	// - unknown MAC addresses create machines
+	// - expired leases are ignored
+	assert.Equal(t, map[string]string{"ip": "10.0.0.21", "hostname": "node1"}, store.Machines["a1b2c3d4"].Facts)
+	assert.Equal(t, storagepb.MachineProvisioned, store.Machines["a1b2c3d4"].State)
+	assert.Equal(t, map[string]string{"ip": "10.0.0.22"}, store.Machines["52:54:00:b2:2f:86"].Facts)
+	syncer.now = func() time.Time { return time.Unix(1491350401, 0) }
+	store.Machines = map[string]*storagepb.Machine{}
+	assert.Nil(t, syncer.Sync(context.Background()))
+	assert.Nil(t, store.Machines["52:54:00:a1:9c:ae"])
+	assert.Nil(t, store.Machines["52:54:00:c3:44:11"])
+	assert.NotNil(t, store.Machines["52:54:00:b2:2f:86"])
+}
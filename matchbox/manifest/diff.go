@@ -0,0 +1,175 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Diff operations on a resource, from the point of view of applying the
+// local manifest to the server.
+const (
+	// DiffAdded resources are only in the local manifest
+	DiffAdded = "added"
+	// DiffModified resources differ between the manifest and the server
+	DiffModified = "modified"
+	// DiffUnmanaged resources are only on the server (apply leaves them)
+	DiffUnmanaged = "unmanaged"
+)
+
+// A Change is a difference in a resource between two manifests.
+type Change struct {
+	// Op is DiffAdded, DiffModified, or DiffUnmanaged
+	Op string
+	// Resource names the resource (e.g. group/node1, ignition/etcd.yaml)
+	Resource string
+	// Lines which differ, prefixed with '-' (server) or '+' (local)
+	Lines []string
+}
+
+// Diff compares a local manifest to the manifest of a server and returns
+// the changes, sorted by resource. Groups and Profiles are compared field by
+// field and templates line by line.
+func Diff(local, server *Manifest) ([]*Change, error) {
+	localDocs, err := documents(local)
+	if err != nil {
+		return nil, err
+	}
+	serverDocs, err := documents(server)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []*Change
+	for resource, lines := range localDocs {
+		current, ok := serverDocs[resource]
+		if !ok {
+			changes = append(changes, &Change{
+				Op:       DiffAdded,
+				Resource: resource,
+				Lines:    diffLines(nil, lines),
+			})
+			continue
+		}
+		if diff := diffLines(current, lines); len(diff) > 0 {
+			changes = append(changes, &Change{
+				Op:       DiffModified,
+				Resource: resource,
+				Lines:    diff,
+			})
+		}
+	}
+	for resource := range serverDocs {
+		if _, ok := localDocs[resource]; !ok {
+			changes = append(changes, &Change{
+				Op:       DiffUnmanaged,
+				Resource: resource,
+			})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Resource < changes[j].Resource })
+	return changes, nil
+}
+
+// documents returns the lines of each resource in a manifest, keyed by
+// resource name. Groups and Profiles are flattened to one line per field.
+func documents(m *Manifest) (map[string][]string, error) {
+	docs := make(map[string][]string)
+	for _, group := range m.Groups {
+		richGroup, err := group.ToRichGroup()
+		if err != nil {
+			return nil, err
+		}
+		lines, err := flatten(richGroup)
+		if err != nil {
+			return nil, err
+		}
+		docs["group/"+group.Id] = lines
+	}
+	for _, profile := range m.Profiles {
+		lines, err := flatten(profile)
+		if err != nil {
+			return nil, err
+		}
+		docs["profile/"+profile.Id] = lines
+	}
+	for _, tmpl := range m.Templates {
+		docs[tmpl.Kind+"/"+tmpl.Name] = strings.Split(strings.TrimSuffix(string(tmpl.Contents), "\n"), "\n")
+	}
+	return docs, nil
+}
+
+// flatten returns a "path: value" line for each scalar field of the JSON
+// encoding of v, in sorted order.
+func flatten(v interface{}) ([]string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var lines []string
+	flattenValue("", doc, &lines)
+	sort.Strings(lines)
+	return lines, nil
+}
+
+func flattenValue(path string, v interface{}, lines *[]string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if path != "" {
+				key = path + "." + key
+			}
+			flattenValue(key, value, lines)
+		}
+	case []interface{}:
+		for i, value := range v {
+			flattenValue(fmt.Sprintf("%s[%d]", path, i), value, lines)
+		}
+	default:
+		value, _ := json.Marshal(v)
+		*lines = append(*lines, fmt.Sprintf("%s: %s", path, value))
+	}
+}
+
+// diffLines returns the lines removed from a ('-') and added in b ('+'),
+// based on their longest common subsequence.
+func diffLines(a, b []string) []string {
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var diff []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			diff = append(diff, "-"+a[i])
+			i++
+		default:
+			diff = append(diff, "+"+b[j])
+			j++
+		}
+	}
+	return diff
+}
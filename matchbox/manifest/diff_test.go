@@ -0,0 +1,65 @@
+package manifest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+func TestDiff(t *testing.T) {
+	local := &Manifest{
+		Groups: []*storagepb.Group{
+			{Id: "a", Profile: "worker", Selector: map[string]string{"mac": "52:54:00:89:d8:10"}},
+			{Id: "b", Profile: "worker"},
+		},
+		Profiles: []*storagepb.Profile{
+			{Id: "worker", Boot: &storagepb.NetBoot{Kernel: "/assets/vmlinuz", Args: []string{"console=ttyS0"}}},
+		},
+		Templates: []*Template{
+			{Kind: KindIgnition, Name: "worker.yaml", Contents: []byte("a\nb\nd\n")},
+		},
+	}
+	server := &Manifest{
+		Groups: []*storagepb.Group{
+			{Id: "a", Profile: "worker", Selector: map[string]string{"mac": "52:54:00:89:d8:10"}},
+			{Id: "c", Profile: "worker"},
+		},
+		Profiles: []*storagepb.Profile{
+			{Id: "worker", Boot: &storagepb.NetBoot{Kernel: "/assets/vmlinuz", Args: []string{"console=tty0"}}},
+		},
+		Templates: []*Template{
+			{Kind: KindIgnition, Name: "worker.yaml", Contents: []byte("a\nc\nd\n")},
+		},
+	}
+	changes, err := Diff(local, server)
+	// assert that:
+	// - identical resources are omitted
+	// - local-only resources are added, server-only resources are unmanaged
+	// - Profiles differ by field and templates differ by line
+	expected := []*Change{
+		{Op: DiffAdded, Resource: "group/b", Lines: []string{`+id: "b"`, `+profile: "worker"`}},
+		{Op: DiffUnmanaged, Resource: "group/c"},
+		{Op: DiffModified, Resource: "ignition/worker.yaml", Lines: []string{"-c", "+b"}},
+		{Op: DiffModified, Resource: "profile/worker", Lines: []string{`-boot.args[0]: "console=tty0"`, `+boot.args[0]: "console=ttyS0"`}},
+	}
+	assert.Nil(t, err)
+	assert.Equal(t, expected, changes)
+}
+
+func TestDiffLines(t *testing.T) {
+	cases := []struct {
+		a, b     []string
+		expected []string
+	}{
+		{nil, nil, nil},
+		{[]string{"x"}, []string{"x"}, nil},
+		{nil, []string{"x", "y"}, []string{"+x", "+y"}},
+		{[]string{"x", "y"}, nil, []string{"-x", "-y"}},
+		{[]string{"a", "b", "c"}, []string{"a", "c", "d"}, []string{"-b", "+d"}},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, diffLines(c.a, c.b))
+	}
+}
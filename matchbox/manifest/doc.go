@@ -0,0 +1,3 @@
+// Package manifest reads matchbox Groups, Profiles, and templates from a
+// directory tree in the conventional data directory layout.
+package manifest
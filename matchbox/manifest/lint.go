@@ -0,0 +1,400 @@
+package manifest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	fuze "github.com/coreos/fuze/config"
+	ignition "github.com/coreos/ignition/config"
+	"github.com/coreos/ignition/config/validate/report"
+
+	"github.com/coreos/matchbox/matchbox/deprecation"
+	"github.com/coreos/matchbox/matchbox/extension"
+	"github.com/coreos/matchbox/matchbox/sealed"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// Problem kinds
+const (
+	ProblemError   = "error"
+	ProblemWarning = "warning"
+)
+
+// A Problem is an error or warning found by Lint in a manifest file.
+type Problem struct {
+	Path    string
+	Line    int
+	Column  int
+	Kind    string
+	Message string
+}
+
+// String formats the problem as "path:line:column: kind: message", omitting
+// an unknown position.
+func (p *Problem) String() string {
+	pos := p.Path
+	if p.Line > 0 {
+		pos += ":" + strconv.Itoa(p.Line)
+		if p.Column > 0 {
+			pos += ":" + strconv.Itoa(p.Column)
+		}
+	}
+	return fmt.Sprintf("%s: %s: %s", pos, p.Kind, p.Message)
+}
+
+// templateLineRegexp matches the line of text/template parse errors
+// ("template: name:line: msg").
+var templateLineRegexp = regexp.MustCompile(`(?s)^template: .*?:(\d+): (.*)$`)
+
+// yamlLineRegexp matches the line of a single YAML unmarshal error.
+var yamlLineRegexp = regexp.MustCompile(`^yaml: unmarshal errors:\n\s+line (\d+): ([^\n]*)$`)
+
+// YAMLErrorLine returns the line and message of a single YAML unmarshal
+// error (e.g. in a Fuze report), or 0 and the unchanged message.
+func YAMLErrorLine(msg string) (int, string) {
+	m := yamlLineRegexp.FindStringSubmatch(msg)
+	if m == nil {
+		return 0, msg
+	}
+	line, _ := strconv.Atoi(m[1])
+	return line, m[2]
+}
+
+// linter collects problems and the resources defined in a manifest.
+type linter struct {
+	problems  []*Problem
+	groups    map[string]*storagepb.Group
+	profiles  map[string]*storagepb.Profile
+	templates map[string]bool
+	paths     map[string]string
+}
+
+// Lint checks the manifest in the given directory and returns every problem
+// found, unlike Load which stops at the first. It checks that Groups and
+// Profiles are well-formed JSON or YAML with known fields, that selectors are sane,
+// that Groups and Profiles only reference resources in the manifest, and
+// that Ignition and Fuze configs are valid. Templates with actions are only
+// checked for template syntax, since they are validated once rendered.
+func Lint(dir string) ([]*Problem, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, ErrNotDirectory
+	}
+	l := &linter{
+		groups:    make(map[string]*storagepb.Group),
+		profiles:  make(map[string]*storagepb.Profile),
+		templates: make(map[string]bool),
+		paths:     make(map[string]string),
+	}
+	if err := l.walk(filepath.Join(dir, "groups"), l.lintGroup); err != nil {
+		return nil, err
+	}
+	if err := l.walk(filepath.Join(dir, "profiles"), l.lintProfile); err != nil {
+		return nil, err
+	}
+	for _, kind := range Kinds {
+		kind := kind
+		err := l.walk(filepath.Join(dir, kind), func(path string, data []byte) {
+			l.lintTemplate(kind, path, data)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	l.lintReferences()
+	l.lintSelectors()
+
+	sort.SliceStable(l.problems, func(i, j int) bool {
+		a, b := l.problems[i], l.problems[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return l.problems, nil
+}
+
+// LintTemplate checks the contents of a single template of the given kind
+// and name, as Lint checks template files, and returns the problems found,
+// sorted by position. Problems are reported with the template name as
+// their path.
+func LintTemplate(kind, name string, data []byte) []*Problem {
+	l := &linter{templates: make(map[string]bool)}
+	l.lintTemplate(kind, name, data)
+	sort.SliceStable(l.problems, func(i, j int) bool {
+		a, b := l.problems[i], l.problems[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return l.problems
+}
+
+// walk calls fn with the contents of each regular file in dir.
+func (l *linter) walk(dir string, fn func(path string, data []byte)) error {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, finfo := range files {
+		if !finfo.Mode().IsRegular() {
+			continue
+		}
+		path := filepath.Join(dir, finfo.Name())
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fn(path, data)
+	}
+	return nil
+}
+
+func (l *linter) add(path string, line, column int, kind, format string, args ...interface{}) {
+	l.problems = append(l.problems, &Problem{
+		Path:    path,
+		Line:    line,
+		Column:  column,
+		Kind:    kind,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *linter) lintGroup(path string, data []byte) {
+	group, err := storagepb.ParseGroupFile(path, data)
+	if err == nil {
+		err = group.AssertValid()
+	}
+	if err != nil {
+		l.addError(path, err)
+		return
+	}
+	l.checkFilename(path, group.Id)
+	l.addWarnings(path, deprecation.Group(group))
+	if other, ok := l.paths["group/"+group.Id]; ok {
+		l.add(path, 0, 0, ProblemError, "duplicate group id %q, also defined in %s", group.Id, other)
+		return
+	}
+	l.paths["group/"+group.Id] = path
+	l.groups[group.Id] = group
+}
+
+func (l *linter) lintProfile(path string, data []byte) {
+	profile, err := storagepb.ParseProfileFile(path, data)
+	if err == nil {
+		err = profile.AssertValid()
+	}
+	if err != nil {
+		l.addError(path, err)
+		return
+	}
+	l.checkFilename(path, profile.Id)
+	l.addWarnings(path, deprecation.Profile(profile))
+	if profile.Boot != nil {
+		// kernel and initrd paths and kernel args may be templates,
+		// rendered when booting
+		funcs := extension.Default().TemplateFuncs()
+		check := func(field, name, value string) {
+			if _, err := template.New(name).Funcs(funcs).Parse(value); err != nil {
+				l.add(path, 0, 0, ProblemError, "%s: %v", field, err)
+			}
+		}
+		check("boot kernel", "kernel", profile.Boot.Kernel)
+		for i, initrd := range profile.Boot.Initrd {
+			check(fmt.Sprintf("boot initrd %d", i), "initrd", initrd)
+		}
+		for i, arg := range profile.Boot.Args {
+			check(fmt.Sprintf("boot arg %d", i), "arg", arg)
+		}
+	}
+	if other, ok := l.paths["profile/"+profile.Id]; ok {
+		l.add(path, 0, 0, ProblemError, "duplicate profile id %q, also defined in %s", profile.Id, other)
+		return
+	}
+	l.paths["profile/"+profile.Id] = path
+	l.profiles[profile.Id] = profile
+}
+
+// addError records an error, at its position if it is a ParseError.
+func (l *linter) addError(path string, err error) {
+	if perr, ok := err.(*storagepb.ParseError); ok {
+		l.add(path, perr.Line, perr.Column, ProblemError, "%v", perr.Err)
+		return
+	}
+	l.add(path, 0, 0, ProblemError, "%v", err)
+}
+
+// addWarnings records uses of deprecated fields.
+func (l *linter) addWarnings(path string, warnings []*deprecation.Warning) {
+	for _, w := range warnings {
+		l.add(path, 0, 0, ProblemWarning, "%s", w)
+	}
+}
+
+// checkFilename warns if a resource is not named for its id (with a JSON or
+// YAML extension), since the matchbox file store reads resources by id.
+func (l *linter) checkFilename(path, id string) {
+	base := filepath.Base(path)
+	expected := id + ".json"
+	if storagepb.IsYAML(base) {
+		expected = id + filepath.Ext(base)
+	}
+	if base != expected {
+		l.add(path, 0, 0, ProblemWarning, "file should be named %s for id %q", expected, id)
+	}
+}
+
+func (l *linter) lintTemplate(kind, path string, data []byte) {
+	name := filepath.Base(path)
+	l.templates[kind+"/"+name] = true
+	contents := string(data)
+
+	if kind == KindIgnition && (strings.HasSuffix(name, ".ign") || strings.HasSuffix(name, ".ignition")) {
+		_, rpt, err := ignition.Parse(data)
+		l.addReport(path, rpt, 0)
+		if err != nil && len(rpt.Entries) == 0 {
+			l.add(path, 0, 0, ProblemError, "%v", err)
+		}
+		return
+	}
+
+	// include and kubeadmToken are only resolved when rendering, extension
+	// functions are those registered in this build
+	funcs := extension.Default().TemplateFuncs()
+	funcs["include"] = func(string, interface{}) (string, error) { return "", nil }
+	funcs["kubeadmToken"] = func() (string, error) { return "", nil }
+	if _, err := template.New(name).Funcs(funcs).Parse(contents); err != nil {
+		line := 0
+		msg := err.Error()
+		if m := templateLineRegexp.FindStringSubmatch(msg); m != nil {
+			line, _ = strconv.Atoi(m[1])
+			msg = m[2]
+		}
+		l.add(path, line, 0, ProblemError, "%s", msg)
+		return
+	}
+	if kind == KindIgnition && !strings.Contains(contents, "{{") {
+		// Fuze positions are 0-based
+		_, rpt := fuze.Parse(data)
+		l.addReport(path, rpt, 1)
+	}
+}
+
+// addReport records the entries of an Ignition or Fuze validation report,
+// adding base to known positions.
+func (l *linter) addReport(path string, rpt report.Report, base int) {
+	for _, entry := range rpt.Entries {
+		kind := ProblemWarning
+		if entry.Kind == report.EntryError {
+			kind = ProblemError
+		}
+		line, column, msg := entry.Line, entry.Column, entry.Message
+		if line > 0 || column > 0 {
+			line, column = line+base, column+base
+		} else {
+			line, msg = YAMLErrorLine(msg)
+		}
+		l.add(path, line, column, kind, "%s", msg)
+	}
+}
+
+// lintReferences reports Groups and Profiles which reference resources
+// missing from the manifest.
+func (l *linter) lintReferences() {
+	for id, group := range l.groups {
+		if profile, ok := l.profiles[group.Profile]; !ok {
+			l.add(l.paths["group/"+id], 0, 0, ProblemError, "group references missing profile %q", group.Profile)
+		} else {
+			l.lintMetadata(group, profile)
+		}
+		if group.Rollout == nil {
+			continue
+		}
+		if profile, ok := l.profiles[group.Rollout.Profile]; !ok {
+			l.add(l.paths["group/"+id], 0, 0, ProblemError, "group rollout references missing profile %q", group.Rollout.Profile)
+		} else {
+			l.lintMetadata(group, profile)
+		}
+	}
+	for id, profile := range l.profiles {
+		type reference struct{ kind, name string }
+		refs := []reference{
+			{KindCloud, profile.CloudId},
+			{KindGeneric, profile.GenericId},
+		}
+		// remote Ignition configs aren't in the manifest, their snippets are
+		if !storagepb.IsIgnitionURL(profile.IgnitionId) {
+			refs = append(refs, reference{KindIgnition, profile.IgnitionId})
+		}
+		for _, snippet := range profile.IgnitionSnippets {
+			refs = append(refs, reference{KindIgnition, snippet})
+		}
+		for _, ref := range refs {
+			if ref.name != "" && !l.templates[ref.kind+"/"+ref.name] {
+				l.add(l.paths["profile/"+id], 0, 0, ProblemError, "profile references missing %s template %q", ref.kind, ref.name)
+			}
+		}
+	}
+}
+
+// lintMetadata reports Group metadata which doesn't satisfy the metadata
+// schema of a Profile. Metadata from sources or encrypted values is only
+// known when rendered, so those Groups are skipped.
+func (l *linter) lintMetadata(group *storagepb.Group, profile *storagepb.Profile) {
+	if len(group.MetadataSources) > 0 || sealed.MayBeEncrypted(group.Metadata) {
+		return
+	}
+	if err := profile.ValidateMetadata(group); err != nil {
+		l.add(l.paths["group/"+group.Id], 0, 0, ProblemError, "%v", err)
+	}
+}
+
+// lintSelectors reports selectors which can never match or which match the
+// same machines as another Group, making the match ambiguous.
+func (l *linter) lintSelectors() {
+	bySelector := make(map[string]string)
+	ids := make([]string, 0, len(l.groups))
+	for id := range l.groups {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		group := l.groups[id]
+		path := l.paths["group/"+id]
+		reqs := make([]string, 0, len(group.Selector))
+		for key, value := range group.Selector {
+			if value == "" {
+				l.add(path, 0, 0, ProblemWarning, "selector %q has an empty value", key)
+			}
+			reqs = append(reqs, key+"="+value)
+		}
+		sort.Strings(reqs)
+		selector := strings.Join(reqs, ",")
+		if other, ok := bySelector[selector]; ok {
+			if selector == "" {
+				l.add(path, 0, 0, ProblemWarning, "group %q and group %q are both default groups (no selectors)", id, other)
+			} else {
+				l.add(path, 0, 0, ProblemWarning, "group %q has the same selectors as group %q", id, other)
+			}
+			continue
+		}
+		bySelector[selector] = id
+	}
+}
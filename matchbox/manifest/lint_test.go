@@ -0,0 +1,111 @@
+package manifest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"groups/worker.json":   `{"id":"worker","profile":"worker","selector":{"mac":"52:54:00:89:d8:10"}}`,
+		"profiles/worker.json": `{"id":"worker","ignition_id":"worker.yaml","generic_id":"worker.tmpl"}`,
+		"profiles/remote.json": `{"id":"remote","ignition_id":"https://example.com/remote.ign","ignition_snippets":["worker.yaml"]}`,
+		"ignition/worker.yaml": "systemd:\n  units:\n    - name: etcd.service\n      enable: true\n",
+		"generic/worker.tmpl":  "{{.mac}}",
+	})
+	defer os.RemoveAll(dir)
+
+	problems, err := Lint(dir)
+	// assert that:
+	// - a valid manifest has no problems
+	assert.Nil(t, err)
+	assert.Empty(t, problems)
+}
+
+func TestLint_Problems(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"groups/a.json":        `{"id":"a","profile":"missing","selector":{"os":"installed"}}`,
+		"groups/b.json":        "{\n  \"id\": \"b\",\n  \"profile\": \"worker\",\n  \"selectors\": {}\n}",
+		"groups/c.json":        "{\n  \"id\": \"c\",\n  \"profile\": \"worker\",\n  \"selector\": {\"os\": \"installed\"}\n}",
+		"groups/d.json":        "{\n  \"id\": \"d\",\n  \"profile\": 1\n}",
+		"groups/f.json":        `{"id":"f","profile":"worker","selector":{"os":"upgrade"},"rollout":{"profile":"next","start":"2026-10-15T22:00:00Z"}}`,
+		"groups/other.json":    `{"id":"e","profile":"worker","selector":{"region":""}}`,
+		"groups/g.yaml":        "id: g\nprofile: worker\nselector:\n  mac: 52:54:00\n",
+		"groups/h.yml":         "id: h\nprofile: worker\nselector:\n  os: h\n",
+		"groups/i.json":        `{"id":"i","profile":"legacy","selector":{"os":"i"},"metadata":{"channel":1}}`,
+		"profiles/worker.json": `{"id":"worker","ignition_id":"worker.yaml","cloud_id":"missing.yaml"}`,
+		"profiles/edge.json":   `{"id":"edge","ignition_id":"https://example.com/edge.ign","ignition_snippets":["missing.yaml"],"boot":{"kernel":"/assets/{{.os_version/kernel","args":["console={{.console}}","ip={{.ip"]}}`,
+		"profiles/legacy.json": `{"id":"legacy","boot":{"kernel":"/assets/vmlinuz","cmdline":{"console":"ttyS0"}},"metadata_schema":{"properties":{"channel":{"type":"string"}}}}`,
+		"ignition/worker.yaml": "systemd:\n  units:\n    - name: etcd.service\n      enable: 1\n",
+		"ignition/unused.yaml": "systemd:\n  unitz: []\n",
+		"ignition/bad.tmpl":    "line one\n{{if .foo}}\n",
+		"ignition/raw.ign":     `{"ignition":{"version":"2.0.0"},"storage":{"files":[{"path":"relative"}]}}`,
+	})
+	defer os.RemoveAll(dir)
+
+	problems, err := Lint(dir)
+	assert.Nil(t, err)
+	var lines []string
+	for _, p := range problems {
+		rel := *p
+		rel.Path = rel.Path[len(dir)+1:]
+		lines = append(lines, rel.String())
+	}
+	// assert that:
+	// - every problem is reported, sorted by file and position
+	// - YAML Groups are checked like JSON Groups
+	// - uses of deprecated fields are warnings
+	// - Group metadata is checked against Profile metadata schemas
+	expected := []string{
+		`groups/a.json: error: group references missing profile "missing"`,
+		`groups/b.json:4:3: error: json: unknown field "selectors"`,
+		`groups/c.json: warning: group "c" has the same selectors as group "a"`,
+		`groups/d.json:3:15: error: json: cannot unmarshal number into Go struct field RichGroup.profile of type string`,
+		`groups/f.json: error: group rollout references missing profile "next"`,
+		`groups/g.yaml:4:3: error: selector mac: address 52:54:00: invalid MAC address`,
+		`groups/i.json: error: group "i" metadata does not match profile "legacy" metadata schema: channel: must be a string, not integer`,
+		`groups/other.json: warning: file should be named e.json for id "e"`,
+		`groups/other.json: warning: selector "region" has an empty value`,
+		`ignition/bad.tmpl:3: error: unexpected EOF`,
+		`ignition/raw.ign:1:71: error: no filesystem specified`,
+		`ignition/unused.yaml:2:3: warning: Config has unrecognized key: unitz`,
+		`ignition/worker.yaml:4: error: cannot unmarshal !!int ` + "`1`" + ` into bool`,
+		`profiles/edge.json: error: boot kernel: template: kernel:1: bad character U+002F '/'`,
+		`profiles/edge.json: error: boot arg 1: template: arg:1: unclosed action`,
+		`profiles/edge.json: error: profile references missing ignition template "missing.yaml"`,
+		`profiles/legacy.json: warning: profile "legacy" field boot.cmdline is deprecated since v0.5.0: list kernel args in boot.args`,
+		`profiles/worker.json: error: profile references missing cloud template "missing.yaml"`,
+	}
+	assert.Equal(t, expected, lines)
+}
+
+func TestLintTemplate(t *testing.T) {
+	// assert that:
+	// - valid templates have no problems
+	// - template syntax errors are reported with their line
+	// - Fuze configs without actions are validated
+	assert.Empty(t, LintTemplate(KindGeneric, "worker.tmpl", []byte("{{.mac}}")))
+	problems := LintTemplate(KindIgnition, "bad.tmpl", []byte("line one\n{{if .foo}}\n"))
+	if assert.Len(t, problems, 1) {
+		assert.Equal(t, "bad.tmpl:3: error: unexpected EOF", problems[0].String())
+	}
+	problems = LintTemplate(KindIgnition, "worker.yaml", []byte("systemd:\n  units:\n    - name: etcd.service\n      enable: 1\n"))
+	if assert.NotEmpty(t, problems) {
+		assert.Equal(t, ProblemError, problems[0].Kind)
+		assert.Equal(t, 4, problems[0].Line)
+	}
+}
+
+func TestYAMLErrorLine(t *testing.T) {
+	// assert that:
+	// - the line and message of a single YAML unmarshal error are parsed
+	// - other messages are unchanged
+	line, msg := YAMLErrorLine("yaml: unmarshal errors:\n  line 4: cannot unmarshal !!int `1` into bool")
+	assert.Equal(t, 4, line)
+	assert.Equal(t, "cannot unmarshal !!int `1` into bool", msg)
+	line, msg = YAMLErrorLine("invalid unit")
+	assert.Equal(t, 0, line)
+	assert.Equal(t, "invalid unit", msg)
+}
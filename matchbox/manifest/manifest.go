@@ -0,0 +1,150 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// Template kinds, named for the directory holding each kind of template.
+const (
+	KindIgnition = "ignition"
+	KindCloud    = "cloud"
+	KindGeneric  = "generic"
+)
+
+// Kinds lists the template kinds in the order they are read.
+var Kinds = []string{KindIgnition, KindCloud, KindGeneric}
+
+// Possible manifest errors
+var (
+	ErrNotDirectory = errors.New("manifest: path is not a directory")
+	ErrDuplicateID  = errors.New("manifest: duplicate id")
+)
+
+// A Template is a named Ignition, Cloud-Config, or generic template.
+type Template struct {
+	Kind     string
+	Name     string
+	Contents []byte
+}
+
+// A Manifest is the set of Groups, Profiles, and templates read from a
+// directory tree laid out like the matchbox data directory:
+//
+//	groups/*.json (or *.yaml)
+//	profiles/*.json (or *.yaml)
+//	ignition/*
+//	cloud/*
+//	generic/*
+type Manifest struct {
+	Groups    []*storagepb.Group
+	Profiles  []*storagepb.Profile
+	Templates []*Template
+}
+
+// Load reads and validates the manifest in the given directory. Missing
+// subdirectories are treated as empty.
+func Load(dir string) (*Manifest, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, ErrNotDirectory
+	}
+
+	m := new(Manifest)
+	groupIDs := make(map[string]string)
+	err = readFiles(filepath.Join(dir, "groups"), func(path string, data []byte) error {
+		group, err := storagepb.ParseGroupFile(path, data)
+		if err != nil {
+			return err
+		}
+		if err := group.AssertValid(); err != nil {
+			return err
+		}
+		if err := claim(groupIDs, group.Id, path); err != nil {
+			return err
+		}
+		m.Groups = append(m.Groups, group)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	profileIDs := make(map[string]string)
+	err = readFiles(filepath.Join(dir, "profiles"), func(path string, data []byte) error {
+		profile, err := storagepb.ParseProfileFile(path, data)
+		if err != nil {
+			return err
+		}
+		if err := profile.AssertValid(); err != nil {
+			return err
+		}
+		if err := claim(profileIDs, profile.Id, path); err != nil {
+			return err
+		}
+		m.Profiles = append(m.Profiles, profile)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, kind := range Kinds {
+		err = readFiles(filepath.Join(dir, kind), func(path string, data []byte) error {
+			m.Templates = append(m.Templates, &Template{
+				Kind:     kind,
+				Name:     filepath.Base(path),
+				Contents: data,
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// readFiles calls fn with the contents of each regular file in dir, in
+// lexical order. Errors are annotated with the file path.
+func readFiles(dir string, fn func(path string, data []byte) error) error {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, finfo := range files {
+		if !finfo.Mode().IsRegular() {
+			continue
+		}
+		path := filepath.Join(dir, finfo.Name())
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := fn(path, data); err != nil {
+			return storagepb.FileError(path, err)
+		}
+	}
+	return nil
+}
+
+// claim records that id is defined by path, or returns ErrDuplicateID if
+// another file already defined it.
+func claim(ids map[string]string, id, path string) error {
+	if other, ok := ids[id]; ok {
+		return fmt.Errorf("%v %q, also defined in %s", ErrDuplicateID, id, other)
+	}
+	ids[id] = path
+	return nil
+}
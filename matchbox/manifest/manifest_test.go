@@ -0,0 +1,98 @@
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTree writes the given files, keyed by relative path, under a new
+// temporary directory.
+func writeTree(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"groups/a.json":          `{"id":"a","profile":"worker","selector":{"mac":"52:54:00:89:d8:10"}}`,
+		"profiles/worker.json":   `{"id":"worker","ignition_id":"worker.yaml"}`,
+		"ignition/worker.yaml":   "systemd: {}",
+		"cloud/worker.yaml":      "#cloud-config",
+		"generic/worker.tmpl":    "{{.mac}}",
+		"generic/subdir/ignored": "",
+	})
+	defer os.RemoveAll(dir)
+
+	m, err := Load(dir)
+	// assert that:
+	// - Groups and Profiles are parsed
+	// - templates are read by kind, skipping subdirectories
+	if !assert.Nil(t, err) {
+		return
+	}
+	if assert.Len(t, m.Groups, 1) {
+		assert.Equal(t, "a", m.Groups[0].Id)
+		assert.Equal(t, map[string]string{"mac": "52:54:00:89:d8:10"}, m.Groups[0].Selector)
+	}
+	if assert.Len(t, m.Profiles, 1) {
+		assert.Equal(t, "worker.yaml", m.Profiles[0].IgnitionId)
+	}
+	expected := []*Template{
+		{Kind: KindIgnition, Name: "worker.yaml", Contents: []byte("systemd: {}")},
+		{Kind: KindCloud, Name: "worker.yaml", Contents: []byte("#cloud-config")},
+		{Kind: KindGeneric, Name: "worker.tmpl", Contents: []byte("{{.mac}}")},
+	}
+	assert.Equal(t, expected, m.Templates)
+}
+
+func TestLoad_Empty(t *testing.T) {
+	dir := writeTree(t, nil)
+	defer os.RemoveAll(dir)
+
+	m, err := Load(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, &Manifest{}, m)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	cases := []map[string]string{
+		{"groups/a.json": `{"id":"a"}`},
+		{"groups/a.json": `{`},
+		{"profiles/p.json": `{"name":"no id"}`},
+		{"profiles/a.json": `{"id":"p"}`, "profiles/b.json": `{"id":"p"}`},
+	}
+	for _, files := range cases {
+		dir := writeTree(t, files)
+		_, err := Load(dir)
+		assert.Error(t, err)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestLoad_NotDirectory(t *testing.T) {
+	f, err := ioutil.TempFile("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.Close()
+
+	_, err = Load(f.Name())
+	assert.Equal(t, ErrNotDirectory, err)
+}
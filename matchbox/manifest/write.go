@@ -0,0 +1,55 @@
+package manifest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Default modes of written directories and files.
+const (
+	dirMode  os.FileMode = 0755
+	fileMode os.FileMode = 0644
+)
+
+// Write writes the manifest to the given directory in the layout read by
+// Load, creating directories as needed and overwriting existing files.
+func Write(dir string, m *Manifest) error {
+	for _, group := range m.Groups {
+		richGroup, err := group.ToRichGroup()
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(richGroup, "", "\t")
+		if err != nil {
+			return err
+		}
+		if err := writeFile(filepath.Join(dir, "groups", group.Id+".json"), data); err != nil {
+			return err
+		}
+	}
+	for _, profile := range m.Profiles {
+		data, err := json.MarshalIndent(profile, "", "\t")
+		if err != nil {
+			return err
+		}
+		if err := writeFile(filepath.Join(dir, "profiles", profile.Id+".json"), data); err != nil {
+			return err
+		}
+	}
+	for _, tmpl := range m.Templates {
+		if err := writeFile(filepath.Join(dir, tmpl.Kind, tmpl.Name), tmpl.Contents); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFile writes data to the named file, creating parent directories.
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), dirMode); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, fileMode)
+}
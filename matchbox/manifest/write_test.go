@@ -0,0 +1,39 @@
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+func TestWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m := &Manifest{
+		Groups: []*storagepb.Group{
+			{Id: "a", Profile: "worker", Selector: map[string]string{"mac": "52:54:00:89:d8:10"}},
+		},
+		Profiles: []*storagepb.Profile{
+			{Id: "worker", IgnitionId: "worker.yaml", Boot: &storagepb.NetBoot{Kernel: "/assets/vmlinuz"}},
+		},
+		Templates: []*Template{
+			{Kind: KindIgnition, Name: "worker.yaml", Contents: []byte("systemd: {}")},
+			{Kind: KindGeneric, Name: "worker.tmpl", Contents: []byte("{{.mac}}")},
+		},
+	}
+	// assert that:
+	// - a written manifest loads back unchanged
+	err = Write(dir, m)
+	assert.Nil(t, err)
+	loaded, err := Load(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, m, loaded)
+}
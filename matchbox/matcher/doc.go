@@ -0,0 +1,3 @@
+// Package matcher delegates machine Group matching to an external HTTP
+// service, such as an existing asset database.
+package matcher
@@ -0,0 +1,214 @@
+package matcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"context"
+	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/requestid"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// Defaults for external matching.
+const (
+	DefaultTimeout  = 5 * time.Second
+	DefaultCacheTTL = time.Minute
+)
+
+// Possible matching errors
+var (
+	ErrNoMatch         = errors.New("matcher: no matching group")
+	ErrInvalidResponse = errors.New("matcher: response must name a group")
+)
+
+// Config configures a Matcher.
+type Config struct {
+	// URL of the external matching service
+	URL string
+	// Timeout of requests to the service
+	Timeout time.Duration
+	// CacheTTL is how long results are cached (0 uses the default)
+	CacheTTL time.Duration
+	// (optional) HTTP client
+	Client *http.Client
+	Logger *logrus.Logger
+}
+
+// request is the body POSTed to the matching service.
+type request struct {
+	Labels map[string]string `json:"labels"`
+}
+
+// response is the body returned by the matching service. A response which
+// names only a group refers to a Group in the store.
+type response struct {
+	Group    string          `json:"group"`
+	Profile  string          `json:"profile"`
+	Metadata json.RawMessage `json:"metadata"`
+}
+
+// result is a cached match (or a nil Group for no match).
+type result struct {
+	group   *storagepb.Group
+	expires time.Time
+}
+
+// Matcher matches machine labels to Groups by POSTing them to an external
+// service and caches the results.
+type Matcher struct {
+	url       string
+	ttl       time.Duration
+	client    *http.Client
+	logger    *logrus.Logger
+	mu        sync.Mutex
+	cache     map[string]result
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMatcher returns a new Matcher.
+func NewMatcher(config *Config) *Matcher {
+	client := config.Client
+	if client == nil {
+		timeout := config.Timeout
+		if timeout == 0 {
+			timeout = DefaultTimeout
+		}
+		client = &http.Client{Timeout: timeout}
+	}
+	ttl := config.CacheTTL
+	if ttl == 0 {
+		ttl = DefaultCacheTTL
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = logrus.New()
+	}
+	return &Matcher{
+		url:    config.URL,
+		ttl:    ttl,
+		client: client,
+		logger: logger,
+		cache:  make(map[string]result),
+		now:    time.Now,
+	}
+}
+
+// Match returns the Group the external service matches to the labels.
+// Groups which name only an id have no Profile and should be looked up in
+// the store. ErrNoMatch is returned if the service matches no Group, other
+// errors mean the service could not be queried.
+func (m *Matcher) Match(ctx context.Context, labels map[string]string) (*storagepb.Group, error) {
+	key := cacheKey(labels)
+	if group, ok := m.cached(key); ok {
+		if group == nil {
+			return nil, ErrNoMatch
+		}
+		return group, nil
+	}
+	group, err := m.query(ctx, labels)
+	if err != nil && err != ErrNoMatch {
+		m.logger.Warningf("matcher: error matching %v: %v", labels, err)
+		return nil, err
+	}
+	m.store(key, group)
+	if group == nil {
+		return nil, ErrNoMatch
+	}
+	return group, nil
+}
+
+// query POSTs labels to the matching service.
+func (m *Matcher) query(ctx context.Context, labels map[string]string) (*storagepb.Group, error) {
+	body, err := json.Marshal(&request{Labels: labels})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", m.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	requestid.SetHeader(ctx, req.Header)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNoContent:
+		return nil, ErrNoMatch
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("matcher: unexpected status %s", resp.Status)
+	}
+	out := new(response)
+	if err := json.Unmarshal(data, out); err != nil {
+		return nil, err
+	}
+	if out.Group == "" {
+		return nil, ErrInvalidResponse
+	}
+	group := &storagepb.Group{
+		Id:      out.Group,
+		Profile: out.Profile,
+	}
+	if len(out.Metadata) > 0 && string(out.Metadata) != "null" {
+		group.Metadata = out.Metadata
+	}
+	return group, nil
+}
+
+// cached returns an unexpired cached result.
+func (m *Matcher) cached(key string) (*storagepb.Group, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.cache[key]
+	if !ok || !m.now().Before(r.expires) {
+		return nil, false
+	}
+	return r.group, true
+}
+
+// store caches a result and discards expired results.
+func (m *Matcher) store(key string, group *storagepb.Group) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	if now.Sub(m.lastSweep) >= m.ttl {
+		for k, r := range m.cache {
+			if !now.Before(r.expires) {
+				delete(m.cache, k)
+			}
+		}
+		m.lastSweep = now
+	}
+	m.cache[key] = result{group: group, expires: now.Add(m.ttl)}
+}
+
+// cacheKey returns a canonical key for labels.
+func cacheKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var b bytes.Buffer
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%q=%q;", key, labels[key])
+	}
+	return b.String()
+}
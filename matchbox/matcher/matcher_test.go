@@ -0,0 +1,139 @@
+package matcher
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"context"
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/requestid"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// fakeService matches machines by uuid label and counts requests.
+func fakeService(requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		*requests++
+		in := new(request)
+		json.NewDecoder(req.Body).Decode(in)
+		switch in.Labels["uuid"] {
+		case "a1b2c3d4":
+			w.Write([]byte(`{"group": "worker-r1", "profile": "worker", "metadata": {"rack": "r1"}}`))
+		case "e5f6a7b8":
+			w.Write([]byte(`{"group": "etcd"}`))
+		case "broken":
+			http.Error(w, "database unavailable", http.StatusServiceUnavailable)
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+}
+
+func TestMatch(t *testing.T) {
+	var requests int
+	srv := fakeService(&requests)
+	defer srv.Close()
+	logger, _ := logtest.NewNullLogger()
+	m := NewMatcher(&Config{URL: srv.URL, Logger: logger})
+
+	cases := []struct {
+		labels map[string]string
+		group  *storagepb.Group
+		err    error
+	}{
+		{map[string]string{"uuid": "a1b2c3d4"}, &storagepb.Group{Id: "worker-r1", Profile: "worker", Metadata: []byte(`{"rack": "r1"}`)}, nil},
+		{map[string]string{"uuid": "e5f6a7b8"}, &storagepb.Group{Id: "etcd"}, nil},
+		{map[string]string{"uuid": "unknown"}, nil, ErrNoMatch},
+	}
+	for _, c := range cases {
+		group, err := m.Match(context.Background(), c.labels)
+		assert.Equal(t, c.err, err)
+		assert.Equal(t, c.group, group)
+	}
+	// assert that:
+	// - service errors are returned and not cached
+	_, err := m.Match(context.Background(), map[string]string{"uuid": "broken"})
+	assert.Error(t, err)
+	_, err = m.Match(context.Background(), map[string]string{"uuid": "broken"})
+	assert.Error(t, err)
+	assert.Equal(t, 5, requests)
+}
+
+func TestMatch_RequestID(t *testing.T) {
+	received := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		received <- req.Header.Get(requestid.Header)
+		w.Write([]byte(`{"group": "etcd"}`))
+	}))
+	defer srv.Close()
+	logger, _ := logtest.NewNullLogger()
+	m := NewMatcher(&Config{URL: srv.URL, Logger: logger})
+	// assert that queries carry the ID of the boot request
+	ctx := requestid.NewContext(context.Background(), "5e8f1a2b3c4d6e7f")
+	_, err := m.Match(ctx, map[string]string{"uuid": "e5f6a7b8"})
+	assert.Nil(t, err)
+	assert.Equal(t, "5e8f1a2b3c4d6e7f", <-received)
+}
+
+func TestMatch_Cache(t *testing.T) {
+	var requests int
+	srv := fakeService(&requests)
+	defer srv.Close()
+	now := time.Now()
+	m := NewMatcher(&Config{URL: srv.URL, CacheTTL: time.Minute})
+	m.now = func() time.Time { return now }
+
+	labels := map[string]string{"uuid": "a1b2c3d4", "mac": "52-54-00-a1-9c-ae"}
+	first, err := m.Match(context.Background(), labels)
+	assert.Nil(t, err)
+	second, err := m.Match(context.Background(), map[string]string{"mac": "52-54-00-a1-9c-ae", "uuid": "a1b2c3d4"})
+	assert.Nil(t, err)
+	_, err = m.Match(context.Background(), map[string]string{"uuid": "unknown"})
+	assert.Equal(t, ErrNoMatch, err)
+	_, err = m.Match(context.Background(), map[string]string{"uuid": "unknown"})
+	assert.Equal(t, ErrNoMatch, err)
+	// assert that:
+	// - matches and non-matches are cached
+	assert.Equal(t, first, second)
+	assert.Equal(t, 2, requests)
+
+	// assert that:
+	// - results expire after the TTL
+	now = now.Add(time.Minute)
+	_, err = m.Match(context.Background(), labels)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, requests)
+	assert.Equal(t, 1, len(m.cache))
+}
+
+func TestMatch_Timeout(t *testing.T) {
+	block := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-block
+	}))
+	defer srv.Close()
+	defer close(block)
+	logger, _ := logtest.NewNullLogger()
+	m := NewMatcher(&Config{URL: srv.URL, Timeout: 50 * time.Millisecond, Logger: logger})
+	// assert that:
+	// - slow services time out
+	_, err := m.Match(context.Background(), map[string]string{"uuid": "a1b2c3d4"})
+	assert.Error(t, err)
+	assert.NotEqual(t, ErrNoMatch, err)
+}
+
+func TestMatch_InvalidResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"profile": "worker"}`))
+	}))
+	defer srv.Close()
+	logger, _ := logtest.NewNullLogger()
+	m := NewMatcher(&Config{URL: srv.URL, Logger: logger})
+	_, err := m.Match(context.Background(), map[string]string{"uuid": "a1b2c3d4"})
+	assert.Equal(t, ErrInvalidResponse, err)
+}
@@ -0,0 +1,3 @@
+// Package metrics provides counters and histograms exposed in the
+// Prometheus text exposition format.
+package metrics
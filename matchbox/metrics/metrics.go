@@ -0,0 +1,335 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefBuckets are the default histogram buckets (seconds), suitable for
+// request and render latencies.
+var DefBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// collector writes its samples in the text exposition format.
+type collector interface {
+	name() string
+	write(w io.Writer)
+}
+
+// Registry holds registered metrics.
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]collector
+}
+
+// NewRegistry returns a new Registry.
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+// DefaultRegistry is the Registry used by matchbox packages and served by
+// Handler.
+var DefaultRegistry = NewRegistry()
+
+// register adds a collector, panicking on duplicate names.
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.collectors[c.name()]; ok {
+		panic(fmt.Sprintf("metrics: duplicate metric %s", c.name()))
+	}
+	r.collectors[c.name()] = c
+}
+
+// Write writes all metrics in the text exposition format, sorted by name.
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	collectors := make([]collector, 0, len(r.collectors))
+	for _, c := range r.collectors {
+		collectors = append(collectors, c)
+	}
+	r.mu.Unlock()
+	sort.Sort(byName(collectors))
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+type byName []collector
+
+func (s byName) Len() int           { return len(s) }
+func (s byName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byName) Less(i, j int) bool { return s[i].name() < s[j].name() }
+
+// Handler returns an http.Handler which serves the Registry's metrics.
+func (r *Registry) Handler() http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		r.Write(w)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Handler returns an http.Handler which serves the DefaultRegistry.
+func Handler() http.Handler {
+	return DefaultRegistry.Handler()
+}
+
+// vec holds series keyed by label values.
+type vec struct {
+	metric string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string][]string
+}
+
+func (v *vec) name() string {
+	return v.metric
+}
+
+// key returns the series key of label values, which must match the
+// labels in number.
+func (v *vec) key(values []string) string {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.metric, len(v.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// sortedKeys returns the series keys in sorted order.
+func (v *vec) sortedKeys() []string {
+	keys := make([]string, 0, len(v.series))
+	for k := range v.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// labelPairs formats label values (plus any extra pair) as {k="v",...}.
+func (v *vec) labelPairs(values []string, extra ...string) string {
+	var pairs []string
+	for i, label := range v.labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", label, values[i]))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", extra[i], extra[i+1]))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func (v *vec) header(w io.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.metric, v.help, v.metric, kind)
+}
+
+// CounterVec is a set of counters partitioned by label values.
+type CounterVec struct {
+	vec
+	values map[string]float64
+}
+
+// NewCounterVec creates and registers a CounterVec in the DefaultRegistry.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return DefaultRegistry.NewCounterVec(name, help, labels...)
+}
+
+// NewCounterVec creates and registers a CounterVec.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		vec:    vec{metric: name, help: help, labels: labels, series: make(map[string][]string)},
+		values: make(map[string]float64),
+	}
+	r.register(c)
+	return c
+}
+
+// Add adds delta (which must not be negative) to the counter with the
+// given label values.
+func (c *CounterVec) Add(delta float64, values ...string) {
+	if delta < 0 {
+		panic("metrics: counters cannot decrease")
+	}
+	key := c.key(values)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.series[key]; !ok {
+		c.series[key] = append([]string(nil), values...)
+	}
+	c.values[key] += delta
+}
+
+// Inc increments the counter with the given label values.
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Value returns the counter value for the given label values.
+func (c *CounterVec) Value(values ...string) float64 {
+	key := c.key(values)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[key]
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header(w, "counter")
+	for _, key := range c.sortedKeys() {
+		fmt.Fprintf(w, "%s%s %s\n", c.metric, c.labelPairs(c.series[key]), formatFloat(c.values[key]))
+	}
+}
+
+// GaugeVec is a set of gauges partitioned by label values.
+type GaugeVec struct {
+	vec
+	values map[string]float64
+}
+
+// NewGaugeVec creates and registers a GaugeVec in the DefaultRegistry.
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return DefaultRegistry.NewGaugeVec(name, help, labels...)
+}
+
+// NewGaugeVec creates and registers a GaugeVec.
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{
+		vec:    vec{metric: name, help: help, labels: labels, series: make(map[string][]string)},
+		values: make(map[string]float64),
+	}
+	r.register(g)
+	return g
+}
+
+// Set sets the gauge with the given label values.
+func (g *GaugeVec) Set(value float64, values ...string) {
+	key := g.key(values)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.series[key]; !ok {
+		g.series[key] = append([]string(nil), values...)
+	}
+	g.values[key] = value
+}
+
+// Value returns the gauge value for the given label values.
+func (g *GaugeVec) Value(values ...string) float64 {
+	key := g.key(values)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.values[key]
+}
+
+func (g *GaugeVec) write(w io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.header(w, "gauge")
+	for _, key := range g.sortedKeys() {
+		fmt.Fprintf(w, "%s%s %s\n", g.metric, g.labelPairs(g.series[key]), formatFloat(g.values[key]))
+	}
+}
+
+// HistogramVec is a set of histograms partitioned by label values.
+type HistogramVec struct {
+	vec
+	buckets []float64
+	values  map[string]*histogram
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec creates and registers a HistogramVec in the
+// DefaultRegistry. Nil buckets uses DefBuckets.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return DefaultRegistry.NewHistogramVec(name, help, buckets, labels...)
+}
+
+// NewHistogramVec creates and registers a HistogramVec. Nil buckets uses
+// DefBuckets.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	h := &HistogramVec{
+		vec:     vec{metric: name, help: help, labels: labels, series: make(map[string][]string)},
+		buckets: append([]float64(nil), buckets...),
+		values:  make(map[string]*histogram),
+	}
+	sort.Float64s(h.buckets)
+	r.register(h)
+	return h
+}
+
+// Observe adds an observation to the histogram with the given label values.
+func (h *HistogramVec) Observe(value float64, values ...string) {
+	key := h.key(values)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hist, ok := h.values[key]
+	if !ok {
+		h.series[key] = append([]string(nil), values...)
+		hist = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.values[key] = hist
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			hist.counts[i]++
+		}
+	}
+	hist.count++
+	hist.sum += value
+}
+
+// ObserveDuration observes the seconds elapsed since start.
+func (h *HistogramVec) ObserveDuration(start time.Time, values ...string) {
+	h.Observe(time.Since(start).Seconds(), values...)
+}
+
+// Count returns the number of observations for the given label values.
+func (h *HistogramVec) Count(values ...string) uint64 {
+	key := h.key(values)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if hist, ok := h.values[key]; ok {
+		return hist.count
+	}
+	return 0
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w, "histogram")
+	for _, key := range h.sortedKeys() {
+		values := h.series[key]
+		hist := h.values[key]
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metric, h.labelPairs(values, "le", formatFloat(bound)), hist.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metric, h.labelPairs(values, "le", "+Inf"), hist.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metric, h.labelPairs(values), formatFloat(hist.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metric, h.labelPairs(values), hist.count)
+	}
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
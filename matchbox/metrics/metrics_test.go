@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounterVec(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_requests_total", "Requests served.", "handler", "code")
+	c.Inc("/ipxe", "200")
+	c.Inc("/ipxe", "200")
+	c.Add(3, "/assets", "404")
+	assert.Equal(t, float64(2), c.Value("/ipxe", "200"))
+
+	var buf bytes.Buffer
+	r.Write(&buf)
+	expected := `# HELP test_requests_total Requests served.
+# TYPE test_requests_total counter
+test_requests_total{handler="/assets",code="404"} 3
+test_requests_total{handler="/ipxe",code="200"} 2
+`
+	assert.Equal(t, expected, buf.String())
+	assert.Panics(t, func() { c.Inc("too-few") })
+	assert.Panics(t, func() { c.Add(-1, "/ipxe", "200") })
+}
+
+func TestGaugeVec(t *testing.T) {
+	r := NewRegistry()
+	g := r.NewGaugeVec("test_last_sync_timestamp_seconds", "Last sync time.")
+	g.Set(1500000000)
+	g.Set(1500000060)
+	assert.Equal(t, float64(1500000060), g.Value())
+
+	var buf bytes.Buffer
+	r.Write(&buf)
+	expected := `# HELP test_last_sync_timestamp_seconds Last sync time.
+# TYPE test_last_sync_timestamp_seconds gauge
+test_last_sync_timestamp_seconds 1.50000006e+09
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestHistogramVec(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogramVec("test_render_seconds", "Render latency.", []float64{0.1, 1}, "config")
+	h.Observe(0.05, "ignition")
+	h.Observe(0.5, "ignition")
+	h.Observe(2, "ignition")
+	assert.Equal(t, uint64(3), h.Count("ignition"))
+
+	var buf bytes.Buffer
+	r.Write(&buf)
+	expected := `# HELP test_render_seconds Render latency.
+# TYPE test_render_seconds histogram
+test_render_seconds_bucket{config="ignition",le="0.1"} 1
+test_render_seconds_bucket{config="ignition",le="1"} 2
+test_render_seconds_bucket{config="ignition",le="+Inf"} 3
+test_render_seconds_sum{config="ignition"} 2.55
+test_render_seconds_count{config="ignition"} 3
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestRegistryHandler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("b_total", "B.").Inc()
+	r.NewCounterVec("a_total", "A.")
+	assert.Panics(t, func() { r.NewCounterVec("a_total", "A.") })
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	r.Handler().ServeHTTP(w, req)
+	// assert that:
+	// - metrics are sorted by name
+	expected := `# HELP a_total A.
+# TYPE a_total counter
+# HELP b_total B.
+# TYPE b_total counter
+b_total 1
+`
+	assert.Equal(t, expected, w.Body.String())
+	assert.Equal(t, "text/plain; version=0.0.4", w.HeaderMap.Get("Content-Type"))
+}
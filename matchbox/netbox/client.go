@@ -0,0 +1,284 @@
+package netbox
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"context"
+)
+
+const (
+	// defaultTimeout bounds each request to NetBox.
+	defaultTimeout = 30 * time.Second
+	// pageSize is the number of objects requested per page.
+	pageSize = 1000
+)
+
+// Custom fields of NetBox devices which matchbox reads or writes.
+const (
+	// CustomFieldUUID is the SMBIOS UUID of a device
+	CustomFieldUUID = "uuid"
+	// CustomFieldState is the provisioning state of a device's Machine
+	CustomFieldState = "matchbox_state"
+)
+
+// ClientConfig configures a Client.
+type ClientConfig struct {
+	// NetBox base URL (e.g. https://netbox.example.com)
+	URL string
+	// API token
+	Token string
+	// (optional) HTTP client, for testing
+	Client *http.Client
+}
+
+// Client reads devices from and writes device state to the NetBox API.
+type Client struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewClient returns a new Client.
+func NewClient(config *ClientConfig) *Client {
+	client := config.Client
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+	return &Client{
+		url:    strings.TrimSuffix(config.URL, "/"),
+		token:  config.Token,
+		client: client,
+	}
+}
+
+// Device is a NetBox device and its interfaces.
+type Device struct {
+	ID     int
+	Name   string
+	Serial string
+	Status string
+	Site   string
+	Rack   string
+	Role   string
+	Tenant string
+	Model  string
+	// primary IPv4 and IPv6 addresses, without prefix lengths
+	IP   string
+	IP6  string
+	UUID string
+	// provisioning state reported by matchbox
+	State string
+	// MAC addresses of non-management interfaces
+	MACs []string
+	// rendered config context
+	ConfigContext map[string]interface{}
+}
+
+// nested is a reference to a related NetBox object.
+type nested struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Slug    string `json:"slug"`
+	Model   string `json:"model"`
+	Address string `json:"address"`
+	Value   string `json:"value"`
+}
+
+// device is a NetBox dcim.Device.
+type device struct {
+	ID            int                    `json:"id"`
+	Name          string                 `json:"name"`
+	Serial        string                 `json:"serial"`
+	Status        *nested                `json:"status"`
+	Site          *nested                `json:"site"`
+	Rack          *nested                `json:"rack"`
+	Role          *nested                `json:"role"`
+	DeviceRole    *nested                `json:"device_role"`
+	Tenant        *nested                `json:"tenant"`
+	DeviceType    *nested                `json:"device_type"`
+	PrimaryIP4    *nested                `json:"primary_ip4"`
+	PrimaryIP6    *nested                `json:"primary_ip6"`
+	CustomFields  map[string]interface{} `json:"custom_fields"`
+	ConfigContext map[string]interface{} `json:"config_context"`
+}
+
+// iface is a NetBox dcim.Interface.
+type iface struct {
+	Device     *nested `json:"device"`
+	MACAddress string  `json:"mac_address"`
+	MgmtOnly   bool    `json:"mgmt_only"`
+}
+
+// page is a page of NetBox API results.
+type page struct {
+	Next    string          `json:"next"`
+	Results json.RawMessage `json:"results"`
+}
+
+// Devices returns all NetBox devices with the MAC addresses of their
+// interfaces.
+func (c *Client) Devices(ctx context.Context) ([]*Device, error) {
+	var devices []*device
+	if err := c.list(ctx, "/api/dcim/devices/", func(results json.RawMessage) error {
+		var found []*device
+		err := json.Unmarshal(results, &found)
+		devices = append(devices, found...)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	macs := make(map[int][]string)
+	if err := c.list(ctx, "/api/dcim/interfaces/", func(results json.RawMessage) error {
+		var found []*iface
+		if err := json.Unmarshal(results, &found); err != nil {
+			return err
+		}
+		for _, i := range found {
+			if i.Device == nil || i.MgmtOnly || i.MACAddress == "" {
+				continue
+			}
+			macs[i.Device.ID] = append(macs[i.Device.ID], strings.ToLower(i.MACAddress))
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	var result []*Device
+	for _, d := range devices {
+		result = append(result, &Device{
+			ID:            d.ID,
+			Name:          d.Name,
+			Serial:        d.Serial,
+			Status:        d.Status.value(),
+			Site:          d.Site.slug(),
+			Rack:          d.Rack.name(),
+			Role:          d.role(),
+			Tenant:        d.Tenant.slug(),
+			Model:         d.DeviceType.model(),
+			IP:            d.PrimaryIP4.ip(),
+			IP6:           d.PrimaryIP6.ip(),
+			UUID:          customField(d.CustomFields, CustomFieldUUID),
+			State:         customField(d.CustomFields, CustomFieldState),
+			MACs:          macs[d.ID],
+			ConfigContext: d.ConfigContext,
+		})
+	}
+	return result, nil
+}
+
+// SetState sets the provisioning state custom field of a device.
+func (c *Client) SetState(ctx context.Context, id int, state string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"custom_fields": map[string]string{CustomFieldState: state},
+	})
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, "PATCH", fmt.Sprintf("%s/api/dcim/devices/%d/", c.url, id), body, nil)
+}
+
+// list calls fn with the results of each page of a NetBox list endpoint.
+func (c *Client) list(ctx context.Context, path string, fn func(json.RawMessage) error) error {
+	next := c.url + path + "?" + url.Values{"limit": {fmt.Sprint(pageSize)}}.Encode()
+	for next != "" {
+		var p page
+		if err := c.do(ctx, "GET", next, nil, &p); err != nil {
+			return err
+		}
+		if err := fn(p.Results); err != nil {
+			return err
+		}
+		next = p.Next
+	}
+	return nil
+}
+
+// do sends a request to NetBox and decodes the JSON response into v, if
+// non-nil.
+func (c *Client) do(ctx context.Context, method, url string, body []byte, v interface{}) error {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Token "+c.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("netbox: %s %s: %s: %s", method, url, resp.Status, bytes.TrimSpace(msg))
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// role returns the slug of a device's role. NetBox 3.6 renamed device_role
+// to role.
+func (d *device) role() string {
+	if d.Role != nil {
+		return d.Role.slug()
+	}
+	return d.DeviceRole.slug()
+}
+
+func (n *nested) value() string {
+	if n == nil {
+		return ""
+	}
+	return n.Value
+}
+
+func (n *nested) slug() string {
+	if n == nil {
+		return ""
+	}
+	return n.Slug
+}
+
+func (n *nested) name() string {
+	if n == nil {
+		return ""
+	}
+	return n.Name
+}
+
+func (n *nested) model() string {
+	if n == nil {
+		return ""
+	}
+	return n.Model
+}
+
+// ip returns an IP address without its prefix length.
+func (n *nested) ip() string {
+	if n == nil {
+		return ""
+	}
+	return strings.SplitN(n.Address, "/", 2)[0]
+}
+
+// customField returns the string value of a custom field, or "".
+func customField(fields map[string]interface{}, name string) string {
+	if value, ok := fields[name].(string); ok {
+		return value
+	}
+	return ""
+}
@@ -0,0 +1,125 @@
+package netbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"context"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeNetBox serves devices and interfaces like the NetBox API, one object
+// per page, and records PATCHed device custom fields.
+type fakeNetBox struct {
+	devices    []string
+	interfaces []string
+	patched    map[string]string
+}
+
+func (f *fakeNetBox) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Header.Get("Authorization") != "Token secret" {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	if req.Method == "PATCH" {
+		body, _ := ioutil.ReadAll(req.Body)
+		f.patched[req.URL.Path] = string(body)
+		return
+	}
+	var objects []string
+	switch req.URL.Path {
+	case "/api/dcim/devices/":
+		objects = f.devices
+	case "/api/dcim/interfaces/":
+		objects = f.interfaces
+	default:
+		http.NotFound(w, req)
+		return
+	}
+	offset := 0
+	fmt.Sscan(req.URL.Query().Get("offset"), &offset)
+	next := ""
+	if offset+1 < len(objects) {
+		next = fmt.Sprintf("http://%s%s?limit=1&offset=%d", req.Host, req.URL.Path, offset+1)
+	}
+	results := "[]"
+	if offset < len(objects) {
+		results = "[" + objects[offset] + "]"
+	}
+	fmt.Fprintf(w, `{"count": %d, "next": %q, "results": %s}`, len(objects), next, results)
+}
+
+func newFakeNetBox() *fakeNetBox {
+	return &fakeNetBox{
+		devices: []string{
+			`{"id": 1, "name": "node1", "serial": "SN1", "status": {"value": "active"}, "site": {"slug": "dc1"}, "rack": {"name": "r1"}, "device_role": {"slug": "worker"}, "device_type": {"model": "PowerEdge R640"}, "primary_ip4": {"address": "10.0.0.21/24"}, "primary_ip6": {"address": "2001:db8::21/64"}, "custom_fields": {"uuid": "a1b2c3d4", "matchbox_state": null}, "config_context": {"etcd_cluster": "node1=http://node1:2380", "disks": 2, "ntp": ["pool.ntp.org"]}}`,
+			`{"id": 2, "name": "node2", "status": {"value": "planned"}, "role": {"slug": "worker"}, "tenant": {"slug": "acme"}, "primary_ip4": null, "custom_fields": {"matchbox_state": "provisioned"}}`,
+			`{"id": 3, "name": "pdu1", "status": {"value": "active"}}`,
+		},
+		interfaces: []string{
+			`{"device": {"id": 1}, "mac_address": "52:54:00:A1:9C:AE", "mgmt_only": false}`,
+			`{"device": {"id": 1}, "mac_address": "52:54:00:00:00:01", "mgmt_only": true}`,
+			`{"device": {"id": 2}, "mac_address": "52:54:00:b2:2f:86", "mgmt_only": false}`,
+			`{"device": {"id": 3}, "mac_address": null, "mgmt_only": false}`,
+		},
+		patched: make(map[string]string),
+	}
+}
+
+func TestClientDevices(t *testing.T) {
+	ts := httptest.NewServer(newFakeNetBox())
+	defer ts.Close()
+	client := NewClient(&ClientConfig{URL: ts.URL + "/", Token: "secret"})
+
+	devices, err := client.Devices(context.Background())
+	assert.Nil(t, err)
+	// assert that:
+	// - devices and interfaces are read from every page
+	// - nested objects are flattened and IP prefix lengths are removed
+	// - MAC addresses of management interfaces are skipped
+	if assert.Len(t, devices, 3) {
+		assert.Equal(t, &Device{
+			ID:            1,
+			Name:          "node1",
+			Serial:        "SN1",
+			Status:        "active",
+			Site:          "dc1",
+			Rack:          "r1",
+			Role:          "worker",
+			Model:         "PowerEdge R640",
+			IP:            "10.0.0.21",
+			IP6:           "2001:db8::21",
+			UUID:          "a1b2c3d4",
+			MACs:          []string{"52:54:00:a1:9c:ae"},
+			ConfigContext: map[string]interface{}{"etcd_cluster": "node1=http://node1:2380", "disks": float64(2), "ntp": []interface{}{"pool.ntp.org"}},
+		}, devices[0])
+		assert.Equal(t, "worker", devices[1].Role)
+		assert.Equal(t, "provisioned", devices[1].State)
+		assert.Empty(t, devices[2].MACs)
+	}
+}
+
+func TestClientSetState(t *testing.T) {
+	netbox := newFakeNetBox()
+	ts := httptest.NewServer(netbox)
+	defer ts.Close()
+	client := NewClient(&ClientConfig{URL: ts.URL, Token: "secret"})
+
+	assert.Nil(t, client.SetState(context.Background(), 2, "provisioned"))
+	var body map[string]map[string]string
+	assert.Nil(t, json.Unmarshal([]byte(netbox.patched["/api/dcim/devices/2/"]), &body))
+	assert.Equal(t, "provisioned", body["custom_fields"]["matchbox_state"])
+}
+
+func TestClient_Unauthorized(t *testing.T) {
+	ts := httptest.NewServer(newFakeNetBox())
+	defer ts.Close()
+	client := NewClient(&ClientConfig{URL: ts.URL, Token: "wrong"})
+
+	_, err := client.Devices(context.Background())
+	assert.Contains(t, err.Error(), "403 Forbidden")
+}
@@ -0,0 +1,4 @@
+// Package netbox records the devices documented in NetBox as Machine facts
+// and optionally reports provisioning state back to NetBox, so DCIM and
+// provisioning stay aligned.
+package netbox
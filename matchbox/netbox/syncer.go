@@ -0,0 +1,194 @@
+package netbox
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"context"
+	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+const (
+	// defaultInterval is how often devices are read from NetBox.
+	defaultInterval = 5 * time.Minute
+	// syncTimeout bounds a sync with NetBox.
+	syncTimeout = 2 * time.Minute
+)
+
+// Facts recorded from a NetBox device.
+const (
+	FactID       = "netbox_id"
+	FactStatus   = "netbox_status"
+	FactHostname = "hostname"
+	FactIP       = "ip"
+	FactIP6      = "ip6"
+	FactSerial   = "serial"
+	FactSite     = "site"
+	FactRack     = "rack"
+	FactRole     = "role"
+	FactTenant   = "tenant"
+	FactModel    = "model"
+)
+
+// Config configures a Syncer.
+type Config struct {
+	Client *Client
+	// Interval between syncs
+	Interval time.Duration
+	// PushState reports Machine states to the matchbox_state custom field
+	PushState bool
+	Server    server.Server
+	Logger    *logrus.Logger
+}
+
+// Syncer periodically records NetBox devices as facts on the Machines with
+// their UUIDs or MAC addresses, creating Machines which have not booted yet.
+type Syncer struct {
+	config *Config
+}
+
+// NewSyncer returns a new Syncer.
+func NewSyncer(config *Config) *Syncer {
+	if config.Interval == 0 {
+		config.Interval = defaultInterval
+	}
+	return &Syncer{
+		config: config,
+	}
+}
+
+// Run syncs with NetBox every interval until the stop channel is closed.
+func (s *Syncer) Run(stop <-chan struct{}) {
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+		if err := s.Sync(ctx); err != nil {
+			s.config.Logger.Errorf("netbox: %v", err)
+		}
+		cancel()
+		select {
+		case <-stop:
+			return
+		case <-time.After(s.config.Interval):
+		}
+	}
+}
+
+// Sync records each NetBox device on its Machine and, if enabled, reports
+// the Machine's state back to NetBox. Devices without a UUID custom field or
+// interface MAC addresses cannot be matched to Machines and are skipped.
+func (s *Syncer) Sync(ctx context.Context) error {
+	devices, err := s.config.Client.Devices(ctx)
+	if err != nil {
+		return err
+	}
+	machines, err := s.config.Server.MachineList(ctx, &pb.MachineListRequest{})
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]*storagepb.Machine)
+	byMAC := make(map[string]*storagepb.Machine)
+	for _, machine := range machines {
+		byID[machine.Id] = machine
+		if mac := machine.Labels["mac"]; mac != "" {
+			byMAC[mac] = machine
+		}
+	}
+
+	for _, device := range devices {
+		machine := findMachine(device, byID, byMAC)
+		if machine == nil {
+			id := device.UUID
+			if id == "" && len(device.MACs) > 0 {
+				id = device.MACs[0]
+			}
+			if id == "" {
+				s.config.Logger.Debugf("netbox: device %d (%s) has no UUID or MAC addresses", device.ID, device.Name)
+				continue
+			}
+			machine = &storagepb.Machine{Id: id}
+		}
+		if err := s.record(ctx, machine.Id, device); err != nil {
+			return err
+		}
+		if s.config.PushState && machine.State != "" && machine.State != device.State {
+			if err := s.config.Client.SetState(ctx, device.ID, machine.State); err != nil {
+				s.config.Logger.Warningf("netbox: error setting state of device %d (%s): %v", device.ID, device.Name, err)
+				continue
+			}
+			s.config.Logger.Debugf("netbox: device %d (%s) is %s", device.ID, device.Name, machine.State)
+		}
+	}
+	return nil
+}
+
+// findMachine returns the Machine with a device's UUID or one of its MAC
+// addresses, or nil.
+func findMachine(device *Device, byID, byMAC map[string]*storagepb.Machine) *storagepb.Machine {
+	if machine, ok := byID[device.UUID]; ok && device.UUID != "" {
+		return machine
+	}
+	for _, mac := range device.MACs {
+		if machine, ok := byID[mac]; ok {
+			return machine
+		}
+		if machine, ok := byMAC[mac]; ok {
+			return machine
+		}
+	}
+	return nil
+}
+
+// record sets the facts of a device on the Machine with an id.
+func (s *Syncer) record(ctx context.Context, id string, device *Device) error {
+	facts := deviceFacts(device)
+	machine, err := s.config.Server.MachineUpdate(ctx, id, func(machine *storagepb.Machine) (*storagepb.Machine, error) {
+		if machine == nil {
+			machine = &storagepb.Machine{Id: id}
+		}
+		if !machine.SetFacts(facts) {
+			return nil, nil
+		}
+		return machine, nil
+	})
+	if machine != nil {
+		s.config.Logger.Debugf("netbox: recorded machine %s from device %d (%s)", id, device.ID, device.Name)
+	}
+	return err
+}
+
+// deviceFacts returns the non-empty attributes of a device and the scalar
+// values of its config context. Attributes take precedence over config
+// context values with the same name.
+func deviceFacts(device *Device) map[string]string {
+	facts := make(map[string]string)
+	for key, value := range device.ConfigContext {
+		switch value.(type) {
+		case string, float64, bool:
+			facts[key] = fmt.Sprint(value)
+		}
+	}
+	attributes := map[string]string{
+		FactID:       strconv.Itoa(device.ID),
+		FactStatus:   device.Status,
+		FactHostname: device.Name,
+		FactIP:       device.IP,
+		FactIP6:      device.IP6,
+		FactSerial:   device.Serial,
+		FactSite:     device.Site,
+		FactRack:     device.Rack,
+		FactRole:     device.Role,
+		FactTenant:   device.Tenant,
+		FactModel:    device.Model,
+	}
+	for key, value := range attributes {
+		if value != "" {
+			facts[key] = value
+		}
+	}
+	return facts
+}
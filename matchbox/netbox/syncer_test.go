@@ -0,0 +1,68 @@
+package netbox
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"context"
+	"github.com/Sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestSync(t *testing.T) {
+	netbox := newFakeNetBox()
+	ts := httptest.NewServer(netbox)
+	defer ts.Close()
+	store := fake.NewFixedStore()
+	store.Machines["52:54:00:b2:2f:86"] = &storagepb.Machine{
+		Id:     "52:54:00:b2:2f:86",
+		Labels: map[string]string{"mac": "52:54:00:b2:2f:86"},
+		State:  storagepb.MachineBooted,
+		Facts:  map[string]string{"ip": "10.0.0.22"},
+	}
+	syncer := NewSyncer(&Config{
+		Client:    NewClient(&ClientConfig{URL: ts.URL, Token: "secret"}),
+		PushState: true,
+		Server:    server.NewServer(&server.Config{Store: store}),
+		Logger:    logrus.New(),
+	})
+	assert.Nil(t, syncer.Sync(context.Background()))
+
+	// assert that:
+	// - devices without a UUID are matched to Machines by MAC address
+	// - device attributes and config context values are recorded as facts
+	// - Machines which have not booted are created by UUID
+	// - Machine states which differ are pushed to NetBox
+	// - devices without a UUID or MAC address are skipped
+	assert.Equal(t, map[string]string{
+		"ip":            "10.0.0.22",
+		"netbox_id":     "2",
+		"netbox_status": "planned",
+		"hostname":      "node2",
+		"role":          "worker",
+		"tenant":        "acme",
+	}, store.Machines["52:54:00:b2:2f:86"].Facts)
+	created := store.Machines["a1b2c3d4"]
+	if assert.NotNil(t, created) {
+		assert.Equal(t, map[string]string{
+			"netbox_id":     "1",
+			"netbox_status": "active",
+			"hostname":      "node1",
+			"ip":            "10.0.0.21",
+			"ip6":           "2001:db8::21",
+			"serial":        "SN1",
+			"site":          "dc1",
+			"rack":          "r1",
+			"role":          "worker",
+			"model":         "PowerEdge R640",
+			"etcd_cluster":  "node1=http://node1:2380",
+			"disks":         "2",
+		}, created.Facts)
+	}
+	assert.Len(t, store.Machines, 2)
+	assert.Equal(t, map[string]string{"/api/dcim/devices/2/": `{"custom_fields":{"matchbox_state":"booted"}}`}, netbox.patched)
+}
@@ -0,0 +1,4 @@
+// Package oidc verifies OpenID Connect ID tokens and maps the groups of
+// their users to roles, so an enterprise identity provider can govern who
+// may read or change provisioning configs.
+package oidc
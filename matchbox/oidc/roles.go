@@ -0,0 +1,70 @@
+package oidc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Role is a level of access to the API.
+type Role int
+
+// Roles, in increasing order of access.
+const (
+	// RoleNone grants no access
+	RoleNone Role = iota
+	// RoleViewer may read groups, profiles, templates, and machines
+	RoleViewer
+	// RoleEditor may also change them
+	RoleEditor
+)
+
+// roleNames maps role names to Roles.
+var roleNames = map[string]Role{
+	"viewer": RoleViewer,
+	"editor": RoleEditor,
+}
+
+func (r Role) String() string {
+	for name, role := range roleNames {
+		if role == r {
+			return name
+		}
+	}
+	return "none"
+}
+
+// RoleMap maps identity provider groups to Roles.
+type RoleMap map[string]Role
+
+// ParseRoles parses comma separated GROUP=ROLE pairs (e.g.
+// "sre=editor,eng=viewer") into a RoleMap.
+func ParseRoles(s string) (RoleMap, error) {
+	roles := make(RoleMap)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		i := strings.LastIndex(pair, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("oidc: role mapping %q must be GROUP=ROLE", pair)
+		}
+		role, ok := roleNames[pair[i+1:]]
+		if !ok {
+			return nil, fmt.Errorf("oidc: unknown role %q, must be viewer or editor", pair[i+1:])
+		}
+		roles[pair[:i]] = role
+	}
+	return roles, nil
+}
+
+// Role returns the greatest Role granted to any of the groups.
+func (m RoleMap) Role(groups []string) Role {
+	role := RoleNone
+	for _, group := range groups {
+		if r := m[group]; r > role {
+			role = r
+		}
+	}
+	return role
+}
@@ -0,0 +1,25 @@
+package oidc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRoles(t *testing.T) {
+	roles, err := ParseRoles("sre=editor, eng=viewer,cn=ops=viewer,")
+	assert.Nil(t, err)
+	// assert that:
+	// - groups may contain "="
+	// - the greatest role of a user's groups is granted
+	assert.Equal(t, RoleMap{"sre": RoleEditor, "eng": RoleViewer, "cn=ops": RoleViewer}, roles)
+	assert.Equal(t, RoleEditor, roles.Role([]string{"eng", "sre"}))
+	assert.Equal(t, RoleViewer, roles.Role([]string{"eng"}))
+	assert.Equal(t, RoleNone, roles.Role([]string{"sales"}))
+	assert.Equal(t, "editor", RoleEditor.String())
+
+	_, err = ParseRoles("sre")
+	assert.Contains(t, err.Error(), "must be GROUP=ROLE")
+	_, err = ParseRoles("sre=admin")
+	assert.Contains(t, err.Error(), `unknown role "admin"`)
+}
@@ -0,0 +1,313 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"context"
+)
+
+// Possible verification errors
+var (
+	ErrMalformedToken    = errors.New("oidc: malformed ID token")
+	ErrUnsupportedAlg    = errors.New("oidc: unsupported ID token signing algorithm")
+	ErrUnknownKey        = errors.New("oidc: ID token signed by an unknown key")
+	ErrInvalidSignature  = errors.New("oidc: invalid ID token signature")
+	ErrIssuerMismatch    = errors.New("oidc: ID token issued by another issuer")
+	ErrAudienceMismatch  = errors.New("oidc: ID token issued to another client")
+	ErrTokenExpired      = errors.New("oidc: ID token is expired")
+	ErrTokenNotYetValid  = errors.New("oidc: ID token is not valid yet")
+	ErrDiscoveryMismatch = errors.New("oidc: discovered issuer does not match")
+)
+
+const (
+	// defaultTimeout bounds requests to the issuer.
+	defaultTimeout = 30 * time.Second
+	// defaultGroupsClaim is the claim which lists a user's groups.
+	defaultGroupsClaim = "groups"
+	// refreshInterval limits how often keys are refetched for unknown key
+	// ids, since anyone may present a token with an unknown key id.
+	refreshInterval = time.Minute
+	// clockSkew is the tolerated difference between issuer and local clocks.
+	clockSkew = time.Minute
+)
+
+// Config configures a Verifier.
+type Config struct {
+	// Issuer URL (e.g. https://accounts.example.com)
+	Issuer string
+	// ClientID tokens must be issued to (the aud claim)
+	ClientID string
+	// Claim which lists the user's groups (default "groups")
+	GroupsClaim string
+	// (optional) HTTP client, for testing
+	Client *http.Client
+}
+
+// Identity is the verified user of an ID token.
+type Identity struct {
+	// email, if present, or subject
+	Subject string
+	Groups  []string
+}
+
+// Verifier verifies ID tokens signed by an issuer's keys, which are
+// discovered from the issuer and cached.
+type Verifier struct {
+	issuer      string
+	clientID    string
+	groupsClaim string
+	client      *http.Client
+	now         func() time.Time
+
+	mu      sync.Mutex
+	keys    map[string]crypto.PublicKey
+	fetched time.Time
+}
+
+// NewVerifier returns a new Verifier.
+func NewVerifier(config *Config) *Verifier {
+	client := config.Client
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+	groupsClaim := config.GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = defaultGroupsClaim
+	}
+	return &Verifier{
+		issuer:      strings.TrimSuffix(config.Issuer, "/"),
+		clientID:    config.ClientID,
+		groupsClaim: groupsClaim,
+		client:      client,
+		now:         time.Now,
+	}
+}
+
+// Verify verifies the signature and claims of a raw ID token and returns
+// its user.
+func (v *Verifier) Verify(ctx context.Context, rawToken string) (*Identity, error) {
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrMalformedToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrMalformedToken
+	}
+	if iss, _ := claims["iss"].(string); strings.TrimSuffix(iss, "/") != v.issuer {
+		return nil, ErrIssuerMismatch
+	}
+	if !contains(stringsClaim(claims["aud"]), v.clientID) {
+		return nil, ErrAudienceMismatch
+	}
+	now := v.now()
+	exp, ok := claims["exp"].(float64)
+	if !ok || now.After(time.Unix(int64(exp), 0).Add(clockSkew)) {
+		return nil, ErrTokenExpired
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(clockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return nil, ErrTokenNotYetValid
+	}
+
+	identity := &Identity{Groups: stringsClaim(claims[v.groupsClaim])}
+	identity.Subject, _ = claims["email"].(string)
+	if identity.Subject == "" {
+		identity.Subject, _ = claims["sub"].(string)
+	}
+	return identity, nil
+}
+
+// key returns the issuer's public key with the key id, fetching the
+// issuer's keys if the key id is unknown.
+func (v *Verifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	if v.keys != nil && v.now().Sub(v.fetched) < refreshInterval {
+		return nil, ErrUnknownKey
+	}
+	keys, err := v.fetchKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	v.keys, v.fetched = keys, v.now()
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+// fetchKeys discovers the issuer's JWKS URL and fetches its signing keys.
+// Keys of unsupported types are skipped.
+func (v *Verifier) fetchKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	var discovery struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := v.get(ctx, v.issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != v.issuer {
+		return nil, ErrDiscoveryMismatch
+	}
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := v.get(ctx, discovery.JWKSURI, &jwks); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for _, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch {
+		case k.Kty == "RSA":
+			n, nerr := decodeInt(k.N)
+			e, eerr := decodeInt(k.E)
+			if nerr == nil && eerr == nil {
+				keys[k.Kid] = &rsa.PublicKey{N: n, E: int(e.Int64())}
+			}
+		case k.Kty == "EC" && k.Crv == "P-256":
+			x, xerr := decodeInt(k.X)
+			y, yerr := decodeInt(k.Y)
+			if xerr == nil && yerr == nil {
+				keys[k.Kid] = &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+			}
+		}
+	}
+	return keys, nil
+}
+
+// get fetches a JSON document from the issuer.
+func (v *Verifier) get(ctx context.Context, url string, doc interface{}) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("oidc: GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(doc)
+}
+
+// verifySignature verifies an RS256 or ES256 signature of the signed
+// header and payload segments.
+func verifySignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signed))
+	switch alg {
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrInvalidSignature
+		}
+		if rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature) != nil {
+			return ErrInvalidSignature
+		}
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return ErrInvalidSignature
+		}
+	default:
+		return ErrUnsupportedAlg
+	}
+	return nil
+}
+
+// decodeSegment decodes a base64url encoded JSON token segment.
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// decodeInt decodes a base64url encoded big-endian integer.
+func decodeInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// stringsClaim returns the values of a string or string list claim.
+func stringsClaim(claim interface{}) []string {
+	switch value := claim.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		var values []string
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
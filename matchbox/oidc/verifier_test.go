@@ -0,0 +1,153 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"context"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeIssuer serves OIDC discovery and JWKS documents and signs tokens.
+type fakeIssuer struct {
+	*httptest.Server
+	rsaKey     *rsa.PrivateKey
+	ecKey      *ecdsa.PrivateKey
+	keyFetches int
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	issuer := &fakeIssuer{rsaKey: rsaKey, ecKey: ecKey}
+	issuer.Server = httptest.NewServer(http.HandlerFunc(issuer.serveHTTP))
+	return issuer
+}
+
+func (f *fakeIssuer) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.URL.Path {
+	case "/.well-known/openid-configuration":
+		fmt.Fprintf(w, `{"issuer": %q, "jwks_uri": %q}`, f.URL, f.URL+"/keys")
+	case "/keys":
+		f.keyFetches++
+		fmt.Fprintf(w, `{"keys": [
+			{"kty": "RSA", "kid": "rsa1", "use": "sig", "n": %q, "e": %q},
+			{"kty": "EC", "kid": "ec1", "crv": "P-256", "x": %q, "y": %q},
+			{"kty": "RSA", "kid": "enc1", "use": "enc", "n": %q, "e": %q}
+		]}`,
+			encodeInt(f.rsaKey.N), encodeInt(big.NewInt(int64(f.rsaKey.E))),
+			encodeInt(f.ecKey.X), encodeInt(f.ecKey.Y),
+			encodeInt(f.rsaKey.N), encodeInt(big.NewInt(int64(f.rsaKey.E))))
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+// sign returns a token with the claims signed by the issuer's key.
+func (f *fakeIssuer) sign(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	var signature []byte
+	if alg == "ES256" {
+		r, s, err := ecdsa.Sign(rand.Reader, f.ecKey, digest[:])
+		assert.Nil(t, err)
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	} else {
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, f.rsaKey, crypto.SHA256, digest[:])
+		assert.Nil(t, err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func encodeInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func TestVerify(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	defer issuer.Close()
+	now := time.Date(2017, 5, 1, 12, 0, 0, 0, time.UTC)
+	verifier := NewVerifier(&Config{Issuer: issuer.URL + "/", ClientID: "matchbox"})
+	verifier.now = func() time.Time { return now }
+	claims := map[string]interface{}{
+		"iss":    issuer.URL,
+		"aud":    []string{"matchbox", "other"},
+		"sub":    "1234",
+		"email":  "jane@example.com",
+		"exp":    now.Add(time.Hour).Unix(),
+		"groups": []string{"sre", "eng"},
+	}
+
+	// assert that:
+	// - RS256 and ES256 tokens are verified with the issuer's keys
+	// - the user's email and groups are returned
+	// - keys are fetched once and cached
+	for _, tc := range []struct{ alg, kid string }{{"RS256", "rsa1"}, {"ES256", "ec1"}} {
+		identity, err := verifier.Verify(context.Background(), issuer.sign(t, tc.alg, tc.kid, claims))
+		if assert.Nil(t, err, tc.alg) {
+			assert.Equal(t, &Identity{Subject: "jane@example.com", Groups: []string{"sre", "eng"}}, identity)
+		}
+	}
+	assert.Equal(t, 1, issuer.keyFetches)
+}
+
+func TestVerify_Errors(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	defer issuer.Close()
+	now := time.Date(2017, 5, 1, 12, 0, 0, 0, time.UTC)
+	verifier := NewVerifier(&Config{Issuer: issuer.URL, ClientID: "matchbox"})
+	verifier.now = func() time.Time { return now }
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{"iss": issuer.URL, "aud": "matchbox", "sub": "1234", "exp": now.Add(time.Hour).Unix()}
+	}
+	with := func(key string, value interface{}) map[string]interface{} {
+		claims := valid()
+		claims[key] = value
+		return claims
+	}
+	token := issuer.sign(t, "RS256", "rsa1", valid())
+
+	cases := []struct {
+		token string
+		err   error
+	}{
+		{"not-a-token", ErrMalformedToken},
+		{issuer.sign(t, "RS256", "missing", valid()), ErrUnknownKey},
+		// keys for encryption are not used to verify signatures
+		{issuer.sign(t, "RS256", "enc1", valid()), ErrUnknownKey},
+		{issuer.sign(t, "HS256", "rsa1", valid()), ErrUnsupportedAlg},
+		// an RSA signature presented as ECDSA
+		{issuer.sign(t, "RS256", "ec1", valid()), ErrInvalidSignature},
+		{token[:len(token)-4] + "AAAA", ErrInvalidSignature},
+		{issuer.sign(t, "RS256", "rsa1", with("iss", "https://evil.example.com")), ErrIssuerMismatch},
+		{issuer.sign(t, "RS256", "rsa1", with("aud", "other")), ErrAudienceMismatch},
+		{issuer.sign(t, "RS256", "rsa1", with("exp", now.Add(-2*time.Minute).Unix())), ErrTokenExpired},
+		{issuer.sign(t, "RS256", "rsa1", with("nbf", now.Add(2*time.Minute).Unix())), ErrTokenNotYetValid},
+	}
+	for _, tc := range cases {
+		_, err := verifier.Verify(context.Background(), tc.token)
+		assert.Equal(t, tc.err, err)
+	}
+	// assert that:
+	// - unknown key ids do not refetch keys more than once per interval
+	assert.Equal(t, 1, issuer.keyFetches)
+}
@@ -0,0 +1,3 @@
+// Package power controls machine power through baseboard management
+// controllers (BMCs).
+package power
@@ -0,0 +1,115 @@
+package power
+
+import (
+	"net/url"
+	"strings"
+
+	"context"
+)
+
+// systemsPath is the path of the Redfish ComputerSystem collection.
+const systemsPath = "/redfish/v1/Systems"
+
+// odataLink is a Redfish link to another resource.
+type odataLink struct {
+	ID string `json:"@odata.id"`
+}
+
+// collection is a Redfish resource collection.
+type collection struct {
+	Members []odataLink
+}
+
+// Systems returns the inventory of the ComputerSystem at a URL, or of each
+// ComputerSystem if the URL has no path.
+func (r *redfish) Systems(ctx context.Context, bmc string) ([]*System, error) {
+	base, err := url.Parse(strings.TrimSuffix(bmc, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if base.Path != "" && base.Path != systemsPath {
+		system, err := r.system(ctx, base)
+		if err != nil {
+			return nil, err
+		}
+		return []*System{system}, nil
+	}
+	var members collection
+	if err := r.do(ctx, "GET", resolve(base, systemsPath), nil, &members); err != nil {
+		return nil, err
+	}
+	var systems []*System
+	for _, member := range members.Members {
+		u, err := url.Parse(resolve(base, member.ID))
+		if err != nil {
+			return nil, err
+		}
+		system, err := r.system(ctx, u)
+		if err != nil {
+			return nil, err
+		}
+		systems = append(systems, system)
+	}
+	return systems, nil
+}
+
+// system reads a ComputerSystem and counts its network interfaces and
+// drives.
+func (r *redfish) system(ctx context.Context, u *url.URL) (*System, error) {
+	var cs struct {
+		UUID             string
+		SerialNumber     string
+		Manufacturer     string
+		Model            string
+		SKU              string
+		ProcessorSummary struct {
+			Count int
+		}
+		MemorySummary struct {
+			TotalSystemMemoryGiB float64
+		}
+		EthernetInterfaces odataLink
+		Storage            odataLink
+	}
+	if err := r.do(ctx, "GET", u.String(), nil, &cs); err != nil {
+		return nil, err
+	}
+	system := &System{
+		URL:          u.String(),
+		UUID:         strings.ToLower(cs.UUID),
+		SerialNumber: cs.SerialNumber,
+		Manufacturer: cs.Manufacturer,
+		Model:        cs.Model,
+		SKU:          cs.SKU,
+		CPUs:         cs.ProcessorSummary.Count,
+		MemoryGiB:    cs.MemorySummary.TotalSystemMemoryGiB,
+	}
+	if cs.EthernetInterfaces.ID != "" {
+		var nics collection
+		if err := r.do(ctx, "GET", resolve(u, cs.EthernetInterfaces.ID), nil, &nics); err != nil {
+			return nil, err
+		}
+		system.NICs = len(nics.Members)
+	}
+	if cs.Storage.ID != "" {
+		var storage collection
+		if err := r.do(ctx, "GET", resolve(u, cs.Storage.ID), nil, &storage); err != nil {
+			return nil, err
+		}
+		for _, member := range storage.Members {
+			var controller struct {
+				Drives []odataLink
+			}
+			if err := r.do(ctx, "GET", resolve(u, member.ID), nil, &controller); err != nil {
+				return nil, err
+			}
+			system.Disks += len(controller.Drives)
+		}
+	}
+	return system, nil
+}
+
+// resolve resolves a Redfish resource path against the BMC URL.
+func resolve(base *url.URL, path string) string {
+	return base.ResolveReference(&url.URL{Path: path}).String()
+}
@@ -0,0 +1,51 @@
+package power
+
+import (
+	"errors"
+
+	"context"
+)
+
+// Power actions
+const (
+	ActionOn     = "on"
+	ActionOff    = "off"
+	ActionCycle  = "cycle"
+	ActionStatus = "status"
+)
+
+// Possible power control errors
+var (
+	ErrUnknownAction = errors.New("power: action must be one of on, off, cycle, or status")
+	ErrBMCRequired   = errors.New("power: machine has no BMC")
+)
+
+// A System is the hardware inventory of a machine, as reported by its BMC.
+type System struct {
+	// Redfish ComputerSystem URL
+	URL          string
+	UUID         string
+	SerialNumber string
+	Manufacturer string
+	Model        string
+	SKU          string
+	CPUs         int
+	MemoryGiB    float64
+	NICs         int
+	Disks        int
+}
+
+// An Inventory reads the hardware inventory of machines from BMCs.
+type Inventory interface {
+	// Systems returns the inventory of the ComputerSystems at a BMC URL,
+	// which may be a ComputerSystem URL or the BMC's base URL (e.g.
+	// https://10.0.0.5) to read all of its ComputerSystems.
+	Systems(ctx context.Context, url string) ([]*System, error)
+}
+
+// A Controller performs power actions on the machine managed by a BMC.
+type Controller interface {
+	// Power performs an action and returns the resulting power state, as
+	// reported by the BMC.
+	Power(ctx context.Context, bmc, action string) (string, error)
+}
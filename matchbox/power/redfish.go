@@ -0,0 +1,130 @@
+package power
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"context"
+)
+
+// resetTypes maps power actions to Redfish ComputerSystem.Reset types.
+var resetTypes = map[string]string{
+	ActionOn:    "On",
+	ActionOff:   "ForceOff",
+	ActionCycle: "ForceRestart",
+}
+
+// defaultTimeout bounds each request to a BMC.
+const defaultTimeout = 30 * time.Second
+
+// RedfishConfig configures a Redfish Controller.
+type RedfishConfig struct {
+	// BMC credentials
+	Username string
+	Password string
+	// skip verification of BMC certificates, which are often self-signed
+	InsecureSkipVerify bool
+	// (optional) HTTP client, for testing
+	Client *http.Client
+}
+
+// redfish controls power through the Redfish API. BMCs are identified by
+// the URL of a Redfish ComputerSystem (e.g.
+// https://10.0.0.5/redfish/v1/Systems/1).
+type redfish struct {
+	username string
+	password string
+	client   *http.Client
+}
+
+// NewRedfish returns a Controller which uses the Redfish API.
+func NewRedfish(config *RedfishConfig) Controller {
+	return newRedfish(config)
+}
+
+// NewRedfishInventory returns an Inventory which uses the Redfish API.
+func NewRedfishInventory(config *RedfishConfig) Inventory {
+	return newRedfish(config)
+}
+
+func newRedfish(config *RedfishConfig) *redfish {
+	client := config.Client
+	if client == nil {
+		client = &http.Client{
+			Timeout: defaultTimeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: config.InsecureSkipVerify},
+			},
+		}
+	}
+	return &redfish{
+		username: config.Username,
+		password: config.Password,
+		client:   client,
+	}
+}
+
+// Power resets the ComputerSystem for on, off, and cycle actions and returns
+// its PowerState.
+func (r *redfish) Power(ctx context.Context, bmc, action string) (string, error) {
+	if bmc == "" {
+		return "", ErrBMCRequired
+	}
+	bmc = strings.TrimSuffix(bmc, "/")
+	if action != ActionStatus {
+		resetType, ok := resetTypes[action]
+		if !ok {
+			return "", ErrUnknownAction
+		}
+		body, err := json.Marshal(map[string]string{"ResetType": resetType})
+		if err != nil {
+			return "", err
+		}
+		if err := r.do(ctx, "POST", bmc+"/Actions/ComputerSystem.Reset", body, nil); err != nil {
+			return "", err
+		}
+	}
+	var system struct {
+		PowerState string
+	}
+	if err := r.do(ctx, "GET", bmc, nil, &system); err != nil {
+		return "", err
+	}
+	return system.PowerState, nil
+}
+
+// do sends a request to the BMC and decodes the JSON response into v, if
+// non-nil.
+func (r *redfish) do(ctx context.Context, method, url string, body []byte, v interface{}) error {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.SetBasicAuth(r.username, r.password)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("power: %s %s: %s: %s", method, url, resp.Status, bytes.TrimSpace(msg))
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
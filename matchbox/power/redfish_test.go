@@ -0,0 +1,146 @@
+package power
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"context"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBMC serves a Redfish ComputerSystem at /redfish/v1/Systems/1.
+type fakeBMC struct {
+	state  string
+	resets []string
+}
+
+func (b *fakeBMC) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if user, pass, ok := req.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case req.Method == "GET" && req.URL.Path == "/redfish/v1/Systems/1":
+		json.NewEncoder(w).Encode(map[string]string{"PowerState": b.state})
+	case req.Method == "POST" && req.URL.Path == "/redfish/v1/Systems/1/Actions/ComputerSystem.Reset":
+		var body struct{ ResetType string }
+		json.NewDecoder(req.Body).Decode(&body)
+		b.resets = append(b.resets, body.ResetType)
+		if body.ResetType == "ForceOff" {
+			b.state = "Off"
+		} else {
+			b.state = "On"
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func TestRedfishPower(t *testing.T) {
+	bmc := &fakeBMC{state: "Off"}
+	server := httptest.NewServer(bmc)
+	defer server.Close()
+	controller := NewRedfish(&RedfishConfig{Username: "admin", Password: "secret"})
+	system := server.URL + "/redfish/v1/Systems/1"
+	ctx := context.Background()
+
+	// assert that:
+	// - status reports the PowerState without resetting
+	// - on, off, and cycle send the matching ResetType
+	state, err := controller.Power(ctx, system, ActionStatus)
+	assert.Nil(t, err)
+	assert.Equal(t, "Off", state)
+	assert.Empty(t, bmc.resets)
+
+	state, err = controller.Power(ctx, system+"/", ActionOn)
+	assert.Nil(t, err)
+	assert.Equal(t, "On", state)
+	_, err = controller.Power(ctx, system, ActionCycle)
+	assert.Nil(t, err)
+	state, err = controller.Power(ctx, system, ActionOff)
+	assert.Nil(t, err)
+	assert.Equal(t, "Off", state)
+	assert.Equal(t, []string{"On", "ForceRestart", "ForceOff"}, bmc.resets)
+}
+
+func TestRedfishPowerErrors(t *testing.T) {
+	server := httptest.NewServer(&fakeBMC{state: "On"})
+	defer server.Close()
+	ctx := context.Background()
+	system := server.URL + "/redfish/v1/Systems/1"
+
+	// assert that:
+	// - a BMC and known action are required
+	// - BMC errors are returned
+	controller := NewRedfish(&RedfishConfig{Username: "admin", Password: "secret"})
+	_, err := controller.Power(ctx, "", ActionOn)
+	assert.Equal(t, ErrBMCRequired, err)
+	_, err = controller.Power(ctx, system, "reboot")
+	assert.Equal(t, ErrUnknownAction, err)
+
+	controller = NewRedfish(&RedfishConfig{Username: "admin", Password: "wrong"})
+	_, err = controller.Power(ctx, system, ActionStatus)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "401 Unauthorized")
+	}
+}
+
+// fakeInventoryBMC serves a Redfish service with two ComputerSystems.
+func fakeInventoryBMC() http.Handler {
+	resources := map[string]string{
+		"/redfish/v1/Systems":                      `{"Members": [{"@odata.id": "/redfish/v1/Systems/1"}, {"@odata.id": "/redfish/v1/Systems/2"}]}`,
+		"/redfish/v1/Systems/1":                    `{"UUID": "A1B2C3D4-0000-0000-0000-000000000001", "SerialNumber": "SN1", "Manufacturer": "Dell Inc.", "Model": "PowerEdge R640", "ProcessorSummary": {"Count": 2}, "MemorySummary": {"TotalSystemMemoryGiB": 384}, "EthernetInterfaces": {"@odata.id": "/redfish/v1/Systems/1/EthernetInterfaces"}, "Storage": {"@odata.id": "/redfish/v1/Systems/1/Storage"}}`,
+		"/redfish/v1/Systems/1/EthernetInterfaces": `{"Members": [{"@odata.id": "/redfish/v1/Systems/1/EthernetInterfaces/1"}, {"@odata.id": "/redfish/v1/Systems/1/EthernetInterfaces/2"}]}`,
+		"/redfish/v1/Systems/1/Storage":            `{"Members": [{"@odata.id": "/redfish/v1/Systems/1/Storage/RAID.1"}]}`,
+		"/redfish/v1/Systems/1/Storage/RAID.1":     `{"Drives": [{"@odata.id": "/redfish/v1/Chassis/1/Drives/0"}, {"@odata.id": "/redfish/v1/Chassis/1/Drives/1"}]}`,
+		"/redfish/v1/Systems/2":                    `{"UUID": "a1b2c3d4-0000-0000-0000-000000000002", "Model": "PowerEdge R640"}`,
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if body, ok := resources[req.URL.Path]; ok {
+			w.Write([]byte(body))
+			return
+		}
+		http.NotFound(w, req)
+	})
+}
+
+func TestRedfishSystems(t *testing.T) {
+	server := httptest.NewServer(fakeInventoryBMC())
+	defer server.Close()
+	inventory := NewRedfishInventory(&RedfishConfig{})
+	ctx := context.Background()
+
+	// assert that:
+	// - base URLs read every ComputerSystem
+	// - ComputerSystem URLs read one ComputerSystem
+	// - UUIDs are lower case and NICs and drives are counted
+	systems, err := inventory.Systems(ctx, server.URL)
+	assert.Nil(t, err)
+	expected := &System{
+		URL:          server.URL + "/redfish/v1/Systems/1",
+		UUID:         "a1b2c3d4-0000-0000-0000-000000000001",
+		SerialNumber: "SN1",
+		Manufacturer: "Dell Inc.",
+		Model:        "PowerEdge R640",
+		CPUs:         2,
+		MemoryGiB:    384,
+		NICs:         2,
+		Disks:        2,
+	}
+	if assert.Len(t, systems, 2) {
+		assert.Equal(t, expected, systems[0])
+		assert.Equal(t, "a1b2c3d4-0000-0000-0000-000000000002", systems[1].UUID)
+	}
+
+	systems, err = inventory.Systems(ctx, server.URL+"/redfish/v1/Systems/2/")
+	assert.Nil(t, err)
+	if assert.Len(t, systems, 1) {
+		assert.Equal(t, server.URL+"/redfish/v1/Systems/2", systems[0].URL)
+	}
+
+	_, err = inventory.Systems(ctx, server.URL+"/redfish/v1/Systems/3")
+	assert.Error(t, err)
+}
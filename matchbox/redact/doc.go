@@ -0,0 +1,4 @@
+// Package redact hides the values of sensitive metadata keys and labels,
+// such as join tokens and passwords, from logs, audit entries, events, and
+// optionally read API responses.
+package redact
@@ -0,0 +1,203 @@
+package redact
+
+import (
+	"encoding/json"
+	"errors"
+	"path"
+	"strings"
+)
+
+// Placeholder replaces the values of sensitive keys.
+const Placeholder = "REDACTED"
+
+// Possible redaction errors
+var (
+	ErrInvalidPattern = errors.New("redact: invalid sensitive key pattern")
+	ErrNoStoredValue  = errors.New("redact: redacted value has no stored value to keep")
+)
+
+// A Redactor hides the values of keys which match any of its sensitive key
+// patterns. All methods are safe to call on a nil Redactor, which redacts
+// nothing.
+type Redactor struct {
+	patterns []string
+}
+
+// NewRedactor returns a Redactor for the given key patterns. Patterns are
+// matched against key names at any depth, case-insensitively, and may use
+// shell globs (e.g. "*password*").
+func NewRedactor(patterns []string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, ErrInvalidPattern
+		}
+		r.patterns = append(r.patterns, pattern)
+	}
+	return r, nil
+}
+
+// Sensitive returns true if the values of the key should be redacted.
+func (r *Redactor) Sensitive(key string) bool {
+	if r == nil {
+		return false
+	}
+	key = strings.ToLower(key)
+	for _, pattern := range r.patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Labels returns a copy of the labels with sensitive values redacted, or
+// the labels themselves if none are sensitive.
+func (r *Redactor) Labels(labels map[string]string) map[string]string {
+	var redacted map[string]string
+	for key := range labels {
+		if !r.Sensitive(key) {
+			continue
+		}
+		if redacted == nil {
+			redacted = make(map[string]string, len(labels))
+			for k, v := range labels {
+				redacted[k] = v
+			}
+		}
+		redacted[key] = Placeholder
+	}
+	if redacted == nil {
+		return labels
+	}
+	return redacted
+}
+
+// Query returns the raw query of a URL with sensitive parameter values
+// redacted.
+func (r *Redactor) Query(rawQuery string) string {
+	if r == nil || rawQuery == "" {
+		return rawQuery
+	}
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		name := param
+		if i := strings.Index(param, "="); i >= 0 {
+			name = param[:i]
+		}
+		if r.Sensitive(name) {
+			params[i] = name + "=" + Placeholder
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// Metadata returns JSON metadata with the values of sensitive keys, at any
+// depth, replaced by the Placeholder.
+func (r *Redactor) Metadata(data []byte) ([]byte, error) {
+	if r == nil || len(r.patterns) == 0 || len(data) == 0 {
+		return data, nil
+	}
+	var metadata interface{}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, err
+	}
+	if !r.redact(metadata) {
+		return data, nil
+	}
+	return json.Marshal(metadata)
+}
+
+// redact replaces sensitive values within a decoded JSON value and returns
+// true if any were replaced.
+func (r *Redactor) redact(value interface{}) bool {
+	var redacted bool
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if r.Sensitive(key) {
+				v[key] = Placeholder
+				redacted = true
+				continue
+			}
+			redacted = r.redact(child) || redacted
+		}
+	case []interface{}:
+		for _, child := range v {
+			redacted = r.redact(child) || redacted
+		}
+	}
+	return redacted
+}
+
+// Restore returns JSON metadata in which sensitive keys whose values are
+// the Placeholder keep the values in the stored metadata, so redacted
+// metadata which was read from the API can be written back.
+func (r *Redactor) Restore(data, stored []byte) ([]byte, error) {
+	if r == nil || len(r.patterns) == 0 || len(data) == 0 {
+		return data, nil
+	}
+	var metadata, previous interface{}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, err
+	}
+	if len(stored) > 0 {
+		if err := json.Unmarshal(stored, &previous); err != nil {
+			return nil, err
+		}
+	}
+	restored, err := r.restore(metadata, previous)
+	if err != nil || !restored {
+		return data, err
+	}
+	return json.Marshal(metadata)
+}
+
+// restore replaces placeholder values of sensitive keys within a decoded
+// JSON value by the value at the same key (or list index) in the previous
+// value.
+func (r *Redactor) restore(value, previous interface{}) (bool, error) {
+	if list, ok := value.([]interface{}); ok {
+		prev, _ := previous.([]interface{})
+		var restored bool
+		for i, child := range list {
+			var stored interface{}
+			if i < len(prev) {
+				stored = prev[i]
+			}
+			ok, err := r.restore(child, stored)
+			if err != nil {
+				return false, err
+			}
+			restored = restored || ok
+		}
+		return restored, nil
+	}
+	v, ok := value.(map[string]interface{})
+	if !ok {
+		return false, nil
+	}
+	prev, _ := previous.(map[string]interface{})
+	var restored bool
+	for key, child := range v {
+		if r.Sensitive(key) && child == Placeholder {
+			stored, ok := prev[key]
+			if !ok {
+				return false, ErrNoStoredValue
+			}
+			v[key] = stored
+			restored = true
+			continue
+		}
+		ok, err := r.restore(child, prev[key])
+		if err != nil {
+			return false, err
+		}
+		restored = restored || ok
+	}
+	return restored, nil
+}
@@ -0,0 +1,92 @@
+package redact
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRedactor(t *testing.T) {
+	r, err := NewRedactor([]string{"*password*", " Token ", ""})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"*password*", "token"}, r.patterns)
+	_, err = NewRedactor([]string{"[password"})
+	assert.Equal(t, ErrInvalidPattern, err)
+}
+
+func TestSensitive(t *testing.T) {
+	r, err := NewRedactor([]string{"*password*", "token"})
+	assert.Nil(t, err)
+	// assert that:
+	// - keys match patterns case-insensitively
+	// - a nil Redactor redacts nothing
+	assert.True(t, r.Sensitive("root_password"))
+	assert.True(t, r.Sensitive("PASSWORD"))
+	assert.True(t, r.Sensitive("token"))
+	assert.False(t, r.Sensitive("kubeadm_token"))
+	assert.False(t, r.Sensitive("mac"))
+	var none *Redactor
+	assert.False(t, none.Sensitive("password"))
+}
+
+func TestLabels(t *testing.T) {
+	r, err := NewRedactor([]string{"token"})
+	assert.Nil(t, err)
+	labels := map[string]string{"uuid": "a1b2c3d4", "token": "s3cr3t"}
+	// assert that:
+	// - sensitive values are redacted in a copy
+	// - labels without sensitive values are returned as is
+	assert.Equal(t, map[string]string{"uuid": "a1b2c3d4", "token": Placeholder}, r.Labels(labels))
+	assert.Equal(t, "s3cr3t", labels["token"])
+	plain := map[string]string{"uuid": "a1b2c3d4"}
+	assert.Equal(t, plain, r.Labels(plain))
+}
+
+func TestQuery(t *testing.T) {
+	r, err := NewRedactor([]string{"token"})
+	assert.Nil(t, err)
+	assert.Equal(t, "uuid=a1b2c3d4&token="+Placeholder, r.Query("uuid=a1b2c3d4&token=s3cr3t"))
+	assert.Equal(t, "uuid=a1b2c3d4", r.Query("uuid=a1b2c3d4"))
+	assert.Equal(t, "", r.Query(""))
+}
+
+func TestMetadata(t *testing.T) {
+	r, err := NewRedactor([]string{"*password*", "join_token"})
+	assert.Nil(t, err)
+	cases := []struct {
+		metadata string
+		expected string
+	}{
+		{`{"pod_network":"10.2.0.0/16"}`, `{"pod_network":"10.2.0.0/16"}`},
+		{`{"join_token":"abcdef.0123456789abcdef","region":"us"}`, `{"join_token":"REDACTED","region":"us"}`},
+		// nested objects and lists, of any value type
+		{`{"users":[{"name":"core","password_hash":"$6$x"}],"db":{"password":{"v":1}}}`, `{"db":{"password":"REDACTED"},"users":[{"name":"core","password_hash":"REDACTED"}]}`},
+	}
+	for _, c := range cases {
+		redacted, err := r.Metadata([]byte(c.metadata))
+		assert.Nil(t, err)
+		assert.Equal(t, c.expected, string(redacted))
+	}
+	_, err = r.Metadata([]byte(`{`))
+	assert.NotNil(t, err)
+}
+
+func TestRestore(t *testing.T) {
+	r, err := NewRedactor([]string{"*password*"})
+	assert.Nil(t, err)
+	stored := []byte(`{"password":"s3cr3t","users":[{"name":"core","password":"c0r3"}]}`)
+	// assert that:
+	// - placeholder values keep the stored values
+	// - new values replace stored values
+	// - placeholders without stored values are rejected
+	restored, err := r.Restore([]byte(`{"password":"REDACTED","region":"us","users":[{"name":"core","password":"REDACTED"}]}`), stored)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"password":"s3cr3t","region":"us","users":[{"name":"core","password":"c0r3"}]}`, string(restored))
+	restored, err = r.Restore([]byte(`{"password":"n3w"}`), stored)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"password":"n3w"}`, string(restored))
+	_, err = r.Restore([]byte(`{"db_password":"REDACTED"}`), stored)
+	assert.Equal(t, ErrNoStoredValue, err)
+	_, err = r.Restore([]byte(`{"password":"REDACTED"}`), nil)
+	assert.Equal(t, ErrNoStoredValue, err)
+}
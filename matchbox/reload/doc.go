@@ -0,0 +1,3 @@
+// Package reload reloads credentials (e.g. TLS certificates and signing keys)
+// when their files change on disk or on demand, such as on SIGHUP.
+package reload
@@ -0,0 +1,10 @@
+package reload
+
+import (
+	"github.com/coreos/matchbox/matchbox/metrics"
+)
+
+var reloadTotal = metrics.NewCounterVec(
+	"matchbox_reload_total",
+	"Credential reloads by target and result (ok or error).",
+	"target", "result")
@@ -0,0 +1,149 @@
+package reload
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// DefaultInterval is the default interval between checks for changed files.
+const DefaultInterval = 10 * time.Second
+
+// Config configures a Watcher.
+type Config struct {
+	// Interval between checks for changed files (defaults to 10s)
+	Interval time.Duration
+	Logger   *logrus.Logger
+}
+
+// A Watcher calls the reload function of a target when any of its files
+// change. Reload functions should load and validate the new files before
+// swapping them in, so a failed reload keeps serving the previous ones.
+type Watcher struct {
+	interval time.Duration
+	logger   *logrus.Logger
+
+	mu      sync.Mutex
+	targets []*target
+}
+
+// target is a set of files reloaded together.
+type target struct {
+	name   string
+	paths  []string
+	reload func() error
+	// stamps of the files when last loaded and when a reload last failed
+	loaded []stamp
+	failed []stamp
+}
+
+// stamp identifies a version of a file by its modification time and size.
+type stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewWatcher returns a new Watcher.
+func NewWatcher(config *Config) *Watcher {
+	w := &Watcher{
+		interval: config.Interval,
+		logger:   config.Logger,
+	}
+	if w.interval <= 0 {
+		w.interval = DefaultInterval
+	}
+	if w.logger == nil {
+		w.logger = logrus.New()
+	}
+	return w
+}
+
+// Add watches the files of a named target, which were just loaded, and
+// calls reload when any of them change. Empty paths are ignored.
+func (w *Watcher) Add(name string, reload func() error, paths ...string) {
+	t := &target{name: name, reload: reload}
+	for _, path := range paths {
+		if path != "" {
+			t.paths = append(t.paths, path)
+		}
+	}
+	t.loaded = stamps(t.paths)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.targets = append(w.targets, t)
+}
+
+// Run checks for changed files at each interval until the stop channel is
+// closed.
+func (w *Watcher) Run(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(w.interval):
+		}
+		w.Check()
+	}
+}
+
+// Check reloads targets whose files changed since they were loaded. A target
+// which fails to reload is retried once its files change again (e.g. after
+// a certificate is written, but before its key is).
+func (w *Watcher) Check() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, t := range w.targets {
+		current := stamps(t.paths)
+		if equal(current, t.loaded) || equal(current, t.failed) {
+			continue
+		}
+		w.reload(t, current)
+	}
+}
+
+// Reload reloads every target, regardless of whether its files changed.
+func (w *Watcher) Reload() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, t := range w.targets {
+		w.reload(t, stamps(t.paths))
+	}
+}
+
+func (w *Watcher) reload(t *target, current []stamp) {
+	if err := t.reload(); err != nil {
+		reloadTotal.Inc(t.name, "error")
+		w.logger.Errorf("reload: error reloading %s, keeping the previous version: %v", t.name, err)
+		t.failed = current
+		return
+	}
+	reloadTotal.Inc(t.name, "ok")
+	w.logger.Infof("reload: reloaded %s", t.name)
+	t.loaded = current
+	t.failed = nil
+}
+
+// stamps returns the stamp of each file. Missing files have a zero stamp.
+func stamps(paths []string) []stamp {
+	stamps := make([]stamp, len(paths))
+	for i, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			stamps[i] = stamp{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return stamps
+}
+
+func equal(a, b []stamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].modTime.Equal(b[i].modTime) || a[i].size != b[i].size {
+			return false
+		}
+	}
+	return true
+}
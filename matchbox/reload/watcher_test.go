@@ -0,0 +1,63 @@
+package reload
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "matchbox-reload")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	cert, key := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	assert.Nil(t, ioutil.WriteFile(cert, []byte("cert"), 0644))
+	assert.Nil(t, ioutil.WriteFile(key, []byte("key"), 0600))
+
+	logger, _ := logtest.NewNullLogger()
+	w := NewWatcher(&Config{Logger: logger})
+	var reloads int
+	var reloadErr error
+	w.Add("tls", func() error {
+		reloads++
+		return reloadErr
+	}, cert, key, "")
+
+	// touch changes the modification time of a file
+	touch := func(path string, age time.Duration) {
+		modTime := time.Now().Add(-age)
+		assert.Nil(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	// assert that:
+	// - unchanged files are not reloaded
+	// - a change to any file reloads the target
+	// - a failed reload is not retried until the files change again
+	// - Reload reloads regardless of changes
+	w.Check()
+	assert.Equal(t, 0, reloads)
+	touch(cert, time.Hour)
+	w.Check()
+	assert.Equal(t, 1, reloads)
+	w.Check()
+	assert.Equal(t, 1, reloads)
+
+	reloadErr = errors.New("tls: private key does not match public key")
+	touch(cert, 2*time.Hour)
+	w.Check()
+	w.Check()
+	assert.Equal(t, 2, reloads)
+	reloadErr = nil
+	touch(key, time.Hour)
+	w.Check()
+	assert.Equal(t, 3, reloads)
+
+	w.Reload()
+	assert.Equal(t, 4, reloads)
+}
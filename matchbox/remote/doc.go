@@ -0,0 +1,3 @@
+// Package remote fetches and caches the Ignition configs of Profiles which
+// reference them by an https URL, such as configs produced by other systems.
+package remote
@@ -0,0 +1,191 @@
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"context"
+	"github.com/Sirupsen/logrus"
+	ignition "github.com/coreos/ignition/config"
+
+	"github.com/coreos/matchbox/matchbox/requestid"
+)
+
+// Defaults for fetching remote Ignition configs.
+const (
+	DefaultTimeout  = 10 * time.Second
+	DefaultCacheTTL = 5 * time.Minute
+	DefaultMaxSize  = 4 << 20
+)
+
+// Possible fetch errors
+var (
+	ErrNotHTTPS  = errors.New("remote: Ignition config URLs must be https")
+	ErrTooLarge  = errors.New("remote: Ignition config is too large")
+	ErrNotConfig = errors.New("remote: invalid Ignition config")
+)
+
+// Config configures a Fetcher.
+type Config struct {
+	// Timeout of each fetch
+	Timeout time.Duration
+	// CacheTTL is how long fetched configs are reused (0 uses the default)
+	CacheTTL time.Duration
+	// MaxSize is the largest config in bytes (0 uses the default)
+	MaxSize int64
+	// (optional) HTTP client
+	Client *http.Client
+	Logger *logrus.Logger
+}
+
+// entry is a cached Ignition config.
+type entry struct {
+	config  []byte
+	etag    string
+	expires time.Time
+}
+
+// Fetcher fetches, validates, and caches remote Ignition configs.
+type Fetcher struct {
+	timeout   time.Duration
+	ttl       time.Duration
+	maxSize   int64
+	client    *http.Client
+	logger    *logrus.Logger
+	mu        sync.Mutex
+	cache     map[string]entry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewFetcher returns a new Fetcher.
+func NewFetcher(config *Config) *Fetcher {
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	ttl := config.CacheTTL
+	if ttl == 0 {
+		ttl = DefaultCacheTTL
+	}
+	maxSize := config.MaxSize
+	if maxSize == 0 {
+		maxSize = DefaultMaxSize
+	}
+	client := config.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = logrus.New()
+	}
+	return &Fetcher{
+		timeout: timeout,
+		ttl:     ttl,
+		maxSize: maxSize,
+		client:  client,
+		logger:  logger,
+		cache:   make(map[string]entry),
+		now:     time.Now,
+	}
+}
+
+// Fetch returns the Ignition config at an https URL, from the cache if
+// fetched within the cache TTL. Expired configs are revalidated with their
+// ETag. If a config cannot be fetched or is invalid, its last valid config
+// is used, or an error is returned if it has none.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	f.mu.Lock()
+	cached, ok := f.cache[rawURL]
+	f.mu.Unlock()
+	if ok && f.now().Before(cached.expires) {
+		return cached.config, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+	fetched, err := f.fetch(ctx, rawURL, cached.etag)
+	if err != nil {
+		if ok {
+			f.logger.Warningf("remote: using last config of %s: %v", rawURL, err)
+			return cached.config, nil
+		}
+		return nil, fmt.Errorf("remote: error fetching %s: %v", rawURL, err)
+	}
+	if fetched == nil {
+		// not modified
+		fetched = &cached
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := f.now()
+	if now.Sub(f.lastSweep) >= f.ttl {
+		// keep expired configs for reuse if a later fetch fails, for up
+		// to ten TTLs
+		for key, e := range f.cache {
+			if now.Sub(e.expires) >= 10*f.ttl {
+				delete(f.cache, key)
+			}
+		}
+		f.lastSweep = now
+	}
+	fetched.expires = now.Add(f.ttl)
+	f.cache[rawURL] = *fetched
+	return fetched.config, nil
+}
+
+// fetch GETs and validates an Ignition config. A nil entry is returned if
+// the config matches the ETag.
+func (f *Fetcher) fetch(ctx context.Context, rawURL, etag string) (*entry, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "https" {
+		return nil, ErrNotHTTPS
+	}
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	requestid.SetHeader(ctx, req.Header)
+	req.Header.Set("Accept", "application/json")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotModified && etag != "":
+		return nil, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, f.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > f.maxSize {
+		return nil, ErrTooLarge
+	}
+	if _, rpt, err := ignition.Parse(body); err != nil {
+		if details := strings.TrimSpace(rpt.String()); details != "" {
+			return nil, fmt.Errorf("%v: %s", ErrNotConfig, details)
+		}
+		return nil, fmt.Errorf("%v: %v", ErrNotConfig, err)
+	}
+	return &entry{config: body, etag: resp.Header.Get("ETag")}, nil
+}
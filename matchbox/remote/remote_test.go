@@ -0,0 +1,112 @@
+package remote
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"context"
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `{"ignition": {"version": "2.0.0"}, "systemd": {"units": [{"name": "etcd2.service", "enable": true}]}}`
+
+func TestFetch(t *testing.T) {
+	var requests, revalidated int
+	body := testConfig
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		switch req.URL.Path {
+		case "/node.ign":
+			if req.Header.Get("If-None-Match") == `"v1"` && body == testConfig {
+				revalidated++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			w.Write([]byte(body))
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer srv.Close()
+	logger, hook := logtest.NewNullLogger()
+	f := NewFetcher(&Config{Client: srv.Client(), Logger: logger, CacheTTL: time.Minute})
+	now := time.Now()
+	f.now = func() time.Time { return now }
+
+	// assert that:
+	// - configs are fetched and validated
+	// - configs are cached within the TTL
+	config, err := f.Fetch(context.Background(), srv.URL+"/node.ign")
+	assert.Nil(t, err)
+	assert.Equal(t, testConfig, string(config))
+	_, err = f.Fetch(context.Background(), srv.URL+"/node.ign")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, requests)
+
+	// - expired configs are revalidated with their ETag
+	now = now.Add(2 * time.Minute)
+	config, err = f.Fetch(context.Background(), srv.URL+"/node.ign")
+	assert.Nil(t, err)
+	assert.Equal(t, testConfig, string(config))
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, 1, revalidated)
+	_, err = f.Fetch(context.Background(), srv.URL+"/node.ign")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, requests)
+
+	// - invalid configs fall back to the last valid config, with a warning
+	body = `{"ignition": {"version": "9.9.9"}}`
+	now = now.Add(2 * time.Minute)
+	config, err = f.Fetch(context.Background(), srv.URL+"/node.ign")
+	assert.Nil(t, err)
+	assert.Equal(t, testConfig, string(config))
+	if assert.NotNil(t, hook.LastEntry()) {
+		assert.Contains(t, hook.LastEntry().Message, "using last config")
+	}
+
+	// - configs without a last valid config are errors
+	_, err = f.Fetch(context.Background(), srv.URL+"/missing.ign")
+	assert.Error(t, err)
+}
+
+func TestFetch_Errors(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/large.ign":
+			w.Write([]byte(`{"ignition": {"version": "2.0.0"}, "padding": "` + strings.Repeat("a", 64) + `"}`))
+		case "/cloud.ign":
+			w.Write([]byte("#cloud-config\n"))
+		case "/empty.ign":
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer srv.Close()
+	logger, _ := logtest.NewNullLogger()
+	f := NewFetcher(&Config{Client: srv.Client(), Logger: logger, MaxSize: 64})
+
+	cases := []struct {
+		url string
+		err string
+	}{
+		{"http://example.com/node.ign", ErrNotHTTPS.Error()},
+		{srv.URL + "/large.ign", ErrTooLarge.Error()},
+		{srv.URL + "/cloud.ign", ErrNotConfig.Error()},
+		{srv.URL + "/empty.ign", ErrNotConfig.Error()},
+		{srv.URL + "/missing.ign", "404 Not Found"},
+	}
+	// assert that:
+	// - non-https URLs, large configs, invalid configs, and error statuses
+	// are errors
+	for _, c := range cases {
+		_, err := f.Fetch(context.Background(), c.url)
+		if assert.Error(t, err, c.url) {
+			assert.Contains(t, err.Error(), c.err, c.url)
+		}
+	}
+}
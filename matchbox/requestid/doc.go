@@ -0,0 +1,5 @@
+// Package requestid generates request IDs and propagates them through
+// contexts, HTTP headers, and gRPC metadata, so a request can be correlated
+// across matchbox's logs, responses, events, and the calls it makes to
+// other systems.
+package requestid
@@ -0,0 +1,73 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+const (
+	// Header is the HTTP header of a request ID
+	Header = "X-Request-Id"
+	// MetadataKey is the gRPC metadata key of a request ID
+	MetadataKey = "x-request-id"
+	// MaxLength is the longest request ID accepted from a client
+	MaxLength = 128
+)
+
+// unexported key type prevents collisions
+type idKey struct{}
+
+// New returns a random request ID.
+func New() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Valid returns true if a request ID propagated by a client may be used,
+// rather than generating one. IDs must be at most MaxLength letters, digits,
+// or any of "-_.:".
+func Valid(id string) bool {
+	if id == "" || len(id) > MaxLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Or returns the request ID propagated by a client if it is valid, or a new
+// request ID.
+func Or(id string) string {
+	if Valid(id) {
+		return id
+	}
+	return New()
+}
+
+// NewContext returns a copy of ctx with the request ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, idKey{}, id)
+}
+
+// FromContext returns the request ID of the ctx, or "" if there is none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(idKey{}).(string)
+	return id
+}
+
+// SetHeader sets the request ID of the ctx, if any, as the Header of an
+// outgoing request.
+func SetHeader(ctx context.Context, h http.Header) {
+	if id := FromContext(ctx); id != "" {
+		h.Set(Header, id)
+	}
+}
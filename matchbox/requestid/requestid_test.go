@@ -0,0 +1,52 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	// assert that request IDs are valid, random, and 16 hex characters
+	id := New()
+	assert.Len(t, id, 16)
+	assert.True(t, Valid(id))
+	assert.NotEqual(t, id, New())
+}
+
+func TestValid(t *testing.T) {
+	cases := []struct {
+		id    string
+		valid bool
+	}{
+		{"1a2b3c4d5e6f7a8b", true},
+		{"lb-7f3a.trace_1:2", true},
+		{"", false},
+		{"with space", false},
+		{"newline\n", false},
+		{"héllo", false},
+		{string(make([]byte, MaxLength+1)), false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.valid, Valid(c.id), c.id)
+	}
+	// assert that invalid propagated IDs are replaced
+	assert.Equal(t, "lb-7f3a", Or("lb-7f3a"))
+	assert.Len(t, Or("with space"), 16)
+}
+
+func TestContext(t *testing.T) {
+	h := http.Header{}
+	// assert that:
+	// - contexts without a request ID have none and don't set headers
+	// - request IDs are propagated as a header
+	assert.Equal(t, "", FromContext(context.Background()))
+	SetHeader(context.Background(), h)
+	assert.Empty(t, h)
+	ctx := NewContext(context.Background(), "1a2b3c4d")
+	assert.Equal(t, "1a2b3c4d", FromContext(ctx))
+	SetHeader(ctx, h)
+	assert.Equal(t, "1a2b3c4d", h.Get(Header))
+}
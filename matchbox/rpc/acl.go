@@ -0,0 +1,50 @@
+package rpc
+
+import (
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+
+	"github.com/coreos/matchbox/matchbox/acl"
+	"github.com/coreos/matchbox/matchbox/socket"
+)
+
+var errAddrNotAllowed = grpcErrorf(codes.PermissionDenied, "matchbox: client address is not allowed")
+
+// Allowlist returns an Interceptor which rejects calls from clients whose
+// address is not in the allowlist. An empty allowlist allows all clients.
+func Allowlist(list acl.List) Interceptor {
+	if len(list) == 0 {
+		return Interceptor{}
+	}
+	return Interceptor{
+		Unary:  allowlistUnary(list),
+		Stream: allowlistStream(list),
+	}
+}
+
+// peerAllowed returns true if the list allows the peer of the ctx. Local
+// peers on unix sockets, which file permissions restrict, are allowed.
+func peerAllowed(ctx context.Context, list acl.List) bool {
+	p, ok := peer.FromContext(ctx)
+	return ok && (socket.IsUnix(p.Addr) || list.AllowsAddr(p.Addr.String()))
+}
+
+func allowlistUnary(list acl.List) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !peerAllowed(ctx, list) {
+			return nil, errAddrNotAllowed
+		}
+		return handler(ctx, req)
+	}
+}
+
+func allowlistStream(list acl.List) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !peerAllowed(ss.Context(), list) {
+			return errAddrNotAllowed
+		}
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,44 @@
+package rpc
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/peer"
+
+	"github.com/coreos/matchbox/matchbox/acl"
+)
+
+func TestAllowlistUnary(t *testing.T) {
+	list, err := acl.Parse("10.0.0.0/8")
+	assert.Nil(t, err)
+	interceptor := allowlistUnary(list)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+	withPeer := func(ip string) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{
+			Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 8081},
+		})
+	}
+	// assert that:
+	// - calls from allowed networks are handled
+	// - calls from other networks or without a peer are denied
+	resp, err := interceptor(withPeer("10.1.2.3"), nil, nil, handler)
+	assert.Nil(t, err)
+	assert.Equal(t, "ok", resp)
+	_, err = interceptor(withPeer("192.168.1.1"), nil, nil, handler)
+	assert.Equal(t, errAddrNotAllowed, err)
+	_, err = interceptor(context.Background(), nil, nil, handler)
+	assert.Equal(t, errAddrNotAllowed, err)
+	// - calls from unix socket peers are handled
+	unix := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.UnixAddr{Net: "unix"}})
+	_, err = interceptor(unix, nil, nil, handler)
+	assert.Nil(t, err)
+
+	assert.Nil(t, Allowlist(nil).Unary)
+	assert.NotNil(t, Allowlist(list).Unary)
+	assert.NotNil(t, Allowlist(list).Stream)
+}
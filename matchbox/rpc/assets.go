@@ -0,0 +1,84 @@
+package rpc
+
+import (
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	"github.com/coreos/matchbox/matchbox/assets"
+	"github.com/coreos/matchbox/matchbox/rpc/rpcpb"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// RegisterAssets registers a gRPC AssetsServer which manages the assets
+// directory through the given Store.
+func RegisterAssets(s *grpc.Server, store *assets.Store) {
+	rpcpb.RegisterAssetsServer(s, &assetsServer{store: store})
+}
+
+// assetsServer takes an assets Store and implements a gRPC AssetsServer.
+type assetsServer struct {
+	store *assets.Store
+}
+
+func (s *assetsServer) AssetPut(stream rpcpb.Assets_AssetPutServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	r := &chunkReader{stream: stream, chunk: first.Chunk}
+	size, err := s.store.Put(first.Path, first.Checksum, r)
+	if err != nil {
+		return grpcError(err)
+	}
+	return stream.SendAndClose(&pb.AssetPutResponse{Size: size})
+}
+
+func (s *assetsServer) AssetFetch(ctx context.Context, req *pb.AssetFetchRequest) (*pb.AssetFetchResponse, error) {
+	if req.Asset == nil {
+		return nil, grpcError(storagepb.ErrAssetPathRequired)
+	}
+	if err := s.store.Fetch(req.Asset); err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.AssetFetchResponse{}, nil
+}
+
+func (s *assetsServer) AssetGet(ctx context.Context, req *pb.AssetGetRequest) (*pb.AssetGetResponse, error) {
+	algorithm := req.Algorithm
+	if algorithm == "" {
+		algorithm = "sha256"
+	}
+	size, checksum, err := s.store.Stat(req.Path, algorithm)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.AssetGetResponse{Path: req.Path, Size: size, Checksum: checksum}, nil
+}
+
+func (s *assetsServer) AssetDelete(ctx context.Context, req *pb.AssetDeleteRequest) (*pb.AssetDeleteResponse, error) {
+	if err := s.store.Delete(req.Path); err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.AssetDeleteResponse{}, nil
+}
+
+// chunkReader reads the chunks of an AssetPut stream as an io.Reader.
+type chunkReader struct {
+	stream rpcpb.Assets_AssetPutServer
+	chunk  []byte
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.chunk) == 0 {
+		req, err := r.stream.Recv()
+		if err != nil {
+			// io.EOF once the client closes the stream
+			return 0, err
+		}
+		r.chunk = req.Chunk
+	}
+	n := copy(p, r.chunk)
+	r.chunk = r.chunk[n:]
+	return n, nil
+}
@@ -0,0 +1,81 @@
+package rpc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/coreos/matchbox/matchbox/assets"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// fakeAssetPutStream replays AssetPut requests and records the response.
+type fakeAssetPutStream struct {
+	grpc.ServerStream
+	reqs []*pb.AssetPutRequest
+	resp *pb.AssetPutResponse
+}
+
+func (s *fakeAssetPutStream) Recv() (*pb.AssetPutRequest, error) {
+	if len(s.reqs) == 0 {
+		return nil, io.EOF
+	}
+	req := s.reqs[0]
+	s.reqs = s.reqs[1:]
+	return req, nil
+}
+
+func (s *fakeAssetPutStream) SendAndClose(resp *pb.AssetPutResponse) error {
+	s.resp = resp
+	return nil
+}
+
+func TestAssets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "matchbox")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	srv := &assetsServer{store: assets.NewStore(&assets.Config{Root: dir})}
+	ctx := context.Background()
+	sum := sha256.Sum256([]byte("kernel image"))
+	checksum := "sha256:" + hex.EncodeToString(sum[:])
+
+	// assert that:
+	// - uploaded chunks are written to the asset path
+	// - the asset size and checksum are reported
+	// - uploads which do not match their checksum are rejected
+	// - deleted assets are not found
+	stream := &fakeAssetPutStream{reqs: []*pb.AssetPutRequest{
+		{Path: "coreos/vmlinuz", Checksum: checksum, Chunk: []byte("kernel")},
+		{Chunk: []byte(" image")},
+	}}
+	assert.Nil(t, srv.AssetPut(stream))
+	assert.Equal(t, int64(12), stream.resp.Size)
+	data, err := ioutil.ReadFile(filepath.Join(dir, "coreos", "vmlinuz"))
+	assert.Nil(t, err)
+	assert.Equal(t, "kernel image", string(data))
+
+	resp, err := srv.AssetGet(ctx, &pb.AssetGetRequest{Path: "coreos/vmlinuz"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(12), resp.Size)
+	assert.Equal(t, checksum, resp.Checksum)
+
+	stream = &fakeAssetPutStream{reqs: []*pb.AssetPutRequest{
+		{Path: "coreos/initrd", Checksum: checksum, Chunk: []byte("tampered")},
+	}}
+	err = srv.AssetPut(stream)
+	assert.Equal(t, codes.InvalidArgument, grpc.Code(err))
+
+	_, err = srv.AssetDelete(ctx, &pb.AssetDeleteRequest{Path: "coreos/vmlinuz"})
+	assert.Nil(t, err)
+	_, err = srv.AssetGet(ctx, &pb.AssetGetRequest{Path: "coreos/vmlinuz"})
+	assert.Equal(t, codes.NotFound, grpc.Code(err))
+}
@@ -0,0 +1,64 @@
+package rpc
+
+import (
+	"net"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+
+	"github.com/coreos/matchbox/matchbox/audit"
+	"github.com/coreos/matchbox/matchbox/requestid"
+)
+
+// Audit returns an Interceptor which records unary calls to the Auditor.
+// A nil Auditor records nothing.
+func Audit(auditor *audit.Auditor) Interceptor {
+	if auditor == nil {
+		return Interceptor{}
+	}
+	return Interceptor{Unary: auditUnary(auditor)}
+}
+
+func auditUnary(auditor *audit.Auditor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		var fields map[string]string
+		if id := requestid.FromContext(ctx); id != "" {
+			fields = map[string]string{"request_id": id}
+		}
+		auditor.Record(&audit.Entry{
+			Type:   audit.TypeAPI,
+			Action: info.FullMethod,
+			Actor:  peerIdentity(ctx),
+			Result: grpc.Code(err).String(),
+			Fields: fields,
+		})
+		return resp, err
+	}
+}
+
+// peerIdentity returns the authenticated user of the call, the common name
+// of the peer's verified client certificate, or the peer's IP address.
+func peerIdentity(ctx context.Context) string {
+	if identity := identityFromContext(ctx); identity != "" {
+		return identity
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+		if chains := tlsInfo.State.VerifiedChains; len(chains) > 0 && len(chains[0]) > 0 {
+			if cn := chains[0][0].Subject.CommonName; cn != "" {
+				return cn
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
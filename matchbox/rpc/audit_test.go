@@ -0,0 +1,78 @@
+package rpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+
+	"github.com/coreos/matchbox/matchbox/audit"
+	"github.com/coreos/matchbox/matchbox/requestid"
+)
+
+func TestPeerIdentity(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.ParseIP("10.1.2.3"), Port: 51234}
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "terraform"}}
+	withTLS := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: addr,
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{cert}},
+		}},
+	})
+	// assert that:
+	// - verified client certificate names identify peers
+	// - otherwise, peers are identified by IP
+	assert.Equal(t, "terraform", peerIdentity(withTLS))
+	assert.Equal(t, "10.1.2.3", peerIdentity(peer.NewContext(context.Background(), &peer.Peer{Addr: addr})))
+	assert.Equal(t, "", peerIdentity(context.Background()))
+}
+
+// entrySink is an audit.Sink which sends entries to a channel.
+type entrySink chan *audit.Entry
+
+func (s entrySink) Write(entry *audit.Entry) error {
+	s <- entry
+	return nil
+}
+
+func (s entrySink) Close() error {
+	return nil
+}
+
+func TestAuditUnary(t *testing.T) {
+	sink := make(entrySink, 1)
+	auditor := audit.NewAuditor(&audit.Config{Sinks: []audit.Sink{sink}})
+	defer auditor.Close()
+	interceptor := Audit(auditor).Unary
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errNoMatchingGroup
+	}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("10.1.2.3"), Port: 51234},
+	})
+	info := &grpc.UnaryServerInfo{FullMethod: "/rpcpb.Select/SelectGroup"}
+	_, err := interceptor(ctx, nil, info, handler)
+	// assert that:
+	// - calls are recorded with the method, caller, and result code
+	assert.Equal(t, errNoMatchingGroup, err)
+	entry := <-sink
+	assert.Equal(t, audit.TypeAPI, entry.Type)
+	assert.Equal(t, "/rpcpb.Select/SelectGroup", entry.Action)
+	assert.Equal(t, "10.1.2.3", entry.Actor)
+	assert.Equal(t, "NotFound", entry.Result)
+	assert.Nil(t, entry.Fields)
+	assert.Nil(t, Audit(nil).Unary)
+
+	// assert that the request ID of the call is recorded
+	_, err = interceptor(requestid.NewContext(ctx, "terraform-1234"), nil, info, handler)
+	assert.Equal(t, errNoMatchingGroup, err)
+	entry = <-sink
+	assert.Equal(t, map[string]string{"request_id": "terraform-1234"}, entry.Fields)
+}
@@ -0,0 +1,100 @@
+package rpc
+
+import (
+	stdcontext "context"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/coreos/matchbox/matchbox/oidc"
+)
+
+var (
+	errTokenRequired = grpcErrorf(codes.Unauthenticated, "matchbox: an ID token is required")
+	errInvalidToken  = grpcErrorf(codes.Unauthenticated, "matchbox: invalid ID token")
+	errRoleRequired  = grpcErrorf(codes.PermissionDenied, "matchbox: role does not permit this call")
+)
+
+// TokenVerifier verifies bearer ID tokens.
+type TokenVerifier interface {
+	Verify(ctx stdcontext.Context, token string) (*oidc.Identity, error)
+}
+
+// Authenticate returns an Interceptor which requires calls to present an ID
+// token ("authorization: Bearer TOKEN" metadata) whose user's groups are
+// granted a role permitting the method. Viewers may call read methods and
+// editors may call any method. A nil verifier authenticates nothing.
+func Authenticate(verifier TokenVerifier, roles oidc.RoleMap) Interceptor {
+	if verifier == nil {
+		return Interceptor{}
+	}
+	return Interceptor{
+		Unary: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			identity, err := authorize(ctx, verifier, roles, info.FullMethod)
+			if err != nil {
+				return nil, err
+			}
+			return handler(withIdentity(ctx, identity.Subject), req)
+		},
+		Stream: func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if _, err := authorize(ss.Context(), verifier, roles, info.FullMethod); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		},
+	}
+}
+
+// authorize verifies the ID token of a call and returns its user if their
+// role permits the method.
+func authorize(ctx context.Context, verifier TokenVerifier, roles oidc.RoleMap, method string) (*oidc.Identity, error) {
+	md, _ := metadata.FromContext(ctx)
+	var token string
+	for _, value := range md["authorization"] {
+		if strings.HasPrefix(value, "Bearer ") {
+			token = strings.TrimPrefix(value, "Bearer ")
+		}
+	}
+	if token == "" {
+		return nil, errTokenRequired
+	}
+	identity, err := verifier.Verify(ctx, token)
+	if err != nil {
+		return nil, errInvalidToken
+	}
+	if roles.Role(identity.Groups) < requiredRole(method) {
+		return nil, errRoleRequired
+	}
+	return identity, nil
+}
+
+// requiredRole returns the role needed to call a method. Methods which only
+// read (Get, List, Describe, Select, Render, RenderTemplate, and Watch
+// methods) require viewers.
+func requiredRole(method string) oidc.Role {
+	name := method[strings.LastIndex(method, "/")+1:]
+	if strings.HasSuffix(name, "Get") || strings.HasSuffix(name, "List") || strings.HasSuffix(name, "Describe") ||
+		strings.HasPrefix(name, "Select") || strings.HasPrefix(name, "Render") || name == "Watch" {
+		return oidc.RoleViewer
+	}
+	return oidc.RoleEditor
+}
+
+// unexported key prevents collisions
+type key int
+
+const identityKey key = iota
+
+// withIdentity returns a copy of ctx that stores the caller's identity.
+func withIdentity(ctx context.Context, identity string) context.Context {
+	return context.WithValue(ctx, identityKey, identity)
+}
+
+// identityFromContext returns the caller's identity from the ctx, or "".
+func identityFromContext(ctx context.Context) string {
+	identity, _ := ctx.Value(identityKey).(string)
+	return identity
+}
@@ -0,0 +1,82 @@
+package rpc
+
+import (
+	stdcontext "context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/coreos/matchbox/matchbox/oidc"
+)
+
+// fakeVerifier returns fixed identities by token.
+type fakeVerifier map[string]*oidc.Identity
+
+func (f fakeVerifier) Verify(ctx stdcontext.Context, token string) (*oidc.Identity, error) {
+	if identity, ok := f[token]; ok {
+		return identity, nil
+	}
+	return nil, errors.New("invalid")
+}
+
+func TestAuthenticateUnary(t *testing.T) {
+	verifier := fakeVerifier{
+		"viewer-token": {Subject: "jane@example.com", Groups: []string{"eng"}},
+		"editor-token": {Subject: "joe@example.com", Groups: []string{"eng", "sre"}},
+		"other-token":  {Subject: "ann@example.com", Groups: []string{"sales"}},
+	}
+	roles := oidc.RoleMap{"eng": oidc.RoleViewer, "sre": oidc.RoleEditor}
+	interceptor := Authenticate(verifier, roles).Unary
+	var actor string
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		actor = peerIdentity(ctx)
+		return "ok", nil
+	}
+	withToken := func(token string) context.Context {
+		return metadata.NewContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+	}
+	get := &grpc.UnaryServerInfo{FullMethod: "/rpcpb.Groups/GroupGet"}
+	put := &grpc.UnaryServerInfo{FullMethod: "/rpcpb.Groups/GroupPut"}
+
+	cases := []struct {
+		ctx  context.Context
+		info *grpc.UnaryServerInfo
+		err  error
+	}{
+		{context.Background(), get, errTokenRequired},
+		{withToken("forged"), get, errInvalidToken},
+		{withToken("other-token"), get, errRoleRequired},
+		{withToken("viewer-token"), get, nil},
+		{withToken("viewer-token"), put, errRoleRequired},
+		{withToken("editor-token"), put, nil},
+	}
+	// assert that:
+	// - calls without a valid token are unauthenticated
+	// - viewers may only read and editors may also write
+	// - the authenticated user is the audited actor
+	for _, tc := range cases {
+		actor = ""
+		resp, err := interceptor(tc.ctx, nil, tc.info, handler)
+		assert.Equal(t, tc.err, err)
+		if tc.err == nil {
+			assert.Equal(t, "ok", resp)
+			assert.Contains(t, actor, "@example.com")
+		}
+	}
+	assert.Nil(t, Authenticate(nil, roles).Unary)
+}
+
+func TestRequiredRole(t *testing.T) {
+	viewer := []string{"/rpcpb.Profiles/ProfileList", "/rpcpb.Profiles/ProfileVersionList", "/rpcpb.Select/SelectGroup", "/rpcpb.Render/Render", "/rpcpb.Render/RenderTemplate", "/rpcpb.Events/Watch", "/rpcpb.Assets/AssetGet", "/rpcpb.Machines/MachineDescribe"}
+	editor := []string{"/rpcpb.Ignition/IgnitionPut", "/rpcpb.Machines/MachineClaim", "/rpcpb.Power/Power", "/rpcpb.Tokens/TokenCreate", "/rpcpb.Assets/AssetFetch", "/rpcpb.Profiles/ProfileRollback", "/rpcpb.Machines/MachineAdopt"}
+	for _, method := range viewer {
+		assert.Equal(t, oidc.RoleViewer, requiredRole(method), method)
+	}
+	for _, method := range editor {
+		assert.Equal(t, oidc.RoleEditor, requiredRole(method), method)
+	}
+}
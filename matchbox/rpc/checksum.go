@@ -0,0 +1,25 @@
+package rpc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// checksum returns the "sha256:hex" checksum of data, in the same format as
+// Asset checksums. Clients compare checksums to detect resources which were
+// changed outside of the API (e.g. edited on disk).
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// objectChecksum returns the checksum of the JSON encoding of a Group or
+// Profile, which is independent of how the stored file is formatted.
+func objectChecksum(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return checksum(data), nil
+}
@@ -0,0 +1,41 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestGetChecksums(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Profiles["etcd"] = &storagepb.Profile{Id: "etcd", IgnitionId: "etcd.yaml"}
+	store.IgnitionConfigs["etcd.yaml"] = "ignition_version: 1"
+	core := server.NewServer(&server.Config{Store: store})
+	profiles := newProfileServer(core)
+	ignition := newIgnitionServer(core)
+	ctx := context.Background()
+
+	// assert that:
+	// - templates are checksummed by their contents
+	// - profile checksums change when the stored profile changes
+	// - checksums are stable across reads
+	iresp, err := ignition.IgnitionGet(ctx, &pb.IgnitionGetRequest{Name: "etcd.yaml"})
+	assert.Nil(t, err)
+	assert.Equal(t, "sha256:9d734896dbd2c9005decd3c13535f4e162e3a5f08ae95990267f1aa8ef4f1432", iresp.Checksum)
+
+	before, err := profiles.ProfileGet(ctx, &pb.ProfileGetRequest{Id: "etcd"})
+	assert.Nil(t, err)
+	again, err := profiles.ProfileGet(ctx, &pb.ProfileGetRequest{Id: "etcd"})
+	assert.Nil(t, err)
+	assert.Equal(t, before.Checksum, again.Checksum)
+	store.Profiles["etcd"] = &storagepb.Profile{Id: "etcd", IgnitionId: "etcd-edited.yaml"}
+	after, err := profiles.ProfileGet(ctx, &pb.ProfileGetRequest{Id: "etcd"})
+	assert.Nil(t, err)
+	assert.NotEqual(t, before.Checksum, after.Checksum)
+}
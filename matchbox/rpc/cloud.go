@@ -0,0 +1,49 @@
+package rpc
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/coreos/matchbox/matchbox/rpc/rpcpb"
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// cloudServer takes a matchbox Server and implements a gRPC CloudServer.
+type cloudServer struct {
+	srv server.Server
+}
+
+func newCloudServer(s server.Server) rpcpb.CloudServer {
+	return &cloudServer{
+		srv: s,
+	}
+}
+
+func (s *cloudServer) CloudPut(ctx context.Context, req *pb.CloudPutRequest) (*pb.CloudPutResponse, error) {
+	_, err := s.srv.CloudPut(ctx, req)
+	return &pb.CloudPutResponse{}, grpcError(err)
+}
+
+func (s *cloudServer) CloudGet(ctx context.Context, req *pb.CloudGetRequest) (*pb.CloudGetResponse, error) {
+	contents, err := s.srv.CloudGet(ctx, req.Name)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.CloudGetResponse{Config: []byte(contents), Checksum: checksum([]byte(contents))}, nil
+}
+
+func (s *cloudServer) CloudList(ctx context.Context, req *pb.CloudListRequest) (*pb.CloudListResponse, error) {
+	names, err := s.srv.CloudList(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.CloudListResponse{Names: names}, nil
+}
+
+func (s *cloudServer) CloudDelete(ctx context.Context, req *pb.CloudDeleteRequest) (*pb.CloudDeleteResponse, error) {
+	err := s.srv.CloudDelete(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.CloudDeleteResponse{}, nil
+}
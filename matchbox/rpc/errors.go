@@ -1,10 +1,17 @@
 package rpc
 
 import (
+	"os"
+
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 
+	"github.com/coreos/matchbox/matchbox/assets"
+	"github.com/coreos/matchbox/matchbox/power"
+	"github.com/coreos/matchbox/matchbox/selectors"
 	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
 )
 
 var (
@@ -12,6 +19,9 @@ var (
 	grpcErrorf           = grpc.Errorf
 	errNoMatchingGroup   = grpcErrorf(codes.NotFound, "matchbox: No matching Group")
 	errNoMatchingProfile = grpcErrorf(codes.NotFound, "matchbox: No matching Profile")
+	errTokenLabels       = grpcErrorf(codes.InvalidArgument, server.ErrTokenLabelsRequired.Error())
+	errPowerDisabled     = grpcErrorf(codes.FailedPrecondition, "matchbox: power control is not enabled")
+	errTemplateInUse     = grpcErrorf(codes.FailedPrecondition, server.ErrTemplateInUse.Error())
 )
 
 // grpcError transforms an error into a gRPC errors with canonical error codes.
@@ -24,6 +34,36 @@ func grpcError(err error) error {
 		return errNoMatchingGroup
 	case server.ErrNoMatchingProfile:
 		return errNoMatchingProfile
+	case server.ErrTokenLabelsRequired:
+		return errTokenLabels
+	case server.ErrTemplateInUse:
+		return errTemplateInUse
+	case server.ErrOwnerRequired, server.ErrProfileRequired, server.ErrGroupRequired, server.ErrInvalidMaintenance:
+		return grpcErrorf(codes.InvalidArgument, err.Error())
+	case server.ErrInvalidEventRange, server.ErrInvalidEventLimit, storagepb.ErrInvalidEventType:
+		return grpcErrorf(codes.InvalidArgument, err.Error())
+	case server.ErrNoMachineCapacity:
+		return grpcErrorf(codes.ResourceExhausted, err.Error())
+	case server.ErrMachineClaimed, server.ErrMachineNotPending, server.ErrNoEarlierVersion:
+		return grpcErrorf(codes.FailedPrecondition, err.Error())
+	case storage.ErrMachineNotFound, storage.ErrVersionNotFound:
+		return grpcErrorf(codes.NotFound, err.Error())
+	case power.ErrBMCRequired:
+		return grpcErrorf(codes.FailedPrecondition, err.Error())
+	case power.ErrUnknownAction:
+		return grpcErrorf(codes.InvalidArgument, err.Error())
+	case assets.ErrChecksumMismatch, storagepb.ErrAssetPathRequired, storagepb.ErrAssetURLRequired, storagepb.ErrInvalidChecksum:
+		return grpcErrorf(codes.InvalidArgument, err.Error())
+	}
+	if _, ok := err.(*storagepb.MetadataError); ok {
+		return grpcErrorf(codes.InvalidArgument, err.Error())
+	}
+	if _, ok := err.(*selectors.Error); ok {
+		return grpcErrorf(codes.InvalidArgument, err.Error())
+	}
+	switch {
+	case os.IsNotExist(err):
+		return grpcErrorf(codes.NotFound, err.Error())
 	default:
 		return grpcErrorf(codes.Unknown, err.Error())
 	}
@@ -2,12 +2,18 @@ package rpc
 
 import (
 	"errors"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc/codes"
 
+	"github.com/coreos/matchbox/matchbox/assets"
+	"github.com/coreos/matchbox/matchbox/power"
+	"github.com/coreos/matchbox/matchbox/selectors"
 	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/storage"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
 )
 
 func TestGRPCError(t *testing.T) {
@@ -18,6 +24,18 @@ func TestGRPCError(t *testing.T) {
 		{nil, nil},
 		{server.ErrNoMatchingGroup, errNoMatchingGroup},
 		{server.ErrNoMatchingProfile, errNoMatchingProfile},
+		{server.ErrTokenLabelsRequired, errTokenLabels},
+		{server.ErrTemplateInUse, errTemplateInUse},
+		{server.ErrNoMachineCapacity, grpcErrorf(codes.ResourceExhausted, server.ErrNoMachineCapacity.Error())},
+		{server.ErrMachineClaimed, grpcErrorf(codes.FailedPrecondition, server.ErrMachineClaimed.Error())},
+		{server.ErrMachineNotPending, grpcErrorf(codes.FailedPrecondition, server.ErrMachineNotPending.Error())},
+		{storage.ErrMachineNotFound, grpcErrorf(codes.NotFound, storage.ErrMachineNotFound.Error())},
+		{power.ErrBMCRequired, grpcErrorf(codes.FailedPrecondition, power.ErrBMCRequired.Error())},
+		{power.ErrUnknownAction, grpcErrorf(codes.InvalidArgument, power.ErrUnknownAction.Error())},
+		{assets.ErrChecksumMismatch, grpcErrorf(codes.InvalidArgument, assets.ErrChecksumMismatch.Error())},
+		{&storagepb.MetadataError{Group: "node1", Profile: "etcd", Err: errors.New("missing etcd_name")}, grpcErrorf(codes.InvalidArgument, `group "node1" metadata does not match profile "etcd" metadata schema: missing etcd_name`)},
+		{&selectors.Error{Group: "node1", Key: "maC", Err: selectors.ErrUndeclaredKey, Similar: "mac"}, grpcErrorf(codes.InvalidArgument, `group "node1" selector key "maC" is not declared (did you mean "mac"?)`)},
+		{&os.PathError{Op: "open", Path: "groups/a.json", Err: os.ErrNotExist}, grpcErrorf(codes.NotFound, "open groups/a.json: file does not exist")},
 		{errors.New("other error"), grpcErrorf(codes.Unknown, "other error")},
 	}
 	for _, c := range cases {
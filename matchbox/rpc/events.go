@@ -0,0 +1,45 @@
+package rpc
+
+import (
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	"github.com/coreos/matchbox/matchbox/events"
+	"github.com/coreos/matchbox/matchbox/rpc/rpcpb"
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// RegisterEvents registers a gRPC EventsServer which streams boot events
+// published to the Hub and lists the events recorded by the Server.
+func RegisterEvents(s *grpc.Server, srv server.Server, hub *events.Hub) {
+	rpcpb.RegisterEventsServer(s, &eventsServer{srv: srv, hub: hub})
+}
+
+// eventsServer takes a matchbox Server and an events Hub and implements a
+// gRPC EventsServer.
+type eventsServer struct {
+	srv server.Server
+	hub *events.Hub
+}
+
+func (s *eventsServer) Watch(req *pb.BootEventsRequest, stream rpcpb.Events_WatchServer) error {
+	sub := s.hub.Subscribe(0)
+	defer sub.Close()
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-sub.C:
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *eventsServer) EventList(ctx context.Context, req *pb.EventListRequest) (*pb.EventListResponse, error) {
+	events, err := s.srv.EventList(ctx, req)
+	return &pb.EventListResponse{Events: events}, grpcError(err)
+}
@@ -0,0 +1,74 @@
+package rpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/coreos/matchbox/matchbox/events"
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+// watchStream is an Events_WatchServer which sends events to a channel.
+type watchStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	events chan *pb.BootEvent
+}
+
+func (s *watchStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *watchStream) Send(event *pb.BootEvent) error {
+	s.events <- event
+	return nil
+}
+
+func TestWatch(t *testing.T) {
+	hub := events.NewHub()
+	srv := &eventsServer{hub: hub}
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &watchStream{ctx: ctx, events: make(chan *pb.BootEvent)}
+	done := make(chan error)
+	go func() {
+		done <- srv.Watch(&pb.BootEventsRequest{}, stream)
+	}()
+	for hub.Subscribers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	event := &pb.BootEvent{Endpoint: "/ipxe", MachineId: "a1b2c3d4"}
+	hub.Publish(event)
+	// assert that:
+	// - published events are streamed to the client
+	// - Watch returns and unsubscribes when the client goes away
+	assert.Equal(t, event, <-stream.events)
+	cancel()
+	assert.Nil(t, <-done)
+	assert.Equal(t, 0, hub.Subscribers())
+}
+
+func TestEventList(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Events = []*storagepb.Event{
+		{Time: "2026-10-13T21:00:00Z", Type: storagepb.EventBoot, MachineId: "a1b2c3d4"},
+		{Time: "2026-10-13T21:02:00Z", Type: storagepb.EventBoot, MachineId: "b2c3d4e5"},
+	}
+	srv := &eventsServer{srv: server.NewServer(&server.Config{Store: store})}
+	// assert that:
+	// - recorded events are listed with the request's filters
+	// - invalid requests are InvalidArgument errors
+	resp, err := srv.EventList(context.Background(), &pb.EventListRequest{MachineId: "b2c3d4e5"})
+	assert.Nil(t, err)
+	assert.Equal(t, store.Events[1:], resp.Events)
+	_, err = srv.EventList(context.Background(), &pb.EventListRequest{Type: "reboot"})
+	assert.Equal(t, codes.InvalidArgument, grpc.Code(err))
+}
@@ -0,0 +1,49 @@
+package rpc
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/coreos/matchbox/matchbox/rpc/rpcpb"
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// genericServer takes a matchbox Server and implements a gRPC GenericServer.
+type genericServer struct {
+	srv server.Server
+}
+
+func newGenericServer(s server.Server) rpcpb.GenericServer {
+	return &genericServer{
+		srv: s,
+	}
+}
+
+func (s *genericServer) GenericPut(ctx context.Context, req *pb.GenericPutRequest) (*pb.GenericPutResponse, error) {
+	_, err := s.srv.GenericPut(ctx, req)
+	return &pb.GenericPutResponse{}, grpcError(err)
+}
+
+func (s *genericServer) GenericGet(ctx context.Context, req *pb.GenericGetRequest) (*pb.GenericGetResponse, error) {
+	contents, err := s.srv.GenericGet(ctx, req.Name)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.GenericGetResponse{Config: []byte(contents), Checksum: checksum([]byte(contents))}, nil
+}
+
+func (s *genericServer) GenericList(ctx context.Context, req *pb.GenericListRequest) (*pb.GenericListResponse, error) {
+	names, err := s.srv.GenericList(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.GenericListResponse{Names: names}, nil
+}
+
+func (s *genericServer) GenericDelete(ctx context.Context, req *pb.GenericDeleteRequest) (*pb.GenericDeleteResponse, error) {
+	err := s.srv.GenericDelete(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.GenericDeleteResponse{}, nil
+}
@@ -3,6 +3,7 @@ package rpc
 import (
 	"golang.org/x/net/context"
 
+	"github.com/coreos/matchbox/matchbox/deprecation"
 	"github.com/coreos/matchbox/matchbox/rpc/rpcpb"
 	"github.com/coreos/matchbox/matchbox/server"
 	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
@@ -21,12 +22,19 @@ func newGroupServer(s server.Server) rpcpb.GroupsServer {
 
 func (s *groupServer) GroupPut(ctx context.Context, req *pb.GroupPutRequest) (*pb.GroupPutResponse, error) {
 	_, err := s.srv.GroupPut(ctx, req)
-	return &pb.GroupPutResponse{}, grpcError(err)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.GroupPutResponse{Warnings: deprecation.Strings(deprecation.Group(req.Group))}, nil
 }
 
 func (s *groupServer) GroupGet(ctx context.Context, req *pb.GroupGetRequest) (*pb.GroupGetResponse, error) {
 	group, err := s.srv.GroupGet(ctx, req)
-	return &pb.GroupGetResponse{Group: group}, grpcError(err)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	sum, err := objectChecksum(group)
+	return &pb.GroupGetResponse{Group: group, Checksum: sum}, grpcError(err)
 }
 
 func (s *groupServer) GroupList(ctx context.Context, req *pb.GroupListRequest) (*pb.GroupListResponse, error) {
@@ -1,27 +1,90 @@
 package rpc
 
 import (
+	"context"
 	"crypto/tls"
+	"net"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 
 	"github.com/coreos/matchbox/matchbox/rpc/rpcpb"
 	"github.com/coreos/matchbox/matchbox/server"
+	"github.com/coreos/matchbox/matchbox/socket"
 )
 
-// NewServer wraps the matchbox Server to return a new gRPC Server.
-func NewServer(s server.Server, tls *tls.Config) *grpc.Server {
-	var opts []grpc.ServerOption
-	if tls != nil {
+// DefaultMaxMessageSize is the default limit of messages received by the
+// gRPC server, large enough for templates and bulk requests.
+const DefaultMaxMessageSize = 64 << 20
+
+// KeepAlivePeriod is the TCP keepalive period of gRPC connections, so idle
+// connections through NATs and load balancers aren't silently dropped and
+// dead peers are detected.
+const KeepAlivePeriod = 30 * time.Second
+
+// Config configures a gRPC Server.
+type Config struct {
+	// (optional) TLS credentials of server connections
+	TLS *tls.Config
+	// compress responses with gzip (clients must accept gzip)
+	Compress bool
+	// limit of received messages in bytes (default DefaultMaxMessageSize)
+	MaxMessageSize int
+}
+
+// NewServer wraps the matchbox Server to return a new gRPC Server. The
+// Interceptors (e.g. Allowlist, Tracing) run in order on each call.
+// gzip compressed requests are always accepted.
+func NewServer(s server.Server, config *Config, interceptors ...Interceptor) *grpc.Server {
+	maxMessageSize := config.MaxMessageSize
+	if maxMessageSize <= 0 {
+		maxMessageSize = DefaultMaxMessageSize
+	}
+	opts := []grpc.ServerOption{
+		grpc.MaxMsgSize(maxMessageSize),
+		grpc.RPCDecompressor(grpc.NewGZIPDecompressor()),
+	}
+	if config.TLS != nil {
 		// Add TLS Credentials as a ServerOption for server connections.
-		opts = append(opts, grpc.Creds(credentials.NewTLS(tls)))
+		opts = append(opts, grpc.Creds(credentials.NewTLS(config.TLS)))
+	}
+	if config.Compress {
+		opts = append(opts, grpc.RPCCompressor(grpc.NewGZIPCompressor()))
 	}
 
+	opts = append(opts, chainInterceptors(interceptors)...)
 	grpcServer := grpc.NewServer(opts...)
 	rpcpb.RegisterGroupsServer(grpcServer, newGroupServer(s))
 	rpcpb.RegisterProfilesServer(grpcServer, newProfileServer(s))
 	rpcpb.RegisterSelectServer(grpcServer, newSelectServer(s))
 	rpcpb.RegisterIgnitionServer(grpcServer, newIgnitionServer(s))
+	rpcpb.RegisterCloudServer(grpcServer, newCloudServer(s))
+	rpcpb.RegisterGenericServer(grpcServer, newGenericServer(s))
+	rpcpb.RegisterTokensServer(grpcServer, newTokenServer(s))
+	rpcpb.RegisterMachinesServer(grpcServer, newMachineServer(s))
+	rpcpb.RegisterMaintenanceServer(grpcServer, newMaintenanceServer(s))
 	return grpcServer
 }
+
+// GracefulStop stops the gRPC server from accepting connections and waits
+// for in-flight calls to finish, until the ctx is done, then stops it.
+func GracefulStop(ctx context.Context, s *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.Stop()
+	}
+}
+
+// Listen listens for gRPC connections on the TCP address, with
+// KeepAlivePeriod keepalives on accepted connections, or on a unix or
+// systemd activated socket address (see socket.Listen).
+func Listen(address string) (net.Listener, error) {
+	return socket.Listen(address, KeepAlivePeriod)
+}
@@ -0,0 +1,69 @@
+package rpc
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	"github.com/coreos/matchbox/matchbox/rpc/rpcpb"
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestNewServer_Compression(t *testing.T) {
+	store := fake.NewFixedStore()
+	core := server.NewServer(&server.Config{Store: store})
+	lis, err := Listen("127.0.0.1:0")
+	assert.Nil(t, err)
+	grpcServer := NewServer(core, &Config{Compress: true, MaxMessageSize: 1024})
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(5*time.Second),
+		grpc.WithCompressor(grpc.NewGZIPCompressor()), grpc.WithDecompressor(grpc.NewGZIPDecompressor()))
+	assert.Nil(t, err)
+	defer conn.Close()
+	groups := rpcpb.NewGroupsClient(conn)
+	ctx := context.Background()
+
+	// assert that:
+	// - gzip compressed requests are accepted and responses are decompressed
+	// - requests larger than the max message size are rejected
+	group := &storagepb.Group{Id: "worker", Profile: "worker"}
+	_, err = groups.GroupPut(ctx, &pb.GroupPutRequest{Group: group})
+	assert.Nil(t, err)
+	resp, err := groups.GroupList(ctx, &pb.GroupListRequest{})
+	if assert.Nil(t, err) {
+		assert.Equal(t, []*storagepb.Group{group}, resp.Groups)
+	}
+	large := &storagepb.Group{Id: "large", Profile: "worker", Metadata: []byte(`{"pad":"` + strings.Repeat("a", 2048) + `"}`)}
+	_, err = groups.GroupPut(ctx, &pb.GroupPutRequest{Group: large})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "exceeding 1024 limit")
+	}
+}
+
+func TestGracefulStop(t *testing.T) {
+	core := server.NewServer(&server.Config{Store: fake.NewFixedStore()})
+	lis, err := Listen("127.0.0.1:0")
+	assert.Nil(t, err)
+	grpcServer := NewServer(core, &Config{})
+	served := make(chan error, 1)
+	go func() { served <- grpcServer.Serve(lis) }()
+
+	// assert that:
+	// - the server stops once idle
+	// - the listener is closed
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	GracefulStop(ctx, grpcServer)
+	<-served
+	_, err = lis.Accept()
+	assert.Error(t, err)
+}
@@ -23,3 +23,27 @@ func (s *ignitionServer) IgnitionPut(ctx context.Context, req *pb.IgnitionPutReq
 	_, err := s.srv.IgnitionPut(ctx, req)
 	return &pb.IgnitionPutResponse{}, grpcError(err)
 }
+
+func (s *ignitionServer) IgnitionGet(ctx context.Context, req *pb.IgnitionGetRequest) (*pb.IgnitionGetResponse, error) {
+	contents, err := s.srv.IgnitionGet(ctx, req.Name)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.IgnitionGetResponse{Config: []byte(contents), Checksum: checksum([]byte(contents))}, nil
+}
+
+func (s *ignitionServer) IgnitionList(ctx context.Context, req *pb.IgnitionListRequest) (*pb.IgnitionListResponse, error) {
+	names, err := s.srv.IgnitionList(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.IgnitionListResponse{Names: names}, nil
+}
+
+func (s *ignitionServer) IgnitionDelete(ctx context.Context, req *pb.IgnitionDeleteRequest) (*pb.IgnitionDeleteResponse, error) {
+	err := s.srv.IgnitionDelete(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.IgnitionDeleteResponse{}, nil
+}
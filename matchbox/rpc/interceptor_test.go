@@ -0,0 +1,32 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+func TestChainUnary(t *testing.T) {
+	var calls []string
+	record := func(name string) grpc.UnaryServerInterceptor {
+		return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			calls = append(calls, name)
+			return handler(ctx, req)
+		}
+	}
+	chain := chainUnary([]grpc.UnaryServerInterceptor{record("first"), record("second")})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls = append(calls, "handler")
+		return "ok", nil
+	}
+	resp, err := chain(context.Background(), nil, &grpc.UnaryServerInfo{}, handler)
+	// assert that:
+	// - interceptors run in order before the handler
+	assert.Nil(t, err)
+	assert.Equal(t, "ok", resp)
+	assert.Equal(t, []string{"first", "second", "handler"}, calls)
+	// - nil interceptors are skipped
+	assert.Len(t, chainInterceptors([]Interceptor{{}, Tracing()}), 1)
+}
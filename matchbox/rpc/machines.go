@@ -0,0 +1,70 @@
+package rpc
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/coreos/matchbox/matchbox/rpc/rpcpb"
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// machineServer takes a matchbox Server and implements a gRPC MachinesServer.
+type machineServer struct {
+	srv server.Server
+}
+
+func newMachineServer(s server.Server) rpcpb.MachinesServer {
+	return &machineServer{
+		srv: s,
+	}
+}
+
+func (s *machineServer) MachinePut(ctx context.Context, req *pb.MachinePutRequest) (*pb.MachinePutResponse, error) {
+	_, err := s.srv.MachinePut(ctx, req)
+	return &pb.MachinePutResponse{}, grpcError(err)
+}
+
+func (s *machineServer) MachineGet(ctx context.Context, req *pb.MachineGetRequest) (*pb.MachineGetResponse, error) {
+	machine, err := s.srv.MachineGet(ctx, req)
+	return &pb.MachineGetResponse{Machine: machine}, grpcError(err)
+}
+
+func (s *machineServer) MachineList(ctx context.Context, req *pb.MachineListRequest) (*pb.MachineListResponse, error) {
+	machines, err := s.srv.MachineList(ctx, req)
+	return &pb.MachineListResponse{Machines: machines}, grpcError(err)
+}
+
+func (s *machineServer) MachinePin(ctx context.Context, req *pb.MachinePinRequest) (*pb.MachinePinResponse, error) {
+	machine, err := s.srv.MachinePin(ctx, req)
+	return &pb.MachinePinResponse{Machine: machine}, grpcError(err)
+}
+
+func (s *machineServer) MachineReinstall(ctx context.Context, req *pb.MachineReinstallRequest) (*pb.MachineReinstallResponse, error) {
+	machine, err := s.srv.MachineReinstall(ctx, req)
+	return &pb.MachineReinstallResponse{Machine: machine}, grpcError(err)
+}
+
+func (s *machineServer) MachineClaim(ctx context.Context, req *pb.MachineClaimRequest) (*pb.MachineClaimResponse, error) {
+	machine, err := s.srv.MachineClaim(ctx, req)
+	return &pb.MachineClaimResponse{Machine: machine}, grpcError(err)
+}
+
+func (s *machineServer) MachineRelease(ctx context.Context, req *pb.MachineReleaseRequest) (*pb.MachineReleaseResponse, error) {
+	machine, err := s.srv.MachineRelease(ctx, req)
+	return &pb.MachineReleaseResponse{Machine: machine}, grpcError(err)
+}
+
+func (s *machineServer) MachineDecommission(ctx context.Context, req *pb.MachineDecommissionRequest) (*pb.MachineDecommissionResponse, error) {
+	machine, err := s.srv.MachineDecommission(ctx, req)
+	return &pb.MachineDecommissionResponse{Machine: machine}, grpcError(err)
+}
+
+func (s *machineServer) MachineAdopt(ctx context.Context, req *pb.MachineAdoptRequest) (*pb.MachineAdoptResponse, error) {
+	machine, err := s.srv.MachineAdopt(ctx, req)
+	return &pb.MachineAdoptResponse{Machine: machine}, grpcError(err)
+}
+
+func (s *machineServer) MachineDescribe(ctx context.Context, req *pb.MachineDescribeRequest) (*pb.MachineDescribeResponse, error) {
+	resp, err := s.srv.MachineDescribe(ctx, req)
+	return resp, grpcError(err)
+}
@@ -0,0 +1,33 @@
+package rpc
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/coreos/matchbox/matchbox/rpc/rpcpb"
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// maintenanceServer takes a matchbox Server and implements a gRPC
+// MaintenanceServer.
+type maintenanceServer struct {
+	srv server.Server
+}
+
+func newMaintenanceServer(s server.Server) rpcpb.MaintenanceServer {
+	return &maintenanceServer{
+		srv: s,
+	}
+}
+
+func (s *maintenanceServer) MaintenanceGet(ctx context.Context, req *pb.MaintenanceGetRequest) (*pb.MaintenanceGetResponse, error) {
+	return &pb.MaintenanceGetResponse{Mode: s.srv.MaintenanceGet(ctx)}, nil
+}
+
+func (s *maintenanceServer) MaintenanceSet(ctx context.Context, req *pb.MaintenanceSetRequest) (*pb.MaintenanceSetResponse, error) {
+	mode, err := s.srv.MaintenanceSet(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.MaintenanceSetResponse{Mode: mode}, nil
+}
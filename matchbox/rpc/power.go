@@ -0,0 +1,39 @@
+package rpc
+
+import (
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	"github.com/coreos/matchbox/matchbox/power"
+	"github.com/coreos/matchbox/matchbox/rpc/rpcpb"
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// RegisterPower registers a gRPC PowerServer which controls the power of
+// Machines through their BMC. If the Controller is nil, power requests fail.
+func RegisterPower(s *grpc.Server, srv server.Server, controller power.Controller) {
+	rpcpb.RegisterPowerServer(s, &powerServer{srv: srv, controller: controller})
+}
+
+// powerServer takes a matchbox Server and power Controller and implements a
+// gRPC PowerServer.
+type powerServer struct {
+	srv        server.Server
+	controller power.Controller
+}
+
+func (s *powerServer) Power(ctx context.Context, req *pb.PowerRequest) (*pb.PowerResponse, error) {
+	if s.controller == nil {
+		return nil, errPowerDisabled
+	}
+	machine, err := s.srv.MachineGet(ctx, &pb.MachineGetRequest{Id: req.Id})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	state, err := s.controller.Power(ctx, machine.Bmc, req.Action)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.PowerResponse{State: state}, nil
+}
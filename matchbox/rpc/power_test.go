@@ -0,0 +1,57 @@
+package rpc
+
+import (
+	stdcontext "context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+
+	"github.com/coreos/matchbox/matchbox/power"
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+// fakeController records power actions.
+type fakeController struct {
+	bmc    string
+	action string
+}
+
+func (c *fakeController) Power(ctx stdcontext.Context, bmc, action string) (string, error) {
+	if bmc == "" {
+		return "", power.ErrBMCRequired
+	}
+	c.bmc, c.action = bmc, action
+	return "On", nil
+}
+
+func TestPower(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Machines["a1b2c3d4"] = &storagepb.Machine{Id: "a1b2c3d4", Bmc: "https://10.0.0.5/redfish/v1/Systems/1"}
+	store.Machines["e5f6a7b8"] = &storagepb.Machine{Id: "e5f6a7b8"}
+	core := server.NewServer(&server.Config{Store: store})
+	controller := &fakeController{}
+	srv := &powerServer{srv: core, controller: controller}
+	ctx := context.Background()
+
+	// assert that:
+	// - the action is performed on the Machine's BMC
+	// - Machines without a BMC are rejected
+	// - power requests fail if power control is not enabled
+	resp, err := srv.Power(ctx, &pb.PowerRequest{Id: "a1b2c3d4", Action: power.ActionCycle})
+	assert.Nil(t, err)
+	assert.Equal(t, "On", resp.State)
+	assert.Equal(t, "https://10.0.0.5/redfish/v1/Systems/1", controller.bmc)
+	assert.Equal(t, power.ActionCycle, controller.action)
+
+	_, err = srv.Power(ctx, &pb.PowerRequest{Id: "e5f6a7b8", Action: power.ActionOn})
+	assert.Equal(t, grpcErrorf(codes.FailedPrecondition, power.ErrBMCRequired.Error()), err)
+
+	srv = &powerServer{srv: core}
+	_, err = srv.Power(ctx, &pb.PowerRequest{Id: "a1b2c3d4", Action: power.ActionOn})
+	assert.Equal(t, errPowerDisabled, err)
+}
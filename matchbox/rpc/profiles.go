@@ -3,6 +3,7 @@ package rpc
 import (
 	"golang.org/x/net/context"
 
+	"github.com/coreos/matchbox/matchbox/deprecation"
 	"github.com/coreos/matchbox/matchbox/rpc/rpcpb"
 	"github.com/coreos/matchbox/matchbox/server"
 	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
@@ -21,16 +22,35 @@ func newProfileServer(s server.Server) rpcpb.ProfilesServer {
 
 func (s *profileServer) ProfilePut(ctx context.Context, req *pb.ProfilePutRequest) (*pb.ProfilePutResponse, error) {
 	_, err := s.srv.ProfilePut(ctx, req)
-	// TODO(dghubble): Decide on create/put and response(s).
-	return &pb.ProfilePutResponse{}, grpcError(err)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.ProfilePutResponse{Warnings: deprecation.Strings(deprecation.Profile(req.Profile))}, nil
 }
 
 func (s *profileServer) ProfileGet(ctx context.Context, req *pb.ProfileGetRequest) (*pb.ProfileGetResponse, error) {
 	profile, err := s.srv.ProfileGet(ctx, req)
-	return &pb.ProfileGetResponse{Profile: profile}, grpcError(err)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	sum, err := objectChecksum(profile)
+	return &pb.ProfileGetResponse{Profile: profile, Checksum: sum}, grpcError(err)
 }
 
 func (s *profileServer) ProfileList(ctx context.Context, req *pb.ProfileListRequest) (*pb.ProfileListResponse, error) {
 	profiles, err := s.srv.ProfileList(ctx, req)
 	return &pb.ProfileListResponse{Profiles: profiles}, grpcError(err)
 }
+
+func (s *profileServer) ProfileVersionList(ctx context.Context, req *pb.ProfileVersionListRequest) (*pb.ProfileVersionListResponse, error) {
+	versions, err := s.srv.ProfileVersionList(ctx, req)
+	return &pb.ProfileVersionListResponse{Versions: versions}, grpcError(err)
+}
+
+func (s *profileServer) ProfileRollback(ctx context.Context, req *pb.ProfileRollbackRequest) (*pb.ProfileRollbackResponse, error) {
+	version, err := s.srv.ProfileRollback(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.ProfileRollbackResponse{Version: version}, nil
+}
@@ -0,0 +1,85 @@
+package rpc
+
+import (
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/coreos/matchbox/matchbox/redact"
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// Redact returns an Interceptor which redacts sensitive Group metadata
+// values in responses, so they are write-only. Groups which are written
+// back with redacted values keep their stored values. A nil Redactor
+// redacts nothing.
+func Redact(redactor *redact.Redactor, srv server.Server) Interceptor {
+	if redactor == nil {
+		return Interceptor{}
+	}
+	return Interceptor{Unary: redactUnary(redactor, srv)}
+}
+
+func redactUnary(redactor *redact.Redactor, srv server.Server) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if put, ok := req.(*pb.GroupPutRequest); ok && put.Group != nil {
+			if err := restoreGroup(ctx, redactor, srv, put.Group); err != nil {
+				return nil, err
+			}
+		}
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+		switch resp := resp.(type) {
+		case *pb.GroupGetResponse:
+			return &pb.GroupGetResponse{Group: redactGroup(redactor, resp.Group), Checksum: resp.Checksum}, nil
+		case *pb.GroupListResponse:
+			groups := make([]*storagepb.Group, len(resp.Groups))
+			for i, group := range resp.Groups {
+				groups[i] = redactGroup(redactor, group)
+			}
+			return &pb.GroupListResponse{Groups: groups}, nil
+		case *pb.SelectGroupResponse:
+			return &pb.SelectGroupResponse{Group: redactGroup(redactor, resp.Group)}, nil
+		case *pb.MachineDescribeResponse:
+			redacted := *resp
+			redacted.Group = redactGroup(redactor, resp.Group)
+			return &redacted, nil
+		}
+		return resp, nil
+	}
+}
+
+// redactGroup returns a copy of the Group with sensitive metadata values
+// redacted.
+func redactGroup(redactor *redact.Redactor, group *storagepb.Group) *storagepb.Group {
+	if group == nil {
+		return nil
+	}
+	metadata, err := redactor.Metadata(group.Metadata)
+	if err != nil {
+		// invalid metadata cannot be redacted, so omit it
+		metadata = nil
+	}
+	redacted := *group
+	redacted.Metadata = metadata
+	return &redacted
+}
+
+// restoreGroup sets redacted metadata values of a Group which is written
+// to the values of the stored Group.
+func restoreGroup(ctx context.Context, redactor *redact.Redactor, srv server.Server, group *storagepb.Group) error {
+	var stored []byte
+	if current, err := srv.GroupGet(ctx, &pb.GroupGetRequest{Id: group.Id}); err == nil {
+		stored = current.Metadata
+	}
+	metadata, err := redactor.Restore(group.Metadata, stored)
+	if err != nil {
+		return grpcErrorf(codes.InvalidArgument, err.Error())
+	}
+	group.Metadata = metadata
+	return nil
+}
@@ -0,0 +1,68 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/coreos/matchbox/matchbox/redact"
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestRedactUnary(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups["workers"] = &storagepb.Group{
+		Id:       "workers",
+		Profile:  "worker",
+		Metadata: []byte(`{"join_token":"abcdef.0123456789abcdef","region":"us"}`),
+	}
+	core := server.NewServer(&server.Config{Store: store})
+	groups := newGroupServer(core)
+	redactor, err := redact.NewRedactor([]string{"join_token"})
+	assert.Nil(t, err)
+	interceptor := Redact(redactor, core).Unary
+	ctx := context.Background()
+	call := func(method string, req interface{}, handler grpc.UnaryHandler) (interface{}, error) {
+		return interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+	}
+	get := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return groups.GroupGet(ctx, req.(*pb.GroupGetRequest))
+	}
+	put := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return groups.GroupPut(ctx, req.(*pb.GroupPutRequest))
+	}
+
+	// assert that:
+	// - sensitive metadata values are redacted in responses
+	// - the stored Group is unchanged
+	resp, err := call("/rpcpb.Groups/GroupGet", &pb.GroupGetRequest{Id: "workers"}, get)
+	assert.Nil(t, err)
+	group := resp.(*pb.GroupGetResponse).Group
+	assert.Equal(t, `{"join_token":"REDACTED","region":"us"}`, string(group.Metadata))
+	assert.Contains(t, string(store.Groups["workers"].Metadata), "abcdef.0123456789abcdef")
+
+	// assert that:
+	// - writing back redacted values keeps the stored values
+	// - redacted values without stored values are rejected
+	group.Metadata = []byte(`{"join_token":"REDACTED","region":"eu"}`)
+	_, err = call("/rpcpb.Groups/GroupPut", &pb.GroupPutRequest{Group: group}, put)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"join_token":"abcdef.0123456789abcdef","region":"eu"}`, string(store.Groups["workers"].Metadata))
+	_, err = call("/rpcpb.Groups/GroupPut", &pb.GroupPutRequest{Group: &storagepb.Group{
+		Id:       "masters",
+		Profile:  "master",
+		Metadata: []byte(`{"join_token":"REDACTED"}`),
+	}}, put)
+	assert.Equal(t, grpcErrorf(codes.InvalidArgument, redact.ErrNoStoredValue.Error()), err)
+	assert.Nil(t, store.Groups["masters"])
+}
+
+func TestRedact_Disabled(t *testing.T) {
+	assert.Nil(t, Redact(nil, nil).Unary)
+}
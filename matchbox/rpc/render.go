@@ -0,0 +1,38 @@
+package rpc
+
+import (
+	stdcontext "context"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	"github.com/coreos/matchbox/matchbox/rpc/rpcpb"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// A Renderer renders the config a machine would receive (e.g. the matchbox
+// HTTP Server).
+type Renderer interface {
+	Render(stdcontext.Context, *pb.RenderRequest) (*pb.RenderResponse, error)
+	RenderTemplate(stdcontext.Context, *pb.RenderTemplateRequest) (*pb.RenderTemplateResponse, error)
+}
+
+// RegisterRenderer registers a gRPC RenderServer backed by the Renderer.
+func RegisterRenderer(s *grpc.Server, r Renderer) {
+	rpcpb.RegisterRenderServer(s, &renderServer{renderer: r})
+}
+
+// renderServer takes a Renderer and implements a gRPC RenderServer.
+type renderServer struct {
+	renderer Renderer
+}
+
+func (s *renderServer) Render(ctx context.Context, req *pb.RenderRequest) (*pb.RenderResponse, error) {
+	resp, err := s.renderer.Render(ctx, req)
+	return resp, grpcError(err)
+}
+
+func (s *renderServer) RenderTemplate(ctx context.Context, req *pb.RenderTemplateRequest) (*pb.RenderTemplateResponse, error) {
+	resp, err := s.renderer.RenderTemplate(ctx, req)
+	return resp, grpcError(err)
+}
@@ -0,0 +1,29 @@
+package rpc
+
+import (
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/coreos/matchbox/matchbox/requestid"
+)
+
+// RequestID returns an Interceptor which identifies unary calls by the
+// x-request-id metadata of the caller, or a new request ID, and returns it
+// in the x-request-id response header.
+func RequestID() Interceptor {
+	return Interceptor{Unary: requestIDUnary}
+}
+
+func requestIDUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	var id string
+	if md, ok := metadata.FromContext(ctx); ok {
+		if values := md[requestid.MetadataKey]; len(values) > 0 {
+			id = values[0]
+		}
+	}
+	id = requestid.Or(id)
+	ctx = requestid.NewContext(ctx, id)
+	grpc.SetHeader(ctx, metadata.Pairs(requestid.MetadataKey, id))
+	return handler(ctx, req)
+}
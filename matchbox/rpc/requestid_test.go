@@ -0,0 +1,54 @@
+package rpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/coreos/matchbox/matchbox/requestid"
+	"github.com/coreos/matchbox/matchbox/rpc/rpcpb"
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestRequestID(t *testing.T) {
+	core := server.NewServer(&server.Config{Store: fake.NewFixedStore()})
+	lis, err := Listen("127.0.0.1:0")
+	assert.Nil(t, err)
+	grpcServer := NewServer(core, &Config{}, RequestID())
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(5*time.Second))
+	assert.Nil(t, err)
+	defer conn.Close()
+	groups := rpcpb.NewGroupsClient(conn)
+
+	// assert that:
+	// - the caller's request ID is returned in the response header
+	// - calls without a valid request ID are given a new one
+	ctx := metadata.NewContext(context.Background(), metadata.Pairs(requestid.MetadataKey, "terraform-1234"))
+	var header metadata.MD
+	_, err = groups.GroupList(ctx, &pb.GroupListRequest{}, grpc.Header(&header))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"terraform-1234"}, header[requestid.MetadataKey])
+
+	for _, ctx := range []context.Context{
+		context.Background(),
+		metadata.NewContext(context.Background(), metadata.Pairs(requestid.MetadataKey, "bad id")),
+	} {
+		header = nil
+		_, err = groups.GroupList(ctx, &pb.GroupListRequest{}, grpc.Header(&header))
+		assert.Nil(t, err)
+		if assert.Len(t, header[requestid.MetadataKey], 1) {
+			id := header[requestid.MetadataKey][0]
+			assert.True(t, requestid.Valid(id))
+			assert.NotEqual(t, "bad id", id)
+		}
+	}
+}
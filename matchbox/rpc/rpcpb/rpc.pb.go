@@ -186,6 +186,10 @@ type ProfilesClient interface {
 	ProfileGet(ctx context.Context, in *serverpb.ProfileGetRequest, opts ...grpc.CallOption) (*serverpb.ProfileGetResponse, error)
 	// List all Profiles.
 	ProfileList(ctx context.Context, in *serverpb.ProfileListRequest, opts ...grpc.CallOption) (*serverpb.ProfileListResponse, error)
+	// List the versions of a Profile.
+	ProfileVersionList(ctx context.Context, in *serverpb.ProfileVersionListRequest, opts ...grpc.CallOption) (*serverpb.ProfileVersionListResponse, error)
+	// Restore a version of a Profile and its templates.
+	ProfileRollback(ctx context.Context, in *serverpb.ProfileRollbackRequest, opts ...grpc.CallOption) (*serverpb.ProfileRollbackResponse, error)
 }
 
 type profilesClient struct {
@@ -223,6 +227,24 @@ func (c *profilesClient) ProfileList(ctx context.Context, in *serverpb.ProfileLi
 	return out, nil
 }
 
+func (c *profilesClient) ProfileVersionList(ctx context.Context, in *serverpb.ProfileVersionListRequest, opts ...grpc.CallOption) (*serverpb.ProfileVersionListResponse, error) {
+	out := new(serverpb.ProfileVersionListResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Profiles/ProfileVersionList", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *profilesClient) ProfileRollback(ctx context.Context, in *serverpb.ProfileRollbackRequest, opts ...grpc.CallOption) (*serverpb.ProfileRollbackResponse, error) {
+	out := new(serverpb.ProfileRollbackResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Profiles/ProfileRollback", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for Profiles service
 
 type ProfilesServer interface {
@@ -232,6 +254,10 @@ type ProfilesServer interface {
 	ProfileGet(context.Context, *serverpb.ProfileGetRequest) (*serverpb.ProfileGetResponse, error)
 	// List all Profiles.
 	ProfileList(context.Context, *serverpb.ProfileListRequest) (*serverpb.ProfileListResponse, error)
+	// List the versions of a Profile.
+	ProfileVersionList(context.Context, *serverpb.ProfileVersionListRequest) (*serverpb.ProfileVersionListResponse, error)
+	// Restore a version of a Profile and its templates.
+	ProfileRollback(context.Context, *serverpb.ProfileRollbackRequest) (*serverpb.ProfileRollbackResponse, error)
 }
 
 func RegisterProfilesServer(s *grpc.Server, srv ProfilesServer) {
@@ -292,6 +318,42 @@ func _Profiles_ProfileList_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Profiles_ProfileVersionList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.ProfileVersionListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProfilesServer).ProfileVersionList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Profiles/ProfileVersionList",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProfilesServer).ProfileVersionList(ctx, req.(*serverpb.ProfileVersionListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Profiles_ProfileRollback_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.ProfileRollbackRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProfilesServer).ProfileRollback(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Profiles/ProfileRollback",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProfilesServer).ProfileRollback(ctx, req.(*serverpb.ProfileRollbackRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Profiles_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "rpcpb.Profiles",
 	HandlerType: (*ProfilesServer)(nil),
@@ -308,6 +370,14 @@ var _Profiles_serviceDesc = grpc.ServiceDesc{
 			MethodName: "ProfileList",
 			Handler:    _Profiles_ProfileList_Handler,
 		},
+		{
+			MethodName: "ProfileVersionList",
+			Handler:    _Profiles_ProfileVersionList_Handler,
+		},
+		{
+			MethodName: "ProfileRollback",
+			Handler:    _Profiles_ProfileRollback_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "rpc.proto",
@@ -318,6 +388,12 @@ var _Profiles_serviceDesc = grpc.ServiceDesc{
 type IgnitionClient interface {
 	// Create or update an Ignition template.
 	IgnitionPut(ctx context.Context, in *serverpb.IgnitionPutRequest, opts ...grpc.CallOption) (*serverpb.IgnitionPutResponse, error)
+	// Get an Ignition template by name.
+	IgnitionGet(ctx context.Context, in *serverpb.IgnitionGetRequest, opts ...grpc.CallOption) (*serverpb.IgnitionGetResponse, error)
+	// List the names of all Ignition templates.
+	IgnitionList(ctx context.Context, in *serverpb.IgnitionListRequest, opts ...grpc.CallOption) (*serverpb.IgnitionListResponse, error)
+	// Delete an Ignition template, unless a Profile references it.
+	IgnitionDelete(ctx context.Context, in *serverpb.IgnitionDeleteRequest, opts ...grpc.CallOption) (*serverpb.IgnitionDeleteResponse, error)
 }
 
 type ignitionClient struct {
@@ -337,11 +413,44 @@ func (c *ignitionClient) IgnitionPut(ctx context.Context, in *serverpb.IgnitionP
 	return out, nil
 }
 
+func (c *ignitionClient) IgnitionGet(ctx context.Context, in *serverpb.IgnitionGetRequest, opts ...grpc.CallOption) (*serverpb.IgnitionGetResponse, error) {
+	out := new(serverpb.IgnitionGetResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Ignition/IgnitionGet", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ignitionClient) IgnitionList(ctx context.Context, in *serverpb.IgnitionListRequest, opts ...grpc.CallOption) (*serverpb.IgnitionListResponse, error) {
+	out := new(serverpb.IgnitionListResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Ignition/IgnitionList", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ignitionClient) IgnitionDelete(ctx context.Context, in *serverpb.IgnitionDeleteRequest, opts ...grpc.CallOption) (*serverpb.IgnitionDeleteResponse, error) {
+	out := new(serverpb.IgnitionDeleteResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Ignition/IgnitionDelete", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for Ignition service
 
 type IgnitionServer interface {
 	// Create or update an Ignition template.
 	IgnitionPut(context.Context, *serverpb.IgnitionPutRequest) (*serverpb.IgnitionPutResponse, error)
+	// Get an Ignition template by name.
+	IgnitionGet(context.Context, *serverpb.IgnitionGetRequest) (*serverpb.IgnitionGetResponse, error)
+	// List the names of all Ignition templates.
+	IgnitionList(context.Context, *serverpb.IgnitionListRequest) (*serverpb.IgnitionListResponse, error)
+	// Delete an Ignition template, unless a Profile references it.
+	IgnitionDelete(context.Context, *serverpb.IgnitionDeleteRequest) (*serverpb.IgnitionDeleteResponse, error)
 }
 
 func RegisterIgnitionServer(s *grpc.Server, srv IgnitionServer) {
@@ -366,6 +475,60 @@ func _Ignition_IgnitionPut_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Ignition_IgnitionGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.IgnitionGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IgnitionServer).IgnitionGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Ignition/IgnitionGet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IgnitionServer).IgnitionGet(ctx, req.(*serverpb.IgnitionGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ignition_IgnitionList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.IgnitionListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IgnitionServer).IgnitionList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Ignition/IgnitionList",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IgnitionServer).IgnitionList(ctx, req.(*serverpb.IgnitionListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ignition_IgnitionDelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.IgnitionDeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IgnitionServer).IgnitionDelete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Ignition/IgnitionDelete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IgnitionServer).IgnitionDelete(ctx, req.(*serverpb.IgnitionDeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Ignition_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "rpcpb.Ignition",
 	HandlerType: (*IgnitionServer)(nil),
@@ -374,133 +537,1580 @@ var _Ignition_serviceDesc = grpc.ServiceDesc{
 			MethodName: "IgnitionPut",
 			Handler:    _Ignition_IgnitionPut_Handler,
 		},
+		{
+			MethodName: "IgnitionGet",
+			Handler:    _Ignition_IgnitionGet_Handler,
+		},
+		{
+			MethodName: "IgnitionList",
+			Handler:    _Ignition_IgnitionList_Handler,
+		},
+		{
+			MethodName: "IgnitionDelete",
+			Handler:    _Ignition_IgnitionDelete_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "rpc.proto",
 }
 
-// Client API for Select service
+// Client API for Cloud service
 
-type SelectClient interface {
-	// SelectGroup returns the Group matching the given labels.
-	SelectGroup(ctx context.Context, in *serverpb.SelectGroupRequest, opts ...grpc.CallOption) (*serverpb.SelectGroupResponse, error)
-	// SelectProfile returns the Profile matching the given labels.
-	SelectProfile(ctx context.Context, in *serverpb.SelectProfileRequest, opts ...grpc.CallOption) (*serverpb.SelectProfileResponse, error)
+type CloudClient interface {
+	// Create or update a Cloud-Config template.
+	CloudPut(ctx context.Context, in *serverpb.CloudPutRequest, opts ...grpc.CallOption) (*serverpb.CloudPutResponse, error)
+	// Get a Cloud-Config template by name.
+	CloudGet(ctx context.Context, in *serverpb.CloudGetRequest, opts ...grpc.CallOption) (*serverpb.CloudGetResponse, error)
+	// List the names of all Cloud-Config templates.
+	CloudList(ctx context.Context, in *serverpb.CloudListRequest, opts ...grpc.CallOption) (*serverpb.CloudListResponse, error)
+	// Delete a Cloud-Config template, unless a Profile references it.
+	CloudDelete(ctx context.Context, in *serverpb.CloudDeleteRequest, opts ...grpc.CallOption) (*serverpb.CloudDeleteResponse, error)
 }
 
-type selectClient struct {
+type cloudClient struct {
 	cc *grpc.ClientConn
 }
 
-func NewSelectClient(cc *grpc.ClientConn) SelectClient {
-	return &selectClient{cc}
+func NewCloudClient(cc *grpc.ClientConn) CloudClient {
+	return &cloudClient{cc}
 }
 
-func (c *selectClient) SelectGroup(ctx context.Context, in *serverpb.SelectGroupRequest, opts ...grpc.CallOption) (*serverpb.SelectGroupResponse, error) {
-	out := new(serverpb.SelectGroupResponse)
-	err := grpc.Invoke(ctx, "/rpcpb.Select/SelectGroup", in, out, c.cc, opts...)
+func (c *cloudClient) CloudPut(ctx context.Context, in *serverpb.CloudPutRequest, opts ...grpc.CallOption) (*serverpb.CloudPutResponse, error) {
+	out := new(serverpb.CloudPutResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Cloud/CloudPut", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *selectClient) SelectProfile(ctx context.Context, in *serverpb.SelectProfileRequest, opts ...grpc.CallOption) (*serverpb.SelectProfileResponse, error) {
-	out := new(serverpb.SelectProfileResponse)
-	err := grpc.Invoke(ctx, "/rpcpb.Select/SelectProfile", in, out, c.cc, opts...)
+func (c *cloudClient) CloudGet(ctx context.Context, in *serverpb.CloudGetRequest, opts ...grpc.CallOption) (*serverpb.CloudGetResponse, error) {
+	out := new(serverpb.CloudGetResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Cloud/CloudGet", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-// Server API for Select service
+func (c *cloudClient) CloudList(ctx context.Context, in *serverpb.CloudListRequest, opts ...grpc.CallOption) (*serverpb.CloudListResponse, error) {
+	out := new(serverpb.CloudListResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Cloud/CloudList", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
 
-type SelectServer interface {
-	// SelectGroup returns the Group matching the given labels.
-	SelectGroup(context.Context, *serverpb.SelectGroupRequest) (*serverpb.SelectGroupResponse, error)
-	// SelectProfile returns the Profile matching the given labels.
-	SelectProfile(context.Context, *serverpb.SelectProfileRequest) (*serverpb.SelectProfileResponse, error)
+func (c *cloudClient) CloudDelete(ctx context.Context, in *serverpb.CloudDeleteRequest, opts ...grpc.CallOption) (*serverpb.CloudDeleteResponse, error) {
+	out := new(serverpb.CloudDeleteResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Cloud/CloudDelete", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-func RegisterSelectServer(s *grpc.Server, srv SelectServer) {
-	s.RegisterService(&_Select_serviceDesc, srv)
+// Server API for Cloud service
+
+type CloudServer interface {
+	// Create or update a Cloud-Config template.
+	CloudPut(context.Context, *serverpb.CloudPutRequest) (*serverpb.CloudPutResponse, error)
+	// Get a Cloud-Config template by name.
+	CloudGet(context.Context, *serverpb.CloudGetRequest) (*serverpb.CloudGetResponse, error)
+	// List the names of all Cloud-Config templates.
+	CloudList(context.Context, *serverpb.CloudListRequest) (*serverpb.CloudListResponse, error)
+	// Delete a Cloud-Config template, unless a Profile references it.
+	CloudDelete(context.Context, *serverpb.CloudDeleteRequest) (*serverpb.CloudDeleteResponse, error)
 }
 
-func _Select_SelectGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(serverpb.SelectGroupRequest)
+func RegisterCloudServer(s *grpc.Server, srv CloudServer) {
+	s.RegisterService(&_Cloud_serviceDesc, srv)
+}
+
+func _Cloud_CloudPut_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.CloudPutRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(SelectServer).SelectGroup(ctx, in)
+		return srv.(CloudServer).CloudPut(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/rpcpb.Select/SelectGroup",
+		FullMethod: "/rpcpb.Cloud/CloudPut",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(SelectServer).SelectGroup(ctx, req.(*serverpb.SelectGroupRequest))
+		return srv.(CloudServer).CloudPut(ctx, req.(*serverpb.CloudPutRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Select_SelectProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(serverpb.SelectProfileRequest)
+func _Cloud_CloudGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.CloudGetRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(SelectServer).SelectProfile(ctx, in)
+		return srv.(CloudServer).CloudGet(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/rpcpb.Select/SelectProfile",
+		FullMethod: "/rpcpb.Cloud/CloudGet",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(SelectServer).SelectProfile(ctx, req.(*serverpb.SelectProfileRequest))
+		return srv.(CloudServer).CloudGet(ctx, req.(*serverpb.CloudGetRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-var _Select_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "rpcpb.Select",
-	HandlerType: (*SelectServer)(nil),
+func _Cloud_CloudList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.CloudListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CloudServer).CloudList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Cloud/CloudList",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CloudServer).CloudList(ctx, req.(*serverpb.CloudListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cloud_CloudDelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.CloudDeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CloudServer).CloudDelete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Cloud/CloudDelete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CloudServer).CloudDelete(ctx, req.(*serverpb.CloudDeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Cloud_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "rpcpb.Cloud",
+	HandlerType: (*CloudServer)(nil),
 	Methods: []grpc.MethodDesc{
 		{
-			MethodName: "SelectGroup",
-			Handler:    _Select_SelectGroup_Handler,
+			MethodName: "CloudPut",
+			Handler:    _Cloud_CloudPut_Handler,
 		},
 		{
-			MethodName: "SelectProfile",
-			Handler:    _Select_SelectProfile_Handler,
+			MethodName: "CloudGet",
+			Handler:    _Cloud_CloudGet_Handler,
+		},
+		{
+			MethodName: "CloudList",
+			Handler:    _Cloud_CloudList_Handler,
+		},
+		{
+			MethodName: "CloudDelete",
+			Handler:    _Cloud_CloudDelete_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "rpc.proto",
 }
 
+// Client API for Generic service
+
+type GenericClient interface {
+	// Create or update a generic template.
+	GenericPut(ctx context.Context, in *serverpb.GenericPutRequest, opts ...grpc.CallOption) (*serverpb.GenericPutResponse, error)
+	// Get a generic template by name.
+	GenericGet(ctx context.Context, in *serverpb.GenericGetRequest, opts ...grpc.CallOption) (*serverpb.GenericGetResponse, error)
+	// List the names of all generic templates.
+	GenericList(ctx context.Context, in *serverpb.GenericListRequest, opts ...grpc.CallOption) (*serverpb.GenericListResponse, error)
+	// Delete a generic template, unless a Profile references it.
+	GenericDelete(ctx context.Context, in *serverpb.GenericDeleteRequest, opts ...grpc.CallOption) (*serverpb.GenericDeleteResponse, error)
+}
+
+type genericClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewGenericClient(cc *grpc.ClientConn) GenericClient {
+	return &genericClient{cc}
+}
+
+func (c *genericClient) GenericPut(ctx context.Context, in *serverpb.GenericPutRequest, opts ...grpc.CallOption) (*serverpb.GenericPutResponse, error) {
+	out := new(serverpb.GenericPutResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Generic/GenericPut", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *genericClient) GenericGet(ctx context.Context, in *serverpb.GenericGetRequest, opts ...grpc.CallOption) (*serverpb.GenericGetResponse, error) {
+	out := new(serverpb.GenericGetResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Generic/GenericGet", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *genericClient) GenericList(ctx context.Context, in *serverpb.GenericListRequest, opts ...grpc.CallOption) (*serverpb.GenericListResponse, error) {
+	out := new(serverpb.GenericListResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Generic/GenericList", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *genericClient) GenericDelete(ctx context.Context, in *serverpb.GenericDeleteRequest, opts ...grpc.CallOption) (*serverpb.GenericDeleteResponse, error) {
+	out := new(serverpb.GenericDeleteResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Generic/GenericDelete", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Generic service
+
+type GenericServer interface {
+	// Create or update a generic template.
+	GenericPut(context.Context, *serverpb.GenericPutRequest) (*serverpb.GenericPutResponse, error)
+	// Get a generic template by name.
+	GenericGet(context.Context, *serverpb.GenericGetRequest) (*serverpb.GenericGetResponse, error)
+	// List the names of all generic templates.
+	GenericList(context.Context, *serverpb.GenericListRequest) (*serverpb.GenericListResponse, error)
+	// Delete a generic template, unless a Profile references it.
+	GenericDelete(context.Context, *serverpb.GenericDeleteRequest) (*serverpb.GenericDeleteResponse, error)
+}
+
+func RegisterGenericServer(s *grpc.Server, srv GenericServer) {
+	s.RegisterService(&_Generic_serviceDesc, srv)
+}
+
+func _Generic_GenericPut_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.GenericPutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GenericServer).GenericPut(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Generic/GenericPut",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GenericServer).GenericPut(ctx, req.(*serverpb.GenericPutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Generic_GenericGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.GenericGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GenericServer).GenericGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Generic/GenericGet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GenericServer).GenericGet(ctx, req.(*serverpb.GenericGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Generic_GenericList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.GenericListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GenericServer).GenericList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Generic/GenericList",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GenericServer).GenericList(ctx, req.(*serverpb.GenericListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Generic_GenericDelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.GenericDeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GenericServer).GenericDelete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Generic/GenericDelete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GenericServer).GenericDelete(ctx, req.(*serverpb.GenericDeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Generic_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "rpcpb.Generic",
+	HandlerType: (*GenericServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GenericPut",
+			Handler:    _Generic_GenericPut_Handler,
+		},
+		{
+			MethodName: "GenericGet",
+			Handler:    _Generic_GenericGet_Handler,
+		},
+		{
+			MethodName: "GenericList",
+			Handler:    _Generic_GenericList_Handler,
+		},
+		{
+			MethodName: "GenericDelete",
+			Handler:    _Generic_GenericDelete_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "rpc.proto",
+}
+
+// Client API for Select service
+
+type SelectClient interface {
+	// SelectGroup returns the Group matching the given labels.
+	SelectGroup(ctx context.Context, in *serverpb.SelectGroupRequest, opts ...grpc.CallOption) (*serverpb.SelectGroupResponse, error)
+	// SelectProfile returns the Profile matching the given labels.
+	SelectProfile(ctx context.Context, in *serverpb.SelectProfileRequest, opts ...grpc.CallOption) (*serverpb.SelectProfileResponse, error)
+}
+
+type selectClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewSelectClient(cc *grpc.ClientConn) SelectClient {
+	return &selectClient{cc}
+}
+
+func (c *selectClient) SelectGroup(ctx context.Context, in *serverpb.SelectGroupRequest, opts ...grpc.CallOption) (*serverpb.SelectGroupResponse, error) {
+	out := new(serverpb.SelectGroupResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Select/SelectGroup", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *selectClient) SelectProfile(ctx context.Context, in *serverpb.SelectProfileRequest, opts ...grpc.CallOption) (*serverpb.SelectProfileResponse, error) {
+	out := new(serverpb.SelectProfileResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Select/SelectProfile", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Select service
+
+type SelectServer interface {
+	// SelectGroup returns the Group matching the given labels.
+	SelectGroup(context.Context, *serverpb.SelectGroupRequest) (*serverpb.SelectGroupResponse, error)
+	// SelectProfile returns the Profile matching the given labels.
+	SelectProfile(context.Context, *serverpb.SelectProfileRequest) (*serverpb.SelectProfileResponse, error)
+}
+
+func RegisterSelectServer(s *grpc.Server, srv SelectServer) {
+	s.RegisterService(&_Select_serviceDesc, srv)
+}
+
+func _Select_SelectGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.SelectGroupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SelectServer).SelectGroup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Select/SelectGroup",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SelectServer).SelectGroup(ctx, req.(*serverpb.SelectGroupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Select_SelectProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.SelectProfileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SelectServer).SelectProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Select/SelectProfile",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SelectServer).SelectProfile(ctx, req.(*serverpb.SelectProfileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Select_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "rpcpb.Select",
+	HandlerType: (*SelectServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SelectGroup",
+			Handler:    _Select_SelectGroup_Handler,
+		},
+		{
+			MethodName: "SelectProfile",
+			Handler:    _Select_SelectProfile_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "rpc.proto",
+}
+
+// Client API for Tokens service
+
+type TokensClient interface {
+	// Create a single-use token for fetching an Ignition config.
+	TokenCreate(ctx context.Context, in *serverpb.TokenCreateRequest, opts ...grpc.CallOption) (*serverpb.TokenCreateResponse, error)
+}
+
+type tokensClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewTokensClient(cc *grpc.ClientConn) TokensClient {
+	return &tokensClient{cc}
+}
+
+func (c *tokensClient) TokenCreate(ctx context.Context, in *serverpb.TokenCreateRequest, opts ...grpc.CallOption) (*serverpb.TokenCreateResponse, error) {
+	out := new(serverpb.TokenCreateResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Tokens/TokenCreate", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Tokens service
+
+type TokensServer interface {
+	// Create a single-use token for fetching an Ignition config.
+	TokenCreate(context.Context, *serverpb.TokenCreateRequest) (*serverpb.TokenCreateResponse, error)
+}
+
+func RegisterTokensServer(s *grpc.Server, srv TokensServer) {
+	s.RegisterService(&_Tokens_serviceDesc, srv)
+}
+
+func _Tokens_TokenCreate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.TokenCreateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TokensServer).TokenCreate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Tokens/TokenCreate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TokensServer).TokenCreate(ctx, req.(*serverpb.TokenCreateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Tokens_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "rpcpb.Tokens",
+	HandlerType: (*TokensServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "TokenCreate",
+			Handler:    _Tokens_TokenCreate_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "rpc.proto",
+}
+
+// Client API for Render service
+
+type RenderClient interface {
+	// Render a config as a machine would receive it, without side effects.
+	Render(ctx context.Context, in *serverpb.RenderRequest, opts ...grpc.CallOption) (*serverpb.RenderResponse, error)
+	// Validate and render unsaved template contents against a Group's
+	// metadata, without side effects.
+	RenderTemplate(ctx context.Context, in *serverpb.RenderTemplateRequest, opts ...grpc.CallOption) (*serverpb.RenderTemplateResponse, error)
+}
+
+type renderClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewRenderClient(cc *grpc.ClientConn) RenderClient {
+	return &renderClient{cc}
+}
+
+func (c *renderClient) Render(ctx context.Context, in *serverpb.RenderRequest, opts ...grpc.CallOption) (*serverpb.RenderResponse, error) {
+	out := new(serverpb.RenderResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Render/Render", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *renderClient) RenderTemplate(ctx context.Context, in *serverpb.RenderTemplateRequest, opts ...grpc.CallOption) (*serverpb.RenderTemplateResponse, error) {
+	out := new(serverpb.RenderTemplateResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Render/RenderTemplate", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Render service
+
+type RenderServer interface {
+	// Render a config as a machine would receive it, without side effects.
+	Render(context.Context, *serverpb.RenderRequest) (*serverpb.RenderResponse, error)
+	// Validate and render unsaved template contents against a Group's
+	// metadata, without side effects.
+	RenderTemplate(context.Context, *serverpb.RenderTemplateRequest) (*serverpb.RenderTemplateResponse, error)
+}
+
+func RegisterRenderServer(s *grpc.Server, srv RenderServer) {
+	s.RegisterService(&_Render_serviceDesc, srv)
+}
+
+func _Render_Render_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.RenderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RenderServer).Render(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Render/Render",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RenderServer).Render(ctx, req.(*serverpb.RenderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Render_RenderTemplate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.RenderTemplateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RenderServer).RenderTemplate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Render/RenderTemplate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RenderServer).RenderTemplate(ctx, req.(*serverpb.RenderTemplateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Render_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "rpcpb.Render",
+	HandlerType: (*RenderServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Render",
+			Handler:    _Render_Render_Handler,
+		},
+		{
+			MethodName: "RenderTemplate",
+			Handler:    _Render_RenderTemplate_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "rpc.proto",
+}
+
+// Client API for Events service
+
+type EventsClient interface {
+	// Watch streams boot events as machines request boot endpoints.
+	Watch(ctx context.Context, in *serverpb.BootEventsRequest, opts ...grpc.CallOption) (Events_WatchClient, error)
+	// EventList lists recorded events, filtered by machine, type, and time.
+	EventList(ctx context.Context, in *serverpb.EventListRequest, opts ...grpc.CallOption) (*serverpb.EventListResponse, error)
+}
+
+type eventsClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewEventsClient(cc *grpc.ClientConn) EventsClient {
+	return &eventsClient{cc}
+}
+
+func (c *eventsClient) Watch(ctx context.Context, in *serverpb.BootEventsRequest, opts ...grpc.CallOption) (Events_WatchClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Events_serviceDesc.Streams[0], c.cc, "/rpcpb.Events/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &eventsWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Events_WatchClient interface {
+	Recv() (*serverpb.BootEvent, error)
+	grpc.ClientStream
+}
+
+type eventsWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *eventsWatchClient) Recv() (*serverpb.BootEvent, error) {
+	m := new(serverpb.BootEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *eventsClient) EventList(ctx context.Context, in *serverpb.EventListRequest, opts ...grpc.CallOption) (*serverpb.EventListResponse, error) {
+	out := new(serverpb.EventListResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Events/EventList", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Events service
+
+type EventsServer interface {
+	// Watch streams boot events as machines request boot endpoints.
+	Watch(*serverpb.BootEventsRequest, Events_WatchServer) error
+	// EventList lists recorded events, filtered by machine, type, and time.
+	EventList(context.Context, *serverpb.EventListRequest) (*serverpb.EventListResponse, error)
+}
+
+func RegisterEventsServer(s *grpc.Server, srv EventsServer) {
+	s.RegisterService(&_Events_serviceDesc, srv)
+}
+
+func _Events_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(serverpb.BootEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EventsServer).Watch(m, &eventsWatchServer{stream})
+}
+
+type Events_WatchServer interface {
+	Send(*serverpb.BootEvent) error
+	grpc.ServerStream
+}
+
+type eventsWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *eventsWatchServer) Send(m *serverpb.BootEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Events_EventList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.EventListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventsServer).EventList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Events/EventList",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventsServer).EventList(ctx, req.(*serverpb.EventListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Events_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "rpcpb.Events",
+	HandlerType: (*EventsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "EventList",
+			Handler:    _Events_EventList_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _Events_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "rpc.proto",
+}
+
+// Client API for Machines service
+
+type MachinesClient interface {
+	// Create or update a Machine.
+	MachinePut(ctx context.Context, in *serverpb.MachinePutRequest, opts ...grpc.CallOption) (*serverpb.MachinePutResponse, error)
+	// Get a Machine by id.
+	MachineGet(ctx context.Context, in *serverpb.MachineGetRequest, opts ...grpc.CallOption) (*serverpb.MachineGetResponse, error)
+	// List all Machines.
+	MachineList(ctx context.Context, in *serverpb.MachineListRequest, opts ...grpc.CallOption) (*serverpb.MachineListResponse, error)
+	// Pin a Machine to a Group, or unpin it.
+	MachinePin(ctx context.Context, in *serverpb.MachinePinRequest, opts ...grpc.CallOption) (*serverpb.MachinePinResponse, error)
+	// Mark a Machine to be reinstalled.
+	MachineReinstall(ctx context.Context, in *serverpb.MachineReinstallRequest, opts ...grpc.CallOption) (*serverpb.MachineReinstallResponse, error)
+	// Claim an unclaimed Machine matching a selector and pin it to a Group.
+	MachineClaim(ctx context.Context, in *serverpb.MachineClaimRequest, opts ...grpc.CallOption) (*serverpb.MachineClaimResponse, error)
+	// Release a claimed Machine and unpin it.
+	MachineRelease(ctx context.Context, in *serverpb.MachineReleaseRequest, opts ...grpc.CallOption) (*serverpb.MachineReleaseResponse, error)
+	// Decommission a Machine with a Profile, then archive it once complete.
+	MachineDecommission(ctx context.Context, in *serverpb.MachineDecommissionRequest, opts ...grpc.CallOption) (*serverpb.MachineDecommissionResponse, error)
+	// Adopt a pending Machine into a Group.
+	MachineAdopt(ctx context.Context, in *serverpb.MachineAdoptRequest, opts ...grpc.CallOption) (*serverpb.MachineAdoptResponse, error)
+	// Describe a Machine with the Group and Profile it matches and its boot history.
+	MachineDescribe(ctx context.Context, in *serverpb.MachineDescribeRequest, opts ...grpc.CallOption) (*serverpb.MachineDescribeResponse, error)
+}
+
+type machinesClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewMachinesClient(cc *grpc.ClientConn) MachinesClient {
+	return &machinesClient{cc}
+}
+
+func (c *machinesClient) MachinePut(ctx context.Context, in *serverpb.MachinePutRequest, opts ...grpc.CallOption) (*serverpb.MachinePutResponse, error) {
+	out := new(serverpb.MachinePutResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Machines/MachinePut", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machinesClient) MachineGet(ctx context.Context, in *serverpb.MachineGetRequest, opts ...grpc.CallOption) (*serverpb.MachineGetResponse, error) {
+	out := new(serverpb.MachineGetResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Machines/MachineGet", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machinesClient) MachineList(ctx context.Context, in *serverpb.MachineListRequest, opts ...grpc.CallOption) (*serverpb.MachineListResponse, error) {
+	out := new(serverpb.MachineListResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Machines/MachineList", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machinesClient) MachinePin(ctx context.Context, in *serverpb.MachinePinRequest, opts ...grpc.CallOption) (*serverpb.MachinePinResponse, error) {
+	out := new(serverpb.MachinePinResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Machines/MachinePin", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machinesClient) MachineReinstall(ctx context.Context, in *serverpb.MachineReinstallRequest, opts ...grpc.CallOption) (*serverpb.MachineReinstallResponse, error) {
+	out := new(serverpb.MachineReinstallResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Machines/MachineReinstall", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machinesClient) MachineClaim(ctx context.Context, in *serverpb.MachineClaimRequest, opts ...grpc.CallOption) (*serverpb.MachineClaimResponse, error) {
+	out := new(serverpb.MachineClaimResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Machines/MachineClaim", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machinesClient) MachineRelease(ctx context.Context, in *serverpb.MachineReleaseRequest, opts ...grpc.CallOption) (*serverpb.MachineReleaseResponse, error) {
+	out := new(serverpb.MachineReleaseResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Machines/MachineRelease", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machinesClient) MachineDecommission(ctx context.Context, in *serverpb.MachineDecommissionRequest, opts ...grpc.CallOption) (*serverpb.MachineDecommissionResponse, error) {
+	out := new(serverpb.MachineDecommissionResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Machines/MachineDecommission", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machinesClient) MachineAdopt(ctx context.Context, in *serverpb.MachineAdoptRequest, opts ...grpc.CallOption) (*serverpb.MachineAdoptResponse, error) {
+	out := new(serverpb.MachineAdoptResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Machines/MachineAdopt", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machinesClient) MachineDescribe(ctx context.Context, in *serverpb.MachineDescribeRequest, opts ...grpc.CallOption) (*serverpb.MachineDescribeResponse, error) {
+	out := new(serverpb.MachineDescribeResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Machines/MachineDescribe", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Machines service
+
+type MachinesServer interface {
+	// Create or update a Machine.
+	MachinePut(context.Context, *serverpb.MachinePutRequest) (*serverpb.MachinePutResponse, error)
+	// Get a Machine by id.
+	MachineGet(context.Context, *serverpb.MachineGetRequest) (*serverpb.MachineGetResponse, error)
+	// List all Machines.
+	MachineList(context.Context, *serverpb.MachineListRequest) (*serverpb.MachineListResponse, error)
+	// Pin a Machine to a Group, or unpin it.
+	MachinePin(context.Context, *serverpb.MachinePinRequest) (*serverpb.MachinePinResponse, error)
+	// Mark a Machine to be reinstalled.
+	MachineReinstall(context.Context, *serverpb.MachineReinstallRequest) (*serverpb.MachineReinstallResponse, error)
+	// Claim an unclaimed Machine matching a selector and pin it to a Group.
+	MachineClaim(context.Context, *serverpb.MachineClaimRequest) (*serverpb.MachineClaimResponse, error)
+	// Release a claimed Machine and unpin it.
+	MachineRelease(context.Context, *serverpb.MachineReleaseRequest) (*serverpb.MachineReleaseResponse, error)
+	// Decommission a Machine with a Profile, then archive it once complete.
+	MachineDecommission(context.Context, *serverpb.MachineDecommissionRequest) (*serverpb.MachineDecommissionResponse, error)
+	// Adopt a pending Machine into a Group.
+	MachineAdopt(context.Context, *serverpb.MachineAdoptRequest) (*serverpb.MachineAdoptResponse, error)
+	// Describe a Machine with the Group and Profile it matches and its boot history.
+	MachineDescribe(context.Context, *serverpb.MachineDescribeRequest) (*serverpb.MachineDescribeResponse, error)
+}
+
+func RegisterMachinesServer(s *grpc.Server, srv MachinesServer) {
+	s.RegisterService(&_Machines_serviceDesc, srv)
+}
+
+func _Machines_MachinePut_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.MachinePutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachinesServer).MachinePut(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Machines/MachinePut",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachinesServer).MachinePut(ctx, req.(*serverpb.MachinePutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Machines_MachineGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.MachineGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachinesServer).MachineGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Machines/MachineGet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachinesServer).MachineGet(ctx, req.(*serverpb.MachineGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Machines_MachineList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.MachineListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachinesServer).MachineList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Machines/MachineList",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachinesServer).MachineList(ctx, req.(*serverpb.MachineListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Machines_MachinePin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.MachinePinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachinesServer).MachinePin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Machines/MachinePin",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachinesServer).MachinePin(ctx, req.(*serverpb.MachinePinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Machines_MachineReinstall_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.MachineReinstallRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachinesServer).MachineReinstall(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Machines/MachineReinstall",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachinesServer).MachineReinstall(ctx, req.(*serverpb.MachineReinstallRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Machines_MachineClaim_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.MachineClaimRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachinesServer).MachineClaim(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Machines/MachineClaim",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachinesServer).MachineClaim(ctx, req.(*serverpb.MachineClaimRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Machines_MachineRelease_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.MachineReleaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachinesServer).MachineRelease(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Machines/MachineRelease",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachinesServer).MachineRelease(ctx, req.(*serverpb.MachineReleaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Machines_MachineDecommission_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.MachineDecommissionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachinesServer).MachineDecommission(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Machines/MachineDecommission",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachinesServer).MachineDecommission(ctx, req.(*serverpb.MachineDecommissionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Machines_MachineAdopt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.MachineAdoptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachinesServer).MachineAdopt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Machines/MachineAdopt",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachinesServer).MachineAdopt(ctx, req.(*serverpb.MachineAdoptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Machines_MachineDescribe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.MachineDescribeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachinesServer).MachineDescribe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Machines/MachineDescribe",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachinesServer).MachineDescribe(ctx, req.(*serverpb.MachineDescribeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Machines_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "rpcpb.Machines",
+	HandlerType: (*MachinesServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "MachinePut",
+			Handler:    _Machines_MachinePut_Handler,
+		},
+		{
+			MethodName: "MachineGet",
+			Handler:    _Machines_MachineGet_Handler,
+		},
+		{
+			MethodName: "MachineList",
+			Handler:    _Machines_MachineList_Handler,
+		},
+		{
+			MethodName: "MachinePin",
+			Handler:    _Machines_MachinePin_Handler,
+		},
+		{
+			MethodName: "MachineReinstall",
+			Handler:    _Machines_MachineReinstall_Handler,
+		},
+		{
+			MethodName: "MachineClaim",
+			Handler:    _Machines_MachineClaim_Handler,
+		},
+		{
+			MethodName: "MachineRelease",
+			Handler:    _Machines_MachineRelease_Handler,
+		},
+		{
+			MethodName: "MachineDecommission",
+			Handler:    _Machines_MachineDecommission_Handler,
+		},
+		{
+			MethodName: "MachineAdopt",
+			Handler:    _Machines_MachineAdopt_Handler,
+		},
+		{
+			MethodName: "MachineDescribe",
+			Handler:    _Machines_MachineDescribe_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "rpc.proto",
+}
+
+// Client API for Power service
+
+type PowerClient interface {
+	// Power a Machine on, off, or cycle it through its BMC, or get its power state.
+	Power(ctx context.Context, in *serverpb.PowerRequest, opts ...grpc.CallOption) (*serverpb.PowerResponse, error)
+}
+
+type powerClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewPowerClient(cc *grpc.ClientConn) PowerClient {
+	return &powerClient{cc}
+}
+
+func (c *powerClient) Power(ctx context.Context, in *serverpb.PowerRequest, opts ...grpc.CallOption) (*serverpb.PowerResponse, error) {
+	out := new(serverpb.PowerResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Power/Power", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Power service
+
+type PowerServer interface {
+	// Power a Machine on, off, or cycle it through its BMC, or get its power state.
+	Power(context.Context, *serverpb.PowerRequest) (*serverpb.PowerResponse, error)
+}
+
+func RegisterPowerServer(s *grpc.Server, srv PowerServer) {
+	s.RegisterService(&_Power_serviceDesc, srv)
+}
+
+func _Power_Power_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.PowerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PowerServer).Power(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Power/Power",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PowerServer).Power(ctx, req.(*serverpb.PowerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Power_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "rpcpb.Power",
+	HandlerType: (*PowerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Power",
+			Handler:    _Power_Power_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "rpc.proto",
+}
+
+// Client API for Maintenance service
+
+type MaintenanceClient interface {
+	// Get the maintenance mode.
+	MaintenanceGet(ctx context.Context, in *serverpb.MaintenanceGetRequest, opts ...grpc.CallOption) (*serverpb.MaintenanceGetResponse, error)
+	// Enter or leave maintenance mode, in which iPXE requests boot from local
+	// disk or hold regardless of matching.
+	MaintenanceSet(ctx context.Context, in *serverpb.MaintenanceSetRequest, opts ...grpc.CallOption) (*serverpb.MaintenanceSetResponse, error)
+}
+
+type maintenanceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewMaintenanceClient(cc *grpc.ClientConn) MaintenanceClient {
+	return &maintenanceClient{cc}
+}
+
+func (c *maintenanceClient) MaintenanceGet(ctx context.Context, in *serverpb.MaintenanceGetRequest, opts ...grpc.CallOption) (*serverpb.MaintenanceGetResponse, error) {
+	out := new(serverpb.MaintenanceGetResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Maintenance/MaintenanceGet", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *maintenanceClient) MaintenanceSet(ctx context.Context, in *serverpb.MaintenanceSetRequest, opts ...grpc.CallOption) (*serverpb.MaintenanceSetResponse, error) {
+	out := new(serverpb.MaintenanceSetResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Maintenance/MaintenanceSet", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Maintenance service
+
+type MaintenanceServer interface {
+	// Get the maintenance mode.
+	MaintenanceGet(context.Context, *serverpb.MaintenanceGetRequest) (*serverpb.MaintenanceGetResponse, error)
+	// Enter or leave maintenance mode, in which iPXE requests boot from local
+	// disk or hold regardless of matching.
+	MaintenanceSet(context.Context, *serverpb.MaintenanceSetRequest) (*serverpb.MaintenanceSetResponse, error)
+}
+
+func RegisterMaintenanceServer(s *grpc.Server, srv MaintenanceServer) {
+	s.RegisterService(&_Maintenance_serviceDesc, srv)
+}
+
+func _Maintenance_MaintenanceGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.MaintenanceGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MaintenanceServer).MaintenanceGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Maintenance/MaintenanceGet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MaintenanceServer).MaintenanceGet(ctx, req.(*serverpb.MaintenanceGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Maintenance_MaintenanceSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.MaintenanceSetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MaintenanceServer).MaintenanceSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Maintenance/MaintenanceSet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MaintenanceServer).MaintenanceSet(ctx, req.(*serverpb.MaintenanceSetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Maintenance_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "rpcpb.Maintenance",
+	HandlerType: (*MaintenanceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "MaintenanceGet",
+			Handler:    _Maintenance_MaintenanceGet_Handler,
+		},
+		{
+			MethodName: "MaintenanceSet",
+			Handler:    _Maintenance_MaintenanceSet_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "rpc.proto",
+}
+
+// Client API for Assets service
+
+type AssetsClient interface {
+	// Upload an asset as a stream of chunks.
+	AssetPut(ctx context.Context, opts ...grpc.CallOption) (Assets_AssetPutClient, error)
+	// Fetch an asset from its upstream URL, unless the local asset matches its checksum.
+	AssetFetch(ctx context.Context, in *serverpb.AssetFetchRequest, opts ...grpc.CallOption) (*serverpb.AssetFetchResponse, error)
+	// Get the size and checksum of an asset.
+	AssetGet(ctx context.Context, in *serverpb.AssetGetRequest, opts ...grpc.CallOption) (*serverpb.AssetGetResponse, error)
+	// Delete an asset.
+	AssetDelete(ctx context.Context, in *serverpb.AssetDeleteRequest, opts ...grpc.CallOption) (*serverpb.AssetDeleteResponse, error)
+}
+
+type assetsClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewAssetsClient(cc *grpc.ClientConn) AssetsClient {
+	return &assetsClient{cc}
+}
+
+func (c *assetsClient) AssetPut(ctx context.Context, opts ...grpc.CallOption) (Assets_AssetPutClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Assets_serviceDesc.Streams[0], c.cc, "/rpcpb.Assets/AssetPut", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &assetsAssetPutClient{stream}
+	return x, nil
+}
+
+type Assets_AssetPutClient interface {
+	Send(*serverpb.AssetPutRequest) error
+	CloseAndRecv() (*serverpb.AssetPutResponse, error)
+	grpc.ClientStream
+}
+
+type assetsAssetPutClient struct {
+	grpc.ClientStream
+}
+
+func (x *assetsAssetPutClient) Send(m *serverpb.AssetPutRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *assetsAssetPutClient) CloseAndRecv() (*serverpb.AssetPutResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(serverpb.AssetPutResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *assetsClient) AssetFetch(ctx context.Context, in *serverpb.AssetFetchRequest, opts ...grpc.CallOption) (*serverpb.AssetFetchResponse, error) {
+	out := new(serverpb.AssetFetchResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Assets/AssetFetch", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *assetsClient) AssetGet(ctx context.Context, in *serverpb.AssetGetRequest, opts ...grpc.CallOption) (*serverpb.AssetGetResponse, error) {
+	out := new(serverpb.AssetGetResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Assets/AssetGet", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *assetsClient) AssetDelete(ctx context.Context, in *serverpb.AssetDeleteRequest, opts ...grpc.CallOption) (*serverpb.AssetDeleteResponse, error) {
+	out := new(serverpb.AssetDeleteResponse)
+	err := grpc.Invoke(ctx, "/rpcpb.Assets/AssetDelete", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Assets service
+
+type AssetsServer interface {
+	// Upload an asset as a stream of chunks.
+	AssetPut(Assets_AssetPutServer) error
+	// Fetch an asset from its upstream URL, unless the local asset matches its checksum.
+	AssetFetch(context.Context, *serverpb.AssetFetchRequest) (*serverpb.AssetFetchResponse, error)
+	// Get the size and checksum of an asset.
+	AssetGet(context.Context, *serverpb.AssetGetRequest) (*serverpb.AssetGetResponse, error)
+	// Delete an asset.
+	AssetDelete(context.Context, *serverpb.AssetDeleteRequest) (*serverpb.AssetDeleteResponse, error)
+}
+
+func RegisterAssetsServer(s *grpc.Server, srv AssetsServer) {
+	s.RegisterService(&_Assets_serviceDesc, srv)
+}
+
+func _Assets_AssetPut_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AssetsServer).AssetPut(&assetsAssetPutServer{stream})
+}
+
+type Assets_AssetPutServer interface {
+	SendAndClose(*serverpb.AssetPutResponse) error
+	Recv() (*serverpb.AssetPutRequest, error)
+	grpc.ServerStream
+}
+
+type assetsAssetPutServer struct {
+	grpc.ServerStream
+}
+
+func (x *assetsAssetPutServer) SendAndClose(m *serverpb.AssetPutResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *assetsAssetPutServer) Recv() (*serverpb.AssetPutRequest, error) {
+	m := new(serverpb.AssetPutRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Assets_AssetFetch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.AssetFetchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AssetsServer).AssetFetch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Assets/AssetFetch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AssetsServer).AssetFetch(ctx, req.(*serverpb.AssetFetchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Assets_AssetGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.AssetGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AssetsServer).AssetGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Assets/AssetGet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AssetsServer).AssetGet(ctx, req.(*serverpb.AssetGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Assets_AssetDelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(serverpb.AssetDeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AssetsServer).AssetDelete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.Assets/AssetDelete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AssetsServer).AssetDelete(ctx, req.(*serverpb.AssetDeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Assets_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "rpcpb.Assets",
+	HandlerType: (*AssetsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AssetFetch",
+			Handler:    _Assets_AssetFetch_Handler,
+		},
+		{
+			MethodName: "AssetGet",
+			Handler:    _Assets_AssetGet_Handler,
+		},
+		{
+			MethodName: "AssetDelete",
+			Handler:    _Assets_AssetDelete_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "AssetPut",
+			Handler:       _Assets_AssetPut_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "rpc.proto",
+}
+
 func init() { proto.RegisterFile("rpc.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 303 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x74, 0x92, 0x41, 0x4e, 0x84, 0x30,
-	0x14, 0x86, 0x65, 0x21, 0x61, 0x6a, 0xdc, 0x74, 0x27, 0x8e, 0x9a, 0x78, 0x00, 0x48, 0xc6, 0x1b,
-	0x68, 0x22, 0x99, 0x64, 0x16, 0x93, 0x71, 0xe3, 0x76, 0x68, 0x9e, 0x0c, 0xc9, 0x40, 0x6b, 0x5f,
-	0x31, 0x9e, 0xc9, 0x23, 0x79, 0x08, 0xcf, 0x60, 0x5a, 0x68, 0xe9, 0x0c, 0x65, 0xc5, 0xcb, 0xff,
-	0xf5, 0x7d, 0xe1, 0x6f, 0x4a, 0x16, 0x52, 0xb0, 0x4c, 0x48, 0xae, 0x38, 0xbd, 0x94, 0x82, 0x89,
-	0x32, 0x7d, 0xae, 0x6a, 0x75, 0xe8, 0xca, 0x8c, 0xf1, 0x26, 0x67, 0x5c, 0x02, 0xc7, 0xbc, 0xd9,
-	0x2b, 0x76, 0x28, 0xf9, 0xf7, 0x38, 0x20, 0xc8, 0x2f, 0x90, 0xc3, 0x47, 0x94, 0x79, 0x03, 0x88,
-	0xfb, 0x0a, 0xb0, 0x57, 0xad, 0x7e, 0x23, 0x12, 0x17, 0x92, 0x77, 0x02, 0xe9, 0x0b, 0x49, 0xcc,
-	0xb4, 0xed, 0x14, 0xbd, 0xc9, 0xec, 0x42, 0x66, 0xb3, 0x1d, 0x7c, 0x76, 0x80, 0x2a, 0x4d, 0x43,
-	0x08, 0x05, 0x6f, 0x11, 0x1e, 0x2f, 0x9c, 0xa4, 0x80, 0xa9, 0xa4, 0x80, 0x59, 0x89, 0x41, 0x4e,
-	0xf2, 0x4a, 0x16, 0x26, 0xdd, 0xd4, 0xa8, 0xe8, 0xf9, 0x51, 0x1d, 0x5a, 0xcd, 0x6d, 0x90, 0x59,
-	0xcf, 0xea, 0x2f, 0x22, 0xc9, 0x56, 0xf2, 0x8f, 0xfa, 0x08, 0x48, 0xd7, 0x84, 0x0c, 0xb3, 0x2e,
-	0xe8, 0x6d, 0x8e, 0xa9, 0xd5, 0x2e, 0xc3, 0xd0, 0xfd, 0xdf, 0xa8, 0xd2, 0x35, 0xa7, 0x2a, 0xaf,
-	0xe8, 0x32, 0x0c, 0x9d, 0x6a, 0x43, 0xae, 0x86, 0xdc, 0x94, 0x9d, 0x1e, 0xf7, 0xeb, 0xde, 0xcd,
-	0x50, 0x57, 0xf8, 0x9d, 0x24, 0xeb, 0xaa, 0xad, 0x55, 0xcd, 0x5b, 0x6d, 0xb6, 0xb3, 0x2e, 0xec,
-	0x99, 0xbd, 0x38, 0x60, 0x3e, 0xa1, 0xce, 0xfc, 0x13, 0x91, 0xf8, 0x0d, 0x8e, 0xc0, 0x94, 0x16,
-	0xf7, 0x93, 0xb9, 0x72, 0x5f, 0xec, 0xc5, 0x01, 0xf1, 0x09, 0x75, 0x17, 0xb0, 0x23, 0xd7, 0x3d,
-	0x18, 0x1a, 0xd1, 0xfb, 0xf3, 0x8d, 0x01, 0x58, 0xe3, 0xc3, 0x2c, 0xb7, 0xce, 0x32, 0x36, 0x6f,
-	0xfb, 0xe9, 0x3f, 0x00, 0x00, 0xff, 0xff, 0xbd, 0xe1, 0xff, 0x95, 0x33, 0x03, 0x00, 0x00,
+	// 924 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x84, 0x57, 0xcd, 0x6e, 0xeb, 0x44,
+	0x14, 0xbe, 0x09, 0x8a, 0xc9, 0x1d, 0x7e, 0x35, 0x57, 0xe2, 0x42, 0xb9, 0x3f, 0x6d, 0x29, 0x12,
+	0xab, 0x14, 0x95, 0x1d, 0x52, 0x17, 0x6d, 0xda, 0x5a, 0x95, 0x8a, 0x88, 0x92, 0x52, 0x90, 0x58,
+	0x39, 0xce, 0xa1, 0x1d, 0xd5, 0x99, 0x31, 0x1e, 0xa7, 0xf0, 0x16, 0xbc, 0x01, 0x5b, 0x24, 0x10,
+	0x4f, 0xc0, 0x8a, 0x17, 0x80, 0x3d, 0x4f, 0x83, 0x3c, 0x9e, 0x19, 0x9f, 0xf9, 0x71, 0xba, 0xea,
+	0xc9, 0xf7, 0x9d, 0xf9, 0x7c, 0xe6, 0x78, 0xbe, 0xe3, 0x29, 0x79, 0x5a, 0x95, 0xf9, 0xa4, 0xac,
+	0x44, 0x2d, 0xe8, 0xa8, 0x2a, 0xf3, 0x72, 0xb9, 0x73, 0x7a, 0xcb, 0xea, 0xbb, 0xcd, 0x72, 0x92,
+	0x8b, 0xf5, 0x61, 0x2e, 0x2a, 0x10, 0xf2, 0x70, 0x9d, 0xd5, 0xf9, 0xdd, 0x52, 0xfc, 0xdc, 0x05,
+	0x12, 0xaa, 0x07, 0xa8, 0xf4, 0x9f, 0x72, 0x79, 0xb8, 0x06, 0x29, 0xb3, 0x5b, 0x90, 0xad, 0xd4,
+	0xd1, 0x7f, 0x03, 0x92, 0xa4, 0x95, 0xd8, 0x94, 0x92, 0x4e, 0xc9, 0x58, 0x45, 0xb3, 0x4d, 0x4d,
+	0x3f, 0x9a, 0x98, 0x05, 0x13, 0x83, 0xcd, 0xe1, 0xc7, 0x0d, 0xc8, 0x7a, 0x67, 0x27, 0x46, 0xc9,
+	0x52, 0x70, 0x09, 0xfb, 0x4f, 0xac, 0x48, 0x0a, 0xa1, 0x48, 0x0a, 0xbd, 0x22, 0x29, 0x60, 0x91,
+	0x0b, 0xf2, 0x54, 0xa1, 0x57, 0x4c, 0xd6, 0xd4, 0x4f, 0x6d, 0x40, 0x23, 0xf3, 0x71, 0x94, 0x33,
+	0x3a, 0x47, 0x7f, 0xbc, 0x41, 0xc6, 0xb3, 0x4a, 0xfc, 0xc0, 0x0a, 0x90, 0xf4, 0x92, 0x10, 0x1d,
+	0x37, 0x1b, 0x44, 0x2b, 0x3b, 0xd4, 0xc8, 0xbe, 0x88, 0x93, 0xb6, 0xbe, 0x4e, 0x2a, 0x85, 0x98,
+	0x54, 0x0a, 0x5b, 0xa4, 0xdc, 0xad, 0x5e, 0x91, 0xb7, 0x34, 0xae, 0x36, 0x1b, 0xa6, 0xe3, 0xed,
+	0xbe, 0xec, 0x61, 0xad, 0x5a, 0x46, 0xa8, 0x26, 0x6e, 0xa0, 0x92, 0x4c, 0x70, 0x25, 0xfa, 0x49,
+	0xb0, 0x0c, 0xb1, 0x46, 0xfb, 0x60, 0x7b, 0x92, 0x7d, 0xc4, 0x77, 0xe4, 0x3d, 0xcd, 0xcf, 0x45,
+	0x51, 0x2c, 0xb3, 0xfc, 0x9e, 0xee, 0x06, 0x4b, 0x0d, 0x65, 0xc4, 0xf7, 0xb6, 0x64, 0xd8, 0xb7,
+	0xf5, 0xcf, 0x90, 0x8c, 0x2f, 0x6f, 0x39, 0xab, 0x99, 0xe0, 0x4d, 0x5f, 0x4c, 0x3c, 0xdb, 0x38,
+	0x7d, 0x41, 0x70, 0xa4, 0x2f, 0x0e, 0x8b, 0xbb, 0x6c, 0x88, 0x14, 0xa2, 0x6a, 0x29, 0x6c, 0x53,
+	0x73, 0xdf, 0xd9, 0xd7, 0xe4, 0x6d, 0x43, 0xa8, 0xfe, 0x46, 0x16, 0xe0, 0xce, 0xbe, 0xea, 0xa3,
+	0xad, 0xe0, 0x37, 0xe4, 0x5d, 0xc3, 0x9c, 0x41, 0x01, 0x35, 0xd0, 0xd7, 0xe1, 0x9a, 0x96, 0x31,
+	0xa2, 0xbb, 0xfd, 0x09, 0xb6, 0xa1, 0xbf, 0x0d, 0xc9, 0x68, 0x5a, 0x88, 0xcd, 0xaa, 0x71, 0xa5,
+	0x0a, 0x3c, 0x6b, 0x1b, 0x2c, 0xe2, 0xca, 0x8e, 0xc2, 0xd6, 0x56, 0xa8, 0x67, 0x6d, 0x83, 0xf5,
+	0x89, 0x04, 0xd6, 0x56, 0xa8, 0x6f, 0x6d, 0x0b, 0x46, 0xac, 0x8d, 0x38, 0xfc, 0x46, 0x15, 0xac,
+	0xfb, 0xf5, 0xc2, 0xcb, 0x76, 0x9b, 0xf5, 0xb2, 0x87, 0xb5, 0x9d, 0xfa, 0x7b, 0x48, 0xde, 0x4c,
+	0x81, 0x43, 0xc5, 0xf2, 0xc6, 0xdc, 0x3a, 0xf4, 0xe6, 0x44, 0x87, 0x46, 0xcc, 0x8d, 0x49, 0x3c,
+	0x27, 0x34, 0xee, 0xcd, 0x89, 0x0e, 0xed, 0x97, 0x0a, 0xe6, 0x84, 0xc6, 0xfd, 0x39, 0x81, 0xe0,
+	0xc8, 0x7e, 0x1d, 0xd6, 0xaa, 0xcd, 0xc9, 0x3b, 0x9a, 0xd0, 0xfd, 0x7b, 0x15, 0xac, 0x70, 0x3b,
+	0xf8, 0xba, 0x97, 0xb7, 0x3d, 0xfc, 0x7d, 0x40, 0x92, 0x05, 0x14, 0x90, 0xd7, 0x4d, 0xb1, 0x6d,
+	0xa4, 0x86, 0x32, 0x2e, 0x16, 0xc1, 0x91, 0x62, 0x1d, 0x16, 0x17, 0xdb, 0x12, 0x7a, 0x74, 0xe0,
+	0x62, 0x1d, 0x22, 0x52, 0xac, 0xc7, 0xdb, 0x62, 0x6f, 0x48, 0x72, 0x2d, 0xee, 0x81, 0xcb, 0xa6,
+	0x56, 0x15, 0x4d, 0x2b, 0xc8, 0xdc, 0x83, 0x84, 0xe0, 0x48, 0xad, 0x0e, 0x6b, 0x75, 0x7f, 0x1d,
+	0x90, 0x64, 0x0e, 0x7c, 0x05, 0x15, 0x3d, 0xb6, 0xd1, 0xf3, 0x6e, 0x55, 0x8b, 0x18, 0xb9, 0x0f,
+	0x43, 0x02, 0xcf, 0x84, 0x16, 0xbb, 0x86, 0x75, 0x59, 0x64, 0xee, 0x4c, 0x70, 0x99, 0xc8, 0x4c,
+	0xf0, 0x13, 0x6c, 0x81, 0xbf, 0x0c, 0x48, 0x72, 0xfe, 0x00, 0xbc, 0x96, 0xf4, 0x98, 0x8c, 0xbe,
+	0x6d, 0x6e, 0x09, 0xf8, 0x60, 0x9e, 0x0a, 0x51, 0xb7, 0xb4, 0x11, 0x7d, 0x16, 0x21, 0xf7, 0x9f,
+	0x7c, 0x3e, 0x68, 0x9c, 0xac, 0x7e, 0xf8, 0x4e, 0xb6, 0x60, 0xc4, 0xc9, 0x88, 0xb3, 0x15, 0xfd,
+	0x9b, 0x90, 0xf1, 0x57, 0x59, 0x7e, 0xc7, 0x78, 0xfb, 0x91, 0xd6, 0xb1, 0x67, 0xbe, 0x0e, 0x8d,
+	0x38, 0x06, 0x93, 0xd8, 0x7c, 0x1a, 0xf7, 0xcc, 0xd7, 0xa1, 0xfd, 0x52, 0x81, 0xf9, 0x34, 0xee,
+	0x9b, 0x0f, 0xc1, 0x91, 0x33, 0xe2, 0xb0, 0x91, 0xc2, 0x66, 0x8c, 0xc7, 0xf6, 0xc8, 0xf8, 0x96,
+	0x3d, 0x32, 0x8e, 0xa4, 0xbe, 0x27, 0xef, 0x6b, 0x7c, 0x0e, 0x8c, 0xcb, 0x3a, 0x2b, 0x0a, 0xba,
+	0x17, 0xac, 0xb1, 0x9c, 0x91, 0xdd, 0xdf, 0x96, 0x82, 0x3f, 0x73, 0x9a, 0x9d, 0x16, 0x19, 0x5b,
+	0xd3, 0x70, 0x63, 0x0a, 0x8f, 0x7c, 0xe6, 0x5c, 0x1a, 0x1f, 0x69, 0xfb, 0xb8, 0x02, 0x32, 0xe9,
+	0x1c, 0x69, 0x97, 0x89, 0x1c, 0x69, 0x3f, 0xc1, 0xca, 0xae, 0xc8, 0x33, 0xcd, 0x9d, 0x41, 0x2e,
+	0xd6, 0x6b, 0x26, 0x9b, 0x6b, 0x0b, 0x3d, 0x08, 0x96, 0x62, 0xda, 0x3c, 0xe0, 0xd3, 0x47, 0xb2,
+	0x22, 0xdd, 0x38, 0x59, 0x89, 0xb2, 0x8e, 0x74, 0x43, 0xe1, 0xfd, 0xdd, 0xd0, 0x34, 0xbe, 0x48,
+	0xd9, 0x27, 0xca, 0xbc, 0x62, 0x4b, 0xa0, 0xbb, 0x91, 0x62, 0x5a, 0x2a, 0x72, 0x91, 0x0a, 0x32,
+	0xac, 0xa3, 0xa6, 0x64, 0x34, 0x13, 0x3f, 0x41, 0x45, 0xbf, 0x34, 0xc1, 0x07, 0xdd, 0x32, 0x05,
+	0x18, 0xb9, 0xe7, 0x01, 0x6e, 0x45, 0xfe, 0x1a, 0x34, 0x87, 0x9e, 0xf1, 0x1a, 0x78, 0xc6, 0x73,
+	0x68, 0x5f, 0x9e, 0xfd, 0xd9, 0x58, 0xca, 0x79, 0x79, 0x98, 0x89, 0xbe, 0x3c, 0x37, 0xc1, 0x3d,
+	0x13, 0x96, 0x5b, 0xf4, 0xca, 0x2e, 0x1e, 0x93, 0x5d, 0x60, 0xd9, 0xa3, 0x3f, 0x87, 0x24, 0x39,
+	0x91, 0x12, 0x6a, 0x49, 0xcf, 0xc9, 0x58, 0x45, 0xde, 0xdd, 0xc7, 0x60, 0x91, 0x6b, 0x4b, 0x47,
+	0x19, 0xbd, 0xcf, 0x06, 0x8d, 0x6b, 0x15, 0x7e, 0x01, 0xde, 0xc8, 0xec, 0xd0, 0x88, 0x6b, 0x31,
+	0x89, 0x2f, 0x52, 0x0a, 0xf7, 0x2e, 0x52, 0x06, 0xeb, 0xab, 0x28, 0x98, 0x49, 0x0a, 0x0d, 0x2f,
+	0x40, 0x08, 0x8e, 0xcc, 0x24, 0x87, 0x35, 0x6a, 0xcb, 0x44, 0xfd, 0x37, 0xf8, 0xc5, 0xff, 0x01,
+	0x00, 0x00, 0xff, 0xff, 0xad, 0x44, 0xb7, 0x01, 0x65, 0x0e, 0x00, 0x00,
 }
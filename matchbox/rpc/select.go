@@ -20,11 +20,13 @@ func newSelectServer(s server.Server) rpcpb.SelectServer {
 }
 
 func (s *selectServer) SelectGroup(ctx context.Context, req *pb.SelectGroupRequest) (*pb.SelectGroupResponse, error) {
+	req = &pb.SelectGroupRequest{Labels: s.srv.MachineLabels(ctx, req.Labels)}
 	group, err := s.srv.SelectGroup(ctx, req)
 	return &pb.SelectGroupResponse{Group: group}, grpcError(err)
 }
 
 func (s *selectServer) SelectProfile(ctx context.Context, req *pb.SelectProfileRequest) (*pb.SelectProfileResponse, error) {
+	req = &pb.SelectProfileRequest{Labels: s.srv.MachineLabels(ctx, req.Labels)}
 	profile, err := s.srv.SelectProfile(ctx, req)
 	return &pb.SelectProfileResponse{Profile: profile}, grpcError(err)
 }
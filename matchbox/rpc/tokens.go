@@ -0,0 +1,25 @@
+package rpc
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/coreos/matchbox/matchbox/rpc/rpcpb"
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// tokenServer takes a matchbox Server and implements a gRPC TokensServer.
+type tokenServer struct {
+	srv server.Server
+}
+
+func newTokenServer(s server.Server) rpcpb.TokensServer {
+	return &tokenServer{
+		srv: s,
+	}
+}
+
+func (s *tokenServer) TokenCreate(ctx context.Context, req *pb.TokenCreateRequest) (*pb.TokenCreateResponse, error) {
+	token, err := s.srv.TokenCreate(ctx, req)
+	return &pb.TokenCreateResponse{Token: token}, grpcError(err)
+}
@@ -0,0 +1,30 @@
+package rpc
+
+import (
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/coreos/matchbox/matchbox/trace"
+)
+
+// Tracing returns an Interceptor which traces unary calls, continuing
+// traces propagated in the traceparent metadata.
+func Tracing() Interceptor {
+	return Interceptor{Unary: tracingUnary}
+}
+
+func tracingUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if md, ok := metadata.FromContext(ctx); ok {
+		if values := md[trace.TraceparentHeader]; len(values) > 0 {
+			if sc, ok := trace.ParseTraceparent(values[0]); ok {
+				ctx = trace.WithRemoteParent(ctx, sc)
+			}
+		}
+	}
+	ctx, span := trace.Start(ctx, info.FullMethod, trace.KindServer)
+	defer span.End()
+	resp, err := handler(ctx, req)
+	span.SetError(err)
+	return resp, err
+}
@@ -0,0 +1,6 @@
+// Package schema validates JSON values, such as Group metadata, against a
+// JSON Schema. The commonly used validation keywords are supported (type,
+// properties, required, additionalProperties, items, enum, const, pattern,
+// length, item count, and numeric bounds) and schemas which use other
+// keywords are rejected, rather than silently not enforced.
+package schema
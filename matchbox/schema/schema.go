@@ -0,0 +1,412 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalidSchema is returned for schemas which aren't valid JSON Schema or
+// use unsupported keywords.
+var ErrInvalidSchema = errors.New("schema: invalid JSON Schema")
+
+// annotations are keywords which don't affect validation.
+var annotations = map[string]bool{
+	"$schema":     true,
+	"$id":         true,
+	"$comment":    true,
+	"title":       true,
+	"description": true,
+	"default":     true,
+	"examples":    true,
+}
+
+// types are the JSON Schema type names.
+var types = map[string]bool{
+	"string":  true,
+	"number":  true,
+	"integer": true,
+	"boolean": true,
+	"object":  true,
+	"array":   true,
+	"null":    true,
+}
+
+// A Schema is a compiled JSON Schema.
+type Schema struct {
+	// false boolean schemas reject every value
+	reject     bool
+	types      []string
+	properties map[string]*Schema
+	required   []string
+	// additional properties are allowed if nil and noAdditional is false
+	additional   *Schema
+	noAdditional bool
+	items        *Schema
+	enum         []interface{}
+	constant     interface{}
+	hasConst     bool
+	pattern      *regexp.Regexp
+	minLength    *int
+	maxLength    *int
+	minItems     *int
+	maxItems     *int
+	minimum      *float64
+	maximum      *float64
+	exclusiveMin *float64
+	exclusiveMax *float64
+}
+
+// Compile parses a JSON Schema.
+func Compile(data []byte) (*Schema, error) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("%v: %v", ErrInvalidSchema, err)
+	}
+	return compile(value, "")
+}
+
+// compile compiles the decoded JSON of a schema at a path.
+func compile(value interface{}, path string) (*Schema, error) {
+	invalid := func(format string, args ...interface{}) error {
+		msg := fmt.Sprintf(format, args...)
+		if path != "" {
+			msg = path + ": " + msg
+		}
+		return fmt.Errorf("%v: %s", ErrInvalidSchema, msg)
+	}
+	switch v := value.(type) {
+	case bool:
+		return &Schema{reject: !v}, nil
+	case map[string]interface{}:
+		s := new(Schema)
+		for _, keyword := range sortedKeys(v) {
+			val := v[keyword]
+			var err error
+			switch keyword {
+			case "type":
+				s.types, err = compileTypes(val)
+			case "properties":
+				props, ok := val.(map[string]interface{})
+				if !ok {
+					return nil, invalid("properties must be an object")
+				}
+				s.properties = make(map[string]*Schema, len(props))
+				for name, prop := range props {
+					if s.properties[name], err = compile(prop, join(path, "properties."+name)); err != nil {
+						return nil, err
+					}
+				}
+			case "required":
+				s.required, err = compileStrings(val)
+			case "additionalProperties":
+				if allowed, ok := val.(bool); ok {
+					s.noAdditional = !allowed
+				} else {
+					s.additional, err = compile(val, join(path, keyword))
+				}
+			case "items":
+				s.items, err = compile(val, join(path, keyword))
+			case "enum":
+				values, ok := val.([]interface{})
+				if !ok || len(values) == 0 {
+					return nil, invalid("enum must be a non-empty array")
+				}
+				s.enum = values
+			case "const":
+				s.constant, s.hasConst = val, true
+			case "pattern":
+				str, ok := val.(string)
+				if !ok {
+					return nil, invalid("pattern must be a string")
+				}
+				if s.pattern, err = regexp.Compile(str); err != nil {
+					return nil, invalid("pattern: %v", err)
+				}
+			case "minLength":
+				s.minLength, err = compileCount(val)
+			case "maxLength":
+				s.maxLength, err = compileCount(val)
+			case "minItems":
+				s.minItems, err = compileCount(val)
+			case "maxItems":
+				s.maxItems, err = compileCount(val)
+			case "minimum":
+				s.minimum, err = compileNumber(val)
+			case "maximum":
+				s.maximum, err = compileNumber(val)
+			case "exclusiveMinimum":
+				s.exclusiveMin, err = compileNumber(val)
+			case "exclusiveMaximum":
+				s.exclusiveMax, err = compileNumber(val)
+			default:
+				if !annotations[keyword] {
+					return nil, invalid("unsupported keyword %q", keyword)
+				}
+			}
+			if err != nil {
+				if strings.HasPrefix(err.Error(), ErrInvalidSchema.Error()) {
+					return nil, err
+				}
+				return nil, invalid("%s %v", keyword, err)
+			}
+		}
+		return s, nil
+	}
+	return nil, invalid("schema must be an object or boolean")
+}
+
+func compileTypes(value interface{}) ([]string, error) {
+	names := []string{}
+	switch v := value.(type) {
+	case string:
+		names = append(names, v)
+	case []interface{}:
+		strs, err := compileStrings(v)
+		if err != nil {
+			return nil, err
+		}
+		names = strs
+	default:
+		return nil, errors.New("must be a type name or an array of type names")
+	}
+	for _, name := range names {
+		if !types[name] {
+			return nil, fmt.Errorf("has unknown type %q", name)
+		}
+	}
+	return names, nil
+}
+
+func compileStrings(value interface{}) ([]string, error) {
+	values, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.New("must be an array of strings")
+	}
+	strs := make([]string, len(values))
+	for i, val := range values {
+		if strs[i], ok = val.(string); !ok {
+			return nil, errors.New("must be an array of strings")
+		}
+	}
+	return strs, nil
+}
+
+func compileCount(value interface{}) (*int, error) {
+	n, ok := value.(float64)
+	if !ok || n < 0 || n != math.Trunc(n) {
+		return nil, errors.New("must be a non-negative integer")
+	}
+	count := int(n)
+	return &count, nil
+}
+
+func compileNumber(value interface{}) (*float64, error) {
+	n, ok := value.(float64)
+	if !ok {
+		return nil, errors.New("must be a number")
+	}
+	return &n, nil
+}
+
+// A Violation is a value which doesn't satisfy a schema.
+type Violation struct {
+	// Path of the value (e.g. etcd.nodes[0].ip), empty for the root
+	Path    string
+	Message string
+}
+
+func (v *Violation) String() string {
+	if v.Path == "" {
+		return v.Message
+	}
+	return v.Path + ": " + v.Message
+}
+
+// A ValidationError lists the violations of a schema by a value.
+type ValidationError struct {
+	Violations []*Violation
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.String()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validate returns a ValidationError listing every violation of the schema
+// by a decoded JSON value, or nil if the value is valid.
+func (s *Schema) Validate(value interface{}) error {
+	var violations []*Violation
+	s.validate(value, "", &violations)
+	if len(violations) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: violations}
+}
+
+func (s *Schema) validate(value interface{}, path string, violations *[]*Violation) {
+	add := func(format string, args ...interface{}) {
+		*violations = append(*violations, &Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if s.reject {
+		add("is not allowed")
+		return
+	}
+	if len(s.types) > 0 && !hasType(value, s.types) {
+		add("must be %s, not %s", article(s.types), typeOf(value))
+		return
+	}
+	if len(s.enum) > 0 && !contains(s.enum, value) {
+		add("must be one of %s", formatValues(s.enum))
+	}
+	if s.hasConst && !reflect.DeepEqual(s.constant, value) {
+		add("must be %s", formatValues([]interface{}{s.constant}))
+	}
+	switch v := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.minLength != nil && length < *s.minLength {
+			add("must be at least %d characters", *s.minLength)
+		}
+		if s.maxLength != nil && length > *s.maxLength {
+			add("must be at most %d characters", *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			add("must match pattern %q", s.pattern.String())
+		}
+	case float64:
+		if s.minimum != nil && v < *s.minimum {
+			add("must be at least %v", *s.minimum)
+		}
+		if s.maximum != nil && v > *s.maximum {
+			add("must be at most %v", *s.maximum)
+		}
+		if s.exclusiveMin != nil && v <= *s.exclusiveMin {
+			add("must be greater than %v", *s.exclusiveMin)
+		}
+		if s.exclusiveMax != nil && v >= *s.exclusiveMax {
+			add("must be less than %v", *s.exclusiveMax)
+		}
+	case []interface{}:
+		if s.minItems != nil && len(v) < *s.minItems {
+			add("must have at least %d items", *s.minItems)
+		}
+		if s.maxItems != nil && len(v) > *s.maxItems {
+			add("must have at most %d items", *s.maxItems)
+		}
+		if s.items != nil {
+			for i, item := range v {
+				s.items.validate(item, path+"["+strconv.Itoa(i)+"]", violations)
+			}
+		}
+	case map[string]interface{}:
+		for _, key := range s.required {
+			if _, ok := v[key]; !ok {
+				add("missing required key %q", key)
+			}
+		}
+		for _, key := range sortedKeys(v) {
+			if prop, ok := s.properties[key]; ok {
+				prop.validate(v[key], join(path, key), violations)
+			} else if s.noAdditional {
+				add("unexpected key %q", key)
+			} else if s.additional != nil {
+				s.additional.validate(v[key], join(path, key), violations)
+			}
+		}
+	}
+}
+
+// typeOf returns the JSON Schema type name of a decoded JSON value.
+func typeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+// hasType returns true if a value is any of the types. Integers are
+// numbers too.
+func hasType(value interface{}, names []string) bool {
+	valueType := typeOf(value)
+	for _, name := range names {
+		if name == valueType || (name == "number" && valueType == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// article describes types (e.g. "a string or null").
+func article(names []string) string {
+	described := make([]string, len(names))
+	for i, name := range names {
+		switch name {
+		case "null":
+			described[i] = name
+		case "integer", "object", "array":
+			described[i] = "an " + name
+		default:
+			described[i] = "a " + name
+		}
+	}
+	return strings.Join(described, " or ")
+}
+
+func contains(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatValues formats values as comma separated JSON.
+func formatValues(values []interface{}) string {
+	strs := make([]string, len(values))
+	for i, v := range values {
+		data, _ := json.Marshal(v)
+		strs[i] = string(data)
+	}
+	return strings.Join(strs, ", ")
+}
+
+// join appends a key to a dotted path.
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,97 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompile(t *testing.T) {
+	cases := []struct {
+		schema string
+		err    string
+	}{
+		{`{}`, ""},
+		{`true`, ""},
+		{`{"$schema": "http://json-schema.org/draft-07/schema#", "title": "etcd", "type": ["string", "null"]}`, ""},
+		{`{"properties": {"etcd": {"properties": {"nodes": {"items": {"pattern": "^node[0-9]+$"}}}}}}`, ""},
+		{`[]`, "schema: invalid JSON Schema: schema must be an object or boolean"},
+		{`{"type": "text"}`, `schema: invalid JSON Schema: type has unknown type "text"`},
+		{`{"required": "etcd_name"}`, "schema: invalid JSON Schema: required must be an array of strings"},
+		{`{"minLength": -1}`, "schema: invalid JSON Schema: minLength must be a non-negative integer"},
+		{`{"enum": []}`, "schema: invalid JSON Schema: enum must be a non-empty array"},
+		{`{"pattern": "("}`, "schema: invalid JSON Schema: pattern: error parsing regexp: missing closing ): `(`"},
+		{`{"properties": {"etcd": {"oneOf": []}}}`, `schema: invalid JSON Schema: properties.etcd: unsupported keyword "oneOf"`},
+		{`{"properties": {"etcd": {"items": {"maximum": "5"}}}}`, "schema: invalid JSON Schema: properties.etcd.items: maximum must be a number"},
+	}
+	// assert that:
+	// - supported keywords and annotations compile
+	// - malformed and unsupported keywords are errors at their path
+	for _, c := range cases {
+		_, err := Compile([]byte(c.schema))
+		if c.err == "" {
+			assert.Nil(t, err, c.schema)
+		} else {
+			assert.EqualError(t, err, c.err, c.schema)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s, err := Compile([]byte(`{
+		"type": "object",
+		"required": ["etcd_name", "nodes"],
+		"properties": {
+			"etcd_name": {"type": "string", "pattern": "^node[0-9]+$", "maxLength": 8},
+			"channel": {"enum": ["stable", "beta", "alpha"]},
+			"replicas": {"type": "integer", "minimum": 1, "exclusiveMaximum": 8},
+			"nodes": {"type": "array", "minItems": 1, "items": {"type": "string", "minLength": 1}},
+			"ssh": {"type": "object", "additionalProperties": false, "properties": {"enabled": {"const": true}}}
+		},
+		"additionalProperties": {"type": ["string", "number"]}
+	}`))
+	if !assert.Nil(t, err) {
+		return
+	}
+	cases := []struct {
+		value      string
+		violations []string
+	}{
+		{`{"etcd_name": "node1", "nodes": ["node1"], "replicas": 3, "pod_network": "10.2.0.0/16"}`, nil},
+		{`[]`, []string{"must be an object, not array"}},
+		{`{}`, []string{`missing required key "etcd_name"`, `missing required key "nodes"`}},
+		{`{"etcd_name": 1, "nodes": []}`, []string{"etcd_name: must be a string, not integer", "nodes: must have at least 1 items"}},
+		{`{"etcd_name": "etcd-node1", "nodes": [""]}`, []string{"etcd_name: must be at most 8 characters", `etcd_name: must match pattern "^node[0-9]+$"`, "nodes[0]: must be at least 1 characters"}},
+		{`{"etcd_name": "node1", "nodes": ["node1"], "channel": "edge", "replicas": 8}`, []string{`channel: must be one of "stable", "beta", "alpha"`, "replicas: must be less than 8"}},
+		{`{"etcd_name": "node1", "nodes": ["node1"], "replicas": 0.5}`, []string{"replicas: must be an integer, not number"}},
+		{`{"etcd_name": "node1", "nodes": ["node1"], "ssh": {"enabled": false, "keys": []}}`, []string{"ssh.enabled: must be true", `ssh: unexpected key "keys"`}},
+		{`{"etcd_name": "node1", "nodes": ["node1"], "debug": true}`, []string{"debug: must be a string or a number, not boolean"}},
+	}
+	// assert that:
+	// - valid values have no violations
+	// - every violation is reported, in a deterministic order, at its path
+	for _, c := range cases {
+		var value interface{}
+		assert.Nil(t, json.Unmarshal([]byte(c.value), &value))
+		err := s.Validate(value)
+		if c.violations == nil {
+			assert.Nil(t, err, c.value)
+			continue
+		}
+		if verr, ok := err.(*ValidationError); assert.True(t, ok, c.value) {
+			var violations []string
+			for _, v := range verr.Violations {
+				violations = append(violations, v.String())
+			}
+			assert.Equal(t, c.violations, violations, c.value)
+		}
+	}
+}
+
+func TestValidate_BooleanSchemas(t *testing.T) {
+	s, _ := Compile([]byte(`{"properties": {"legacy": false, "any": true}}`))
+	// assert that false schemas reject any value and true schemas accept any
+	assert.EqualError(t, s.Validate(map[string]interface{}{"legacy": "x", "any": 1.0}), "legacy: is not allowed")
+	assert.Nil(t, s.Validate(map[string]interface{}{"any": []interface{}{}}))
+}
@@ -0,0 +1,3 @@
+// Package sealed decrypts encrypted Group metadata values at render time, so
+// secrets in Group files are never stored in plaintext.
+package sealed
@@ -0,0 +1,270 @@
+package sealed
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"context"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	"github.com/coreos/matchbox/matchbox/vault"
+)
+
+// Encrypted value prefixes
+const (
+	// agePrefix precedes a base64 encoded age ciphertext
+	agePrefix = "age:"
+	// ageArmor begins an ASCII armored age ciphertext
+	ageArmor = "-----BEGIN AGE ENCRYPTED FILE-----"
+	// transitPrefix precedes a Vault Transit key name and ciphertext
+	// (e.g. "transit:matchbox:vault:v1:...")
+	transitPrefix = "transit:"
+)
+
+// Possible decryption errors
+var (
+	ErrAgeDisabled        = errors.New("sealed: age encrypted value, but no age identity is configured")
+	ErrTransitDisabled    = errors.New("sealed: transit encrypted value, but Vault Transit is not configured")
+	ErrInvalidTransitText = errors.New("sealed: transit values must be transit:KEY:CIPHERTEXT")
+)
+
+// DefaultAgeCommand is the age command used to decrypt age values.
+const DefaultAgeCommand = "age"
+
+// DefaultCacheSize is the number of plaintexts a Decrypter caches if none
+// is configured.
+const DefaultCacheSize = 1000
+
+// Config configures a Decrypter.
+type Config struct {
+	// (optional) path to an age identity file to decrypt age values
+	AgeIdentity string
+	// (optional) age command (defaults to "age")
+	AgeCommand string
+	// (optional) Vault Transit to decrypt transit values
+	Transit *vault.Transit
+	// (optional) number of plaintexts cached (defaults to DefaultCacheSize)
+	CacheSize int
+}
+
+// Decrypter decrypts encrypted Group metadata values. Decrypted values are
+// kept in memory only. All methods are safe to call on a nil Decrypter,
+// which leaves values as they are.
+type Decrypter struct {
+	ageIdentity string
+	ageCommand  string
+	transit     *vault.Transit
+
+	mu sync.Mutex
+	// plaintexts by encrypted value, since ciphertexts are immutable
+	cache     map[string]*list.Element
+	cacheSize int
+	// cached values, most recently used first
+	order *list.List
+}
+
+// cacheEntry is a cached plaintext and its encrypted value.
+type cacheEntry struct {
+	value     string
+	plaintext string
+}
+
+// NewDecrypter returns a new Decrypter.
+func NewDecrypter(config *Config) *Decrypter {
+	d := &Decrypter{
+		ageIdentity: config.AgeIdentity,
+		ageCommand:  config.AgeCommand,
+		transit:     config.Transit,
+		cache:       make(map[string]*list.Element),
+		cacheSize:   config.CacheSize,
+		order:       list.New(),
+	}
+	if d.ageCommand == "" {
+		d.ageCommand = DefaultAgeCommand
+	}
+	if d.cacheSize <= 0 {
+		d.cacheSize = DefaultCacheSize
+	}
+	return d
+}
+
+// IsEncrypted returns true if a metadata value is encrypted.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, agePrefix) ||
+		strings.HasPrefix(value, ageArmor) ||
+		strings.HasPrefix(value, transitPrefix)
+}
+
+// MayBeEncrypted returns false if JSON metadata has no encrypted values,
+// to skip decoding the metadata of most Groups.
+func MayBeEncrypted(metadata []byte) bool {
+	for _, prefix := range []string{agePrefix, ageArmor, transitPrefix} {
+		if bytes.Contains(metadata, []byte(`"`+prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Unseal returns the Group with its encrypted metadata values decrypted. The
+// Group is returned as is if it has no encrypted values, otherwise a copy is
+// returned so the stored Group keeps its ciphertexts.
+func (d *Decrypter) Unseal(ctx context.Context, group *storagepb.Group) (*storagepb.Group, error) {
+	if d == nil || !MayBeEncrypted(group.Metadata) {
+		return group, nil
+	}
+	var metadata map[string]interface{}
+	if err := json.Unmarshal(group.Metadata, &metadata); err != nil {
+		return nil, err
+	}
+	changed, err := d.walk(ctx, metadata)
+	if err != nil {
+		return nil, fmt.Errorf("group %s: %v", group.Id, err)
+	}
+	if !changed {
+		return group, nil
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+	unsealed := group.Copy()
+	unsealed.Metadata = data
+	return unsealed, nil
+}
+
+// walk decrypts the encrypted string values of nested objects and arrays in
+// place and returns true if any were decrypted.
+func (d *Decrypter) walk(ctx context.Context, value interface{}) (bool, error) {
+	changed := false
+	decrypt := func(v interface{}, set func(string)) error {
+		if s, ok := v.(string); ok {
+			if !IsEncrypted(s) {
+				return nil
+			}
+			plaintext, err := d.Decrypt(ctx, s)
+			if err != nil {
+				return err
+			}
+			set(plaintext)
+			changed = true
+			return nil
+		}
+		nested, err := d.walk(ctx, v)
+		changed = changed || nested
+		return err
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, elem := range v {
+			key := key
+			if err := decrypt(elem, func(s string) { v[key] = s }); err != nil {
+				return changed, fmt.Errorf("%s: %v", key, err)
+			}
+		}
+	case []interface{}:
+		for i, elem := range v {
+			i := i
+			if err := decrypt(elem, func(s string) { v[i] = s }); err != nil {
+				return changed, err
+			}
+		}
+	}
+	return changed, nil
+}
+
+// Decrypt returns the plaintext of an encrypted value.
+func (d *Decrypter) Decrypt(ctx context.Context, value string) (string, error) {
+	if plaintext, ok := d.cached(value); ok {
+		return plaintext, nil
+	}
+
+	var data []byte
+	var err error
+	switch {
+	case strings.HasPrefix(value, ageArmor):
+		data, err = d.decryptAge(ctx, []byte(value))
+	case strings.HasPrefix(value, agePrefix):
+		var ciphertext []byte
+		ciphertext, err = base64.StdEncoding.DecodeString(strings.TrimPrefix(value, agePrefix))
+		if err == nil {
+			data, err = d.decryptAge(ctx, ciphertext)
+		}
+	case strings.HasPrefix(value, transitPrefix):
+		data, err = d.decryptTransit(ctx, strings.TrimPrefix(value, transitPrefix))
+	default:
+		return value, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	plaintext := string(data)
+	d.store(value, plaintext)
+	return plaintext, nil
+}
+
+// cached returns the cached plaintext of an encrypted value.
+func (d *Decrypter) cached(value string) (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	elem, ok := d.cache[value]
+	if !ok {
+		return "", false
+	}
+	d.order.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).plaintext, true
+}
+
+// store caches the plaintext of an encrypted value, evicting the least
+// recently used plaintexts beyond the cache size, so values of Groups which
+// were changed or deleted aren't kept in memory indefinitely.
+func (d *Decrypter) store(value, plaintext string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if elem, ok := d.cache[value]; ok {
+		d.order.MoveToFront(elem)
+		return
+	}
+	d.cache[value] = d.order.PushFront(&cacheEntry{value: value, plaintext: plaintext})
+	for d.order.Len() > d.cacheSize {
+		oldest := d.order.Back()
+		d.order.Remove(oldest)
+		delete(d.cache, oldest.Value.(*cacheEntry).value)
+	}
+}
+
+// decryptAge decrypts an age ciphertext with the age command.
+func (d *Decrypter) decryptAge(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	if d.ageIdentity == "" {
+		return nil, ErrAgeDisabled
+	}
+	cmd := exec.CommandContext(ctx, d.ageCommand, "--decrypt", "--identity", d.ageIdentity)
+	cmd.Stdin = bytes.NewReader(ciphertext)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("sealed: age: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// decryptTransit decrypts a "KEY:CIPHERTEXT" value with Vault Transit.
+func (d *Decrypter) decryptTransit(ctx context.Context, value string) ([]byte, error) {
+	if d.transit == nil {
+		return nil, ErrTransitDisabled
+	}
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, ErrInvalidTransitText
+	}
+	return d.transit.Decrypt(ctx, parts[0], parts[1])
+}
@@ -0,0 +1,129 @@
+package sealed
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"context"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	"github.com/coreos/matchbox/matchbox/vault"
+)
+
+// fakeAge writes an age command which "decrypts" by echoing its input.
+func fakeAge(t *testing.T, dir string) string {
+	path := filepath.Join(dir, "age")
+	script := "#!/bin/sh\n[ \"$1 $2\" = \"--decrypt --identity\" ] || { echo bad args >&2; exit 1; }\ncat\n"
+	assert.Nil(t, ioutil.WriteFile(path, []byte(script), 0755))
+	return path
+}
+
+func TestUnseal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "matchbox-sealed")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// base64 of "kms-secret"
+		w.Write([]byte(`{"data": {"plaintext": "a21zLXNlY3JldA=="}}`))
+	}))
+	defer ts.Close()
+	d := NewDecrypter(&Config{
+		AgeIdentity: filepath.Join(dir, "identity.txt"),
+		AgeCommand:  fakeAge(t, dir),
+		Transit:     vault.NewTransit(&vault.TransitConfig{Address: ts.URL}),
+	})
+
+	metadata := map[string]interface{}{
+		"plain": "value",
+		"age":   "age:" + base64.StdEncoding.EncodeToString([]byte("age-secret")),
+		"nested": map[string]interface{}{
+			"list": []interface{}{"transit:matchbox:vault:v1:abcd", 3},
+		},
+	}
+	data, _ := json.Marshal(metadata)
+	group := &storagepb.Group{Id: "node1", Metadata: data}
+
+	// assert that:
+	// - encrypted values (including nested) are decrypted
+	// - the stored Group keeps its ciphertexts
+	unsealed, err := d.Unseal(context.Background(), group)
+	if assert.Nil(t, err) {
+		assert.JSONEq(t, `{"plain":"value","age":"age-secret","nested":{"list":["kms-secret",3]}}`, string(unsealed.Metadata))
+		assert.Equal(t, data, group.Metadata)
+	}
+	// - Groups without encrypted values are returned as is
+	plain := &storagepb.Group{Id: "node2", Metadata: []byte(`{"a":"b"}`)}
+	unsealed, err = d.Unseal(context.Background(), plain)
+	assert.Nil(t, err)
+	assert.True(t, plain == unsealed)
+	// - nil Decrypters leave values as they are
+	var none *Decrypter
+	unsealed, err = none.Unseal(context.Background(), group)
+	assert.Nil(t, err)
+	assert.True(t, group == unsealed)
+}
+
+func TestDecrypt(t *testing.T) {
+	d := NewDecrypter(&Config{})
+	cases := []struct {
+		value string
+		err   error
+	}{
+		{"age:YWJj", ErrAgeDisabled},
+		{"-----BEGIN AGE ENCRYPTED FILE-----\nYWJj\n-----END AGE ENCRYPTED FILE-----\n", ErrAgeDisabled},
+		{"transit:matchbox:vault:v1:abcd", ErrTransitDisabled},
+	}
+	// assert that values fail to decrypt without their decryption method
+	for _, c := range cases {
+		_, err := d.Decrypt(context.Background(), c.value)
+		assert.Equal(t, c.err, err)
+	}
+
+	d = NewDecrypter(&Config{Transit: vault.NewTransit(&vault.TransitConfig{Address: "http://127.0.0.1:0"})})
+	_, err := d.Decrypt(context.Background(), "transit:vault:v1")
+	assert.Error(t, err)
+	_, err = d.Decrypt(context.Background(), "transit:matchbox")
+	assert.Equal(t, ErrInvalidTransitText, err)
+	assert.True(t, IsEncrypted("age:YWJj"))
+	assert.False(t, IsEncrypted("plain"))
+}
+
+func TestDecrypt_Cache(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		// base64 of "kms-secret"
+		w.Write([]byte(`{"data": {"plaintext": "a21zLXNlY3JldA=="}}`))
+	}))
+	defer ts.Close()
+	d := NewDecrypter(&Config{
+		Transit:   vault.NewTransit(&vault.TransitConfig{Address: ts.URL}),
+		CacheSize: 2,
+	})
+	decrypt := func(value string) {
+		plaintext, err := d.Decrypt(context.Background(), value)
+		assert.Nil(t, err)
+		assert.Equal(t, "kms-secret", plaintext)
+	}
+
+	// assert that:
+	// - plaintexts are cached
+	// - the least recently used plaintexts are evicted beyond the cache size
+	decrypt("transit:matchbox:vault:v1:a")
+	decrypt("transit:matchbox:vault:v1:b")
+	decrypt("transit:matchbox:vault:v1:a")
+	assert.Equal(t, 2, requests)
+	decrypt("transit:matchbox:vault:v1:c")
+	assert.Equal(t, 2, d.order.Len())
+	decrypt("transit:matchbox:vault:v1:a")
+	assert.Equal(t, 3, requests)
+	decrypt("transit:matchbox:vault:v1:b")
+	assert.Equal(t, 4, requests)
+}
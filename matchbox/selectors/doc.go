@@ -0,0 +1,4 @@
+// Package selectors is a registry of the selector keys Groups may use and
+// the formats of their values, to reject selectors which could never match
+// (e.g. a misspelled key) when Groups are written.
+package selectors
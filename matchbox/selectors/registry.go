@@ -0,0 +1,278 @@
+package selectors
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Selector value types
+const (
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeMAC     = "mac"
+	TypeUUID    = "uuid"
+	// TypeIP is an IP address or a CIDR network
+	TypeIP = "ip"
+)
+
+// Possible validation errors
+var (
+	ErrUndeclaredKey = errors.New("selector key is not declared")
+	ErrInvalidValue  = errors.New("selector value is invalid")
+	ErrInvalidType   = errors.New("type must be string, integer, mac, uuid, or ip")
+)
+
+// uuidRegexp matches UUIDs, in any case.
+var uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Builtin are the selector keys of the labels matchbox reads from requests
+// or sets itself, which are always declared.
+var Builtin = map[string]*Key{
+	"uuid":         {Type: TypeUUID},
+	"mac":          {Type: TypeMAC},
+	"hostname":     {Type: TypeString},
+	"serial":       {Type: TypeString},
+	"asset":        {Type: TypeString},
+	"manufacturer": {Type: TypeString},
+	"product":      {Type: TypeString},
+	"platform":     {Type: TypeString},
+	"buildarch":    {Type: TypeString},
+	"arch":         {Type: TypeString},
+	"ip":           {Type: TypeIP},
+	"source_ip":    {Type: TypeIP},
+}
+
+// A Key is a declared selector key.
+type Key struct {
+	// Type of values, which defaults to string
+	Type string `yaml:"type"`
+	// (optional) regular expression values must match
+	Format string `yaml:"format"`
+
+	format *regexp.Regexp
+}
+
+// compile validates the Key and compiles its format.
+func (k *Key) compile() error {
+	switch k.Type {
+	case "":
+		k.Type = TypeString
+	case TypeString, TypeInteger, TypeMAC, TypeUUID, TypeIP:
+	default:
+		return ErrInvalidType
+	}
+	if k.Format != "" {
+		format, err := regexp.Compile(k.Format)
+		if err != nil {
+			return fmt.Errorf("invalid format %q: %v", k.Format, err)
+		}
+		k.format = format
+	}
+	return nil
+}
+
+// validate returns ErrInvalidValue if a selector value isn't of the Key's
+// type or doesn't match its format.
+func (k *Key) validate(value string) error {
+	var valid bool
+	switch k.Type {
+	case TypeInteger:
+		_, err := strconv.ParseInt(value, 10, 64)
+		valid = err == nil
+	case TypeMAC:
+		_, err := net.ParseMAC(value)
+		valid = err == nil
+	case TypeUUID:
+		valid = uuidRegexp.MatchString(value)
+	case TypeIP:
+		_, _, err := net.ParseCIDR(value)
+		valid = err == nil || net.ParseIP(value) != nil
+	default:
+		valid = true
+	}
+	if valid && k.format != nil {
+		valid = k.format.MatchString(value)
+	}
+	if !valid {
+		return ErrInvalidValue
+	}
+	return nil
+}
+
+// describe describes the values of the Key.
+func (k *Key) describe() string {
+	var kind string
+	switch k.Type {
+	case TypeInteger:
+		kind = "an integer"
+	case TypeMAC:
+		kind = "a MAC address"
+	case TypeUUID:
+		kind = "a UUID"
+	case TypeIP:
+		kind = "an IP address or CIDR"
+	default:
+		kind = "a string"
+	}
+	if k.Format != "" {
+		return fmt.Sprintf("%s matching %s", kind, k.Format)
+	}
+	return kind
+}
+
+// Error is a selector of a Group which isn't declared or whose value is
+// invalid.
+type Error struct {
+	Group string
+	Key   string
+	Value string
+	Err   error
+	// (optional) declared key an undeclared key differs from only in case
+	Similar string
+	// description of the valid values of an invalid value
+	Want string
+}
+
+func (e *Error) Error() string {
+	if e.Err == ErrUndeclaredKey {
+		msg := fmt.Sprintf("group %q selector key %q is not declared", e.Group, e.Key)
+		if e.Similar != "" {
+			msg += fmt.Sprintf(" (did you mean %q?)", e.Similar)
+		}
+		return msg
+	}
+	return fmt.Sprintf("group %q selector %s value %q must be %s", e.Group, e.Key, e.Value, e.Want)
+}
+
+// file is the format of a registry file.
+type file struct {
+	Keys map[string]*Key `yaml:"keys"`
+}
+
+// Registry is the set of declared selector keys, the built-in keys and
+// custom keys loaded from a YAML or JSON file, which can be read again when
+// it changes.
+type Registry struct {
+	path string
+
+	mu   sync.RWMutex
+	keys map[string]*Key
+}
+
+// Load reads the registry file at path.
+func Load(path string) (*Registry, error) {
+	keys, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Registry{path: path, keys: keys}, nil
+}
+
+// New returns a Registry of the built-in keys and the given custom keys,
+// which isn't backed by a file.
+func New(custom map[string]*Key) (*Registry, error) {
+	keys, err := declare(custom)
+	if err != nil {
+		return nil, err
+	}
+	return &Registry{keys: keys}, nil
+}
+
+// Path returns the path of the registry file.
+func (r *Registry) Path() string {
+	return r.path
+}
+
+// Reload reads the registry file again. If it's invalid, the previous keys
+// are kept.
+func (r *Registry) Reload() error {
+	keys, err := readFile(r.path)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys = keys
+	return nil
+}
+
+// Validate returns an Error if a selector of a Group uses an undeclared key
+// or a value invalid for its key. Keys are case sensitive. A nil Registry
+// accepts any selectors.
+func (r *Registry) Validate(group string, selector map[string]string) error {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(selector))
+	for name := range selector {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := selector[name]
+		key, ok := r.keys[name]
+		if !ok {
+			return &Error{Group: group, Key: name, Value: value, Err: ErrUndeclaredKey, Similar: r.similar(name)}
+		}
+		if err := key.validate(value); err != nil {
+			return &Error{Group: group, Key: name, Value: value, Err: err, Want: key.describe()}
+		}
+	}
+	return nil
+}
+
+// similar returns the declared key which differs from the name only in
+// case, if any.
+func (r *Registry) similar(name string) string {
+	for declared := range r.keys {
+		if strings.EqualFold(declared, name) {
+			return declared
+		}
+	}
+	return ""
+}
+
+// declare returns the built-in keys and the custom keys, which may redeclare
+// built-in keys to restrict their values.
+func declare(custom map[string]*Key) (map[string]*Key, error) {
+	keys := make(map[string]*Key, len(Builtin)+len(custom))
+	for name, key := range Builtin {
+		keys[name] = key
+	}
+	for name, key := range custom {
+		if key == nil {
+			key = &Key{}
+		}
+		declared := *key
+		if err := declared.compile(); err != nil {
+			return nil, fmt.Errorf("selectors: key %s: %v", name, err)
+		}
+		keys[name] = &declared
+	}
+	return keys, nil
+}
+
+// readFile reads and parses the registry file at path, as YAML (of which
+// JSON is a subset).
+func readFile(path string) (map[string]*Key, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f := new(file)
+	if err := yaml.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("selectors: %s: %v", path, err)
+	}
+	return declare(f.Keys)
+}
@@ -0,0 +1,85 @@
+package selectors
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	registry, err := New(map[string]*Key{
+		"rack":       {Format: `^r[0-9]+$`},
+		"memory_gib": {Type: TypeInteger},
+		"hostname":   {Format: `^[a-z0-9-]+$`},
+		"role":       nil,
+	})
+	assert.Nil(t, err)
+	cases := []struct {
+		selector map[string]string
+		err      error
+	}{
+		{map[string]string{"mac": "52:54:00:89:d8:10", "uuid": "1CD1C8E4-4E4B-4A5E-9F3D-2E5C1B0A7F6D"}, nil},
+		{map[string]string{"source_ip": "10.0.0.0/8", "ip": "2001:db8::1"}, nil},
+		{map[string]string{"rack": "r12", "memory_gib": "64", "hostname": "node1", "role": "worker"}, nil},
+		{map[string]string{}, nil},
+		{map[string]string{"maC": "52:54:00:89:d8:10"}, &Error{Group: "g", Key: "maC", Value: "52:54:00:89:d8:10", Err: ErrUndeclaredKey, Similar: "mac"}},
+		{map[string]string{"site": "sfo"}, &Error{Group: "g", Key: "site", Value: "sfo", Err: ErrUndeclaredKey}},
+		{map[string]string{"mac": "52:54:00:89:d8"}, &Error{Group: "g", Key: "mac", Value: "52:54:00:89:d8", Err: ErrInvalidValue, Want: "a MAC address"}},
+		{map[string]string{"uuid": "a1b2c3d4"}, &Error{Group: "g", Key: "uuid", Value: "a1b2c3d4", Err: ErrInvalidValue, Want: "a UUID"}},
+		{map[string]string{"source_ip": "10.0.0"}, &Error{Group: "g", Key: "source_ip", Value: "10.0.0", Err: ErrInvalidValue, Want: "an IP address or CIDR"}},
+		{map[string]string{"memory_gib": "64GiB"}, &Error{Group: "g", Key: "memory_gib", Value: "64GiB", Err: ErrInvalidValue, Want: "an integer"}},
+		{map[string]string{"rack": "12"}, &Error{Group: "g", Key: "rack", Value: "12", Err: ErrInvalidValue, Want: "a string matching ^r[0-9]+$"}},
+		{map[string]string{"hostname": "Node1"}, &Error{Group: "g", Key: "hostname", Value: "Node1", Err: ErrInvalidValue, Want: "a string matching ^[a-z0-9-]+$"}},
+	}
+	// assert that:
+	// - built-in and custom keys are declared, case sensitively
+	// - values must be of the key's type and match its format
+	for _, c := range cases {
+		assert.Equal(t, c.err, registry.Validate("g", c.selector))
+	}
+	assert.Equal(t, `group "g" selector key "maC" is not declared (did you mean "mac"?)`, registry.Validate("g", map[string]string{"maC": "52:54:00:89:d8:10"}).Error())
+	assert.Equal(t, `group "g" selector uuid value "a1b2c3d4" must be a UUID`, registry.Validate("g", map[string]string{"uuid": "a1b2c3d4"}).Error())
+
+	// - a nil Registry accepts any selectors
+	var none *Registry
+	assert.Nil(t, none.Validate("g", map[string]string{"maC": "any"}))
+}
+
+func TestNew_Invalid(t *testing.T) {
+	// assert that unknown types and invalid formats are errors
+	_, err := New(map[string]*Key{"rack": {Type: "rack"}})
+	assert.EqualError(t, err, "selectors: key rack: type must be string, integer, mac, uuid, or ip")
+	_, err = New(map[string]*Key{"rack": {Format: "r[0-9"}})
+	assert.NotNil(t, err)
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "matchbox-selectors")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "selectors.yaml")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("keys:\n  rack:\n    format: ^r[0-9]+$\n"), 0644))
+
+	registry, err := Load(path)
+	assert.Nil(t, err)
+	assert.Equal(t, path, registry.Path())
+	// assert that:
+	// - custom keys are loaded from YAML
+	// - invalid files aren't reloaded, the previous keys are kept
+	// - valid files are reloaded
+	assert.Nil(t, registry.Validate("g", map[string]string{"rack": "r1"}))
+	assert.Nil(t, ioutil.WriteFile(path, []byte("keys:\n  rack:\n    type: rack\n"), 0644))
+	assert.NotNil(t, registry.Reload())
+	assert.Nil(t, registry.Validate("g", map[string]string{"rack": "r1"}))
+	assert.Nil(t, ioutil.WriteFile(path, []byte(`{"keys": {"site": {}}}`), 0644))
+	assert.Nil(t, registry.Reload())
+	assert.Nil(t, registry.Validate("g", map[string]string{"site": "sfo"}))
+	assert.IsType(t, &Error{}, registry.Validate("g", map[string]string{"rack": "r1"}))
+
+	// - missing files are errors
+	_, err = Load(filepath.Join(dir, "missing.yaml"))
+	assert.NotNil(t, err)
+}
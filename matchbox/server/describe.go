@@ -0,0 +1,53 @@
+package server
+
+import (
+	"context"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// How the Group of a machine was selected
+const (
+	// MatchPinned is a Group the machine is pinned to
+	MatchPinned = "pinned"
+	// MatchExternal is a Group chosen by the external matcher
+	MatchExternal = "external"
+	// MatchSelector is a Group whose selectors match the machine's labels
+	MatchSelector = "selector"
+	// MatchDecommission serves the decommission Profile of the machine
+	MatchDecommission = "decommission"
+	// MatchRescue serves the rescue Profile after too many install attempts
+	MatchRescue = "rescue"
+	// MatchHolding serves the holding Profile to a machine which matches no
+	// Group
+	MatchHolding = "holding"
+)
+
+// MachineDescribe describes a Machine, by id or MAC address, with the
+// labels it is matched with, and the Group and Profile it is served as of
+// now and how they were selected. The Machine's boot history shows what it
+// was actually served.
+func (s *server) MachineDescribe(ctx context.Context, req *pb.MachineDescribeRequest) (*pb.MachineDescribeResponse, error) {
+	machine, err := s.store.MachineGet(req.Id)
+	if err != nil {
+		if machine = s.machineByMAC(req.Id); machine == nil {
+			return nil, err
+		}
+	}
+	labels := machineLabels(machine)
+	resp := &pb.MachineDescribeResponse{
+		Machine: machine.Copy(),
+		Labels:  labels,
+	}
+	group, match, err := s.selectGroup(ctx, labels)
+	if err == ErrNoMatchingGroup {
+		return resp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp.Group = group
+	resp.Match = match
+	resp.Profile = group.Profile
+	return resp, nil
+}
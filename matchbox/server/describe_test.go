@@ -0,0 +1,69 @@
+package server
+
+import (
+	"testing"
+
+	"context"
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestMachineDescribe(t *testing.T) {
+	other := &storagepb.Group{Id: "other", Profile: "other-profile"}
+	machine := &storagepb.Machine{
+		Id:     fake.Machine.Id,
+		Labels: map[string]string{"uuid": fake.Machine.Id, "mac": "52:54:00:a1:9c:ae"},
+		Facts:  map[string]string{"hostname": "node1"},
+	}
+	store := fake.NewFixedStore()
+	store.Groups = map[string]*storagepb.Group{fake.Group.Id: fake.Group, other.Id: other}
+	store.Machines[machine.Id] = machine
+	srv := NewServer(&Config{Store: store})
+	ctx := context.Background()
+
+	// assert that:
+	// - machines are described with their labels and facts, and the Group
+	// and Profile selected by their labels
+	// - machines may be described by MAC address
+	// - pinned machines are described with the pinned Group
+	// - unknown machines are errors
+	resp, err := srv.MachineDescribe(ctx, &pb.MachineDescribeRequest{Id: machine.Id})
+	assert.Nil(t, err)
+	assert.Equal(t, machine, resp.Machine)
+	assert.Equal(t, "node1", resp.Labels["hostname"])
+	assert.Equal(t, fake.Group, resp.Group)
+	assert.Equal(t, fake.Group.Profile, resp.Profile)
+	assert.Equal(t, MatchSelector, resp.Match)
+
+	resp, err = srv.MachineDescribe(ctx, &pb.MachineDescribeRequest{Id: "52:54:00:A1:9C:AE"})
+	assert.Nil(t, err)
+	assert.Equal(t, machine.Id, resp.Machine.Id)
+
+	_, err = srv.MachinePin(ctx, &pb.MachinePinRequest{Id: machine.Id, Group: other.Id})
+	assert.Nil(t, err)
+	resp, err = srv.MachineDescribe(ctx, &pb.MachineDescribeRequest{Id: machine.Id})
+	assert.Nil(t, err)
+	assert.Equal(t, other, resp.Group)
+	assert.Equal(t, MatchPinned, resp.Match)
+
+	_, err = srv.MachineDescribe(ctx, &pb.MachineDescribeRequest{Id: "missing"})
+	assert.Error(t, err)
+}
+
+func TestMachineDescribe_NoMatchingGroup(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Machines[fake.Machine.Id] = fake.Machine
+	srv := NewServer(&Config{Store: store})
+
+	// assert that:
+	// - machines which match no Group are described without one
+	resp, err := srv.MachineDescribe(context.Background(), &pb.MachineDescribeRequest{Id: fake.Machine.Id})
+	assert.Nil(t, err)
+	assert.Equal(t, fake.Machine, resp.Machine)
+	assert.Nil(t, resp.Group)
+	assert.Equal(t, "", resp.Match)
+	assert.Equal(t, "", resp.Profile)
+}
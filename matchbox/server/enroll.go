@@ -0,0 +1,130 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"strconv"
+	"text/template"
+
+	"context"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// DefaultEnrollHostname is the hostname template of enrolled machines if
+// none is configured.
+const DefaultEnrollHostname = "node-{{.index}}"
+
+// Facts recorded on enrolled Machines.
+const (
+	enrollIndexFact = "enroll_index"
+	hostnameFact    = "hostname"
+)
+
+// ErrEmptyHostname is returned when an enrollment hostname template renders
+// an empty hostname.
+var ErrEmptyHostname = errors.New("matchbox: Enrollment hostname template rendered an empty hostname")
+
+// ParseEnrollHostname parses a template for the hostnames of enrolled
+// machines. Templates may use the machine's enrollment {{.index}}, counted
+// from 1, a {{.hash}} of its MAC address (or UUID), and its {{.uuid}} and
+// {{.mac}}.
+func ParseEnrollHostname(text string) (*template.Template, error) {
+	return template.New("hostname").Option("missingkey=error").Parse(text)
+}
+
+// MachineEnroll enrolls a machine (identified by its labels) which matches
+// no Group, other than the enrollment Group, into the enrollment Group. The Machine is pinned to the Group
+// and assigned the next enrollment index and a hostname from the hostname
+// template, which are recorded as facts so they persist. Machines which
+// already have a hostname fact (e.g. from DHCP leases) keep it. Returns
+// nil if enrollment is disabled or the machine was not enrolled.
+func (s *server) MachineEnroll(ctx context.Context, labels map[string]string) (*storagepb.Machine, error) {
+	if s.enrollGroup == "" || labels["uuid"] == "" {
+		return nil, nil
+	}
+	s.enrollMu.Lock()
+	defer s.enrollMu.Unlock()
+	machine := s.lookupMachine(ctx, labels)
+	if machine != nil && (machine.Group != "" || machine.Facts[enrollIndexFact] != "") {
+		return nil, nil
+	}
+	// the enrollment Group may have no selectors, so it matches machines
+	// which match no other Group
+	group, err := s.SelectGroup(ctx, &pb.SelectGroupRequest{Labels: labels})
+	if err == nil && group.Id != s.enrollGroup {
+		return nil, nil
+	}
+	if err != nil && err != ErrNoMatchingGroup {
+		return nil, err
+	}
+	if _, err := s.store.GroupGet(s.enrollGroup); err != nil {
+		return nil, err
+	}
+
+	machines, err := s.store.MachineList()
+	if err != nil {
+		return nil, err
+	}
+	index := 1
+	for _, other := range machines {
+		if n, err := strconv.Atoi(other.Facts[enrollIndexFact]); err == nil && n >= index {
+			index = n + 1
+		}
+	}
+	hostname, err := s.enrollHostname(index, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	id := labels["uuid"]
+	if machine != nil {
+		id = machine.Id
+	}
+	return s.MachineUpdate(ctx, id, func(machine *storagepb.Machine) (*storagepb.Machine, error) {
+		if machine == nil {
+			machine = &storagepb.Machine{Id: id, Labels: labels}
+		}
+		// the machine may have been pinned since it was looked up
+		if machine.Group != "" || machine.Facts[enrollIndexFact] != "" {
+			return nil, nil
+		}
+		if machine.Facts == nil {
+			machine.Facts = make(map[string]string)
+		}
+		machine.Facts[enrollIndexFact] = strconv.Itoa(index)
+		if machine.Facts[hostnameFact] == "" {
+			machine.Facts[hostnameFact] = hostname
+		}
+		machine.Group = s.enrollGroup
+		return machine, nil
+	})
+}
+
+// enrollHostname renders the hostname of the machine enrolled with the
+// given index.
+func (s *server) enrollHostname(index int, labels map[string]string) (string, error) {
+	id := labels["uuid"]
+	if hw, err := net.ParseMAC(labels["mac"]); err == nil {
+		id = hw.String()
+	}
+	sum := sha256.Sum256([]byte(id))
+	data := map[string]interface{}{
+		"index": index,
+		"hash":  hex.EncodeToString(sum[:4]),
+		"uuid":  labels["uuid"],
+		"mac":   labels["mac"],
+	}
+	var buf bytes.Buffer
+	if err := s.hostnames.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	if buf.Len() == 0 {
+		return "", ErrEmptyHostname
+	}
+	return buf.String(), nil
+}
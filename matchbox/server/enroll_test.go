@@ -0,0 +1,84 @@
+package server
+
+import (
+	"testing"
+
+	"context"
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestMachineEnroll(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups[fake.Group.Id] = fake.Group
+	store.Groups["default"] = &storagepb.Group{Id: "default", Profile: fake.Profile.Id}
+	srv := NewServer(&Config{Store: store, EnrollGroup: "default"})
+	ctx := context.Background()
+
+	// assert that:
+	// - machines which match no Group are enrolled with the next index and
+	// a hostname, and pinned to the enrollment Group
+	// - enrollment is idempotent
+	// - machines which match a Group, or without a uuid, are not enrolled
+	machine, err := srv.MachineEnroll(ctx, map[string]string{"uuid": "e5f6a7b8"})
+	if assert.Nil(t, err) && assert.NotNil(t, machine) {
+		assert.Equal(t, "default", machine.Group)
+		assert.Equal(t, "1", machine.Facts["enroll_index"])
+		assert.Equal(t, "node-1", machine.Facts["hostname"])
+	}
+	machine, err = srv.MachineEnroll(ctx, map[string]string{"uuid": "e5f6a7b8"})
+	assert.Nil(t, err)
+	assert.Nil(t, machine)
+	machine, err = srv.MachineEnroll(ctx, map[string]string{"uuid": "c9d0e1f2"})
+	if assert.Nil(t, err) && assert.NotNil(t, machine) {
+		assert.Equal(t, "node-2", machine.Facts["hostname"])
+	}
+	group, err := srv.SelectGroup(ctx, &pb.SelectGroupRequest{Labels: map[string]string{"uuid": "c9d0e1f2"}})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "default", group.Id)
+	}
+
+	machine, err = srv.MachineEnroll(ctx, map[string]string{"uuid": "a1b2c3d4"})
+	assert.Nil(t, err)
+	assert.Nil(t, machine)
+	machine, err = srv.MachineEnroll(ctx, map[string]string{"mac": "52:54:00:a1:9c:ae"})
+	assert.Nil(t, err)
+	assert.Nil(t, machine)
+}
+
+func TestMachineEnroll_Hostname(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups["default"] = &storagepb.Group{Id: "default", Profile: fake.Profile.Id}
+	store.Machines["52:54:00:b2:2f:86"] = &storagepb.Machine{
+		Id:    "52:54:00:b2:2f:86",
+		Facts: map[string]string{"hostname": "leased"},
+	}
+	hostnames, err := ParseEnrollHostname("worker-{{.hash}}")
+	assert.Nil(t, err)
+	srv := NewServer(&Config{Store: store, EnrollGroup: "default", EnrollHostname: hostnames})
+	ctx := context.Background()
+
+	// assert that:
+	// - hostnames are rendered from the template, with a hash of the MAC
+	// - machines with a hostname fact keep it
+	// - templates which render an empty hostname fail
+	machine, err := srv.MachineEnroll(ctx, map[string]string{"uuid": "e5f6a7b8", "mac": "52:54:00:a1:9c:ae"})
+	if assert.Nil(t, err) && assert.NotNil(t, machine) {
+		assert.Regexp(t, "^worker-[0-9a-f]{8}$", machine.Facts["hostname"])
+	}
+	machine, err = srv.MachineEnroll(ctx, map[string]string{"uuid": "c9d0e1f2", "mac": "52:54:00:b2:2f:86"})
+	if assert.Nil(t, err) && assert.NotNil(t, machine) {
+		assert.Equal(t, "leased", machine.Facts["hostname"])
+		assert.Equal(t, "2", machine.Facts["enroll_index"])
+	}
+
+	_, err = ParseEnrollHostname("node-{{.index")
+	assert.Error(t, err)
+	empty, _ := ParseEnrollHostname("{{if false}}node{{end}}")
+	srv = NewServer(&Config{Store: store, EnrollGroup: "default", EnrollHostname: empty})
+	_, err = srv.MachineEnroll(ctx, map[string]string{"uuid": "f3a4b5c6"})
+	assert.Equal(t, ErrEmptyHostname, err)
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"errors"
+	"time"
+
+	"context"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// Event errors
+var (
+	ErrInvalidEventRange = errors.New("matchbox: Event since and until must be RFC 3339 times, since before until")
+	ErrInvalidEventLimit = errors.New("matchbox: Event limit must not be negative")
+)
+
+// EventPut records an Event in the event history.
+func (s *server) EventPut(ctx context.Context, event *storagepb.Event) error {
+	if err := event.AssertValid(); err != nil {
+		return err
+	}
+	return s.store.EventPut(event)
+}
+
+// EventList lists recorded Events, oldest first, optionally only those of a
+// machine or type, in a time range, or the most recent up to a limit.
+func (s *server) EventList(ctx context.Context, req *pb.EventListRequest) ([]*storagepb.Event, error) {
+	if req.Type != "" && !storagepb.IsEventType(req.Type) {
+		return nil, storagepb.ErrInvalidEventType
+	}
+	if req.Limit < 0 {
+		return nil, ErrInvalidEventLimit
+	}
+	since, err := parseEventTime(req.Since)
+	if err != nil {
+		return nil, err
+	}
+	until, err := parseEventTime(req.Until)
+	if err != nil {
+		return nil, err
+	}
+	if !since.IsZero() && !until.IsZero() && !since.Before(until) {
+		return nil, ErrInvalidEventRange
+	}
+	events, err := s.store.EventList(since, until)
+	if err != nil {
+		return nil, err
+	}
+	matches := []*storagepb.Event{}
+	for _, event := range events {
+		if (req.MachineId == "" || event.MachineId == req.MachineId) && (req.Type == "" || event.Type == req.Type) {
+			matches = append(matches, event)
+		}
+	}
+	if req.Limit > 0 && len(matches) > int(req.Limit) {
+		matches = matches[len(matches)-int(req.Limit):]
+	}
+	return matches, nil
+}
+
+// EventPrune deletes the Events recorded before a time.
+func (s *server) EventPrune(ctx context.Context, before time.Time) error {
+	return s.store.EventPrune(before)
+}
+
+// parseEventTime parses an RFC 3339 time of an Event range, or returns the
+// zero time if it is empty.
+func parseEventTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, ErrInvalidEventRange
+	}
+	return t, nil
+}
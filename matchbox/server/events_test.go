@@ -0,0 +1,76 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"context"
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestEventList(t *testing.T) {
+	store := fake.NewFixedStore()
+	srv := NewServer(&Config{Store: store})
+	ctx := context.Background()
+	for _, event := range []*storagepb.Event{
+		{Time: "2026-10-13T21:00:00Z", Type: storagepb.EventBoot, MachineId: "a1b2c3d4", Endpoint: "/ipxe"},
+		{Time: "2026-10-13T21:01:00Z", Type: storagepb.EventConfig, MachineId: "a1b2c3d4", Endpoint: "/ignition"},
+		{Time: "2026-10-13T21:02:00Z", Type: storagepb.EventBoot, MachineId: "b2c3d4e5", Endpoint: "/ipxe"},
+		{Time: "2026-10-14T09:00:00Z", Type: storagepb.EventComplete, MachineId: "a1b2c3d4", Endpoint: "/v1/complete"},
+	} {
+		assert.Nil(t, srv.EventPut(ctx, event))
+	}
+	endpoints := func(req *pb.EventListRequest) []string {
+		events, err := srv.EventList(ctx, req)
+		assert.Nil(t, err)
+		var names []string
+		for _, event := range events {
+			names = append(names, event.MachineId+event.Endpoint)
+		}
+		return names
+	}
+	// assert that:
+	// - Events are filtered by machine, type, and time range
+	// - limits keep the most recent Events
+	assert.Len(t, endpoints(&pb.EventListRequest{}), 4)
+	assert.Equal(t, []string{"a1b2c3d4/ipxe", "a1b2c3d4/ignition", "a1b2c3d4/v1/complete"}, endpoints(&pb.EventListRequest{MachineId: "a1b2c3d4"}))
+	assert.Equal(t, []string{"a1b2c3d4/ipxe", "b2c3d4e5/ipxe"}, endpoints(&pb.EventListRequest{Type: storagepb.EventBoot}))
+	assert.Equal(t, []string{"a1b2c3d4/ignition", "b2c3d4e5/ipxe"}, endpoints(&pb.EventListRequest{Since: "2026-10-13T21:01:00Z", Until: "2026-10-14T00:00:00Z"}))
+	assert.Equal(t, []string{"a1b2c3d4/v1/complete"}, endpoints(&pb.EventListRequest{Limit: 1}))
+
+	// - invalid types, times, ranges, and limits are errors
+	cases := []struct {
+		req *pb.EventListRequest
+		err error
+	}{
+		{&pb.EventListRequest{Type: "reboot"}, storagepb.ErrInvalidEventType},
+		{&pb.EventListRequest{Since: "last tuesday"}, ErrInvalidEventRange},
+		{&pb.EventListRequest{Since: "2026-10-14T00:00:00Z", Until: "2026-10-13T00:00:00Z"}, ErrInvalidEventRange},
+		{&pb.EventListRequest{Limit: -1}, ErrInvalidEventLimit},
+	}
+	for _, c := range cases {
+		_, err := srv.EventList(ctx, c.req)
+		assert.Equal(t, c.err, err)
+	}
+	// - invalid Events aren't recorded
+	assert.Equal(t, storagepb.ErrInvalidEventType, srv.EventPut(ctx, &storagepb.Event{Time: "2026-10-14T09:00:00Z"}))
+}
+
+func TestEventPrune(t *testing.T) {
+	store := fake.NewFixedStore()
+	srv := NewServer(&Config{Store: store})
+	store.Events = []*storagepb.Event{
+		{Time: "2026-10-13T21:00:00Z", Type: storagepb.EventBoot},
+		{Time: "2026-10-14T09:00:00Z", Type: storagepb.EventComplete},
+	}
+	// assert that Events before the time are deleted
+	err := srv.EventPrune(context.Background(), time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	if assert.Len(t, store.Events, 1) {
+		assert.Equal(t, storagepb.EventComplete, store.Events[0].Type)
+	}
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/coreos/matchbox/matchbox/storage"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// generationStore wraps a Store to count writes to Groups, Profiles, and
+// templates, the inputs of rendered configs. Machine writes aren't counted,
+// machines write on most boots and their labels and facts are request
+// inputs.
+type generationStore struct {
+	storage.Store
+	generation uint64
+}
+
+// bump records a write.
+func (s *generationStore) bump() {
+	atomic.AddUint64(&s.generation, 1)
+}
+
+func (s *generationStore) GroupPut(group *storagepb.Group) error {
+	defer s.bump()
+	return s.Store.GroupPut(group)
+}
+
+func (s *generationStore) GroupDelete(id string) error {
+	defer s.bump()
+	return s.Store.GroupDelete(id)
+}
+
+func (s *generationStore) ProfilePut(profile *storagepb.Profile) error {
+	defer s.bump()
+	return s.Store.ProfilePut(profile)
+}
+
+func (s *generationStore) ProfileDelete(id string) error {
+	defer s.bump()
+	return s.Store.ProfileDelete(id)
+}
+
+func (s *generationStore) ProfileVersionPut(version *storagepb.ProfileVersion) error {
+	defer s.bump()
+	return s.Store.ProfileVersionPut(version)
+}
+
+func (s *generationStore) IgnitionPut(name string, config []byte) error {
+	defer s.bump()
+	return s.Store.IgnitionPut(name, config)
+}
+
+func (s *generationStore) IgnitionDelete(name string) error {
+	defer s.bump()
+	return s.Store.IgnitionDelete(name)
+}
+
+func (s *generationStore) CloudPut(name string, config []byte) error {
+	defer s.bump()
+	return s.Store.CloudPut(name, config)
+}
+
+func (s *generationStore) CloudDelete(name string) error {
+	defer s.bump()
+	return s.Store.CloudDelete(name)
+}
+
+func (s *generationStore) GenericPut(name string, config []byte) error {
+	defer s.bump()
+	return s.Store.GenericPut(name, config)
+}
+
+func (s *generationStore) GenericDelete(name string) error {
+	defer s.bump()
+	return s.Store.GenericDelete(name)
+}
+
+// Generation returns the number of writes to Groups, Profiles, and
+// templates since the server started.
+func (s *server) Generation(ctx context.Context) uint64 {
+	return atomic.LoadUint64(&s.writes.generation)
+}
+
+// Reload discards the resources cached by the Store, if it's a Reloader,
+// and advances the generation so the matching index and rendered configs
+// are rebuilt.
+func (s *server) Reload() {
+	if reloader, ok := s.writes.Store.(storage.Reloader); ok {
+		reloader.Reload()
+	}
+	s.writes.bump()
+}
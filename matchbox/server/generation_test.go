@@ -0,0 +1,53 @@
+package server
+
+import (
+	"testing"
+
+	"context"
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestGeneration(t *testing.T) {
+	srv := NewServer(&Config{Store: fake.NewFixedStore()})
+	ctx := context.Background()
+	assert.Equal(t, uint64(0), srv.Generation(ctx))
+
+	_, err := srv.IgnitionPut(ctx, &pb.IgnitionPutRequest{Name: "a.yaml", Config: []byte("a")})
+	assert.Nil(t, err)
+	_, err = srv.ProfilePut(ctx, &pb.ProfilePutRequest{Profile: fake.Profile})
+	assert.Nil(t, err)
+	generation := srv.Generation(ctx)
+	_, err = srv.MachinePut(ctx, &pb.MachinePutRequest{Machine: &storagepb.Machine{Id: "a1b2c3d4"}})
+	assert.Nil(t, err)
+	// assert that:
+	// - writes to templates and Profiles advance the generation
+	// - Machine writes don't
+	assert.True(t, generation >= 2)
+	assert.Equal(t, generation, srv.Generation(ctx))
+}
+
+// reloadStore is a Store which counts reloads.
+type reloadStore struct {
+	*fake.FixedStore
+	reloads int
+}
+
+func (s *reloadStore) Reload() {
+	s.reloads++
+}
+
+func TestReload(t *testing.T) {
+	store := &reloadStore{FixedStore: fake.NewFixedStore()}
+	srv := NewServer(&Config{Store: store})
+	ctx := context.Background()
+	srv.Reload()
+	// assert that:
+	// - the Store's cached resources are discarded
+	// - the generation advances, so rendered configs are rebuilt
+	assert.Equal(t, 1, store.reloads)
+	assert.Equal(t, uint64(1), srv.Generation(ctx))
+}
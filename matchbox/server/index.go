@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	"github.com/coreos/matchbox/matchbox/trace"
+)
+
+// groupIndexTTL is how long a Group index is used before it is rebuilt, so
+// Groups written to the store directly (e.g. files edited in the data path)
+// are matched too.
+const groupIndexTTL = 10 * time.Second
+
+// groupIndex indexes Groups by selector, so matching a machine's labels
+// checks only the Groups which select one of its labels, rather than every
+// Group.
+type groupIndex struct {
+	// Groups in match order, most selectors first
+	groups []*storagepb.Group
+	// positions of Groups by the key and value of their indexed selector
+	selectors map[string]map[string][]int
+	// positions of Groups with only address selectors, which are checked
+	// for every match
+	scan []int
+	// position of the first Group without selectors, or -1
+	fallback int
+	// store generation and time the index was built
+	generation uint64
+	built      time.Time
+}
+
+// newGroupIndex returns an index of the given Groups. Each Group is indexed
+// by one of its selectors, preferring the "uuid" and "mac" selectors which
+// identify single machines. Address selectors (IPs and CIDRs) match more
+// than one label value, so they aren't indexed.
+func newGroupIndex(groups []*storagepb.Group) *groupIndex {
+	sorted := make([]*storagepb.Group, len(groups))
+	copy(sorted, groups)
+	sort.Sort(sort.Reverse(storagepb.ByReqs(sorted)))
+	index := &groupIndex{
+		groups:    sorted,
+		selectors: make(map[string]map[string][]int),
+		fallback:  -1,
+	}
+	for i, group := range sorted {
+		key, ok := indexKey(group.Selector)
+		if !ok && len(group.Selector) > 0 {
+			index.scan = append(index.scan, i)
+			continue
+		}
+		if !ok {
+			if index.fallback < 0 {
+				index.fallback = i
+			}
+			continue
+		}
+		values, ok := index.selectors[key]
+		if !ok {
+			values = make(map[string][]int)
+			index.selectors[key] = values
+		}
+		value := group.Selector[key]
+		values[value] = append(values[value], i)
+	}
+	return index
+}
+
+// indexKey returns the selector key a Group is indexed by, or false if the
+// Group has no selectors other than address selectors.
+func indexKey(selector map[string]string) (string, bool) {
+	for _, key := range []string{"uuid", "mac"} {
+		if value, ok := selector[key]; ok && !storagepb.IsAddressSelector(value) {
+			return key, true
+		}
+	}
+	first, ok := "", false
+	for key, value := range selector {
+		if storagepb.IsAddressSelector(value) {
+			continue
+		}
+		if !ok || key < first {
+			first, ok = key, true
+		}
+	}
+	return first, ok
+}
+
+// match returns the first Group, in match order, whose selectors match the
+// labels, or nil.
+func (idx *groupIndex) match(labels map[string]string) *storagepb.Group {
+	best := idx.fallback
+	for key, value := range labels {
+		// positions are in match order, so the first match is the best
+		// of the Groups selecting this label
+		for _, i := range idx.selectors[key][value] {
+			if best >= 0 && i >= best {
+				break
+			}
+			if idx.groups[i].Matches(labels) {
+				best = i
+				break
+			}
+		}
+	}
+	for _, i := range idx.scan {
+		if best >= 0 && i >= best {
+			break
+		}
+		if idx.groups[i].Matches(labels) {
+			best = i
+			break
+		}
+	}
+	if best < 0 {
+		return nil
+	}
+	return idx.groups[best]
+}
+
+// groupIndex returns the index of Groups in the store, rebuilding it if
+// Groups may have been written since it was built.
+func (s *server) groupIndex(ctx context.Context) (*groupIndex, error) {
+	generation := atomic.LoadUint64(&s.writes.generation)
+	s.groupsMu.Lock()
+	defer s.groupsMu.Unlock()
+	now := s.now()
+	if s.groups != nil && s.groups.generation == generation && now.Sub(s.groups.built) < groupIndexTTL {
+		return s.groups, nil
+	}
+	start := time.Now()
+	groups, err := s.store.GroupList()
+	trace.Record(ctx, "store.GroupList", start, err)
+	if err != nil {
+		return nil, err
+	}
+	index := newGroupIndex(groups)
+	index.generation = generation
+	index.built = now
+	s.groups = index
+	return index, nil
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"context"
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestGroupIndex_Match(t *testing.T) {
+	all := &storagepb.Group{Id: "all", Profile: "p"}
+	region := &storagepb.Group{Id: "region", Profile: "p", Selector: map[string]string{"region": "us"}}
+	rack := &storagepb.Group{Id: "rack", Profile: "p", Selector: map[string]string{"region": "us", "rack": "a"}}
+	node := &storagepb.Group{Id: "node", Profile: "p", Selector: map[string]string{"mac": "52:54:00:89:d8:10", "region": "us"}}
+	index := newGroupIndex([]*storagepb.Group{all, region, rack, node})
+	cases := []struct {
+		labels map[string]string
+		group  *storagepb.Group
+	}{
+		{map[string]string{"mac": "52:54:00:89:d8:10", "region": "us", "rack": "a"}, rack},
+		{map[string]string{"mac": "52:54:00:89:d8:10", "region": "us"}, node},
+		{map[string]string{"mac": "52:54:00:89:d8:10", "region": "eu"}, all},
+		{map[string]string{"region": "us", "rack": "b"}, region},
+		{nil, all},
+	}
+	// assert that:
+	// - the Group with the most selectors matching the labels is selected
+	// - Groups without selectors match any labels
+	for _, c := range cases {
+		assert.Equal(t, c.group, index.match(c.labels))
+	}
+
+	index = newGroupIndex([]*storagepb.Group{region})
+	// - no Group matches labels which no Group selects
+	assert.Nil(t, index.match(map[string]string{"region": "eu"}))
+}
+
+func TestGroupIndex_AddressSelectors(t *testing.T) {
+	all := &storagepb.Group{Id: "all", Profile: "p"}
+	site := &storagepb.Group{Id: "site", Profile: "p", Selector: map[string]string{"source_ip": "2001:db8::/32"}}
+	rack := &storagepb.Group{Id: "rack", Profile: "p", Selector: map[string]string{"source_ip": "2001:db8:a::/48", "os": "installed"}}
+	v4 := &storagepb.Group{Id: "v4", Profile: "p", Selector: map[string]string{"source_ip": "10.0.0.0/8"}}
+	index := newGroupIndex([]*storagepb.Group{all, site, rack, v4})
+	cases := []struct {
+		labels map[string]string
+		group  *storagepb.Group
+	}{
+		{map[string]string{"source_ip": "2001:db8::5"}, site},
+		{map[string]string{"source_ip": "2001:db8:a::5", "os": "installed"}, rack},
+		{map[string]string{"source_ip": "2001:db8:a::5"}, site},
+		{map[string]string{"source_ip": "10.1.2.3"}, v4},
+		{map[string]string{"source_ip": "192.168.1.3"}, all},
+	}
+	// assert that:
+	// - Groups with only address selectors aren't indexed by them, but
+	// match labels in their networks
+	// - Groups with other selectors are indexed by those
+	assert.Equal(t, []int{1, 2}, index.scan)
+	assert.Len(t, index.selectors["os"], 1)
+	for _, c := range cases {
+		assert.Equal(t, c.group, index.match(c.labels), c.labels["source_ip"])
+	}
+}
+
+func TestGroupIndex_ManyGroups(t *testing.T) {
+	groups := make([]*storagepb.Group, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		groups = append(groups, &storagepb.Group{
+			Id:       fmt.Sprintf("node%d", i),
+			Profile:  "p",
+			Selector: map[string]string{"uuid": fmt.Sprintf("uuid-%d", i)},
+		})
+	}
+	index := newGroupIndex(groups)
+	// assert that:
+	// - per-machine Groups are indexed by their selector
+	assert.Len(t, index.selectors["uuid"], 1000)
+	assert.Equal(t, "node512", index.match(map[string]string{"uuid": "uuid-512", "mac": "52:54:00:89:d8:10"}).Id)
+}
+
+func TestSelectGroup_Index(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups[fake.Group.Id] = fake.Group
+	srv := NewServer(&Config{Store: store}).(*server)
+	now := time.Now()
+	srv.now = func() time.Time { return now }
+	ctx := context.Background()
+	labels := map[string]string{"uuid": "a1b2c3d4", "mac": "52:54:00:89:d8:10"}
+
+	group, err := srv.SelectGroup(ctx, &pb.SelectGroupRequest{Labels: labels})
+	assert.Nil(t, err)
+	assert.Equal(t, fake.Group.Id, group.Id)
+
+	// assert that:
+	// - Groups written through the server are matched immediately
+	node := &storagepb.Group{Id: "node", Profile: fake.Profile.Id, Selector: map[string]string{"uuid": "a1b2c3d4", "mac": "52:54:00:89:d8:10"}}
+	_, err = srv.GroupPut(ctx, &pb.GroupPutRequest{Group: node})
+	assert.Nil(t, err)
+	group, err = srv.SelectGroup(ctx, &pb.SelectGroupRequest{Labels: labels})
+	assert.Nil(t, err)
+	assert.Equal(t, "node", group.Id)
+
+	// - Groups removed from the store directly are matched until the index
+	// is rebuilt
+	delete(store.Groups, "node")
+	group, _ = srv.SelectGroup(ctx, &pb.SelectGroupRequest{Labels: labels})
+	assert.Equal(t, "node", group.Id)
+	now = now.Add(groupIndexTTL)
+	group, _ = srv.SelectGroup(ctx, &pb.SelectGroupRequest{Labels: labels})
+	assert.Equal(t, fake.Group.Id, group.Id)
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"errors"
+
+	"context"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// Maintenance modes, in which iPXE requests are answered regardless of
+// Group matching.
+const (
+	// MaintenanceLocal boots machines from local disk
+	MaintenanceLocal = "local"
+	// MaintenanceHold holds machines at an iPXE script which retries until
+	// maintenance ends
+	MaintenanceHold = "hold"
+)
+
+// ErrInvalidMaintenance is returned for an unknown maintenance mode.
+var ErrInvalidMaintenance = errors.New("matchbox: Maintenance mode must be local or hold")
+
+// ValidateMaintenance returns an error if the maintenance mode is unknown.
+// The empty mode is not in maintenance.
+func ValidateMaintenance(mode string) error {
+	switch mode {
+	case "", MaintenanceLocal, MaintenanceHold:
+		return nil
+	}
+	return ErrInvalidMaintenance
+}
+
+// MaintenanceGet returns the maintenance mode, or "" if not in maintenance.
+func (s *server) MaintenanceGet(ctx context.Context) string {
+	s.maintenanceMu.RLock()
+	defer s.maintenanceMu.RUnlock()
+	return s.maintenance
+}
+
+// MaintenanceSet enters the given maintenance mode, or leaves maintenance
+// for the empty mode. The mode is held in memory, so a restarted server
+// returns to its configured mode.
+func (s *server) MaintenanceSet(ctx context.Context, req *pb.MaintenanceSetRequest) (string, error) {
+	if err := ValidateMaintenance(req.Mode); err != nil {
+		return "", err
+	}
+	s.maintenanceMu.Lock()
+	defer s.maintenanceMu.Unlock()
+	s.maintenance = req.Mode
+	return s.maintenance, nil
+}
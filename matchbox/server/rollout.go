@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"hash/fnv"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	"github.com/coreos/matchbox/matchbox/trace"
+)
+
+// rolloutRanksTTL is how long a Rollout's ranking of Machines is used before
+// it is rebuilt, so Machines written to the store directly are ranked too.
+const rolloutRanksTTL = groupIndexTTL
+
+// rolloutRanks orders the Machines matching a Group for its Rollout, so
+// admitting a machine doesn't list every Machine.
+type rolloutRanks struct {
+	group *storagepb.Group
+	// ids of matching Machines in Rollout order
+	ids []string
+	// store generation and time the ranks were built
+	generation uint64
+	built      time.Time
+}
+
+// newRolloutRanks returns the ranks of the Machines matching a Group.
+func newRolloutRanks(group *storagepb.Group, machines []*storagepb.Machine) *rolloutRanks {
+	ranks := &rolloutRanks{group: group}
+	for _, machine := range machines {
+		if group.Matches(machine.Labels) {
+			ranks.ids = append(ranks.ids, machine.Id)
+		}
+	}
+	sort.Slice(ranks.ids, func(i, j int) bool {
+		return ranks.before(ranks.ids[i], ranks.ids[j])
+	})
+	return ranks
+}
+
+// before returns true if Machine a is ahead of Machine b in the Rollout.
+func (r *rolloutRanks) before(a, b string) bool {
+	rankA, rankB := rolloutRank(r.group.Id, a), rolloutRank(r.group.Id, b)
+	return rankA < rankB || (rankA == rankB && a < b)
+}
+
+// ahead returns the number of matching Machines ahead of a Machine.
+func (r *rolloutRanks) ahead(id string) int {
+	return sort.Search(len(r.ids), func(i int) bool {
+		return !r.before(r.ids[i], id)
+	})
+}
+
+// ranked returns true if a Machine is among the ranked Machines.
+func (r *rolloutRanks) ranked(id string) bool {
+	i := r.ahead(id)
+	return i < len(r.ids) && r.ids[i] == id
+}
+
+// rolloutRank returns the position of a machine in a Group's Rollout.
+func rolloutRank(groupID, machineID string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(groupID + "/" + machineID))
+	return h.Sum64()
+}
+
+// inRollout returns true if a machine is among the first admitted machines
+// matching a Group. Machines are ranked by a hash of the Group and Machine
+// ids, so waves are stable between requests and spread across the fleet.
+func (s *server) inRollout(ctx context.Context, group *storagepb.Group, machine *storagepb.Machine, admitted int) bool {
+	ranks, err := s.rolloutRanks(ctx, group)
+	if err != nil {
+		return false
+	}
+	return ranks.ahead(machine.Id) < admitted
+}
+
+// rolloutRanks returns the ranks of the Machines matching a Group, ranking
+// them again if Groups or matching Machines may have been written since.
+func (s *server) rolloutRanks(ctx context.Context, group *storagepb.Group) (*rolloutRanks, error) {
+	generation := atomic.LoadUint64(&s.writes.generation)
+	s.rolloutsMu.Lock()
+	defer s.rolloutsMu.Unlock()
+	now := s.now()
+	if ranks, ok := s.rollouts[group.Id]; ok && ranks.generation == generation && now.Sub(ranks.built) < rolloutRanksTTL {
+		return ranks, nil
+	}
+	start := time.Now()
+	machines, err := s.store.MachineList()
+	trace.Record(ctx, "store.MachineList", start, err)
+	if err != nil {
+		return nil, err
+	}
+	ranks := newRolloutRanks(group, machines)
+	ranks.generation = generation
+	ranks.built = now
+	s.rollouts[group.Id] = ranks
+	return ranks, nil
+}
+
+// invalidateRollouts discards the Rollout ranks a written (or removed)
+// Machine changes, i.e. those of Groups it joins or leaves. Machines write
+// on most boots without changing their labels, which keeps their ranks.
+func (s *server) invalidateRollouts(machine *storagepb.Machine, removed bool) {
+	s.rolloutsMu.Lock()
+	defer s.rolloutsMu.Unlock()
+	for id, ranks := range s.rollouts {
+		matches := !removed && ranks.group.Matches(machine.Labels)
+		if ranks.ranked(machine.Id) != matches {
+			delete(s.rollouts, id)
+		}
+	}
+}
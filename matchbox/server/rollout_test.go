@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+// listStore is a Store which counts Machine listings.
+type listStore struct {
+	*fake.FixedStore
+	lists int
+}
+
+func (s *listStore) MachineList() ([]*storagepb.Machine, error) {
+	s.lists++
+	return s.FixedStore.MachineList()
+}
+
+func TestRolloutRanks(t *testing.T) {
+	start := time.Date(2026, 10, 15, 22, 0, 0, 0, time.UTC)
+	group := &storagepb.Group{
+		Id:       "workers",
+		Profile:  "worker",
+		Selector: map[string]string{"role": "worker"},
+		Rollout:  &storagepb.Rollout{Profile: "worker-next", Start: start.Format(time.RFC3339), BatchSize: 1, Interval: "1h"},
+	}
+	store := &listStore{FixedStore: fake.NewFixedStore()}
+	store.Groups[group.Id] = group
+	for _, id := range []string{"a1", "b2", "c3"} {
+		store.Machines[id] = &storagepb.Machine{Id: id, Labels: map[string]string{"uuid": id, "role": "worker"}}
+	}
+	srv := NewServer(&Config{Store: store})
+	srv.(*server).now = func() time.Time { return start }
+	ctx := context.Background()
+	selectAll := func() {
+		for _, id := range []string{"a1", "b2", "c3"} {
+			_, err := srv.SelectGroup(ctx, &pb.SelectGroupRequest{Labels: map[string]string{"uuid": id, "role": "worker"}})
+			assert.Nil(t, err)
+		}
+	}
+
+	// assert that:
+	// - Machines are listed once to rank them, not per selection
+	selectAll()
+	assert.Equal(t, 1, store.lists)
+	// - Machine writes which don't change the Group's Machines keep the ranks
+	_, err := srv.MachinePut(ctx, &pb.MachinePutRequest{Machine: &storagepb.Machine{Id: "a1", Labels: map[string]string{"uuid": "a1", "role": "worker"}, State: "booting"}})
+	assert.Nil(t, err)
+	selectAll()
+	assert.Equal(t, 1, store.lists)
+	// - Machines joining or leaving the Group rank them again
+	_, err = srv.MachinePut(ctx, &pb.MachinePutRequest{Machine: &storagepb.Machine{Id: "d4", Labels: map[string]string{"uuid": "d4", "role": "worker"}}})
+	assert.Nil(t, err)
+	selectAll()
+	assert.Equal(t, 2, store.lists)
+	_, err = srv.MachinePut(ctx, &pb.MachinePutRequest{Machine: &storagepb.Machine{Id: "b2", Labels: map[string]string{"uuid": "b2", "role": "storage"}}})
+	assert.Nil(t, err)
+	selectAll()
+	assert.Equal(t, 3, store.lists)
+	// - Group changes rank them again
+	group = proto.Clone(group).(*storagepb.Group)
+	group.Rollout.BatchSize = 2
+	_, err = srv.GroupPut(ctx, &pb.GroupPutRequest{Group: group})
+	assert.Nil(t, err)
+	selectAll()
+	assert.Equal(t, 4, store.lists)
+}
@@ -2,19 +2,35 @@ package server
 
 import (
 	"errors"
+	"net"
 	"sort"
+	"sync"
+	"text/template"
+	"time"
 
 	"context"
 
+	"github.com/coreos/matchbox/matchbox/defaults"
+	"github.com/coreos/matchbox/matchbox/sealed"
+	"github.com/coreos/matchbox/matchbox/selectors"
 	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
 	"github.com/coreos/matchbox/matchbox/storage"
 	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	"github.com/coreos/matchbox/matchbox/trace"
 )
 
 // Possible service errors
 var (
 	ErrNoMatchingGroup   = errors.New("matchbox: No matching Group")
 	ErrNoMatchingProfile = errors.New("matchbox: No matching Profile")
+	ErrTemplateInUse     = errors.New("matchbox: Template is referenced by a Profile")
+	ErrProfileInUse      = errors.New("matchbox: Profile is referenced by a Group")
+	ErrOwnerRequired     = errors.New("matchbox: Machine claims require an owner")
+	ErrNoMachineCapacity = errors.New("matchbox: No unclaimed Machine matches the selector")
+	ErrMachineClaimed    = errors.New("matchbox: Machine is claimed by another owner")
+	ErrProfileRequired   = errors.New("matchbox: Machine decommissions require a Profile")
+	ErrGroupRequired     = errors.New("matchbox: Machine adoptions require a Group")
+	ErrMachineNotPending = errors.New("matchbox: Machine is not pending adoption")
 )
 
 // Server defines the matchbox server interface.
@@ -30,6 +46,8 @@ type Server interface {
 	GroupGet(context.Context, *pb.GroupGetRequest) (*storagepb.Group, error)
 	// List all machine Groups.
 	GroupList(context.Context, *pb.GroupListRequest) ([]*storagepb.Group, error)
+	// Delete a machine Group by id.
+	GroupDelete(context.Context, *pb.GroupDeleteRequest) error
 
 	// Create or update a Profile.
 	ProfilePut(context.Context, *pb.ProfilePutRequest) (*storagepb.Profile, error)
@@ -37,33 +55,205 @@ type Server interface {
 	ProfileGet(context.Context, *pb.ProfileGetRequest) (*storagepb.Profile, error)
 	// List all Profiles.
 	ProfileList(context.Context, *pb.ProfileListRequest) ([]*storagepb.Profile, error)
+	// Delete a Profile which no Group references.
+	ProfileDelete(context.Context, *pb.ProfileDeleteRequest) error
+	// List the versions of a Profile, oldest first.
+	ProfileVersionList(context.Context, *pb.ProfileVersionListRequest) ([]*storagepb.ProfileVersion, error)
+	// Restore a version of a Profile and its templates.
+	ProfileRollback(context.Context, *pb.ProfileRollbackRequest) (*storagepb.ProfileVersion, error)
 
 	// Create or update an Ignition template.
 	IgnitionPut(context.Context, *pb.IgnitionPutRequest) (string, error)
 	// Get an Ignition template by name.
 	IgnitionGet(ctx context.Context, name string) (string, error)
+	// List the names of all Ignition templates.
+	IgnitionList(context.Context, *pb.IgnitionListRequest) ([]string, error)
+	// Delete an Ignition template which no Profile references.
+	IgnitionDelete(context.Context, *pb.IgnitionDeleteRequest) error
 
+	// Create or update a Cloud-Config template.
+	CloudPut(context.Context, *pb.CloudPutRequest) (string, error)
 	// Get a Cloud-Config template by name.
 	CloudGet(ctx context.Context, name string) (string, error)
+	// List the names of all Cloud-Config templates.
+	CloudList(context.Context, *pb.CloudListRequest) ([]string, error)
+	// Delete a Cloud-Config template which no Profile references.
+	CloudDelete(context.Context, *pb.CloudDeleteRequest) error
 
+	// Create or update a generic template.
+	GenericPut(context.Context, *pb.GenericPutRequest) (string, error)
 	// Get a generic template by name.
 	GenericGet(ctc context.Context, name string) (string, error)
+	// List the names of all generic templates.
+	GenericList(context.Context, *pb.GenericListRequest) ([]string, error)
+	// Delete a generic template which no Profile references.
+	GenericDelete(context.Context, *pb.GenericDeleteRequest) error
+
+	// Create or update a Machine.
+	MachinePut(context.Context, *pb.MachinePutRequest) (*storagepb.Machine, error)
+	// Get a Machine by id.
+	MachineGet(context.Context, *pb.MachineGetRequest) (*storagepb.Machine, error)
+	// List all Machines.
+	MachineList(context.Context, *pb.MachineListRequest) ([]*storagepb.Machine, error)
+	// Pin a Machine to a Group, or unpin it.
+	MachinePin(context.Context, *pb.MachinePinRequest) (*storagepb.Machine, error)
+	// Mark a Machine to be reinstalled.
+	MachineReinstall(context.Context, *pb.MachineReinstallRequest) (*storagepb.Machine, error)
+	// Claim an unclaimed Machine matching a selector and pin it to a Group.
+	MachineClaim(context.Context, *pb.MachineClaimRequest) (*storagepb.Machine, error)
+	// Release a claimed Machine and unpin it.
+	MachineRelease(context.Context, *pb.MachineReleaseRequest) (*storagepb.Machine, error)
+	// Mark a Machine to be decommissioned with a Profile.
+	MachineDecommission(context.Context, *pb.MachineDecommissionRequest) (*storagepb.Machine, error)
+	// Enroll a machine which matches no Group into the enrollment Group.
+	MachineEnroll(ctx context.Context, labels map[string]string) (*storagepb.Machine, error)
+	// Adopt a pending Machine into a Group.
+	MachineAdopt(context.Context, *pb.MachineAdoptRequest) (*storagepb.Machine, error)
+	// Describe a Machine with the Group and Profile it matches.
+	MachineDescribe(context.Context, *pb.MachineDescribeRequest) (*pb.MachineDescribeResponse, error)
+	// Archive a decommissioned Machine.
+	MachineArchive(context.Context, *storagepb.Machine) error
+	// Apply an update to the Machine with an id and write it, serialized
+	// with other writes of the Machine.
+	MachineUpdate(ctx context.Context, id string, update func(*storagepb.Machine) (*storagepb.Machine, error)) (*storagepb.Machine, error)
+	// Add the facts of the Machine identified by labels to the labels.
+	MachineLabels(ctx context.Context, labels map[string]string) map[string]string
+
+	// Record an Event in the event history.
+	EventPut(context.Context, *storagepb.Event) error
+	// List recorded Events, filtered by machine, type, and time.
+	EventList(context.Context, *pb.EventListRequest) ([]*storagepb.Event, error)
+	// Delete the Events recorded before a time.
+	EventPrune(ctx context.Context, before time.Time) error
+
+	// Create a single-use token bound to machine labels.
+	TokenCreate(context.Context, *pb.TokenCreateRequest) (string, error)
+	// Redeem (invalidate) a token presented by a machine.
+	TokenRedeem(context.Context, *pb.TokenRedeemRequest) error
+	// Restore a redeemed token whose request failed, so it can be used again.
+	TokenRestore(context.Context, *pb.TokenRedeemRequest) error
+	// Check a token presented by a machine, without redeeming it.
+	TokenCheck(context.Context, *pb.TokenRedeemRequest) error
+
+	// Get the maintenance mode, or "" if not in maintenance.
+	MaintenanceGet(context.Context) string
+	// Enter or leave maintenance mode.
+	MaintenanceSet(context.Context, *pb.MaintenanceSetRequest) (string, error)
+
+	// Get the number of writes to Groups, Profiles, and templates, which
+	// changes whenever rendered configs may change.
+	Generation(context.Context) uint64
+	// Reload discards resources cached from the Store, so changes made
+	// outside of matchbox are served.
+	Reload()
+}
+
+// Matcher matches machine labels to Groups from an external source. Groups
+// without a Profile refer to a Group in the store by id.
+type Matcher interface {
+	Match(ctx context.Context, labels map[string]string) (*storagepb.Group, error)
 }
 
+// Ids of Groups which override Group matching for machines being
+// decommissioned or rescued.
+const (
+	decommissionGroupID = "decommission"
+	rescueGroupID       = "rescue"
+)
+
+// HoldingGroupID is the id of the Group which serves the holding Profile to
+// machines which match no Group.
+const HoldingGroupID = "holding"
+
 // Config configures a server implementation.
 type Config struct {
 	Store storage.Store
+	// (optional) external matching, which falls back to Group selectors
+	Matcher Matcher
+	// (optional) network boots without being installed after which machines
+	// are served the RescueProfile
+	InstallAttempts int
+	// (optional) id of the Profile served to machines which exceed their
+	// InstallAttempts
+	RescueProfile string
+	// (optional) id of the Profile served to machines which match no Group
+	// until they are adopted
+	HoldingProfile string
+	// (optional) id of the Group machines which match no Group are enrolled
+	// into
+	EnrollGroup string
+	// (optional) hostname template of enrolled machines, which defaults to
+	// DefaultEnrollHostname
+	EnrollHostname *template.Template
+	// (optional) maintenance mode the server starts in
+	Maintenance string
+	// (optional) default metadata merged beneath the metadata of every Group
+	Defaults *defaults.Defaults
+	// (optional) registry of the selector keys Groups may use, any are
+	// allowed if nil
+	Selectors *selectors.Registry
 }
 
 // server implements the Server interface.
 type server struct {
-	store storage.Store
+	store   storage.Store
+	writes  *generationStore
+	matcher Matcher
+	tokens  *tokenStore
+	// rescue machines which fail to install
+	installAttempts int
+	rescueProfile   string
+	// hold machines which match no Group
+	holdingProfile string
+	// default metadata of Groups
+	defaults *defaults.Defaults
+	// declared selector keys of Groups
+	selectors *selectors.Registry
+	// enroll machines which match no Group
+	enrollGroup string
+	hostnames   *template.Template
+	// serializes Machine enrollments
+	enrollMu sync.Mutex
+	// serializes Machine claims
+	claimMu sync.Mutex
+	// serializes Profile versions
+	versionMu sync.Mutex
+	// serializes the writes of each Machine
+	machineLocks machineLocks
+	// index of Groups for matching
+	groups   *groupIndex
+	groupsMu sync.Mutex
+	// ranks of Machines in Group Rollouts by Group id
+	rollouts   map[string]*rolloutRanks
+	rolloutsMu sync.Mutex
+	// maintenance mode
+	maintenance   string
+	maintenanceMu sync.RWMutex
+	now           func() time.Time
 }
 
 // NewServer returns a new Server.
 func NewServer(config *Config) Server {
+	hostname := config.EnrollHostname
+	if hostname == nil {
+		hostname = template.Must(ParseEnrollHostname(DefaultEnrollHostname))
+	}
+	writes := &generationStore{Store: config.Store}
 	return &server{
-		store: config.Store,
+		store:           writes,
+		writes:          writes,
+		matcher:         config.Matcher,
+		tokens:          newTokenStore(),
+		installAttempts: config.InstallAttempts,
+		rescueProfile:   config.RescueProfile,
+		holdingProfile:  config.HoldingProfile,
+		defaults:        config.Defaults,
+		selectors:       config.Selectors,
+		enrollGroup:     config.EnrollGroup,
+		hostnames:       hostname,
+		maintenance:     config.Maintenance,
+		rollouts:        make(map[string]*rolloutRanks),
+		now:             time.Now,
 	}
 }
 
@@ -71,6 +261,28 @@ func (s *server) GroupPut(ctx context.Context, req *pb.GroupPutRequest) (*storag
 	if err := req.Group.AssertValid(); err != nil {
 		return nil, err
 	}
+	if err := s.selectors.Validate(req.Group.Id, req.Group.Selector); err != nil {
+		return nil, err
+	}
+	var profiles []*storagepb.Profile
+	if req.Group.ProfileVersion > 0 {
+		// a pinned Profile version must exist
+		version, err := s.store.ProfileVersionGet(req.Group.Profile, req.Group.ProfileVersion)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, version.Profile)
+	} else if profile, err := s.store.ProfileGet(req.Group.Profile); err == nil {
+		profiles = append(profiles, profile)
+	}
+	if req.Group.Rollout != nil {
+		if profile, err := s.store.ProfileGet(req.Group.Rollout.Profile); err == nil {
+			profiles = append(profiles, profile)
+		}
+	}
+	if err := s.validateMetadata(req.Group, profiles...); err != nil {
+		return nil, err
+	}
 	err := s.store.GroupPut(req.Group)
 	if err != nil {
 		return nil, err
@@ -78,6 +290,26 @@ func (s *server) GroupPut(ctx context.Context, req *pb.GroupPutRequest) (*storag
 	return req.Group, nil
 }
 
+// validateMetadata validates the metadata of a Group, with the default
+// metadata merged beneath it, against the metadata schemas of Profiles.
+// Groups with metadata sources or encrypted values are validated when
+// rendered instead, once their metadata is known.
+func (s *server) validateMetadata(group *storagepb.Group, profiles ...*storagepb.Profile) error {
+	if len(group.MetadataSources) > 0 || sealed.MayBeEncrypted(group.Metadata) {
+		return nil
+	}
+	group, err := s.defaults.Merge(group)
+	if err != nil {
+		return err
+	}
+	for _, profile := range profiles {
+		if err := profile.ValidateMetadata(group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *server) GroupGet(ctx context.Context, req *pb.GroupGetRequest) (*storagepb.Group, error) {
 	group, err := s.store.GroupGet(req.Id)
 	if err != nil {
@@ -94,19 +326,50 @@ func (s *server) GroupList(ctx context.Context, req *pb.GroupListRequest) ([]*st
 	return groups, nil
 }
 
+// GroupDelete deletes a machine Group by id.
+func (s *server) GroupDelete(ctx context.Context, req *pb.GroupDeleteRequest) error {
+	return s.store.GroupDelete(req.Id)
+}
+
 func (s *server) ProfilePut(ctx context.Context, req *pb.ProfilePutRequest) (*storagepb.Profile, error) {
 	if err := req.Profile.AssertValid(); err != nil {
 		return nil, err
 	}
+	if len(req.Profile.MetadataSchema) > 0 {
+		groups, err := s.store.GroupList()
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range groups {
+			if (group.Profile == req.Profile.Id && group.ProfileVersion == 0) || (group.Rollout != nil && group.Rollout.Profile == req.Profile.Id) {
+				if err := s.validateMetadata(group, req.Profile); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	s.versionMu.Lock()
+	defer s.versionMu.Unlock()
 	err := s.store.ProfilePut(req.Profile)
 	if err != nil {
 		return nil, err
 	}
+	if _, err := s.snapshot(req.Profile); err != nil {
+		return nil, err
+	}
 	return req.Profile, nil
 }
 
 func (s *server) ProfileGet(ctx context.Context, req *pb.ProfileGetRequest) (*storagepb.Profile, error) {
+	if snapshot, err := s.pinnedSnapshot(ctx, req.Id); snapshot != nil || err != nil {
+		if err != nil {
+			return nil, err
+		}
+		return snapshot.Profile, nil
+	}
+	start := time.Now()
 	profile, err := s.store.ProfileGet(req.Id)
+	trace.Record(ctx, "store.ProfileGet", start, err)
 	if err != nil {
 		return nil, err
 	}
@@ -124,27 +387,199 @@ func (s *server) ProfileList(ctx context.Context, req *pb.ProfileListRequest) ([
 	return profiles, nil
 }
 
+// ProfileDelete deletes a Profile by id, unless a Group references it.
+func (s *server) ProfileDelete(ctx context.Context, req *pb.ProfileDeleteRequest) error {
+	groups, err := s.store.GroupList()
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		if group.Profile == req.Id || (group.Rollout != nil && group.Rollout.Profile == req.Id) {
+			return ErrProfileInUse
+		}
+	}
+	return s.store.ProfileDelete(req.Id)
+}
+
 // SelectGroup selects the Group whose selector matches the given labels.
 // Groups are evaluated in sorted order from most selectors to least, using
-// alphabetical order as a deterministic tie-breaker.
+// alphabetical order as a deterministic tie-breaker. A machine pinned to a
+// Group receives that Group instead.
 func (s *server) SelectGroup(ctx context.Context, req *pb.SelectGroupRequest) (*storagepb.Group, error) {
-	groups, err := s.store.GroupList()
+	group, _, err := s.selectGroup(ctx, req.Labels)
+	return group, err
+}
+
+// selectGroup returns the Group a machine (identified by its labels) is
+// served and how it was selected.
+func (s *server) selectGroup(ctx context.Context, labels map[string]string) (*storagepb.Group, string, error) {
+	ctx, span := trace.Start(ctx, "matcher.SelectGroup", trace.KindInternal)
+	defer span.End()
+	if group := s.machineGroup(ctx, labels); group != nil {
+		span.SetAttribute("matchbox.group", group.Id)
+		span.SetAttribute("matchbox.override", true)
+		if group.Id == rescueGroupID {
+			return group, MatchRescue, nil
+		}
+		return group, MatchDecommission, nil
+	}
+	if group := s.pinnedGroup(ctx, labels); group != nil {
+		span.SetAttribute("matchbox.group", group.Id)
+		span.SetAttribute("matchbox.pinned", true)
+		return s.rolloutGroup(ctx, group, labels), MatchPinned, nil
+	}
+	if group := s.externalGroup(ctx, labels); group != nil {
+		span.SetAttribute("matchbox.group", group.Id)
+		span.SetAttribute("matchbox.external", true)
+		return s.rolloutGroup(ctx, group, labels), MatchExternal, nil
+	}
+	index, err := s.groupIndex(ctx)
 	if err != nil {
-		return nil, err
+		span.SetError(err)
+		return nil, "", err
 	}
-	sort.Sort(sort.Reverse(storagepb.ByReqs(groups)))
-	for _, group := range groups {
-		if group.Matches(req.Labels) {
-			return group, nil
+	span.SetAttribute("matchbox.groups", len(index.groups))
+	if group := index.match(labels); group != nil {
+		span.SetAttribute("matchbox.group", group.Id)
+		return s.rolloutGroup(ctx, group, labels), MatchSelector, nil
+	}
+	if group := s.holdingGroup(labels); group != nil {
+		span.SetAttribute("matchbox.group", group.Id)
+		span.SetAttribute("matchbox.holding", true)
+		return group, MatchHolding, nil
+	}
+	span.SetError(ErrNoMatchingGroup)
+	return nil, "", ErrNoMatchingGroup
+}
+
+// pinnedGroup returns the Group a machine (identified by its labels) is
+// pinned to, or nil.
+func (s *server) pinnedGroup(ctx context.Context, labels map[string]string) *storagepb.Group {
+	machine := s.lookupMachine(ctx, labels)
+	if machine == nil || machine.Group == "" {
+		return nil
+	}
+	group, err := s.store.GroupGet(machine.Group)
+	if err != nil {
+		// the pinned Group was removed, fall back to selectors
+		return nil
+	}
+	return group
+}
+
+// machineGroup returns a Group which overrides Group matching for a machine
+// (identified by its labels): the decommission Profile of a machine being
+// decommissioned, or the rescue Profile of a machine which exceeded its
+// install attempts. Otherwise, it returns nil.
+func (s *server) machineGroup(ctx context.Context, labels map[string]string) *storagepb.Group {
+	machine := s.lookupMachine(ctx, labels)
+	if machine == nil {
+		return nil
+	}
+	if machine.State == storagepb.MachineDecommission && machine.Decommission != "" {
+		return &storagepb.Group{
+			Id:      decommissionGroupID,
+			Name:    "Decommission " + machine.Id,
+			Profile: machine.Decommission,
 		}
 	}
-	return nil, ErrNoMatchingGroup
+	if s.rescueProfile != "" && s.installAttempts > 0 && int(machine.Attempts) >= s.installAttempts {
+		return &storagepb.Group{
+			Id:      rescueGroupID,
+			Name:    "Rescue " + machine.Id,
+			Profile: s.rescueProfile,
+		}
+	}
+	return nil
+}
+
+// holdingGroup returns a Group which serves the holding Profile to a machine
+// (identified by its uuid label) which matches no Group, or nil if there is
+// no holding Profile.
+func (s *server) holdingGroup(labels map[string]string) *storagepb.Group {
+	if s.holdingProfile == "" || labels["uuid"] == "" {
+		return nil
+	}
+	return &storagepb.Group{
+		Id:      HoldingGroupID,
+		Name:    "Holding " + labels["uuid"],
+		Profile: s.holdingProfile,
+	}
+}
+
+// rolloutGroup returns a copy of a Group with its Rollout Profile if the
+// Rollout admits the machine identified by labels. Otherwise, it returns
+// the Group unchanged. Machines without a record are admitted once the
+// Rollout starts, since they have no installed Profile to upgrade.
+func (s *server) rolloutGroup(ctx context.Context, group *storagepb.Group, labels map[string]string) *storagepb.Group {
+	rollout := group.Rollout
+	if rollout == nil || rollout.AssertValid() != nil {
+		return group
+	}
+	admitted := rollout.Admitted(s.now())
+	if admitted == 0 {
+		return group
+	}
+	if admitted > 0 {
+		machine := s.lookupMachine(ctx, labels)
+		if machine != nil && !s.inRollout(ctx, group, machine, admitted) {
+			return group
+		}
+	}
+	trace.FromContext(ctx).SetAttribute("matchbox.rollout", rollout.Profile)
+	staged := group.Copy()
+	staged.Profile = rollout.Profile
+	staged.ProfileVersion = 0
+	return staged
+}
+
+// externalGroup returns the Group the external Matcher matches to labels,
+// or nil if there is no Matcher, it matches no Group, or it fails, so
+// Group selectors are used instead.
+func (s *server) externalGroup(ctx context.Context, labels map[string]string) *storagepb.Group {
+	if s.matcher == nil {
+		return nil
+	}
+	start := time.Now()
+	group, err := s.matcher.Match(ctx, labels)
+	trace.Record(ctx, "matcher.Match", start, err)
+	if err != nil {
+		return nil
+	}
+	if group.Profile != "" {
+		return group
+	}
+	group, err = s.store.GroupGet(group.Id)
+	if err != nil {
+		return nil
+	}
+	return group
+}
+
+// lookupMachine returns the Machine identified by the uuid label, or by the
+// mac label for machines known only by MAC address (e.g. from DHCP leases),
+// or nil.
+func (s *server) lookupMachine(ctx context.Context, labels map[string]string) *storagepb.Machine {
+	for _, key := range []string{"uuid", "mac"} {
+		id := labels[key]
+		if id == "" {
+			continue
+		}
+		start := time.Now()
+		machine, err := s.store.MachineGet(id)
+		trace.Record(ctx, "store.MachineGet", start, err)
+		if err == nil {
+			return machine
+		}
+	}
+	return nil
 }
 
 func (s *server) SelectProfile(ctx context.Context, req *pb.SelectProfileRequest) (*storagepb.Profile, error) {
 	group, err := s.SelectGroup(ctx, &pb.SelectGroupRequest{Labels: req.Labels})
 	if err == nil {
-		// lookup the Profile by id
+		// lookup the Profile by id, at the version the Group pins
+		ctx = WithProfileVersion(ctx, group)
 		profile, err := s.ProfileGet(ctx, &pb.ProfileGetRequest{Id: group.Profile})
 		if err == nil {
 			return profile, nil
@@ -156,24 +591,442 @@ func (s *server) SelectProfile(ctx context.Context, req *pb.SelectProfileRequest
 
 // IgnitionPut creates or updates an Ignition template by name.
 func (s *server) IgnitionPut(ctx context.Context, req *pb.IgnitionPutRequest) (string, error) {
+	s.versionMu.Lock()
+	defer s.versionMu.Unlock()
 	err := s.store.IgnitionPut(req.Name, req.Config)
 	if err != nil {
 		return "", err
 	}
+	err = s.snapshotReferencing(func(profile *storagepb.Profile) bool {
+		return profile.IgnitionId == req.Name
+	})
 	return string(req.Config), err
 }
 
 // IgnitionGet gets an Ignition template by name.
 func (s *server) IgnitionGet(ctx context.Context, name string) (string, error) {
-	return s.store.IgnitionGet(name)
+	if contents, ok := s.pinnedTemplate(ctx, name, ignitionRef); ok {
+		return contents, nil
+	}
+	start := time.Now()
+	contents, err := s.store.IgnitionGet(name)
+	trace.Record(ctx, "store.IgnitionGet", start, err)
+	return contents, err
+}
+
+// IgnitionList lists the names of all Ignition templates.
+func (s *server) IgnitionList(ctx context.Context, req *pb.IgnitionListRequest) ([]string, error) {
+	return s.store.IgnitionList()
+}
+
+// IgnitionDelete deletes an Ignition template by name, unless a Profile references it.
+func (s *server) IgnitionDelete(ctx context.Context, req *pb.IgnitionDeleteRequest) error {
+	err := s.assertUnreferenced(func(profile *storagepb.Profile) bool {
+		return profile.IgnitionId == req.Name
+	})
+	if err != nil {
+		return err
+	}
+	return s.store.IgnitionDelete(req.Name)
+}
+
+// CloudPut creates or updates a Cloud-Config template by name.
+func (s *server) CloudPut(ctx context.Context, req *pb.CloudPutRequest) (string, error) {
+	s.versionMu.Lock()
+	defer s.versionMu.Unlock()
+	err := s.store.CloudPut(req.Name, req.Config)
+	if err != nil {
+		return "", err
+	}
+	err = s.snapshotReferencing(func(profile *storagepb.Profile) bool {
+		return profile.CloudId == req.Name
+	})
+	return string(req.Config), err
 }
 
 // CloudGet gets a Cloud-Config template by name.
 func (s *server) CloudGet(ctx context.Context, name string) (string, error) {
-	return s.store.CloudGet(name)
+	if contents, ok := s.pinnedTemplate(ctx, name, cloudRef); ok {
+		return contents, nil
+	}
+	start := time.Now()
+	contents, err := s.store.CloudGet(name)
+	trace.Record(ctx, "store.CloudGet", start, err)
+	return contents, err
+}
+
+// CloudList lists the names of all Cloud-Config templates.
+func (s *server) CloudList(ctx context.Context, req *pb.CloudListRequest) ([]string, error) {
+	return s.store.CloudList()
+}
+
+// CloudDelete deletes a Cloud-Config template by name, unless a Profile references it.
+func (s *server) CloudDelete(ctx context.Context, req *pb.CloudDeleteRequest) error {
+	err := s.assertUnreferenced(func(profile *storagepb.Profile) bool {
+		return profile.CloudId == req.Name
+	})
+	if err != nil {
+		return err
+	}
+	return s.store.CloudDelete(req.Name)
+}
+
+// GenericPut creates or updates a generic template by name.
+func (s *server) GenericPut(ctx context.Context, req *pb.GenericPutRequest) (string, error) {
+	s.versionMu.Lock()
+	defer s.versionMu.Unlock()
+	err := s.store.GenericPut(req.Name, req.Config)
+	if err != nil {
+		return "", err
+	}
+	err = s.snapshotReferencing(func(profile *storagepb.Profile) bool {
+		return profile.GenericId == req.Name
+	})
+	return string(req.Config), err
 }
 
 // GenericGet gets a generic template by name.
 func (s *server) GenericGet(ctx context.Context, name string) (string, error) {
-	return s.store.GenericGet(name)
+	if contents, ok := s.pinnedTemplate(ctx, name, genericRef); ok {
+		return contents, nil
+	}
+	start := time.Now()
+	contents, err := s.store.GenericGet(name)
+	trace.Record(ctx, "store.GenericGet", start, err)
+	return contents, err
+}
+
+// GenericList lists the names of all generic templates.
+func (s *server) GenericList(ctx context.Context, req *pb.GenericListRequest) ([]string, error) {
+	return s.store.GenericList()
+}
+
+// GenericDelete deletes a generic template by name, unless a Profile references it.
+func (s *server) GenericDelete(ctx context.Context, req *pb.GenericDeleteRequest) error {
+	err := s.assertUnreferenced(func(profile *storagepb.Profile) bool {
+		return profile.GenericId == req.Name
+	})
+	if err != nil {
+		return err
+	}
+	return s.store.GenericDelete(req.Name)
+}
+
+func (s *server) MachinePut(ctx context.Context, req *pb.MachinePutRequest) (*storagepb.Machine, error) {
+	if req.Machine != nil {
+		mu := s.machineLocks.lock(req.Machine.Id)
+		mu.Lock()
+		defer mu.Unlock()
+	}
+	return s.putMachine(ctx, req)
+}
+
+// putMachine writes a Machine, with its lock held.
+func (s *server) putMachine(ctx context.Context, req *pb.MachinePutRequest) (*storagepb.Machine, error) {
+	if err := req.Machine.AssertValid(); err != nil {
+		return nil, err
+	}
+	err := s.store.MachinePut(req.Machine)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidateRollouts(req.Machine, false)
+	return req.Machine, nil
+}
+
+func (s *server) MachineGet(ctx context.Context, req *pb.MachineGetRequest) (*storagepb.Machine, error) {
+	return s.store.MachineGet(req.Id)
+}
+
+func (s *server) MachineList(ctx context.Context, req *pb.MachineListRequest) ([]*storagepb.Machine, error) {
+	machines, err := s.store.MachineList()
+	if err != nil || req.State == "" {
+		return machines, err
+	}
+	var matches []*storagepb.Machine
+	for _, machine := range machines {
+		if machine.State == req.State {
+			matches = append(matches, machine)
+		}
+	}
+	return matches, nil
+}
+
+// MachinePin pins a Machine to a Group so it receives that Group's Profile
+// regardless of selectors. Machines may be pinned before they first boot.
+func (s *server) MachinePin(ctx context.Context, req *pb.MachinePinRequest) (*storagepb.Machine, error) {
+	if req.Group != "" {
+		if _, err := s.store.GroupGet(req.Group); err != nil {
+			return nil, err
+		}
+	}
+	return s.MachineUpdate(ctx, req.Id, func(machine *storagepb.Machine) (*storagepb.Machine, error) {
+		if machine == nil {
+			machine = &storagepb.Machine{Id: req.Id}
+		}
+		machine.Group = req.Group
+		return machine, nil
+	})
+}
+
+// MachineReinstall marks a Machine, by id or MAC address, to be reinstalled,
+// clearing its reported completion and progress. Optionally, the Machine's disks are
+// marked to be wiped until it is installed again.
+func (s *server) MachineReinstall(ctx context.Context, req *pb.MachineReinstallRequest) (*storagepb.Machine, error) {
+	id, err := s.machineID(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return s.MachineUpdate(ctx, id, func(machine *storagepb.Machine) (*storagepb.Machine, error) {
+		if machine == nil {
+			return nil, storage.ErrMachineNotFound
+		}
+		machine.State = storagepb.MachineReinstall
+		machine.Completed = ""
+		machine.Payload = nil
+		machine.Progress = nil
+		machine.Attempts = 0
+		machine.Wipe = req.Wipe
+		return machine, nil
+	})
+}
+
+// machineID returns the id of the Machine with the given id or MAC address.
+func (s *server) machineID(id string) (string, error) {
+	_, err := s.store.MachineGet(id)
+	if err != nil {
+		if machine := s.machineByMAC(id); machine != nil {
+			return machine.Id, nil
+		}
+	}
+	return id, err
+}
+
+// machineByMAC returns the Machine whose "mac" label is the given MAC
+// address, or nil.
+func (s *server) machineByMAC(mac string) *storagepb.Machine {
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		return nil
+	}
+	machines, err := s.store.MachineList()
+	if err != nil {
+		return nil
+	}
+	for _, machine := range machines {
+		if machine.Labels["mac"] == hw.String() {
+			return machine
+		}
+	}
+	return nil
+}
+
+// MachineClaim claims the first unclaimed Machine (ordered by id) whose
+// labels and facts match the selector for an owner, such as a Cluster API
+// infrastructure provider, and pins it to a Group. Claims are idempotent, a
+// Machine the owner has already claimed is returned.
+func (s *server) MachineClaim(ctx context.Context, req *pb.MachineClaimRequest) (*storagepb.Machine, error) {
+	if req.Owner == "" {
+		return nil, ErrOwnerRequired
+	}
+	if req.Group != "" {
+		if _, err := s.store.GroupGet(req.Group); err != nil {
+			return nil, err
+		}
+	}
+	s.claimMu.Lock()
+	defer s.claimMu.Unlock()
+	machines, err := s.store.MachineList()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(machines, func(i, j int) bool {
+		return machines[i].Id < machines[j].Id
+	})
+	for _, machine := range machines {
+		if machine.Owner == req.Owner {
+			return machine, nil
+		}
+	}
+	selector := &storagepb.Group{Selector: req.Selector}
+	claimable := func(machine *storagepb.Machine) bool {
+		return machine.Owner == "" && selector.Matches(machineLabels(machine))
+	}
+	for _, candidate := range machines {
+		if !claimable(candidate) {
+			continue
+		}
+		// re-check the candidate under its lock, it may have changed since
+		// it was listed
+		machine, err := s.MachineUpdate(ctx, candidate.Id, func(machine *storagepb.Machine) (*storagepb.Machine, error) {
+			if machine == nil || !claimable(machine) {
+				return nil, nil
+			}
+			machine.Owner = req.Owner
+			machine.Group = req.Group
+			return machine, nil
+		})
+		if machine != nil || err != nil {
+			return machine, err
+		}
+	}
+	return nil, ErrNoMachineCapacity
+}
+
+// MachineRelease releases a claimed Machine and unpins it. If an owner is
+// given, it must hold the claim.
+func (s *server) MachineRelease(ctx context.Context, req *pb.MachineReleaseRequest) (*storagepb.Machine, error) {
+	s.claimMu.Lock()
+	defer s.claimMu.Unlock()
+	return s.MachineUpdate(ctx, req.Id, func(machine *storagepb.Machine) (*storagepb.Machine, error) {
+		if machine == nil {
+			return nil, storage.ErrMachineNotFound
+		}
+		if req.Owner != "" && machine.Owner != req.Owner {
+			return nil, ErrMachineClaimed
+		}
+		machine.Owner = ""
+		machine.Group = ""
+		return machine, nil
+	})
+}
+
+// MachineDecommission marks a Machine, by id or MAC address, to be
+// decommissioned. The Machine is served the given Profile instead of its
+// Group's until it reports completion and is archived.
+func (s *server) MachineDecommission(ctx context.Context, req *pb.MachineDecommissionRequest) (*storagepb.Machine, error) {
+	if req.Profile == "" {
+		return nil, ErrProfileRequired
+	}
+	if _, err := s.store.ProfileGet(req.Profile); err != nil {
+		return nil, err
+	}
+	id, err := s.machineID(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return s.MachineUpdate(ctx, id, func(machine *storagepb.Machine) (*storagepb.Machine, error) {
+		if machine == nil {
+			return nil, storage.ErrMachineNotFound
+		}
+		machine.State = storagepb.MachineDecommission
+		machine.Decommission = req.Profile
+		machine.Completed = ""
+		machine.Payload = nil
+		machine.Progress = nil
+		return machine, nil
+	})
+}
+
+// MachineAdopt adopts a pending Machine, by id or MAC address, into a Group.
+// The Machine is pinned to the Group, so it is served the Group's Profile
+// instead of the holding Profile when it next boots.
+func (s *server) MachineAdopt(ctx context.Context, req *pb.MachineAdoptRequest) (*storagepb.Machine, error) {
+	if req.Group == "" {
+		return nil, ErrGroupRequired
+	}
+	if _, err := s.store.GroupGet(req.Group); err != nil {
+		return nil, err
+	}
+	id, err := s.machineID(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return s.MachineUpdate(ctx, id, func(machine *storagepb.Machine) (*storagepb.Machine, error) {
+		if machine == nil {
+			return nil, storage.ErrMachineNotFound
+		}
+		if machine.State != storagepb.MachinePending {
+			return nil, ErrMachineNotPending
+		}
+		machine.Group = req.Group
+		machine.State = storagepb.MachineBooted
+		machine.Attempts = 0
+		return machine, nil
+	})
+}
+
+// MachineArchive archives a decommissioned Machine, removing it from the
+// Machines.
+func (s *server) MachineArchive(ctx context.Context, machine *storagepb.Machine) error {
+	if err := machine.AssertValid(); err != nil {
+		return err
+	}
+	mu := s.machineLocks.lock(machine.Id)
+	mu.Lock()
+	defer mu.Unlock()
+	if err := s.store.MachineArchive(machine); err != nil {
+		return err
+	}
+	s.invalidateRollouts(machine, true)
+	return nil
+}
+
+// machineLabels returns a Machine's facts and reported labels, which take
+// precedence.
+func machineLabels(machine *storagepb.Machine) map[string]string {
+	labels := make(map[string]string, len(machine.Labels)+len(machine.Facts))
+	for key, value := range machine.Facts {
+		labels[key] = value
+	}
+	for key, value := range machine.Labels {
+		labels[key] = value
+	}
+	return labels
+}
+
+// MachineLabels returns the labels with the facts of the Machine they
+// identify added, so Groups may select on discovered facts. Labels reported
+// by the machine take precedence over facts.
+func (s *server) MachineLabels(ctx context.Context, labels map[string]string) map[string]string {
+	machine := s.lookupMachine(ctx, labels)
+	if machine == nil || len(machine.Facts) == 0 {
+		return labels
+	}
+	merged := make(map[string]string, len(labels)+len(machine.Facts))
+	for key, value := range machine.Facts {
+		merged[key] = value
+	}
+	for key, value := range labels {
+		merged[key] = value
+	}
+	return merged
+}
+
+// assertUnreferenced returns ErrTemplateInUse if any Profile references a
+// template, so templates are not removed from under booting machines.
+func (s *server) assertUnreferenced(references func(*storagepb.Profile) bool) error {
+	profiles, err := s.store.ProfileList()
+	if err != nil {
+		return err
+	}
+	for _, profile := range profiles {
+		if references(profile) {
+			return ErrTemplateInUse
+		}
+	}
+	return nil
+}
+
+// TokenCreate mints a single-use token bound to the uuid and/or mac labels.
+// Tokens are held in memory and do not survive restarts.
+func (s *server) TokenCreate(ctx context.Context, req *pb.TokenCreateRequest) (string, error) {
+	return s.tokens.create(req.Labels, time.Duration(req.Ttl)*time.Second)
+}
+
+// TokenRedeem invalidates a token if it is valid for the given labels.
+func (s *server) TokenRedeem(ctx context.Context, req *pb.TokenRedeemRequest) error {
+	return s.tokens.redeem(req.Token, req.Labels)
+}
+
+// TokenRestore makes a token redeemed for the given labels valid again,
+// until it expires, so a machine may retry a request which failed.
+func (s *server) TokenRestore(ctx context.Context, req *pb.TokenRedeemRequest) error {
+	return s.tokens.restore(req.Token, req.Labels)
+}
+
+// TokenCheck returns an error unless a token is valid for the given labels,
+// whether or not it was redeemed, without redeeming it.
+func (s *server) TokenCheck(ctx context.Context, req *pb.TokenRedeemRequest) error {
+	return s.tokens.check(req.Token, req.Labels)
 }
@@ -1,11 +1,15 @@
 package server
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"context"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/coreos/matchbox/matchbox/defaults"
+	"github.com/coreos/matchbox/matchbox/selectors"
 	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
 	"github.com/coreos/matchbox/matchbox/storage"
 	"github.com/coreos/matchbox/matchbox/storage/storagepb"
@@ -29,7 +33,58 @@ func TestSelectGroup(t *testing.T) {
 		{&fake.EmptyStore{}, map[string]string{"a": "b"}, nil, ErrNoMatchingGroup},
 	}
 	for _, c := range cases {
-		srv := NewServer(&Config{c.store})
+		srv := NewServer(&Config{Store: c.store})
+		group, err := srv.SelectGroup(context.Background(), &pb.SelectGroupRequest{Labels: c.labels})
+		if assert.Equal(t, c.err, err) {
+			assert.Equal(t, c.group, group)
+		}
+	}
+}
+
+// fakeMatcher matches fixed labels to a Group.
+type fakeMatcher struct {
+	labels map[string]string
+	group  *storagepb.Group
+	err    error
+}
+
+func (m *fakeMatcher) Match(ctx context.Context, labels map[string]string) (*storagepb.Group, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for key, value := range m.labels {
+		if labels[key] != value {
+			return nil, errors.New("no match")
+		}
+	}
+	return m.group, nil
+}
+
+func TestSelectGroup_Matcher(t *testing.T) {
+	store := &fake.FixedStore{
+		Groups: map[string]*storagepb.Group{fake.Group.Id: fake.Group},
+	}
+	external := &storagepb.Group{Id: "asset-db", Profile: "worker", Metadata: []byte(`{"rack":"r1"}`)}
+	cases := []struct {
+		matcher Matcher
+		labels  map[string]string
+		group   *storagepb.Group
+		err     error
+	}{
+		// external Group with a Profile
+		{&fakeMatcher{labels: map[string]string{"uuid": "e5f6"}, group: external}, map[string]string{"uuid": "e5f6"}, external, nil},
+		// external reference to a stored Group
+		{&fakeMatcher{labels: map[string]string{"uuid": "e5f6"}, group: &storagepb.Group{Id: fake.Group.Id}}, map[string]string{"uuid": "e5f6"}, fake.Group, nil},
+		// external reference to a missing Group falls back to selectors
+		{&fakeMatcher{group: &storagepb.Group{Id: "missing"}}, map[string]string{"uuid": "a1b2c3d4"}, fake.Group, nil},
+		// no external match falls back to selectors
+		{&fakeMatcher{labels: map[string]string{"uuid": "e5f6"}, group: external}, map[string]string{"uuid": "a1b2c3d4"}, fake.Group, nil},
+		// matcher errors fall back to selectors
+		{&fakeMatcher{err: errors.New("unavailable")}, map[string]string{"uuid": "a1b2c3d4"}, fake.Group, nil},
+		{&fakeMatcher{err: errors.New("unavailable")}, map[string]string{"uuid": "e5f6"}, nil, ErrNoMatchingGroup},
+	}
+	for _, c := range cases {
+		srv := NewServer(&Config{Store: store, Matcher: c.matcher})
 		group, err := srv.SelectGroup(context.Background(), &pb.SelectGroupRequest{Labels: c.labels})
 		if assert.Equal(t, c.err, err) {
 			assert.Equal(t, c.group, group)
@@ -60,7 +115,7 @@ func TestSelectProfile(t *testing.T) {
 		{&fake.EmptyStore{}, map[string]string{"a": "b"}, nil, ErrNoMatchingGroup},
 	}
 	for _, c := range cases {
-		srv := NewServer(&Config{c.store})
+		srv := NewServer(&Config{Store: c.store})
 		profile, err := srv.SelectProfile(context.Background(), &pb.SelectProfileRequest{Labels: c.labels})
 		if assert.Equal(t, c.err, err) {
 			assert.Equal(t, c.profile, profile)
@@ -87,11 +142,94 @@ func TestGroupCreate_Invalid(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestGroupCreate_MetadataSchema(t *testing.T) {
+	profile := fake.Profile.Copy()
+	profile.MetadataSchema = []byte(`{"required": ["service_name", "etcd_name"]}`)
+	store := &fake.FixedStore{
+		Groups:   map[string]*storagepb.Group{},
+		Profiles: map[string]*storagepb.Profile{profile.Id: profile},
+	}
+	srv := NewServer(&Config{Store: store})
+	// assert that:
+	// - Groups whose metadata doesn't match the Profile schema are rejected
+	// - Groups with encrypted values are validated when rendered instead
+	_, err := srv.GroupPut(context.Background(), &pb.GroupPutRequest{Group: fake.Group})
+	if assert.IsType(t, &storagepb.MetadataError{}, err) {
+		assert.Equal(t, `group "test-group" metadata does not match profile "g1h2i3j4" metadata schema: missing required key "etcd_name"`, err.Error())
+	}
+	assert.Empty(t, store.Groups)
+	sealed := fake.Group.Copy()
+	sealed.Metadata = []byte(`{"service_name":"etcd2","etcd_name":"transit:matchbox:vault:v1:abc"}`)
+	_, err = srv.GroupPut(context.Background(), &pb.GroupPutRequest{Group: sealed})
+	assert.Nil(t, err)
+}
+
+func TestGroupCreate_MetadataDefaults(t *testing.T) {
+	profile := fake.Profile.Copy()
+	profile.MetadataSchema = []byte(`{"required": ["service_name", "etcd_name"]}`)
+	store := &fake.FixedStore{
+		Groups:   map[string]*storagepb.Group{},
+		Profiles: map[string]*storagepb.Profile{profile.Id: profile},
+	}
+	srv := NewServer(&Config{
+		Store:    store,
+		Defaults: defaults.New(map[string]interface{}{"etcd_name": "default"}),
+	})
+	// assert that Group metadata is validated with the default metadata
+	// merged beneath it, but stored without it
+	_, err := srv.GroupPut(context.Background(), &pb.GroupPutRequest{Group: fake.Group})
+	assert.Nil(t, err)
+	assert.Equal(t, fake.Group.Metadata, store.Groups[fake.Group.Id].Metadata)
+}
+
+func TestGroupCreate_Selectors(t *testing.T) {
+	store := &fake.FixedStore{
+		Groups: map[string]*storagepb.Group{},
+	}
+	registry, err := selectors.New(map[string]*selectors.Key{"rack": {}})
+	assert.Nil(t, err)
+	srv := NewServer(&Config{Store: store, Selectors: registry})
+	// assert that:
+	// - Groups with undeclared selector keys are rejected
+	// - Groups with invalid selector values are rejected
+	// - Groups with declared keys and valid values are created
+	group := &storagepb.Group{Id: "node1", Profile: "worker", Selector: map[string]string{"maC": "52:54:00:89:d8:10"}}
+	_, err = srv.GroupPut(context.Background(), &pb.GroupPutRequest{Group: group})
+	assert.IsType(t, &selectors.Error{}, err)
+	group.Selector = map[string]string{"uuid": "node1"}
+	_, err = srv.GroupPut(context.Background(), &pb.GroupPutRequest{Group: group})
+	assert.IsType(t, &selectors.Error{}, err)
+	assert.Empty(t, store.Groups)
+	group.Selector = map[string]string{"uuid": "1cd1c8e4-4e4b-4a5e-9f3d-2e5c1b0a7f6d", "rack": "r1"}
+	_, err = srv.GroupPut(context.Background(), &pb.GroupPutRequest{Group: group})
+	assert.Nil(t, err)
+	assert.Contains(t, store.Groups, "node1")
+}
+
+func TestProfileCreate_MetadataSchema(t *testing.T) {
+	store := &fake.FixedStore{
+		Groups:   map[string]*storagepb.Group{fake.Group.Id: fake.Group},
+		Profiles: map[string]*storagepb.Profile{},
+	}
+	srv := NewServer(&Config{Store: store})
+	profile := fake.Profile.Copy()
+	// assert that:
+	// - Profiles whose schema rejects the metadata of their Groups are rejected
+	// - Profiles whose schema accepts it are created
+	profile.MetadataSchema = []byte(`{"properties": {"service_name": {"enum": ["etcd3"]}}}`)
+	_, err := srv.ProfilePut(context.Background(), &pb.ProfilePutRequest{Profile: profile})
+	assert.IsType(t, &storagepb.MetadataError{}, err)
+	assert.Empty(t, store.Profiles)
+	profile.MetadataSchema = []byte(`{"properties": {"service_name": {"enum": ["etcd2", "etcd3"]}}}`)
+	_, err = srv.ProfilePut(context.Background(), &pb.ProfilePutRequest{Profile: profile})
+	assert.Nil(t, err)
+}
+
 func TestGroupList(t *testing.T) {
 	store := &fake.FixedStore{
 		Groups: map[string]*storagepb.Group{fake.Group.Id: fake.Group},
 	}
-	srv := NewServer(&Config{store})
+	srv := NewServer(&Config{Store: store})
 	groups, err := srv.GroupList(context.Background(), &pb.GroupListRequest{})
 	assert.Nil(t, err)
 	if assert.Equal(t, 1, len(groups)) {
@@ -100,7 +238,7 @@ func TestGroupList(t *testing.T) {
 }
 
 func TestGroup_BrokenStore(t *testing.T) {
-	srv := NewServer(&Config{&fake.BrokenStore{}})
+	srv := NewServer(&Config{Store: &fake.BrokenStore{}})
 	_, err := srv.GroupPut(context.Background(), &pb.GroupPutRequest{Group: fake.Group})
 	assert.Error(t, err)
 	_, err = srv.GroupGet(context.Background(), &pb.GroupGetRequest{Id: fake.Group.Id})
@@ -139,7 +277,7 @@ func TestProfileGet(t *testing.T) {
 	}{
 		{fake.Profile.Id, fake.Profile, nil},
 	}
-	srv := NewServer(&Config{store})
+	srv := NewServer(&Config{Store: store})
 	for _, c := range cases {
 		profile, err := srv.ProfileGet(context.Background(), &pb.ProfileGetRequest{Id: c.id})
 		assert.Equal(t, c.err, err)
@@ -151,7 +289,7 @@ func TestProfileList(t *testing.T) {
 	store := &fake.FixedStore{
 		Profiles: map[string]*storagepb.Profile{fake.Profile.Id: fake.Profile},
 	}
-	srv := NewServer(&Config{store})
+	srv := NewServer(&Config{Store: store})
 	profiles, err := srv.ProfileList(context.Background(), &pb.ProfileListRequest{})
 	assert.Nil(t, err)
 	if assert.Equal(t, 1, len(profiles)) {
@@ -160,14 +298,14 @@ func TestProfileList(t *testing.T) {
 }
 
 func TestProfileList_Empty(t *testing.T) {
-	srv := NewServer(&Config{&fake.EmptyStore{}})
+	srv := NewServer(&Config{Store: &fake.EmptyStore{}})
 	profiles, err := srv.ProfileList(context.Background(), &pb.ProfileListRequest{})
 	assert.Nil(t, err)
 	assert.Equal(t, 0, len(profiles))
 }
 
 func TestProfiles_BrokenStore(t *testing.T) {
-	srv := NewServer(&Config{&fake.BrokenStore{}})
+	srv := NewServer(&Config{Store: &fake.BrokenStore{}})
 	_, err := srv.ProfilePut(context.Background(), &pb.ProfilePutRequest{Profile: fake.Profile})
 	assert.Error(t, err)
 	_, err = srv.ProfileGet(context.Background(), &pb.ProfileGetRequest{Id: fake.Profile.Id})
@@ -193,7 +331,7 @@ func TestIgnitionPut(t *testing.T) {
 }
 
 func TestIgnition_BrokenStore(t *testing.T) {
-	srv := NewServer(&Config{&fake.BrokenStore{}})
+	srv := NewServer(&Config{Store: &fake.BrokenStore{}})
 	req := &pb.IgnitionPutRequest{
 		Name:   fake.IgnitionYAMLName,
 		Config: []byte(fake.IgnitionYAML),
@@ -201,3 +339,460 @@ func TestIgnition_BrokenStore(t *testing.T) {
 	_, err := srv.IgnitionPut(context.Background(), req)
 	assert.Error(t, err)
 }
+
+func TestCloudPut(t *testing.T) {
+	srv := NewServer(&Config{Store: fake.NewFixedStore()})
+	_, err := srv.CloudPut(context.Background(), &pb.CloudPutRequest{Name: "cloud.yaml", Config: []byte("#cloud-config")})
+	// assert that:
+	// - Cloud-Config template creation is successful
+	// - Cloud-Config template can be retrieved by name
+	assert.Nil(t, err)
+	template, err := srv.CloudGet(context.Background(), "cloud.yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, "#cloud-config", template)
+}
+
+func TestGenericPut(t *testing.T) {
+	srv := NewServer(&Config{Store: fake.NewFixedStore()})
+	_, err := srv.GenericPut(context.Background(), &pb.GenericPutRequest{Name: "generic.tmpl", Config: []byte("{{.uuid}}")})
+	// assert that:
+	// - generic template creation is successful
+	// - generic template can be retrieved by name
+	assert.Nil(t, err)
+	template, err := srv.GenericGet(context.Background(), "generic.tmpl")
+	assert.Nil(t, err)
+	assert.Equal(t, "{{.uuid}}", template)
+}
+
+func TestTemplateDelete(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Profiles[fake.Profile.Id] = fake.Profile
+	store.IgnitionConfigs[fake.Profile.IgnitionId] = fake.IgnitionYAML
+	store.IgnitionConfigs["unused.yaml"] = fake.IgnitionYAML
+	store.CloudConfigs["unused.yaml"] = "#cloud-config"
+	store.GenericConfigs["unused.tmpl"] = "{{.uuid}}"
+	srv := NewServer(&Config{Store: store})
+	ctx := context.Background()
+
+	// assert that:
+	// - templates referenced by a Profile are not deleted
+	// - unreferenced templates are deleted
+	err := srv.IgnitionDelete(ctx, &pb.IgnitionDeleteRequest{Name: fake.Profile.IgnitionId})
+	assert.Equal(t, ErrTemplateInUse, err)
+	assert.Contains(t, store.IgnitionConfigs, fake.Profile.IgnitionId)
+	assert.Nil(t, srv.IgnitionDelete(ctx, &pb.IgnitionDeleteRequest{Name: "unused.yaml"}))
+	assert.Nil(t, srv.CloudDelete(ctx, &pb.CloudDeleteRequest{Name: "unused.yaml"}))
+	assert.Nil(t, srv.GenericDelete(ctx, &pb.GenericDeleteRequest{Name: "unused.tmpl"}))
+	assert.NotContains(t, store.IgnitionConfigs, "unused.yaml")
+	assert.Empty(t, store.CloudConfigs)
+	assert.Empty(t, store.GenericConfigs)
+}
+
+func TestGroupProfileDelete(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups[fake.Group.Id] = fake.Group
+	store.Profiles[fake.Profile.Id] = fake.Profile
+	srv := NewServer(&Config{Store: store})
+	ctx := context.Background()
+
+	// assert that:
+	// - Profiles referenced by a Group are not deleted
+	// - Groups are deleted, then their Profile may be
+	err := srv.ProfileDelete(ctx, &pb.ProfileDeleteRequest{Id: fake.Profile.Id})
+	assert.Equal(t, ErrProfileInUse, err)
+	assert.Nil(t, srv.GroupDelete(ctx, &pb.GroupDeleteRequest{Id: fake.Group.Id}))
+	assert.Nil(t, srv.ProfileDelete(ctx, &pb.ProfileDeleteRequest{Id: fake.Profile.Id}))
+	assert.Empty(t, store.Groups)
+	assert.Empty(t, store.Profiles)
+}
+
+func TestGroupProfileDelete_Rollout(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups["workers"] = &storagepb.Group{Id: "workers", Profile: "worker", Rollout: &storagepb.Rollout{Profile: fake.Profile.Id, Start: "2026-10-15T22:00:00Z"}}
+	store.Profiles[fake.Profile.Id] = fake.Profile
+	srv := NewServer(&Config{Store: store})
+
+	// assert that:
+	// - Profiles referenced by a Group's Rollout are not deleted
+	err := srv.ProfileDelete(context.Background(), &pb.ProfileDeleteRequest{Id: fake.Profile.Id})
+	assert.Equal(t, ErrProfileInUse, err)
+}
+
+func TestMachinePut(t *testing.T) {
+	srv := NewServer(&Config{Store: fake.NewFixedStore()})
+	_, err := srv.MachinePut(context.Background(), &pb.MachinePutRequest{Machine: fake.Machine})
+	// assert that:
+	// - Machine creation is successful
+	// - Machine can be retrieved by id and listed
+	assert.Nil(t, err)
+	machine, err := srv.MachineGet(context.Background(), &pb.MachineGetRequest{Id: fake.Machine.Id})
+	assert.Nil(t, err)
+	assert.Equal(t, fake.Machine, machine)
+	machines, err := srv.MachineList(context.Background(), &pb.MachineListRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, []*storagepb.Machine{fake.Machine}, machines)
+
+	// invalid Machines are rejected
+	_, err = srv.MachinePut(context.Background(), &pb.MachinePutRequest{Machine: &storagepb.Machine{}})
+	assert.Equal(t, storagepb.ErrIdRequired, err)
+}
+
+func TestMachinePin(t *testing.T) {
+	other := &storagepb.Group{Id: "other", Profile: "other-profile"}
+	store := fake.NewFixedStore()
+	store.Groups = map[string]*storagepb.Group{fake.Group.Id: fake.Group, other.Id: other}
+	store.Machines[fake.Machine.Id] = fake.Machine
+	srv := NewServer(&Config{Store: store})
+	ctx := context.Background()
+	labels := map[string]string{"uuid": fake.Machine.Id}
+
+	// assert that:
+	// - a pinned machine receives the pinned Group, despite selectors
+	// - an unpinned machine is matched by selectors again
+	// - machines cannot be pinned to unknown Groups
+	machine, err := srv.MachinePin(ctx, &pb.MachinePinRequest{Id: fake.Machine.Id, Group: other.Id})
+	assert.Nil(t, err)
+	assert.Equal(t, other.Id, machine.Group)
+	assert.Equal(t, fake.Machine.State, machine.State)
+	group, err := srv.SelectGroup(ctx, &pb.SelectGroupRequest{Labels: labels})
+	assert.Nil(t, err)
+	assert.Equal(t, other, group)
+
+	_, err = srv.MachinePin(ctx, &pb.MachinePinRequest{Id: fake.Machine.Id})
+	assert.Nil(t, err)
+	group, err = srv.SelectGroup(ctx, &pb.SelectGroupRequest{Labels: labels})
+	assert.Nil(t, err)
+	assert.Equal(t, fake.Group, group)
+
+	_, err = srv.MachinePin(ctx, &pb.MachinePinRequest{Id: fake.Machine.Id, Group: "missing"})
+	assert.Error(t, err)
+	assert.Equal(t, "", fake.Machine.Group)
+}
+
+func TestMachineReinstall(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Machines[fake.Machine.Id] = fake.Machine
+	srv := NewServer(&Config{Store: store})
+
+	// assert that:
+	// - the Machine is marked for reinstall and its completion is cleared
+	// - unknown Machines cannot be reinstalled
+	machine, err := srv.MachineReinstall(context.Background(), &pb.MachineReinstallRequest{Id: fake.Machine.Id})
+	assert.Nil(t, err)
+	assert.Equal(t, storagepb.MachineReinstall, machine.State)
+	assert.Equal(t, "", machine.Completed)
+	assert.Equal(t, machine, store.Machines[fake.Machine.Id])
+	assert.Equal(t, storagepb.MachineProvisioned, fake.Machine.State)
+
+	_, err = srv.MachineReinstall(context.Background(), &pb.MachineReinstallRequest{Id: "missing"})
+	assert.Error(t, err)
+}
+
+func TestMachineReinstall_ByMAC(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Machines["a1b2c3d4"] = &storagepb.Machine{
+		Id:       "a1b2c3d4",
+		Labels:   map[string]string{"uuid": "a1b2c3d4", "mac": "52:54:00:a1:9c:ae"},
+		State:    storagepb.MachineProvisioned,
+		Progress: []*storagepb.MachineStep{{Name: "install-os", Status: storagepb.StepSucceeded}},
+	}
+	srv := NewServer(&Config{Store: store})
+
+	// assert that:
+	// - Machines are found by the MAC address label, in any format
+	// - progress is cleared
+	// - the wipe flag is recorded
+	// - unknown MAC addresses cannot be reinstalled
+	machine, err := srv.MachineReinstall(context.Background(), &pb.MachineReinstallRequest{Id: "52-54-00-A1-9C-AE", Wipe: true})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "a1b2c3d4", machine.Id)
+		assert.Empty(t, machine.Progress)
+		assert.Equal(t, storagepb.MachineReinstall, machine.State)
+		assert.True(t, machine.Wipe)
+	}
+	_, err = srv.MachineReinstall(context.Background(), &pb.MachineReinstallRequest{Id: "52:54:00:00:00:00"})
+	assert.Error(t, err)
+}
+
+func TestMachineLabels(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Machines["a1b2c3d4"] = &storagepb.Machine{Id: "a1b2c3d4", Facts: map[string]string{"hostname": "node1", "uuid": "other"}}
+	store.Machines["52:54:00:b2:2f:86"] = &storagepb.Machine{Id: "52:54:00:b2:2f:86", Facts: map[string]string{"ip": "10.0.0.22"}}
+	srv := NewServer(&Config{Store: store})
+	ctx := context.Background()
+
+	// assert that:
+	// - facts of the Machine identified by uuid are added
+	// - reported labels take precedence over facts
+	// - Machines known only by MAC address are identified by the mac label
+	// - labels of unknown machines are unchanged
+	labels := srv.MachineLabels(ctx, map[string]string{"uuid": "a1b2c3d4"})
+	assert.Equal(t, map[string]string{"uuid": "a1b2c3d4", "hostname": "node1"}, labels)
+	labels = srv.MachineLabels(ctx, map[string]string{"uuid": "e5f6a7b8", "mac": "52:54:00:b2:2f:86"})
+	assert.Equal(t, map[string]string{"uuid": "e5f6a7b8", "mac": "52:54:00:b2:2f:86", "ip": "10.0.0.22"}, labels)
+	labels = srv.MachineLabels(ctx, map[string]string{"uuid": "e5f6a7b8"})
+	assert.Equal(t, map[string]string{"uuid": "e5f6a7b8"}, labels)
+}
+
+func TestMachineClaim(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups[fake.Group.Id] = fake.Group
+	store.Machines["a1"] = &storagepb.Machine{Id: "a1", Facts: map[string]string{"model": "R640"}, Owner: "other"}
+	store.Machines["b2"] = &storagepb.Machine{Id: "b2", Facts: map[string]string{"model": "R640"}}
+	store.Machines["c3"] = &storagepb.Machine{Id: "c3", Facts: map[string]string{"model": "R640"}}
+	store.Machines["d4"] = &storagepb.Machine{Id: "d4", Facts: map[string]string{"model": "R740"}}
+	srv := NewServer(&Config{Store: store})
+	ctx := context.Background()
+	req := &pb.MachineClaimRequest{Selector: map[string]string{"model": "R640"}, Group: fake.Group.Id, Owner: "capi-worker-1"}
+
+	// assert that:
+	// - the first unclaimed matching Machine is claimed and pinned
+	// - claims are idempotent for the same owner
+	// - claims fail when no unclaimed Machine matches
+	// - Machines can only be released by their owner
+	machine, err := srv.MachineClaim(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, "b2", machine.Id)
+	assert.Equal(t, "capi-worker-1", machine.Owner)
+	assert.Equal(t, fake.Group.Id, machine.Group)
+	machine, err = srv.MachineClaim(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, "b2", machine.Id)
+
+	machine, err = srv.MachineClaim(ctx, &pb.MachineClaimRequest{Selector: req.Selector, Owner: "capi-worker-2"})
+	assert.Nil(t, err)
+	assert.Equal(t, "c3", machine.Id)
+	_, err = srv.MachineClaim(ctx, &pb.MachineClaimRequest{Selector: req.Selector, Owner: "capi-worker-3"})
+	assert.Equal(t, ErrNoMachineCapacity, err)
+	_, err = srv.MachineClaim(ctx, &pb.MachineClaimRequest{Selector: req.Selector})
+	assert.Equal(t, ErrOwnerRequired, err)
+
+	_, err = srv.MachineRelease(ctx, &pb.MachineReleaseRequest{Id: "b2", Owner: "capi-worker-2"})
+	assert.Equal(t, ErrMachineClaimed, err)
+	machine, err = srv.MachineRelease(ctx, &pb.MachineReleaseRequest{Id: "b2", Owner: "capi-worker-1"})
+	assert.Nil(t, err)
+	assert.Equal(t, "", machine.Owner)
+	assert.Equal(t, "", machine.Group)
+	machine, err = srv.MachineClaim(ctx, &pb.MachineClaimRequest{Selector: req.Selector, Owner: "capi-worker-3"})
+	assert.Nil(t, err)
+	assert.Equal(t, "b2", machine.Id)
+}
+
+func TestMachineDecommission(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups[fake.Group.Id] = fake.Group
+	store.Profiles["wipe"] = &storagepb.Profile{Id: "wipe"}
+	store.Machines["a1b2c3d4"] = &storagepb.Machine{
+		Id:        "a1b2c3d4",
+		Labels:    map[string]string{"uuid": "a1b2c3d4", "mac": "52:54:00:a1:9c:ae"},
+		State:     storagepb.MachineProvisioned,
+		Completed: "2017-03-01T00:00:00Z",
+	}
+	srv := NewServer(&Config{Store: store})
+
+	// assert that:
+	// - a Profile is required and must exist
+	// - the Machine is marked to be decommissioned with the Profile
+	// - the Machine is served a Group with the decommission Profile
+	_, err := srv.MachineDecommission(context.Background(), &pb.MachineDecommissionRequest{Id: "a1b2c3d4"})
+	assert.Equal(t, ErrProfileRequired, err)
+	_, err = srv.MachineDecommission(context.Background(), &pb.MachineDecommissionRequest{Id: "a1b2c3d4", Profile: "missing"})
+	assert.Error(t, err)
+	_, err = srv.MachineDecommission(context.Background(), &pb.MachineDecommissionRequest{Id: "missing", Profile: "wipe"})
+	assert.Error(t, err)
+
+	machine, err := srv.MachineDecommission(context.Background(), &pb.MachineDecommissionRequest{Id: "52:54:00:a1:9c:ae", Profile: "wipe"})
+	if assert.Nil(t, err) {
+		assert.Equal(t, storagepb.MachineDecommission, machine.State)
+		assert.Equal(t, "wipe", machine.Decommission)
+		assert.Equal(t, "", machine.Completed)
+	}
+	group, err := srv.SelectGroup(context.Background(), &pb.SelectGroupRequest{Labels: map[string]string{"uuid": "a1b2c3d4"}})
+	if assert.Nil(t, err) {
+		assert.Equal(t, decommissionGroupID, group.Id)
+		assert.Equal(t, "wipe", group.Profile)
+	}
+	profile, err := srv.SelectProfile(context.Background(), &pb.SelectProfileRequest{Labels: map[string]string{"uuid": "a1b2c3d4"}})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "wipe", profile.Id)
+	}
+}
+
+func TestSelectGroup_Holding(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups[fake.Group.Id] = fake.Group
+	srv := NewServer(&Config{Store: store, HoldingProfile: "holding"})
+	ctx := context.Background()
+
+	// assert that:
+	// - machines which match no Group are served the holding Profile
+	// - machines which match a Group are served it
+	// - machines without a uuid can't be held
+	group, err := srv.SelectGroup(ctx, &pb.SelectGroupRequest{Labels: map[string]string{"uuid": "e5f6a7b8"}})
+	if assert.Nil(t, err) {
+		assert.Equal(t, HoldingGroupID, group.Id)
+		assert.Equal(t, "holding", group.Profile)
+	}
+	group, err = srv.SelectGroup(ctx, &pb.SelectGroupRequest{Labels: map[string]string{"uuid": "a1b2c3d4"}})
+	if assert.Nil(t, err) {
+		assert.Equal(t, fake.Group.Id, group.Id)
+	}
+	_, err = srv.SelectGroup(ctx, &pb.SelectGroupRequest{Labels: map[string]string{"mac": "52:54:00:a1:9c:ae"}})
+	assert.Equal(t, ErrNoMatchingGroup, err)
+}
+
+func TestMachineAdopt(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups["workers"] = &storagepb.Group{Id: "workers", Profile: fake.Profile.Id}
+	store.Machines["e5f6a7b8"] = &storagepb.Machine{
+		Id:       "e5f6a7b8",
+		Labels:   map[string]string{"uuid": "e5f6a7b8", "mac": "52:54:00:b2:2f:86"},
+		State:    storagepb.MachinePending,
+		Attempts: 3,
+	}
+	store.Machines[fake.Machine.Id] = fake.Machine
+	srv := NewServer(&Config{Store: store, HoldingProfile: "holding"})
+	ctx := context.Background()
+
+	// assert that:
+	// - a Group is required and must exist
+	// - only pending Machines can be adopted
+	// - adopted Machines are pinned to the Group and served its Profile
+	_, err := srv.MachineAdopt(ctx, &pb.MachineAdoptRequest{Id: "e5f6a7b8"})
+	assert.Equal(t, ErrGroupRequired, err)
+	_, err = srv.MachineAdopt(ctx, &pb.MachineAdoptRequest{Id: "e5f6a7b8", Group: "missing"})
+	assert.Error(t, err)
+	_, err = srv.MachineAdopt(ctx, &pb.MachineAdoptRequest{Id: fake.Machine.Id, Group: "workers"})
+	assert.Equal(t, ErrMachineNotPending, err)
+
+	pending, err := srv.MachineList(ctx, &pb.MachineListRequest{State: storagepb.MachinePending})
+	if assert.Nil(t, err) && assert.Len(t, pending, 1) {
+		assert.Equal(t, "e5f6a7b8", pending[0].Id)
+	}
+	machine, err := srv.MachineAdopt(ctx, &pb.MachineAdoptRequest{Id: "52:54:00:b2:2f:86", Group: "workers"})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "workers", machine.Group)
+		assert.Equal(t, storagepb.MachineBooted, machine.State)
+		assert.Equal(t, int32(0), machine.Attempts)
+	}
+	group, err := srv.SelectGroup(ctx, &pb.SelectGroupRequest{Labels: map[string]string{"uuid": "e5f6a7b8"}})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "workers", group.Id)
+	}
+	pending, err = srv.MachineList(ctx, &pb.MachineListRequest{State: storagepb.MachinePending})
+	assert.Nil(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestMachineArchive(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Machines["a1b2c3d4"] = &storagepb.Machine{Id: "a1b2c3d4", State: storagepb.MachineDecommissioned}
+	srv := NewServer(&Config{Store: store})
+
+	// assert that:
+	// - the Machine is archived and removed
+	// - invalid Machines are not archived
+	err := srv.MachineArchive(context.Background(), store.Machines["a1b2c3d4"])
+	assert.Nil(t, err)
+	assert.Empty(t, store.Machines)
+	assert.Len(t, store.Archive, 1)
+	assert.Equal(t, storagepb.ErrIdRequired, srv.MachineArchive(context.Background(), &storagepb.Machine{}))
+}
+
+func TestSelectGroup_Rescue(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups[fake.Group.Id] = fake.Group
+	store.Machines["a1b2c3d4"] = &storagepb.Machine{Id: "a1b2c3d4", State: storagepb.MachineBooted, Attempts: 2}
+	store.Machines["b2c3d4e5"] = &storagepb.Machine{Id: "b2c3d4e5", State: storagepb.MachineBooted, Attempts: 3}
+	srv := NewServer(&Config{Store: store, InstallAttempts: 3, RescueProfile: "rescue"})
+
+	// assert that:
+	// - machines within their install attempts match Groups as usual
+	// - machines which exceeded their install attempts get the rescue Profile
+	group, err := srv.SelectGroup(context.Background(), &pb.SelectGroupRequest{Labels: map[string]string{"uuid": "a1b2c3d4"}})
+	if assert.Nil(t, err) {
+		assert.Equal(t, fake.Group.Id, group.Id)
+	}
+	group, err = srv.SelectGroup(context.Background(), &pb.SelectGroupRequest{Labels: map[string]string{"uuid": "b2c3d4e5"}})
+	if assert.Nil(t, err) {
+		assert.Equal(t, rescueGroupID, group.Id)
+		assert.Equal(t, "rescue", group.Profile)
+	}
+
+	// - machines aren't rescued if install attempts aren't limited
+	store.Machines["a1b2c3d4"].Attempts = 5
+	srv = NewServer(&Config{Store: store})
+	group, err = srv.SelectGroup(context.Background(), &pb.SelectGroupRequest{Labels: map[string]string{"uuid": "a1b2c3d4"}})
+	if assert.Nil(t, err) {
+		assert.Equal(t, fake.Group.Id, group.Id)
+	}
+}
+
+func TestSelectGroup_Rollout(t *testing.T) {
+	start := time.Date(2026, 10, 15, 22, 0, 0, 0, time.UTC)
+	group := &storagepb.Group{
+		Id:       "workers",
+		Profile:  "worker",
+		Selector: map[string]string{"role": "worker"},
+		Rollout:  &storagepb.Rollout{Profile: "worker-next", Start: start.Format(time.RFC3339), BatchSize: 1, Interval: "1h"},
+	}
+	store := fake.NewFixedStore()
+	store.Groups[group.Id] = group
+	ids := []string{"a1", "b2", "c3"}
+	for _, id := range ids {
+		store.Machines[id] = &storagepb.Machine{Id: id, Labels: map[string]string{"uuid": id, "role": "worker"}}
+	}
+	srv := NewServer(&Config{Store: store})
+	now := start.Add(-time.Minute)
+	srv.(*server).now = func() time.Time { return now }
+
+	profiles := func() map[string]int {
+		counts := make(map[string]int)
+		for _, id := range ids {
+			selected, err := srv.SelectGroup(context.Background(), &pb.SelectGroupRequest{Labels: map[string]string{"uuid": id, "role": "worker"}})
+			if assert.Nil(t, err) {
+				assert.Equal(t, group.Id, selected.Id)
+				counts[selected.Profile]++
+			}
+		}
+		return counts
+	}
+
+	// assert that:
+	// - machines get the Group Profile before the Rollout starts
+	// - a batch of machines gets the Rollout Profile each interval
+	// - the stored Group is not modified
+	// - machines without a record get the Rollout Profile once it starts
+	assert.Equal(t, map[string]int{"worker": 3}, profiles())
+	now = start
+	assert.Equal(t, map[string]int{"worker": 2, "worker-next": 1}, profiles())
+	now = start.Add(90 * time.Minute)
+	assert.Equal(t, map[string]int{"worker": 1, "worker-next": 2}, profiles())
+	now = start.Add(2 * time.Hour)
+	assert.Equal(t, map[string]int{"worker-next": 3}, profiles())
+	assert.Equal(t, "worker", store.Groups[group.Id].Profile)
+
+	selected, err := srv.SelectGroup(context.Background(), &pb.SelectGroupRequest{Labels: map[string]string{"uuid": "d4", "role": "worker"}})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "worker-next", selected.Profile)
+	}
+}
+
+func TestMaintenanceSet(t *testing.T) {
+	srv := NewServer(&Config{Store: fake.NewFixedStore()})
+	ctx := context.Background()
+	// assert that:
+	// - servers start out of maintenance
+	// - maintenance modes may be entered and left
+	// - unknown modes are rejected
+	assert.Equal(t, "", srv.MaintenanceGet(ctx))
+	mode, err := srv.MaintenanceSet(ctx, &pb.MaintenanceSetRequest{Mode: MaintenanceHold})
+	assert.Nil(t, err)
+	assert.Equal(t, MaintenanceHold, mode)
+	assert.Equal(t, MaintenanceHold, srv.MaintenanceGet(ctx))
+	_, err = srv.MaintenanceSet(ctx, &pb.MaintenanceSetRequest{Mode: "reboot"})
+	assert.Equal(t, ErrInvalidMaintenance, err)
+	assert.Equal(t, MaintenanceHold, srv.MaintenanceGet(ctx))
+	srv.MaintenanceSet(ctx, &pb.MaintenanceSetRequest{})
+	assert.Equal(t, "", srv.MaintenanceGet(ctx))
+}
@@ -19,14 +19,88 @@ It has these top-level messages:
 	GroupListRequest
 	GroupGetResponse
 	GroupListResponse
+	GroupDeleteRequest
 	ProfilePutRequest
 	ProfilePutResponse
 	ProfileGetRequest
 	ProfileGetResponse
 	ProfileListRequest
 	ProfileListResponse
+	ProfileDeleteRequest
+	ProfileVersionListRequest
+	ProfileVersionListResponse
+	ProfileRollbackRequest
+	ProfileRollbackResponse
 	IgnitionPutRequest
 	IgnitionPutResponse
+	IgnitionGetRequest
+	IgnitionGetResponse
+	IgnitionListRequest
+	IgnitionListResponse
+	IgnitionDeleteRequest
+	IgnitionDeleteResponse
+	CloudPutRequest
+	CloudPutResponse
+	CloudGetRequest
+	CloudGetResponse
+	CloudListRequest
+	CloudListResponse
+	CloudDeleteRequest
+	CloudDeleteResponse
+	GenericPutRequest
+	GenericPutResponse
+	GenericGetRequest
+	GenericGetResponse
+	GenericListRequest
+	GenericListResponse
+	GenericDeleteRequest
+	GenericDeleteResponse
+	MachinePutRequest
+	MachineGetRequest
+	MachinePutResponse
+	MachineGetResponse
+	MachineListRequest
+	MachineListResponse
+	MachinePinRequest
+	MachinePinResponse
+	MachineReinstallRequest
+	MachineReinstallResponse
+	MachineClaimRequest
+	MachineClaimResponse
+	MachineReleaseRequest
+	MachineReleaseResponse
+	MachineDecommissionRequest
+	MachineDecommissionResponse
+	MachineAdoptRequest
+	MachineAdoptResponse
+	MachineDescribeRequest
+	MachineDescribeResponse
+	PowerRequest
+	PowerResponse
+	AssetPutRequest
+	AssetPutResponse
+	AssetFetchRequest
+	AssetFetchResponse
+	AssetGetRequest
+	AssetGetResponse
+	AssetDeleteRequest
+	AssetDeleteResponse
+	TokenCreateRequest
+	TokenCreateResponse
+	TokenRedeemRequest
+	RenderRequest
+	RenderResponse
+	RenderTemplateRequest
+	RenderTemplateResponse
+	TemplateProblem
+	BootEventsRequest
+	BootEvent
+	EventListRequest
+	EventListResponse
+	MaintenanceGetRequest
+	MaintenanceGetResponse
+	MaintenanceSetRequest
+	MaintenanceSetResponse
 */
 package serverpb
 
@@ -127,6 +201,8 @@ func (m *GroupPutRequest) GetGroup() *storagepb.Group {
 }
 
 type GroupPutResponse struct {
+	// uses of deprecated fields
+	Warnings []string `protobuf:"bytes,1,rep,name=warnings" json:"warnings,omitempty"`
 }
 
 func (m *GroupPutResponse) Reset()                    { *m = GroupPutResponse{} }
@@ -134,6 +210,13 @@ func (m *GroupPutResponse) String() string            { return proto.CompactText
 func (*GroupPutResponse) ProtoMessage()               {}
 func (*GroupPutResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
 
+func (m *GroupPutResponse) GetWarnings() []string {
+	if m != nil {
+		return m.Warnings
+	}
+	return nil
+}
+
 type GroupGetRequest struct {
 	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
 }
@@ -160,6 +243,8 @@ func (*GroupListRequest) Descriptor() ([]byte, []int) { return fileDescriptor0,
 
 type GroupGetResponse struct {
 	Group *storagepb.Group `protobuf:"bytes,1,opt,name=group" json:"group,omitempty"`
+	// sha256:hex checksum of the stored group, to detect changes
+	Checksum string `protobuf:"bytes,2,opt,name=checksum" json:"checksum,omitempty"`
 }
 
 func (m *GroupGetResponse) Reset()                    { *m = GroupGetResponse{} }
@@ -174,6 +259,13 @@ func (m *GroupGetResponse) GetGroup() *storagepb.Group {
 	return nil
 }
 
+func (m *GroupGetResponse) GetChecksum() string {
+	if m != nil {
+		return m.Checksum
+	}
+	return ""
+}
+
 type GroupListResponse struct {
 	Groups []*storagepb.Group `protobuf:"bytes,1,rep,name=groups" json:"groups,omitempty"`
 }
@@ -190,6 +282,22 @@ func (m *GroupListResponse) GetGroups() []*storagepb.Group {
 	return nil
 }
 
+type GroupDeleteRequest struct {
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+}
+
+func (m *GroupDeleteRequest) Reset()                    { *m = GroupDeleteRequest{} }
+func (m *GroupDeleteRequest) String() string            { return proto.CompactTextString(m) }
+func (*GroupDeleteRequest) ProtoMessage()               {}
+func (*GroupDeleteRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+func (m *GroupDeleteRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
 type ProfilePutRequest struct {
 	Profile *storagepb.Profile `protobuf:"bytes,1,opt,name=profile" json:"profile,omitempty"`
 }
@@ -197,7 +305,7 @@ type ProfilePutRequest struct {
 func (m *ProfilePutRequest) Reset()                    { *m = ProfilePutRequest{} }
 func (m *ProfilePutRequest) String() string            { return proto.CompactTextString(m) }
 func (*ProfilePutRequest) ProtoMessage()               {}
-func (*ProfilePutRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+func (*ProfilePutRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
 
 func (m *ProfilePutRequest) GetProfile() *storagepb.Profile {
 	if m != nil {
@@ -207,12 +315,21 @@ func (m *ProfilePutRequest) GetProfile() *storagepb.Profile {
 }
 
 type ProfilePutResponse struct {
+	// uses of deprecated fields
+	Warnings []string `protobuf:"bytes,1,rep,name=warnings" json:"warnings,omitempty"`
 }
 
 func (m *ProfilePutResponse) Reset()                    { *m = ProfilePutResponse{} }
 func (m *ProfilePutResponse) String() string            { return proto.CompactTextString(m) }
 func (*ProfilePutResponse) ProtoMessage()               {}
-func (*ProfilePutResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+func (*ProfilePutResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+func (m *ProfilePutResponse) GetWarnings() []string {
+	if m != nil {
+		return m.Warnings
+	}
+	return nil
+}
 
 type ProfileGetRequest struct {
 	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
@@ -221,7 +338,7 @@ type ProfileGetRequest struct {
 func (m *ProfileGetRequest) Reset()                    { *m = ProfileGetRequest{} }
 func (m *ProfileGetRequest) String() string            { return proto.CompactTextString(m) }
 func (*ProfileGetRequest) ProtoMessage()               {}
-func (*ProfileGetRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+func (*ProfileGetRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
 
 func (m *ProfileGetRequest) GetId() string {
 	if m != nil {
@@ -232,12 +349,14 @@ func (m *ProfileGetRequest) GetId() string {
 
 type ProfileGetResponse struct {
 	Profile *storagepb.Profile `protobuf:"bytes,1,opt,name=profile" json:"profile,omitempty"`
+	// sha256:hex checksum of the stored profile, to detect changes
+	Checksum string `protobuf:"bytes,2,opt,name=checksum" json:"checksum,omitempty"`
 }
 
 func (m *ProfileGetResponse) Reset()                    { *m = ProfileGetResponse{} }
 func (m *ProfileGetResponse) String() string            { return proto.CompactTextString(m) }
 func (*ProfileGetResponse) ProtoMessage()               {}
-func (*ProfileGetResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+func (*ProfileGetResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
 
 func (m *ProfileGetResponse) GetProfile() *storagepb.Profile {
 	if m != nil {
@@ -246,13 +365,20 @@ func (m *ProfileGetResponse) GetProfile() *storagepb.Profile {
 	return nil
 }
 
+func (m *ProfileGetResponse) GetChecksum() string {
+	if m != nil {
+		return m.Checksum
+	}
+	return ""
+}
+
 type ProfileListRequest struct {
 }
 
 func (m *ProfileListRequest) Reset()                    { *m = ProfileListRequest{} }
 func (m *ProfileListRequest) String() string            { return proto.CompactTextString(m) }
 func (*ProfileListRequest) ProtoMessage()               {}
-func (*ProfileListRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+func (*ProfileListRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
 
 type ProfileListResponse struct {
 	Profiles []*storagepb.Profile `protobuf:"bytes,1,rep,name=profiles" json:"profiles,omitempty"`
@@ -261,7 +387,7 @@ type ProfileListResponse struct {
 func (m *ProfileListResponse) Reset()                    { *m = ProfileListResponse{} }
 func (m *ProfileListResponse) String() string            { return proto.CompactTextString(m) }
 func (*ProfileListResponse) ProtoMessage()               {}
-func (*ProfileListResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+func (*ProfileListResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
 
 func (m *ProfileListResponse) GetProfiles() []*storagepb.Profile {
 	if m != nil {
@@ -270,6 +396,97 @@ func (m *ProfileListResponse) GetProfiles() []*storagepb.Profile {
 	return nil
 }
 
+type ProfileDeleteRequest struct {
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+}
+
+func (m *ProfileDeleteRequest) Reset()                    { *m = ProfileDeleteRequest{} }
+func (m *ProfileDeleteRequest) String() string            { return proto.CompactTextString(m) }
+func (*ProfileDeleteRequest) ProtoMessage()               {}
+func (*ProfileDeleteRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *ProfileDeleteRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type ProfileVersionListRequest struct {
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+}
+
+func (m *ProfileVersionListRequest) Reset()                    { *m = ProfileVersionListRequest{} }
+func (m *ProfileVersionListRequest) String() string            { return proto.CompactTextString(m) }
+func (*ProfileVersionListRequest) ProtoMessage()               {}
+func (*ProfileVersionListRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *ProfileVersionListRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type ProfileVersionListResponse struct {
+	// snapshots of the Profile, oldest first
+	Versions []*storagepb.ProfileVersion `protobuf:"bytes,1,rep,name=versions" json:"versions,omitempty"`
+}
+
+func (m *ProfileVersionListResponse) Reset()                    { *m = ProfileVersionListResponse{} }
+func (m *ProfileVersionListResponse) String() string            { return proto.CompactTextString(m) }
+func (*ProfileVersionListResponse) ProtoMessage()               {}
+func (*ProfileVersionListResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *ProfileVersionListResponse) GetVersions() []*storagepb.ProfileVersion {
+	if m != nil {
+		return m.Versions
+	}
+	return nil
+}
+
+type ProfileRollbackRequest struct {
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	// version to restore, the version before the latest if 0
+	Version int32 `protobuf:"varint,2,opt,name=version" json:"version,omitempty"`
+}
+
+func (m *ProfileRollbackRequest) Reset()                    { *m = ProfileRollbackRequest{} }
+func (m *ProfileRollbackRequest) String() string            { return proto.CompactTextString(m) }
+func (*ProfileRollbackRequest) ProtoMessage()               {}
+func (*ProfileRollbackRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+
+func (m *ProfileRollbackRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *ProfileRollbackRequest) GetVersion() int32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+type ProfileRollbackResponse struct {
+	// snapshot recording the restored Profile
+	Version *storagepb.ProfileVersion `protobuf:"bytes,1,opt,name=version" json:"version,omitempty"`
+}
+
+func (m *ProfileRollbackResponse) Reset()                    { *m = ProfileRollbackResponse{} }
+func (m *ProfileRollbackResponse) String() string            { return proto.CompactTextString(m) }
+func (*ProfileRollbackResponse) ProtoMessage()               {}
+func (*ProfileRollbackResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+
+func (m *ProfileRollbackResponse) GetVersion() *storagepb.ProfileVersion {
+	if m != nil {
+		return m.Version
+	}
+	return nil
+}
+
 type IgnitionPutRequest struct {
 	Name   string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
 	Config []byte `protobuf:"bytes,2,opt,name=config,proto3" json:"config,omitempty"`
@@ -278,7 +495,7 @@ type IgnitionPutRequest struct {
 func (m *IgnitionPutRequest) Reset()                    { *m = IgnitionPutRequest{} }
 func (m *IgnitionPutRequest) String() string            { return proto.CompactTextString(m) }
 func (*IgnitionPutRequest) ProtoMessage()               {}
-func (*IgnitionPutRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+func (*IgnitionPutRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
 
 func (m *IgnitionPutRequest) GetName() string {
 	if m != nil {
@@ -300,7 +517,1465 @@ type IgnitionPutResponse struct {
 func (m *IgnitionPutResponse) Reset()                    { *m = IgnitionPutResponse{} }
 func (m *IgnitionPutResponse) String() string            { return proto.CompactTextString(m) }
 func (*IgnitionPutResponse) ProtoMessage()               {}
-func (*IgnitionPutResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+func (*IgnitionPutResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+
+type IgnitionGetRequest struct {
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *IgnitionGetRequest) Reset()                    { *m = IgnitionGetRequest{} }
+func (m *IgnitionGetRequest) String() string            { return proto.CompactTextString(m) }
+func (*IgnitionGetRequest) ProtoMessage()               {}
+func (*IgnitionGetRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
+
+func (m *IgnitionGetRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type IgnitionGetResponse struct {
+	Config []byte `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`
+	// sha256:hex checksum of the template, to detect changes
+	Checksum string `protobuf:"bytes,2,opt,name=checksum" json:"checksum,omitempty"`
+}
+
+func (m *IgnitionGetResponse) Reset()                    { *m = IgnitionGetResponse{} }
+func (m *IgnitionGetResponse) String() string            { return proto.CompactTextString(m) }
+func (*IgnitionGetResponse) ProtoMessage()               {}
+func (*IgnitionGetResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
+
+func (m *IgnitionGetResponse) GetConfig() []byte {
+	if m != nil {
+		return m.Config
+	}
+	return nil
+}
+
+func (m *IgnitionGetResponse) GetChecksum() string {
+	if m != nil {
+		return m.Checksum
+	}
+	return ""
+}
+
+type IgnitionListRequest struct {
+}
+
+func (m *IgnitionListRequest) Reset()                    { *m = IgnitionListRequest{} }
+func (m *IgnitionListRequest) String() string            { return proto.CompactTextString(m) }
+func (*IgnitionListRequest) ProtoMessage()               {}
+func (*IgnitionListRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
+
+type IgnitionListResponse struct {
+	Names []string `protobuf:"bytes,1,rep,name=names" json:"names,omitempty"`
+}
+
+func (m *IgnitionListResponse) Reset()                    { *m = IgnitionListResponse{} }
+func (m *IgnitionListResponse) String() string            { return proto.CompactTextString(m) }
+func (*IgnitionListResponse) ProtoMessage()               {}
+func (*IgnitionListResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
+
+func (m *IgnitionListResponse) GetNames() []string {
+	if m != nil {
+		return m.Names
+	}
+	return nil
+}
+
+type IgnitionDeleteRequest struct {
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *IgnitionDeleteRequest) Reset()                    { *m = IgnitionDeleteRequest{} }
+func (m *IgnitionDeleteRequest) String() string            { return proto.CompactTextString(m) }
+func (*IgnitionDeleteRequest) ProtoMessage()               {}
+func (*IgnitionDeleteRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
+
+func (m *IgnitionDeleteRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type IgnitionDeleteResponse struct {
+}
+
+func (m *IgnitionDeleteResponse) Reset()                    { *m = IgnitionDeleteResponse{} }
+func (m *IgnitionDeleteResponse) String() string            { return proto.CompactTextString(m) }
+func (*IgnitionDeleteResponse) ProtoMessage()               {}
+func (*IgnitionDeleteResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{29} }
+
+type CloudPutRequest struct {
+	Name   string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Config []byte `protobuf:"bytes,2,opt,name=config,proto3" json:"config,omitempty"`
+}
+
+func (m *CloudPutRequest) Reset()                    { *m = CloudPutRequest{} }
+func (m *CloudPutRequest) String() string            { return proto.CompactTextString(m) }
+func (*CloudPutRequest) ProtoMessage()               {}
+func (*CloudPutRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{30} }
+
+func (m *CloudPutRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *CloudPutRequest) GetConfig() []byte {
+	if m != nil {
+		return m.Config
+	}
+	return nil
+}
+
+type CloudPutResponse struct {
+}
+
+func (m *CloudPutResponse) Reset()                    { *m = CloudPutResponse{} }
+func (m *CloudPutResponse) String() string            { return proto.CompactTextString(m) }
+func (*CloudPutResponse) ProtoMessage()               {}
+func (*CloudPutResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{31} }
+
+type CloudGetRequest struct {
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *CloudGetRequest) Reset()                    { *m = CloudGetRequest{} }
+func (m *CloudGetRequest) String() string            { return proto.CompactTextString(m) }
+func (*CloudGetRequest) ProtoMessage()               {}
+func (*CloudGetRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{32} }
+
+func (m *CloudGetRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type CloudGetResponse struct {
+	Config []byte `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`
+	// sha256:hex checksum of the template, to detect changes
+	Checksum string `protobuf:"bytes,2,opt,name=checksum" json:"checksum,omitempty"`
+}
+
+func (m *CloudGetResponse) Reset()                    { *m = CloudGetResponse{} }
+func (m *CloudGetResponse) String() string            { return proto.CompactTextString(m) }
+func (*CloudGetResponse) ProtoMessage()               {}
+func (*CloudGetResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{33} }
+
+func (m *CloudGetResponse) GetConfig() []byte {
+	if m != nil {
+		return m.Config
+	}
+	return nil
+}
+
+func (m *CloudGetResponse) GetChecksum() string {
+	if m != nil {
+		return m.Checksum
+	}
+	return ""
+}
+
+type CloudListRequest struct {
+}
+
+func (m *CloudListRequest) Reset()                    { *m = CloudListRequest{} }
+func (m *CloudListRequest) String() string            { return proto.CompactTextString(m) }
+func (*CloudListRequest) ProtoMessage()               {}
+func (*CloudListRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{34} }
+
+type CloudListResponse struct {
+	Names []string `protobuf:"bytes,1,rep,name=names" json:"names,omitempty"`
+}
+
+func (m *CloudListResponse) Reset()                    { *m = CloudListResponse{} }
+func (m *CloudListResponse) String() string            { return proto.CompactTextString(m) }
+func (*CloudListResponse) ProtoMessage()               {}
+func (*CloudListResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{35} }
+
+func (m *CloudListResponse) GetNames() []string {
+	if m != nil {
+		return m.Names
+	}
+	return nil
+}
+
+type CloudDeleteRequest struct {
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *CloudDeleteRequest) Reset()                    { *m = CloudDeleteRequest{} }
+func (m *CloudDeleteRequest) String() string            { return proto.CompactTextString(m) }
+func (*CloudDeleteRequest) ProtoMessage()               {}
+func (*CloudDeleteRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{36} }
+
+func (m *CloudDeleteRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type CloudDeleteResponse struct {
+}
+
+func (m *CloudDeleteResponse) Reset()                    { *m = CloudDeleteResponse{} }
+func (m *CloudDeleteResponse) String() string            { return proto.CompactTextString(m) }
+func (*CloudDeleteResponse) ProtoMessage()               {}
+func (*CloudDeleteResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37} }
+
+type GenericPutRequest struct {
+	Name   string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Config []byte `protobuf:"bytes,2,opt,name=config,proto3" json:"config,omitempty"`
+}
+
+func (m *GenericPutRequest) Reset()                    { *m = GenericPutRequest{} }
+func (m *GenericPutRequest) String() string            { return proto.CompactTextString(m) }
+func (*GenericPutRequest) ProtoMessage()               {}
+func (*GenericPutRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{38} }
+
+func (m *GenericPutRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *GenericPutRequest) GetConfig() []byte {
+	if m != nil {
+		return m.Config
+	}
+	return nil
+}
+
+type GenericPutResponse struct {
+}
+
+func (m *GenericPutResponse) Reset()                    { *m = GenericPutResponse{} }
+func (m *GenericPutResponse) String() string            { return proto.CompactTextString(m) }
+func (*GenericPutResponse) ProtoMessage()               {}
+func (*GenericPutResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{39} }
+
+type GenericGetRequest struct {
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *GenericGetRequest) Reset()                    { *m = GenericGetRequest{} }
+func (m *GenericGetRequest) String() string            { return proto.CompactTextString(m) }
+func (*GenericGetRequest) ProtoMessage()               {}
+func (*GenericGetRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{40} }
+
+func (m *GenericGetRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type GenericGetResponse struct {
+	Config []byte `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`
+	// sha256:hex checksum of the template, to detect changes
+	Checksum string `protobuf:"bytes,2,opt,name=checksum" json:"checksum,omitempty"`
+}
+
+func (m *GenericGetResponse) Reset()                    { *m = GenericGetResponse{} }
+func (m *GenericGetResponse) String() string            { return proto.CompactTextString(m) }
+func (*GenericGetResponse) ProtoMessage()               {}
+func (*GenericGetResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{41} }
+
+func (m *GenericGetResponse) GetConfig() []byte {
+	if m != nil {
+		return m.Config
+	}
+	return nil
+}
+
+func (m *GenericGetResponse) GetChecksum() string {
+	if m != nil {
+		return m.Checksum
+	}
+	return ""
+}
+
+type GenericListRequest struct {
+}
+
+func (m *GenericListRequest) Reset()                    { *m = GenericListRequest{} }
+func (m *GenericListRequest) String() string            { return proto.CompactTextString(m) }
+func (*GenericListRequest) ProtoMessage()               {}
+func (*GenericListRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{42} }
+
+type GenericListResponse struct {
+	Names []string `protobuf:"bytes,1,rep,name=names" json:"names,omitempty"`
+}
+
+func (m *GenericListResponse) Reset()                    { *m = GenericListResponse{} }
+func (m *GenericListResponse) String() string            { return proto.CompactTextString(m) }
+func (*GenericListResponse) ProtoMessage()               {}
+func (*GenericListResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{43} }
+
+func (m *GenericListResponse) GetNames() []string {
+	if m != nil {
+		return m.Names
+	}
+	return nil
+}
+
+type GenericDeleteRequest struct {
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *GenericDeleteRequest) Reset()                    { *m = GenericDeleteRequest{} }
+func (m *GenericDeleteRequest) String() string            { return proto.CompactTextString(m) }
+func (*GenericDeleteRequest) ProtoMessage()               {}
+func (*GenericDeleteRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{44} }
+
+func (m *GenericDeleteRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type GenericDeleteResponse struct {
+}
+
+func (m *GenericDeleteResponse) Reset()                    { *m = GenericDeleteResponse{} }
+func (m *GenericDeleteResponse) String() string            { return proto.CompactTextString(m) }
+func (*GenericDeleteResponse) ProtoMessage()               {}
+func (*GenericDeleteResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{45} }
+
+type MachinePutRequest struct {
+	Machine *storagepb.Machine `protobuf:"bytes,1,opt,name=machine" json:"machine,omitempty"`
+}
+
+func (m *MachinePutRequest) Reset()                    { *m = MachinePutRequest{} }
+func (m *MachinePutRequest) String() string            { return proto.CompactTextString(m) }
+func (*MachinePutRequest) ProtoMessage()               {}
+func (*MachinePutRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{46} }
+
+func (m *MachinePutRequest) GetMachine() *storagepb.Machine {
+	if m != nil {
+		return m.Machine
+	}
+	return nil
+}
+
+type MachineGetRequest struct {
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+}
+
+func (m *MachineGetRequest) Reset()                    { *m = MachineGetRequest{} }
+func (m *MachineGetRequest) String() string            { return proto.CompactTextString(m) }
+func (*MachineGetRequest) ProtoMessage()               {}
+func (*MachineGetRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{47} }
+
+func (m *MachineGetRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type MachinePutResponse struct {
+}
+
+func (m *MachinePutResponse) Reset()                    { *m = MachinePutResponse{} }
+func (m *MachinePutResponse) String() string            { return proto.CompactTextString(m) }
+func (*MachinePutResponse) ProtoMessage()               {}
+func (*MachinePutResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{48} }
+
+type MachineGetResponse struct {
+	Machine *storagepb.Machine `protobuf:"bytes,1,opt,name=machine" json:"machine,omitempty"`
+}
+
+func (m *MachineGetResponse) Reset()                    { *m = MachineGetResponse{} }
+func (m *MachineGetResponse) String() string            { return proto.CompactTextString(m) }
+func (*MachineGetResponse) ProtoMessage()               {}
+func (*MachineGetResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{49} }
+
+func (m *MachineGetResponse) GetMachine() *storagepb.Machine {
+	if m != nil {
+		return m.Machine
+	}
+	return nil
+}
+
+type MachineListRequest struct {
+	// (optional) only list machines in the given provisioning state
+	State string `protobuf:"bytes,1,opt,name=state" json:"state,omitempty"`
+}
+
+func (m *MachineListRequest) Reset()                    { *m = MachineListRequest{} }
+func (m *MachineListRequest) String() string            { return proto.CompactTextString(m) }
+func (*MachineListRequest) ProtoMessage()               {}
+func (*MachineListRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{50} }
+
+func (m *MachineListRequest) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+type MachineListResponse struct {
+	Machines []*storagepb.Machine `protobuf:"bytes,1,rep,name=machines" json:"machines,omitempty"`
+}
+
+func (m *MachineListResponse) Reset()                    { *m = MachineListResponse{} }
+func (m *MachineListResponse) String() string            { return proto.CompactTextString(m) }
+func (*MachineListResponse) ProtoMessage()               {}
+func (*MachineListResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{51} }
+
+func (m *MachineListResponse) GetMachines() []*storagepb.Machine {
+	if m != nil {
+		return m.Machines
+	}
+	return nil
+}
+
+type MachinePinRequest struct {
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	// id of the Group to pin the machine to, or empty to unpin
+	Group string `protobuf:"bytes,2,opt,name=group" json:"group,omitempty"`
+}
+
+func (m *MachinePinRequest) Reset()                    { *m = MachinePinRequest{} }
+func (m *MachinePinRequest) String() string            { return proto.CompactTextString(m) }
+func (*MachinePinRequest) ProtoMessage()               {}
+func (*MachinePinRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{52} }
+
+func (m *MachinePinRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *MachinePinRequest) GetGroup() string {
+	if m != nil {
+		return m.Group
+	}
+	return ""
+}
+
+type MachinePinResponse struct {
+	Machine *storagepb.Machine `protobuf:"bytes,1,opt,name=machine" json:"machine,omitempty"`
+}
+
+func (m *MachinePinResponse) Reset()                    { *m = MachinePinResponse{} }
+func (m *MachinePinResponse) String() string            { return proto.CompactTextString(m) }
+func (*MachinePinResponse) ProtoMessage()               {}
+func (*MachinePinResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{53} }
+
+func (m *MachinePinResponse) GetMachine() *storagepb.Machine {
+	if m != nil {
+		return m.Machine
+	}
+	return nil
+}
+
+type MachineReinstallRequest struct {
+	// machine id, or the MAC address of a machine
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	// wipe the machine's disks when it is reinstalled
+	Wipe bool `protobuf:"varint,2,opt,name=wipe" json:"wipe,omitempty"`
+}
+
+func (m *MachineReinstallRequest) Reset()                    { *m = MachineReinstallRequest{} }
+func (m *MachineReinstallRequest) String() string            { return proto.CompactTextString(m) }
+func (*MachineReinstallRequest) ProtoMessage()               {}
+func (*MachineReinstallRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{54} }
+
+func (m *MachineReinstallRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *MachineReinstallRequest) GetWipe() bool {
+	if m != nil {
+		return m.Wipe
+	}
+	return false
+}
+
+type MachineReinstallResponse struct {
+	Machine *storagepb.Machine `protobuf:"bytes,1,opt,name=machine" json:"machine,omitempty"`
+}
+
+func (m *MachineReinstallResponse) Reset()                    { *m = MachineReinstallResponse{} }
+func (m *MachineReinstallResponse) String() string            { return proto.CompactTextString(m) }
+func (*MachineReinstallResponse) ProtoMessage()               {}
+func (*MachineReinstallResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{55} }
+
+func (m *MachineReinstallResponse) GetMachine() *storagepb.Machine {
+	if m != nil {
+		return m.Machine
+	}
+	return nil
+}
+
+type MachineClaimRequest struct {
+	// labels or facts an unclaimed machine must have
+	Selector map[string]string `protobuf:"bytes,1,rep,name=selector" json:"selector,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// id of the Group to pin the claimed machine to
+	Group string `protobuf:"bytes,2,opt,name=group" json:"group,omitempty"`
+	// consumer claiming the machine (e.g. a Cluster API Machine)
+	Owner string `protobuf:"bytes,3,opt,name=owner" json:"owner,omitempty"`
+}
+
+func (m *MachineClaimRequest) Reset()                    { *m = MachineClaimRequest{} }
+func (m *MachineClaimRequest) String() string            { return proto.CompactTextString(m) }
+func (*MachineClaimRequest) ProtoMessage()               {}
+func (*MachineClaimRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{56} }
+
+func (m *MachineClaimRequest) GetSelector() map[string]string {
+	if m != nil {
+		return m.Selector
+	}
+	return nil
+}
+
+func (m *MachineClaimRequest) GetGroup() string {
+	if m != nil {
+		return m.Group
+	}
+	return ""
+}
+
+func (m *MachineClaimRequest) GetOwner() string {
+	if m != nil {
+		return m.Owner
+	}
+	return ""
+}
+
+type MachineClaimResponse struct {
+	Machine *storagepb.Machine `protobuf:"bytes,1,opt,name=machine" json:"machine,omitempty"`
+}
+
+func (m *MachineClaimResponse) Reset()                    { *m = MachineClaimResponse{} }
+func (m *MachineClaimResponse) String() string            { return proto.CompactTextString(m) }
+func (*MachineClaimResponse) ProtoMessage()               {}
+func (*MachineClaimResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{57} }
+
+func (m *MachineClaimResponse) GetMachine() *storagepb.Machine {
+	if m != nil {
+		return m.Machine
+	}
+	return nil
+}
+
+type MachineReleaseRequest struct {
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	// (optional) owner which must hold the claim
+	Owner string `protobuf:"bytes,2,opt,name=owner" json:"owner,omitempty"`
+}
+
+func (m *MachineReleaseRequest) Reset()                    { *m = MachineReleaseRequest{} }
+func (m *MachineReleaseRequest) String() string            { return proto.CompactTextString(m) }
+func (*MachineReleaseRequest) ProtoMessage()               {}
+func (*MachineReleaseRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{58} }
+
+func (m *MachineReleaseRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *MachineReleaseRequest) GetOwner() string {
+	if m != nil {
+		return m.Owner
+	}
+	return ""
+}
+
+type MachineReleaseResponse struct {
+	Machine *storagepb.Machine `protobuf:"bytes,1,opt,name=machine" json:"machine,omitempty"`
+}
+
+func (m *MachineReleaseResponse) Reset()                    { *m = MachineReleaseResponse{} }
+func (m *MachineReleaseResponse) String() string            { return proto.CompactTextString(m) }
+func (*MachineReleaseResponse) ProtoMessage()               {}
+func (*MachineReleaseResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{59} }
+
+func (m *MachineReleaseResponse) GetMachine() *storagepb.Machine {
+	if m != nil {
+		return m.Machine
+	}
+	return nil
+}
+
+type MachineDecommissionRequest struct {
+	// machine id, or the MAC address of a machine
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	// id of the Profile (e.g. a disk wipe) to serve the machine before it is
+	// archived
+	Profile string `protobuf:"bytes,2,opt,name=profile" json:"profile,omitempty"`
+}
+
+func (m *MachineDecommissionRequest) Reset()                    { *m = MachineDecommissionRequest{} }
+func (m *MachineDecommissionRequest) String() string            { return proto.CompactTextString(m) }
+func (*MachineDecommissionRequest) ProtoMessage()               {}
+func (*MachineDecommissionRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{60} }
+
+func (m *MachineDecommissionRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *MachineDecommissionRequest) GetProfile() string {
+	if m != nil {
+		return m.Profile
+	}
+	return ""
+}
+
+type MachineDecommissionResponse struct {
+	Machine *storagepb.Machine `protobuf:"bytes,1,opt,name=machine" json:"machine,omitempty"`
+}
+
+func (m *MachineDecommissionResponse) Reset()                    { *m = MachineDecommissionResponse{} }
+func (m *MachineDecommissionResponse) String() string            { return proto.CompactTextString(m) }
+func (*MachineDecommissionResponse) ProtoMessage()               {}
+func (*MachineDecommissionResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{61} }
+
+func (m *MachineDecommissionResponse) GetMachine() *storagepb.Machine {
+	if m != nil {
+		return m.Machine
+	}
+	return nil
+}
+
+type MachineAdoptRequest struct {
+	// machine id, or the MAC address of a machine
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	// id of the Group to pin the adopted machine to
+	Group string `protobuf:"bytes,2,opt,name=group" json:"group,omitempty"`
+}
+
+func (m *MachineAdoptRequest) Reset()                    { *m = MachineAdoptRequest{} }
+func (m *MachineAdoptRequest) String() string            { return proto.CompactTextString(m) }
+func (*MachineAdoptRequest) ProtoMessage()               {}
+func (*MachineAdoptRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{62} }
+
+func (m *MachineAdoptRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *MachineAdoptRequest) GetGroup() string {
+	if m != nil {
+		return m.Group
+	}
+	return ""
+}
+
+type MachineAdoptResponse struct {
+	Machine *storagepb.Machine `protobuf:"bytes,1,opt,name=machine" json:"machine,omitempty"`
+}
+
+func (m *MachineAdoptResponse) Reset()                    { *m = MachineAdoptResponse{} }
+func (m *MachineAdoptResponse) String() string            { return proto.CompactTextString(m) }
+func (*MachineAdoptResponse) ProtoMessage()               {}
+func (*MachineAdoptResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{63} }
+
+func (m *MachineAdoptResponse) GetMachine() *storagepb.Machine {
+	if m != nil {
+		return m.Machine
+	}
+	return nil
+}
+
+type MachineDescribeRequest struct {
+	// machine id, or the MAC address of a machine
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+}
+
+func (m *MachineDescribeRequest) Reset()                    { *m = MachineDescribeRequest{} }
+func (m *MachineDescribeRequest) String() string            { return proto.CompactTextString(m) }
+func (*MachineDescribeRequest) ProtoMessage()               {}
+func (*MachineDescribeRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{64} }
+
+func (m *MachineDescribeRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type MachineDescribeResponse struct {
+	Machine *storagepb.Machine `protobuf:"bytes,1,opt,name=machine" json:"machine,omitempty"`
+	// labels Groups are matched with: the machine's facts and reported labels
+	Labels map[string]string `protobuf:"bytes,2,rep,name=labels" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Group the machine matches, if any
+	Group *storagepb.Group `protobuf:"bytes,3,opt,name=group" json:"group,omitempty"`
+	// how the Group was selected: pinned, external, selector, decommission,
+	// rescue, or holding
+	Match string `protobuf:"bytes,4,opt,name=match" json:"match,omitempty"`
+	// id of the Profile the machine is served, if any
+	Profile string `protobuf:"bytes,5,opt,name=profile" json:"profile,omitempty"`
+}
+
+func (m *MachineDescribeResponse) Reset()                    { *m = MachineDescribeResponse{} }
+func (m *MachineDescribeResponse) String() string            { return proto.CompactTextString(m) }
+func (*MachineDescribeResponse) ProtoMessage()               {}
+func (*MachineDescribeResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{65} }
+
+func (m *MachineDescribeResponse) GetMachine() *storagepb.Machine {
+	if m != nil {
+		return m.Machine
+	}
+	return nil
+}
+
+func (m *MachineDescribeResponse) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *MachineDescribeResponse) GetGroup() *storagepb.Group {
+	if m != nil {
+		return m.Group
+	}
+	return nil
+}
+
+func (m *MachineDescribeResponse) GetMatch() string {
+	if m != nil {
+		return m.Match
+	}
+	return ""
+}
+
+func (m *MachineDescribeResponse) GetProfile() string {
+	if m != nil {
+		return m.Profile
+	}
+	return ""
+}
+
+type PowerRequest struct {
+	// machine id
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	// on, off, cycle, or status
+	Action string `protobuf:"bytes,2,opt,name=action" json:"action,omitempty"`
+}
+
+func (m *PowerRequest) Reset()                    { *m = PowerRequest{} }
+func (m *PowerRequest) String() string            { return proto.CompactTextString(m) }
+func (*PowerRequest) ProtoMessage()               {}
+func (*PowerRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{66} }
+
+func (m *PowerRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *PowerRequest) GetAction() string {
+	if m != nil {
+		return m.Action
+	}
+	return ""
+}
+
+type PowerResponse struct {
+	// power state reported by the BMC (e.g. On, Off)
+	State string `protobuf:"bytes,1,opt,name=state" json:"state,omitempty"`
+}
+
+func (m *PowerResponse) Reset()                    { *m = PowerResponse{} }
+func (m *PowerResponse) String() string            { return proto.CompactTextString(m) }
+func (*PowerResponse) ProtoMessage()               {}
+func (*PowerResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{67} }
+
+func (m *PowerResponse) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+type AssetPutRequest struct {
+	// path relative to the assets directory, set in the first message
+	Path string `protobuf:"bytes,1,opt,name=path" json:"path,omitempty"`
+	// (optional) checksum (sha256:hex or sha512:hex), set in the first message
+	Checksum string `protobuf:"bytes,2,opt,name=checksum" json:"checksum,omitempty"`
+	// next chunk of the asset contents
+	Chunk []byte `protobuf:"bytes,3,opt,name=chunk,proto3" json:"chunk,omitempty"`
+}
+
+func (m *AssetPutRequest) Reset()                    { *m = AssetPutRequest{} }
+func (m *AssetPutRequest) String() string            { return proto.CompactTextString(m) }
+func (*AssetPutRequest) ProtoMessage()               {}
+func (*AssetPutRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{68} }
+
+func (m *AssetPutRequest) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *AssetPutRequest) GetChecksum() string {
+	if m != nil {
+		return m.Checksum
+	}
+	return ""
+}
+
+func (m *AssetPutRequest) GetChunk() []byte {
+	if m != nil {
+		return m.Chunk
+	}
+	return nil
+}
+
+type AssetPutResponse struct {
+	Size int64 `protobuf:"varint,1,opt,name=size" json:"size,omitempty"`
+}
+
+func (m *AssetPutResponse) Reset()                    { *m = AssetPutResponse{} }
+func (m *AssetPutResponse) String() string            { return proto.CompactTextString(m) }
+func (*AssetPutResponse) ProtoMessage()               {}
+func (*AssetPutResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{69} }
+
+func (m *AssetPutResponse) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+type AssetFetchRequest struct {
+	Asset *storagepb.Asset `protobuf:"bytes,1,opt,name=asset" json:"asset,omitempty"`
+}
+
+func (m *AssetFetchRequest) Reset()                    { *m = AssetFetchRequest{} }
+func (m *AssetFetchRequest) String() string            { return proto.CompactTextString(m) }
+func (*AssetFetchRequest) ProtoMessage()               {}
+func (*AssetFetchRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{70} }
+
+func (m *AssetFetchRequest) GetAsset() *storagepb.Asset {
+	if m != nil {
+		return m.Asset
+	}
+	return nil
+}
+
+type AssetFetchResponse struct {
+}
+
+func (m *AssetFetchResponse) Reset()                    { *m = AssetFetchResponse{} }
+func (m *AssetFetchResponse) String() string            { return proto.CompactTextString(m) }
+func (*AssetFetchResponse) ProtoMessage()               {}
+func (*AssetFetchResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{71} }
+
+type AssetGetRequest struct {
+	Path string `protobuf:"bytes,1,opt,name=path" json:"path,omitempty"`
+	// checksum algorithm, sha256 (default) or sha512
+	Algorithm string `protobuf:"bytes,2,opt,name=algorithm" json:"algorithm,omitempty"`
+}
+
+func (m *AssetGetRequest) Reset()                    { *m = AssetGetRequest{} }
+func (m *AssetGetRequest) String() string            { return proto.CompactTextString(m) }
+func (*AssetGetRequest) ProtoMessage()               {}
+func (*AssetGetRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{72} }
+
+func (m *AssetGetRequest) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *AssetGetRequest) GetAlgorithm() string {
+	if m != nil {
+		return m.Algorithm
+	}
+	return ""
+}
+
+type AssetGetResponse struct {
+	Path string `protobuf:"bytes,1,opt,name=path" json:"path,omitempty"`
+	Size int64  `protobuf:"varint,2,opt,name=size" json:"size,omitempty"`
+	// checksum as algorithm:hex
+	Checksum string `protobuf:"bytes,3,opt,name=checksum" json:"checksum,omitempty"`
+}
+
+func (m *AssetGetResponse) Reset()                    { *m = AssetGetResponse{} }
+func (m *AssetGetResponse) String() string            { return proto.CompactTextString(m) }
+func (*AssetGetResponse) ProtoMessage()               {}
+func (*AssetGetResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{73} }
+
+func (m *AssetGetResponse) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *AssetGetResponse) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *AssetGetResponse) GetChecksum() string {
+	if m != nil {
+		return m.Checksum
+	}
+	return ""
+}
+
+type AssetDeleteRequest struct {
+	Path string `protobuf:"bytes,1,opt,name=path" json:"path,omitempty"`
+}
+
+func (m *AssetDeleteRequest) Reset()                    { *m = AssetDeleteRequest{} }
+func (m *AssetDeleteRequest) String() string            { return proto.CompactTextString(m) }
+func (*AssetDeleteRequest) ProtoMessage()               {}
+func (*AssetDeleteRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{74} }
+
+func (m *AssetDeleteRequest) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+type AssetDeleteResponse struct {
+}
+
+func (m *AssetDeleteResponse) Reset()                    { *m = AssetDeleteResponse{} }
+func (m *AssetDeleteResponse) String() string            { return proto.CompactTextString(m) }
+func (*AssetDeleteResponse) ProtoMessage()               {}
+func (*AssetDeleteResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{75} }
+
+type TokenCreateRequest struct {
+	// labels (e.g. uuid, mac) the token is bound to
+	Labels map[string]string `protobuf:"bytes,1,rep,name=labels" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// seconds until the token expires (0 for the default)
+	Ttl int64 `protobuf:"varint,2,opt,name=ttl" json:"ttl,omitempty"`
+}
+
+func (m *TokenCreateRequest) Reset()                    { *m = TokenCreateRequest{} }
+func (m *TokenCreateRequest) String() string            { return proto.CompactTextString(m) }
+func (*TokenCreateRequest) ProtoMessage()               {}
+func (*TokenCreateRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{76} }
+
+func (m *TokenCreateRequest) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *TokenCreateRequest) GetTtl() int64 {
+	if m != nil {
+		return m.Ttl
+	}
+	return 0
+}
+
+type TokenCreateResponse struct {
+	Token string `protobuf:"bytes,1,opt,name=token" json:"token,omitempty"`
+}
+
+func (m *TokenCreateResponse) Reset()                    { *m = TokenCreateResponse{} }
+func (m *TokenCreateResponse) String() string            { return proto.CompactTextString(m) }
+func (*TokenCreateResponse) ProtoMessage()               {}
+func (*TokenCreateResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{77} }
+
+func (m *TokenCreateResponse) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+type TokenRedeemRequest struct {
+	Token string `protobuf:"bytes,1,opt,name=token" json:"token,omitempty"`
+	// labels of the requesting machine
+	Labels map[string]string `protobuf:"bytes,2,rep,name=labels" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+}
+
+func (m *TokenRedeemRequest) Reset()                    { *m = TokenRedeemRequest{} }
+func (m *TokenRedeemRequest) String() string            { return proto.CompactTextString(m) }
+func (*TokenRedeemRequest) ProtoMessage()               {}
+func (*TokenRedeemRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{78} }
+
+func (m *TokenRedeemRequest) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+func (m *TokenRedeemRequest) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+type RenderRequest struct {
+	// config to render: ipxe, ignition, cloud, or generic
+	Config string `protobuf:"bytes,1,opt,name=config" json:"config,omitempty"`
+	// labels (e.g. uuid, mac) of the machine
+	Labels map[string]string `protobuf:"bytes,2,rep,name=labels" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// profile to render instead of the matching Group's Profile
+	Profile string `protobuf:"bytes,3,opt,name=profile" json:"profile,omitempty"`
+	// vars which override Group metadata
+	Vars map[string]string `protobuf:"bytes,4,rep,name=vars" json:"vars,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+}
+
+func (m *RenderRequest) Reset()                    { *m = RenderRequest{} }
+func (m *RenderRequest) String() string            { return proto.CompactTextString(m) }
+func (*RenderRequest) ProtoMessage()               {}
+func (*RenderRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{79} }
+
+func (m *RenderRequest) GetConfig() string {
+	if m != nil {
+		return m.Config
+	}
+	return ""
+}
+
+func (m *RenderRequest) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *RenderRequest) GetProfile() string {
+	if m != nil {
+		return m.Profile
+	}
+	return ""
+}
+
+func (m *RenderRequest) GetVars() map[string]string {
+	if m != nil {
+		return m.Vars
+	}
+	return nil
+}
+
+type RenderResponse struct {
+	// id of the matching Group, if any
+	Group   string `protobuf:"bytes,1,opt,name=group" json:"group,omitempty"`
+	Profile string `protobuf:"bytes,2,opt,name=profile" json:"profile,omitempty"`
+	Config  []byte `protobuf:"bytes,3,opt,name=config,proto3" json:"config,omitempty"`
+}
+
+func (m *RenderResponse) Reset()                    { *m = RenderResponse{} }
+func (m *RenderResponse) String() string            { return proto.CompactTextString(m) }
+func (*RenderResponse) ProtoMessage()               {}
+func (*RenderResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{80} }
+
+func (m *RenderResponse) GetGroup() string {
+	if m != nil {
+		return m.Group
+	}
+	return ""
+}
+
+func (m *RenderResponse) GetProfile() string {
+	if m != nil {
+		return m.Profile
+	}
+	return ""
+}
+
+func (m *RenderResponse) GetConfig() []byte {
+	if m != nil {
+		return m.Config
+	}
+	return nil
+}
+
+type RenderTemplateRequest struct {
+	// config the template renders: ignition, cloud, or generic
+	Config string `protobuf:"bytes,1,opt,name=config" json:"config,omitempty"`
+	// template name, Ignition templates named *.ign or *.ignition are plain
+	// Ignition rather than Fuze
+	Name string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+	// template contents, which need not be saved
+	Template []byte `protobuf:"bytes,3,opt,name=template,proto3" json:"template,omitempty"`
+	// id of the Group whose metadata is rendered, if any
+	Group string `protobuf:"bytes,4,opt,name=group" json:"group,omitempty"`
+	// labels (e.g. uuid, mac) of the machine, the Group's selectors if empty
+	Labels map[string]string `protobuf:"bytes,5,rep,name=labels" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// vars which override Group metadata
+	Vars map[string]string `protobuf:"bytes,6,rep,name=vars" json:"vars,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+}
+
+func (m *RenderTemplateRequest) Reset()                    { *m = RenderTemplateRequest{} }
+func (m *RenderTemplateRequest) String() string            { return proto.CompactTextString(m) }
+func (*RenderTemplateRequest) ProtoMessage()               {}
+func (*RenderTemplateRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{81} }
+
+func (m *RenderTemplateRequest) GetConfig() string {
+	if m != nil {
+		return m.Config
+	}
+	return ""
+}
+
+func (m *RenderTemplateRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *RenderTemplateRequest) GetTemplate() []byte {
+	if m != nil {
+		return m.Template
+	}
+	return nil
+}
+
+func (m *RenderTemplateRequest) GetGroup() string {
+	if m != nil {
+		return m.Group
+	}
+	return ""
+}
+
+func (m *RenderTemplateRequest) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *RenderTemplateRequest) GetVars() map[string]string {
+	if m != nil {
+		return m.Vars
+	}
+	return nil
+}
+
+type RenderTemplateResponse struct {
+	// rendered config, if the template rendered without errors
+	Config []byte `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`
+	// errors and warnings found validating and rendering the template
+	Problems []*TemplateProblem `protobuf:"bytes,2,rep,name=problems" json:"problems,omitempty"`
+}
+
+func (m *RenderTemplateResponse) Reset()                    { *m = RenderTemplateResponse{} }
+func (m *RenderTemplateResponse) String() string            { return proto.CompactTextString(m) }
+func (*RenderTemplateResponse) ProtoMessage()               {}
+func (*RenderTemplateResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{82} }
+
+func (m *RenderTemplateResponse) GetConfig() []byte {
+	if m != nil {
+		return m.Config
+	}
+	return nil
+}
+
+func (m *RenderTemplateResponse) GetProblems() []*TemplateProblem {
+	if m != nil {
+		return m.Problems
+	}
+	return nil
+}
+
+// A TemplateProblem is an error or warning found in a template or the
+// config it rendered.
+type TemplateProblem struct {
+	// error or warning
+	Kind string `protobuf:"bytes,1,opt,name=kind" json:"kind,omitempty"`
+	// 1-based position, if known
+	Line    int32  `protobuf:"varint,2,opt,name=line" json:"line,omitempty"`
+	Column  int32  `protobuf:"varint,3,opt,name=column" json:"column,omitempty"`
+	Message string `protobuf:"bytes,4,opt,name=message" json:"message,omitempty"`
+	// source line of the position, with a marker under the column
+	Highlight string `protobuf:"bytes,5,opt,name=highlight" json:"highlight,omitempty"`
+}
+
+func (m *TemplateProblem) Reset()                    { *m = TemplateProblem{} }
+func (m *TemplateProblem) String() string            { return proto.CompactTextString(m) }
+func (*TemplateProblem) ProtoMessage()               {}
+func (*TemplateProblem) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{83} }
+
+func (m *TemplateProblem) GetKind() string {
+	if m != nil {
+		return m.Kind
+	}
+	return ""
+}
+
+func (m *TemplateProblem) GetLine() int32 {
+	if m != nil {
+		return m.Line
+	}
+	return 0
+}
+
+func (m *TemplateProblem) GetColumn() int32 {
+	if m != nil {
+		return m.Column
+	}
+	return 0
+}
+
+func (m *TemplateProblem) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *TemplateProblem) GetHighlight() string {
+	if m != nil {
+		return m.Highlight
+	}
+	return ""
+}
+
+type BootEventsRequest struct {
+}
+
+func (m *BootEventsRequest) Reset()                    { *m = BootEventsRequest{} }
+func (m *BootEventsRequest) String() string            { return proto.CompactTextString(m) }
+func (*BootEventsRequest) ProtoMessage()               {}
+func (*BootEventsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{84} }
+
+// A BootEvent is a machine request to a boot endpoint.
+type BootEvent struct {
+	// RFC 3339 time of the request
+	Time string `protobuf:"bytes,1,opt,name=time" json:"time,omitempty"`
+	// endpoint path (e.g. /ipxe, /ignition)
+	Endpoint string `protobuf:"bytes,2,opt,name=endpoint" json:"endpoint,omitempty"`
+	// HTTP response status
+	Status int32 `protobuf:"varint,3,opt,name=status" json:"status,omitempty"`
+	// machine uuid, or mac if there is no uuid
+	MachineId string            `protobuf:"bytes,4,opt,name=machine_id,json=machineId" json:"machine_id,omitempty"`
+	Labels    map[string]string `protobuf:"bytes,5,rep,name=labels" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Group     string            `protobuf:"bytes,6,opt,name=group" json:"group,omitempty"`
+	Profile   string            `protobuf:"bytes,7,opt,name=profile" json:"profile,omitempty"`
+	RemoteIp  string            `protobuf:"bytes,8,opt,name=remote_ip,json=remoteIp" json:"remote_ip,omitempty"`
+	// request ID, as in the request log
+	RequestId string `protobuf:"bytes,9,opt,name=request_id,json=requestId" json:"request_id,omitempty"`
+}
+
+func (m *BootEvent) Reset()                    { *m = BootEvent{} }
+func (m *BootEvent) String() string            { return proto.CompactTextString(m) }
+func (*BootEvent) ProtoMessage()               {}
+func (*BootEvent) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{85} }
+
+func (m *BootEvent) GetTime() string {
+	if m != nil {
+		return m.Time
+	}
+	return ""
+}
+
+func (m *BootEvent) GetEndpoint() string {
+	if m != nil {
+		return m.Endpoint
+	}
+	return ""
+}
+
+func (m *BootEvent) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *BootEvent) GetMachineId() string {
+	if m != nil {
+		return m.MachineId
+	}
+	return ""
+}
+
+func (m *BootEvent) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *BootEvent) GetGroup() string {
+	if m != nil {
+		return m.Group
+	}
+	return ""
+}
+
+func (m *BootEvent) GetProfile() string {
+	if m != nil {
+		return m.Profile
+	}
+	return ""
+}
+
+func (m *BootEvent) GetRemoteIp() string {
+	if m != nil {
+		return m.RemoteIp
+	}
+	return ""
+}
+
+func (m *BootEvent) GetRequestId() string {
+	if m != nil {
+		return m.RequestId
+	}
+	return ""
+}
+
+type EventListRequest struct {
+	// (optional) machine uuid, or mac if it has no uuid
+	MachineId string `protobuf:"bytes,1,opt,name=machine_id,json=machineId" json:"machine_id,omitempty"`
+	// (optional) event type (boot, config, progress, or complete)
+	Type string `protobuf:"bytes,2,opt,name=type" json:"type,omitempty"`
+	// (optional) RFC 3339 time of the earliest events
+	Since string `protobuf:"bytes,3,opt,name=since" json:"since,omitempty"`
+	// (optional) RFC 3339 time after the latest events
+	Until string `protobuf:"bytes,4,opt,name=until" json:"until,omitempty"`
+	// (optional) maximum number of events, the most recent (0 for no limit)
+	Limit int32 `protobuf:"varint,5,opt,name=limit" json:"limit,omitempty"`
+}
+
+func (m *EventListRequest) Reset()                    { *m = EventListRequest{} }
+func (m *EventListRequest) String() string            { return proto.CompactTextString(m) }
+func (*EventListRequest) ProtoMessage()               {}
+func (*EventListRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{86} }
+
+func (m *EventListRequest) GetMachineId() string {
+	if m != nil {
+		return m.MachineId
+	}
+	return ""
+}
+
+func (m *EventListRequest) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *EventListRequest) GetSince() string {
+	if m != nil {
+		return m.Since
+	}
+	return ""
+}
+
+func (m *EventListRequest) GetUntil() string {
+	if m != nil {
+		return m.Until
+	}
+	return ""
+}
+
+func (m *EventListRequest) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type EventListResponse struct {
+	// recorded events, oldest first
+	Events []*storagepb.Event `protobuf:"bytes,1,rep,name=events" json:"events,omitempty"`
+}
+
+func (m *EventListResponse) Reset()                    { *m = EventListResponse{} }
+func (m *EventListResponse) String() string            { return proto.CompactTextString(m) }
+func (*EventListResponse) ProtoMessage()               {}
+func (*EventListResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{87} }
+
+func (m *EventListResponse) GetEvents() []*storagepb.Event {
+	if m != nil {
+		return m.Events
+	}
+	return nil
+}
+
+type MaintenanceGetRequest struct {
+}
+
+func (m *MaintenanceGetRequest) Reset()                    { *m = MaintenanceGetRequest{} }
+func (m *MaintenanceGetRequest) String() string            { return proto.CompactTextString(m) }
+func (*MaintenanceGetRequest) ProtoMessage()               {}
+func (*MaintenanceGetRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{88} }
+
+type MaintenanceGetResponse struct {
+	// maintenance mode (local or hold), or empty if not in maintenance
+	Mode string `protobuf:"bytes,1,opt,name=mode" json:"mode,omitempty"`
+}
+
+func (m *MaintenanceGetResponse) Reset()                    { *m = MaintenanceGetResponse{} }
+func (m *MaintenanceGetResponse) String() string            { return proto.CompactTextString(m) }
+func (*MaintenanceGetResponse) ProtoMessage()               {}
+func (*MaintenanceGetResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{89} }
+
+func (m *MaintenanceGetResponse) GetMode() string {
+	if m != nil {
+		return m.Mode
+	}
+	return ""
+}
+
+type MaintenanceSetRequest struct {
+	// maintenance mode (local or hold), or empty to leave maintenance
+	Mode string `protobuf:"bytes,1,opt,name=mode" json:"mode,omitempty"`
+}
+
+func (m *MaintenanceSetRequest) Reset()                    { *m = MaintenanceSetRequest{} }
+func (m *MaintenanceSetRequest) String() string            { return proto.CompactTextString(m) }
+func (*MaintenanceSetRequest) ProtoMessage()               {}
+func (*MaintenanceSetRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{90} }
+
+func (m *MaintenanceSetRequest) GetMode() string {
+	if m != nil {
+		return m.Mode
+	}
+	return ""
+}
+
+type MaintenanceSetResponse struct {
+	Mode string `protobuf:"bytes,1,opt,name=mode" json:"mode,omitempty"`
+}
+
+func (m *MaintenanceSetResponse) Reset()                    { *m = MaintenanceSetResponse{} }
+func (m *MaintenanceSetResponse) String() string            { return proto.CompactTextString(m) }
+func (*MaintenanceSetResponse) ProtoMessage()               {}
+func (*MaintenanceSetResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{91} }
+
+func (m *MaintenanceSetResponse) GetMode() string {
+	if m != nil {
+		return m.Mode
+	}
+	return ""
+}
 
 func init() {
 	proto.RegisterType((*SelectGroupRequest)(nil), "serverpb.SelectGroupRequest")
@@ -313,46 +1988,200 @@ func init() {
 	proto.RegisterType((*GroupListRequest)(nil), "serverpb.GroupListRequest")
 	proto.RegisterType((*GroupGetResponse)(nil), "serverpb.GroupGetResponse")
 	proto.RegisterType((*GroupListResponse)(nil), "serverpb.GroupListResponse")
+	proto.RegisterType((*GroupDeleteRequest)(nil), "serverpb.GroupDeleteRequest")
 	proto.RegisterType((*ProfilePutRequest)(nil), "serverpb.ProfilePutRequest")
 	proto.RegisterType((*ProfilePutResponse)(nil), "serverpb.ProfilePutResponse")
 	proto.RegisterType((*ProfileGetRequest)(nil), "serverpb.ProfileGetRequest")
 	proto.RegisterType((*ProfileGetResponse)(nil), "serverpb.ProfileGetResponse")
 	proto.RegisterType((*ProfileListRequest)(nil), "serverpb.ProfileListRequest")
 	proto.RegisterType((*ProfileListResponse)(nil), "serverpb.ProfileListResponse")
+	proto.RegisterType((*ProfileDeleteRequest)(nil), "serverpb.ProfileDeleteRequest")
+	proto.RegisterType((*ProfileVersionListRequest)(nil), "serverpb.ProfileVersionListRequest")
+	proto.RegisterType((*ProfileVersionListResponse)(nil), "serverpb.ProfileVersionListResponse")
+	proto.RegisterType((*ProfileRollbackRequest)(nil), "serverpb.ProfileRollbackRequest")
+	proto.RegisterType((*ProfileRollbackResponse)(nil), "serverpb.ProfileRollbackResponse")
 	proto.RegisterType((*IgnitionPutRequest)(nil), "serverpb.IgnitionPutRequest")
 	proto.RegisterType((*IgnitionPutResponse)(nil), "serverpb.IgnitionPutResponse")
+	proto.RegisterType((*IgnitionGetRequest)(nil), "serverpb.IgnitionGetRequest")
+	proto.RegisterType((*IgnitionGetResponse)(nil), "serverpb.IgnitionGetResponse")
+	proto.RegisterType((*IgnitionListRequest)(nil), "serverpb.IgnitionListRequest")
+	proto.RegisterType((*IgnitionListResponse)(nil), "serverpb.IgnitionListResponse")
+	proto.RegisterType((*IgnitionDeleteRequest)(nil), "serverpb.IgnitionDeleteRequest")
+	proto.RegisterType((*IgnitionDeleteResponse)(nil), "serverpb.IgnitionDeleteResponse")
+	proto.RegisterType((*CloudPutRequest)(nil), "serverpb.CloudPutRequest")
+	proto.RegisterType((*CloudPutResponse)(nil), "serverpb.CloudPutResponse")
+	proto.RegisterType((*CloudGetRequest)(nil), "serverpb.CloudGetRequest")
+	proto.RegisterType((*CloudGetResponse)(nil), "serverpb.CloudGetResponse")
+	proto.RegisterType((*CloudListRequest)(nil), "serverpb.CloudListRequest")
+	proto.RegisterType((*CloudListResponse)(nil), "serverpb.CloudListResponse")
+	proto.RegisterType((*CloudDeleteRequest)(nil), "serverpb.CloudDeleteRequest")
+	proto.RegisterType((*CloudDeleteResponse)(nil), "serverpb.CloudDeleteResponse")
+	proto.RegisterType((*GenericPutRequest)(nil), "serverpb.GenericPutRequest")
+	proto.RegisterType((*GenericPutResponse)(nil), "serverpb.GenericPutResponse")
+	proto.RegisterType((*GenericGetRequest)(nil), "serverpb.GenericGetRequest")
+	proto.RegisterType((*GenericGetResponse)(nil), "serverpb.GenericGetResponse")
+	proto.RegisterType((*GenericListRequest)(nil), "serverpb.GenericListRequest")
+	proto.RegisterType((*GenericListResponse)(nil), "serverpb.GenericListResponse")
+	proto.RegisterType((*GenericDeleteRequest)(nil), "serverpb.GenericDeleteRequest")
+	proto.RegisterType((*GenericDeleteResponse)(nil), "serverpb.GenericDeleteResponse")
+	proto.RegisterType((*MachinePutRequest)(nil), "serverpb.MachinePutRequest")
+	proto.RegisterType((*MachineGetRequest)(nil), "serverpb.MachineGetRequest")
+	proto.RegisterType((*MachinePutResponse)(nil), "serverpb.MachinePutResponse")
+	proto.RegisterType((*MachineGetResponse)(nil), "serverpb.MachineGetResponse")
+	proto.RegisterType((*MachineListRequest)(nil), "serverpb.MachineListRequest")
+	proto.RegisterType((*MachineListResponse)(nil), "serverpb.MachineListResponse")
+	proto.RegisterType((*MachinePinRequest)(nil), "serverpb.MachinePinRequest")
+	proto.RegisterType((*MachinePinResponse)(nil), "serverpb.MachinePinResponse")
+	proto.RegisterType((*MachineReinstallRequest)(nil), "serverpb.MachineReinstallRequest")
+	proto.RegisterType((*MachineReinstallResponse)(nil), "serverpb.MachineReinstallResponse")
+	proto.RegisterType((*MachineClaimRequest)(nil), "serverpb.MachineClaimRequest")
+	proto.RegisterType((*MachineClaimResponse)(nil), "serverpb.MachineClaimResponse")
+	proto.RegisterType((*MachineReleaseRequest)(nil), "serverpb.MachineReleaseRequest")
+	proto.RegisterType((*MachineReleaseResponse)(nil), "serverpb.MachineReleaseResponse")
+	proto.RegisterType((*MachineDecommissionRequest)(nil), "serverpb.MachineDecommissionRequest")
+	proto.RegisterType((*MachineDecommissionResponse)(nil), "serverpb.MachineDecommissionResponse")
+	proto.RegisterType((*MachineAdoptRequest)(nil), "serverpb.MachineAdoptRequest")
+	proto.RegisterType((*MachineAdoptResponse)(nil), "serverpb.MachineAdoptResponse")
+	proto.RegisterType((*MachineDescribeRequest)(nil), "serverpb.MachineDescribeRequest")
+	proto.RegisterType((*MachineDescribeResponse)(nil), "serverpb.MachineDescribeResponse")
+	proto.RegisterType((*PowerRequest)(nil), "serverpb.PowerRequest")
+	proto.RegisterType((*PowerResponse)(nil), "serverpb.PowerResponse")
+	proto.RegisterType((*AssetPutRequest)(nil), "serverpb.AssetPutRequest")
+	proto.RegisterType((*AssetPutResponse)(nil), "serverpb.AssetPutResponse")
+	proto.RegisterType((*AssetFetchRequest)(nil), "serverpb.AssetFetchRequest")
+	proto.RegisterType((*AssetFetchResponse)(nil), "serverpb.AssetFetchResponse")
+	proto.RegisterType((*AssetGetRequest)(nil), "serverpb.AssetGetRequest")
+	proto.RegisterType((*AssetGetResponse)(nil), "serverpb.AssetGetResponse")
+	proto.RegisterType((*AssetDeleteRequest)(nil), "serverpb.AssetDeleteRequest")
+	proto.RegisterType((*AssetDeleteResponse)(nil), "serverpb.AssetDeleteResponse")
+	proto.RegisterType((*TokenCreateRequest)(nil), "serverpb.TokenCreateRequest")
+	proto.RegisterType((*TokenCreateResponse)(nil), "serverpb.TokenCreateResponse")
+	proto.RegisterType((*TokenRedeemRequest)(nil), "serverpb.TokenRedeemRequest")
+	proto.RegisterType((*RenderRequest)(nil), "serverpb.RenderRequest")
+	proto.RegisterType((*RenderResponse)(nil), "serverpb.RenderResponse")
+	proto.RegisterType((*RenderTemplateRequest)(nil), "serverpb.RenderTemplateRequest")
+	proto.RegisterType((*RenderTemplateResponse)(nil), "serverpb.RenderTemplateResponse")
+	proto.RegisterType((*TemplateProblem)(nil), "serverpb.TemplateProblem")
+	proto.RegisterType((*BootEventsRequest)(nil), "serverpb.BootEventsRequest")
+	proto.RegisterType((*BootEvent)(nil), "serverpb.BootEvent")
+	proto.RegisterType((*EventListRequest)(nil), "serverpb.EventListRequest")
+	proto.RegisterType((*EventListResponse)(nil), "serverpb.EventListResponse")
+	proto.RegisterType((*MaintenanceGetRequest)(nil), "serverpb.MaintenanceGetRequest")
+	proto.RegisterType((*MaintenanceGetResponse)(nil), "serverpb.MaintenanceGetResponse")
+	proto.RegisterType((*MaintenanceSetRequest)(nil), "serverpb.MaintenanceSetRequest")
+	proto.RegisterType((*MaintenanceSetResponse)(nil), "serverpb.MaintenanceSetResponse")
 }
 
 func init() { proto.RegisterFile("messages.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 436 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xac, 0x54, 0x5d, 0x6b, 0xd4, 0x40,
-	0x14, 0x25, 0xa9, 0x8d, 0xf5, 0xae, 0xd4, 0xed, 0xec, 0x56, 0x96, 0x3e, 0xd5, 0x11, 0x24, 0x88,
-	0x4c, 0xa1, 0xbe, 0xd8, 0x42, 0xa1, 0x56, 0x96, 0x22, 0xf4, 0xa1, 0xc4, 0x5f, 0x90, 0xa4, 0xb7,
-	0xe9, 0x60, 0x92, 0x89, 0x33, 0x93, 0x62, 0x7f, 0x86, 0x0f, 0xfe, 0x5f, 0xe9, 0x7c, 0xa4, 0x93,
-	0xad, 0x88, 0x2b, 0x3e, 0xe5, 0xce, 0xbd, 0xe7, 0x9c, 0x9b, 0x73, 0x26, 0x04, 0xb6, 0x1b, 0x54,
-	0x2a, 0xaf, 0x50, 0xb1, 0x4e, 0x0a, 0x2d, 0xc8, 0x96, 0x42, 0x79, 0x8b, 0xb2, 0x2b, 0xf6, 0x3e,
-	0x55, 0x5c, 0xdf, 0xf4, 0x05, 0x2b, 0x45, 0x73, 0x50, 0x0a, 0x89, 0x42, 0x1d, 0x34, 0xb9, 0x2e,
-	0x6f, 0x0a, 0xf1, 0xfd, 0xa1, 0x50, 0x5a, 0xc8, 0xbc, 0x42, 0xff, 0xec, 0x0a, 0x5f, 0x59, 0x39,
-	0xfa, 0x23, 0x02, 0xf2, 0x05, 0x6b, 0x2c, 0xf5, 0xb9, 0x14, 0x7d, 0x97, 0xe1, 0xb7, 0x1e, 0x95,
-	0x26, 0xa7, 0x90, 0xd4, 0x79, 0x81, 0xb5, 0x5a, 0x44, 0xfb, 0x1b, 0xe9, 0xe4, 0x30, 0x65, 0x7e,
-	0x2d, 0x7b, 0x8c, 0x66, 0x17, 0x06, 0xba, 0x6c, 0xb5, 0xbc, 0xcb, 0x1c, 0x6f, 0xef, 0x08, 0x26,
-	0x41, 0x9b, 0x4c, 0x61, 0xe3, 0x2b, 0xde, 0x2d, 0xa2, 0xfd, 0x28, 0x7d, 0x96, 0xdd, 0x97, 0x64,
-	0x0e, 0x9b, 0xb7, 0x79, 0xdd, 0xe3, 0x22, 0x36, 0x3d, 0x7b, 0x38, 0x8e, 0x3f, 0x44, 0xf4, 0x04,
-	0x66, 0xa3, 0x25, 0xaa, 0x13, 0xad, 0x42, 0xf2, 0x06, 0x36, 0xab, 0xfb, 0x86, 0x11, 0x99, 0x1c,
-	0x4e, 0xd9, 0xe0, 0x89, 0x59, 0xa0, 0x1d, 0xd3, 0x9f, 0x11, 0xcc, 0x2d, 0xff, 0x52, 0x8a, 0x6b,
-	0x5e, 0xa3, 0x37, 0x75, 0xb6, 0x62, 0xea, 0xed, 0xaa, 0xa9, 0x31, 0xfe, 0x7f, 0xdb, 0x5a, 0xc2,
-	0xee, 0xca, 0x1a, 0x67, 0xec, 0x1d, 0x3c, 0xed, 0x6c, 0xcb, 0x59, 0x23, 0x81, 0x35, 0x0f, 0xf6,
-	0x10, 0x7a, 0x04, 0x2f, 0x8c, 0xdd, 0xcb, 0x5e, 0x7b, 0x63, 0x7f, 0x9b, 0x0c, 0x81, 0xe9, 0x03,
-	0xd5, 0x2e, 0xa7, 0xaf, 0x9c, 0xdc, 0x39, 0x0e, 0x72, 0xdb, 0x10, 0xf3, 0x2b, 0xe7, 0x29, 0xe6,
-	0x57, 0x03, 0xed, 0x82, 0x2b, 0x8f, 0xa1, 0xc7, 0xae, 0x67, 0x68, 0x6b, 0x5e, 0xd0, 0x09, 0xec,
-	0x04, 0x7a, 0x8e, 0x9c, 0x42, 0x62, 0xa6, 0xfe, 0x72, 0x1e, 0xb3, 0xdd, 0x9c, 0x7e, 0x84, 0x1d,
-	0x17, 0x4a, 0x10, 0xc1, 0x7a, 0x19, 0xce, 0x81, 0x84, 0x12, 0x2e, 0x8a, 0xd7, 0x83, 0xf0, 0x1f,
-	0xc2, 0x38, 0x1b, 0xa8, 0xa1, 0xf5, 0x7f, 0x5d, 0x1f, 0x46, 0xba, 0x84, 0xd9, 0xa8, 0xeb, 0xa4,
-	0x19, 0x6c, 0x39, 0x9e, 0x8f, 0xe6, 0x77, 0xda, 0x03, 0x86, 0x9e, 0x02, 0xf9, 0x5c, 0xb5, 0x5c,
-	0x73, 0xd1, 0x06, 0xf9, 0x10, 0x78, 0xd2, 0xe6, 0x0d, 0x3a, 0x23, 0xa6, 0x26, 0x2f, 0x21, 0x29,
-	0x45, 0x7b, 0xcd, 0x2b, 0xf3, 0xad, 0x3e, 0xcf, 0xdc, 0x89, 0xee, 0xc2, 0x6c, 0xa4, 0x60, 0x5f,
-	0xa4, 0x48, 0xcc, 0x1f, 0xe3, 0xfd, 0xaf, 0x00, 0x00, 0x00, 0xff, 0xff, 0x28, 0xea, 0x61, 0x11,
-	0x92, 0x04, 0x00, 0x00,
+	// 1726 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xcc, 0x59, 0xcd, 0x72, 0x1b, 0x41,
+	0x11, 0x2e, 0x49, 0x96, 0x23, 0x75, 0x7e, 0x2c, 0xaf, 0x64, 0x5b, 0x51, 0xa0, 0x48, 0x36, 0x24,
+	0x28, 0x71, 0x50, 0xa8, 0xa4, 0x42, 0x08, 0x2e, 0x17, 0xf1, 0x7f, 0x5c, 0x24, 0x94, 0x6b, 0x9d,
+	0x0a, 0x9c, 0x48, 0xad, 0x56, 0x13, 0x69, 0xca, 0xfb, 0x23, 0x76, 0x47, 0x36, 0xe1, 0x05, 0x28,
+	0x8e, 0x1c, 0x78, 0x00, 0x8a, 0x03, 0xc5, 0x93, 0x70, 0xe7, 0x3d, 0x78, 0x07, 0x6a, 0x66, 0x7a,
+	0x76, 0x66, 0x57, 0x2b, 0x39, 0x96, 0x73, 0xe0, 0xe4, 0xe9, 0x56, 0x4f, 0xf7, 0xd7, 0x5f, 0xcf,
+	0xec, 0xf4, 0x8c, 0xe1, 0x4e, 0x40, 0x92, 0xc4, 0x1d, 0x92, 0xa4, 0x37, 0x8e, 0x23, 0x16, 0x59,
+	0xb5, 0x84, 0xc4, 0xe7, 0x24, 0x1e, 0xf7, 0x3b, 0x7b, 0x43, 0xca, 0x46, 0x93, 0x7e, 0xcf, 0x8b,
+	0x82, 0xe7, 0x5e, 0x14, 0x93, 0x28, 0x79, 0x1e, 0xb8, 0xcc, 0x1b, 0xf5, 0xa3, 0x3f, 0xea, 0x41,
+	0xc2, 0xa2, 0xd8, 0x1d, 0x12, 0xf5, 0x77, 0xdc, 0x57, 0x23, 0xe9, 0xce, 0xfe, 0x6b, 0x09, 0xac,
+	0x53, 0xe2, 0x13, 0x8f, 0x1d, 0xc5, 0xd1, 0x64, 0xec, 0x90, 0x3f, 0x4c, 0x48, 0xc2, 0xac, 0xb7,
+	0xb0, 0xec, 0xbb, 0x7d, 0xe2, 0x27, 0xed, 0xd2, 0xfd, 0x4a, 0xf7, 0xe6, 0x8b, 0x6e, 0x4f, 0x85,
+	0xed, 0x4d, 0x5b, 0xf7, 0xde, 0x0b, 0xd3, 0x83, 0x90, 0xc5, 0x5f, 0x1d, 0x9c, 0xd7, 0x79, 0x03,
+	0x37, 0x0d, 0xb5, 0xd5, 0x80, 0xca, 0x19, 0xf9, 0xda, 0x2e, 0xdd, 0x2f, 0x75, 0xeb, 0x0e, 0x1f,
+	0x5a, 0x2d, 0xa8, 0x9e, 0xbb, 0xfe, 0x84, 0xb4, 0xcb, 0x42, 0x27, 0x85, 0x5f, 0x96, 0x7f, 0x51,
+	0xb2, 0xb7, 0xa1, 0x99, 0x09, 0x92, 0x8c, 0xa3, 0x30, 0x21, 0xd6, 0x63, 0xa8, 0x0e, 0xb9, 0x42,
+	0x38, 0xb9, 0xf9, 0xa2, 0xd1, 0x4b, 0x73, 0xea, 0x49, 0x43, 0xf9, 0xb3, 0xfd, 0xb7, 0x12, 0xb4,
+	0xe4, 0xfc, 0x93, 0x38, 0xfa, 0x42, 0x7d, 0xa2, 0x92, 0xda, 0xcd, 0x25, 0xf5, 0x34, 0x9f, 0x54,
+	0xd6, 0xfe, 0x7b, 0xa7, 0x75, 0x00, 0x6b, 0xb9, 0x30, 0x98, 0xd8, 0x33, 0xb8, 0x31, 0x96, 0x2a,
+	0x4c, 0xcd, 0x32, 0x52, 0x53, 0xc6, 0xca, 0xc4, 0x7e, 0x03, 0x2b, 0x22, 0xdd, 0x93, 0x09, 0x53,
+	0x89, 0x7d, 0x2b, 0x33, 0x3d, 0x68, 0xe8, 0xa9, 0x18, 0xbc, 0x03, 0xb5, 0x0b, 0x37, 0x0e, 0x69,
+	0x38, 0x94, 0xb4, 0xd4, 0x9d, 0x54, 0xb6, 0x1f, 0x60, 0xa8, 0x23, 0x92, 0x86, 0xba, 0x03, 0x65,
+	0x3a, 0xc0, 0x7c, 0xcb, 0x74, 0x60, 0x5b, 0xe8, 0xf2, 0x3d, 0x4d, 0x94, 0x8d, 0xfd, 0x09, 0x1a,
+	0x7a, 0xda, 0xd5, 0x8a, 0xc7, 0xe1, 0x78, 0x23, 0xe2, 0x9d, 0x25, 0x93, 0x00, 0x19, 0x4c, 0x65,
+	0x7b, 0x1b, 0x56, 0x8d, 0x58, 0xe8, 0xb8, 0x0b, 0xcb, 0x62, 0xa6, 0x2a, 0xea, 0xb4, 0x67, 0xfc,
+	0xdd, 0xfe, 0x31, 0x58, 0x42, 0xb1, 0x4f, 0x7c, 0xc2, 0xc8, 0xac, 0x84, 0x76, 0x60, 0x15, 0x29,
+	0x37, 0x08, 0xbe, 0x5a, 0x85, 0x7e, 0x06, 0x96, 0xe9, 0xe2, 0x1b, 0x88, 0x7e, 0x98, 0x06, 0x9d,
+	0x43, 0xf5, 0xef, 0xc1, 0x32, 0x8d, 0x16, 0x59, 0x3c, 0x73, 0xe9, 0x6d, 0xa5, 0xfe, 0xcd, 0x62,
+	0x1e, 0x40, 0x33, 0xa3, 0xc5, 0xb0, 0x3d, 0xa8, 0xa1, 0x4f, 0x45, 0x7c, 0x51, 0xdc, 0xd4, 0xc6,
+	0x7e, 0x0c, 0x2d, 0x54, 0xce, 0xa7, 0x7f, 0x13, 0xee, 0xa2, 0xdd, 0x27, 0x12, 0x27, 0x34, 0x0a,
+	0x0d, 0x2c, 0x53, 0xc6, 0xa7, 0xd0, 0x29, 0x32, 0x46, 0x88, 0xaf, 0xa0, 0x76, 0x2e, 0xd5, 0x0a,
+	0xe2, 0xdd, 0x69, 0x88, 0x38, 0xd1, 0x49, 0x4d, 0xed, 0x5d, 0x58, 0x57, 0xf0, 0x23, 0xdf, 0xef,
+	0xbb, 0xde, 0xd9, 0x8c, 0xf0, 0x56, 0x1b, 0x6e, 0xe0, 0x2c, 0xc1, 0x65, 0xd5, 0x51, 0xa2, 0xfd,
+	0x1b, 0xd8, 0x98, 0xf2, 0x81, 0xa8, 0x5e, 0xea, 0x49, 0xb2, 0x5e, 0x73, 0x40, 0xa5, 0xfe, 0xde,
+	0x82, 0x75, 0x3c, 0x0c, 0x29, 0xa3, 0x51, 0x68, 0xac, 0x4a, 0x0b, 0x96, 0x42, 0x37, 0x20, 0x88,
+	0x48, 0x8c, 0xad, 0x75, 0x58, 0xf6, 0xa2, 0xf0, 0x0b, 0x1d, 0x0a, 0x48, 0xb7, 0x1c, 0x94, 0xec,
+	0x35, 0x68, 0x66, 0x3c, 0x48, 0x34, 0x76, 0x57, 0x3b, 0x3e, 0x22, 0xf3, 0x1c, 0xdb, 0xc7, 0xd0,
+	0xcc, 0x58, 0x62, 0x3a, 0x3a, 0x5e, 0xc9, 0x8c, 0x37, 0x77, 0xa1, 0x19, 0x58, 0xcc, 0x95, 0xf6,
+	0x0c, 0x5a, 0x59, 0x35, 0x86, 0x68, 0x41, 0x95, 0x23, 0x50, 0xbb, 0x46, 0x0a, 0xf6, 0x26, 0xac,
+	0x29, 0xeb, 0xec, 0x8a, 0x2a, 0x02, 0xdf, 0x86, 0xf5, 0xbc, 0x31, 0x12, 0xb0, 0x0d, 0x2b, 0x7b,
+	0x7e, 0x34, 0x19, 0x2c, 0x48, 0xab, 0x05, 0x0d, 0x3d, 0x1d, 0x5d, 0x3e, 0x42, 0x97, 0x97, 0x10,
+	0x7a, 0x08, 0x0d, 0x6d, 0x76, 0x0d, 0x36, 0x15, 0x04, 0x93, 0xca, 0x27, 0xb0, 0x6a, 0xe8, 0xe6,
+	0xf2, 0xd8, 0x05, 0x4b, 0x98, 0x5e, 0x4e, 0xe2, 0x1a, 0x34, 0x33, 0x96, 0x98, 0xee, 0xaf, 0x60,
+	0xf5, 0x88, 0x84, 0x24, 0xa6, 0xde, 0x82, 0x1c, 0xb6, 0xc0, 0x32, 0x1d, 0xa0, 0xdb, 0x9f, 0xa4,
+	0x6e, 0x2f, 0xe1, 0xf1, 0x1d, 0x58, 0xa6, 0xe1, 0x35, 0x98, 0xd4, 0x40, 0x4c, 0x2e, 0x37, 0xa1,
+	0x99, 0xd1, 0xce, 0x65, 0xf3, 0x29, 0xb4, 0xd0, 0xf8, 0x72, 0x3e, 0x37, 0x60, 0x2d, 0x67, 0x8b,
+	0xa9, 0xef, 0xc0, 0xea, 0x07, 0xd7, 0x1b, 0xd1, 0x30, 0x77, 0x04, 0x05, 0x52, 0x59, 0xf0, 0x9d,
+	0x47, 0x73, 0x47, 0x99, 0xf0, 0x03, 0x05, 0x75, 0x73, 0x0e, 0x94, 0x16, 0x58, 0x66, 0x1c, 0x8c,
+	0xbe, 0x0b, 0x96, 0x39, 0x55, 0x1f, 0x33, 0x57, 0x08, 0xff, 0x34, 0xf5, 0x61, 0x7e, 0xbe, 0x5b,
+	0x50, 0x4d, 0x98, 0xcb, 0x14, 0x0b, 0x52, 0xe0, 0x07, 0x4c, 0xc6, 0x56, 0x1f, 0x30, 0xe8, 0xad,
+	0xe8, 0x80, 0x51, 0x11, 0x53, 0x1b, 0xfb, 0x8d, 0x26, 0x8d, 0x86, 0xb3, 0xbe, 0xd8, 0x2d, 0xd5,
+	0x85, 0x60, 0x73, 0x26, 0x04, 0x23, 0x63, 0x31, 0x75, 0xa1, 0x8c, 0xb7, 0x61, 0x43, 0xe9, 0x08,
+	0x0d, 0x13, 0xe6, 0xfa, 0xfe, 0x2c, 0x10, 0x16, 0x2c, 0x5d, 0xd0, 0xb1, 0x6c, 0x10, 0x6b, 0x8e,
+	0x18, 0xdb, 0xef, 0xa0, 0x3d, 0x3d, 0x7d, 0x21, 0x20, 0xff, 0x2e, 0xa5, 0x7c, 0xee, 0xf9, 0x2e,
+	0x0d, 0x14, 0x8a, 0x23, 0xa8, 0x25, 0xa2, 0xfb, 0x8c, 0x62, 0xe4, 0x73, 0x53, 0xb7, 0xbf, 0x05,
+	0x13, 0xb0, 0x25, 0x8e, 0x62, 0xd9, 0xff, 0xa6, 0x93, 0x8b, 0x39, 0xe4, 0xda, 0xe8, 0x22, 0x24,
+	0x71, 0xbb, 0x22, 0xb5, 0x42, 0xe8, 0x6c, 0xc1, 0xed, 0x8c, 0x9b, 0x2b, 0xf5, 0xcb, 0xfb, 0xd0,
+	0xca, 0xe2, 0x5a, 0xb0, 0x30, 0x6b, 0x4a, 0x47, 0x7c, 0xe2, 0x26, 0x64, 0xce, 0xda, 0x90, 0x19,
+	0x94, 0x8d, 0x0c, 0xec, 0x43, 0x58, 0xcf, 0x4f, 0x5f, 0x08, 0xc6, 0x21, 0x74, 0x50, 0xb7, 0x4f,
+	0xbc, 0x28, 0x08, 0x68, 0x22, 0x4e, 0xf8, 0xd9, 0x9d, 0x85, 0x6a, 0xea, 0x24, 0x1a, 0x25, 0xda,
+	0xbf, 0x86, 0x7b, 0x85, 0x7e, 0x16, 0x02, 0xb5, 0x95, 0x2e, 0x95, 0x9d, 0x41, 0x34, 0x66, 0x57,
+	0xdb, 0x35, 0xba, 0x3c, 0x38, 0x79, 0x21, 0x08, 0xdd, 0x94, 0xdf, 0x7d, 0x92, 0x78, 0x31, 0xed,
+	0xcf, 0xec, 0x0c, 0xff, 0x5e, 0x86, 0x8d, 0x29, 0xd3, 0x45, 0x62, 0x5a, 0x07, 0xe9, 0x3d, 0xb0,
+	0x2c, 0x36, 0xc2, 0x4f, 0xa7, 0x36, 0x42, 0x3e, 0x40, 0xd1, 0x55, 0x50, 0x5f, 0x69, 0x2a, 0xf3,
+	0xaf, 0x34, 0x2d, 0xa8, 0x8a, 0xeb, 0x78, 0x7b, 0x49, 0xd2, 0x27, 0x04, 0xb3, 0xc4, 0xd5, 0x4c,
+	0x89, 0xaf, 0x73, 0xc5, 0xfc, 0x39, 0xdc, 0x3a, 0x89, 0x2e, 0x48, 0x3c, 0xab, 0x92, 0xeb, 0xb0,
+	0xec, 0x7a, 0x4c, 0x35, 0xac, 0x75, 0x07, 0x25, 0xfb, 0x11, 0xdc, 0xc6, 0x79, 0xfa, 0x74, 0x2b,
+	0xf8, 0x54, 0xff, 0x16, 0x56, 0x76, 0x92, 0x84, 0xb0, 0xec, 0x41, 0x3f, 0x76, 0xd9, 0x48, 0x1d,
+	0x6c, 0x7c, 0x3c, 0xef, 0x8c, 0xe5, 0x8e, 0xbd, 0xd1, 0x24, 0x3c, 0x13, 0xa4, 0xdd, 0x72, 0xa4,
+	0x60, 0x3f, 0x86, 0x86, 0x76, 0x8c, 0x10, 0x2c, 0x58, 0x4a, 0xe8, 0x9f, 0x24, 0x82, 0x8a, 0x23,
+	0xc6, 0xf6, 0x16, 0xac, 0x0a, 0xbb, 0x43, 0xc2, 0xbc, 0x91, 0x71, 0xfb, 0x75, 0xb9, 0xb2, 0xe0,
+	0x6a, 0x29, 0x8c, 0x1d, 0xf9, 0x33, 0x3f, 0xee, 0xcc, 0xc9, 0x78, 0xdc, 0xed, 0x61, 0x4e, 0xd9,
+	0x2e, 0x63, 0x2a, 0xa7, 0x1f, 0x40, 0xdd, 0xf5, 0x87, 0x51, 0x4c, 0xd9, 0x48, 0x25, 0xa5, 0x15,
+	0xfc, 0xc6, 0xab, 0x9d, 0x68, 0xfc, 0x53, 0x5e, 0x54, 0x4e, 0x65, 0x9d, 0x53, 0x86, 0xad, 0x4a,
+	0xae, 0x23, 0xe9, 0x22, 0xe4, 0xa9, 0x66, 0x22, 0xef, 0x99, 0x37, 0x67, 0x19, 0x4b, 0xcc, 0xee,
+	0x1f, 0x25, 0xb0, 0x3e, 0x46, 0x67, 0x24, 0xdc, 0x8b, 0x89, 0xcb, 0xc8, 0x37, 0x3c, 0xef, 0x4c,
+	0x5b, 0x17, 0x2e, 0xfe, 0x06, 0x54, 0x18, 0xf3, 0x31, 0x11, 0x3e, 0xbc, 0xce, 0xb2, 0xdd, 0x84,
+	0x66, 0x26, 0xac, 0x5e, 0x84, 0x8c, 0xab, 0xd5, 0x22, 0x14, 0x82, 0xfd, 0x4f, 0x95, 0x92, 0x43,
+	0x06, 0x84, 0x04, 0x46, 0x73, 0x31, 0x6d, 0x6c, 0xbd, 0xcd, 0x6d, 0xf5, 0x7c, 0xa2, 0x19, 0x1f,
+	0xdf, 0xfb, 0xc1, 0xe7, 0x5f, 0x65, 0xb8, 0xed, 0x90, 0x70, 0xa0, 0xf7, 0x63, 0xb6, 0x2b, 0xad,
+	0xa7, 0x5d, 0xe9, 0x56, 0x0e, 0xe6, 0x43, 0x0d, 0x33, 0xe3, 0xa0, 0xb0, 0x14, 0xc6, 0x97, 0xa4,
+	0x92, 0xf9, 0x92, 0x58, 0xaf, 0x60, 0xe9, 0xdc, 0x8d, 0x93, 0xf6, 0x92, 0x70, 0xfa, 0x60, 0x96,
+	0xd3, 0x4f, 0x6e, 0x8c, 0x2e, 0x85, 0xf9, 0x35, 0x52, 0xee, 0xbc, 0x86, 0x7a, 0xea, 0xed, 0x4a,
+	0x5c, 0xfd, 0x0e, 0xee, 0x28, 0x50, 0xba, 0xfa, 0xfa, 0xc5, 0x28, 0xed, 0x33, 0x66, 0x9e, 0x8c,
+	0x06, 0xb7, 0x95, 0xcc, 0xf5, 0xe2, 0xbf, 0x65, 0x58, 0x93, 0xae, 0x3f, 0x92, 0x60, 0xec, 0x1b,
+	0xbb, 0x60, 0x56, 0x35, 0x54, 0xb3, 0x5e, 0x36, 0x2e, 0x2f, 0x1d, 0xa8, 0x31, 0x9c, 0x8e, 0xfe,
+	0x53, 0x59, 0x23, 0x5d, 0x32, 0x91, 0xee, 0xa5, 0x35, 0xad, 0xe6, 0xdb, 0xad, 0x42, 0x38, 0x85,
+	0xb5, 0xdd, 0xc6, 0x0a, 0x2e, 0x0b, 0x17, 0x4f, 0x2e, 0x73, 0xf1, 0xff, 0x50, 0xc9, 0x21, 0xac,
+	0xe7, 0xc1, 0x5d, 0x72, 0x27, 0x7b, 0x25, 0xde, 0x92, 0xfa, 0x3e, 0x09, 0xd4, 0xfa, 0xbf, 0x6b,
+	0x6c, 0x53, 0xf4, 0x72, 0x22, 0x2d, 0x9c, 0xd4, 0xd4, 0xfe, 0x4b, 0x09, 0x56, 0x72, 0xbf, 0xf2,
+	0xd2, 0x9d, 0xd1, 0x50, 0x1d, 0x79, 0x62, 0xcc, 0x75, 0x3e, 0x0d, 0x25, 0xd2, 0xaa, 0x23, 0xc6,
+	0x12, 0x8a, 0x3f, 0x09, 0x42, 0x51, 0xcc, 0xaa, 0x83, 0x12, 0x5f, 0x5e, 0xf8, 0xde, 0x8e, 0xc5,
+	0x54, 0x22, 0x3f, 0x00, 0x46, 0x74, 0x38, 0xf2, 0xe9, 0x70, 0xc4, 0xf0, 0xc4, 0xd6, 0x0a, 0xbb,
+	0x09, 0xab, 0xbb, 0x51, 0xc4, 0x0e, 0xce, 0x49, 0xc8, 0x12, 0x75, 0x73, 0xfc, 0x4f, 0x19, 0xea,
+	0xa9, 0x96, 0xc3, 0x60, 0x54, 0x5f, 0x01, 0x19, 0x95, 0xab, 0x8a, 0x84, 0x83, 0x71, 0x44, 0x43,
+	0xa6, 0x4e, 0x4a, 0x25, 0x73, 0x88, 0xfc, 0xd4, 0x9d, 0x24, 0x0a, 0xa2, 0x94, 0xac, 0x1f, 0x02,
+	0x60, 0x23, 0xf3, 0x99, 0x0e, 0x10, 0x65, 0x1d, 0x35, 0xc7, 0x03, 0xeb, 0x75, 0x6e, 0xd9, 0xfd,
+	0x48, 0x53, 0x99, 0x62, 0x29, 0x5c, 0x6a, 0xe9, 0x2a, 0x5e, 0x9e, 0xb1, 0xdf, 0x6e, 0x64, 0xf7,
+	0xdb, 0x3d, 0xa8, 0xc7, 0x24, 0x88, 0x18, 0xf9, 0x4c, 0xc7, 0xed, 0x9a, 0x04, 0x2f, 0x15, 0xc7,
+	0x63, 0x0e, 0x32, 0x96, 0x2c, 0x70, 0x90, 0x75, 0x09, 0x12, 0x35, 0xc7, 0x83, 0xeb, 0x7c, 0x54,
+	0xff, 0x5c, 0x82, 0x86, 0x48, 0xc2, 0xbc, 0x59, 0x66, 0x39, 0x29, 0xe5, 0x39, 0xe1, 0xd4, 0x7f,
+	0x1d, 0xa7, 0x1b, 0x9a, 0x8f, 0x79, 0x84, 0x84, 0x86, 0x9e, 0xfa, 0x66, 0x4a, 0x81, 0x6b, 0x27,
+	0x21, 0xa3, 0xbe, 0xda, 0xca, 0x42, 0xe0, 0x5a, 0x9f, 0x06, 0x54, 0xd6, 0xbd, 0xea, 0x48, 0x81,
+	0x3f, 0x47, 0x1b, 0x40, 0xf4, 0x73, 0x34, 0xe1, 0xca, 0xa2, 0xe7, 0x68, 0x61, 0xed, 0xe0, 0xef,
+	0xfc, 0xfa, 0xff, 0xc1, 0xa5, 0x21, 0x23, 0xa1, 0x1b, 0x7a, 0xc6, 0x35, 0xdd, 0x7e, 0x06, 0xeb,
+	0xf9, 0x1f, 0x74, 0x4b, 0x11, 0x44, 0x83, 0x74, 0x09, 0xf1, 0x31, 0x7f, 0x07, 0x33, 0xac, 0x4f,
+	0x33, 0x5d, 0xcc, 0x94, 0x71, 0xd6, 0xf5, 0xe9, 0x7c, 0xd7, 0xfd, 0x65, 0xf1, 0x2f, 0xa2, 0x97,
+	0xff, 0x0b, 0x00, 0x00, 0xff, 0xff, 0xc6, 0x4b, 0x56, 0xf2, 0x83, 0x1a, 0x00, 0x00,
 }
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// Token errors
+var (
+	ErrTokenLabelsRequired = errors.New("matchbox: Token must be bound to a uuid or mac label")
+	ErrInvalidToken        = errors.New("matchbox: Invalid or expired token")
+)
+
+// defaultTokenTTL is how long minted tokens remain valid by default.
+const defaultTokenTTL = 24 * time.Hour
+
+// token is a single-use token bound to machine labels.
+type token struct {
+	labels  map[string]string
+	expires time.Time
+}
+
+// tokenStore holds unredeemed tokens in memory, and redeemed tokens until
+// they expire, so a token whose use failed can be restored.
+type tokenStore struct {
+	mu       sync.Mutex
+	tokens   map[string]*token
+	redeemed map[string]*token
+	now      func() time.Time
+}
+
+func newTokenStore() *tokenStore {
+	return &tokenStore{
+		tokens:   make(map[string]*token),
+		redeemed: make(map[string]*token),
+		now:      time.Now,
+	}
+}
+
+// boundLabels returns the uuid and mac labels a token is bound to, with the
+// mac normalized so any MAC address notation matches.
+func boundLabels(labels map[string]string) map[string]string {
+	bound := make(map[string]string)
+	if uuid := labels["uuid"]; uuid != "" {
+		bound["uuid"] = uuid
+	}
+	if mac := labels["mac"]; mac != "" {
+		if hw, err := net.ParseMAC(mac); err == nil {
+			mac = hw.String()
+		}
+		bound["mac"] = mac
+	}
+	return bound
+}
+
+// create mints a token bound to the uuid and/or mac labels.
+func (s *tokenStore) create(labels map[string]string, ttl time.Duration) (string, error) {
+	bound := boundLabels(labels)
+	if len(bound) == 0 {
+		return "", ErrTokenLabelsRequired
+	}
+	if ttl <= 0 {
+		ttl = defaultTokenTTL
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(b)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire()
+	s.tokens[id] = &token{labels: bound, expires: s.now().Add(ttl)}
+	return id, nil
+}
+
+// redeem invalidates the token if it is valid and bound to the labels.
+// Tokens presented with non-matching labels are not invalidated.
+func (s *tokenStore) redeem(id string, labels map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire()
+	tok, ok := s.tokens[id]
+	if !ok || !tok.boundTo(labels) {
+		return ErrInvalidToken
+	}
+	delete(s.tokens, id)
+	s.redeemed[id] = tok
+	return nil
+}
+
+// restore makes a redeemed token bound to the labels valid again, such as
+// when the request it was redeemed for failed.
+func (s *tokenStore) restore(id string, labels map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire()
+	tok, ok := s.redeemed[id]
+	if !ok || !tok.boundTo(labels) {
+		return ErrInvalidToken
+	}
+	delete(s.redeemed, id)
+	s.tokens[id] = tok
+	return nil
+}
+
+// check returns an error unless the token is unexpired and bound to the
+// labels, whether or not it was redeemed.
+func (s *tokenStore) check(id string, labels map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire()
+	tok, ok := s.tokens[id]
+	if !ok {
+		tok, ok = s.redeemed[id]
+	}
+	if !ok || !tok.boundTo(labels) {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// boundTo returns true if the labels match those the token is bound to.
+func (t *token) boundTo(labels map[string]string) bool {
+	presented := boundLabels(labels)
+	for key, value := range t.labels {
+		if presented[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// expire removes expired tokens.
+func (s *tokenStore) expire() {
+	now := s.now()
+	for _, tokens := range []map[string]*token{s.tokens, s.redeemed} {
+		for id, tok := range tokens {
+			if now.After(tok.expires) {
+				delete(tokens, id)
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenRedeem(t *testing.T) {
+	store := newTokenStore()
+	labels := map[string]string{"uuid": "a1b2c3d4", "mac": "52:54:00:a1:9c:ae", "os": "installed"}
+	id, err := store.create(labels, 0)
+	assert.Nil(t, err)
+	// assert that:
+	// - tokens are rejected for other machines, without invalidating them
+	// - tokens are accepted once for the bound machine
+	assert.Equal(t, ErrInvalidToken, store.redeem(id, map[string]string{"uuid": "a1b2c3d4"}))
+	assert.Equal(t, ErrInvalidToken, store.redeem("unknown", labels))
+	assert.Nil(t, store.redeem(id, map[string]string{"uuid": "a1b2c3d4", "mac": "52:54:00:a1:9c:ae"}))
+	assert.Equal(t, ErrInvalidToken, store.redeem(id, labels))
+}
+
+func TestTokenRedeem_MAC(t *testing.T) {
+	store := newTokenStore()
+	id, err := store.create(map[string]string{"mac": "52:54:00:A1:9C:AE"}, 0)
+	assert.Nil(t, err)
+	// assert that tokens are bound to MAC addresses in any notation
+	assert.Nil(t, store.redeem(id, map[string]string{"mac": "52-54-00-a1-9c-ae"}))
+}
+
+func TestTokenRestore(t *testing.T) {
+	store := newTokenStore()
+	labels := map[string]string{"uuid": "a1b2c3d4"}
+	id, err := store.create(labels, 0)
+	assert.Nil(t, err)
+	// assert that:
+	// - only redeemed tokens can be restored, for the bound machine
+	// - restored tokens can be redeemed again
+	assert.Equal(t, ErrInvalidToken, store.restore(id, labels))
+	assert.Nil(t, store.redeem(id, labels))
+	assert.Equal(t, ErrInvalidToken, store.restore(id, map[string]string{"uuid": "e5f6"}))
+	assert.Nil(t, store.restore(id, labels))
+	assert.Nil(t, store.redeem(id, labels))
+	assert.Equal(t, ErrInvalidToken, store.redeem(id, labels))
+}
+
+func TestTokenCheck(t *testing.T) {
+	store := newTokenStore()
+	labels := map[string]string{"uuid": "a1b2c3d4"}
+	id, err := store.create(labels, 0)
+	assert.Nil(t, err)
+	// assert that:
+	// - tokens are checked for the bound machine without being redeemed
+	// - redeemed tokens still pass checks
+	assert.Equal(t, ErrInvalidToken, store.check(id, map[string]string{"uuid": "e5f6"}))
+	assert.Equal(t, ErrInvalidToken, store.check("unknown", labels))
+	assert.Nil(t, store.check(id, labels))
+	assert.Nil(t, store.redeem(id, labels))
+	assert.Nil(t, store.check(id, labels))
+}
+
+func TestTokenExpiry(t *testing.T) {
+	now := time.Now()
+	store := newTokenStore()
+	store.now = func() time.Time { return now }
+	id, err := store.create(map[string]string{"uuid": "a1b2c3d4"}, time.Minute)
+	assert.Nil(t, err)
+	now = now.Add(2 * time.Minute)
+	assert.Equal(t, ErrInvalidToken, store.redeem(id, map[string]string{"uuid": "a1b2c3d4"}))
+	assert.Empty(t, store.tokens)
+	// redeemed tokens are discarded once they expire
+	id, err = store.create(map[string]string{"uuid": "a1b2c3d4"}, time.Minute)
+	assert.Nil(t, err)
+	assert.Nil(t, store.redeem(id, map[string]string{"uuid": "a1b2c3d4"}))
+	now = now.Add(2 * time.Minute)
+	assert.Equal(t, ErrInvalidToken, store.restore(id, map[string]string{"uuid": "a1b2c3d4"}))
+	assert.Empty(t, store.redeemed)
+}
+
+func TestTokenCreate_LabelsRequired(t *testing.T) {
+	_, err := newTokenStore().create(map[string]string{"os": "installed"}, 0)
+	assert.Equal(t, ErrTokenLabelsRequired, err)
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// machineLockCount is the number of locks serializing Machine writes.
+const machineLockCount = 64
+
+// machineLocks serializes the writes of each Machine. Machines share a
+// fixed number of locks by a hash of their id, so the locks don't grow with
+// the fleet.
+type machineLocks [machineLockCount]sync.Mutex
+
+// lock returns the lock of a Machine.
+func (l *machineLocks) lock(id string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return &l[h.Sum32()%machineLockCount]
+}
+
+// MachineUpdate applies an update to a copy of the Machine with the id, or
+// to nil if there is none, and writes the Machine the update returns.
+// Nothing is written if the update returns nil or an error. Writes of a
+// Machine are serialized, so concurrent updates (e.g. the boot history and
+// install attempts recorded for a request) aren't lost.
+func (s *server) MachineUpdate(ctx context.Context, id string, update func(*storagepb.Machine) (*storagepb.Machine, error)) (*storagepb.Machine, error) {
+	mu := s.machineLocks.lock(id)
+	mu.Lock()
+	defer mu.Unlock()
+	var existing *storagepb.Machine
+	if machine, err := s.store.MachineGet(id); err == nil {
+		existing = machine.Copy()
+	}
+	machine, err := update(existing)
+	if machine == nil || err != nil {
+		return nil, err
+	}
+	return s.putMachine(ctx, &pb.MachinePutRequest{Machine: machine})
+}
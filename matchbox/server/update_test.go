@@ -0,0 +1,62 @@
+package server
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"context"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestMachineUpdate(t *testing.T) {
+	store := fake.NewFixedStore()
+	srv := NewServer(&Config{Store: store})
+	ctx := context.Background()
+	increment := func(machine *storagepb.Machine) (*storagepb.Machine, error) {
+		if machine == nil {
+			machine = &storagepb.Machine{Id: "a1b2c3d4"}
+		}
+		machine.Attempts++
+		return machine, nil
+	}
+
+	// assert that:
+	// - concurrent updates of a Machine are serialized, so none are lost
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := srv.MachineUpdate(ctx, "a1b2c3d4", increment)
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(20), store.Machines["a1b2c3d4"].Attempts)
+
+	// - updates are applied to a copy of the stored Machine
+	// - updates which return nil write nothing
+	stored := store.Machines["a1b2c3d4"]
+	machine, err := srv.MachineUpdate(ctx, "a1b2c3d4", func(machine *storagepb.Machine) (*storagepb.Machine, error) {
+		machine.Attempts = 0
+		return nil, nil
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, machine)
+	assert.Equal(t, int32(20), stored.Attempts)
+	assert.Equal(t, stored, store.Machines["a1b2c3d4"])
+
+	// - updates which return an error write nothing and return the error
+	errConflict := errors.New("conflict")
+	machine, err = srv.MachineUpdate(ctx, "a1b2c3d4", func(machine *storagepb.Machine) (*storagepb.Machine, error) {
+		machine.Attempts = 0
+		return machine, errConflict
+	})
+	assert.Equal(t, errConflict, err)
+	assert.Nil(t, machine)
+	assert.Equal(t, stored, store.Machines["a1b2c3d4"])
+}
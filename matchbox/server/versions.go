@@ -0,0 +1,207 @@
+package server
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"context"
+	"github.com/golang/protobuf/proto"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	"github.com/coreos/matchbox/matchbox/trace"
+)
+
+// Version errors
+var (
+	ErrNoEarlierVersion = errors.New("matchbox: Profile has no earlier version to roll back to")
+)
+
+// versionKey is the ctx key of a pinned Profile version.
+type versionKey struct{}
+
+// pinnedVersion identifies the Profile version a Group pins.
+type pinnedVersion struct {
+	id      string
+	version int32
+}
+
+// WithProfileVersion returns a copy of ctx in which the Profile of a Group
+// which pins a Profile version, and the templates that Profile references,
+// resolve to the snapshot of that version. Groups which serve the latest
+// version return ctx unchanged.
+func WithProfileVersion(ctx context.Context, group *storagepb.Group) context.Context {
+	if group == nil || group.ProfileVersion == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, versionKey{}, pinnedVersion{id: group.Profile, version: group.ProfileVersion})
+}
+
+// pinnedSnapshot returns the snapshot of the Profile version pinned in ctx
+// if the Profile has the given id, or nil if no version of it is pinned.
+func (s *server) pinnedSnapshot(ctx context.Context, id string) (*storagepb.ProfileVersion, error) {
+	pin, ok := ctx.Value(versionKey{}).(pinnedVersion)
+	if !ok || pin.id != id {
+		return nil, nil
+	}
+	start := time.Now()
+	snapshot, err := s.store.ProfileVersionGet(pin.id, pin.version)
+	trace.Record(ctx, "store.ProfileVersionGet", start, err)
+	return snapshot, err
+}
+
+// pinnedTemplate returns the contents of a template from the snapshot of
+// the Profile version pinned in ctx, if the snapshot references a template
+// with the given name.
+func (s *server) pinnedTemplate(ctx context.Context, name string, ref func(*storagepb.ProfileVersion) (string, string)) (string, bool) {
+	pin, ok := ctx.Value(versionKey{}).(pinnedVersion)
+	if !ok {
+		return "", false
+	}
+	snapshot, err := s.pinnedSnapshot(ctx, pin.id)
+	if err != nil {
+		return "", false
+	}
+	id, contents := ref(snapshot)
+	if id != name {
+		return "", false
+	}
+	return contents, true
+}
+
+// ignitionRef returns the Ignition template name and contents of a
+// ProfileVersion.
+func ignitionRef(v *storagepb.ProfileVersion) (string, string) {
+	return v.Profile.IgnitionId, v.Ignition
+}
+
+// cloudRef returns the Cloud-Config template name and contents of a
+// ProfileVersion.
+func cloudRef(v *storagepb.ProfileVersion) (string, string) {
+	return v.Profile.CloudId, v.Cloud
+}
+
+// genericRef returns the generic template name and contents of a
+// ProfileVersion.
+func genericRef(v *storagepb.ProfileVersion) (string, string) {
+	return v.Profile.GenericId, v.Generic
+}
+
+// ProfileVersionList lists the snapshots of a Profile, oldest first.
+func (s *server) ProfileVersionList(ctx context.Context, req *pb.ProfileVersionListRequest) ([]*storagepb.ProfileVersion, error) {
+	return s.store.ProfileVersionList(req.Id)
+}
+
+// ProfileRollback restores a Profile and the templates it references from
+// a snapshot, the version before the latest by default. The restored
+// Profile is recorded as a new version, which is returned.
+func (s *server) ProfileRollback(ctx context.Context, req *pb.ProfileRollbackRequest) (*storagepb.ProfileVersion, error) {
+	s.versionMu.Lock()
+	defer s.versionMu.Unlock()
+	var target *storagepb.ProfileVersion
+	if req.Version == 0 {
+		versions, err := s.store.ProfileVersionList(req.Id)
+		if err != nil {
+			return nil, err
+		}
+		if len(versions) < 2 {
+			return nil, ErrNoEarlierVersion
+		}
+		target = versions[len(versions)-2]
+	} else {
+		var err error
+		if target, err = s.store.ProfileVersionGet(req.Id, req.Version); err != nil {
+			return nil, err
+		}
+	}
+
+	profile := target.Profile
+	restores := []struct {
+		name     string
+		contents string
+		put      func(string, []byte) error
+	}{
+		{profile.IgnitionId, target.Ignition, s.store.IgnitionPut},
+		{profile.CloudId, target.Cloud, s.store.CloudPut},
+		{profile.GenericId, target.Generic, s.store.GenericPut},
+	}
+	for _, restore := range restores {
+		if restore.name == "" || restore.contents == "" {
+			continue
+		}
+		if err := restore.put(restore.name, []byte(restore.contents)); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.store.ProfilePut(profile); err != nil {
+		return nil, err
+	}
+	// other Profiles which share a restored template change too
+	err := s.snapshotReferencing(func(other *storagepb.Profile) bool {
+		return other.Id != profile.Id && ((profile.IgnitionId != "" && other.IgnitionId == profile.IgnitionId) ||
+			(profile.CloudId != "" && other.CloudId == profile.CloudId) ||
+			(profile.GenericId != "" && other.GenericId == profile.GenericId))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.snapshot(profile)
+}
+
+// snapshot records a new version of a Profile with the current contents
+// of the templates it references, unless the latest version is identical,
+// and returns the latest version. The caller must hold the versionMu.
+func (s *server) snapshot(profile *storagepb.Profile) (*storagepb.ProfileVersion, error) {
+	versions, err := s.store.ProfileVersionList(profile.Id)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &storagepb.ProfileVersion{
+		Version: 1,
+		Profile: profile,
+		Created: s.now().UTC().Format(time.RFC3339),
+	}
+	// templates which don't exist (yet) and remote Ignition configs are
+	// recorded as empty
+	if profile.IgnitionId != "" && !storagepb.IsIgnitionURL(profile.IgnitionId) {
+		snapshot.Ignition, _ = s.store.IgnitionGet(profile.IgnitionId)
+	}
+	if profile.CloudId != "" {
+		snapshot.Cloud, _ = s.store.CloudGet(profile.CloudId)
+	}
+	if profile.GenericId != "" {
+		snapshot.Generic, _ = s.store.GenericGet(profile.GenericId)
+	}
+	if n := len(versions); n > 0 {
+		latest := versions[n-1]
+		if proto.Equal(latest.Profile, profile) && latest.Ignition == snapshot.Ignition &&
+			latest.Cloud == snapshot.Cloud && latest.Generic == snapshot.Generic {
+			return latest, nil
+		}
+		snapshot.Version = latest.Version + 1
+	}
+	return snapshot, s.store.ProfileVersionPut(snapshot)
+}
+
+// snapshotReferencing records a new version of each Profile which matches,
+// typically the Profiles which reference a changed template. The caller
+// must hold the versionMu.
+func (s *server) snapshotReferencing(references func(*storagepb.Profile) bool) error {
+	profiles, err := s.store.ProfileList()
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, profile := range profiles {
+		if !references(profile) {
+			continue
+		}
+		if _, err := s.snapshot(profile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
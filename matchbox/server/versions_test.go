@@ -0,0 +1,112 @@
+package server
+
+import (
+	"testing"
+
+	"context"
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestProfileVersions(t *testing.T) {
+	store := fake.NewFixedStore()
+	srv := NewServer(&Config{Store: store})
+	ctx := context.Background()
+	profile := &storagepb.Profile{Id: "worker", IgnitionId: "worker.yaml"}
+
+	// assert that:
+	// - writing a Profile records a version with its templates
+	// - writing a referenced template records a version
+	// - unchanged writes don't record versions
+	_, err := srv.ProfilePut(ctx, &pb.ProfilePutRequest{Profile: profile})
+	assert.Nil(t, err)
+	_, err = srv.IgnitionPut(ctx, &pb.IgnitionPutRequest{Name: "worker.yaml", Config: []byte("v1")})
+	assert.Nil(t, err)
+	_, err = srv.IgnitionPut(ctx, &pb.IgnitionPutRequest{Name: "worker.yaml", Config: []byte("v1")})
+	assert.Nil(t, err)
+	_, err = srv.IgnitionPut(ctx, &pb.IgnitionPutRequest{Name: "other.yaml", Config: []byte("other")})
+	assert.Nil(t, err)
+	_, err = srv.ProfilePut(ctx, &pb.ProfilePutRequest{Profile: profile})
+	assert.Nil(t, err)
+
+	versions, err := srv.ProfileVersionList(ctx, &pb.ProfileVersionListRequest{Id: "worker"})
+	assert.Nil(t, err)
+	if assert.Len(t, versions, 2) {
+		assert.Equal(t, int32(1), versions[0].Version)
+		assert.Equal(t, "", versions[0].Ignition)
+		assert.Equal(t, int32(2), versions[1].Version)
+		assert.Equal(t, "v1", versions[1].Ignition)
+	}
+}
+
+func TestProfileVersions_Pinned(t *testing.T) {
+	store := fake.NewFixedStore()
+	srv := NewServer(&Config{Store: store})
+	ctx := context.Background()
+	profile := &storagepb.Profile{Id: "worker", IgnitionId: "worker.yaml"}
+	srv.IgnitionPut(ctx, &pb.IgnitionPutRequest{Name: "worker.yaml", Config: []byte("v1")})
+	srv.ProfilePut(ctx, &pb.ProfilePutRequest{Profile: profile})
+	srv.IgnitionPut(ctx, &pb.IgnitionPutRequest{Name: "worker.yaml", Config: []byte("v2")})
+
+	// assert that:
+	// - Groups may only pin Profile versions which exist
+	// - a pinned Group is served the Profile and templates of its version
+	// - other Groups are served the latest version
+	_, err := srv.GroupPut(ctx, &pb.GroupPutRequest{Group: &storagepb.Group{Id: "pinned", Profile: "worker", ProfileVersion: 3}})
+	assert.Error(t, err)
+	pinned := &storagepb.Group{Id: "pinned", Profile: "worker", ProfileVersion: 1}
+	_, err = srv.GroupPut(ctx, &pb.GroupPutRequest{Group: pinned})
+	assert.Nil(t, err)
+
+	pinnedCtx := WithProfileVersion(ctx, pinned)
+	contents, err := srv.IgnitionGet(pinnedCtx, "worker.yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", contents)
+	contents, err = srv.IgnitionGet(ctx, "worker.yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, "v2", contents)
+	got, err := srv.ProfileGet(pinnedCtx, &pb.ProfileGetRequest{Id: "worker"})
+	if assert.Nil(t, err) {
+		assert.Equal(t, profile, got)
+	}
+	latest := WithProfileVersion(ctx, &storagepb.Group{Id: "latest", Profile: "worker"})
+	contents, err = srv.IgnitionGet(latest, "worker.yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, "v2", contents)
+}
+
+func TestProfileRollback(t *testing.T) {
+	store := fake.NewFixedStore()
+	srv := NewServer(&Config{Store: store})
+	ctx := context.Background()
+	profile := &storagepb.Profile{Id: "worker", IgnitionId: "worker.yaml"}
+
+	// assert that:
+	// - Profiles without an earlier version can't be rolled back
+	srv.IgnitionPut(ctx, &pb.IgnitionPutRequest{Name: "worker.yaml", Config: []byte("good")})
+	srv.ProfilePut(ctx, &pb.ProfilePutRequest{Profile: profile})
+	_, err := srv.ProfileRollback(ctx, &pb.ProfileRollbackRequest{Id: "worker"})
+	assert.Equal(t, ErrNoEarlierVersion, err)
+
+	// - rolling back restores the version before the latest, with its
+	// templates, and records it as a new version
+	srv.IgnitionPut(ctx, &pb.IgnitionPutRequest{Name: "worker.yaml", Config: []byte("bad")})
+	version, err := srv.ProfileRollback(ctx, &pb.ProfileRollbackRequest{Id: "worker"})
+	if assert.Nil(t, err) {
+		assert.Equal(t, int32(3), version.Version)
+		assert.Equal(t, "good", version.Ignition)
+	}
+	assert.Equal(t, "good", store.IgnitionConfigs["worker.yaml"])
+
+	// - a specific version may be restored
+	version, err = srv.ProfileRollback(ctx, &pb.ProfileRollbackRequest{Id: "worker", Version: 2})
+	if assert.Nil(t, err) {
+		assert.Equal(t, int32(4), version.Version)
+	}
+	assert.Equal(t, "bad", store.IgnitionConfigs["worker.yaml"])
+	_, err = srv.ProfileRollback(ctx, &pb.ProfileRollbackRequest{Id: "worker", Version: 9})
+	assert.Error(t, err)
+}
@@ -0,0 +1,147 @@
+package sign
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"math/big"
+)
+
+var errUnsupportedCMSKey = errors.New("sign: CMS signing requires an RSA key")
+
+// CMS object identifiers
+var (
+	oidData          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidSHA256        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidRSAEncryption = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+)
+
+// contentInfo is a CMS ContentInfo (RFC 5652 section 3).
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"optional"`
+}
+
+// signedData is a CMS SignedData with detached content (RFC 5652 section
+// 5.1).
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      contentInfo
+	Certificates     asn1.RawValue `asn1:"optional"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+// signerInfo is a CMS SignerInfo without signed attributes (RFC 5652
+// section 5.3).
+type signerInfo struct {
+	Version                   int
+	IssuerAndSerialNumber     issuerAndSerialNumber
+	DigestAlgorithm           pkix.AlgorithmIdentifier
+	DigestEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedDigest           []byte
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+// cmsSigner reads messages and writes detached CMS (PKCS #7) signatures.
+type cmsSigner struct {
+	cert  *x509.Certificate
+	key   *rsa.PrivateKey
+	chain []*x509.Certificate
+}
+
+// NewCMSSigner returns a new Signer that reads messages and writes detached
+// DER CMS (PKCS #7) signatures by the certificate's RSA key, as verified by
+// iPXE's imgverify. Intermediate certificates in the chain are included in
+// signatures.
+func NewCMSSigner(cert *x509.Certificate, key crypto.PrivateKey, chain ...*x509.Certificate) (Signer, error) {
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errUnsupportedCMSKey
+	}
+	return &cmsSigner{
+		cert:  cert,
+		key:   rsaKey,
+		chain: chain,
+	}, nil
+}
+
+// LoadCMSSigner loads a PEM certificate file, whose certificates after the
+// first are intermediates, and its PEM private key file and returns a new
+// CMS Signer.
+func LoadCMSSigner(certPath, keyPath string) (Signer, error) {
+	pair, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, err
+	}
+	var certs []*x509.Certificate
+	for _, der := range pair.Certificate {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	return NewCMSSigner(certs[0], pair.PrivateKey, certs[1:]...)
+}
+
+// Sign signs the given message and writes the detached DER CMS signature
+// to w.
+func (s *cmsSigner) Sign(w io.Writer, message io.Reader) error {
+	h := sha256.New()
+	if _, err := io.Copy(h, message); err != nil {
+		return err
+	}
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, h.Sum(nil))
+	if err != nil {
+		return err
+	}
+
+	var certs []byte
+	for _, cert := range append([]*x509.Certificate{s.cert}, s.chain...) {
+		certs = append(certs, cert.Raw...)
+	}
+	sha256Alg := pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue}
+	signed, err := asn1.Marshal(signedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha256Alg},
+		ContentInfo:      contentInfo{ContentType: oidData},
+		// [0] IMPLICIT SET OF Certificate
+		Certificates: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certs},
+		SignerInfos: []signerInfo{{
+			Version: 1,
+			IssuerAndSerialNumber: issuerAndSerialNumber{
+				Issuer:       asn1.RawValue{FullBytes: s.cert.RawIssuer},
+				SerialNumber: s.cert.SerialNumber,
+			},
+			DigestAlgorithm:           sha256Alg,
+			DigestEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1.NullRawValue},
+			EncryptedDigest:           signature,
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	der, err := asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		// [0] EXPLICIT SignedData
+		Content: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signed},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(der)
+	return err
+}
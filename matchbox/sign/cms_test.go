@@ -0,0 +1,77 @@
+package sign
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newCodeSigningCert returns a self-signed code signing certificate.
+func newCodeSigningCert(t *testing.T) (*x509.Certificate, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: "matchbox image signing"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+	return cert, key
+}
+
+func TestCMSSigner(t *testing.T) {
+	cert, key := newCodeSigningCert(t)
+	signer, err := NewCMSSigner(cert, key)
+	assert.Nil(t, err)
+	message := "kernel image"
+	signature := new(bytes.Buffer)
+	assert.Nil(t, signer.Sign(signature, strings.NewReader(message)))
+
+	// assert that:
+	// - the signature is a detached CMS SignedData
+	// - it includes the certificate and identifies the signer by issuer and
+	//   serial number
+	// - the encrypted digest is the signature of the message's SHA-256
+	var info contentInfo
+	rest, err := asn1.Unmarshal(signature.Bytes(), &info)
+	assert.Nil(t, err)
+	assert.Empty(t, rest)
+	assert.True(t, info.ContentType.Equal(oidSignedData))
+	var signed signedData
+	_, err = asn1.Unmarshal(info.Content.Bytes, &signed)
+	assert.Nil(t, err)
+	assert.True(t, signed.ContentInfo.ContentType.Equal(oidData))
+	assert.Empty(t, signed.ContentInfo.Content.Bytes)
+	assert.Equal(t, cert.Raw, signed.Certificates.Bytes)
+	if assert.Len(t, signed.SignerInfos, 1) {
+		signerInfo := signed.SignerInfos[0]
+		assert.Equal(t, cert.RawIssuer, signerInfo.IssuerAndSerialNumber.Issuer.FullBytes)
+		assert.Equal(t, cert.SerialNumber, signerInfo.IssuerAndSerialNumber.SerialNumber)
+		assert.True(t, signerInfo.DigestAlgorithm.Algorithm.Equal(oidSHA256))
+		digest := sha256.Sum256([]byte(message))
+		assert.Nil(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signerInfo.EncryptedDigest))
+	}
+}
+
+func TestNewCMSSigner_UnsupportedKey(t *testing.T) {
+	cert, _ := newCodeSigningCert(t)
+	_, err := NewCMSSigner(cert, "not a key")
+	assert.Equal(t, errUnsupportedCMSKey, err)
+}
@@ -0,0 +1,72 @@
+package sign
+
+import (
+	"io"
+	"sync"
+)
+
+// A CMSKeyPair holds a CMS Signer loaded from a code signing certificate
+// file and its private key file, which can be reloaded to rotate the
+// certificate without restarting.
+type CMSKeyPair struct {
+	certPath string
+	keyPath  string
+
+	mu     sync.RWMutex
+	signer Signer
+}
+
+// NewCMSKeyPair returns a CMSKeyPair which has loaded the PEM certificate
+// file and its PEM private key file.
+func NewCMSKeyPair(certPath, keyPath string) (*CMSKeyPair, error) {
+	k := &CMSKeyPair{
+		certPath: certPath,
+		keyPath:  keyPath,
+	}
+	if err := k.Reload(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Reload loads the certificate and key files. If either fails to load, the
+// previous certificate and key are kept.
+func (k *CMSKeyPair) Reload() error {
+	signer, err := LoadCMSSigner(k.certPath, k.keyPath)
+	if err != nil {
+		return err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.signer = signer
+	return nil
+}
+
+// Paths returns the certificate and key files.
+func (k *CMSKeyPair) Paths() []string {
+	return []string{k.certPath, k.keyPath}
+}
+
+// Signer returns a Signer which writes CMS signatures by the latest loaded
+// certificate and key.
+func (k *CMSKeyPair) Signer() Signer {
+	return &cmsKeyPairSigner{keyPair: k}
+}
+
+// current returns the latest loaded Signer.
+func (k *CMSKeyPair) current() Signer {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.signer
+}
+
+// cmsKeyPairSigner signs with the certificate and key of a CMSKeyPair.
+type cmsKeyPairSigner struct {
+	keyPair *CMSKeyPair
+}
+
+// Sign signs the given message by the latest loaded certificate and key and
+// writes the detached DER CMS signature to w.
+func (s *cmsKeyPairSigner) Sign(w io.Writer, message io.Reader) error {
+	return s.keyPair.current().Sign(w, message)
+}
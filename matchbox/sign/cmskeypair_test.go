@@ -0,0 +1,68 @@
+package sign
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeKeyPair writes a certificate and its private key to PEM files.
+func writeKeyPair(t *testing.T, certPath, keyPath string, cert *x509.Certificate, key *rsa.PrivateKey) {
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	assert.Nil(t, ioutil.WriteFile(certPath, certPEM, 0644))
+	assert.Nil(t, ioutil.WriteFile(keyPath, keyPEM, 0600))
+}
+
+func TestCMSKeyPair_Reload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "matchbox-sign")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	certPath, keyPath := filepath.Join(dir, "imgtrust.crt"), filepath.Join(dir, "imgtrust.key")
+	old, oldKey := newCodeSigningCert(t)
+	current, currentKey := newCodeSigningCert(t)
+
+	writeKeyPair(t, certPath, keyPath, old, oldKey)
+	keyPair, err := NewCMSKeyPair(certPath, keyPath)
+	assert.Nil(t, err)
+	signer := keyPair.Signer()
+	signedBy := func() []byte {
+		signature := new(bytes.Buffer)
+		assert.Nil(t, signer.Sign(signature, strings.NewReader("kernel image")))
+		var info contentInfo
+		_, err := asn1.Unmarshal(signature.Bytes(), &info)
+		assert.Nil(t, err)
+		var signed signedData
+		_, err = asn1.Unmarshal(info.Content.Bytes, &signed)
+		assert.Nil(t, err)
+		return signed.Certificates.Bytes
+	}
+
+	// assert that:
+	// - signatures include the loaded certificate
+	assert.Equal(t, []string{certPath, keyPath}, keyPair.Paths())
+	assert.Equal(t, old.Raw, signedBy())
+
+	// - reloading rotates the certificate of existing Signers
+	writeKeyPair(t, certPath, keyPath, current, currentKey)
+	assert.Nil(t, keyPair.Reload())
+	assert.Equal(t, current.Raw, signedBy())
+
+	// - invalid files are errors and keep the previous certificate
+	assert.Nil(t, ioutil.WriteFile(keyPath, []byte("mangled"), 0600))
+	assert.Error(t, keyPair.Reload())
+	assert.Equal(t, current.Raw, signedBy())
+
+	// - missing files are errors
+	_, err = NewCMSKeyPair(filepath.Join(dir, "missing.crt"), keyPath)
+	assert.Error(t, err)
+}
@@ -0,0 +1,87 @@
+package sign
+
+import (
+	"io"
+	"sync"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// A Keyring holds the signing keys loaded from key ring files, which can be
+// reloaded to rotate keys without restarting.
+type Keyring struct {
+	paths      []string
+	passphrase string
+
+	mu      sync.RWMutex
+	signers []*openpgp.Entity
+}
+
+// NewKeyring returns a Keyring which has loaded the private keys of each
+// key ring file, unlocked with the given passphrase.
+func NewKeyring(paths []string, passphrase string) (*Keyring, error) {
+	k := &Keyring{
+		paths:      paths,
+		passphrase: passphrase,
+	}
+	if err := k.Reload(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Reload loads the key ring files. If any fail to load, the previous keys
+// are kept.
+func (k *Keyring) Reload() error {
+	var signers []*openpgp.Entity
+	for _, path := range k.paths {
+		entities, err := LoadGPGEntities(path, k.passphrase)
+		if err != nil {
+			return err
+		}
+		signers = append(signers, entities...)
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.signers = signers
+	return nil
+}
+
+// Paths returns the key ring files.
+func (k *Keyring) Paths() []string {
+	return k.paths
+}
+
+// Signer returns a Signer which writes OpenPGP signatures by the latest
+// loaded keys.
+func (k *Keyring) Signer() Signer {
+	return &keyringSigner{keyring: k}
+}
+
+// ArmoredSigner returns a Signer which writes ascii armored OpenPGP
+// signatures by the latest loaded keys.
+func (k *Keyring) ArmoredSigner() Signer {
+	return &keyringSigner{keyring: k, armored: true}
+}
+
+// current returns the latest loaded keys.
+func (k *Keyring) current() []*openpgp.Entity {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.signers
+}
+
+// keyringSigner signs with the keys of a Keyring.
+type keyringSigner struct {
+	keyring *Keyring
+	armored bool
+}
+
+// Sign signs the given message by the latest loaded keys and writes the
+// detached OpenPGP signature to w.
+func (s *keyringSigner) Sign(w io.Writer, message io.Reader) error {
+	if s.armored {
+		return NewArmoredGPGSigner(s.keyring.current()...).Sign(w, message)
+	}
+	return NewGPGSigner(s.keyring.current()...).Sign(w, message)
+}
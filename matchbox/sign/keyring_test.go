@@ -0,0 +1,59 @@
+package sign
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+)
+
+// writeKeyRing writes the private key of the entity to a key ring file.
+func writeKeyRing(t *testing.T, path string, entity *openpgp.Entity) {
+	buf := new(bytes.Buffer)
+	assert.Nil(t, entity.SerializePrivate(buf, nil))
+	assert.Nil(t, ioutil.WriteFile(path, buf.Bytes(), 0600))
+}
+
+func TestKeyring_Reload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "matchbox-sign")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "secring.gpg")
+	old, err := openpgp.NewEntity("old", "", "old@example.com", nil)
+	assert.Nil(t, err)
+	current, err := openpgp.NewEntity("current", "", "current@example.com", nil)
+	assert.Nil(t, err)
+	message := "Hello World!"
+
+	writeKeyRing(t, path, old)
+	keyring, err := NewKeyring([]string{path}, "")
+	assert.Nil(t, err)
+	signer, armored := keyring.Signer(), keyring.ArmoredSigner()
+	signedBy := func(trusted *openpgp.Entity) bool {
+		signature := new(bytes.Buffer)
+		assert.Nil(t, signer.Sign(signature, strings.NewReader(message)))
+		_, err := openpgp.CheckDetachedSignature(openpgp.EntityList{trusted}, strings.NewReader(message), signature)
+		armoredSignature := new(bytes.Buffer)
+		assert.Nil(t, armored.Sign(armoredSignature, strings.NewReader(message)))
+		_, armoredErr := openpgp.CheckArmoredDetachedSignature(openpgp.EntityList{trusted}, strings.NewReader(message), armoredSignature)
+		return err == nil && armoredErr == nil
+	}
+	assert.True(t, signedBy(old))
+
+	// assert that:
+	// - existing signers sign with the reloaded keys
+	// - keys which fail to load are not swapped in
+	writeKeyRing(t, path, current)
+	assert.Nil(t, keyring.Reload())
+	assert.True(t, signedBy(current))
+	assert.False(t, signedBy(old))
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte("mangled"), 0600))
+	assert.NotNil(t, keyring.Reload())
+	assert.True(t, signedBy(current))
+}
@@ -1,16 +1,21 @@
 package sign
 
 import (
+	"bytes"
 	"errors"
 	"io"
+	"io/ioutil"
 	"os"
 
 	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
 )
 
 var (
 	errEmptyKeyring      = errors.New("sign: provided key ring file contained no keys")
 	errMissingPassphrase = errors.New("sign: missing passphrase for encrypted private key")
+	errNoPrivateKeys     = errors.New("sign: provided key ring file contained no private keys")
+	errNoSigners         = errors.New("sign: no signing keys")
 )
 
 // A Signer signs messages and writes detached signatures to w.
@@ -20,42 +25,71 @@ type Signer interface {
 
 // gpgSigner reads messages and writes OpenPGP signatures.
 type gpgSigner struct {
-	signer *openpgp.Entity
+	signers []*openpgp.Entity
 }
 
 // Sign signs the given message and writes the detached OpenPGP signature
-// to w.
+// to w. With several signers, the signature holds a signature packet from
+// each of them.
 func (s *gpgSigner) Sign(w io.Writer, message io.Reader) error {
-	return openpgp.DetachSignText(w, s.signer, message, nil)
+	return signEach(w, s.signers, message)
 }
 
 // NewGPGSigner returns a new Signer that reads messages and writes OpenPGP
-// signatures.
-func NewGPGSigner(signer *openpgp.Entity) Signer {
+// signatures. Messages are signed by each of the signers, so clients which
+// trust any one of the keys can verify signatures while keys are rotated.
+func NewGPGSigner(signers ...*openpgp.Entity) Signer {
 	return &gpgSigner{
-		signer: signer,
+		signers: signers,
 	}
 }
 
 // armoredGPGSigner reads messages and writes ascii armored OpenPGP signatures.
 type armoredGPGSigner struct {
-	signer *openpgp.Entity
+	signers []*openpgp.Entity
 }
 
 // Sign signs the given message and writes the detached ascii armored OpenPGP
 // signature to w.
 func (s *armoredGPGSigner) Sign(w io.Writer, message io.Reader) error {
-	return openpgp.ArmoredDetachSignText(w, s.signer, message, nil)
+	aw, err := armor.Encode(w, openpgp.SignatureType, nil)
+	if err != nil {
+		return err
+	}
+	if err := signEach(aw, s.signers, message); err != nil {
+		return err
+	}
+	return aw.Close()
 }
 
 // NewArmoredGPGSigner returns a new Signer that reads messages and writes
-// ascii armored OpenPGP signatures.
-func NewArmoredGPGSigner(signer *openpgp.Entity) Signer {
+// ascii armored OpenPGP signatures, signed by each of the signers.
+func NewArmoredGPGSigner(signers ...*openpgp.Entity) Signer {
 	return &armoredGPGSigner{
-		signer: signer,
+		signers: signers,
 	}
 }
 
+// signEach writes a detached signature packet of the message by each signer.
+func signEach(w io.Writer, signers []*openpgp.Entity, message io.Reader) error {
+	if len(signers) == 0 {
+		return errNoSigners
+	}
+	if len(signers) == 1 {
+		return openpgp.DetachSignText(w, signers[0], message, nil)
+	}
+	data, err := ioutil.ReadAll(message)
+	if err != nil {
+		return err
+	}
+	for _, signer := range signers {
+		if err := openpgp.DetachSignText(w, signer, bytes.NewReader(data), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // LoadGPGEntity loads a key ring file, unlocks the first key using the given
 // passphrase, and returns a new OpenPGP Entity for signing.
 func LoadGPGEntity(keyRingPath, passphrase string) (*openpgp.Entity, error) {
@@ -83,13 +117,51 @@ func unlockKeyRingEntity(ring io.Reader, passphrase string) (*openpgp.Entity, er
 		return nil, errEmptyKeyring
 	}
 	entity := entities[0]
+	if err := unlock(entity, passphrase); err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+// unlock decrypts the private key of an Entity if it is encrypted.
+func unlock(entity *openpgp.Entity, passphrase string) error {
 	if entity.PrivateKey != nil && entity.PrivateKey.Encrypted {
 		if passphrase == "" {
-			return nil, errMissingPassphrase
+			return errMissingPassphrase
 		}
-		if err := entity.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
+		return entity.PrivateKey.Decrypt([]byte(passphrase))
+	}
+	return nil
+}
+
+// LoadGPGEntities loads a key ring file, unlocks each private key using the
+// given passphrase, and returns the OpenPGP Entities for signing. Key rings
+// with several private keys sign with each, to rotate keys.
+func LoadGPGEntities(keyRingPath, passphrase string) ([]*openpgp.Entity, error) {
+	kring, err := os.Open(keyRingPath)
+	if err != nil {
+		return nil, err
+	}
+	defer kring.Close()
+	entities, err := openpgp.ReadKeyRing(kring)
+	if err != nil {
+		return nil, err
+	}
+	if len(entities) < 1 {
+		return nil, errEmptyKeyring
+	}
+	var signers []*openpgp.Entity
+	for _, entity := range entities {
+		if entity.PrivateKey == nil {
+			continue
+		}
+		if err := unlock(entity, passphrase); err != nil {
 			return nil, err
 		}
+		signers = append(signers, entity)
 	}
-	return entity, nil
+	if len(signers) == 0 {
+		return nil, errNoPrivateKeys
+	}
+	return signers, nil
 }
@@ -88,3 +88,45 @@ func TestArmoredGPGSigner(t *testing.T) {
 	_, err = openpgp.CheckArmoredDetachedSignature(entities, strings.NewReader(expectedMessage), signature)
 	assert.Nil(t, err)
 }
+
+func TestLoadGPGEntities(t *testing.T) {
+	entities, err := LoadGPGEntities("fixtures/secring.gpg", "test")
+	assert.Nil(t, err)
+	assert.Len(t, entities, 1)
+	_, err = LoadGPGEntities("fixtures/secring.gpg", "")
+	assert.Equal(t, errMissingPassphrase, err)
+	_, err = LoadGPGEntities("fixtures/empty.gpg", "")
+	assert.Equal(t, errEmptyKeyring, err)
+}
+
+func TestGPGSigner_Rotation(t *testing.T) {
+	old, err := openpgp.NewEntity("old", "", "old@example.com", nil)
+	assert.Nil(t, err)
+	current, err := openpgp.NewEntity("current", "", "current@example.com", nil)
+	assert.Nil(t, err)
+	message := "Hello World!"
+
+	// assert that:
+	// - signatures by several keys can be verified by clients which trust
+	//   any one of the keys
+	// - armored signatures can be verified likewise
+	signature := new(bytes.Buffer)
+	err = NewGPGSigner(old, current).Sign(signature, strings.NewReader(message))
+	assert.Nil(t, err)
+	for _, trusted := range []*openpgp.Entity{old, current} {
+		signer, err := openpgp.CheckDetachedSignature(openpgp.EntityList{trusted}, strings.NewReader(message), bytes.NewReader(signature.Bytes()))
+		assert.Nil(t, err)
+		assert.Equal(t, trusted, signer)
+	}
+
+	armored := new(bytes.Buffer)
+	err = NewArmoredGPGSigner(old, current).Sign(armored, strings.NewReader(message))
+	assert.Nil(t, err)
+	for _, trusted := range []*openpgp.Entity{old, current} {
+		_, err := openpgp.CheckArmoredDetachedSignature(openpgp.EntityList{trusted}, strings.NewReader(message), bytes.NewReader(armored.Bytes()))
+		assert.Nil(t, err)
+	}
+
+	// signers without keys fail
+	assert.Equal(t, errNoSigners, NewGPGSigner().Sign(new(bytes.Buffer), strings.NewReader(message)))
+}
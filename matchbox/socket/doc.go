@@ -0,0 +1,3 @@
+// Package socket listens on TCP addresses, unix domain sockets, and sockets
+// passed by systemd socket activation.
+package socket
@@ -0,0 +1,158 @@
+package socket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Address prefixes of unix domain sockets (e.g. "unix:/run/matchbox/rpc.sock")
+// and systemd activated sockets (e.g. "systemd:matchbox-rpc").
+const (
+	UnixPrefix    = "unix:"
+	SystemdPrefix = "systemd:"
+)
+
+// UnixMode is the file mode of unix domain sockets, so members of the
+// socket's group may connect.
+const UnixMode os.FileMode = 0660
+
+// listenFDsStart is the first file descriptor passed by systemd.
+const listenFDsStart = 3
+
+var (
+	// ErrNotActivated is returned for systemd addresses if systemd passed no
+	// sockets to matchbox.
+	ErrNotActivated = errors.New("socket: no sockets were passed by systemd")
+	// ErrSocketInUse is returned if another process listens on a unix socket.
+	ErrSocketInUse = errors.New("socket: unix socket is in use")
+)
+
+// Listen listens on an address: a TCP host:port, with keepAlive periods on
+// accepted connections (0 uses the net default), a unix socket path with
+// UnixPrefix, or a systemd socket with SystemdPrefix and the socket's
+// FileDescriptorName (which may be omitted if systemd passed one socket).
+func Listen(address string, keepAlive time.Duration) (net.Listener, error) {
+	switch {
+	case strings.HasPrefix(address, UnixPrefix):
+		return listenUnix(strings.TrimPrefix(address, UnixPrefix))
+	case strings.HasPrefix(address, SystemdPrefix):
+		return activated.listen(strings.TrimPrefix(address, SystemdPrefix))
+	}
+	lc := net.ListenConfig{KeepAlive: keepAlive}
+	return lc.Listen(context.Background(), "tcp", address)
+}
+
+// IsUnix returns true if an address is a unix domain socket address.
+func IsUnix(addr net.Addr) bool {
+	return addr != nil && addr.Network() == "unix"
+}
+
+// listenUnix listens on a unix socket at path, replacing a stale socket
+// left by a process which exited without removing it.
+func listenUnix(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%v: %s", ErrSocketInUse, path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	lis, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, UnixMode); err != nil {
+		lis.Close()
+		return nil, err
+	}
+	return lis, nil
+}
+
+// activated holds the sockets passed by systemd.
+var activated = &sockets{getenv: os.Getenv, start: listenFDsStart}
+
+// sockets are the sockets passed to a process with the sd_listen_fds(3)
+// protocol, read once and each claimed by one listener.
+type sockets struct {
+	getenv func(string) string
+	start  int
+	once   sync.Once
+	mu     sync.Mutex
+	files  []*os.File
+}
+
+// load reads the passed sockets and unsets the environment variables which
+// describe them, so they aren't passed on to child processes.
+func (s *sockets) load() {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+	if pid, err := strconv.Atoi(s.getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return
+	}
+	count, err := strconv.Atoi(s.getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return
+	}
+	names := strings.Split(s.getenv("LISTEN_FDNAMES"), ":")
+	for i := 0; i < count; i++ {
+		fd := s.start + i
+		syscall.CloseOnExec(fd)
+		name := "unknown"
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		s.files = append(s.files, os.NewFile(uintptr(fd), name))
+	}
+}
+
+// listen returns a listener for the first unclaimed socket with the name,
+// or for the only socket if name is empty.
+func (s *sockets) listen(name string) (net.Listener, error) {
+	s.once.Do(s.load)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.files) == 0 {
+		return nil, ErrNotActivated
+	}
+	index := -1
+	for i, file := range s.files {
+		if file == nil {
+			continue
+		}
+		if name == "" {
+			if index >= 0 {
+				return nil, errors.New("socket: systemd passed several sockets, give a socket name")
+			}
+			index = i
+		} else if file.Name() == name {
+			index = i
+			break
+		}
+	}
+	if index < 0 && name == "" {
+		return nil, errors.New("socket: the systemd socket is already in use")
+	} else if index < 0 {
+		return nil, fmt.Errorf("socket: systemd socket %q was not passed", name)
+	}
+	file := s.files[index]
+	lis, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("socket: systemd socket %q: %v", file.Name(), err)
+	}
+	file.Close()
+	s.files[index] = nil
+	return lis, nil
+}
@@ -0,0 +1,90 @@
+package socket
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListen_TCP(t *testing.T) {
+	lis, err := Listen("127.0.0.1:0", 0)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "tcp", lis.Addr().Network())
+		assert.False(t, IsUnix(lis.Addr()))
+		lis.Close()
+	}
+}
+
+func TestListen_Unix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "socket")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "matchbox.sock")
+
+	// assert that:
+	// - unix sockets are listened on with the UnixMode
+	// - sockets in use by another listener are not replaced
+	// - stale sockets are replaced
+	lis, err := Listen(UnixPrefix+path, 0)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.True(t, IsUnix(lis.Addr()))
+	info, err := os.Stat(path)
+	if assert.Nil(t, err) {
+		assert.Equal(t, UnixMode, info.Mode().Perm())
+	}
+	_, err = Listen(UnixPrefix+path, 0)
+	assert.Contains(t, err.Error(), ErrSocketInUse.Error())
+
+	stale, err := net.Listen("unix", path+".stale")
+	assert.Nil(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+	lis.Close()
+	os.Rename(path+".stale", path)
+	lis, err = Listen(UnixPrefix+path, 0)
+	if assert.Nil(t, err) {
+		lis.Close()
+	}
+}
+
+func TestSockets(t *testing.T) {
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer tcp.Close()
+	file, err := tcp.(*net.TCPListener).File()
+	assert.Nil(t, err)
+	env := map[string]string{
+		"LISTEN_PID":     strconv.Itoa(os.Getpid()),
+		"LISTEN_FDS":     "1",
+		"LISTEN_FDNAMES": "matchbox-rpc",
+	}
+	s := &sockets{
+		getenv: func(key string) string { return env[key] },
+		start:  int(file.Fd()),
+	}
+
+	// assert that:
+	// - passed sockets are listened on by name
+	// - each socket is claimed once
+	_, err = s.listen("matchbox-http")
+	assert.EqualError(t, err, `socket: systemd socket "matchbox-http" was not passed`)
+	lis, err := s.listen("matchbox-rpc")
+	if assert.Nil(t, err) {
+		assert.Equal(t, tcp.Addr().String(), lis.Addr().String())
+		lis.Close()
+	}
+	_, err = s.listen("matchbox-rpc")
+	assert.Error(t, err)
+
+	// - sockets passed to another process are ignored
+	env["LISTEN_PID"] = "1"
+	_, err = (&sockets{getenv: func(key string) string { return env[key] }}).listen("")
+	assert.Equal(t, ErrNotActivated, err)
+}
@@ -0,0 +1,3 @@
+// Package sources fetches Group metadata from external sources (HTTP JSON
+// endpoints, Consul KV, and DNS TXT records) at render time.
+package sources
@@ -0,0 +1,296 @@
+package sources
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"context"
+	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/requestid"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// Defaults for fetching metadata sources.
+const (
+	DefaultTimeout  = 5 * time.Second
+	DefaultCacheTTL = 30 * time.Second
+)
+
+// Possible fetch errors
+var (
+	ErrNotObject         = errors.New("sources: value without a key must be a JSON object")
+	ErrUnsupportedScheme = errors.New("sources: unsupported metadata source scheme")
+)
+
+// Config configures a Fetcher.
+type Config struct {
+	// Timeout of each source fetch
+	Timeout time.Duration
+	// CacheTTL is how long fetched values are reused (0 uses the default)
+	CacheTTL time.Duration
+	// (optional) Consul ACL token for consul sources
+	ConsulToken string
+	// (optional) HTTP client
+	Client *http.Client
+	Logger *logrus.Logger
+}
+
+// entry is a cached source value.
+type entry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// Fetcher fetches and caches metadata source values.
+type Fetcher struct {
+	timeout     time.Duration
+	ttl         time.Duration
+	consulToken string
+	client      *http.Client
+	logger      *logrus.Logger
+	lookupTXT   func(ctx context.Context, name string) ([]string, error)
+	mu          sync.Mutex
+	cache       map[string]entry
+	lastSweep   time.Time
+	now         func() time.Time
+}
+
+// NewFetcher returns a new Fetcher.
+func NewFetcher(config *Config) *Fetcher {
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	ttl := config.CacheTTL
+	if ttl == 0 {
+		ttl = DefaultCacheTTL
+	}
+	client := config.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = logrus.New()
+	}
+	return &Fetcher{
+		timeout:     timeout,
+		ttl:         ttl,
+		consulToken: config.ConsulToken,
+		client:      client,
+		logger:      logger,
+		lookupTXT:   net.DefaultResolver.LookupTXT,
+		cache:       make(map[string]entry),
+		now:         time.Now,
+	}
+}
+
+// Merge returns a copy of the Group with the values of its metadata sources
+// merged into its metadata, in order. Source URLs are rendered with the
+// machine labels. If a source cannot be fetched, its last value is used, or
+// an error is returned if it has none.
+func (f *Fetcher) Merge(ctx context.Context, group *storagepb.Group, labels map[string]string) (*storagepb.Group, error) {
+	if len(group.MetadataSources) == 0 {
+		return group, nil
+	}
+	metadata := make(map[string]interface{})
+	if group.Metadata != nil {
+		if err := json.Unmarshal(group.Metadata, &metadata); err != nil {
+			return nil, err
+		}
+	}
+	for _, source := range group.MetadataSources {
+		rawURL, err := renderURL(source.Url, labels)
+		if err != nil {
+			return nil, fmt.Errorf("sources: invalid url %q: %v", source.Url, err)
+		}
+		value, err := f.Fetch(ctx, rawURL)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			continue
+		}
+		if source.Key != "" {
+			metadata[source.Key] = value
+			continue
+		}
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, ErrNotObject
+		}
+		for key, value := range values {
+			metadata[key] = value
+		}
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+	merged := group.Copy()
+	merged.Metadata = data
+	return merged, nil
+}
+
+// Fetch returns the value of a metadata source URL, from the cache if
+// fetched within the cache TTL. Sources which don't exist have a nil value.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (interface{}, error) {
+	f.mu.Lock()
+	cached, ok := f.cache[rawURL]
+	f.mu.Unlock()
+	if ok && f.now().Before(cached.expires) {
+		return cached.value, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+	value, err := f.fetch(ctx, rawURL)
+	if err != nil {
+		if ok {
+			f.logger.Warningf("sources: using last value of %s: %v", rawURL, err)
+			return cached.value, nil
+		}
+		return nil, fmt.Errorf("sources: error fetching %s: %v", rawURL, err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := f.now()
+	if now.Sub(f.lastSweep) >= f.ttl {
+		// keep expired values for reuse if a later fetch fails, for up
+		// to ten TTLs
+		for key, e := range f.cache {
+			if now.Sub(e.expires) >= 10*f.ttl {
+				delete(f.cache, key)
+			}
+		}
+		f.lastSweep = now
+	}
+	f.cache[rawURL] = entry{value: value, expires: now.Add(f.ttl)}
+	return value, nil
+}
+
+// fetch fetches the value of a metadata source URL.
+func (f *Fetcher) fetch(ctx context.Context, rawURL string) (interface{}, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return f.fetchHTTP(ctx, u.String(), nil)
+	case "consul", "consul+https":
+		return f.fetchConsul(ctx, u)
+	case "dns":
+		return f.fetchTXT(ctx, u)
+	}
+	return nil, ErrUnsupportedScheme
+}
+
+// fetchHTTP GETs a JSON value.
+func (f *Fetcher) fetchHTTP(ctx context.Context, rawURL string, header http.Header) (interface{}, error) {
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	requestid.SetHeader(ctx, req.Header)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return decodeValue(body), nil
+}
+
+// fetchConsul reads a Consul KV key, as JSON if it parses.
+func (f *Fetcher) fetchConsul(ctx context.Context, u *url.URL) (interface{}, error) {
+	scheme := "http"
+	if u.Scheme == "consul+https" {
+		scheme = "https"
+	}
+	// keep escaping, so labels can't traverse keys
+	kv := fmt.Sprintf("%s://%s/v1/kv/%s?raw", scheme, u.Host, strings.TrimPrefix(u.EscapedPath(), "/"))
+	header := http.Header{}
+	if f.consulToken != "" {
+		header.Set("X-Consul-Token", f.consulToken)
+	}
+	return f.fetchHTTP(ctx, kv, header)
+}
+
+// fetchTXT reads "key=value" pairs from the TXT records of a DNS name.
+func (f *Fetcher) fetchTXT(ctx context.Context, u *url.URL) (interface{}, error) {
+	name := u.Opaque
+	if name == "" {
+		name = strings.TrimPrefix(u.Path, "/")
+	}
+	records, err := f.lookupTXT(ctx, name)
+	if err != nil {
+		if dnsErr, ok := err.(*net.DNSError); ok && dnsErr.IsNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	values := make(map[string]interface{})
+	for _, record := range records {
+		parts := strings.SplitN(record, "=", 2)
+		if len(parts) == 2 && parts[0] != "" {
+			values[parts[0]] = parts[1]
+		}
+	}
+	return values, nil
+}
+
+// decodeValue decodes JSON, or returns the data as a string.
+func decodeValue(data []byte) interface{} {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return string(data)
+	}
+	return value
+}
+
+// renderURL renders a source URL template with path escaped machine labels.
+func renderURL(rawURL string, labels map[string]string) (string, error) {
+	if !strings.Contains(rawURL, "{{") {
+		return rawURL, nil
+	}
+	tmpl, err := template.New("url").Option("missingkey=error").Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	escaped := make(map[string]string, len(labels))
+	for key, value := range labels {
+		escaped[key] = url.PathEscape(value)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, escaped); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
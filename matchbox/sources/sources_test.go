@@ -0,0 +1,185 @@
+package sources
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"context"
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+func TestMerge(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.EscapedPath() {
+		case "/nodes/a1b2c3d4":
+			w.Write([]byte(`{"rack": "r1", "etcd_name": "node1"}`))
+		case "/v1/kv/nodes/../secrets":
+			w.Write([]byte(`{"secret": "value"}`))
+		case "/v1/kv/matchbox/release":
+			if req.URL.RawQuery != "raw" || req.Header.Get("X-Consul-Token") != "consul-token" {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			w.Write([]byte(`stable`))
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	f := NewFetcher(&Config{ConsulToken: "consul-token"})
+	f.lookupTXT = func(ctx context.Context, name string) ([]string, error) {
+		if name != "_matchbox.example.com" {
+			return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+		}
+		return []string{"channel=beta", "v=spf1 -all", "=ignored"}, nil
+	}
+	group := &storagepb.Group{
+		Id:       "node1",
+		Profile:  "worker",
+		Metadata: []byte(`{"rack": "unknown", "domain": "example.com"}`),
+		MetadataSources: []*storagepb.MetadataSource{
+			{Url: srv.URL + "/nodes/{{.uuid}}"},
+			{Url: "consul://" + host + "/matchbox/release", Key: "release"},
+			{Url: "dns:_matchbox.example.com", Key: "dns"},
+			// missing sources are skipped
+			{Url: srv.URL + "/missing"},
+			{Url: "dns:_missing.example.com", Key: "missing"},
+			// labels are escaped, so the key is not found
+			{Url: "consul://" + host + "/nodes/{{.mac}}", Key: "escaped"},
+		},
+	}
+	merged, err := f.Merge(context.Background(), group, map[string]string{"uuid": "a1b2c3d4", "mac": "../secrets"})
+	// assert that:
+	// - source objects are merged over Group metadata
+	// - keyed sources are set under their key
+	// - DNS TXT records are read as key=value pairs
+	// - the Group is not modified
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"rack": "r1", "etcd_name": "node1", "domain": "example.com", "release": "stable", "dns": {"channel": "beta", "v": "spf1 -all"}}`, string(merged.Metadata))
+	assert.Equal(t, `{"rack": "unknown", "domain": "example.com"}`, string(group.Metadata))
+
+	// assert that:
+	// - Groups without sources are returned as is
+	plain := &storagepb.Group{Id: "plain", Metadata: []byte(`{}`)}
+	merged, err = f.Merge(context.Background(), plain, nil)
+	assert.Nil(t, err)
+	assert.True(t, plain == merged)
+}
+
+func TestMerge_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/list" {
+			w.Write([]byte(`[1, 2]`))
+			return
+		}
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	f := NewFetcher(&Config{})
+
+	cases := []struct {
+		source *storagepb.MetadataSource
+		labels map[string]string
+	}{
+		// unavailable source
+		{&storagepb.MetadataSource{Url: srv.URL + "/nodes"}, nil},
+		// values merged without a key must be objects
+		{&storagepb.MetadataSource{Url: srv.URL + "/list"}, nil},
+		// missing labels
+		{&storagepb.MetadataSource{Url: srv.URL + "/nodes/{{.uuid}}"}, map[string]string{"mac": "52:54:00:a1:9c:ae"}},
+		{&storagepb.MetadataSource{Url: "ftp://example.com/nodes"}, nil},
+	}
+	for _, c := range cases {
+		group := &storagepb.Group{Id: "node1", MetadataSources: []*storagepb.MetadataSource{c.source}}
+		_, err := f.Merge(context.Background(), group, c.labels)
+		assert.Error(t, err)
+	}
+}
+
+func TestFetch_Cache(t *testing.T) {
+	var requests int
+	fail := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		if fail {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"channel": "stable"}`))
+	}))
+	defer srv.Close()
+	logger, _ := logtest.NewNullLogger()
+	now := time.Now()
+	f := NewFetcher(&Config{CacheTTL: time.Minute, Logger: logger})
+	f.now = func() time.Time { return now }
+
+	first, err := f.Fetch(context.Background(), srv.URL)
+	assert.Nil(t, err)
+	second, err := f.Fetch(context.Background(), srv.URL)
+	assert.Nil(t, err)
+	// assert that:
+	// - values are cached for the TTL
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, requests)
+
+	// assert that:
+	// - expired values are refetched
+	// - the last value is used if a fetch fails
+	now = now.Add(time.Minute)
+	fail = true
+	value, err := f.Fetch(context.Background(), srv.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, first, value)
+	assert.Equal(t, 2, requests)
+}
+
+func TestFetch_Timeout(t *testing.T) {
+	block := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-block
+	}))
+	defer srv.Close()
+	defer close(block)
+	f := NewFetcher(&Config{Timeout: 50 * time.Millisecond})
+	// assert that:
+	// - slow sources time out
+	_, err := f.Fetch(context.Background(), srv.URL)
+	assert.Error(t, err)
+}
+
+func TestFetch_DNSError(t *testing.T) {
+	f := NewFetcher(&Config{})
+	f.lookupTXT = func(ctx context.Context, name string) ([]string, error) {
+		return nil, errors.New("server misbehaving")
+	}
+	_, err := f.Fetch(context.Background(), "dns:_matchbox.example.com")
+	assert.Error(t, err)
+}
+
+func TestRenderURL(t *testing.T) {
+	cases := []struct {
+		url      string
+		labels   map[string]string
+		expected string
+	}{
+		{"https://cmdb.example.com/nodes", nil, "https://cmdb.example.com/nodes"},
+		{"https://cmdb.example.com/nodes/{{.mac}}", map[string]string{"mac": "52:54:00:a1:9c:ae"}, "https://cmdb.example.com/nodes/52:54:00:a1:9c:ae"},
+		// labels can't traverse paths
+		{"consul://127.0.0.1:8500/nodes/{{.uuid}}", map[string]string{"uuid": "../secrets/x"}, "consul://127.0.0.1:8500/nodes/..%2Fsecrets%2Fx"},
+	}
+	for _, c := range cases {
+		rendered, err := renderURL(c.url, c.labels)
+		assert.Nil(t, err)
+		assert.Equal(t, c.expected, rendered)
+	}
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"container/list"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultCacheSize is the number of parsed Groups, Profiles, and Profile
+// versions a file store caches if none is configured.
+const DefaultCacheSize = 10000
+
+// resourceCache is a least recently used cache of parsed resources by file
+// path. Entries are valid while their file's modification time and size
+// are unchanged, so files edited directly are parsed again.
+type resourceCache struct {
+	size int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// entries, most recently used first
+	order *list.List
+}
+
+// cacheEntry is a parsed resource and the file it was parsed from.
+type cacheEntry struct {
+	path    string
+	modtime time.Time
+	size    int64
+	value   interface{}
+}
+
+func newResourceCache(size int) *resourceCache {
+	return &resourceCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// get returns the resource parsed from the file at path, if the file is
+// unchanged since.
+func (c *resourceCache) get(path string, info os.FileInfo) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[path]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !entry.modtime.Equal(info.ModTime()) || entry.size != info.Size() {
+		c.order.Remove(elem)
+		delete(c.entries, path)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// put caches the resource parsed from the file at path, evicting the least
+// recently used resources beyond the cache size.
+func (c *resourceCache) put(path string, info os.FileInfo, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &cacheEntry{path: path, modtime: info.ModTime(), size: info.Size(), value: value}
+	if elem, ok := c.entries[path]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[path] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).path)
+	}
+}
+
+// remove discards the resource parsed from the file at path.
+func (c *resourceCache) remove(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[path]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, path)
+	}
+}
+
+// clear discards all cached resources.
+func (c *resourceCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+}
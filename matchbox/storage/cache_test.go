@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+// fileInfo is a FileInfo with a fixed modification time and size.
+type fileInfo struct {
+	os.FileInfo
+	modtime time.Time
+	size    int64
+}
+
+func (f fileInfo) ModTime() time.Time { return f.modtime }
+func (f fileInfo) Size() int64        { return f.size }
+
+func TestResourceCache(t *testing.T) {
+	now := time.Now()
+	info := fileInfo{modtime: now, size: 1}
+	c := newResourceCache(2)
+	c.put("a", info, "a")
+	c.put("b", info, "b")
+	value, ok := c.get("a", info)
+	// assert that:
+	// - resources are cached while their file is unchanged
+	assert.True(t, ok)
+	assert.Equal(t, "a", value)
+
+	// - the least recently used resource is evicted beyond the size
+	c.put("c", info, "c")
+	_, ok = c.get("b", info)
+	assert.False(t, ok)
+	_, ok = c.get("a", info)
+	assert.True(t, ok)
+
+	// - resources of changed files are discarded
+	_, ok = c.get("a", fileInfo{modtime: now.Add(time.Second), size: 1})
+	assert.False(t, ok)
+	_, ok = c.get("c", fileInfo{modtime: now, size: 2})
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.order.Len())
+
+	// - clearing discards all resources
+	c.put("a", info, "a")
+	c.clear()
+	_, ok = c.get("a", info)
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.order.Len())
+}
+
+func TestFileStore_Cache(t *testing.T) {
+	dir, err := setup(&fake.FixedStore{
+		Groups: map[string]*storagepb.Group{fake.Group.Id: fake.Group},
+	})
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store := NewFileStore(&Config{Root: dir, CacheSize: 8})
+	group, err := store.GroupGet(fake.Group.Id)
+	assert.Nil(t, err)
+	// assert that:
+	// - cached resources are copied, so callers can't modify them
+	group.Name = "changed"
+	group, err = store.GroupGet(fake.Group.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, fake.Group, group)
+
+	// - writes discard cached resources
+	other := fake.Group.Copy()
+	other.Name = "other"
+	assert.Nil(t, store.GroupPut(other))
+	group, err = store.GroupGet(fake.Group.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, "other", group.Name)
+
+	// - files edited directly are parsed again
+	path := filepath.Join(dir, "groups", fake.Group.Id+".json")
+	assert.Nil(t, ioutil.WriteFile(path, []byte(`{"id":"test-group","profile":"g1h2i3j4","name":"edited"}`), 0644))
+	later := time.Now().Add(time.Minute)
+	assert.Nil(t, os.Chtimes(path, later, later))
+	group, err = store.GroupGet(fake.Group.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, "edited", group.Name)
+
+	// - deleted files aren't served from the cache
+	assert.Nil(t, store.GroupDelete(fake.Group.Id))
+	_, err = store.GroupGet(fake.Group.Id)
+	assert.Error(t, err)
+}
+
+func TestFileStore_Reload(t *testing.T) {
+	dir, err := setup(&fake.FixedStore{
+		Groups: map[string]*storagepb.Group{fake.Group.Id: fake.Group},
+	})
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store := Instrument(NewFileStore(&Config{Root: dir, CacheSize: 8}))
+	_, err = store.GroupGet(fake.Group.Id)
+	assert.Nil(t, err)
+	// edit the file without changing its modification time or size
+	path := filepath.Join(dir, "groups", fake.Group.Id+".json")
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	edited := []byte(strings.Replace(string(data), fake.Group.Name, strings.ToUpper(fake.Group.Name), 1))
+	assert.Nil(t, ioutil.WriteFile(path, edited, 0644))
+	assert.Nil(t, os.Chtimes(path, info.ModTime(), info.ModTime()))
+
+	// assert that:
+	// - instrumented Stores are Reloaders
+	// - reloading discards cached resources, so files are parsed again
+	reloader, ok := store.(Reloader)
+	if assert.True(t, ok) {
+		reloader.Reload()
+	}
+	group, err := store.GroupGet(fake.Group.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, strings.ToUpper(fake.Group.Name), group.Name)
+}
@@ -0,0 +1,292 @@
+package storage
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/metrics"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+var (
+	fallbackTotal = metrics.NewCounterVec(
+		"matchbox_store_fallback_total",
+		"Store reads served from the last known good snapshot because the store failed, by operation.",
+		"operation")
+	staleSeconds = metrics.NewGaugeVec(
+		"matchbox_store_stale_seconds",
+		"Age of the snapshot served by the latest store read, or 0 if the store served it.")
+)
+
+// fallbackStore wraps a Store to keep the last known good result of each
+// read, and serve it if the Store fails (e.g. a remote backend is down),
+// so booting machines aren't given errors. Missing resource errors are
+// returned, since the Store answered. Writes, and the Event history which
+// machines don't read, are passed to the Store.
+type fallbackStore struct {
+	store  Store
+	logger *logrus.Logger
+
+	mu       sync.Mutex
+	snapshot map[string]*snapshotEntry
+	// degraded is true while reads are served from the snapshot
+	degraded bool
+	now      func() time.Time
+}
+
+// snapshotEntry is the result of a successful read.
+type snapshotEntry struct {
+	value interface{}
+	time  time.Time
+}
+
+// Fallback returns a Store which serves the last known good result of
+// each read when the given Store fails. Only resources read since the
+// Store was wrapped can be served.
+func Fallback(store Store, logger *logrus.Logger) Store {
+	if logger == nil {
+		logger = logrus.New()
+	}
+	return &fallbackStore{
+		store:    store,
+		logger:   logger,
+		snapshot: make(map[string]*snapshotEntry),
+		now:      time.Now,
+	}
+}
+
+// Reload discards the resources cached by the Store, if it's a Reloader.
+// The snapshot is kept, in case the Store fails.
+func (s *fallbackStore) Reload() {
+	if reloader, ok := s.store.(Reloader); ok {
+		reloader.Reload()
+	}
+}
+
+// read reads a resource from the Store, saving it in the snapshot by key,
+// or returns the snapshot if the Store failed.
+func (s *fallbackStore) read(operation, key string, read func() (interface{}, error)) (interface{}, error) {
+	value, err := read()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil || notFound(err) {
+		if err == nil {
+			s.snapshot[key] = &snapshotEntry{value: value, time: s.now()}
+		} else {
+			delete(s.snapshot, key)
+		}
+		if s.degraded {
+			s.degraded = false
+			s.logger.Info("store recovered, serving reads from the store")
+		}
+		staleSeconds.Set(0)
+		return value, err
+	}
+	entry, ok := s.snapshot[key]
+	if !ok {
+		return value, err
+	}
+	age := s.now().Sub(entry.time)
+	fallbackTotal.Inc(operation)
+	staleSeconds.Set(age.Seconds())
+	fields := logrus.Fields{"operation": operation, "age": age.Seconds()}
+	if !s.degraded {
+		s.degraded = true
+		s.logger.WithFields(fields).Warningf("store failed, serving reads from the last known good snapshot: %v", err)
+	} else {
+		s.logger.WithFields(fields).Debugf("store failed, serving %s from snapshot: %v", key, err)
+	}
+	return entry.value, nil
+}
+
+// forget discards snapshot keys after a successful write, so later reads
+// can't fall back to resources which were changed.
+func (s *fallbackStore) forget(err error, keys ...string) error {
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		delete(s.snapshot, key)
+	}
+	return nil
+}
+
+func (s *fallbackStore) GroupPut(group *storagepb.Group) error {
+	return s.forget(s.store.GroupPut(group), "group/"+group.Id, "groups")
+}
+
+func (s *fallbackStore) GroupGet(id string) (*storagepb.Group, error) {
+	value, err := s.read("group_get", "group/"+id, func() (interface{}, error) {
+		return s.store.GroupGet(id)
+	})
+	group, _ := value.(*storagepb.Group)
+	return group, err
+}
+
+func (s *fallbackStore) GroupList() ([]*storagepb.Group, error) {
+	value, err := s.read("group_list", "groups", func() (interface{}, error) {
+		return s.store.GroupList()
+	})
+	groups, _ := value.([]*storagepb.Group)
+	return groups, err
+}
+
+func (s *fallbackStore) GroupDelete(id string) error {
+	return s.forget(s.store.GroupDelete(id), "group/"+id, "groups")
+}
+
+func (s *fallbackStore) ProfilePut(profile *storagepb.Profile) error {
+	return s.forget(s.store.ProfilePut(profile), "profile/"+profile.Id, "profiles")
+}
+
+func (s *fallbackStore) ProfileGet(id string) (*storagepb.Profile, error) {
+	value, err := s.read("profile_get", "profile/"+id, func() (interface{}, error) {
+		return s.store.ProfileGet(id)
+	})
+	profile, _ := value.(*storagepb.Profile)
+	return profile, err
+}
+
+func (s *fallbackStore) ProfileList() ([]*storagepb.Profile, error) {
+	value, err := s.read("profile_list", "profiles", func() (interface{}, error) {
+		return s.store.ProfileList()
+	})
+	profiles, _ := value.([]*storagepb.Profile)
+	return profiles, err
+}
+
+func (s *fallbackStore) ProfileDelete(id string) error {
+	return s.forget(s.store.ProfileDelete(id), "profile/"+id, "profiles")
+}
+
+func (s *fallbackStore) ProfileVersionPut(version *storagepb.ProfileVersion) error {
+	err := s.store.ProfileVersionPut(version)
+	if version.Profile == nil {
+		return err
+	}
+	return s.forget(err, "profile_versions/"+version.Profile.Id)
+}
+
+func (s *fallbackStore) ProfileVersionGet(id string, version int32) (*storagepb.ProfileVersion, error) {
+	key := "profile_version/" + id + "/" + strconv.Itoa(int(version))
+	value, err := s.read("profile_version_get", key, func() (interface{}, error) {
+		return s.store.ProfileVersionGet(id, version)
+	})
+	snapshot, _ := value.(*storagepb.ProfileVersion)
+	return snapshot, err
+}
+
+func (s *fallbackStore) ProfileVersionList(id string) ([]*storagepb.ProfileVersion, error) {
+	value, err := s.read("profile_version_list", "profile_versions/"+id, func() (interface{}, error) {
+		return s.store.ProfileVersionList(id)
+	})
+	versions, _ := value.([]*storagepb.ProfileVersion)
+	return versions, err
+}
+
+// templateGet reads a template of a kind (e.g. ignition).
+func (s *fallbackStore) templateGet(kind, name string, get func(string) (string, error)) (string, error) {
+	value, err := s.read(kind+"_get", kind+"/"+name, func() (interface{}, error) {
+		return get(name)
+	})
+	contents, _ := value.(string)
+	return contents, err
+}
+
+// templateList lists the templates of a kind (e.g. ignition).
+func (s *fallbackStore) templateList(kind string, list func() ([]string, error)) ([]string, error) {
+	value, err := s.read(kind+"_list", kind+"s", func() (interface{}, error) {
+		return list()
+	})
+	names, _ := value.([]string)
+	return names, err
+}
+
+func (s *fallbackStore) IgnitionPut(name string, config []byte) error {
+	return s.forget(s.store.IgnitionPut(name, config), "ignition/"+name, "ignitions")
+}
+
+func (s *fallbackStore) IgnitionGet(name string) (string, error) {
+	return s.templateGet("ignition", name, s.store.IgnitionGet)
+}
+
+func (s *fallbackStore) IgnitionList() ([]string, error) {
+	return s.templateList("ignition", s.store.IgnitionList)
+}
+
+func (s *fallbackStore) IgnitionDelete(name string) error {
+	return s.forget(s.store.IgnitionDelete(name), "ignition/"+name, "ignitions")
+}
+
+func (s *fallbackStore) CloudPut(name string, config []byte) error {
+	return s.forget(s.store.CloudPut(name, config), "cloud/"+name, "clouds")
+}
+
+func (s *fallbackStore) CloudGet(name string) (string, error) {
+	return s.templateGet("cloud", name, s.store.CloudGet)
+}
+
+func (s *fallbackStore) CloudList() ([]string, error) {
+	return s.templateList("cloud", s.store.CloudList)
+}
+
+func (s *fallbackStore) CloudDelete(name string) error {
+	return s.forget(s.store.CloudDelete(name), "cloud/"+name, "clouds")
+}
+
+func (s *fallbackStore) GenericPut(name string, config []byte) error {
+	return s.forget(s.store.GenericPut(name, config), "generic/"+name, "generics")
+}
+
+func (s *fallbackStore) GenericGet(name string) (string, error) {
+	return s.templateGet("generic", name, s.store.GenericGet)
+}
+
+func (s *fallbackStore) GenericList() ([]string, error) {
+	return s.templateList("generic", s.store.GenericList)
+}
+
+func (s *fallbackStore) GenericDelete(name string) error {
+	return s.forget(s.store.GenericDelete(name), "generic/"+name, "generics")
+}
+
+func (s *fallbackStore) MachinePut(machine *storagepb.Machine) error {
+	return s.forget(s.store.MachinePut(machine), "machine/"+machine.Id, "machines")
+}
+
+func (s *fallbackStore) MachineGet(id string) (*storagepb.Machine, error) {
+	value, err := s.read("machine_get", "machine/"+id, func() (interface{}, error) {
+		return s.store.MachineGet(id)
+	})
+	machine, _ := value.(*storagepb.Machine)
+	return machine, err
+}
+
+func (s *fallbackStore) MachineList() ([]*storagepb.Machine, error) {
+	value, err := s.read("machine_list", "machines", func() (interface{}, error) {
+		return s.store.MachineList()
+	})
+	machines, _ := value.([]*storagepb.Machine)
+	return machines, err
+}
+
+func (s *fallbackStore) MachineArchive(machine *storagepb.Machine) error {
+	return s.forget(s.store.MachineArchive(machine), "machine/"+machine.Id, "machines")
+}
+
+func (s *fallbackStore) EventPut(event *storagepb.Event) error {
+	return s.store.EventPut(event)
+}
+
+func (s *fallbackStore) EventList(since, until time.Time) ([]*storagepb.Event, error) {
+	return s.store.EventList(since, until)
+}
+
+func (s *fallbackStore) EventPrune(before time.Time) error {
+	return s.store.EventPrune(before)
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+// switchStore is a Store which can be switched to a failing Store.
+type switchStore struct {
+	Store
+}
+
+func TestFallback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "matchbox")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	backend := &switchStore{NewFileStore(&Config{Root: dir})}
+	logger, hook := logtest.NewNullLogger()
+	store := Fallback(backend, logger)
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	store.(*fallbackStore).now = func() time.Time { return now }
+
+	assert.Nil(t, store.GroupPut(fake.Group))
+	assert.Nil(t, store.IgnitionPut("etcd.yaml", []byte("ignition")))
+	_, err = store.GroupGet(fake.Group.Id)
+	assert.Nil(t, err)
+	_, err = store.IgnitionGet("etcd.yaml")
+	assert.Nil(t, err)
+	groups, err := store.GroupList()
+	assert.Nil(t, err)
+
+	// assert that:
+	// - reads are served from the snapshot while the store fails
+	// - the staleness and fallbacks are recorded and logged once
+	// - resources which weren't read are errors
+	// - writes are passed to the store
+	backend.Store = &fake.BrokenStore{}
+	now = now.Add(time.Minute)
+	before := fallbackTotal.Value("group_get")
+	group, err := store.GroupGet(fake.Group.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, fake.Group, group)
+	listed, err := store.GroupList()
+	assert.Nil(t, err)
+	assert.Equal(t, groups, listed)
+	contents, err := store.IgnitionGet("etcd.yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, "ignition", contents)
+	assert.Equal(t, before+1, fallbackTotal.Value("group_get"))
+	assert.Equal(t, float64(60), staleSeconds.Value())
+	warnings := 0
+	for _, entry := range hook.Entries {
+		if entry.Level == logrus.WarnLevel {
+			warnings++
+		}
+	}
+	assert.Equal(t, 1, warnings)
+	_, err = store.ProfileGet(fake.Profile.Id)
+	assert.Error(t, err)
+	assert.Error(t, store.GroupPut(fake.Group))
+
+	// - reads are served from the store once it recovers
+	backend.Store = NewFileStore(&Config{Root: dir})
+	_, err = store.GroupGet("missing")
+	assert.True(t, notFound(err))
+	assert.Equal(t, float64(0), staleSeconds.Value())
+	assert.Equal(t, "store recovered, serving reads from the store", hook.LastEntry().Message)
+
+	// - resources which were written or deleted aren't served from the snapshot
+	assert.Nil(t, store.GroupDelete(fake.Group.Id))
+	backend.Store = &fake.BrokenStore{}
+	_, err = store.GroupGet(fake.Group.Id)
+	assert.Error(t, err)
+}
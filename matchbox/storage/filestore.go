@@ -2,17 +2,40 @@ package storage
 
 import (
 	"encoding/json"
+	"fmt"
+	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/coreos/matchbox/matchbox/storage/storagepb"
 )
 
+// archiveTimeFormat formats the time Machines are archived in file names.
+const archiveTimeFormat = "20060102T150405Z"
+
+// eventDayFormat formats the day of the Events in an event history file.
+const eventDayFormat = "2006-01-02"
+
+// eventsExt is the extension of event history files, which have an Event
+// JSON object per line.
+const eventsExt = ".jsonl"
+
+// listWorkers is the number of files read and parsed concurrently when
+// listing resources.
+const listWorkers = 16
+
 // Config initializes a fileStore.
 type Config struct {
 	Root   string
 	Logger *logrus.Logger
+	// number of parsed Groups, Profiles, Profile versions, and Machines
+	// cached (0 disables)
+	CacheSize int
 }
 
 // fileStore implements ths Store interface. Queries to the file system
@@ -20,14 +43,145 @@ type Config struct {
 type fileStore struct {
 	root   string
 	logger *logrus.Logger
+	// (optional) parsed resources by path
+	cache *resourceCache
+	// serializes appends to and pruning of event history files
+	eventsMu sync.Mutex
 }
 
 // NewFileStore returns a new memory-backed Store.
 func NewFileStore(config *Config) Store {
-	return &fileStore{
+	store := &fileStore{
 		root:   config.Root,
 		logger: config.Logger,
 	}
+	if config.CacheSize > 0 {
+		store.cache = newResourceCache(config.CacheSize)
+	}
+	return store
+}
+
+// readParsed returns the resource parsed from the file at the given path,
+// from the cache if the file is unchanged since it was parsed. Resources
+// are parsed when first read, rather than loaded up front.
+func (s *fileStore) readParsed(path string, parse func([]byte) (interface{}, error)) (interface{}, error) {
+	if s.cache == nil {
+		data, err := Dir(s.root).readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return parse(data)
+	}
+	info, err := Dir(s.root).stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if value, ok := s.cache.get(path, info); ok {
+		return value, nil
+	}
+	data, err := Dir(s.root).readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	value, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.put(path, info, value)
+	return value, nil
+}
+
+// resourcePath returns the path of the file of a Group or Profile in dir:
+// its JSON file, or else its existing YAML file.
+func (s *fileStore) resourcePath(dir, id string) string {
+	path := filepath.Join(dir, id+".json")
+	if _, err := Dir(s.root).stat(path); err == nil {
+		return path
+	}
+	for _, ext := range []string{".yaml", ".yml"} {
+		if _, err := Dir(s.root).stat(filepath.Join(dir, id+ext)); err == nil {
+			return filepath.Join(dir, id+ext)
+		}
+	}
+	return path
+}
+
+// marshalResource formats a Group or Profile for the file at path, as YAML
+// if the path has a YAML extension, so edits keep the file's format.
+func marshalResource(path string, v interface{}) ([]byte, error) {
+	data, err := json.MarshalIndent(v, "", "\t")
+	if err != nil || !storagepb.IsYAML(path) {
+		return data, err
+	}
+	return storagepb.JSONToYAML(data)
+}
+
+// resourceNames returns the ids of the Group or Profile files, once each
+// if an id has both JSON and YAML files.
+func resourceNames(files []os.FileInfo) []string {
+	seen := make(map[string]bool)
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		name := strings.TrimSuffix(file.Name(), filepath.Ext(file.Name()))
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// readEach calls read with the index of each of n files, reading up to
+// listWorkers files concurrently.
+func readEach(n int, read func(i int)) {
+	workers := listWorkers
+	if n < workers {
+		workers = n
+	}
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				read(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+}
+
+// Reload discards the cached resources, so each file is parsed again when
+// next read.
+func (s *fileStore) Reload() {
+	if s.cache != nil {
+		s.cache.clear()
+	}
+}
+
+// writeParsed writes the file at the given path and discards the resource
+// cached from it.
+func (s *fileStore) writeParsed(path string, data []byte) error {
+	err := Dir(s.root).writeFile(path, data)
+	if s.cache != nil {
+		s.cache.remove(path)
+	}
+	return err
+}
+
+// deleteParsed deletes the file at the given path and discards the
+// resource cached from it.
+func (s *fileStore) deleteParsed(path string) error {
+	err := Dir(s.root).deleteFile(path)
+	if s.cache != nil {
+		s.cache.remove(path)
+	}
+	return err
 }
 
 // GroupPut writes the given Group.
@@ -36,24 +190,28 @@ func (s *fileStore) GroupPut(group *storagepb.Group) error {
 	if err != nil {
 		return err
 	}
-	data, err := json.MarshalIndent(richGroup, "", "\t")
+	path := s.resourcePath("groups", group.Id)
+	data, err := marshalResource(path, richGroup)
 	if err != nil {
 		return err
 	}
-	return Dir(s.root).writeFile(filepath.Join("groups", group.Id+".json"), data)
+	return s.writeParsed(path, data)
 }
 
 // GroupGet returns a machine Group by id.
 func (s *fileStore) GroupGet(id string) (*storagepb.Group, error) {
-	data, err := Dir(s.root).readFile(filepath.Join("groups", id+".json"))
-	if err != nil {
-		return nil, err
-	}
-	group, err := storagepb.ParseGroup(data)
+	path := s.resourcePath("groups", id)
+	value, err := s.readParsed(path, func(data []byte) (interface{}, error) {
+		group, err := storagepb.ParseGroupFile(path, data)
+		if err != nil {
+			return nil, storagepb.FileError(path, err)
+		}
+		return group, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return group, err
+	return value.(*storagepb.Group).Copy(), nil
 }
 
 // GroupList lists all machine Groups.
@@ -62,43 +220,58 @@ func (s *fileStore) GroupList() ([]*storagepb.Group, error) {
 	if err != nil {
 		return nil, err
 	}
-	groups := make([]*storagepb.Group, 0, len(files))
-	for _, finfo := range files {
-		name := strings.TrimSuffix(finfo.Name(), filepath.Ext(finfo.Name()))
+	names := resourceNames(files)
+	found := make([]*storagepb.Group, len(names))
+	readEach(len(names), func(i int) {
+		name := names[i]
 		group, err := s.GroupGet(name)
 		if err == nil {
-			groups = append(groups, group)
+			found[i] = group
 		} else if s.logger != nil {
-			s.logger.Infof("Group %q: %v", name, err)
+			s.logger.Errorf("Group %q: %v", name, err)
+		}
+	})
+	groups := make([]*storagepb.Group, 0, len(names))
+	for _, group := range found {
+		if group != nil {
+			groups = append(groups, group)
 		}
 	}
 	return groups, nil
 }
 
+// GroupDelete deletes a machine Group by id.
+func (s *fileStore) GroupDelete(id string) error {
+	return s.deleteParsed(s.resourcePath("groups", id))
+}
+
 // ProfilePut writes the given Profile.
 func (s *fileStore) ProfilePut(profile *storagepb.Profile) error {
-	data, err := json.MarshalIndent(profile, "", "\t")
+	path := s.resourcePath("profiles", profile.Id)
+	data, err := marshalResource(path, profile)
 	if err != nil {
 		return err
 	}
-	return Dir(s.root).writeFile(filepath.Join("profiles", profile.Id+".json"), data)
+	return s.writeParsed(path, data)
 }
 
 // ProfileGet gets a profile by id.
 func (s *fileStore) ProfileGet(id string) (*storagepb.Profile, error) {
-	data, err := Dir(s.root).readFile(filepath.Join("profiles", id+".json"))
-	if err != nil {
-		return nil, err
-	}
-	profile := new(storagepb.Profile)
-	err = json.Unmarshal(data, profile)
+	path := s.resourcePath("profiles", id)
+	value, err := s.readParsed(path, func(data []byte) (interface{}, error) {
+		profile, err := storagepb.ParseProfileFile(path, data)
+		if err != nil {
+			return nil, storagepb.FileError(path, err)
+		}
+		if err := profile.AssertValid(); err != nil {
+			return nil, err
+		}
+		return profile, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	if err := profile.AssertValid(); err != nil {
-		return nil, err
-	}
-	return profile, err
+	return value.(*storagepb.Profile).Copy(), nil
 }
 
 // ProfileList lists all profiles.
@@ -107,19 +280,99 @@ func (s *fileStore) ProfileList() ([]*storagepb.Profile, error) {
 	if err != nil {
 		return nil, err
 	}
-	profiles := make([]*storagepb.Profile, 0, len(files))
-	for _, finfo := range files {
-		name := strings.TrimSuffix(finfo.Name(), filepath.Ext(finfo.Name()))
+	names := resourceNames(files)
+	found := make([]*storagepb.Profile, len(names))
+	readEach(len(names), func(i int) {
+		name := names[i]
 		profile, err := s.ProfileGet(name)
 		if err == nil {
-			profiles = append(profiles, profile)
+			found[i] = profile
 		} else if s.logger != nil {
-			s.logger.Infof("Profile %q: %v", name, err)
+			s.logger.Errorf("Profile %q: %v", name, err)
+		}
+	})
+	profiles := make([]*storagepb.Profile, 0, len(names))
+	for _, profile := range found {
+		if profile != nil {
+			profiles = append(profiles, profile)
 		}
 	}
 	return profiles, nil
 }
 
+// ProfileDelete deletes a profile by id.
+func (s *fileStore) ProfileDelete(id string) error {
+	return s.deleteParsed(s.resourcePath("profiles", id))
+}
+
+// ProfileVersionPut writes the given Profile snapshot to the versions
+// directory, named by its version.
+func (s *fileStore) ProfileVersionPut(version *storagepb.ProfileVersion) error {
+	data, err := json.MarshalIndent(version, "", "\t")
+	if err != nil {
+		return err
+	}
+	name := strconv.Itoa(int(version.Version)) + ".json"
+	return s.writeParsed(filepath.Join("versions", "profiles", version.Profile.Id, name), data)
+}
+
+// ProfileVersionGet gets a Profile snapshot by id and version.
+func (s *fileStore) ProfileVersionGet(id string, version int32) (*storagepb.ProfileVersion, error) {
+	name := strconv.Itoa(int(version)) + ".json"
+	value, err := s.readParsed(filepath.Join("versions", "profiles", id, name), func(data []byte) (interface{}, error) {
+		snapshot, err := storagepb.ParseProfileVersion(data)
+		if err != nil {
+			return nil, err
+		}
+		if err := snapshot.AssertValid(); err != nil {
+			return nil, err
+		}
+		return snapshot, nil
+	})
+	if os.IsNotExist(err) {
+		return nil, ErrVersionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return value.(*storagepb.ProfileVersion).Copy(), nil
+}
+
+// ProfileVersionList lists the snapshots of a Profile, oldest first. A
+// Profile without snapshots has an empty list.
+func (s *fileStore) ProfileVersionList(id string) ([]*storagepb.ProfileVersion, error) {
+	files, err := Dir(s.root).readDir(filepath.Join("versions", "profiles", id))
+	if os.IsNotExist(err) {
+		return []*storagepb.ProfileVersion{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	found := make([]*storagepb.ProfileVersion, len(files))
+	readEach(len(files), func(i int) {
+		number, err := strconv.Atoi(strings.TrimSuffix(files[i].Name(), filepath.Ext(files[i].Name())))
+		if err != nil {
+			return
+		}
+		version, err := s.ProfileVersionGet(id, int32(number))
+		if err == nil {
+			found[i] = version
+		} else if s.logger != nil {
+			s.logger.Infof("Profile %q version %d: %v", id, number, err)
+		}
+	})
+	versions := make([]*storagepb.ProfileVersion, 0, len(files))
+	for _, version := range found {
+		if version != nil {
+			versions = append(versions, version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version < versions[j].Version
+	})
+	return versions, nil
+}
+
 // IgnitionPut creates or updates an Ignition template.
 func (s *fileStore) IgnitionPut(name string, config []byte) error {
 	return Dir(s.root).writeFile(filepath.Join("ignition", name), config)
@@ -131,14 +384,296 @@ func (s *fileStore) IgnitionGet(name string) (string, error) {
 	return string(data), err
 }
 
+// IgnitionList lists the names of all Ignition templates.
+func (s *fileStore) IgnitionList() ([]string, error) {
+	return s.templateList("ignition")
+}
+
+// IgnitionDelete deletes an Ignition template by name.
+func (s *fileStore) IgnitionDelete(name string) error {
+	return Dir(s.root).deleteFile(filepath.Join("ignition", name))
+}
+
+// CloudPut creates or updates a Cloud-Config template.
+func (s *fileStore) CloudPut(name string, config []byte) error {
+	return Dir(s.root).writeFile(filepath.Join("cloud", name), config)
+}
+
 // CloudGet gets a Cloud-Config template by name.
 func (s *fileStore) CloudGet(name string) (string, error) {
 	data, err := Dir(s.root).readFile(filepath.Join("cloud", name))
 	return string(data), err
 }
 
+// CloudList lists the names of all Cloud-Config templates.
+func (s *fileStore) CloudList() ([]string, error) {
+	return s.templateList("cloud")
+}
+
+// CloudDelete deletes a Cloud-Config template by name.
+func (s *fileStore) CloudDelete(name string) error {
+	return Dir(s.root).deleteFile(filepath.Join("cloud", name))
+}
+
+// GenericPut creates or updates a generic template.
+func (s *fileStore) GenericPut(name string, config []byte) error {
+	return Dir(s.root).writeFile(filepath.Join("generic", name), config)
+}
+
 // GenericGet gets a generic template by name.
 func (s *fileStore) GenericGet(name string) (string, error) {
 	data, err := Dir(s.root).readFile(filepath.Join("generic", name))
 	return string(data), err
 }
+
+// GenericList lists the names of all generic templates.
+func (s *fileStore) GenericList() ([]string, error) {
+	return s.templateList("generic")
+}
+
+// GenericDelete deletes a generic template by name.
+func (s *fileStore) GenericDelete(name string) error {
+	return Dir(s.root).deleteFile(filepath.Join("generic", name))
+}
+
+// templateList lists the names of the templates in the given directory. A
+// missing directory has no templates.
+func (s *fileStore) templateList(dirname string) ([]string, error) {
+	files, err := Dir(s.root).readDir(dirname)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(files))
+	for _, finfo := range files {
+		if finfo.Mode().IsRegular() {
+			names = append(names, finfo.Name())
+		}
+	}
+	return names, nil
+}
+
+// MachinePut writes the given Machine.
+func (s *fileStore) MachinePut(machine *storagepb.Machine) error {
+	data, err := json.MarshalIndent(machine, "", "\t")
+	if err != nil {
+		return err
+	}
+	return s.writeParsed(filepath.Join("machines", machine.Id+".json"), data)
+}
+
+// MachineGet gets a Machine by id.
+func (s *fileStore) MachineGet(id string) (*storagepb.Machine, error) {
+	value, err := s.readParsed(filepath.Join("machines", id+".json"), func(data []byte) (interface{}, error) {
+		machine, err := storagepb.ParseMachine(data)
+		if err != nil {
+			return nil, err
+		}
+		if err := machine.AssertValid(); err != nil {
+			return nil, err
+		}
+		return machine, nil
+	})
+	if os.IsNotExist(err) {
+		return nil, ErrMachineNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return value.(*storagepb.Machine).Copy(), nil
+}
+
+// MachineArchive writes the given Machine to the archive directory, named
+// by its id and the time it was archived, and removes it from the machines
+// directory.
+func (s *fileStore) MachineArchive(machine *storagepb.Machine) error {
+	data, err := json.MarshalIndent(machine, "", "\t")
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.json", machine.Id, time.Now().UTC().Format(archiveTimeFormat))
+	if err := Dir(s.root).writeFile(filepath.Join("archive", "machines", name), data); err != nil {
+		return err
+	}
+	err = s.deleteParsed(filepath.Join("machines", machine.Id+".json"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// MachineList lists all Machines. Machines are only recorded once they
+// report in, so a missing machines directory is an empty list.
+func (s *fileStore) MachineList() ([]*storagepb.Machine, error) {
+	files, err := Dir(s.root).readDir("machines")
+	if os.IsNotExist(err) {
+		return []*storagepb.Machine{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	found := make([]*storagepb.Machine, len(files))
+	readEach(len(files), func(i int) {
+		name := strings.TrimSuffix(files[i].Name(), filepath.Ext(files[i].Name()))
+		machine, err := s.MachineGet(name)
+		if err == nil {
+			found[i] = machine
+		} else if s.logger != nil {
+			s.logger.Infof("Machine %q: %v", name, err)
+		}
+	})
+	machines := make([]*storagepb.Machine, 0, len(files))
+	for _, machine := range found {
+		if machine != nil {
+			machines = append(machines, machine)
+		}
+	}
+	return machines, nil
+}
+
+// EventPut appends the given Event to the event history file of its day.
+func (s *fileStore) EventPut(event *storagepb.Event) error {
+	t, err := event.ParseTime()
+	if err != nil {
+		return storagepb.ErrInvalidEventTime
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	s.eventsMu.Lock()
+	defer s.eventsMu.Unlock()
+	return Dir(s.root).appendFile(eventsPath(t), append(data, '\n'))
+}
+
+// EventList lists the Events recorded from since until before until, oldest
+// first, reading only the event history files of the days in the range.
+// Events are only recorded once machines make requests, so a missing
+// events directory is an empty list.
+func (s *fileStore) EventList(since, until time.Time) ([]*storagepb.Event, error) {
+	days, err := s.eventDays()
+	if err != nil {
+		return nil, err
+	}
+	events := []*storagepb.Event{}
+	for _, day := range days {
+		if !until.IsZero() && !day.Before(until) {
+			break
+		}
+		if !since.IsZero() && !day.AddDate(0, 0, 1).After(since) {
+			continue
+		}
+		data, err := Dir(s.root).readFile(eventsPath(day))
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range s.parseEvents(eventsPath(day), data) {
+			t, _ := event.ParseTime()
+			if (since.IsZero() || !t.Before(since)) && (until.IsZero() || t.Before(until)) {
+				events = append(events, event)
+			}
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		ti, _ := events[i].ParseTime()
+		tj, _ := events[j].ParseTime()
+		return ti.Before(tj)
+	})
+	return events, nil
+}
+
+// EventPrune deletes the event history files of days before a time, and
+// rewrites the file of its day without the Events before it.
+func (s *fileStore) EventPrune(before time.Time) error {
+	s.eventsMu.Lock()
+	defer s.eventsMu.Unlock()
+	days, err := s.eventDays()
+	if err != nil {
+		return err
+	}
+	for _, day := range days {
+		if !day.Before(before) {
+			break
+		}
+		path := eventsPath(day)
+		if !day.AddDate(0, 0, 1).After(before) {
+			if err := Dir(s.root).deleteFile(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		data, err := Dir(s.root).readFile(path)
+		if err != nil {
+			return err
+		}
+		var kept []byte
+		for _, event := range s.parseEvents(path, data) {
+			if t, _ := event.ParseTime(); t.Before(before) {
+				continue
+			}
+			line, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			kept = append(append(kept, line...), '\n')
+		}
+		if err := Dir(s.root).writeFile(path, kept); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eventDays returns the days of the event history files, oldest first.
+func (s *fileStore) eventDays() ([]time.Time, error) {
+	files, err := Dir(s.root).readDir("events")
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	days := make([]time.Time, 0, len(files))
+	for _, finfo := range files {
+		if !finfo.Mode().IsRegular() || filepath.Ext(finfo.Name()) != eventsExt {
+			continue
+		}
+		day, err := time.Parse(eventDayFormat, strings.TrimSuffix(finfo.Name(), eventsExt))
+		if err != nil {
+			continue
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// parseEvents parses the Events of an event history file, skipping (and
+// logging) invalid lines, such as one partially written before a crash.
+func (s *fileStore) parseEvents(path string, data []byte) []*storagepb.Event {
+	var events []*storagepb.Event
+	for i, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		event, err := storagepb.ParseEvent([]byte(line))
+		if err == nil {
+			err = event.AssertValid()
+		}
+		if err != nil {
+			if s.logger != nil {
+				s.logger.Infof("Event %s:%d: %v", path, i+1, err)
+			}
+			continue
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// eventsPath returns the path of the event history file of the day of a
+// time, in UTC.
+func eventsPath(t time.Time) string {
+	return filepath.Join("events", t.UTC().Format(eventDayFormat)+eventsExt)
+}
@@ -2,10 +2,12 @@ package storage
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -82,6 +84,30 @@ func TestGroupList(t *testing.T) {
 	}
 }
 
+func TestGroupList_Many(t *testing.T) {
+	dir, err := setup(&fake.FixedStore{})
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store := NewFileStore(&Config{Root: dir})
+	for i := 0; i < 100; i++ {
+		err := store.GroupPut(&storagepb.Group{Id: fmt.Sprintf("group-%03d", i), Profile: "worker"})
+		assert.Nil(t, err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "groups", "bad.json"), []byte("{"), defaultFileMode)
+	assert.Nil(t, err)
+	// assert that:
+	// - every valid Group is listed, in file name order
+	// - invalid Groups are skipped
+	groups, err := store.GroupList()
+	assert.Nil(t, err)
+	if assert.Equal(t, 100, len(groups)) {
+		for i, group := range groups {
+			assert.Equal(t, fmt.Sprintf("group-%03d", i), group.Id)
+		}
+	}
+}
+
 func TestProfilePut(t *testing.T) {
 	dir, err := setup(&fake.FixedStore{})
 	assert.Nil(t, err)
@@ -160,6 +186,158 @@ func TestIgnitionGet(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestCloudPut(t *testing.T) {
+	dir, err := setup(&fake.FixedStore{})
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store := NewFileStore(&Config{Root: dir})
+	// assert that:
+	// - Cloud-Config and generic template creation was successful
+	// - templates can be retrieved by name
+	err = store.CloudPut("cloudcfg.yaml", []byte("#cloud-config"))
+	assert.Nil(t, err)
+	err = store.GenericPut("generic.tmpl", []byte("{{.uuid}}"))
+	assert.Nil(t, err)
+	cfg, err := store.CloudGet("cloudcfg.yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, "#cloud-config", cfg)
+	cfg, err = store.GenericGet("generic.tmpl")
+	assert.Nil(t, err)
+	assert.Equal(t, "{{.uuid}}", cfg)
+}
+
+func TestTemplateList(t *testing.T) {
+	dir, err := setup(&fake.FixedStore{
+		IgnitionConfigs: map[string]string{"b.yaml": "", "a.json": ""},
+	})
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store := NewFileStore(&Config{Root: dir})
+	// assert that:
+	// - template names are listed in sorted order
+	// - empty or missing template directories have no templates
+	names, err := store.IgnitionList()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a.json", "b.yaml"}, names)
+	names, err = store.CloudList()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{}, names)
+	names, err = store.GenericList()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{}, names)
+}
+
+func TestTemplateDelete(t *testing.T) {
+	dir, err := setup(&fake.FixedStore{
+		IgnitionConfigs: map[string]string{"a.yaml": ""},
+		CloudConfigs:    map[string]string{"b.yaml": ""},
+		GenericConfigs:  map[string]string{"c.tmpl": ""},
+	})
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store := NewFileStore(&Config{Root: dir})
+	// assert that:
+	// - templates are deleted by name
+	// - deleting a missing template is a not exist error
+	assert.Nil(t, store.IgnitionDelete("a.yaml"))
+	assert.Nil(t, store.CloudDelete("b.yaml"))
+	assert.Nil(t, store.GenericDelete("c.tmpl"))
+	for _, list := range []func() ([]string, error){store.IgnitionList, store.CloudList, store.GenericList} {
+		names, err := list()
+		assert.Nil(t, err)
+		assert.Empty(t, names)
+	}
+	err = store.IgnitionDelete("a.yaml")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestGroupProfileDelete(t *testing.T) {
+	dir, err := setup(&fake.FixedStore{
+		Groups:   map[string]*storagepb.Group{fake.Group.Id: fake.Group},
+		Profiles: map[string]*storagepb.Profile{fake.Profile.Id: fake.Profile},
+	})
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store := NewFileStore(&Config{Root: dir})
+	// assert that:
+	// - Groups and Profiles are deleted by id
+	// - deleting a missing Group is a not exist error
+	assert.Nil(t, store.GroupDelete(fake.Group.Id))
+	assert.Nil(t, store.ProfileDelete(fake.Profile.Id))
+	groups, err := store.GroupList()
+	assert.Nil(t, err)
+	assert.Empty(t, groups)
+	profiles, err := store.ProfileList()
+	assert.Nil(t, err)
+	assert.Empty(t, profiles)
+	err = store.GroupDelete(fake.Group.Id)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFileStore_YAML(t *testing.T) {
+	dir, err := setup(&fake.FixedStore{})
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	group := `# workers in rack 4
+id: node1
+profile: worker
+selector:
+  mac: 52:54:00:A1:9C:AE
+metadata:
+  pod_network: 10.2.0.0/16
+`
+	profile := `id: worker
+ignition_id: worker.yaml
+boot:
+  kernel: /assets/kernel
+  args:
+    - console=ttyS0
+`
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "groups", "node1.yaml"), []byte(group), defaultFileMode))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "profiles", "worker.yml"), []byte(profile), defaultFileMode))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "groups", "typo.yaml"), []byte("id: typo\nprofile: worker\nselecter: {}\n"), defaultFileMode))
+
+	store := NewFileStore(&Config{Root: dir})
+	// assert that:
+	// - YAML Groups and Profiles are read by id
+	// - invalid YAML files are errors with their path and position
+	got, err := store.GroupGet("node1")
+	assert.Nil(t, err)
+	assert.Equal(t, "52:54:00:a1:9c:ae", got.Selector["mac"])
+	assert.Equal(t, []byte(`{"pod_network":"10.2.0.0/16"}`), got.Metadata)
+	gotProfile, err := store.ProfileGet("worker")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"console=ttyS0"}, gotProfile.Boot.Args)
+	_, err = store.GroupGet("typo")
+	if assert.Error(t, err) {
+		assert.Equal(t, `groups/typo.yaml:3:1: json: unknown field "selecter"`, err.Error())
+	}
+
+	// - updates keep the file's format
+	got.Profile = "worker-next"
+	assert.Nil(t, store.GroupPut(got))
+	data, err := ioutil.ReadFile(filepath.Join(dir, "groups", "node1.yaml"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), "profile: worker-next")
+	_, err = os.Stat(filepath.Join(dir, "groups", "node1.json"))
+	assert.True(t, os.IsNotExist(err))
+
+	// - ids with both JSON and YAML files are listed once
+	assert.Nil(t, store.GroupPut(&storagepb.Group{Id: "typo", Profile: "worker"}))
+	groups, err := store.GroupList()
+	assert.Nil(t, err)
+	assert.Len(t, groups, 2)
+
+	// - YAML files are deleted by id
+	assert.Nil(t, store.ProfileDelete("worker"))
+	_, err = os.Stat(filepath.Join(dir, "profiles", "worker.yml"))
+	assert.True(t, os.IsNotExist(err))
+}
+
 func TestCloudGet(t *testing.T) {
 	contents := "#cloud-config"
 	dir, err := setup(&fake.FixedStore{
@@ -174,6 +352,88 @@ func TestCloudGet(t *testing.T) {
 	assert.Equal(t, contents, cfg)
 }
 
+func TestMachinePutGetList(t *testing.T) {
+	dir, err := setup(&fake.FixedStore{})
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store := NewFileStore(&Config{Root: dir})
+	// assert that:
+	// - no machines are listed before any report in
+	// - Machine creation was successful
+	// - Machine can be retrieved by id and listed
+	machines, err := store.MachineList()
+	assert.Nil(t, err)
+	assert.Empty(t, machines)
+	_, err = store.MachineGet(fake.Machine.Id)
+	assert.Equal(t, ErrMachineNotFound, err)
+
+	err = store.MachinePut(fake.Machine)
+	assert.Nil(t, err)
+	machine, err := store.MachineGet(fake.Machine.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, fake.Machine, machine)
+	machines, err = store.MachineList()
+	assert.Nil(t, err)
+	assert.Equal(t, []*storagepb.Machine{fake.Machine}, machines)
+}
+
+func TestMachineArchive(t *testing.T) {
+	dir, err := setup(&fake.FixedStore{})
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store := NewFileStore(&Config{Root: dir})
+	err = store.MachinePut(fake.Machine)
+	assert.Nil(t, err)
+	// assert that:
+	// - the Machine is written to the archive
+	// - the Machine is removed from the Machines
+	err = store.MachineArchive(fake.Machine)
+	assert.Nil(t, err)
+	_, err = store.MachineGet(fake.Machine.Id)
+	assert.Equal(t, ErrMachineNotFound, err)
+	archived, err := filepath.Glob(filepath.Join(dir, "archive", "machines", fake.Machine.Id+"-*.json"))
+	assert.Nil(t, err)
+	if assert.Len(t, archived, 1) {
+		data, err := ioutil.ReadFile(archived[0])
+		assert.Nil(t, err)
+		machine, err := storagepb.ParseMachine(data)
+		assert.Nil(t, err)
+		assert.Equal(t, fake.Machine, machine)
+	}
+	// - Machines which were never recorded may be archived
+	err = store.MachineArchive(&storagepb.Machine{Id: "b2c3d4e5"})
+	assert.Nil(t, err)
+}
+
+func TestProfileVersions(t *testing.T) {
+	dir, err := setup(&fake.FixedStore{})
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store := NewFileStore(&Config{Root: dir})
+	// assert that:
+	// - Profiles without snapshots list no versions
+	// - snapshots can be retrieved by version and are listed oldest first
+	versions, err := store.ProfileVersionList(fake.Profile.Id)
+	assert.Nil(t, err)
+	assert.Empty(t, versions)
+	_, err = store.ProfileVersionGet(fake.Profile.Id, 1)
+	assert.Equal(t, ErrVersionNotFound, err)
+
+	v2 := &storagepb.ProfileVersion{Version: 2, Profile: fake.Profile, Ignition: "ignition: {}"}
+	v10 := &storagepb.ProfileVersion{Version: 10, Profile: fake.Profile, Generic: "{{.uuid}}"}
+	assert.Nil(t, store.ProfileVersionPut(v10))
+	assert.Nil(t, store.ProfileVersionPut(v2))
+	version, err := store.ProfileVersionGet(fake.Profile.Id, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, v2, version)
+	versions, err = store.ProfileVersionList(fake.Profile.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, []*storagepb.ProfileVersion{v2, v10}, versions)
+}
+
 // setup creates a temp fileStore directory to mirror a given fixedStore
 // for testing. Returns the directory tree root. The caller must remove the
 // temp directory when finished.
@@ -187,7 +447,8 @@ func setup(fixedStore *fake.FixedStore) (root string, err error) {
 	groupDir := filepath.Join(root, "groups")
 	ignitionDir := filepath.Join(root, "ignition")
 	cloudDir := filepath.Join(root, "cloud")
-	if err := mkdirs(profileDir, groupDir, ignitionDir, cloudDir); err != nil {
+	genericDir := filepath.Join(root, "generic")
+	if err := mkdirs(profileDir, groupDir, ignitionDir, cloudDir, genericDir); err != nil {
 		return root, err
 	}
 	// files
@@ -231,6 +492,13 @@ func setup(fixedStore *fake.FixedStore) (root string, err error) {
 			return root, err
 		}
 	}
+	for name, content := range fixedStore.GenericConfigs {
+		genericFile := filepath.Join(genericDir, name)
+		err = ioutil.WriteFile(genericFile, []byte(content), defaultFileMode)
+		if err != nil {
+			return root, err
+		}
+	}
 	return root, nil
 }
 
@@ -244,3 +512,54 @@ func mkdirs(names ...string) error {
 	}
 	return nil
 }
+
+func TestEvents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "matchbox")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store := NewFileStore(&Config{Root: dir})
+	events, err := store.EventList(time.Time{}, time.Time{})
+	assert.Nil(t, err)
+	assert.Empty(t, events)
+	for _, ts := range []string{"2026-10-13T22:04:05Z", "2026-10-12T23:59:59Z", "2026-10-14T00:00:00Z", "2026-10-13T08:00:00Z"} {
+		assert.Nil(t, store.EventPut(&storagepb.Event{Time: ts, Type: storagepb.EventBoot, MachineId: "a1b2c3d4"}))
+	}
+	times := func(events []*storagepb.Event) []string {
+		var ts []string
+		for _, event := range events {
+			ts = append(ts, event.Time)
+		}
+		return ts
+	}
+	at := func(value string) time.Time {
+		ts, _ := time.Parse(time.RFC3339, value)
+		return ts
+	}
+	// assert that:
+	// - Events are appended to the file of their day
+	// - Events are listed oldest first, within the time range
+	// - invalid lines are skipped
+	files, _ := filepath.Glob(filepath.Join(dir, "events", "*.jsonl"))
+	assert.Len(t, files, 3)
+	f, err := os.OpenFile(filepath.Join(dir, "events", "2026-10-13.jsonl"), os.O_WRONLY|os.O_APPEND, 0644)
+	assert.Nil(t, err)
+	f.WriteString(`{"time": "2026-10`)
+	f.Close()
+	events, err = store.EventList(time.Time{}, time.Time{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2026-10-12T23:59:59Z", "2026-10-13T08:00:00Z", "2026-10-13T22:04:05Z", "2026-10-14T00:00:00Z"}, times(events))
+	events, err = store.EventList(at("2026-10-13T08:00:00Z"), at("2026-10-14T00:00:00Z"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2026-10-13T08:00:00Z", "2026-10-13T22:04:05Z"}, times(events))
+	// - pruning deletes the files of earlier days and the earlier Events of
+	// the day of the time
+	assert.Nil(t, store.EventPrune(at("2026-10-13T12:00:00Z")))
+	events, err = store.EventList(time.Time{}, time.Time{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2026-10-13T22:04:05Z", "2026-10-14T00:00:00Z"}, times(events))
+	files, _ = filepath.Glob(filepath.Join(dir, "events", "*.jsonl"))
+	assert.Len(t, files, 2)
+	// - Events without a valid time are rejected
+	assert.Equal(t, storagepb.ErrInvalidEventTime, store.EventPut(&storagepb.Event{Type: storagepb.EventBoot}))
+}
@@ -55,6 +55,37 @@ func (d Dir) writeFile(path string, data []byte) error {
 	return ioutil.WriteFile(path, data, defaultFileMode)
 }
 
+// appendFile appends the data to the file at the given path, creating it if
+// needed, restricted to a specific directory tree.
+func (d Dir) appendFile(path string, data []byte) error {
+	path, err := d.sanitize(path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), defaultDirectoryMode); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, defaultFileMode)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// deleteFile removes the file at the given path, restricted to a specific
+// directory tree.
+func (d Dir) deleteFile(path string) error {
+	path, err := d.sanitize(path)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
 // Borrowed directly from net/http Dir.Open and FileServer.
 func (d Dir) sanitize(name string) (string, error) {
 	if filepath.Separator != '/' && strings.ContainsRune(name, filepath.Separator) ||
@@ -67,3 +98,13 @@ func (d Dir) sanitize(name string) (string, error) {
 	}
 	return filepath.Join(dir, filepath.FromSlash(path.Clean("/"+name))), nil
 }
+
+// stat returns the FileInfo of the file at the given path, restricted to a
+// specific directory tree.
+func (d Dir) stat(path string) (os.FileInfo, error) {
+	path, err := d.sanitize(path)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(path)
+}
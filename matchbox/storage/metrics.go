@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/coreos/matchbox/matchbox/metrics"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+var storeDuration = metrics.NewHistogramVec(
+	"matchbox_store_operation_duration_seconds",
+	"Store operation latency by operation and result (ok or error).",
+	nil, "operation", "result")
+
+// instrumentedStore wraps a Store to record operation latencies.
+type instrumentedStore struct {
+	store Store
+}
+
+// Instrument returns a Store which records the latency of each operation
+// on the given Store.
+func Instrument(store Store) Store {
+	return &instrumentedStore{store: store}
+}
+
+// Reload discards the resources cached by the Store, if it's a Reloader.
+func (s *instrumentedStore) Reload() {
+	if reloader, ok := s.store.(Reloader); ok {
+		reloader.Reload()
+	}
+}
+
+// observe records an operation which began at start.
+func observe(operation string, start time.Time, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	storeDuration.ObserveDuration(start, operation, result)
+}
+
+func (s *instrumentedStore) GroupPut(group *storagepb.Group) (err error) {
+	defer func(start time.Time) { observe("group_put", start, err) }(time.Now())
+	return s.store.GroupPut(group)
+}
+
+func (s *instrumentedStore) GroupGet(id string) (group *storagepb.Group, err error) {
+	defer func(start time.Time) { observe("group_get", start, err) }(time.Now())
+	return s.store.GroupGet(id)
+}
+
+func (s *instrumentedStore) GroupList() (groups []*storagepb.Group, err error) {
+	defer func(start time.Time) { observe("group_list", start, err) }(time.Now())
+	return s.store.GroupList()
+}
+
+func (s *instrumentedStore) GroupDelete(id string) (err error) {
+	defer func(start time.Time) { observe("group_delete", start, err) }(time.Now())
+	return s.store.GroupDelete(id)
+}
+
+func (s *instrumentedStore) ProfilePut(profile *storagepb.Profile) (err error) {
+	defer func(start time.Time) { observe("profile_put", start, err) }(time.Now())
+	return s.store.ProfilePut(profile)
+}
+
+func (s *instrumentedStore) ProfileGet(id string) (profile *storagepb.Profile, err error) {
+	defer func(start time.Time) { observe("profile_get", start, err) }(time.Now())
+	return s.store.ProfileGet(id)
+}
+
+func (s *instrumentedStore) ProfileList() (profiles []*storagepb.Profile, err error) {
+	defer func(start time.Time) { observe("profile_list", start, err) }(time.Now())
+	return s.store.ProfileList()
+}
+
+func (s *instrumentedStore) ProfileDelete(id string) (err error) {
+	defer func(start time.Time) { observe("profile_delete", start, err) }(time.Now())
+	return s.store.ProfileDelete(id)
+}
+
+func (s *instrumentedStore) ProfileVersionPut(version *storagepb.ProfileVersion) (err error) {
+	defer func(start time.Time) { observe("profile_version_put", start, err) }(time.Now())
+	return s.store.ProfileVersionPut(version)
+}
+
+func (s *instrumentedStore) ProfileVersionGet(id string, version int32) (snapshot *storagepb.ProfileVersion, err error) {
+	defer func(start time.Time) { observe("profile_version_get", start, err) }(time.Now())
+	return s.store.ProfileVersionGet(id, version)
+}
+
+func (s *instrumentedStore) ProfileVersionList(id string) (versions []*storagepb.ProfileVersion, err error) {
+	defer func(start time.Time) { observe("profile_version_list", start, err) }(time.Now())
+	return s.store.ProfileVersionList(id)
+}
+
+func (s *instrumentedStore) IgnitionPut(name string, config []byte) (err error) {
+	defer func(start time.Time) { observe("ignition_put", start, err) }(time.Now())
+	return s.store.IgnitionPut(name, config)
+}
+
+func (s *instrumentedStore) IgnitionGet(name string) (contents string, err error) {
+	defer func(start time.Time) { observe("ignition_get", start, err) }(time.Now())
+	return s.store.IgnitionGet(name)
+}
+
+func (s *instrumentedStore) IgnitionList() (names []string, err error) {
+	defer func(start time.Time) { observe("ignition_list", start, err) }(time.Now())
+	return s.store.IgnitionList()
+}
+
+func (s *instrumentedStore) IgnitionDelete(name string) (err error) {
+	defer func(start time.Time) { observe("ignition_delete", start, err) }(time.Now())
+	return s.store.IgnitionDelete(name)
+}
+
+func (s *instrumentedStore) CloudPut(name string, config []byte) (err error) {
+	defer func(start time.Time) { observe("cloud_put", start, err) }(time.Now())
+	return s.store.CloudPut(name, config)
+}
+
+func (s *instrumentedStore) CloudGet(name string) (contents string, err error) {
+	defer func(start time.Time) { observe("cloud_get", start, err) }(time.Now())
+	return s.store.CloudGet(name)
+}
+
+func (s *instrumentedStore) CloudList() (names []string, err error) {
+	defer func(start time.Time) { observe("cloud_list", start, err) }(time.Now())
+	return s.store.CloudList()
+}
+
+func (s *instrumentedStore) CloudDelete(name string) (err error) {
+	defer func(start time.Time) { observe("cloud_delete", start, err) }(time.Now())
+	return s.store.CloudDelete(name)
+}
+
+func (s *instrumentedStore) GenericPut(name string, config []byte) (err error) {
+	defer func(start time.Time) { observe("generic_put", start, err) }(time.Now())
+	return s.store.GenericPut(name, config)
+}
+
+func (s *instrumentedStore) GenericGet(name string) (contents string, err error) {
+	defer func(start time.Time) { observe("generic_get", start, err) }(time.Now())
+	return s.store.GenericGet(name)
+}
+
+func (s *instrumentedStore) GenericList() (names []string, err error) {
+	defer func(start time.Time) { observe("generic_list", start, err) }(time.Now())
+	return s.store.GenericList()
+}
+
+func (s *instrumentedStore) GenericDelete(name string) (err error) {
+	defer func(start time.Time) { observe("generic_delete", start, err) }(time.Now())
+	return s.store.GenericDelete(name)
+}
+
+func (s *instrumentedStore) MachinePut(machine *storagepb.Machine) (err error) {
+	defer func(start time.Time) { observe("machine_put", start, err) }(time.Now())
+	return s.store.MachinePut(machine)
+}
+
+func (s *instrumentedStore) MachineGet(id string) (machine *storagepb.Machine, err error) {
+	defer func(start time.Time) { observe("machine_get", start, err) }(time.Now())
+	return s.store.MachineGet(id)
+}
+
+func (s *instrumentedStore) MachineList() (machines []*storagepb.Machine, err error) {
+	defer func(start time.Time) { observe("machine_list", start, err) }(time.Now())
+	return s.store.MachineList()
+}
+
+func (s *instrumentedStore) MachineArchive(machine *storagepb.Machine) (err error) {
+	defer func(start time.Time) { observe("machine_archive", start, err) }(time.Now())
+	return s.store.MachineArchive(machine)
+}
+
+func (s *instrumentedStore) EventPut(event *storagepb.Event) (err error) {
+	defer func(start time.Time) { observe("event_put", start, err) }(time.Now())
+	return s.store.EventPut(event)
+}
+
+func (s *instrumentedStore) EventList(since, until time.Time) (events []*storagepb.Event, err error) {
+	defer func(start time.Time) { observe("event_list", start, err) }(time.Now())
+	return s.store.EventList(since, until)
+}
+
+func (s *instrumentedStore) EventPrune(before time.Time) (err error) {
+	defer func(start time.Time) { observe("event_prune", start, err) }(time.Now())
+	return s.store.EventPrune(before)
+}
@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestInstrument(t *testing.T) {
+	store := Instrument(fake.NewFixedStore())
+	okBefore := storeDuration.Count("group_put", "ok")
+	errBefore := storeDuration.Count("group_get", "error")
+	// assert that:
+	// - operations are passed to the wrapped store
+	// - latencies are recorded by operation and result
+	assert.Nil(t, store.GroupPut(fake.Group))
+	group, err := store.GroupGet(fake.Group.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, fake.Group, group)
+	_, err = store.GroupGet("missing")
+	assert.Error(t, err)
+	assert.Equal(t, okBefore+1, storeDuration.Count("group_put", "ok"))
+	assert.Equal(t, errBefore+1, storeDuration.Count("group_get", "error"))
+}
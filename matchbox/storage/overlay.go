@@ -0,0 +1,275 @@
+package storage
+
+import (
+	"os"
+	"sort"
+	"time"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// overlayStore layers Stores, so Groups, Profiles, and templates of upper
+// layers override those of lower layers with the same id or name. Writes,
+// deletes, Machines, Profile versions, and Events use the top layer only,
+// lower layers are read-only.
+type overlayStore struct {
+	// layers, lowest first
+	layers []Store
+	top    Store
+}
+
+// NewOverlayStore returns a Store which merges the given Stores in order, so
+// each overrides the ones before it, and writes to the last. A single Store
+// is returned as is.
+func NewOverlayStore(layers ...Store) Store {
+	if len(layers) == 1 {
+		return layers[0]
+	}
+	return &overlayStore{
+		layers: layers,
+		top:    layers[len(layers)-1],
+	}
+}
+
+// notFound returns true if the error is a Store's missing resource error.
+func notFound(err error) bool {
+	switch err {
+	case ErrGroupNotFound, ErrProfileNotFound, ErrMachineNotFound, ErrVersionNotFound:
+		return true
+	}
+	return os.IsNotExist(err)
+}
+
+// Reload discards the resources cached by each layer which is a Reloader.
+func (s *overlayStore) Reload() {
+	for _, layer := range s.layers {
+		if reloader, ok := layer.(Reloader); ok {
+			reloader.Reload()
+		}
+	}
+}
+
+// get returns the resource of the highest layer which has it.
+func (s *overlayStore) get(get func(Store) (interface{}, error)) (value interface{}, err error) {
+	for i := len(s.layers) - 1; i >= 0; i-- {
+		value, err = get(s.layers[i])
+		if !notFound(err) {
+			return value, err
+		}
+	}
+	return value, err
+}
+
+// names lists the names of each layer, without duplicates and sorted.
+// Layers may omit directories.
+func (s *overlayStore) names(list func(Store) ([]string, error)) ([]string, error) {
+	seen := make(map[string]bool)
+	names := []string{}
+	for _, layer := range s.layers {
+		layerNames, err := list(layer)
+		if notFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range layerNames {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *overlayStore) GroupPut(group *storagepb.Group) error {
+	return s.top.GroupPut(group)
+}
+
+func (s *overlayStore) GroupGet(id string) (*storagepb.Group, error) {
+	value, err := s.get(func(layer Store) (interface{}, error) {
+		return layer.GroupGet(id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*storagepb.Group), nil
+}
+
+func (s *overlayStore) GroupList() ([]*storagepb.Group, error) {
+	byID := make(map[string]*storagepb.Group)
+	for _, layer := range s.layers {
+		groups, err := layer.GroupList()
+		if notFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range groups {
+			byID[group.Id] = group
+		}
+	}
+	groups := make([]*storagepb.Group, 0, len(byID))
+	for _, group := range byID {
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Id < groups[j].Id
+	})
+	return groups, nil
+}
+
+func (s *overlayStore) GroupDelete(id string) error {
+	return s.top.GroupDelete(id)
+}
+
+func (s *overlayStore) ProfilePut(profile *storagepb.Profile) error {
+	return s.top.ProfilePut(profile)
+}
+
+func (s *overlayStore) ProfileGet(id string) (*storagepb.Profile, error) {
+	value, err := s.get(func(layer Store) (interface{}, error) {
+		return layer.ProfileGet(id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*storagepb.Profile), nil
+}
+
+func (s *overlayStore) ProfileList() ([]*storagepb.Profile, error) {
+	byID := make(map[string]*storagepb.Profile)
+	for _, layer := range s.layers {
+		profiles, err := layer.ProfileList()
+		if notFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, profile := range profiles {
+			byID[profile.Id] = profile
+		}
+	}
+	profiles := make([]*storagepb.Profile, 0, len(byID))
+	for _, profile := range byID {
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Id < profiles[j].Id
+	})
+	return profiles, nil
+}
+
+func (s *overlayStore) ProfileDelete(id string) error {
+	return s.top.ProfileDelete(id)
+}
+
+func (s *overlayStore) ProfileVersionPut(version *storagepb.ProfileVersion) error {
+	return s.top.ProfileVersionPut(version)
+}
+
+func (s *overlayStore) ProfileVersionGet(id string, version int32) (*storagepb.ProfileVersion, error) {
+	return s.top.ProfileVersionGet(id, version)
+}
+
+func (s *overlayStore) ProfileVersionList(id string) ([]*storagepb.ProfileVersion, error) {
+	return s.top.ProfileVersionList(id)
+}
+
+func (s *overlayStore) IgnitionPut(name string, config []byte) error {
+	return s.top.IgnitionPut(name, config)
+}
+
+func (s *overlayStore) IgnitionGet(name string) (string, error) {
+	value, err := s.get(func(layer Store) (interface{}, error) {
+		return layer.IgnitionGet(name)
+	})
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
+
+func (s *overlayStore) IgnitionList() ([]string, error) {
+	return s.names(Store.IgnitionList)
+}
+
+func (s *overlayStore) IgnitionDelete(name string) error {
+	return s.top.IgnitionDelete(name)
+}
+
+func (s *overlayStore) CloudPut(name string, config []byte) error {
+	return s.top.CloudPut(name, config)
+}
+
+func (s *overlayStore) CloudGet(name string) (string, error) {
+	value, err := s.get(func(layer Store) (interface{}, error) {
+		return layer.CloudGet(name)
+	})
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
+
+func (s *overlayStore) CloudList() ([]string, error) {
+	return s.names(Store.CloudList)
+}
+
+func (s *overlayStore) CloudDelete(name string) error {
+	return s.top.CloudDelete(name)
+}
+
+func (s *overlayStore) GenericPut(name string, config []byte) error {
+	return s.top.GenericPut(name, config)
+}
+
+func (s *overlayStore) GenericGet(name string) (string, error) {
+	value, err := s.get(func(layer Store) (interface{}, error) {
+		return layer.GenericGet(name)
+	})
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
+
+func (s *overlayStore) GenericList() ([]string, error) {
+	return s.names(Store.GenericList)
+}
+
+func (s *overlayStore) GenericDelete(name string) error {
+	return s.top.GenericDelete(name)
+}
+
+func (s *overlayStore) MachinePut(machine *storagepb.Machine) error {
+	return s.top.MachinePut(machine)
+}
+
+func (s *overlayStore) MachineGet(id string) (*storagepb.Machine, error) {
+	return s.top.MachineGet(id)
+}
+
+func (s *overlayStore) MachineList() ([]*storagepb.Machine, error) {
+	return s.top.MachineList()
+}
+
+func (s *overlayStore) MachineArchive(machine *storagepb.Machine) error {
+	return s.top.MachineArchive(machine)
+}
+
+func (s *overlayStore) EventPut(event *storagepb.Event) error {
+	return s.top.EventPut(event)
+}
+
+func (s *overlayStore) EventList(since, until time.Time) ([]*storagepb.Event, error) {
+	return s.top.EventList(since, until)
+}
+
+func (s *overlayStore) EventPrune(before time.Time) error {
+	return s.top.EventPrune(before)
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+func TestOverlayStore(t *testing.T) {
+	baseDir, err := setup(&fake.FixedStore{
+		Groups:          map[string]*storagepb.Group{fake.Group.Id: fake.Group},
+		Profiles:        map[string]*storagepb.Profile{fake.Profile.Id: fake.Profile},
+		IgnitionConfigs: map[string]string{"base.yaml": "base", "shared.yaml": "base"},
+	})
+	assert.Nil(t, err)
+	defer os.RemoveAll(baseDir)
+	// the site layer starts without any directories
+	siteDir, err := ioutil.TempDir("", "data")
+	assert.Nil(t, err)
+	defer os.RemoveAll(siteDir)
+	base := NewFileStore(&Config{Root: baseDir})
+	site := NewFileStore(&Config{Root: siteDir})
+	store := NewOverlayStore(base, site)
+
+	// assert that:
+	// - resources of lower layers are read, layers may omit directories
+	group, err := store.GroupGet(fake.Group.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, fake.Group, group)
+	groups, err := store.GroupList()
+	assert.Nil(t, err)
+	assert.Len(t, groups, 1)
+
+	// - writes go to the top layer and override lower layers
+	override := fake.Group.Copy()
+	override.Name = "site group"
+	other := fake.Group.Copy()
+	other.Id = "a-site-group"
+	assert.Nil(t, store.GroupPut(override))
+	assert.Nil(t, store.GroupPut(other))
+	assert.Nil(t, store.IgnitionPut("shared.yaml", []byte("site")))
+	_, err = os.Stat(filepath.Join(siteDir, "groups", fake.Group.Id+".json"))
+	assert.Nil(t, err)
+	group, err = store.GroupGet(fake.Group.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, "site group", group.Name)
+	group, err = base.GroupGet(fake.Group.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, fake.Group.Name, group.Name)
+	config, err := store.IgnitionGet("shared.yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, "site", config)
+
+	// - lists merge layers by id, sorted
+	groups, err = store.GroupList()
+	assert.Nil(t, err)
+	if assert.Len(t, groups, 2) {
+		assert.Equal(t, "a-site-group", groups[0].Id)
+		assert.Equal(t, "site group", groups[1].Name)
+	}
+	names, err := store.IgnitionList()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"base.yaml", "shared.yaml"}, names)
+	profiles, err := store.ProfileList()
+	assert.Nil(t, err)
+	assert.Equal(t, []*storagepb.Profile{fake.Profile}, profiles)
+
+	// - deleting an override reveals the lower layer's resource, which
+	// can't be deleted
+	assert.Nil(t, store.GroupDelete(fake.Group.Id))
+	group, err = store.GroupGet(fake.Group.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, fake.Group.Name, group.Name)
+	assert.Error(t, store.GroupDelete(fake.Group.Id))
+
+	// - missing resources are errors
+	_, err = store.ProfileGet("missing")
+	assert.Error(t, err)
+	_, err = store.GenericGet("missing")
+	assert.Error(t, err)
+
+	// - a single Store isn't wrapped
+	assert.Equal(t, base, NewOverlayStore(base))
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"errors"
+	"time"
 
 	"github.com/coreos/matchbox/matchbox/storage/storagepb"
 )
@@ -10,9 +11,11 @@ import (
 var (
 	ErrGroupNotFound   = errors.New("storage: No Group found")
 	ErrProfileNotFound = errors.New("storage: No Profile found")
+	ErrMachineNotFound = errors.New("storage: No Machine found")
+	ErrVersionNotFound = errors.New("storage: No Profile version found")
 )
 
-// A Store stores machine Groups, Profiles, and Configs.
+// A Store stores machine Groups, Profiles, Configs, and Machines.
 type Store interface {
 	// GroupPut creates or updates a Group.
 	GroupPut(group *storagepb.Group) error
@@ -20,6 +23,8 @@ type Store interface {
 	GroupGet(id string) (*storagepb.Group, error)
 	// GroupList lists all machine Groups.
 	GroupList() ([]*storagepb.Group, error)
+	// GroupDelete deletes a machine Group by id.
+	GroupDelete(id string) error
 
 	// ProfilePut creates or updates a Profile.
 	ProfilePut(profile *storagepb.Profile) error
@@ -27,15 +32,65 @@ type Store interface {
 	ProfileGet(id string) (*storagepb.Profile, error)
 	// ProfileList lists all profiles.
 	ProfileList() ([]*storagepb.Profile, error)
+	// ProfileDelete deletes a profile by id.
+	ProfileDelete(id string) error
+	// ProfileVersionPut writes a snapshot of a Profile.
+	ProfileVersionPut(version *storagepb.ProfileVersion) error
+	// ProfileVersionGet gets a snapshot of a Profile by id and version.
+	ProfileVersionGet(id string, version int32) (*storagepb.ProfileVersion, error)
+	// ProfileVersionList lists the snapshots of a Profile, oldest first.
+	ProfileVersionList(id string) ([]*storagepb.ProfileVersion, error)
 
 	// IgnitionPut creates or updates an Ignition template.
 	IgnitionPut(name string, config []byte) error
 	// IgnitionGet gets an Ignition template by name.
 	IgnitionGet(name string) (string, error)
+	// IgnitionList lists the names of all Ignition templates.
+	IgnitionList() ([]string, error)
+	// IgnitionDelete deletes an Ignition template by name.
+	IgnitionDelete(name string) error
 
+	// CloudPut creates or updates a Cloud-Config template.
+	CloudPut(name string, config []byte) error
 	// CloudGet gets a Cloud-Config template by name.
 	CloudGet(name string) (string, error)
+	// CloudList lists the names of all Cloud-Config templates.
+	CloudList() ([]string, error)
+	// CloudDelete deletes a Cloud-Config template by name.
+	CloudDelete(name string) error
 
+	// GenericPut creates or updates a generic template.
+	GenericPut(name string, config []byte) error
 	// GenericGet gets a generic template by name.
 	GenericGet(name string) (string, error)
+	// GenericList lists the names of all generic templates.
+	GenericList() ([]string, error)
+	// GenericDelete deletes a generic template by name.
+	GenericDelete(name string) error
+
+	// MachinePut creates or updates a Machine.
+	MachinePut(machine *storagepb.Machine) error
+	// MachineGet gets a Machine by id.
+	MachineGet(id string) (*storagepb.Machine, error)
+	// MachineList lists all Machines.
+	MachineList() ([]*storagepb.Machine, error)
+	// MachineArchive writes a Machine to the archive of retired Machines
+	// and removes it from the Machines.
+	MachineArchive(machine *storagepb.Machine) error
+
+	// EventPut appends an Event to the event history.
+	EventPut(event *storagepb.Event) error
+	// EventList lists the Events recorded at or after since and before
+	// until, oldest first. A zero time leaves that end of the range open.
+	EventList(since, until time.Time) ([]*storagepb.Event, error)
+	// EventPrune deletes the Events recorded before a time.
+	EventPrune(before time.Time) error
+}
+
+// A Reloader is a Store which caches resources and can discard them, so
+// that changes made outside of the Store (e.g. files edited directly) are
+// read again.
+type Reloader interface {
+	// Reload discards cached resources.
+	Reload()
 }
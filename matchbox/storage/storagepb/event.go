@@ -0,0 +1,63 @@
+package storagepb
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Event types
+const (
+	// EventBoot is a request for a network boot config (/ipxe or /grub).
+	EventBoot = "boot"
+	// EventConfig is a request for a provisioning config (/ignition,
+	// /cloud, /generic, or /metadata).
+	EventConfig = "config"
+	// EventProgress is a provisioning step reported to /v1/progress.
+	EventProgress = "progress"
+	// EventComplete is a completion reported to /v1/complete.
+	EventComplete = "complete"
+)
+
+// event errors
+var (
+	ErrInvalidEventTime = errors.New("Event time must be an RFC 3339 time")
+	ErrInvalidEventType = errors.New("Event type must be boot, config, progress, or complete")
+)
+
+// eventTypes are the valid Event types.
+var eventTypes = map[string]bool{
+	EventBoot:     true,
+	EventConfig:   true,
+	EventProgress: true,
+	EventComplete: true,
+}
+
+// ParseEvent parses bytes into an Event.
+func ParseEvent(data []byte) (*Event, error) {
+	event := new(Event)
+	err := json.Unmarshal(data, event)
+	return event, err
+}
+
+// AssertValid validates an Event. Returns nil if there are no validation
+// errors.
+func (e *Event) AssertValid() error {
+	if _, err := e.ParseTime(); err != nil {
+		return ErrInvalidEventTime
+	}
+	if !IsEventType(e.Type) {
+		return ErrInvalidEventType
+	}
+	return nil
+}
+
+// ParseTime returns the time of the Event.
+func (e *Event) ParseTime() (time.Time, error) {
+	return time.Parse(time.RFC3339, e.Time)
+}
+
+// IsEventType returns true if the type is a valid Event type.
+func IsEventType(eventType string) bool {
+	return eventTypes[eventType]
+}
@@ -0,0 +1,40 @@
+package storagepb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testEvent = &Event{
+	Time:      "2026-10-13T22:04:05Z",
+	Type:      EventBoot,
+	MachineId: "a1b2c3d4",
+	Endpoint:  "/ipxe",
+	Status:    200,
+	Group:     "node1",
+	Profile:   "worker",
+}
+
+func TestEventParse(t *testing.T) {
+	event, err := ParseEvent([]byte(`{"time": "2026-10-13T22:04:05Z", "type": "boot", "machine_id": "a1b2c3d4", "endpoint": "/ipxe", "status": 200, "group": "node1", "profile": "worker"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, testEvent, event)
+}
+
+func TestEventValidate(t *testing.T) {
+	cases := []struct {
+		event *Event
+		err   error
+	}{
+		{testEvent, nil},
+		{&Event{Time: "2026-10-13T22:04:05Z", Type: EventComplete}, nil},
+		{&Event{Type: EventBoot}, ErrInvalidEventTime},
+		{&Event{Time: "yesterday", Type: EventBoot}, ErrInvalidEventTime},
+		{&Event{Time: "2026-10-13T22:04:05Z"}, ErrInvalidEventType},
+		{&Event{Time: "2026-10-13T22:04:05Z", Type: "reboot"}, ErrInvalidEventType},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.err, c.event.AssertValid())
+	}
+}
@@ -3,20 +3,39 @@ package storagepb
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net"
 	"sort"
 	"strings"
+	"time"
 )
 
 var (
-	ErrProfileRequired = errors.New("Group requires a Profile")
+	ErrProfileRequired           = errors.New("Group requires a Profile")
+	ErrInvalidSelectorMAC        = errors.New("Group mac selector must be a MAC address")
+	ErrMetadataSourceURLRequired = errors.New("MetadataSource requires a URL")
+	ErrInvalidMetadataSourceURL  = errors.New("MetadataSource URL must be http, https, consul, consul+https, or dns")
+	ErrInvalidProfileVersion     = errors.New("Group profile version must not be negative")
+	ErrRolloutProfileRequired    = errors.New("Rollout requires a Profile")
+	ErrInvalidRolloutStart       = errors.New("Rollout start must be an RFC 3339 time")
+	ErrInvalidRolloutBatchSize   = errors.New("Rollout batch size must not be negative")
+	ErrInvalidRolloutInterval    = errors.New("Rollout with a batch size requires a positive interval")
 )
 
-// ParseGroup parses bytes into a Group.
+// metadata source URL schemes
+var metadataSourceSchemes = map[string]bool{
+	"http":         true,
+	"https":        true,
+	"consul":       true,
+	"consul+https": true,
+	"dns":          true,
+}
+
+// ParseGroup parses bytes into a Group. Unknown fields, values of the wrong
+// type, and malformed MAC selectors are ParseErrors.
 func ParseGroup(data []byte) (*Group, error) {
 	richGroup := new(RichGroup)
-	err := json.Unmarshal(data, richGroup)
-	if err != nil {
+	if err := decodeStrict(data, richGroup); err != nil {
 		return nil, err
 	}
 	group, err := richGroup.ToGroup()
@@ -24,9 +43,10 @@ func ParseGroup(data []byte) (*Group, error) {
 		return nil, err
 	}
 	if err := group.Normalize(); err != nil {
-		return nil, err
+		line, column := Position(data, keyOffset(data, `"mac"`))
+		return nil, &ParseError{Line: line, Column: column, Err: fmt.Errorf("selector mac: %v", err), key: "mac"}
 	}
-	return group, err
+	return group, nil
 }
 
 func (g *Group) Copy() *Group {
@@ -35,25 +55,89 @@ func (g *Group) Copy() *Group {
 		selectors[k] = v
 	}
 	return &Group{
-		Id:       g.Id,
-		Name:     g.Name,
-		Profile:  g.Profile,
-		Selector: selectors,
-		Metadata: g.Metadata,
+		Id:              g.Id,
+		Name:            g.Name,
+		Profile:         g.Profile,
+		Selector:        selectors,
+		Metadata:        g.Metadata,
+		MetadataSources: copyMetadataSources(g.MetadataSources),
+		Rollout:         copyRollout(g.Rollout),
+		ProfileVersion:  g.ProfileVersion,
+	}
+}
+
+// copyRollout returns a copy of a Rollout.
+func copyRollout(rollout *Rollout) *Rollout {
+	if rollout == nil {
+		return nil
+	}
+	return &Rollout{
+		Profile:   rollout.Profile,
+		Start:     rollout.Start,
+		BatchSize: rollout.BatchSize,
+		Interval:  rollout.Interval,
+	}
+}
+
+// copyMetadataSources returns a deep copy of metadata sources.
+func copyMetadataSources(sources []*MetadataSource) []*MetadataSource {
+	if sources == nil {
+		return nil
+	}
+	clone := make([]*MetadataSource, len(sources))
+	for i, source := range sources {
+		clone[i] = &MetadataSource{
+			Url: source.Url,
+			Key: source.Key,
+		}
 	}
+	return clone
 }
 
 // Matches returns true if the given labels satisfy all the selector
 // requirements, false otherwise.
 func (g *Group) Matches(labels map[string]string) bool {
 	for key, val := range g.Selector {
-		if labels == nil || labels[key] != val {
+		if labels == nil || !selectorMatches(val, labels[key]) {
 			return false
 		}
 	}
 	return true
 }
 
+// IsAddressSelector returns true if a selector value is an IP address or a
+// CIDR, which match labels by address rather than by exact value.
+func IsAddressSelector(value string) bool {
+	if strings.Contains(value, "/") {
+		_, _, err := net.ParseCIDR(value)
+		return err == nil
+	}
+	return net.ParseIP(value) != nil
+}
+
+// selectorMatches returns true if a label value satisfies a selector value.
+// CIDR selectors (e.g. "2001:db8::/32") match IP address labels within the
+// network and IP address selectors match the same address however it's
+// written (e.g. "2001:DB8:0::1" and "2001:db8::1").
+func selectorMatches(selector, label string) bool {
+	if selector == label {
+		return true
+	}
+	if label == "" {
+		return false
+	}
+	if strings.Contains(selector, "/") {
+		_, network, err := net.ParseCIDR(selector)
+		if err != nil {
+			return false
+		}
+		ip := net.ParseIP(label)
+		return ip != nil && network.Contains(ip)
+	}
+	ip := net.ParseIP(selector)
+	return ip != nil && ip.Equal(net.ParseIP(label))
+}
+
 // Normalize normalizes Group selectors according to reserved selector rules
 // which require "mac" addresses to be valid, normalized MAC addresses.
 func (g *Group) Normalize() error {
@@ -80,9 +164,80 @@ func (g *Group) AssertValid() error {
 	if g.Profile == "" {
 		return ErrProfileRequired
 	}
+	if g.ProfileVersion < 0 {
+		return ErrInvalidProfileVersion
+	}
+	for key, value := range g.Selector {
+		if strings.ToLower(key) != "mac" {
+			continue
+		}
+		if _, err := net.ParseMAC(value); err != nil {
+			return ErrInvalidSelectorMAC
+		}
+	}
+	for _, source := range g.MetadataSources {
+		if err := source.AssertValid(); err != nil {
+			return err
+		}
+	}
+	if g.Rollout != nil {
+		return g.Rollout.AssertValid()
+	}
 	return nil
 }
 
+// AssertValid validates a MetadataSource. Returns nil if there are no
+// validation errors.
+func (m *MetadataSource) AssertValid() error {
+	if m.Url == "" {
+		return ErrMetadataSourceURLRequired
+	}
+	i := strings.Index(m.Url, ":")
+	if i < 0 || !metadataSourceSchemes[m.Url[:i]] {
+		return ErrInvalidMetadataSourceURL
+	}
+	return nil
+}
+
+// AssertValid validates a Rollout. Returns nil if there are no validation
+// errors.
+func (r *Rollout) AssertValid() error {
+	if r.Profile == "" {
+		return ErrRolloutProfileRequired
+	}
+	if _, err := time.Parse(time.RFC3339, r.Start); err != nil {
+		return ErrInvalidRolloutStart
+	}
+	if r.BatchSize < 0 {
+		return ErrInvalidRolloutBatchSize
+	}
+	if r.BatchSize > 0 {
+		if interval, err := time.ParseDuration(r.Interval); err != nil || interval <= 0 {
+			return ErrInvalidRolloutInterval
+		}
+	}
+	return nil
+}
+
+// Admitted returns the number of machines a valid Rollout admits at the
+// given time: 0 before it starts, its batch size for each wave activated
+// since, or -1 if every machine is admitted.
+func (r *Rollout) Admitted(now time.Time) int {
+	start, err := time.Parse(time.RFC3339, r.Start)
+	if err != nil || now.Before(start) {
+		return 0
+	}
+	if r.BatchSize <= 0 {
+		return -1
+	}
+	interval, err := time.ParseDuration(r.Interval)
+	if err != nil || interval <= 0 {
+		return 0
+	}
+	waves := int(now.Sub(start)/interval) + 1
+	return waves * int(r.BatchSize)
+}
+
 // selectorString returns Group selectors as a string of sorted key value
 // pairs for comparisons.
 func (g *Group) selectorString() string {
@@ -106,11 +261,14 @@ func (g *Group) ToRichGroup() (*RichGroup, error) {
 		}
 	}
 	return &RichGroup{
-		Id:       g.Id,
-		Name:     g.Name,
-		Profile:  g.Profile,
-		Selector: g.Selector,
-		Metadata: metadata,
+		Id:              g.Id,
+		Name:            g.Name,
+		Profile:         g.Profile,
+		Selector:        g.Selector,
+		Metadata:        metadata,
+		MetadataSources: g.MetadataSources,
+		Rollout:         g.Rollout,
+		ProfileVersion:  g.ProfileVersion,
 	}, nil
 }
 
@@ -147,6 +305,12 @@ type RichGroup struct {
 	Selector map[string]string `json:"selector,omitempty"`
 	// Metadata
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	// External metadata sources
+	MetadataSources []*MetadataSource `json:"metadata_sources,omitempty"`
+	// Staged Profile rollout
+	Rollout *Rollout `json:"rollout,omitempty"`
+	// Profile version, the latest if 0
+	ProfileVersion int32 `json:"profile_version,omitempty"`
 }
 
 // ToGroup converts a user provided RichGroup into a Group which can be
@@ -161,10 +325,13 @@ func (rg *RichGroup) ToGroup() (*Group, error) {
 		}
 	}
 	return &Group{
-		Id:       rg.Id,
-		Name:     rg.Name,
-		Profile:  rg.Profile,
-		Selector: rg.Selector,
-		Metadata: metadata,
+		Id:              rg.Id,
+		Name:            rg.Name,
+		Profile:         rg.Profile,
+		Selector:        rg.Selector,
+		Metadata:        metadata,
+		MetadataSources: rg.MetadataSources,
+		Rollout:         rg.Rollout,
+		ProfileVersion:  rg.ProfileVersion,
 	}, nil
 }
@@ -4,6 +4,7 @@ import (
 	"net"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -32,6 +33,7 @@ func TestGroupParse(t *testing.T) {
 		group *Group
 	}{
 		{`{"id":"node1","name":"test group","profile":"g1h2i3j4","selector":{"uuid":"a1b2c3d4","mac":"52:da:00:89:d8:10"},"metadata":{"some-key":"some-val"}}`, testGroup},
+		{`{"id":"node1","profile":"worker","metadata_sources":[{"url":"https://cmdb.example.com/nodes/{{.uuid}}","key":"cmdb"}]}`, &Group{Id: "node1", Profile: "worker", MetadataSources: []*MetadataSource{{Url: "https://cmdb.example.com/nodes/{{.uuid}}", Key: "cmdb"}}}},
 	}
 	for _, c := range cases {
 		group, _ := ParseGroup([]byte(c.json))
@@ -56,6 +58,17 @@ func TestGroupCopy(t *testing.T) {
 	assert.NotEqual(t, testGroup.Selector, copy.Selector)
 }
 
+func TestGroupCopy_MetadataSources(t *testing.T) {
+	group := &Group{Id: "node1", MetadataSources: []*MetadataSource{{Url: "dns:_matchbox.example.com"}}}
+	copy := group.Copy()
+	// assert that:
+	// - metadata sources are copied
+	// - mutation of the copy does not affect the original
+	assert.Equal(t, group.MetadataSources, copy.MetadataSources)
+	copy.MetadataSources[0].Key = "dns"
+	assert.Equal(t, "", group.MetadataSources[0].Key)
+}
+
 func TestGroupMatches(t *testing.T) {
 	cases := []struct {
 		labels    map[string]string
@@ -66,16 +79,38 @@ func TestGroupMatches(t *testing.T) {
 		{map[string]string{"a": "b"}, map[string]string{"a": "c"}, false},
 		{map[string]string{"uuid": "a", "mac": "b"}, map[string]string{"uuid": "a"}, true},
 		{map[string]string{"uuid": "a"}, map[string]string{"uuid": "a", "mac": "b"}, false},
+		{map[string]string{"source_ip": "10.1.2.3"}, map[string]string{"source_ip": "10.0.0.0/8"}, true},
+		{map[string]string{"source_ip": "192.168.1.3"}, map[string]string{"source_ip": "10.0.0.0/8"}, false},
+		{map[string]string{"source_ip": "2001:db8::5"}, map[string]string{"source_ip": "2001:db8::/32"}, true},
+		{map[string]string{"source_ip": "2001:db9::5"}, map[string]string{"source_ip": "2001:db8::/32"}, false},
+		{map[string]string{"source_ip": "10.1.2.3"}, map[string]string{"source_ip": "2001:db8::/32"}, false},
+		{map[string]string{"ip": "2001:DB8:0::1"}, map[string]string{"ip": "2001:db8::1"}, true},
+		{map[string]string{"ip": "not-an-ip"}, map[string]string{"ip": "10.0.0.0/8"}, false},
+		{map[string]string{}, map[string]string{"ip": "10.0.0.0/8"}, false},
+		{map[string]string{"os": "b"}, map[string]string{"os": "a/b"}, false},
 	}
 	// assert that:
 	// - Group selectors must be satisfied for a match
 	// - labels may provide additional key/value pairs
+	// - CIDR selectors match IPv4 and IPv6 address labels in the network
+	// - IP address selectors match the same address however it's written
 	for _, c := range cases {
 		group := &Group{Selector: c.selectors}
 		assert.Equal(t, c.expected, group.Matches(c.labels))
 	}
 }
 
+func TestIsAddressSelector(t *testing.T) {
+	// assert that:
+	// - IP addresses and CIDRs are address selectors, other values aren't
+	for _, value := range []string{"10.1.2.3", "10.0.0.0/8", "2001:db8::1", "2001:db8::/32"} {
+		assert.True(t, IsAddressSelector(value), value)
+	}
+	for _, value := range []string{"", "metal", "52:da:00:89:d8:10", "a/b", "10.0.0.0/33"} {
+		assert.False(t, IsAddressSelector(value), value)
+	}
+}
+
 func TestNormalize(t *testing.T) {
 	expectedInvalidMAC := &net.AddrError{Err: "invalid MAC address", Addr: "not-a-mac"}
 	cases := []struct {
@@ -112,6 +147,19 @@ func TestGroupValidate(t *testing.T) {
 		{testGroupWithoutProfile, false},
 		{&Group{Id: "node1"}, false},
 		{&Group{}, false},
+		{&Group{Id: "node1", Profile: "worker", MetadataSources: []*MetadataSource{{Url: "consul://127.0.0.1:8500/nodes/{{.uuid}}"}, {Url: "dns:_matchbox.example.com", Key: "dns"}}}, true},
+		{&Group{Id: "node1", Profile: "worker", MetadataSources: []*MetadataSource{{Key: "etcd"}}}, false},
+		{&Group{Id: "node1", Profile: "worker", MetadataSources: []*MetadataSource{{Url: "file:///etc/passwd"}}}, false},
+		{&Group{Id: "node1", Profile: "worker", Rollout: &Rollout{Profile: "worker-next", Start: "2026-10-15T22:00:00Z"}}, true},
+		{&Group{Id: "node1", Profile: "worker", Rollout: &Rollout{Profile: "worker-next", Start: "2026-10-15T22:00:00Z", BatchSize: 10, Interval: "30m"}}, true},
+		{&Group{Id: "node1", Profile: "worker", Rollout: &Rollout{Start: "2026-10-15T22:00:00Z"}}, false},
+		{&Group{Id: "node1", Profile: "worker", ProfileVersion: 3}, true},
+		{&Group{Id: "node1", Profile: "worker", ProfileVersion: -1}, false},
+		{&Group{Id: "node1", Profile: "worker", Selector: map[string]string{"mac": "52:54:00:a1:9c:ae"}}, true},
+		{&Group{Id: "node1", Profile: "worker", Selector: map[string]string{"MAC": "52:54:00:a1:9c"}}, false},
+		{&Group{Id: "node1", Profile: "worker", Rollout: &Rollout{Profile: "worker-next", Start: "tonight"}}, false},
+		{&Group{Id: "node1", Profile: "worker", Rollout: &Rollout{Profile: "worker-next", Start: "2026-10-15T22:00:00Z", BatchSize: -1}}, false},
+		{&Group{Id: "node1", Profile: "worker", Rollout: &Rollout{Profile: "worker-next", Start: "2026-10-15T22:00:00Z", BatchSize: 10}}, false},
 	}
 	for _, c := range cases {
 		valid := c.group.AssertValid() == nil
@@ -119,6 +167,21 @@ func TestGroupValidate(t *testing.T) {
 	}
 }
 
+func TestRolloutAdmitted(t *testing.T) {
+	start := time.Date(2026, 10, 15, 22, 0, 0, 0, time.UTC)
+	rollout := &Rollout{Profile: "worker-next", Start: start.Format(time.RFC3339), BatchSize: 10, Interval: "30m"}
+	// assert that:
+	// - no machines are admitted before the start
+	// - a batch of machines is admitted each interval from the start
+	// - all machines are admitted without a batch size
+	assert.Equal(t, 0, rollout.Admitted(start.Add(-time.Second)))
+	assert.Equal(t, 10, rollout.Admitted(start))
+	assert.Equal(t, 10, rollout.Admitted(start.Add(29*time.Minute)))
+	assert.Equal(t, 30, rollout.Admitted(start.Add(time.Hour)))
+	rollout.BatchSize = 0
+	assert.Equal(t, -1, rollout.Admitted(start))
+}
+
 func TestSelectorString(t *testing.T) {
 	group := Group{
 		Selector: map[string]string{
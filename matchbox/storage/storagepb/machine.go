@@ -0,0 +1,157 @@
+package storagepb
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Machine provisioning states
+const (
+	// MachineBooted is set when a machine first network boots.
+	MachineBooted = "booted"
+	// MachineProvisioned is set when a machine reports installation is done.
+	MachineProvisioned = "provisioned"
+	// MachineReinstall is set when a machine is marked to be reinstalled.
+	MachineReinstall = "reinstall"
+	// MachineInstalled is set when a machine first fetches its Ignition
+	// config, if machines boot from local disk once they fetch it.
+	MachineInstalled = "installed"
+	// MachineDecommission is set when a machine is marked to be
+	// decommissioned.
+	MachineDecommission = "decommission"
+	// MachineDecommissioned is set when a decommissioned machine reports
+	// completion, before it is archived.
+	MachineDecommissioned = "decommissioned"
+	// MachinePending is set when a machine network boots without matching
+	// a Group and is served the holding Profile until it is adopted.
+	MachinePending = "pending"
+)
+
+// Provisioning step statuses
+const (
+	StepStarted   = "started"
+	StepSucceeded = "succeeded"
+	StepFailed    = "failed"
+)
+
+// MaxProgressSteps is the maximum number of provisioning steps recorded per
+// Machine. The earliest steps are dropped once it is reached.
+const MaxProgressSteps = 64
+
+// step errors
+var (
+	ErrStepNameRequired  = errors.New("Step name is required")
+	ErrInvalidStepStatus = errors.New("Step status must be started, succeeded, or failed")
+)
+
+// ParseMachine parses bytes into a Machine.
+func ParseMachine(data []byte) (*Machine, error) {
+	machine := new(Machine)
+	err := json.Unmarshal(data, machine)
+	return machine, err
+}
+
+// AssertValid validates a Machine. Returns nil if there are no validation
+// errors.
+func (m *Machine) AssertValid() error {
+	// Id is required
+	if m.Id == "" {
+		return ErrIdRequired
+	}
+	return nil
+}
+
+// AssertValid validates a MachineStep. Returns nil if there are no
+// validation errors.
+func (s *MachineStep) AssertValid() error {
+	if s.Name == "" {
+		return ErrStepNameRequired
+	}
+	switch s.Status {
+	case StepStarted, StepSucceeded, StepFailed:
+		return nil
+	}
+	return ErrInvalidStepStatus
+}
+
+// SetProgress records a provisioning step, replacing the status of a step
+// with the same name or appending a new step.
+func (m *Machine) SetProgress(step *MachineStep) {
+	for i, existing := range m.Progress {
+		if existing.Name == step.Name {
+			m.Progress[i] = step
+			return
+		}
+	}
+	m.Progress = append(m.Progress, step)
+	if len(m.Progress) > MaxProgressSteps {
+		m.Progress = m.Progress[len(m.Progress)-MaxProgressSteps:]
+	}
+}
+
+// SetFacts sets the values of facts on the Machine's facts and returns true
+// if any value changed.
+func (m *Machine) SetFacts(facts map[string]string) bool {
+	changed := false
+	for key, value := range facts {
+		if m.Facts[key] == value {
+			continue
+		}
+		if m.Facts == nil {
+			m.Facts = make(map[string]string)
+		}
+		m.Facts[key] = value
+		changed = true
+	}
+	return changed
+}
+
+// AddBoot records a config request of the machine, keeping only the most
+// recent max boots.
+func (m *Machine) AddBoot(boot *MachineBoot, max int) {
+	m.Boots = append(m.Boots, boot)
+	if len(m.Boots) > max {
+		m.Boots = m.Boots[len(m.Boots)-max:]
+	}
+}
+
+// Copy returns a copy of the Machine.
+func (m *Machine) Copy() *Machine {
+	labels := make(map[string]string)
+	for k, v := range m.Labels {
+		labels[k] = v
+	}
+	var facts map[string]string
+	if m.Facts != nil {
+		facts = make(map[string]string)
+		for k, v := range m.Facts {
+			facts[k] = v
+		}
+	}
+	var progress []*MachineStep
+	for _, step := range m.Progress {
+		clone := *step
+		progress = append(progress, &clone)
+	}
+	var boots []*MachineBoot
+	for _, boot := range m.Boots {
+		clone := *boot
+		boots = append(boots, &clone)
+	}
+	return &Machine{
+		Id:           m.Id,
+		Labels:       labels,
+		Facts:        facts,
+		State:        m.State,
+		Completed:    m.Completed,
+		Payload:      m.Payload,
+		Group:        m.Group,
+		Bmc:          m.Bmc,
+		Owner:        m.Owner,
+		Wipe:         m.Wipe,
+		Decommission: m.Decommission,
+		Progress:     progress,
+		Attempts:     m.Attempts,
+		Boots:        boots,
+	}
+}
@@ -0,0 +1,115 @@
+package storagepb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testMachine = &Machine{
+	Id:     "a1b2c3d4",
+	Labels: map[string]string{"uuid": "a1b2c3d4", "mac": "52:54:00:a1:9c:ae"},
+	State:  MachineProvisioned,
+	Group:  "node1",
+}
+
+func TestMachineParse(t *testing.T) {
+	machine, err := ParseMachine([]byte(`{"id": "a1b2c3d4", "labels": {"uuid": "a1b2c3d4", "mac": "52:54:00:a1:9c:ae"}, "state": "provisioned", "group": "node1"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, testMachine, machine)
+}
+
+func TestMachineValidate(t *testing.T) {
+	assert.Nil(t, testMachine.AssertValid())
+	assert.Equal(t, ErrIdRequired, (&Machine{}).AssertValid())
+}
+
+func TestMachineSetFacts(t *testing.T) {
+	machine := &Machine{Id: "a1b2c3d4"}
+	// assert that:
+	// - facts are set, keeping other facts
+	// - setting facts reports whether any value changed
+	assert.True(t, machine.SetFacts(map[string]string{"ip": "10.0.0.2", "hostname": "node1"}))
+	assert.True(t, machine.SetFacts(map[string]string{"ip": "10.0.0.3"}))
+	assert.False(t, machine.SetFacts(map[string]string{"ip": "10.0.0.3"}))
+	assert.Equal(t, map[string]string{"ip": "10.0.0.3", "hostname": "node1"}, machine.Facts)
+}
+
+func TestMachineCopy(t *testing.T) {
+	clone := testMachine.Copy()
+	assert.Equal(t, testMachine, clone)
+	// assert that:
+	// - labels are deep copied
+	clone.Labels["uuid"] = "other"
+	assert.Equal(t, "a1b2c3d4", testMachine.Labels["uuid"])
+
+	// - facts are deep copied
+	machine := &Machine{Id: "a1b2c3d4", Labels: map[string]string{}, Facts: map[string]string{"ip": "10.0.0.20"}}
+	clone = machine.Copy()
+	assert.Equal(t, machine, clone)
+	clone.Facts["ip"] = "10.0.0.21"
+	assert.Equal(t, "10.0.0.20", machine.Facts["ip"])
+
+	// - progress steps are deep copied
+	machine = &Machine{Id: "a1b2c3d4", Labels: map[string]string{}, Progress: []*MachineStep{{Name: "install", Status: StepStarted}}}
+	clone = machine.Copy()
+	assert.Equal(t, machine, clone)
+	clone.Progress[0].Status = StepFailed
+	assert.Equal(t, StepStarted, machine.Progress[0].Status)
+
+	// - boots are deep copied
+	machine = &Machine{Id: "a1b2c3d4", Labels: map[string]string{}, Boots: []*MachineBoot{{Endpoint: "/ipxe", Status: 200}}}
+	clone = machine.Copy()
+	assert.Equal(t, machine, clone)
+	clone.Boots[0].Status = 404
+	assert.Equal(t, int32(200), machine.Boots[0].Status)
+}
+
+func TestMachineStepValidate(t *testing.T) {
+	cases := []struct {
+		step *MachineStep
+		err  error
+	}{
+		{&MachineStep{Name: "install", Status: StepStarted}, nil},
+		{&MachineStep{Name: "install", Status: StepSucceeded, Message: "done"}, nil},
+		{&MachineStep{Status: StepFailed}, ErrStepNameRequired},
+		{&MachineStep{Name: "install"}, ErrInvalidStepStatus},
+		{&MachineStep{Name: "install", Status: "done"}, ErrInvalidStepStatus},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.err, c.step.AssertValid())
+	}
+}
+
+func TestMachineSetProgress(t *testing.T) {
+	machine := &Machine{Id: "a1b2c3d4"}
+	machine.SetProgress(&MachineStep{Name: "partition", Status: StepStarted})
+	machine.SetProgress(&MachineStep{Name: "install", Status: StepStarted})
+	machine.SetProgress(&MachineStep{Name: "partition", Status: StepSucceeded})
+	// assert that:
+	// - steps keep the order they started in
+	// - a step's status is replaced
+	assert.Equal(t, []*MachineStep{
+		{Name: "partition", Status: StepSucceeded},
+		{Name: "install", Status: StepStarted},
+	}, machine.Progress)
+
+	// - the earliest steps are dropped beyond MaxProgressSteps
+	for i := 0; i < MaxProgressSteps; i++ {
+		machine.SetProgress(&MachineStep{Name: fmt.Sprintf("step-%d", i), Status: StepStarted})
+	}
+	assert.Len(t, machine.Progress, MaxProgressSteps)
+	assert.Equal(t, "step-0", machine.Progress[0].Name)
+}
+
+func TestMachineAddBoot(t *testing.T) {
+	machine := &Machine{Id: "a1b2c3d4"}
+	for _, endpoint := range []string{"/ipxe", "/ignition", "/ipxe"} {
+		machine.AddBoot(&MachineBoot{Endpoint: endpoint}, 2)
+	}
+	// assert that:
+	// - boots are kept oldest first
+	// - the earliest boots are dropped beyond the max
+	assert.Equal(t, []*MachineBoot{{Endpoint: "/ignition"}, {Endpoint: "/ipxe"}}, machine.Boots)
+}
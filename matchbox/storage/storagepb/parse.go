@@ -0,0 +1,120 @@
+package storagepb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A ParseError is an error decoding the JSON of a Group or Profile, such as
+// a syntax error, an unknown field, or a value of the wrong type, at a
+// 1-based line and column if known. Errors parsing files are formatted as
+// "path:line:column: message", like manifest problems.
+type ParseError struct {
+	Path   string
+	Line   int
+	Column int
+	Err    error
+	// object key of the problem, to position errors in YAML
+	key string
+}
+
+func (e *ParseError) Error() string {
+	if e.Path == "" {
+		switch {
+		case e.Line == 0:
+			return e.Err.Error()
+		case e.Column == 0:
+			return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+		}
+		return fmt.Sprintf("line %d, column %d: %v", e.Line, e.Column, e.Err)
+	}
+	pos := e.Path
+	if e.Line > 0 {
+		pos += ":" + strconv.Itoa(e.Line)
+		if e.Column > 0 {
+			pos += ":" + strconv.Itoa(e.Column)
+		}
+	}
+	return fmt.Sprintf("%s: %v", pos, e.Err)
+}
+
+// FileError returns an error parsing the file at path. ParseErrors are given
+// the path, other errors are prefixed with it.
+func FileError(path string, err error) error {
+	if perr, ok := err.(*ParseError); ok {
+		return &ParseError{Path: path, Line: perr.Line, Column: perr.Column, Err: perr.Err}
+	}
+	return fmt.Errorf("%s: %v", path, err)
+}
+
+// errTrailingData is returned for data after the decoded JSON value.
+var errTrailingData = errors.New("invalid data after the JSON object")
+
+// unknownFieldPrefix prefixes encoding/json unknown field errors.
+const unknownFieldPrefix = "json: unknown field "
+
+// decodeStrict decodes JSON into v, rejecting unknown fields and trailing
+// data, and returns a ParseError with the position of the problem.
+func decodeStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err == nil {
+		offset := dec.InputOffset()
+		if len(bytes.TrimSpace(data[offset:])) == 0 {
+			return nil
+		}
+		offset += int64(len(data[offset:]) - len(bytes.TrimLeft(data[offset:], " \t\r\n")))
+		line, column := Position(data, offset)
+		return &ParseError{Line: line, Column: column, Err: errTrailingData}
+	}
+	var (
+		offset int64
+		key    string
+	)
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		offset = e.Offset
+	case *json.UnmarshalTypeError:
+		offset = e.Offset
+		key = e.Field[strings.LastIndex(e.Field, ".")+1:]
+	default:
+		if strings.HasPrefix(err.Error(), unknownFieldPrefix) {
+			quoted := strings.TrimPrefix(err.Error(), unknownFieldPrefix)
+			offset = keyOffset(data, quoted)
+			key, _ = strconv.Unquote(quoted)
+		}
+	}
+	line, column := Position(data, offset)
+	return &ParseError{Line: line, Column: column, Err: err, key: key}
+}
+
+// keyOffset returns the offset of the first object key with the given
+// quoted name in data, or 0 if it isn't found.
+func keyOffset(data []byte, quoted string) int64 {
+	re, err := regexp.Compile(regexp.QuoteMeta(quoted) + `\s*:`)
+	if err != nil {
+		return 0
+	}
+	if loc := re.FindIndex(data); loc != nil {
+		return int64(loc[0])
+	}
+	return 0
+}
+
+// Position returns the 1-based line and column of the byte at an offset in
+// data, or zeros if the offset is unknown.
+func Position(data []byte, offset int64) (int, int) {
+	if offset <= 0 || offset > int64(len(data)) {
+		return 0, 0
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
@@ -0,0 +1,47 @@
+package storagepb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_Errors(t *testing.T) {
+	cases := []struct {
+		json    string
+		profile bool
+		err     string
+	}{
+		{"{\n\t\"id\": \"node1\",\n\t\"selecter\": {}\n}", false, `line 3, column 2: json: unknown field "selecter"`},
+		{"{\n\t\"id\": \"node1\",\n\t\"rollout\": {\"profile\": \"a\", \"when\": \"now\"}\n}", false, `line 3, column 30: json: unknown field "when"`},
+		{"{\n\t\"id\": \"node1\",\n\t\"selector\": {\"mac\": \"52:54:00\"}\n}", false, "line 3, column 15: selector mac: address 52:54:00: invalid MAC address"},
+		{`{"id": "node1"} {}`, false, "line 1, column 17: invalid data after the JSON object"},
+		{"{\n  \"id\": \"worker\",\n  \"install_limit\": \"5\"\n}", true, "line 3, column 23: json: cannot unmarshal string into Go struct field Profile.install_limit of type int32"},
+		{`{"id": "worker", "boot": {"kernal": "/assets/kernel"}}`, true, `line 1, column 27: json: unknown field "kernal"`},
+	}
+	// assert that:
+	// - unknown fields, values of the wrong type, malformed MAC selectors,
+	// and trailing data are errors at their line and column
+	for _, c := range cases {
+		var err error
+		if c.profile {
+			_, err = ParseProfile([]byte(c.json))
+		} else {
+			_, err = ParseGroup([]byte(c.json))
+		}
+		if assert.Error(t, err) {
+			assert.Equal(t, c.err, err.Error())
+			assert.IsType(t, &ParseError{}, err)
+		}
+	}
+}
+
+func TestFileError(t *testing.T) {
+	// assert that:
+	// - parse errors are formatted as path:line:column: message
+	// - other errors are prefixed with the path
+	_, err := ParseGroup([]byte("{\n\t\"selecter\": {}\n}"))
+	assert.Equal(t, `groups/node1.json:2:2: json: unknown field "selecter"`, FileError("groups/node1.json", err).Error())
+	assert.Equal(t, "groups/node1.json: oops", FileError("groups/node1.json", errors.New("oops")).Error())
+}
@@ -1,21 +1,115 @@
 package storagepb
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/coreos/matchbox/matchbox/schema"
 )
 
 var (
 	ErrIdRequired = errors.New("Id is required")
+	// asset errors
+	ErrAssetPathRequired = errors.New("Asset requires a relative path")
+	ErrAssetURLRequired  = errors.New("Asset requires an upstream URL")
+	ErrInvalidChecksum   = errors.New("Asset checksum must be sha256:hex or sha512:hex")
+	// install limit errors
+	ErrInvalidInstallLimit = errors.New("Profile install limit must not be negative")
+	// remote ignition errors
+	ErrInvalidIgnitionURL  = errors.New("Profile ignition URL must be an absolute https URL")
+	ErrSnippetsRequireURL  = errors.New("Profile ignition snippets require an ignition URL")
+	ErrSnippetNameRequired = errors.New("Profile ignition snippets must not be empty")
+	// metadata schema errors
+	ErrInvalidMetadataSchema = errors.New("Profile metadata schema must be a valid JSON Schema")
+	// header errors
+	ErrInvalidHeader  = errors.New("Profile headers must be valid HTTP header names and values")
+	ErrReservedHeader = errors.New("Profile headers must not set headers matchbox manages")
+	// version errors
+	ErrInvalidVersion      = errors.New("ProfileVersion requires a positive version")
+	ErrVersionProfileEmpty = errors.New("ProfileVersion requires a Profile")
 )
 
-// ParseProfile parses bytes into a Profile.
+// checksum algorithms and their hex digest lengths
+var checksumLengths = map[string]int{
+	"sha256": 64,
+	"sha512": 128,
+}
+
+// headerNameRegexp matches HTTP header names (RFC 7230 tokens).
+var headerNameRegexp = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+// reservedHeaders are response headers which Profiles may not set, since
+// they frame the response or are set by matchbox.
+var reservedHeaders = map[string]bool{
+	"Connection":        true,
+	"Content-Length":    true,
+	"Etag":              true,
+	"Keep-Alive":        true,
+	"Trailer":           true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+	"Warning":           true,
+	"X-Request-Id":      true,
+}
+
+// ParseProfile parses bytes into a Profile. Unknown fields and values of the
+// wrong type are ParseErrors.
 func ParseProfile(data []byte) (*Profile, error) {
 	profile := new(Profile)
-	err := json.Unmarshal(data, profile)
+	aux := &profileJSON{profileAlias: (*profileAlias)(profile)}
+	err := decodeStrict(data, aux)
+	if perr, ok := err.(*ParseError); ok {
+		if terr, ok := perr.Err.(*json.UnmarshalTypeError); ok {
+			terr.Struct = "Profile"
+		}
+	}
+	profile.MetadataSchema = aux.schema()
 	return profile, err
 }
 
+// profileAlias has the fields of a Profile without its JSON methods.
+type profileAlias Profile
+
+// profileJSON is the JSON form of a Profile, with the metadata schema as a
+// JSON object rather than base64.
+type profileJSON struct {
+	*profileAlias
+	MetadataSchema json.RawMessage `json:"metadata_schema,omitempty"`
+}
+
+// schema returns the metadata schema, or nil if it is unset or null.
+func (p *profileJSON) schema() []byte {
+	if len(p.MetadataSchema) == 0 || string(p.MetadataSchema) == "null" {
+		return nil
+	}
+	return []byte(p.MetadataSchema)
+}
+
+// MarshalJSON encodes a Profile as JSON.
+func (p *Profile) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&profileJSON{
+		profileAlias:   (*profileAlias)(p),
+		MetadataSchema: json.RawMessage(p.MetadataSchema),
+	})
+}
+
+// UnmarshalJSON decodes a Profile from JSON.
+func (p *Profile) UnmarshalJSON(data []byte) error {
+	aux := &profileJSON{profileAlias: (*profileAlias)(p)}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+	p.MetadataSchema = aux.schema()
+	return nil
+}
+
 // AssertValid validates a Profile. Returns nil if there are no validation
 // errors.
 func (p *Profile) AssertValid() error {
@@ -23,28 +117,169 @@ func (p *Profile) AssertValid() error {
 	if p.Id == "" {
 		return ErrIdRequired
 	}
+	if p.InstallLimit < 0 {
+		return ErrInvalidInstallLimit
+	}
+	if IsIgnitionURL(p.IgnitionId) {
+		u, err := url.Parse(p.IgnitionId)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return ErrInvalidIgnitionURL
+		}
+	} else if len(p.IgnitionSnippets) > 0 {
+		return ErrSnippetsRequireURL
+	}
+	for _, snippet := range p.IgnitionSnippets {
+		if snippet == "" {
+			return ErrSnippetNameRequired
+		}
+	}
+	for _, asset := range p.Assets {
+		if err := asset.AssertValid(); err != nil {
+			return err
+		}
+	}
+	for name, value := range p.Headers {
+		if !headerNameRegexp.MatchString(name) || strings.ContainsAny(value, "\r\n\x00") {
+			return fmt.Errorf("%v: %q", ErrInvalidHeader, name)
+		}
+		if reservedHeaders[http.CanonicalHeaderKey(name)] {
+			return fmt.Errorf("%v: %q", ErrReservedHeader, name)
+		}
+	}
+	if len(p.MetadataSchema) > 0 {
+		if _, err := schema.Compile(p.MetadataSchema); err != nil {
+			return fmt.Errorf("%v: %v", ErrInvalidMetadataSchema, strings.TrimPrefix(err.Error(), schema.ErrInvalidSchema.Error()+": "))
+		}
+	}
+	return nil
+}
+
+// A MetadataError is Group metadata which doesn't satisfy the metadata
+// schema of a Profile.
+type MetadataError struct {
+	Group   string
+	Profile string
+	Err     error
+}
+
+func (e *MetadataError) Error() string {
+	return fmt.Sprintf("group %q metadata does not match profile %q metadata schema: %v", e.Group, e.Profile, e.Err)
+}
+
+// ValidateMetadata returns a MetadataError if the metadata of a Group doesn't
+// satisfy the metadata schema of the Profile. Profiles without a schema
+// accept any metadata.
+func (p *Profile) ValidateMetadata(group *Group) error {
+	if len(p.MetadataSchema) == 0 {
+		return nil
+	}
+	s, err := schema.Compile(p.MetadataSchema)
+	if err != nil {
+		return err
+	}
+	metadata := map[string]interface{}{}
+	if len(group.Metadata) > 0 {
+		if err := json.Unmarshal(group.Metadata, &metadata); err != nil {
+			return err
+		}
+	}
+	if err := s.Validate(metadata); err != nil {
+		return &MetadataError{Group: group.Id, Profile: p.Id, Err: err}
+	}
 	return nil
 }
 
+// IsIgnitionURL returns true if an ignition id is the URL of a remote
+// Ignition config, rather than the name of a template.
+func IsIgnitionURL(id string) bool {
+	return strings.Contains(id, "://")
+}
+
+// ParseProfileVersion parses bytes into a ProfileVersion.
+func ParseProfileVersion(data []byte) (*ProfileVersion, error) {
+	version := new(ProfileVersion)
+	err := json.Unmarshal(data, version)
+	return version, err
+}
+
+// AssertValid validates a ProfileVersion. Returns nil if there are no
+// validation errors.
+func (v *ProfileVersion) AssertValid() error {
+	if v.Version < 1 {
+		return ErrInvalidVersion
+	}
+	if v.Profile == nil {
+		return ErrVersionProfileEmpty
+	}
+	return v.Profile.AssertValid()
+}
+
+// Copy returns a copy of the ProfileVersion.
+func (v *ProfileVersion) Copy() *ProfileVersion {
+	clone := *v
+	if v.Profile != nil {
+		clone.Profile = v.Profile.Copy()
+	}
+	return &clone
+}
+
 func (p *Profile) Copy() *Profile {
 	return &Profile{
-		Id:         p.Id,
-		Name:       p.Name,
-		IgnitionId: p.IgnitionId,
-		CloudId:    p.CloudId,
-		GenericId:  p.GenericId,
-		Boot:       p.Boot.Copy(),
+		Id:           p.Id,
+		Name:         p.Name,
+		IgnitionId:   p.IgnitionId,
+		CloudId:      p.CloudId,
+		GenericId:    p.GenericId,
+		Boot:         p.Boot.Copy(),
+		Assets:       copyAssets(p.Assets),
+		InstallLimit: p.InstallLimit,
+		// nil snippets stay nil, so copies equal the original
+		IgnitionSnippets: copyStrings(p.IgnitionSnippets),
+		MetadataSchema:   p.MetadataSchema,
+		Headers:          copyHeaders(p.Headers),
+	}
+}
+
+func copyHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	clone := make(map[string]string, len(headers))
+	for name, value := range headers {
+		clone[name] = value
 	}
+	return clone
+}
+
+func copyStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	clone := make([]string, len(values))
+	copy(clone, values)
+	return clone
 }
 
 func (b *NetBoot) Copy() *NetBoot {
-	initrd := make([]string, len(b.Initrd))
-	copy(initrd, b.Initrd)
-	args := make([]string, len(b.Args))
-	copy(args, b.Args)
-	cmdline := make(map[string]string)
-	for k, v := range b.Cmdline {
-		cmdline[k] = v
+	if b == nil {
+		return nil
+	}
+	// nil fields stay nil, so copies equal the original
+	var initrd, args []string
+	if b.Initrd != nil {
+		initrd = make([]string, len(b.Initrd))
+		copy(initrd, b.Initrd)
+	}
+	if b.Args != nil {
+		args = make([]string, len(b.Args))
+		copy(args, b.Args)
+	}
+	var cmdline map[string]string
+	if b.Cmdline != nil {
+		cmdline = make(map[string]string, len(b.Cmdline))
+		for k, v := range b.Cmdline {
+			cmdline[k] = v
+		}
 	}
 	return &NetBoot{
 		Kernel: b.Kernel,
@@ -54,3 +289,52 @@ func (b *NetBoot) Copy() *NetBoot {
 		Cmdline: cmdline,
 	}
 }
+
+func copyAssets(assets []*Asset) []*Asset {
+	if assets == nil {
+		return nil
+	}
+	clone := make([]*Asset, len(assets))
+	for i, asset := range assets {
+		clone[i] = &Asset{
+			Path:     asset.Path,
+			Url:      asset.Url,
+			Checksum: asset.Checksum,
+		}
+	}
+	return clone
+}
+
+// AssertValid validates an Asset. Returns nil if there are no validation
+// errors.
+func (a *Asset) AssertValid() error {
+	if a.Path == "" || path.IsAbs(a.Path) || strings.HasPrefix(path.Clean(a.Path), "..") {
+		return ErrAssetPathRequired
+	}
+	if a.Url == "" {
+		return ErrAssetURLRequired
+	}
+	if _, _, err := a.ParseChecksum(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ParseChecksum returns the algorithm and decoded digest of the Asset
+// checksum.
+func (a *Asset) ParseChecksum() (string, []byte, error) {
+	parts := strings.SplitN(a.Checksum, ":", 2)
+	if len(parts) != 2 {
+		return "", nil, ErrInvalidChecksum
+	}
+	algorithm := strings.ToLower(parts[0])
+	length, ok := checksumLengths[algorithm]
+	if !ok || len(parts[1]) != length {
+		return "", nil, ErrInvalidChecksum
+	}
+	digest, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, ErrInvalidChecksum
+	}
+	return algorithm, digest, nil
+}
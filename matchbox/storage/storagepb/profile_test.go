@@ -1,6 +1,7 @@
 package storagepb
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -12,6 +13,11 @@ var (
 		CloudId:    "cloud.yaml",
 		IgnitionId: "ignition.json",
 	}
+	testAsset = &Asset{
+		Path:     "coreos/1298.7.0/coreos_production_pxe.vmlinuz",
+		Url:      "https://stable.release.core-os.net/amd64-usr/1298.7.0/coreos_production_pxe.vmlinuz",
+		Checksum: "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+	}
 )
 
 func TestProfileParse(t *testing.T) {
@@ -27,6 +33,61 @@ func TestProfileParse(t *testing.T) {
 	}
 }
 
+func TestProfileJSON_MetadataSchema(t *testing.T) {
+	data := `{"id": "id", "metadata_schema": {"required": ["etcd_name"]}}`
+	// assert that:
+	// - metadata schemas are parsed and encoded as JSON objects, not base64
+	// - unknown fields and values of the wrong type are still ParseErrors
+	// - a null schema is unset
+	profile, err := ParseProfile([]byte(data))
+	if assert.Nil(t, err) {
+		assert.Equal(t, `{"required": ["etcd_name"]}`, string(profile.MetadataSchema))
+	}
+	encoded, err := json.Marshal(profile)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"id":"id","metadata_schema":{"required":["etcd_name"]}}`, string(encoded))
+	decoded := new(Profile)
+	assert.Nil(t, json.Unmarshal(encoded, decoded))
+	assert.Equal(t, `{"required":["etcd_name"]}`, string(decoded.MetadataSchema))
+
+	_, err = ParseProfile([]byte(`{"id": "id", "metadata_schemas": {}}`))
+	assert.EqualError(t, err, `line 1, column 14: json: unknown field "metadata_schemas"`)
+	_, err = ParseProfile([]byte(`{"id": "id", "install_limit": "2"}`))
+	assert.EqualError(t, err, "line 1, column 34: json: cannot unmarshal string into Go struct field Profile.install_limit of type int32")
+	profile, err = ParseProfile([]byte(`{"id": "id", "metadata_schema": null}`))
+	if assert.Nil(t, err) {
+		assert.Nil(t, profile.MetadataSchema)
+	}
+}
+
+func TestProfileValidateMetadata(t *testing.T) {
+	profile := &Profile{
+		Id:             "etcd",
+		MetadataSchema: []byte(`{"required": ["etcd_name"], "properties": {"etcd_name": {"type": "string"}}}`),
+	}
+	cases := []struct {
+		metadata string
+		err      string
+	}{
+		{`{"etcd_name": "node1"}`, ""},
+		{``, `group "node1" metadata does not match profile "etcd" metadata schema: missing required key "etcd_name"`},
+		{`{"etcd_name": 1}`, `group "node1" metadata does not match profile "etcd" metadata schema: etcd_name: must be a string, not integer`},
+	}
+	// assert that:
+	// - Group metadata is validated against the Profile metadata schema
+	// - Profiles without a schema accept any metadata
+	for _, c := range cases {
+		group := &Group{Id: "node1", Metadata: []byte(c.metadata)}
+		err := profile.ValidateMetadata(group)
+		if c.err == "" {
+			assert.Nil(t, err)
+		} else if assert.IsType(t, &MetadataError{}, err) {
+			assert.Equal(t, c.err, err.Error())
+		}
+		assert.Nil(t, testProfile.ValidateMetadata(group))
+	}
+}
+
 func TestProfileValidate(t *testing.T) {
 	cases := []struct {
 		profile *Profile
@@ -35,6 +96,23 @@ func TestProfileValidate(t *testing.T) {
 		{testProfile, true},
 		{&Profile{Id: "a1b2c3d4"}, true},
 		{&Profile{}, false},
+		{&Profile{Id: "a1b2c3d4", Assets: []*Asset{testAsset}}, true},
+		{&Profile{Id: "a1b2c3d4", Assets: []*Asset{{Path: "kernel"}}}, false},
+		{&Profile{Id: "a1b2c3d4", InstallLimit: 5}, true},
+		{&Profile{Id: "a1b2c3d4", InstallLimit: -1}, false},
+		{&Profile{Id: "a1b2c3d4", IgnitionId: "https://example.com/node.ign", IgnitionSnippets: []string{"ssh.yaml"}}, true},
+		{&Profile{Id: "a1b2c3d4", IgnitionId: "http://example.com/node.ign"}, false},
+		{&Profile{Id: "a1b2c3d4", IgnitionId: "https:///node.ign"}, false},
+		{&Profile{Id: "a1b2c3d4", IgnitionId: "https://example.com/node.ign", IgnitionSnippets: []string{""}}, false},
+		{&Profile{Id: "a1b2c3d4", IgnitionId: "node.yaml", IgnitionSnippets: []string{"ssh.yaml"}}, false},
+		{&Profile{Id: "a1b2c3d4", MetadataSchema: []byte(`{"required": ["etcd_name"]}`)}, true},
+		{&Profile{Id: "a1b2c3d4", MetadataSchema: []byte(`{"oneOf": []}`)}, false},
+		{&Profile{Id: "a1b2c3d4", MetadataSchema: []byte(`{`)}, false},
+		{&Profile{Id: "a1b2c3d4", Headers: map[string]string{"Cache-Control": "no-store", "Content-Type": "text/plain"}}, true},
+		{&Profile{Id: "a1b2c3d4", Headers: map[string]string{"Bad Name": "value"}}, false},
+		{&Profile{Id: "a1b2c3d4", Headers: map[string]string{"X-Vendor": "a\r\nSet-Cookie: b"}}, false},
+		{&Profile{Id: "a1b2c3d4", Headers: map[string]string{"content-length": "0"}}, false},
+		{&Profile{Id: "a1b2c3d4", Headers: map[string]string{"ETag": `"abc"`}}, false},
 	}
 	for _, c := range cases {
 		valid := c.profile.AssertValid() == nil
@@ -42,6 +120,22 @@ func TestProfileValidate(t *testing.T) {
 	}
 }
 
+func TestProfileVersionValidate(t *testing.T) {
+	cases := []struct {
+		version *ProfileVersion
+		valid   bool
+	}{
+		{&ProfileVersion{Version: 1, Profile: testProfile, Ignition: "{}"}, true},
+		{&ProfileVersion{Profile: testProfile}, false},
+		{&ProfileVersion{Version: 1}, false},
+		{&ProfileVersion{Version: 1, Profile: &Profile{}}, false},
+	}
+	for _, c := range cases {
+		valid := c.version.AssertValid() == nil
+		assert.Equal(t, c.valid, valid)
+	}
+}
+
 func TestProfileCopy(t *testing.T) {
 	profile := &Profile{
 		Id:         "id",
@@ -53,6 +147,10 @@ func TestProfileCopy(t *testing.T) {
 			Cmdline: map[string]string{"a": "b"},
 			Args:    []string{"a=b"},
 		},
+		InstallLimit:     5,
+		IgnitionSnippets: []string{"ssh.yaml"},
+		MetadataSchema:   []byte(`{"required": ["etcd_name"]}`),
+		Headers:          map[string]string{"Cache-Control": "no-store"},
 	}
 	clone := profile.Copy()
 	// assert that:
@@ -63,6 +161,10 @@ func TestProfileCopy(t *testing.T) {
 	assert.Equal(t, profile.IgnitionId, clone.IgnitionId)
 	assert.Equal(t, profile.CloudId, clone.CloudId)
 	assert.Equal(t, profile.Boot, clone.Boot)
+	assert.Equal(t, profile.InstallLimit, clone.InstallLimit)
+	assert.Equal(t, profile.IgnitionSnippets, clone.IgnitionSnippets)
+	assert.Equal(t, profile.MetadataSchema, clone.MetadataSchema)
+	assert.Equal(t, profile.Headers, clone.Headers)
 
 	// mutate the NetBoot struct
 	clone.Boot.Initrd = []string{"/image/initrd_b"}
@@ -71,6 +173,10 @@ func TestProfileCopy(t *testing.T) {
 	assert.NotEqual(t, profile.Boot.Initrd, clone.Boot.Initrd)
 	assert.NotEqual(t, profile.Boot.Cmdline, clone.Boot.Cmdline)
 	assert.NotEqual(t, profile.Boot.Args, clone.Boot.Args)
+	clone.IgnitionSnippets[0] = "users.yaml"
+	assert.Equal(t, "ssh.yaml", profile.IgnitionSnippets[0])
+	clone.Headers["Cache-Control"] = "max-age=60"
+	assert.Equal(t, "no-store", profile.Headers["Cache-Control"])
 }
 
 func TestNetBootCopy(t *testing.T) {
@@ -97,3 +203,43 @@ func TestNetBootCopy(t *testing.T) {
 	assert.NotEqual(t, boot.Initrd, clone.Initrd)
 	assert.NotEqual(t, boot.Args, clone.Args)
 }
+
+func TestAssetValidate(t *testing.T) {
+	cases := []struct {
+		asset *Asset
+		err   error
+	}{
+		{testAsset, nil},
+		{&Asset{Url: testAsset.Url, Checksum: testAsset.Checksum}, ErrAssetPathRequired},
+		{&Asset{Path: "/abs/kernel", Url: testAsset.Url, Checksum: testAsset.Checksum}, ErrAssetPathRequired},
+		{&Asset{Path: "../kernel", Url: testAsset.Url, Checksum: testAsset.Checksum}, ErrAssetPathRequired},
+		{&Asset{Path: testAsset.Path, Checksum: testAsset.Checksum}, ErrAssetURLRequired},
+		{&Asset{Path: testAsset.Path, Url: testAsset.Url}, ErrInvalidChecksum},
+		{&Asset{Path: testAsset.Path, Url: testAsset.Url, Checksum: "md5:d41d8cd98f00b204e9800998ecf8427e"}, ErrInvalidChecksum},
+		{&Asset{Path: testAsset.Path, Url: testAsset.Url, Checksum: "sha256:abc"}, ErrInvalidChecksum},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.err, c.asset.AssertValid())
+	}
+}
+
+func TestProfileVersionCopy(t *testing.T) {
+	version := &ProfileVersion{
+		Version:  2,
+		Profile:  &Profile{Id: "id", Boot: &NetBoot{Kernel: "/image/kernel"}},
+		Ignition: "ignition",
+		Created:  "2026-10-15T00:00:00Z",
+	}
+	clone := version.Copy()
+	// assert that:
+	// - ProfileVersion fields are copied to the clone
+	// - Mutation of the clone's Profile does not affect the original
+	assert.Equal(t, version.Version, clone.Version)
+	assert.Equal(t, version.Profile.Id, clone.Profile.Id)
+	assert.Equal(t, version.Ignition, clone.Ignition)
+	assert.Equal(t, version.Created, clone.Created)
+	clone.Profile.Boot.Kernel = "/image/other"
+	assert.Equal(t, "/image/kernel", version.Profile.Boot.Kernel)
+	// - Profiles without a NetBoot config are copied
+	assert.Nil(t, (&Profile{Id: "id"}).Copy().Boot)
+}
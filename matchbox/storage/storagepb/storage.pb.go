@@ -10,8 +10,16 @@ It is generated from these files:
 
 It has these top-level messages:
 	Group
+	Rollout
+	MetadataSource
 	Profile
+	ProfileVersion
 	NetBoot
+	Asset
+	Machine
+	MachineStep
+	MachineBoot
+	Event
 */
 package storagepb
 
@@ -42,6 +50,12 @@ type Group struct {
 	Selector map[string]string `protobuf:"bytes,4,rep,name=selector" json:"selector,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	// JSON encoded metadata
 	Metadata []byte `protobuf:"bytes,5,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	// external metadata fetched and merged at render time
+	MetadataSources []*MetadataSource `protobuf:"bytes,6,rep,name=metadata_sources,json=metadataSources" json:"metadata_sources,omitempty"`
+	// (optional) Profile change staged to roll out across matching machines
+	Rollout *Rollout `protobuf:"bytes,7,opt,name=rollout" json:"rollout,omitempty"`
+	// (optional) version of the Profile to serve, the latest if 0
+	ProfileVersion int32 `protobuf:"varint,8,opt,name=profile_version,json=profileVersion" json:"profile_version,omitempty"`
 }
 
 func (m *Group) Reset()                    { *m = Group{} }
@@ -84,13 +98,109 @@ func (m *Group) GetMetadata() []byte {
 	return nil
 }
 
+func (m *Group) GetMetadataSources() []*MetadataSource {
+	if m != nil {
+		return m.MetadataSources
+	}
+	return nil
+}
+
+func (m *Group) GetRollout() *Rollout {
+	if m != nil {
+		return m.Rollout
+	}
+	return nil
+}
+
+func (m *Group) GetProfileVersion() int32 {
+	if m != nil {
+		return m.ProfileVersion
+	}
+	return 0
+}
+
+// Rollout stages a Group's change to another Profile, which is served to
+// matching machines in waves from an activation time.
+type Rollout struct {
+	// id of the Profile rolled out
+	Profile string `protobuf:"bytes,1,opt,name=profile" json:"profile,omitempty"`
+	// time the first wave is activated (RFC 3339)
+	Start string `protobuf:"bytes,2,opt,name=start" json:"start,omitempty"`
+	// (optional) machines admitted per wave, all machines if 0
+	BatchSize int32 `protobuf:"varint,3,opt,name=batch_size,json=batchSize" json:"batch_size,omitempty"`
+	// duration between waves (e.g. 30m), required with a batch size
+	Interval string `protobuf:"bytes,4,opt,name=interval" json:"interval,omitempty"`
+}
+
+func (m *Rollout) Reset()                    { *m = Rollout{} }
+func (m *Rollout) String() string            { return proto.CompactTextString(m) }
+func (*Rollout) ProtoMessage()               {}
+func (*Rollout) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *Rollout) GetProfile() string {
+	if m != nil {
+		return m.Profile
+	}
+	return ""
+}
+
+func (m *Rollout) GetStart() string {
+	if m != nil {
+		return m.Start
+	}
+	return ""
+}
+
+func (m *Rollout) GetBatchSize() int32 {
+	if m != nil {
+		return m.BatchSize
+	}
+	return 0
+}
+
+func (m *Rollout) GetInterval() string {
+	if m != nil {
+		return m.Interval
+	}
+	return ""
+}
+
+// MetadataSource is an external source of Group metadata.
+type MetadataSource struct {
+	// source URL (http, https, consul, consul+https, or dns), which may
+	// reference machine labels as {{.label}}
+	Url string `protobuf:"bytes,1,opt,name=url" json:"url,omitempty"`
+	// (optional) metadata key the fetched value is set under, otherwise the
+	// fetched JSON object is merged into the metadata
+	Key string `protobuf:"bytes,2,opt,name=key" json:"key,omitempty"`
+}
+
+func (m *MetadataSource) Reset()                    { *m = MetadataSource{} }
+func (m *MetadataSource) String() string            { return proto.CompactTextString(m) }
+func (*MetadataSource) ProtoMessage()               {}
+func (*MetadataSource) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *MetadataSource) GetUrl() string {
+	if m != nil {
+		return m.Url
+	}
+	return ""
+}
+
+func (m *MetadataSource) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
 // Profile defines the boot and provisioning behavior of a group of machines.
 type Profile struct {
 	// profile id
 	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
 	// human readable name
 	Name string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
-	// ignition id
+	// ignition id, or an https URL of an Ignition config to fetch
 	IgnitionId string `protobuf:"bytes,3,opt,name=ignition_id,json=ignitionId" json:"ignition_id,omitempty"`
 	// cloud config id
 	CloudId string `protobuf:"bytes,4,opt,name=cloud_id,json=cloudId" json:"cloud_id,omitempty"`
@@ -98,12 +208,25 @@ type Profile struct {
 	Boot *NetBoot `protobuf:"bytes,5,opt,name=boot" json:"boot,omitempty"`
 	// generic config id
 	GenericId string `protobuf:"bytes,6,opt,name=generic_id,json=genericId" json:"generic_id,omitempty"`
+	// assets to mirror from upstream
+	Assets []*Asset `protobuf:"bytes,7,rep,name=assets" json:"assets,omitempty"`
+	// maximum machines installing the profile at once (0 for no limit)
+	InstallLimit int32 `protobuf:"varint,8,opt,name=install_limit,json=installLimit" json:"install_limit,omitempty"`
+	// (optional) ignition ids of templates appended to a remote Ignition
+	// config
+	IgnitionSnippets []string `protobuf:"bytes,9,rep,name=ignition_snippets,json=ignitionSnippets" json:"ignition_snippets,omitempty"`
+	// (optional) JSON Schema the metadata of Groups selecting the profile must
+	// satisfy
+	MetadataSchema []byte `protobuf:"bytes,10,opt,name=metadata_schema,json=metadataSchema,proto3" json:"metadata_schema,omitempty"`
+	// (optional) response headers of the profile's rendered configs (e.g.
+	// Cache-Control or Content-Type)
+	Headers map[string]string `protobuf:"bytes,11,rep,name=headers" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 }
 
 func (m *Profile) Reset()                    { *m = Profile{} }
 func (m *Profile) String() string            { return proto.CompactTextString(m) }
 func (*Profile) ProtoMessage()               {}
-func (*Profile) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+func (*Profile) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
 
 func (m *Profile) GetId() string {
 	if m != nil {
@@ -147,6 +270,104 @@ func (m *Profile) GetGenericId() string {
 	return ""
 }
 
+func (m *Profile) GetAssets() []*Asset {
+	if m != nil {
+		return m.Assets
+	}
+	return nil
+}
+
+func (m *Profile) GetInstallLimit() int32 {
+	if m != nil {
+		return m.InstallLimit
+	}
+	return 0
+}
+
+func (m *Profile) GetIgnitionSnippets() []string {
+	if m != nil {
+		return m.IgnitionSnippets
+	}
+	return nil
+}
+
+func (m *Profile) GetMetadataSchema() []byte {
+	if m != nil {
+		return m.MetadataSchema
+	}
+	return nil
+}
+
+func (m *Profile) GetHeaders() map[string]string {
+	if m != nil {
+		return m.Headers
+	}
+	return nil
+}
+
+// ProfileVersion is a snapshot of a Profile and the templates it references.
+type ProfileVersion struct {
+	// version number, increasing from 1
+	Version int32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	// Profile as it was written
+	Profile *Profile `protobuf:"bytes,2,opt,name=profile" json:"profile,omitempty"`
+	// contents of the referenced Ignition template
+	Ignition string `protobuf:"bytes,3,opt,name=ignition" json:"ignition,omitempty"`
+	// contents of the referenced Cloud-Config template
+	Cloud string `protobuf:"bytes,4,opt,name=cloud" json:"cloud,omitempty"`
+	// contents of the referenced generic template
+	Generic string `protobuf:"bytes,5,opt,name=generic" json:"generic,omitempty"`
+	// time the snapshot was taken (RFC 3339)
+	Created string `protobuf:"bytes,6,opt,name=created" json:"created,omitempty"`
+}
+
+func (m *ProfileVersion) Reset()                    { *m = ProfileVersion{} }
+func (m *ProfileVersion) String() string            { return proto.CompactTextString(m) }
+func (*ProfileVersion) ProtoMessage()               {}
+func (*ProfileVersion) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *ProfileVersion) GetVersion() int32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *ProfileVersion) GetProfile() *Profile {
+	if m != nil {
+		return m.Profile
+	}
+	return nil
+}
+
+func (m *ProfileVersion) GetIgnition() string {
+	if m != nil {
+		return m.Ignition
+	}
+	return ""
+}
+
+func (m *ProfileVersion) GetCloud() string {
+	if m != nil {
+		return m.Cloud
+	}
+	return ""
+}
+
+func (m *ProfileVersion) GetGeneric() string {
+	if m != nil {
+		return m.Generic
+	}
+	return ""
+}
+
+func (m *ProfileVersion) GetCreated() string {
+	if m != nil {
+		return m.Created
+	}
+	return ""
+}
+
 // NetBoot describes network or PXE boot settings for a machine.
 type NetBoot struct {
 	// the URL of the kernel image
@@ -162,7 +383,7 @@ type NetBoot struct {
 func (m *NetBoot) Reset()                    { *m = NetBoot{} }
 func (m *NetBoot) String() string            { return proto.CompactTextString(m) }
 func (*NetBoot) ProtoMessage()               {}
-func (*NetBoot) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+func (*NetBoot) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
 
 func (m *NetBoot) GetKernel() string {
 	if m != nil {
@@ -192,37 +413,471 @@ func (m *NetBoot) GetArgs() []string {
 	return nil
 }
 
+// Asset describes an upstream file which is mirrored and served under assets.
+type Asset struct {
+	// path relative to the assets directory
+	Path string `protobuf:"bytes,1,opt,name=path" json:"path,omitempty"`
+	// upstream URL of the file
+	Url string `protobuf:"bytes,2,opt,name=url" json:"url,omitempty"`
+	// checksum of the file as algorithm:hex (e.g. sha512:abc...)
+	Checksum string `protobuf:"bytes,3,opt,name=checksum" json:"checksum,omitempty"`
+}
+
+func (m *Asset) Reset()                    { *m = Asset{} }
+func (m *Asset) String() string            { return proto.CompactTextString(m) }
+func (*Asset) ProtoMessage()               {}
+func (*Asset) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+func (m *Asset) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *Asset) GetUrl() string {
+	if m != nil {
+		return m.Url
+	}
+	return ""
+}
+
+func (m *Asset) GetChecksum() string {
+	if m != nil {
+		return m.Checksum
+	}
+	return ""
+}
+
+// Machine records the provisioning state of a machine.
+type Machine struct {
+	// machine id (UUID)
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	// labels the machine last reported
+	Labels map[string]string `protobuf:"bytes,2,rep,name=labels" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// provisioning state
+	State string `protobuf:"bytes,3,opt,name=state" json:"state,omitempty"`
+	// time the machine reported completion (RFC 3339)
+	Completed string `protobuf:"bytes,4,opt,name=completed" json:"completed,omitempty"`
+	// payload reported on completion
+	Payload []byte `protobuf:"bytes,5,opt,name=payload,proto3" json:"payload,omitempty"`
+	// id of a Group the machine is pinned to, used instead of selector matching
+	Group string `protobuf:"bytes,6,opt,name=group" json:"group,omitempty"`
+	// Redfish ComputerSystem URL of the machine's BMC, used for power control
+	Bmc string `protobuf:"bytes,7,opt,name=bmc" json:"bmc,omitempty"`
+	// facts discovered about the machine (e.g. from DHCP leases), which are
+	// added to its labels when matching Groups
+	Facts map[string]string `protobuf:"bytes,8,rep,name=facts" json:"facts,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// consumer which claimed the machine (e.g. a Cluster API Machine)
+	Owner string `protobuf:"bytes,9,opt,name=owner" json:"owner,omitempty"`
+	// wipe the machine's disks when it is reinstalled, cleared once it is
+	// installed again
+	Wipe bool `protobuf:"varint,10,opt,name=wipe" json:"wipe,omitempty"`
+	// id of the Profile served to the machine while it is decommissioned,
+	// instead of its Group's
+	Decommission string `protobuf:"bytes,11,opt,name=decommission" json:"decommission,omitempty"`
+	// provisioning steps reported by the machine's installer, in the order
+	// they started
+	Progress []*MachineStep `protobuf:"bytes,12,rep,name=progress" json:"progress,omitempty"`
+	// network boots since the machine was last installed, counted when
+	// install attempts are limited
+	Attempts int32 `protobuf:"varint,13,opt,name=attempts" json:"attempts,omitempty"`
+	// most recent config requests of the machine, oldest first
+	Boots []*MachineBoot `protobuf:"bytes,14,rep,name=boots" json:"boots,omitempty"`
+}
+
+func (m *Machine) Reset()                    { *m = Machine{} }
+func (m *Machine) String() string            { return proto.CompactTextString(m) }
+func (*Machine) ProtoMessage()               {}
+func (*Machine) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *Machine) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Machine) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *Machine) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *Machine) GetCompleted() string {
+	if m != nil {
+		return m.Completed
+	}
+	return ""
+}
+
+func (m *Machine) GetPayload() []byte {
+	if m != nil {
+		return m.Payload
+	}
+	return nil
+}
+
+func (m *Machine) GetGroup() string {
+	if m != nil {
+		return m.Group
+	}
+	return ""
+}
+
+func (m *Machine) GetBmc() string {
+	if m != nil {
+		return m.Bmc
+	}
+	return ""
+}
+
+func (m *Machine) GetFacts() map[string]string {
+	if m != nil {
+		return m.Facts
+	}
+	return nil
+}
+
+func (m *Machine) GetOwner() string {
+	if m != nil {
+		return m.Owner
+	}
+	return ""
+}
+
+func (m *Machine) GetWipe() bool {
+	if m != nil {
+		return m.Wipe
+	}
+	return false
+}
+
+func (m *Machine) GetDecommission() string {
+	if m != nil {
+		return m.Decommission
+	}
+	return ""
+}
+
+func (m *Machine) GetProgress() []*MachineStep {
+	if m != nil {
+		return m.Progress
+	}
+	return nil
+}
+
+func (m *Machine) GetAttempts() int32 {
+	if m != nil {
+		return m.Attempts
+	}
+	return 0
+}
+
+func (m *Machine) GetBoots() []*MachineBoot {
+	if m != nil {
+		return m.Boots
+	}
+	return nil
+}
+
+// MachineStep is a provisioning step reported by a machine.
+type MachineStep struct {
+	// step name (e.g. install-os)
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// started, succeeded, or failed
+	Status string `protobuf:"bytes,2,opt,name=status" json:"status,omitempty"`
+	// (optional) detail reported with the status
+	Message string `protobuf:"bytes,3,opt,name=message" json:"message,omitempty"`
+	// time the status was reported (RFC 3339)
+	Time string `protobuf:"bytes,4,opt,name=time" json:"time,omitempty"`
+}
+
+func (m *MachineStep) Reset()                    { *m = MachineStep{} }
+func (m *MachineStep) String() string            { return proto.CompactTextString(m) }
+func (*MachineStep) ProtoMessage()               {}
+func (*MachineStep) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+func (m *MachineStep) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *MachineStep) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+func (m *MachineStep) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *MachineStep) GetTime() string {
+	if m != nil {
+		return m.Time
+	}
+	return ""
+}
+
+// MachineBoot is a config request made by a machine as it booted.
+type MachineBoot struct {
+	// RFC 3339 time of the request
+	Time string `protobuf:"bytes,1,opt,name=time" json:"time,omitempty"`
+	// endpoint path (e.g. /ipxe, /ignition)
+	Endpoint string `protobuf:"bytes,2,opt,name=endpoint" json:"endpoint,omitempty"`
+	// HTTP response status
+	Status int32 `protobuf:"varint,3,opt,name=status" json:"status,omitempty"`
+	// id of the Group the machine matched, if any
+	Group string `protobuf:"bytes,4,opt,name=group" json:"group,omitempty"`
+	// id of the Profile the machine was served, if any
+	Profile string `protobuf:"bytes,5,opt,name=profile" json:"profile,omitempty"`
+	// checksum of the served config as sha256:hex
+	ConfigHash string `protobuf:"bytes,6,opt,name=config_hash,json=configHash" json:"config_hash,omitempty"`
+}
+
+func (m *MachineBoot) Reset()                    { *m = MachineBoot{} }
+func (m *MachineBoot) String() string            { return proto.CompactTextString(m) }
+func (*MachineBoot) ProtoMessage()               {}
+func (*MachineBoot) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+func (m *MachineBoot) GetTime() string {
+	if m != nil {
+		return m.Time
+	}
+	return ""
+}
+
+func (m *MachineBoot) GetEndpoint() string {
+	if m != nil {
+		return m.Endpoint
+	}
+	return ""
+}
+
+func (m *MachineBoot) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *MachineBoot) GetGroup() string {
+	if m != nil {
+		return m.Group
+	}
+	return ""
+}
+
+func (m *MachineBoot) GetProfile() string {
+	if m != nil {
+		return m.Profile
+	}
+	return ""
+}
+
+func (m *MachineBoot) GetConfigHash() string {
+	if m != nil {
+		return m.ConfigHash
+	}
+	return ""
+}
+
+// Event is a recorded machine request to a boot or provisioning endpoint.
+type Event struct {
+	// RFC 3339 time of the request
+	Time string `protobuf:"bytes,1,opt,name=time" json:"time,omitempty"`
+	// event type (boot, config, progress, or complete)
+	Type string `protobuf:"bytes,2,opt,name=type" json:"type,omitempty"`
+	// machine uuid, or mac if there is no uuid
+	MachineId string `protobuf:"bytes,3,opt,name=machine_id,json=machineId" json:"machine_id,omitempty"`
+	// endpoint path (e.g. /ipxe, /v1/complete)
+	Endpoint string `protobuf:"bytes,4,opt,name=endpoint" json:"endpoint,omitempty"`
+	// HTTP response status
+	Status int32             `protobuf:"varint,5,opt,name=status" json:"status,omitempty"`
+	Labels map[string]string `protobuf:"bytes,6,rep,name=labels" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// id of the Group the machine matched, if any
+	Group string `protobuf:"bytes,7,opt,name=group" json:"group,omitempty"`
+	// id of the Profile the machine was served, if any
+	Profile  string `protobuf:"bytes,8,opt,name=profile" json:"profile,omitempty"`
+	RemoteIp string `protobuf:"bytes,9,opt,name=remote_ip,json=remoteIp" json:"remote_ip,omitempty"`
+	// request ID, as in the request log
+	RequestId string `protobuf:"bytes,10,opt,name=request_id,json=requestId" json:"request_id,omitempty"`
+}
+
+func (m *Event) Reset()                    { *m = Event{} }
+func (m *Event) String() string            { return proto.CompactTextString(m) }
+func (*Event) ProtoMessage()               {}
+func (*Event) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+func (m *Event) GetTime() string {
+	if m != nil {
+		return m.Time
+	}
+	return ""
+}
+
+func (m *Event) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *Event) GetMachineId() string {
+	if m != nil {
+		return m.MachineId
+	}
+	return ""
+}
+
+func (m *Event) GetEndpoint() string {
+	if m != nil {
+		return m.Endpoint
+	}
+	return ""
+}
+
+func (m *Event) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *Event) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *Event) GetGroup() string {
+	if m != nil {
+		return m.Group
+	}
+	return ""
+}
+
+func (m *Event) GetProfile() string {
+	if m != nil {
+		return m.Profile
+	}
+	return ""
+}
+
+func (m *Event) GetRemoteIp() string {
+	if m != nil {
+		return m.RemoteIp
+	}
+	return ""
+}
+
+func (m *Event) GetRequestId() string {
+	if m != nil {
+		return m.RequestId
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*Group)(nil), "storagepb.Group")
+	proto.RegisterType((*Rollout)(nil), "storagepb.Rollout")
+	proto.RegisterType((*MetadataSource)(nil), "storagepb.MetadataSource")
 	proto.RegisterType((*Profile)(nil), "storagepb.Profile")
+	proto.RegisterType((*ProfileVersion)(nil), "storagepb.ProfileVersion")
 	proto.RegisterType((*NetBoot)(nil), "storagepb.NetBoot")
+	proto.RegisterType((*Asset)(nil), "storagepb.Asset")
+	proto.RegisterType((*Machine)(nil), "storagepb.Machine")
+	proto.RegisterType((*MachineStep)(nil), "storagepb.MachineStep")
+	proto.RegisterType((*MachineBoot)(nil), "storagepb.MachineBoot")
+	proto.RegisterType((*Event)(nil), "storagepb.Event")
 }
 
 func init() { proto.RegisterFile("storage.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 355 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x94, 0x52, 0xcd, 0x4a, 0x03, 0x31,
-	0x10, 0x66, 0x7f, 0xda, 0xed, 0x4e, 0x5b, 0x91, 0x41, 0x64, 0x2d, 0x68, 0x97, 0x1e, 0xa4, 0xa7,
-	0x3d, 0xd4, 0x8b, 0xd6, 0x9b, 0x22, 0xd2, 0x8b, 0xc8, 0xfa, 0x00, 0x92, 0x6e, 0xe2, 0x12, 0xba,
-	0x4d, 0x96, 0x34, 0x15, 0xfa, 0x56, 0x3e, 0x84, 0x4f, 0xe1, 0xd3, 0x48, 0xb2, 0xd9, 0x52, 0xf1,
-	0xa2, 0xb7, 0xf9, 0xbe, 0x7c, 0xc9, 0x7c, 0x33, 0x5f, 0x60, 0xb8, 0xd1, 0x52, 0x91, 0x92, 0x65,
-	0xb5, 0x92, 0x5a, 0x62, 0xec, 0x60, 0xbd, 0x9c, 0x7c, 0x79, 0xd0, 0x79, 0x54, 0x72, 0x5b, 0xe3,
-	0x11, 0xf8, 0x9c, 0x26, 0x5e, 0xea, 0x4d, 0xe3, 0xdc, 0xe7, 0x14, 0x11, 0x42, 0x41, 0xd6, 0x2c,
-	0xf1, 0x2d, 0x63, 0x6b, 0x4c, 0x20, 0xaa, 0x95, 0x7c, 0xe3, 0x15, 0x4b, 0x02, 0x4b, 0xb7, 0x10,
-	0xe7, 0xd0, 0xdb, 0xb0, 0x8a, 0x15, 0x5a, 0xaa, 0x24, 0x4c, 0x83, 0x69, 0x7f, 0x76, 0x91, 0xed,
-	0xbb, 0x64, 0xb6, 0x43, 0xf6, 0xe2, 0x04, 0x0f, 0x42, 0xab, 0x5d, 0xbe, 0xd7, 0xe3, 0x08, 0x7a,
-	0x6b, 0xa6, 0x09, 0x25, 0x9a, 0x24, 0x9d, 0xd4, 0x9b, 0x0e, 0xf2, 0x3d, 0x1e, 0xdd, 0xc2, 0xf0,
-	0xc7, 0x35, 0x3c, 0x86, 0x60, 0xc5, 0x76, 0xce, 0xa7, 0x29, 0xf1, 0x04, 0x3a, 0xef, 0xa4, 0xda,
-	0xb6, 0x4e, 0x1b, 0x30, 0xf7, 0xaf, 0xbd, 0xc9, 0x87, 0x07, 0xd1, 0xb3, 0x33, 0xf8, 0x97, 0xf1,
-	0xc6, 0xd0, 0xe7, 0xa5, 0xe0, 0x9a, 0x4b, 0xf1, 0xca, 0xa9, 0x1b, 0x11, 0x5a, 0x6a, 0x41, 0xf1,
-	0x0c, 0x7a, 0x45, 0x25, 0xb7, 0xd4, 0x9c, 0x86, 0xcd, 0x02, 0x2c, 0x5e, 0x50, 0xbc, 0x84, 0x70,
-	0x29, 0xa5, 0xb6, 0x03, 0xf4, 0x67, 0x78, 0x30, 0xfc, 0x13, 0xd3, 0x77, 0x52, 0xea, 0xdc, 0x9e,
-	0xe3, 0x39, 0x40, 0xc9, 0x04, 0x53, 0xbc, 0x30, 0x8f, 0x74, 0xed, 0x23, 0xb1, 0x63, 0x16, 0x74,
-	0xf2, 0xe9, 0x41, 0xe4, 0x2e, 0xe0, 0x29, 0x74, 0x57, 0x4c, 0x09, 0x56, 0x39, 0xdb, 0x0e, 0x19,
-	0x9e, 0x0b, 0xae, 0x15, 0x4d, 0xfc, 0x34, 0x30, 0x7c, 0x83, 0xf0, 0x06, 0xa2, 0x62, 0x4d, 0x2b,
-	0x2e, 0x4c, 0x3a, 0x26, 0x82, 0xf1, 0x6f, 0x17, 0xd9, 0x7d, 0xa3, 0x68, 0x32, 0x68, 0xf5, 0x66,
-	0x1b, 0x44, 0x95, 0x1b, 0x1b, 0x5d, 0x9c, 0xdb, 0x7a, 0x34, 0x87, 0xc1, 0xa1, 0xf8, 0x3f, 0x9b,
-	0x5f, 0x76, 0xed, 0x47, 0xbb, 0xfa, 0x0e, 0x00, 0x00, 0xff, 0xff, 0xec, 0xc2, 0x7b, 0x74, 0x79,
-	0x02, 0x00, 0x00,
+	// 1088 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xa4, 0x56, 0xcd, 0x6e, 0xdb, 0xc6,
+	0x13, 0x07, 0x69, 0x51, 0x14, 0x47, 0xb6, 0xe2, 0xff, 0x22, 0x30, 0x36, 0xfa, 0x27, 0xb5, 0xa0,
+	0x02, 0xad, 0x80, 0x06, 0x3a, 0x38, 0x41, 0x91, 0xb8, 0xa7, 0x7e, 0xa4, 0x8d, 0x80, 0xa4, 0x08,
+	0x68, 0xa0, 0x57, 0x61, 0x45, 0xae, 0xa5, 0x85, 0x49, 0x2e, 0xbb, 0xbb, 0x74, 0xe0, 0x3c, 0x50,
+	0xdf, 0xa2, 0x3d, 0xf5, 0xd8, 0x77, 0xe8, 0x63, 0xf4, 0x5a, 0xec, 0x17, 0x45, 0xd6, 0x31, 0x50,
+	0xa3, 0xb7, 0xfd, 0xcd, 0xcc, 0x0e, 0xe7, 0xe3, 0x37, 0xb3, 0x84, 0x23, 0xa9, 0xb8, 0x20, 0x5b,
+	0xba, 0xac, 0x05, 0x57, 0x1c, 0x25, 0x0e, 0xd6, 0x9b, 0xf9, 0x5f, 0x21, 0x44, 0x3f, 0x08, 0xde,
+	0xd4, 0x68, 0x02, 0x21, 0xcb, 0x71, 0x30, 0x0b, 0x16, 0x49, 0x1a, 0xb2, 0x1c, 0x21, 0x18, 0x54,
+	0xa4, 0xa4, 0x38, 0x34, 0x12, 0x73, 0x46, 0x18, 0xe2, 0x5a, 0xf0, 0x4b, 0x56, 0x50, 0x7c, 0x60,
+	0xc4, 0x1e, 0xa2, 0x73, 0x18, 0x49, 0x5a, 0xd0, 0x4c, 0x71, 0x81, 0x07, 0xb3, 0x83, 0xc5, 0xf8,
+	0xec, 0x93, 0x65, 0xfb, 0x95, 0xa5, 0xf9, 0xc2, 0xf2, 0xc2, 0x19, 0xbc, 0xaa, 0x94, 0xb8, 0x49,
+	0x5b, 0x7b, 0x34, 0x85, 0x51, 0x49, 0x15, 0xc9, 0x89, 0x22, 0x38, 0x9a, 0x05, 0x8b, 0xc3, 0xb4,
+	0xc5, 0xe8, 0x3b, 0x38, 0xf6, 0xe7, 0xb5, 0xe4, 0x8d, 0xc8, 0xa8, 0xc4, 0x43, 0xe3, 0xff, 0x51,
+	0xc7, 0xff, 0x5b, 0x67, 0x72, 0x61, 0x2c, 0xd2, 0x07, 0x65, 0x0f, 0x4b, 0xf4, 0x14, 0x62, 0xc1,
+	0x8b, 0x82, 0x37, 0x0a, 0xc7, 0xb3, 0x60, 0x31, 0x3e, 0x43, 0x9d, 0xcb, 0xa9, 0xd5, 0xa4, 0xde,
+	0x04, 0x7d, 0x0e, 0x0f, 0x5c, 0x5a, 0xeb, 0x6b, 0x2a, 0x24, 0xe3, 0x15, 0x1e, 0xcd, 0x82, 0x45,
+	0x94, 0x4e, 0x9c, 0xf8, 0x27, 0x2b, 0x9d, 0x7e, 0x05, 0x47, 0xbd, 0x9c, 0xd0, 0x31, 0x1c, 0x5c,
+	0xd1, 0x1b, 0x57, 0x44, 0x7d, 0x44, 0x0f, 0x21, 0xba, 0x26, 0x45, 0xe3, 0xcb, 0x68, 0xc1, 0x79,
+	0xf8, 0x22, 0x98, 0x2b, 0x88, 0xdd, 0x97, 0xbb, 0x65, 0x0d, 0xfa, 0x65, 0x7d, 0x08, 0x91, 0x54,
+	0x44, 0x28, 0x7f, 0xdd, 0x00, 0xf4, 0x04, 0x60, 0x43, 0x54, 0xb6, 0x5b, 0x4b, 0xf6, 0xc1, 0x76,
+	0x22, 0x4a, 0x13, 0x23, 0xb9, 0x60, 0x1f, 0xa8, 0xae, 0x27, 0xab, 0x14, 0x15, 0xd7, 0xa4, 0xc0,
+	0x03, 0x73, 0xaf, 0xc5, 0xf3, 0xe7, 0x30, 0xe9, 0x17, 0x4b, 0xc7, 0xdc, 0x88, 0xc2, 0xc7, 0xdc,
+	0x88, 0xc2, 0x67, 0x11, 0xb6, 0x59, 0xcc, 0xff, 0x38, 0x80, 0xf8, 0x9d, 0x0b, 0xe9, 0xdf, 0xf0,
+	0xe4, 0x14, 0xc6, 0x6c, 0x5b, 0x31, 0xc5, 0x78, 0xb5, 0x66, 0xb9, 0xe3, 0x0a, 0x78, 0xd1, 0x2a,
+	0x47, 0x8f, 0x60, 0x94, 0x15, 0xbc, 0xc9, 0xb5, 0xd6, 0x86, 0x18, 0x1b, 0xbc, 0xca, 0xd1, 0x67,
+	0x30, 0xd8, 0x70, 0xae, 0x70, 0x74, 0xab, 0x51, 0x3f, 0x52, 0xf5, 0x0d, 0xe7, 0x2a, 0x35, 0x7a,
+	0x5d, 0x84, 0x2d, 0xad, 0xa8, 0x60, 0x99, 0x76, 0x32, 0x34, 0x4e, 0x12, 0x27, 0x59, 0xe5, 0x68,
+	0x01, 0x43, 0x22, 0x25, 0x55, 0x12, 0xc7, 0x86, 0x2e, 0xc7, 0x1d, 0x47, 0x5f, 0x6b, 0x45, 0xea,
+	0xf4, 0xe8, 0x53, 0x38, 0x62, 0x95, 0x54, 0xa4, 0x28, 0xd6, 0x05, 0x2b, 0x99, 0x72, 0xcd, 0x3e,
+	0x74, 0xc2, 0x37, 0x5a, 0x86, 0xbe, 0x80, 0xff, 0xb5, 0x19, 0xc9, 0x8a, 0xd5, 0xb5, 0xf6, 0x9c,
+	0xcc, 0x0e, 0x16, 0x49, 0x7a, 0xec, 0x15, 0x17, 0x4e, 0xae, 0x09, 0xb4, 0x27, 0x6d, 0xb6, 0xa3,
+	0x25, 0xc1, 0x60, 0x78, 0x3d, 0x69, 0x89, 0x69, 0xa4, 0xe8, 0x25, 0xc4, 0x3b, 0x4a, 0x72, 0x2a,
+	0x24, 0x1e, 0x9b, 0x28, 0x4f, 0x3b, 0x51, 0xba, 0x82, 0x2f, 0x5f, 0x5b, 0x0b, 0x3b, 0x35, 0xde,
+	0x7e, 0x7a, 0x0e, 0x87, 0x5d, 0xc5, 0xbd, 0xa8, 0xf7, 0x5b, 0x00, 0x93, 0x77, 0x3d, 0x2a, 0x6b,
+	0x0a, 0x7a, 0xae, 0x07, 0x26, 0x7d, 0x0f, 0xf5, 0xec, 0x78, 0x72, 0x86, 0xb7, 0x5a, 0xe2, 0xbc,
+	0xec, 0x09, 0xab, 0xb9, 0xe7, 0xca, 0xe1, 0xda, 0xde, 0x62, 0x1d, 0x90, 0x69, 0xb2, 0xeb, 0xb8,
+	0x05, 0xfa, 0xcb, 0xae, 0x6b, 0xa6, 0xe5, 0x49, 0xea, 0xa1, 0xd6, 0x64, 0x82, 0x12, 0x45, 0x7d,
+	0x7b, 0x3d, 0x9c, 0xff, 0x1e, 0x40, 0xec, 0xd8, 0x80, 0x4e, 0x60, 0x78, 0x45, 0x45, 0x45, 0x3d,
+	0x85, 0x1d, 0xd2, 0x72, 0x56, 0x31, 0x25, 0x72, 0x1c, 0x9a, 0x36, 0x39, 0xa4, 0x6b, 0x9e, 0x95,
+	0x79, 0xc1, 0x2a, 0x3d, 0x39, 0xff, 0xac, 0xb9, 0x73, 0xba, 0xfc, 0xd6, 0x5a, 0xb8, 0x9a, 0x3b,
+	0x7b, 0x4d, 0x75, 0x22, 0xb6, 0xd2, 0x2c, 0xb8, 0x24, 0x35, 0x67, 0xdd, 0x87, 0xae, 0xf1, 0xbd,
+	0xfa, 0xb0, 0x82, 0xc8, 0x50, 0x51, 0x3b, 0xae, 0x89, 0xda, 0xb9, 0x5b, 0xe6, 0xec, 0xe7, 0x32,
+	0xdc, 0xcf, 0xe5, 0x14, 0x46, 0xd9, 0x8e, 0x66, 0x57, 0xb2, 0x29, 0x7d, 0x6d, 0x3d, 0x9e, 0xff,
+	0x3a, 0x80, 0xf8, 0x2d, 0xc9, 0x76, 0xac, 0xba, 0x3d, 0xa1, 0x5f, 0xc2, 0xb0, 0x20, 0x1b, 0x5a,
+	0x48, 0x1c, 0xde, 0xda, 0xcc, 0xee, 0xce, 0xf2, 0x8d, 0x31, 0xb0, 0xf9, 0x3a, 0x6b, 0xb7, 0x7c,
+	0x94, 0xdf, 0xf5, 0x16, 0xa0, 0xc7, 0x90, 0x64, 0xbc, 0xac, 0x0b, 0xaa, 0xa8, 0xef, 0xe4, 0x5e,
+	0x60, 0x56, 0x19, 0xb9, 0x29, 0x38, 0xc9, 0xdd, 0x2a, 0xf7, 0x50, 0x7b, 0xdb, 0xea, 0x67, 0xc0,
+	0xf5, 0xd2, 0x02, 0x9d, 0xe5, 0xa6, 0xcc, 0xcc, 0x56, 0x4e, 0x52, 0x7d, 0x44, 0xcf, 0x20, 0xba,
+	0x24, 0x99, 0x92, 0x78, 0x64, 0x82, 0x7d, 0xf2, 0x91, 0x60, 0xbf, 0xd7, 0x7a, 0x1b, 0xab, 0xb5,
+	0xd5, 0xce, 0xf9, 0xfb, 0x8a, 0x0a, 0x9c, 0x58, 0xe7, 0x06, 0xe8, 0xb2, 0xbe, 0x67, 0x35, 0x35,
+	0xc3, 0x37, 0x4a, 0xcd, 0x19, 0xcd, 0xe1, 0x30, 0xa7, 0x19, 0x2f, 0x4b, 0x26, 0x0d, 0xdb, 0xc7,
+	0xe6, 0x42, 0x4f, 0x86, 0xce, 0x60, 0x54, 0x0b, 0xbe, 0x15, 0x54, 0x4a, 0x7c, 0x68, 0xa2, 0x38,
+	0xb9, 0x1d, 0xc5, 0x85, 0xa2, 0x75, 0xda, 0xda, 0xe9, 0xe6, 0x10, 0xa5, 0x68, 0x59, 0x2b, 0x89,
+	0x8f, 0xcc, 0x04, 0xb5, 0x18, 0x3d, 0x85, 0x48, 0xaf, 0x2c, 0x89, 0x27, 0x77, 0x39, 0x33, 0x7b,
+	0xcd, 0x1a, 0x4d, 0x5f, 0xc2, 0xb8, 0xd3, 0x8d, 0xfb, 0x10, 0x6a, 0xfa, 0x02, 0x60, 0x5f, 0x9b,
+	0x7b, 0x51, 0x71, 0x0b, 0xe3, 0x4e, 0x5e, 0xed, 0x52, 0x0f, 0x3a, 0x4b, 0xfd, 0x04, 0x86, 0x9a,
+	0x01, 0x8d, 0x74, 0xb7, 0x1d, 0xd2, 0x2d, 0x2f, 0xa9, 0x94, 0x64, 0xdb, 0xfe, 0x14, 0x38, 0xa8,
+	0xbd, 0x28, 0x56, 0x52, 0xc7, 0x12, 0x73, 0x9e, 0xff, 0x12, 0xc0, 0xb8, 0x93, 0x74, 0x6b, 0x13,
+	0xec, 0x6d, 0x74, 0x2d, 0x69, 0x95, 0xd7, 0x9c, 0x55, 0xfe, 0xe1, 0x6b, 0x71, 0x27, 0x0a, 0xfb,
+	0xee, 0xf9, 0x28, 0x5a, 0x7a, 0x0d, 0xba, 0xf4, 0xea, 0xbc, 0xac, 0x51, 0xff, 0x65, 0x3d, 0x85,
+	0x71, 0xc6, 0xab, 0x4b, 0xb6, 0x5d, 0xef, 0x88, 0xdc, 0x39, 0x52, 0x82, 0x15, 0xbd, 0x26, 0x72,
+	0x37, 0xff, 0x33, 0x84, 0xe8, 0xd5, 0x35, 0xad, 0x3e, 0x1e, 0xa2, 0x96, 0xdd, 0xd4, 0xed, 0xab,
+	0xa7, 0xcf, 0xfa, 0x45, 0x2a, 0x6d, 0x66, 0xfb, 0x47, 0x2f, 0x71, 0x92, 0x55, 0xde, 0xcb, 0x6a,
+	0x70, 0x67, 0x56, 0x51, 0x2f, 0xab, 0xe7, 0xed, 0xe8, 0xda, 0x9f, 0x9e, 0xc7, 0x1d, 0xea, 0x98,
+	0xe0, 0xee, 0x1a, 0x5c, 0x5b, 0x8b, 0xf8, 0x8e, 0x5a, 0x8c, 0xfa, 0xb5, 0xf8, 0x3f, 0x24, 0x82,
+	0x96, 0x5c, 0xd1, 0x35, 0xab, 0xdd, 0x04, 0x8d, 0xac, 0x60, 0x55, 0xeb, 0xac, 0x04, 0xfd, 0xb9,
+	0xa1, 0x52, 0xe9, 0xac, 0xc0, 0x66, 0xe5, 0x24, 0xab, 0xfc, 0x3f, 0xb0, 0x75, 0x33, 0x34, 0x7f,
+	0xa3, 0xcf, 0xfe, 0x0e, 0x00, 0x00, 0xff, 0xff, 0xdb, 0x96, 0xd7, 0x5d, 0x9e, 0x0a, 0x00, 0x00,
 }
@@ -0,0 +1,121 @@
+package storagepb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// yamlLineRegexp matches the line of YAML syntax errors.
+var yamlLineRegexp = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// IsYAML returns true if a Group or Profile file name has a .yaml or .yml
+// extension.
+func IsYAML(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// ParseGroupFile parses the contents of a Group file, as YAML if the name
+// has a YAML extension, or as JSON.
+func ParseGroupFile(name string, data []byte) (*Group, error) {
+	if !IsYAML(name) {
+		return ParseGroup(data)
+	}
+	jsonData, err := yamlToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	group, err := ParseGroup(jsonData)
+	if err != nil {
+		return nil, yamlError(data, err)
+	}
+	return group, nil
+}
+
+// ParseProfileFile parses the contents of a Profile file, as YAML if the
+// name has a YAML extension, or as JSON.
+func ParseProfileFile(name string, data []byte) (*Profile, error) {
+	if !IsYAML(name) {
+		return ParseProfile(data)
+	}
+	jsonData, err := yamlToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := ParseProfile(jsonData)
+	if err != nil {
+		return nil, yamlError(data, err)
+	}
+	return profile, nil
+}
+
+// JSONToYAML converts a JSON Group or Profile to YAML.
+func JSONToYAML(data []byte) ([]byte, error) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(value)
+}
+
+// yamlToJSON converts a YAML document to JSON. YAML syntax errors are
+// ParseErrors at their line.
+func yamlToJSON(data []byte) ([]byte, error) {
+	var value interface{}
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		if m := yamlLineRegexp.FindStringSubmatch(err.Error()); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			return nil, &ParseError{Line: line, Err: errors.New(m[2])}
+		}
+		return nil, &ParseError{Err: err}
+	}
+	return json.Marshal(JSONValue(value))
+}
+
+// JSONValue converts the maps of a decoded YAML value, which may have keys
+// of any type, to maps with string keys, as in decoded JSON.
+func JSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			m[fmt.Sprint(key)] = JSONValue(val)
+		}
+		return m
+	case []interface{}:
+		for i, val := range v {
+			v[i] = JSONValue(val)
+		}
+	}
+	return value
+}
+
+// yamlError positions an error parsing YAML converted to JSON at the key of
+// the problem in the YAML, since JSON positions don't apply.
+func yamlError(data []byte, err error) error {
+	perr, ok := err.(*ParseError)
+	if !ok {
+		return err
+	}
+	line, column := 0, 0
+	if perr.key != "" {
+		re := regexp.MustCompile(`(?m)^[ \t]*(?:-[ \t]+)?(["']?` + regexp.QuoteMeta(perr.key) + `["']?)[ \t]*:`)
+		if loc := re.FindSubmatchIndex(data); loc != nil {
+			before := data[:loc[2]]
+			line = bytes.Count(before, []byte("\n")) + 1
+			column = len(before) - bytes.LastIndexByte(before, '\n')
+		}
+	}
+	return &ParseError{Line: line, Column: column, Err: perr.Err}
+}
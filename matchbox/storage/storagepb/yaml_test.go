@@ -0,0 +1,86 @@
+package storagepb
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsYAML(t *testing.T) {
+	assert.True(t, IsYAML("groups/node1.yaml"))
+	assert.True(t, IsYAML("profiles/worker.YML"))
+	assert.False(t, IsYAML("groups/node1.json"))
+	assert.False(t, IsYAML("groups/yaml"))
+}
+
+func TestParseGroupFile(t *testing.T) {
+	data := []byte(`# comments are allowed
+id: node1
+profile: worker
+selector:
+  mac: 52:54:00:A1:9C:AE
+  rack: "4"
+metadata:
+  k8s_version: v1.5.2
+  ssh_authorized_keys:
+    - ssh-rsa AAAA
+`)
+	// assert that:
+	// - YAML and JSON files are parsed by extension
+	group, err := ParseGroupFile("node1.yaml", data)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "node1", group.Id)
+		assert.Equal(t, map[string]string{"mac": "52:54:00:a1:9c:ae", "rack": "4"}, group.Selector)
+		assert.Equal(t, []byte(`{"k8s_version":"v1.5.2","ssh_authorized_keys":["ssh-rsa AAAA"]}`), group.Metadata)
+	}
+	_, err = ParseGroupFile("node1.json", data)
+	assert.Error(t, err)
+	group, err = ParseGroupFile("node1.json", []byte(`{"id":"node1","profile":"worker"}`))
+	if assert.Nil(t, err) {
+		assert.Equal(t, "node1", group.Id)
+	}
+}
+
+func TestParseFile_YAMLErrors(t *testing.T) {
+	cases := []struct {
+		yaml    string
+		profile bool
+		err     string
+	}{
+		{"id: node1\nprofile: worker\nselecter:\n  os: installed\n", false, `line 3, column 1: json: unknown field "selecter"`},
+		{"id: node1\nselector:\n  rack: 4\n", false, "line 3, column 3: json: cannot unmarshal number into Go struct field RichGroup.selector.rack of type string"},
+		{"id: node1\nselector:\n  mac: 52:54:00\n", false, "line 3, column 3: selector mac: address 52:54:00: invalid MAC address"},
+		{"id: node1\nprofile: [worker\n", false, "line 2: did not find expected ',' or ']'"},
+		{"id: worker\nboot:\n  kernal: /assets/kernel\n", true, `line 3, column 3: json: unknown field "kernal"`},
+	}
+	// assert that:
+	// - errors are positioned at the key of the problem in the YAML
+	// - YAML syntax errors are positioned at their line
+	for _, c := range cases {
+		var err error
+		if c.profile {
+			_, err = ParseProfileFile("worker.yaml", []byte(c.yaml))
+		} else {
+			_, err = ParseGroupFile("node1.yaml", []byte(c.yaml))
+		}
+		if assert.Error(t, err) {
+			assert.Equal(t, c.err, err.Error())
+		}
+	}
+}
+
+func TestJSONToYAML(t *testing.T) {
+	// assert that:
+	// - JSON Groups converted to YAML parse as the same Group
+	group := &Group{Id: "node1", Profile: "worker", Selector: map[string]string{"rack": "4"}, Metadata: []byte(`{"count":3}`)}
+	rich, err := group.ToRichGroup()
+	assert.Nil(t, err)
+	data, err := json.Marshal(rich)
+	assert.Nil(t, err)
+	yamlData, err := JSONToYAML(data)
+	assert.Nil(t, err)
+	parsed, err := ParseGroupFile("node1.yaml", yamlData)
+	assert.Nil(t, err)
+	assert.Equal(t, group, parsed)
+}
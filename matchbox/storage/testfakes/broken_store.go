@@ -2,6 +2,7 @@ package testfakes
 
 import (
 	"errors"
+	"time"
 
 	"github.com/coreos/matchbox/matchbox/storage/storagepb"
 )
@@ -28,6 +29,11 @@ func (s *BrokenStore) GroupList() (groups []*storagepb.Group, err error) {
 	return groups, errIntentional
 }
 
+// GroupDelete returns an error.
+func (s *BrokenStore) GroupDelete(id string) error {
+	return errIntentional
+}
+
 // ProfilePut returns an error.
 func (s *BrokenStore) ProfilePut(profile *storagepb.Profile) error {
 	return errIntentional
@@ -43,6 +49,26 @@ func (s *BrokenStore) ProfileList() (profiles []*storagepb.Profile, err error) {
 	return profiles, errIntentional
 }
 
+// ProfileDelete returns an error.
+func (s *BrokenStore) ProfileDelete(id string) error {
+	return errIntentional
+}
+
+// ProfileVersionPut returns an error.
+func (s *BrokenStore) ProfileVersionPut(version *storagepb.ProfileVersion) error {
+	return errIntentional
+}
+
+// ProfileVersionGet returns an error.
+func (s *BrokenStore) ProfileVersionGet(id string, version int32) (*storagepb.ProfileVersion, error) {
+	return nil, errIntentional
+}
+
+// ProfileVersionList returns an error.
+func (s *BrokenStore) ProfileVersionList(id string) ([]*storagepb.ProfileVersion, error) {
+	return nil, errIntentional
+}
+
 // IgnitionPut returns an error.
 func (s *BrokenStore) IgnitionPut(name string, config []byte) error {
 	return errIntentional
@@ -53,12 +79,87 @@ func (s *BrokenStore) IgnitionGet(name string) (string, error) {
 	return "", errIntentional
 }
 
+// IgnitionList returns an error.
+func (s *BrokenStore) IgnitionList() ([]string, error) {
+	return nil, errIntentional
+}
+
+// IgnitionDelete returns an error.
+func (s *BrokenStore) IgnitionDelete(name string) error {
+	return errIntentional
+}
+
+// CloudPut returns an error.
+func (s *BrokenStore) CloudPut(name string, config []byte) error {
+	return errIntentional
+}
+
 // CloudGet returns an error.
 func (s *BrokenStore) CloudGet(name string) (string, error) {
 	return "", errIntentional
 }
 
+// CloudList returns an error.
+func (s *BrokenStore) CloudList() ([]string, error) {
+	return nil, errIntentional
+}
+
+// CloudDelete returns an error.
+func (s *BrokenStore) CloudDelete(name string) error {
+	return errIntentional
+}
+
+// GenericPut returns an error.
+func (s *BrokenStore) GenericPut(name string, config []byte) error {
+	return errIntentional
+}
+
 // GenericGet returns an error.
 func (s *BrokenStore) GenericGet(name string) (string, error) {
 	return "", errIntentional
 }
+
+// GenericList returns an error.
+func (s *BrokenStore) GenericList() ([]string, error) {
+	return nil, errIntentional
+}
+
+// GenericDelete returns an error.
+func (s *BrokenStore) GenericDelete(name string) error {
+	return errIntentional
+}
+
+// MachinePut returns an error.
+func (s *BrokenStore) MachinePut(machine *storagepb.Machine) error {
+	return errIntentional
+}
+
+// MachineGet returns an error.
+func (s *BrokenStore) MachineGet(id string) (*storagepb.Machine, error) {
+	return nil, errIntentional
+}
+
+// MachineList returns an error.
+func (s *BrokenStore) MachineList() (machines []*storagepb.Machine, err error) {
+	return machines, errIntentional
+}
+
+// MachineArchive returns an error.
+func (s *BrokenStore) MachineArchive(machine *storagepb.Machine) error {
+	return errIntentional
+}
+
+// EventPut returns an error.
+func (s *BrokenStore) EventPut(event *storagepb.Event) error {
+	return errIntentional
+}
+
+// EventList returns an error.
+func (s *BrokenStore) EventList(since, until time.Time) (events []*storagepb.Event, err error) {
+	return events, errIntentional
+}
+
+// EventPrune returns an error.
+func (s *BrokenStore) EventPrune(before time.Time) error {
+	return errIntentional
+}
@@ -2,6 +2,7 @@ package testfakes
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/coreos/matchbox/matchbox/storage/storagepb"
 )
@@ -24,6 +25,11 @@ func (s *EmptyStore) GroupList() (groups []*storagepb.Group, err error) {
 	return groups, nil
 }
 
+// GroupDelete returns a Group not found error.
+func (s *EmptyStore) GroupDelete(id string) error {
+	return fmt.Errorf("no Group %s", id)
+}
+
 // ProfilePut returns an error writing any Profile.
 func (s *EmptyStore) ProfilePut(profile *storagepb.Profile) error {
 	return fmt.Errorf("emptyStore does not accept Profiles")
@@ -39,6 +45,26 @@ func (s *EmptyStore) ProfileList() (profiles []*storagepb.Profile, err error) {
 	return profiles, nil
 }
 
+// ProfileDelete returns a Profile not found error.
+func (s *EmptyStore) ProfileDelete(id string) error {
+	return fmt.Errorf("no Profile %s", id)
+}
+
+// ProfileVersionPut returns an error writing any Profile snapshot.
+func (s *EmptyStore) ProfileVersionPut(version *storagepb.ProfileVersion) error {
+	return fmt.Errorf("emptyStore does not accept Profile versions")
+}
+
+// ProfileVersionGet returns a Profile version not found error.
+func (s *EmptyStore) ProfileVersionGet(id string, version int32) (*storagepb.ProfileVersion, error) {
+	return nil, fmt.Errorf("no Profile %s version %d", id, version)
+}
+
+// ProfileVersionList returns an empty list of Profile versions.
+func (s *EmptyStore) ProfileVersionList(id string) ([]*storagepb.ProfileVersion, error) {
+	return []*storagepb.ProfileVersion{}, nil
+}
+
 // IgnitionPut returns an error writing any Ignition template.
 func (s *EmptyStore) IgnitionPut(name string, config []byte) error {
 	return fmt.Errorf("emptyStore does not accept Ignition templates")
@@ -49,12 +75,87 @@ func (s *EmptyStore) IgnitionGet(name string) (string, error) {
 	return "", fmt.Errorf("no Ignition template %s", name)
 }
 
+// IgnitionList returns an empty list of Ignition templates.
+func (s *EmptyStore) IgnitionList() ([]string, error) {
+	return []string{}, nil
+}
+
+// IgnitionDelete returns an Ignition template not found error.
+func (s *EmptyStore) IgnitionDelete(name string) error {
+	return fmt.Errorf("no Ignition template %s", name)
+}
+
+// CloudPut returns an error writing any Cloud-Config template.
+func (s *EmptyStore) CloudPut(name string, config []byte) error {
+	return fmt.Errorf("emptyStore does not accept Cloud-Config templates")
+}
+
 // CloudGet returns a Cloud-config template not found error.
 func (s *EmptyStore) CloudGet(name string) (string, error) {
 	return "", fmt.Errorf("no Cloud-Config template %s", name)
 }
 
+// CloudList returns an empty list of Cloud-Config templates.
+func (s *EmptyStore) CloudList() ([]string, error) {
+	return []string{}, nil
+}
+
+// CloudDelete returns a Cloud-Config template not found error.
+func (s *EmptyStore) CloudDelete(name string) error {
+	return fmt.Errorf("no Cloud-Config template %s", name)
+}
+
+// GenericPut returns an error writing any generic template.
+func (s *EmptyStore) GenericPut(name string, config []byte) error {
+	return fmt.Errorf("emptyStore does not accept generic templates")
+}
+
 // GenericGet returns a generic template not found error.
 func (s *EmptyStore) GenericGet(name string) (string, error) {
 	return "", fmt.Errorf("no generic template %s", name)
 }
+
+// GenericList returns an empty list of generic templates.
+func (s *EmptyStore) GenericList() ([]string, error) {
+	return []string{}, nil
+}
+
+// GenericDelete returns a generic template not found error.
+func (s *EmptyStore) GenericDelete(name string) error {
+	return fmt.Errorf("no generic template %s", name)
+}
+
+// MachinePut returns an error writing any Machine.
+func (s *EmptyStore) MachinePut(machine *storagepb.Machine) error {
+	return fmt.Errorf("emptyStore does not accept Machines")
+}
+
+// MachineGet returns a machine not found error.
+func (s *EmptyStore) MachineGet(id string) (*storagepb.Machine, error) {
+	return nil, fmt.Errorf("Machine not found")
+}
+
+// MachineList returns an empty list of machines.
+func (s *EmptyStore) MachineList() (machines []*storagepb.Machine, err error) {
+	return machines, nil
+}
+
+// MachineArchive returns an error archiving any Machine.
+func (s *EmptyStore) MachineArchive(machine *storagepb.Machine) error {
+	return fmt.Errorf("emptyStore does not accept Machines")
+}
+
+// EventPut returns an error writing any Event.
+func (s *EmptyStore) EventPut(event *storagepb.Event) error {
+	return fmt.Errorf("emptyStore does not accept Events")
+}
+
+// EventList returns an empty list of events.
+func (s *EmptyStore) EventList(since, until time.Time) (events []*storagepb.Event, err error) {
+	return events, nil
+}
+
+// EventPrune returns nil, since there are no Events.
+func (s *EmptyStore) EventPrune(before time.Time) error {
+	return nil
+}
@@ -2,6 +2,8 @@ package testfakes
 
 import (
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/coreos/matchbox/matchbox/storage/storagepb"
 )
@@ -13,6 +15,10 @@ type FixedStore struct {
 	IgnitionConfigs map[string]string
 	CloudConfigs    map[string]string
 	GenericConfigs  map[string]string
+	Machines        map[string]*storagepb.Machine
+	Archive         []*storagepb.Machine
+	Versions        map[string][]*storagepb.ProfileVersion
+	Events          []*storagepb.Event
 }
 
 // NewFixedStore returns a new FixedStore.
@@ -23,6 +29,8 @@ func NewFixedStore() *FixedStore {
 		IgnitionConfigs: make(map[string]string),
 		CloudConfigs:    make(map[string]string),
 		GenericConfigs:  make(map[string]string),
+		Machines:        make(map[string]*storagepb.Machine),
+		Versions:        make(map[string][]*storagepb.ProfileVersion),
 	}
 }
 
@@ -51,6 +59,15 @@ func (s *FixedStore) GroupList() ([]*storagepb.Group, error) {
 	return groups, nil
 }
 
+// GroupDelete deletes a Group by id.
+func (s *FixedStore) GroupDelete(id string) error {
+	if _, present := s.Groups[id]; !present {
+		return fmt.Errorf("Group not found")
+	}
+	delete(s.Groups, id)
+	return nil
+}
+
 // ProfilePut writes the given Profile to the Profiles map.
 func (s *FixedStore) ProfilePut(profile *storagepb.Profile) error {
 	s.Profiles[profile.Id] = profile
@@ -76,6 +93,41 @@ func (s *FixedStore) ProfileList() ([]*storagepb.Profile, error) {
 	return profiles, nil
 }
 
+// ProfileDelete deletes a Profile by id.
+func (s *FixedStore) ProfileDelete(id string) error {
+	if _, present := s.Profiles[id]; !present {
+		return fmt.Errorf("Profile not found")
+	}
+	delete(s.Profiles, id)
+	return nil
+}
+
+// ProfileVersionPut appends the given snapshot to the Versions of its
+// Profile.
+func (s *FixedStore) ProfileVersionPut(version *storagepb.ProfileVersion) error {
+	if s.Versions == nil {
+		s.Versions = make(map[string][]*storagepb.ProfileVersion)
+	}
+	id := version.Profile.Id
+	s.Versions[id] = append(s.Versions[id], version)
+	return nil
+}
+
+// ProfileVersionGet returns a snapshot from the Versions of a Profile.
+func (s *FixedStore) ProfileVersionGet(id string, version int32) (*storagepb.ProfileVersion, error) {
+	for _, snapshot := range s.Versions[id] {
+		if snapshot.Version == version {
+			return snapshot, nil
+		}
+	}
+	return nil, fmt.Errorf("Profile version not found")
+}
+
+// ProfileVersionList returns the Versions of a Profile.
+func (s *FixedStore) ProfileVersionList(id string) ([]*storagepb.ProfileVersion, error) {
+	return append([]*storagepb.ProfileVersion{}, s.Versions[id]...), nil
+}
+
 // IgnitionPut create or updates an Ignition template.
 func (s *FixedStore) IgnitionPut(name string, config []byte) error {
 	s.IgnitionConfigs[name] = string(config)
@@ -90,6 +142,26 @@ func (s *FixedStore) IgnitionGet(name string) (string, error) {
 	return "", fmt.Errorf("no Ignition template %s", name)
 }
 
+// IgnitionList returns the sorted names of Ignition templates.
+func (s *FixedStore) IgnitionList() ([]string, error) {
+	return sortedKeys(s.IgnitionConfigs), nil
+}
+
+// IgnitionDelete deletes an Ignition template by name.
+func (s *FixedStore) IgnitionDelete(name string) error {
+	if _, present := s.IgnitionConfigs[name]; !present {
+		return fmt.Errorf("no Ignition template %s", name)
+	}
+	delete(s.IgnitionConfigs, name)
+	return nil
+}
+
+// CloudPut create or updates a Cloud-Config template.
+func (s *FixedStore) CloudPut(name string, config []byte) error {
+	s.CloudConfigs[name] = string(config)
+	return nil
+}
+
 // CloudGet returns a Cloud-config template by name.
 func (s *FixedStore) CloudGet(name string) (string, error) {
 	if config, present := s.CloudConfigs[name]; present {
@@ -98,6 +170,26 @@ func (s *FixedStore) CloudGet(name string) (string, error) {
 	return "", fmt.Errorf("no Cloud-Config template %s", name)
 }
 
+// CloudList returns the sorted names of Cloud-Config templates.
+func (s *FixedStore) CloudList() ([]string, error) {
+	return sortedKeys(s.CloudConfigs), nil
+}
+
+// CloudDelete deletes a Cloud-Config template by name.
+func (s *FixedStore) CloudDelete(name string) error {
+	if _, present := s.CloudConfigs[name]; !present {
+		return fmt.Errorf("no Cloud-Config template %s", name)
+	}
+	delete(s.CloudConfigs, name)
+	return nil
+}
+
+// GenericPut create or updates a generic template.
+func (s *FixedStore) GenericPut(name string, config []byte) error {
+	s.GenericConfigs[name] = string(config)
+	return nil
+}
+
 // GenericGet returns a generic template by name.
 func (s *FixedStore) GenericGet(name string) (string, error) {
 	if config, present := s.GenericConfigs[name]; present {
@@ -105,3 +197,90 @@ func (s *FixedStore) GenericGet(name string) (string, error) {
 	}
 	return "", fmt.Errorf("no generic template %s", name)
 }
+
+// GenericList returns the sorted names of generic templates.
+func (s *FixedStore) GenericList() ([]string, error) {
+	return sortedKeys(s.GenericConfigs), nil
+}
+
+// GenericDelete deletes a generic template by name.
+func (s *FixedStore) GenericDelete(name string) error {
+	if _, present := s.GenericConfigs[name]; !present {
+		return fmt.Errorf("no generic template %s", name)
+	}
+	delete(s.GenericConfigs, name)
+	return nil
+}
+
+// MachinePut writes the given Machine to the Machines map.
+func (s *FixedStore) MachinePut(machine *storagepb.Machine) error {
+	s.Machines[machine.Id] = machine
+	return nil
+}
+
+// MachineGet returns the Machine from the Machines map with the given id.
+func (s *FixedStore) MachineGet(id string) (*storagepb.Machine, error) {
+	if machine, present := s.Machines[id]; present {
+		return machine, nil
+	}
+	return nil, fmt.Errorf("Machine not found")
+}
+
+// MachineList returns the machines in the Machines map.
+func (s *FixedStore) MachineList() ([]*storagepb.Machine, error) {
+	machines := make([]*storagepb.Machine, len(s.Machines))
+	i := 0
+	for _, m := range s.Machines {
+		machines[i] = m
+		i++
+	}
+	return machines, nil
+}
+
+// MachineArchive appends the given Machine to the Archive and removes it
+// from the Machines map.
+func (s *FixedStore) MachineArchive(machine *storagepb.Machine) error {
+	s.Archive = append(s.Archive, machine)
+	delete(s.Machines, machine.Id)
+	return nil
+}
+
+// EventPut appends the given Event to the Events.
+func (s *FixedStore) EventPut(event *storagepb.Event) error {
+	s.Events = append(s.Events, event)
+	return nil
+}
+
+// EventList returns the Events recorded from since until before until.
+func (s *FixedStore) EventList(since, until time.Time) ([]*storagepb.Event, error) {
+	events := []*storagepb.Event{}
+	for _, event := range s.Events {
+		t, _ := event.ParseTime()
+		if (since.IsZero() || !t.Before(since)) && (until.IsZero() || t.Before(until)) {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// EventPrune removes the Events recorded before a time.
+func (s *FixedStore) EventPrune(before time.Time) error {
+	var kept []*storagepb.Event
+	for _, event := range s.Events {
+		if t, _ := event.ParseTime(); !t.Before(before) {
+			kept = append(kept, event)
+		}
+	}
+	s.Events = kept
+	return nil
+}
+
+// sortedKeys returns the sorted keys of a map.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -37,6 +37,14 @@ var (
 		GenericId:  "generic.tmpl",
 	}
 
+	// Machine is a provisioned machine for testing.
+	Machine = &storagepb.Machine{
+		Id:        "a1b2c3d4",
+		Labels:    map[string]string{"uuid": "a1b2c3d4"},
+		State:     storagepb.MachineProvisioned,
+		Completed: "2017-03-01T12:00:00Z",
+	}
+
 	// IgnitionYAMLName is an Ignition template name for testing.
 	IgnitionYAMLName = "ignition.tmpl"
 
@@ -9,6 +9,9 @@ type TLSInfo struct {
 	CAFile   string
 	CertFile string
 	KeyFile  string
+	// GetCertificate, if set, provides server certificates instead of
+	// CertFile and KeyFile (e.g. certificates obtained via ACME)
+	GetCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)
 }
 
 // ClientConfig returns a tls.Config for client use.
@@ -38,9 +41,13 @@ func (info *TLSInfo) ClientConfig() (*tls.Config, error) {
 // ServerConfig returns a tls.Config for server use.
 func (info *TLSInfo) ServerConfig() (*tls.Config, error) {
 	// server certificate to present to clients
-	cert, err := tls.LoadX509KeyPair(info.CertFile, info.KeyFile)
-	if err != nil {
-		return nil, err
+	var certs []tls.Certificate
+	if info.GetCertificate == nil {
+		cert, err := tls.LoadX509KeyPair(info.CertFile, info.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
 	}
 
 	// CA for authenticating clients
@@ -52,7 +59,8 @@ func (info *TLSInfo) ServerConfig() (*tls.Config, error) {
 	return &tls.Config{
 		MinVersion: tls.VersionTLS12,
 		// Certificates the server should present to clients
-		Certificates: []tls.Certificate{cert},
+		Certificates:   certs,
+		GetCertificate: info.GetCertificate,
 		// Client Authentication (required)
 		ClientAuth: tls.RequireAndVerifyClientCert,
 		// CA for verifying and authorizing client certificates
@@ -0,0 +1,104 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"sync"
+)
+
+// A Reloader serves the TLS certificate and client CA pool of a TLSInfo and
+// can reload them from their files. Handshakes use the latest loaded
+// credentials, while established connections are unaffected.
+type Reloader struct {
+	info *TLSInfo
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+	pool *x509.CertPool
+}
+
+// NewReloader returns a Reloader which has loaded the credentials of info.
+func NewReloader(info *TLSInfo) (*Reloader, error) {
+	r := &Reloader{info: info}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload loads the certificate, key, and CA files. If any fail to load, the
+// previous credentials are kept.
+func (r *Reloader) Reload() error {
+	var cert *tls.Certificate
+	if r.info.GetCertificate == nil && r.info.CertFile != "" {
+		pair, err := tls.LoadX509KeyPair(r.info.CertFile, r.info.KeyFile)
+		if err != nil {
+			return err
+		}
+		cert = &pair
+	}
+	var pool *x509.CertPool
+	if r.info.CAFile != "" {
+		var err error
+		pool, err = NewCertPool([]string{r.info.CAFile})
+		if err != nil {
+			return err
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = cert
+	r.pool = pool
+	return nil
+}
+
+// Paths returns the files the credentials are loaded from.
+func (r *Reloader) Paths() []string {
+	var paths []string
+	if r.info.GetCertificate == nil {
+		paths = append(paths, r.info.CertFile, r.info.KeyFile)
+	}
+	return append(paths, r.info.CAFile)
+}
+
+// GetCertificate returns the latest loaded server certificate, for use as a
+// tls.Config GetCertificate.
+func (r *Reloader) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if r.info.GetCertificate != nil {
+		return r.info.GetCertificate(hello)
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// ClientCAs returns the latest loaded CA pool for verifying clients.
+func (r *Reloader) ClientCAs() *x509.CertPool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.pool
+}
+
+// ServerConfig returns a tls.Config for server use, like TLSInfo's, which
+// presents and verifies with the latest loaded credentials.
+func (r *Reloader) ServerConfig() (*tls.Config, error) {
+	info := *r.info
+	info.GetCertificate = r.GetCertificate
+	config, err := info.ServerConfig()
+	if err != nil {
+		return nil, err
+	}
+	return r.WithClientCAs(config), nil
+}
+
+// WithClientCAs sets the config to verify client certificates with the
+// latest loaded CA pool at each handshake and returns it.
+func (r *Reloader) WithClientCAs(config *tls.Config) *tls.Config {
+	base := config.Clone()
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		handshake := base.Clone()
+		handshake.ClientCAs = r.ClientCAs()
+		return handshake, nil
+	}
+	return config
+}
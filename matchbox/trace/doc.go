@@ -0,0 +1,3 @@
+// Package trace records OpenTelemetry compatible spans, propagates W3C
+// trace context, and exports spans to an OTLP/HTTP collector.
+package trace
@@ -0,0 +1,247 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+const (
+	defaultBatchSize     = 512
+	defaultFlushInterval = 5 * time.Second
+	// spans are dropped if the queue is full rather than block requests
+	queueSize = 4096
+)
+
+// OTLPConfig configures an OTLPExporter.
+type OTLPConfig struct {
+	// OTLP/HTTP traces endpoint (e.g. http://collector:4318/v1/traces)
+	Endpoint string
+	// service.name resource attribute (defaults to matchbox)
+	ServiceName string
+	// HTTP client (defaults to http.DefaultClient)
+	Client *http.Client
+	Logger *logrus.Logger
+}
+
+// OTLPExporter batches spans and exports them to an OTLP/HTTP collector
+// using the JSON encoding.
+type OTLPExporter struct {
+	endpoint    string
+	serviceName string
+	client      *http.Client
+	logger      *logrus.Logger
+
+	queue chan *Span
+	flush chan chan struct{}
+	stop  chan struct{}
+	once  sync.Once
+}
+
+// NewOTLPExporter returns a new OTLPExporter which exports spans in the
+// background until Shutdown.
+func NewOTLPExporter(config *OTLPConfig) *OTLPExporter {
+	e := &OTLPExporter{
+		endpoint:    config.Endpoint,
+		serviceName: config.ServiceName,
+		client:      config.Client,
+		logger:      config.Logger,
+		queue:       make(chan *Span, queueSize),
+		flush:       make(chan chan struct{}),
+		stop:        make(chan struct{}),
+	}
+	if e.serviceName == "" {
+		e.serviceName = "matchbox"
+	}
+	if e.client == nil {
+		e.client = http.DefaultClient
+	}
+	if e.logger == nil {
+		e.logger = logrus.New()
+	}
+	go e.run()
+	return e
+}
+
+// ExportSpan queues an ended span for export.
+func (e *OTLPExporter) ExportSpan(span *Span) {
+	select {
+	case e.queue <- span:
+	default:
+		e.logger.Debugf("trace: export queue full, dropping span %s", span.Name)
+	}
+}
+
+// Flush exports all queued spans.
+func (e *OTLPExporter) Flush() {
+	done := make(chan struct{})
+	select {
+	case e.flush <- done:
+		<-done
+	case <-e.stop:
+	}
+}
+
+// Shutdown exports queued spans and stops the exporter.
+func (e *OTLPExporter) Shutdown() {
+	e.Flush()
+	e.once.Do(func() { close(e.stop) })
+}
+
+func (e *OTLPExporter) run() {
+	ticker := time.NewTicker(defaultFlushInterval)
+	defer ticker.Stop()
+	var batch []*Span
+	send := func() {
+		if len(batch) > 0 {
+			if err := e.send(batch); err != nil {
+				e.logger.Warningf("trace: error exporting %d spans: %v", len(batch), err)
+			}
+			batch = nil
+		}
+	}
+	for {
+		select {
+		case span := <-e.queue:
+			batch = append(batch, span)
+			if len(batch) >= defaultBatchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case done := <-e.flush:
+			for drained := false; !drained; {
+				select {
+				case span := <-e.queue:
+					batch = append(batch, span)
+				default:
+					drained = true
+				}
+			}
+			send()
+			close(done)
+		case <-e.stop:
+			return
+		}
+	}
+}
+
+// send POSTs spans to the collector.
+func (e *OTLPExporter) send(spans []*Span) error {
+	data, err := json.Marshal(e.encode(spans))
+	if err != nil {
+		return err
+	}
+	resp, err := e.client.Post(e.endpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+// OTLP JSON encoding of an ExportTraceServiceRequest
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Name              string         `json:"name"`
+		Kind              int            `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Status            *otlpStatus    `json:"status,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string                 `json:"key"`
+		Value map[string]interface{} `json:"value"`
+	}
+	otlpStatus struct {
+		// 2 is STATUS_CODE_ERROR
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+)
+
+func (e *OTLPExporter) encode(spans []*Span) *otlpRequest {
+	out := make([]otlpSpan, len(spans))
+	for i, span := range spans {
+		span.mu.Lock()
+		s := otlpSpan{
+			TraceID:           hex.EncodeToString(span.Context.TraceID[:]),
+			SpanID:            hex.EncodeToString(span.Context.SpanID[:]),
+			Name:              span.Name,
+			Kind:              span.Kind,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.EndTime.UnixNano(), 10),
+		}
+		if span.ParentID != [8]byte{} {
+			s.ParentSpanID = hex.EncodeToString(span.ParentID[:])
+		}
+		for key, value := range span.Attributes {
+			s.Attributes = append(s.Attributes, keyValue(key, value))
+		}
+		if span.Err != "" {
+			s.Status = &otlpStatus{Code: 2, Message: span.Err}
+		}
+		span.mu.Unlock()
+		out[i] = s
+	}
+	return &otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: []otlpKeyValue{keyValue("service.name", e.serviceName)},
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: "github.com/coreos/matchbox"},
+				Spans: out,
+			}},
+		}},
+	}
+}
+
+// keyValue encodes an attribute as an OTLP AnyValue.
+func keyValue(key string, value interface{}) otlpKeyValue {
+	var v map[string]interface{}
+	switch val := value.(type) {
+	case bool:
+		v = map[string]interface{}{"boolValue": val}
+	case int:
+		v = map[string]interface{}{"intValue": strconv.Itoa(val)}
+	case int64:
+		v = map[string]interface{}{"intValue": strconv.FormatInt(val, 10)}
+	case float64:
+		v = map[string]interface{}{"doubleValue": val}
+	default:
+		v = map[string]interface{}{"stringValue": fmt.Sprint(val)}
+	}
+	return otlpKeyValue{Key: key, Value: v}
+}
@@ -0,0 +1,208 @@
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Span kinds (OTLP SpanKind values)
+const (
+	KindInternal = 1
+	KindServer   = 2
+)
+
+// TraceparentHeader is the W3C trace context header.
+const TraceparentHeader = "traceparent"
+
+// SpanContext identifies a span within a trace.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+}
+
+// IsValid returns true if the trace and span ids are set.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Exporter receives ended spans.
+type Exporter interface {
+	ExportSpan(span *Span)
+}
+
+// Tracer starts spans and passes them to an Exporter when they end.
+type Tracer struct {
+	exporter Exporter
+}
+
+// NewTracer returns a new Tracer.
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+var (
+	globalMu sync.RWMutex
+	global   *Tracer
+)
+
+// SetTracer sets the Tracer used by Start. A nil Tracer disables tracing.
+func SetTracer(t *Tracer) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+	global = t
+}
+
+func getTracer() *Tracer {
+	globalMu.RLock()
+	defer globalMu.RUnlock()
+	return global
+}
+
+// Span is a timed operation within a trace. All methods are safe to call
+// on a nil Span, which is returned when tracing is disabled.
+type Span struct {
+	tracer *Tracer
+	Name   string
+	Kind   int
+	// Context identifies the span
+	Context  SpanContext
+	ParentID [8]byte
+	Start    time.Time
+
+	mu         sync.Mutex
+	EndTime    time.Time
+	Attributes map[string]interface{}
+	Err        string
+}
+
+// SetAttribute sets a string, bool, integer, or float attribute.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Attributes[key] = value
+}
+
+// SetError marks the span as failed if err is not nil.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Err = err.Error()
+}
+
+// End ends the span and exports it.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.EndTime = time.Now()
+	s.mu.Unlock()
+	s.tracer.exporter.ExportSpan(s)
+}
+
+// TraceID returns the hex trace id, or "" for a nil Span.
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.Context.TraceID[:])
+}
+
+// unexported key types prevent collisions
+type spanKey struct{}
+type remoteKey struct{}
+
+// FromContext returns the current Span of the ctx, if any.
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// WithRemoteParent returns a copy of ctx with a parent span propagated
+// from another process.
+func WithRemoteParent(ctx context.Context, sc SpanContext) context.Context {
+	if !sc.IsValid() {
+		return ctx
+	}
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// Start starts a span as a child of the ctx's span (or remote parent) and
+// returns a ctx containing it. If tracing is disabled, the ctx is returned
+// with a nil Span.
+func Start(ctx context.Context, name string, kind int) (context.Context, *Span) {
+	return StartAt(ctx, name, kind, time.Now())
+}
+
+// StartAt is like Start, but records the given start time, for operations
+// which are traced once complete.
+func StartAt(ctx context.Context, name string, kind int, start time.Time) (context.Context, *Span) {
+	tracer := getTracer()
+	if tracer == nil {
+		return ctx, nil
+	}
+	span := &Span{
+		tracer:     tracer,
+		Name:       name,
+		Kind:       kind,
+		Start:      start,
+		Attributes: make(map[string]interface{}),
+	}
+	if parent := FromContext(ctx); parent != nil {
+		span.Context.TraceID = parent.Context.TraceID
+		span.ParentID = parent.Context.SpanID
+	} else if remote, ok := ctx.Value(remoteKey{}).(SpanContext); ok {
+		span.Context.TraceID = remote.TraceID
+		span.ParentID = remote.SpanID
+	} else {
+		rand.Read(span.Context.TraceID[:])
+	}
+	rand.Read(span.Context.SpanID[:])
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Record records a completed internal span which began at start.
+func Record(ctx context.Context, name string, start time.Time, err error) {
+	_, span := StartAt(ctx, name, KindInternal, start)
+	span.SetError(err)
+	span.End()
+}
+
+// Traceparent formats the span as a W3C traceparent header value.
+func (s *Span) Traceparent() string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-01", hex.EncodeToString(s.Context.TraceID[:]), hex.EncodeToString(s.Context.SpanID[:]))
+}
+
+// ParseTraceparent parses a W3C traceparent header value.
+func ParseTraceparent(value string) (SpanContext, bool) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return sc, false
+	}
+	traceID, err := hex.DecodeString(parts[1])
+	if err != nil || len(traceID) != 16 {
+		return sc, false
+	}
+	spanID, err := hex.DecodeString(parts[2])
+	if err != nil || len(spanID) != 8 {
+		return sc, false
+	}
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	return sc, sc.IsValid()
+}
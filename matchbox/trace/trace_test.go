@@ -0,0 +1,117 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder is an Exporter which records spans.
+type recorder struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func (r *recorder) ExportSpan(span *Span) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, span)
+}
+
+func TestStart_Disabled(t *testing.T) {
+	SetTracer(nil)
+	ctx, span := Start(context.Background(), "op", KindInternal)
+	// assert that:
+	// - no span is started and nil Span methods are no-ops
+	assert.Nil(t, span)
+	assert.Nil(t, FromContext(ctx))
+	span.SetAttribute("a", "b")
+	span.SetError(errors.New("error"))
+	span.End()
+	assert.Equal(t, "", span.TraceID())
+}
+
+func TestStart_Children(t *testing.T) {
+	rec := &recorder{}
+	SetTracer(NewTracer(rec))
+	defer SetTracer(nil)
+
+	remote, ok := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.True(t, ok)
+	ctx := WithRemoteParent(context.Background(), remote)
+	ctx, root := Start(ctx, "GET /ignition", KindServer)
+	Record(ctx, "render", time.Now(), errors.New("bad template"))
+	root.End()
+
+	// assert that:
+	// - the root span continues the remote trace
+	// - child spans share the trace and have the root as parent
+	if assert.Len(t, rec.spans, 2) {
+		child, server := rec.spans[0], rec.spans[1]
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.TraceID())
+		assert.Equal(t, remote.SpanID, server.ParentID)
+		assert.Equal(t, server.Context.TraceID, child.Context.TraceID)
+		assert.Equal(t, server.Context.SpanID, child.ParentID)
+		assert.Equal(t, "bad template", child.Err)
+	}
+	parsed, ok := ParseTraceparent(root.Traceparent())
+	assert.True(t, ok)
+	assert.Equal(t, root.Context, parsed)
+}
+
+func TestParseTraceparent(t *testing.T) {
+	cases := []struct {
+		value string
+		valid bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"00-4bf92f35-00f067aa0ba902b7-01", false},
+		{"garbage", false},
+	}
+	for _, c := range cases {
+		_, ok := ParseTraceparent(c.value)
+		assert.Equal(t, c.valid, ok, c.value)
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	received := make(chan *otlpRequest, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body := new(otlpRequest)
+		assert.Nil(t, json.NewDecoder(req.Body).Decode(body))
+		received <- body
+	}))
+	defer collector.Close()
+
+	exporter := NewOTLPExporter(&OTLPConfig{Endpoint: collector.URL})
+	SetTracer(NewTracer(exporter))
+	defer SetTracer(nil)
+	_, span := Start(context.Background(), "GET /ipxe", KindServer)
+	span.SetAttribute("http.status_code", 200)
+	span.End()
+	exporter.Shutdown()
+
+	body := <-received
+	// assert that:
+	// - spans are exported with the service name and attributes
+	if assert.Len(t, body.ResourceSpans, 1) {
+		rs := body.ResourceSpans[0]
+		assert.Equal(t, "matchbox", rs.Resource.Attributes[0].Value["stringValue"])
+		spans := rs.ScopeSpans[0].Spans
+		if assert.Len(t, spans, 1) {
+			assert.Equal(t, "GET /ipxe", spans[0].Name)
+			assert.Equal(t, KindServer, spans[0].Kind)
+			assert.Equal(t, span.TraceID(), spans[0].TraceID)
+			assert.Equal(t, "200", spans[0].Attributes[0].Value["intValue"])
+		}
+	}
+}
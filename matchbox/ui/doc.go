@@ -0,0 +1,4 @@
+// Package ui serves a read-only web dashboard of groups, profiles,
+// machines, recent boot events, and rendered config previews, for
+// operators who don't use bootcmd.
+package ui
@@ -0,0 +1,257 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/coreos/matchbox/matchbox/audit"
+	"github.com/coreos/matchbox/matchbox/manifest"
+	"github.com/coreos/matchbox/matchbox/oidc"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+)
+
+// Possible editor errors
+var (
+	errEditDisabled   = errors.New("ui: saving templates is disabled")
+	errTemplateErrors = errors.New("ui: template has errors, not saved")
+	errCrossOrigin    = errors.New("ui: cross-origin requests are not allowed")
+	errTokenRequired  = errors.New("ui: an ID token is required to save templates")
+	errInvalidToken   = errors.New("ui: invalid ID token")
+	errRoleRequired   = errors.New("ui: saving templates requires the editor role")
+)
+
+// templateSet is the names of the templates of a kind.
+type templateSet struct {
+	Kind  string
+	Names []string
+}
+
+// templates lists templates by kind, or redirects to the editor of the
+// template named by the new template form.
+func (d *Dashboard) templates(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	if kind, name := query.Get("kind"), query.Get("name"); validKind(kind) && name != "" {
+		http.Redirect(w, req, "/ui/templates/"+kind+"/"+url.PathEscape(name), http.StatusFound)
+		return
+	}
+	ctx := req.Context()
+	sets := make([]templateSet, 0, len(manifest.Kinds))
+	for _, kind := range manifest.Kinds {
+		names, err := d.templateList(ctx, kind)
+		if err != nil {
+			d.fail(w, err)
+			return
+		}
+		sort.Strings(names)
+		sets = append(sets, templateSet{Kind: kind, Names: names})
+	}
+	d.render(w, templatesPage, map[string]interface{}{
+		"Templates": sets,
+		"Kinds":     manifest.Kinds,
+	})
+}
+
+// editor shows a template in an editor. Posted template contents are
+// validated and rendered with the chosen Group's metadata, without saving
+// them, or saved if editing is enabled and the template has no errors.
+func (d *Dashboard) editor(w http.ResponseWriter, req *http.Request) {
+	parts := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/ui/templates/"), "/", 2)
+	if len(parts) != 2 || !validKind(parts[0]) || parts[1] == "" || strings.Contains(parts[1], "/") {
+		http.NotFound(w, req)
+		return
+	}
+	kind, name := parts[0], parts[1]
+	ctx := req.Context()
+	groups, err := d.core.GroupList(ctx, &pb.GroupListRequest{})
+	if err != nil {
+		d.fail(w, err)
+		return
+	}
+	ids := make([]string, len(groups))
+	for i, group := range groups {
+		ids[i] = group.Id
+	}
+	sort.Strings(ids)
+	data := map[string]interface{}{
+		"Kind":    kind,
+		"Name":    name,
+		"Groups":  ids,
+		"Edit":    d.edit,
+		"Token":   d.edit && d.verifier != nil,
+		"Enabled": d.renderer != nil,
+	}
+
+	switch req.Method {
+	case "GET":
+		contents, err := d.templateGet(ctx, kind, name)
+		data["Template"] = contents
+		data["New"] = err != nil
+		data["Saved"] = req.URL.Query().Get("saved") != ""
+	case "POST":
+		// browsers submit textarea lines with CRLF line endings
+		contents := strings.Replace(req.PostFormValue("template"), "\r\n", "\n", -1)
+		data["Template"] = contents
+		data["Group"] = req.PostFormValue("group")
+		data["Labels"] = req.PostFormValue("labels")
+		if d.renderer == nil {
+			break
+		}
+		resp, err := d.renderer.RenderTemplate(ctx, &pb.RenderTemplateRequest{
+			Config:   kind,
+			Name:     name,
+			Template: []byte(contents),
+			Group:    req.PostFormValue("group"),
+			Labels:   parseLabels(req.PostFormValue("labels")),
+		})
+		if err != nil {
+			data["Error"] = err.Error()
+			break
+		}
+		data["Problems"] = resp.Problems
+		data["Rendered"] = string(resp.Config)
+		if req.PostFormValue("action") != "save" {
+			break
+		}
+		if !d.edit {
+			http.Error(w, errEditDisabled.Error(), http.StatusForbidden)
+			return
+		}
+		user, err := d.authorizeSave(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if hasErrors(resp.Problems) {
+			data["Error"] = errTemplateErrors.Error()
+			break
+		}
+		err = d.templatePut(ctx, kind, name, []byte(contents))
+		status := http.StatusSeeOther
+		if err != nil {
+			status = http.StatusInternalServerError
+		}
+		d.auditor.Record(&audit.Entry{
+			Type:   audit.TypeUI,
+			Action: req.URL.Path,
+			Actor:  user,
+			Result: strconv.Itoa(status),
+			Fields: map[string]string{"template": kind + "/" + name},
+		})
+		if err != nil {
+			d.fail(w, err)
+			return
+		}
+		http.Redirect(w, req, req.URL.Path+"?saved=1", status)
+		return
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	d.render(w, editorPage, data)
+}
+
+// authorizeSave returns the user saving a template. If ID tokens are
+// verified, saves must present one ("Authorization: Bearer TOKEN", e.g. from
+// an authenticating proxy, or the token form field) whose user's groups are
+// granted the editor role, like gRPC calls which change templates.
+func (d *Dashboard) authorizeSave(req *http.Request) (string, error) {
+	if d.verifier == nil {
+		return actor(req), nil
+	}
+	token := req.PostFormValue("token")
+	if value := req.Header.Get("Authorization"); strings.HasPrefix(value, "Bearer ") {
+		token = strings.TrimPrefix(value, "Bearer ")
+	}
+	if token == "" {
+		return "", errTokenRequired
+	}
+	identity, err := d.verifier.Verify(req.Context(), token)
+	if err != nil {
+		return "", errInvalidToken
+	}
+	if d.roles.Role(identity.Groups) < oidc.RoleEditor {
+		return "", errRoleRequired
+	}
+	return identity.Subject, nil
+}
+
+// templateList lists the names of the templates of a kind.
+func (d *Dashboard) templateList(ctx context.Context, kind string) ([]string, error) {
+	switch kind {
+	case manifest.KindIgnition:
+		return d.core.IgnitionList(ctx, &pb.IgnitionListRequest{})
+	case manifest.KindCloud:
+		return d.core.CloudList(ctx, &pb.CloudListRequest{})
+	default:
+		return d.core.GenericList(ctx, &pb.GenericListRequest{})
+	}
+}
+
+// templateGet gets a template of a kind by name.
+func (d *Dashboard) templateGet(ctx context.Context, kind, name string) (string, error) {
+	switch kind {
+	case manifest.KindIgnition:
+		return d.core.IgnitionGet(ctx, name)
+	case manifest.KindCloud:
+		return d.core.CloudGet(ctx, name)
+	default:
+		return d.core.GenericGet(ctx, name)
+	}
+}
+
+// templatePut creates or updates a template of a kind by name.
+func (d *Dashboard) templatePut(ctx context.Context, kind, name string, contents []byte) (err error) {
+	switch kind {
+	case manifest.KindIgnition:
+		_, err = d.core.IgnitionPut(ctx, &pb.IgnitionPutRequest{Name: name, Config: contents})
+	case manifest.KindCloud:
+		_, err = d.core.CloudPut(ctx, &pb.CloudPutRequest{Name: name, Config: contents})
+	default:
+		_, err = d.core.GenericPut(ctx, &pb.GenericPutRequest{Name: name, Config: contents})
+	}
+	return err
+}
+
+// validKind returns true if the kind is a template kind.
+func validKind(kind string) bool {
+	for _, k := range manifest.Kinds {
+		if kind == k {
+			return true
+		}
+	}
+	return false
+}
+
+// hasErrors returns true if any of the problems is an error.
+func hasErrors(problems []*pb.TemplateProblem) bool {
+	for _, problem := range problems {
+		if problem.Kind == manifest.ProblemError {
+			return true
+		}
+	}
+	return false
+}
+
+// actor returns the common name of the request's verified client
+// certificate, or the client's IP address.
+func actor(req *http.Request) string {
+	if req.TLS != nil {
+		if chains := req.TLS.VerifiedChains; len(chains) > 0 && len(chains[0]) > 0 {
+			if cn := chains[0][0].Subject.CommonName; cn != "" {
+				return cn
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,250 @@
+package ui
+
+import (
+	"html/template"
+	"sort"
+	"strings"
+
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// layout is the page shared by the dashboard's pages, which define
+// "title" and "content".
+const layout = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>matchbox - {{template "title" .}}</title>
+<style>
+body { font-family: sans-serif; margin: 0; color: #222; }
+nav { background: #2c3e50; padding: 0.75em 1.5em; }
+nav a { color: #fff; margin-right: 1.5em; text-decoration: none; }
+main { padding: 1em 1.5em; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { text-align: left; padding: 0.3em 0.6em; border-bottom: 1px solid #ddd; vertical-align: top; }
+pre { background: #f5f5f5; padding: 1em; overflow: auto; }
+.error { color: #c0392b; }
+.warning { color: #d35400; }
+textarea { width: 100%; font-family: monospace; }
+</style>
+</head>
+<body>
+<nav>
+<a href="/ui/">Groups &amp; Profiles</a>
+<a href="/ui/machines">Machines</a>
+<a href="/ui/events">Boot events</a>
+<a href="/ui/preview">Preview</a>
+<a href="/ui/templates">Templates</a>
+</nav>
+<main>
+{{template "content" .}}
+</main>
+</body>
+</html>
+`
+
+const overviewContent = `{{define "title"}}Groups &amp; Profiles{{end}}
+{{define "content"}}
+<h2>Groups ({{len .Groups}})</h2>
+<table>
+<tr><th>ID</th><th>Name</th><th>Profile</th><th>Selectors</th></tr>
+{{range .Groups}}<tr>
+<td><a href="/ui/groups/{{.Id}}">{{.Id}}</a></td>
+<td>{{.Name}}</td>
+<td><a href="/ui/profiles/{{.Profile}}">{{.Profile}}</a></td>
+<td>{{pairs .Selector}}</td>
+</tr>{{end}}
+</table>
+<h2>Profiles ({{len .Profiles}})</h2>
+<table>
+<tr><th>ID</th><th>Name</th><th>Ignition</th><th>Cloud-Config</th><th>Generic</th></tr>
+{{range .Profiles}}<tr>
+<td><a href="/ui/profiles/{{.Id}}">{{.Id}}</a></td>
+<td>{{.Name}}</td>
+<td>{{with .IgnitionId}}{{if isURL .}}{{.}}{{else}}<a href="/ui/templates/ignition/{{.}}">{{.}}</a>{{end}}{{end}}</td>
+<td>{{with .CloudId}}<a href="/ui/templates/cloud/{{.}}">{{.}}</a>{{end}}</td>
+<td>{{with .GenericId}}<a href="/ui/templates/generic/{{.}}">{{.}}</a>{{end}}</td>
+</tr>{{end}}
+</table>
+{{end}}`
+
+const machinesContent = `{{define "title"}}Machines{{end}}
+{{define "content"}}
+<form method="get" action="/ui/machines">
+<input type="search" name="q" value="{{.Query}}" placeholder="ID, state, group, or label">
+<button type="submit">Filter</button>
+</form>
+<h2>Machines ({{.Total}}{{if gt .Total (len .Machines)}}, showing {{len .Machines}}{{end}})</h2>
+<table>
+<tr><th>ID</th><th>State</th><th>Group</th><th>Labels</th></tr>
+{{range .Machines}}<tr>
+<td><a href="/ui/machines/{{.Id}}">{{.Id}}</a></td>
+<td>{{.State}}</td>
+<td>{{if .Group}}<a href="/ui/groups/{{.Group}}">{{.Group}}</a>{{end}}</td>
+<td>{{pairs .Labels}}</td>
+</tr>{{end}}
+</table>
+{{end}}`
+
+const machineContent = `{{define "title"}}Machine {{.Machine.Id}}{{end}}
+{{define "content"}}
+<h2>Machine {{.Machine.Id}}</h2>
+<table>
+<tr><th>State</th><td>{{.Machine.State}}</td></tr>
+<tr><th>Labels</th><td>{{pairs .Labels}}</td></tr>
+<tr><th>Group</th><td>{{with .Group}}<a href="/ui/groups/{{.Id}}">{{.Id}}</a> ({{$.Match}}){{else}}(none){{end}}</td></tr>
+<tr><th>Profile</th><td>{{if .Profile}}<a href="/ui/profiles/{{.Profile}}">{{.Profile}}</a>{{end}}</td></tr>
+<tr><th>Install attempts</th><td>{{.Machine.Attempts}}</td></tr>
+<tr><th>Completed</th><td>{{.Machine.Completed}}</td></tr>
+<tr><th>Last config hash</th><td>{{.LastHash}}</td></tr>
+</table>
+{{with .Machine.Progress}}<h3>Progress</h3>
+<table>
+<tr><th>Step</th><th>Status</th><th>Time</th><th>Message</th></tr>
+{{range .}}<tr><td>{{.Name}}</td><td>{{.Status}}</td><td>{{.Time}}</td><td>{{.Message}}</td></tr>{{end}}
+</table>{{end}}
+<h3>Boots</h3>
+<table>
+<tr><th>Time</th><th>Endpoint</th><th>Status</th><th>Group</th><th>Profile</th><th>Config hash</th></tr>
+{{range .Machine.Boots}}<tr>
+<td>{{.Time}}</td>
+<td>{{.Endpoint}}</td>
+<td>{{.Status}}</td>
+<td>{{.Group}}</td>
+<td>{{.Profile}}</td>
+<td>{{.ConfigHash}}</td>
+</tr>{{else}}<tr><td colspan="6">No boots recorded.</td></tr>{{end}}
+</table>
+<h3>JSON</h3>
+<pre>{{.JSON}}</pre>
+{{end}}`
+
+const resourceContent = `{{define "title"}}{{.Kind}} {{.Id}}{{end}}
+{{define "content"}}
+<h2>{{.Kind}} {{.Id}}</h2>
+<pre>{{.JSON}}</pre>
+{{end}}`
+
+const eventsContent = `{{define "title"}}Boot events{{end}}
+{{define "content"}}
+<h2>Recent boot events</h2>
+{{if not .Enabled}}<p>Boot events are not available.</p>{{else}}
+<table>
+<tr><th>Time</th><th>Endpoint</th><th>Status</th><th>Machine</th><th>Group</th><th>Profile</th><th>Remote IP</th></tr>
+{{range .Events}}<tr>
+<td>{{.Time}}</td>
+<td>{{.Endpoint}}</td>
+<td>{{.Status}}</td>
+<td>{{if .MachineId}}<a href="/ui/machines/{{.MachineId}}">{{.MachineId}}</a>{{end}}</td>
+<td>{{.Group}}</td>
+<td>{{.Profile}}</td>
+<td>{{.RemoteIp}}</td>
+</tr>{{else}}<tr><td colspan="7">No boot events since matchbox started.</td></tr>{{end}}
+</table>
+{{end}}
+{{end}}`
+
+const previewContent = `{{define "title"}}Preview{{end}}
+{{define "content"}}
+<h2>Preview a rendered config</h2>
+{{if not .Enabled}}<p>Previews are not available.</p>{{else}}
+<form method="get" action="/ui/preview">
+<select name="config">
+{{range $config := configs}}<option value="{{$config}}"{{if eq $config $.Config}} selected{{end}}>{{$config}}</option>{{end}}
+</select>
+<input type="text" name="labels" value="{{.Labels}}" size="50" placeholder="uuid=...,mac=...">
+<input type="text" name="profile" value="{{.Profile}}" placeholder="profile (optional)">
+<button type="submit">Render</button>
+</form>
+{{with .Error}}<p class="error">{{.}}</p>{{end}}
+{{with .Result}}<p>Group: {{if .Group}}<a href="/ui/groups/{{.Group}}">{{.Group}}</a>{{else}}(none){{end}},
+Profile: <a href="/ui/profiles/{{.Profile}}">{{.Profile}}</a></p>
+<pre>{{$.Rendered}}</pre>{{end}}
+{{end}}
+{{end}}`
+
+const templatesContent = `{{define "title"}}Templates{{end}}
+{{define "content"}}
+{{range .Templates}}<h2>{{.Kind}} ({{len .Names}})</h2>
+<table>
+{{$kind := .Kind}}{{range .Names}}<tr><td><a href="/ui/templates/{{$kind}}/{{.}}">{{.}}</a></td></tr>{{else}}<tr><td>No templates.</td></tr>{{end}}
+</table>
+{{end}}
+<h2>New template</h2>
+<form method="get" action="/ui/templates">
+<select name="kind">
+{{range .Kinds}}<option value="{{.}}">{{.}}</option>{{end}}
+</select>
+<input type="text" name="name" placeholder="name (e.g. worker.yaml)">
+<button type="submit">Create</button>
+</form>
+{{end}}`
+
+const editorContent = `{{define "title"}}{{.Kind}} template {{.Name}}{{end}}
+{{define "content"}}
+<h2>{{.Kind}} template {{.Name}}{{if .New}} (new){{end}}</h2>
+{{if .Saved}}<p>Saved.</p>{{end}}
+{{if not .Enabled}}<p>Previews are not available.</p>{{end}}
+<form method="post" action="/ui/templates/{{.Kind}}/{{.Name}}">
+<textarea name="template" rows="30" spellcheck="false">
+{{.Template}}</textarea>
+<p>
+<select name="group">
+<option value="">(no group)</option>
+{{range $group := .Groups}}<option value="{{$group}}"{{if eq $group $.Group}} selected{{end}}>{{$group}}</option>{{end}}
+</select>
+<input type="text" name="labels" value="{{.Labels}}" size="50" placeholder="uuid=...,mac=... (the group's selectors if empty)">
+<button type="submit" name="action" value="preview">Preview</button>
+{{if .Token}}<input type="password" name="token" size="30" placeholder="ID token (to save)">{{end}}
+{{if .Edit}}<button type="submit" name="action" value="save">Save</button>{{end}}
+</p>
+</form>
+{{with .Error}}<p class="error">{{.}}</p>{{end}}
+{{with .Problems}}<h3>Problems</h3>
+<table>
+<tr><th>Kind</th><th>Line</th><th>Column</th><th>Message</th></tr>
+{{range .}}<tr class="{{.Kind}}">
+<td>{{.Kind}}</td>
+<td>{{if .Line}}{{.Line}}{{end}}</td>
+<td>{{if .Column}}{{.Column}}{{end}}</td>
+<td>{{.Message}}{{with .Highlight}}<pre>{{.}}</pre>{{end}}</td>
+</tr>{{end}}
+</table>{{end}}
+{{with .Rendered}}<h3>Rendered</h3>
+<pre>{{.}}</pre>{{end}}
+{{end}}`
+
+var funcs = template.FuncMap{
+	"pairs":   pairs,
+	"configs": func() []string { return []string{"ipxe", "ignition", "cloud", "generic"} },
+	"isURL":   storagepb.IsIgnitionURL,
+}
+
+var (
+	overviewPage  = page(overviewContent)
+	machinesPage  = page(machinesContent)
+	machinePage   = page(machineContent)
+	resourcePage  = page(resourceContent)
+	eventsPage    = page(eventsContent)
+	previewPage   = page(previewContent)
+	templatesPage = page(templatesContent)
+	editorPage    = page(editorContent)
+)
+
+// page parses a page's "title" and "content" templates with the layout.
+func page(content string) *template.Template {
+	return template.Must(template.Must(template.New("layout").Funcs(funcs).Parse(layout)).Parse(content))
+}
+
+// pairs formats a map as sorted, comma separated key=value pairs.
+func pairs(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for i, key := range keys {
+		keys[i] = key + "=" + m[key]
+	}
+	return strings.Join(keys, ", ")
+}
@@ -0,0 +1,423 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/audit"
+	"github.com/coreos/matchbox/matchbox/events"
+	"github.com/coreos/matchbox/matchbox/oidc"
+	"github.com/coreos/matchbox/matchbox/redact"
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// DefaultRecentEvents is the number of recent boot events shown if none is
+// configured.
+const DefaultRecentEvents = 100
+
+// maxMachines is the number of machines listed on a page.
+const maxMachines = 500
+
+// TokenVerifier verifies bearer ID tokens.
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (*oidc.Identity, error)
+}
+
+// A Renderer renders the config a machine would receive and unsaved
+// templates (e.g. the matchbox HTTP server).
+type Renderer interface {
+	Render(context.Context, *pb.RenderRequest) (*pb.RenderResponse, error)
+	RenderTemplate(context.Context, *pb.RenderTemplateRequest) (*pb.RenderTemplateResponse, error)
+}
+
+// Config configures a Dashboard.
+type Config struct {
+	Core   server.Server
+	Logger *logrus.Logger
+	// (optional) renderer of config and template previews, previews are
+	// disabled if nil
+	Renderer Renderer
+	// (optional) hub of boot events to show
+	Events *events.Hub
+	// (optional) redactor of sensitive labels and metadata values
+	Redactor *redact.Redactor
+	// number of recent boot events shown (DefaultRecentEvents if zero)
+	RecentEvents int
+	// allow saving templates from the template editor
+	Edit bool
+	// (optional) verifier of ID tokens, saves must present an ID token whose
+	// user's groups are granted the editor role if set
+	Verifier TokenVerifier
+	// roles granted to identity provider groups
+	Roles oidc.RoleMap
+	// (optional) auditor of changes made in the dashboard
+	Auditor *audit.Auditor
+}
+
+// Dashboard serves the web dashboard under /ui/.
+type Dashboard struct {
+	core     server.Server
+	logger   *logrus.Logger
+	renderer Renderer
+	hub      *events.Hub
+	redactor *redact.Redactor
+	edit     bool
+	verifier TokenVerifier
+	roles    oidc.RoleMap
+	auditor  *audit.Auditor
+
+	mu sync.Mutex
+	// recent boot events, oldest first once full
+	recent []*pb.BootEvent
+	next   int
+}
+
+// New returns a new Dashboard.
+func New(config *Config) *Dashboard {
+	size := config.RecentEvents
+	if size <= 0 {
+		size = DefaultRecentEvents
+	}
+	return &Dashboard{
+		core:     config.Core,
+		logger:   config.Logger,
+		renderer: config.Renderer,
+		hub:      config.Events,
+		redactor: config.Redactor,
+		edit:     config.Edit,
+		verifier: config.Verifier,
+		roles:    config.Roles,
+		auditor:  config.Auditor,
+		recent:   make([]*pb.BootEvent, 0, size),
+	}
+}
+
+// Run records boot events published to the Hub until stop is closed.
+func (d *Dashboard) Run(stop <-chan struct{}) {
+	if d.hub == nil {
+		return
+	}
+	sub := d.hub.Subscribe(0)
+	defer sub.Close()
+	for {
+		select {
+		case <-stop:
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			d.record(event)
+		}
+	}
+}
+
+// record adds a boot event, replacing the oldest once full.
+func (d *Dashboard) record(event *pb.BootEvent) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.recent) < cap(d.recent) {
+		d.recent = append(d.recent, event)
+		return
+	}
+	d.recent[d.next] = event
+	d.next = (d.next + 1) % len(d.recent)
+}
+
+// recentEvents returns the recent boot events, newest first.
+func (d *Dashboard) recentEvents() []*pb.BootEvent {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	events := make([]*pb.BootEvent, 0, len(d.recent))
+	for i := len(d.recent) - 1; i >= 0; i-- {
+		events = append(events, d.recent[(d.next+i)%len(d.recent)])
+	}
+	return events
+}
+
+// Handler returns a handler which serves the dashboard under /ui/ and
+// redirects other paths to it. Cross-origin form posts are rejected, so
+// other sites can't submit forms with a user's client certificate.
+func (d *Dashboard) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ui/", d.overview)
+	mux.HandleFunc("/ui/groups/", d.group)
+	mux.HandleFunc("/ui/profiles/", d.profile)
+	mux.HandleFunc("/ui/machines", d.machines)
+	mux.HandleFunc("/ui/machines/", d.machine)
+	mux.HandleFunc("/ui/events", d.events)
+	mux.HandleFunc("/ui/preview", d.preview)
+	mux.HandleFunc("/ui/templates", d.templates)
+	mux.HandleFunc("/ui/templates/", d.editor)
+	mux.Handle("/", http.RedirectHandler("/ui/", http.StatusFound))
+	return sameOrigin(mux)
+}
+
+// sameOrigin returns a handler which rejects cross-origin requests which
+// aren't GET or HEAD requests, then calls the next handler. Browsers send
+// the Sec-Fetch-Site or Origin header with form posts, requests without
+// either aren't from browsers and are allowed.
+func sameOrigin(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != "GET" && req.Method != "HEAD" && !fromSameOrigin(req) {
+			http.Error(w, errCrossOrigin.Error(), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, req)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// fromSameOrigin returns true if a request wasn't sent by a browser from
+// another origin.
+func fromSameOrigin(req *http.Request) bool {
+	switch req.Header.Get("Sec-Fetch-Site") {
+	case "same-origin", "none":
+		return true
+	case "":
+	default:
+		return false
+	}
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == req.Host
+}
+
+// overview lists groups and profiles.
+func (d *Dashboard) overview(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/ui/" {
+		http.NotFound(w, req)
+		return
+	}
+	ctx := req.Context()
+	groups, err := d.core.GroupList(ctx, &pb.GroupListRequest{})
+	if err != nil {
+		d.fail(w, err)
+		return
+	}
+	profiles, err := d.core.ProfileList(ctx, &pb.ProfileListRequest{})
+	if err != nil {
+		d.fail(w, err)
+		return
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Id < groups[j].Id })
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Id < profiles[j].Id })
+	d.render(w, overviewPage, map[string]interface{}{
+		"Groups":   groups,
+		"Profiles": profiles,
+	})
+}
+
+// group shows a Group with its metadata.
+func (d *Dashboard) group(w http.ResponseWriter, req *http.Request) {
+	id := strings.TrimPrefix(req.URL.Path, "/ui/groups/")
+	group, err := d.core.GroupGet(req.Context(), &pb.GroupGetRequest{Id: id})
+	if err != nil {
+		http.NotFound(w, req)
+		return
+	}
+	if d.redactor != nil {
+		group = group.Copy()
+		if group.Metadata, err = d.redactor.Metadata(group.Metadata); err != nil {
+			d.fail(w, err)
+			return
+		}
+	}
+	rich, err := group.ToRichGroup()
+	if err != nil {
+		d.fail(w, err)
+		return
+	}
+	d.renderResource(w, "Group", id, rich)
+}
+
+// profile shows a Profile.
+func (d *Dashboard) profile(w http.ResponseWriter, req *http.Request) {
+	id := strings.TrimPrefix(req.URL.Path, "/ui/profiles/")
+	profile, err := d.core.ProfileGet(req.Context(), &pb.ProfileGetRequest{Id: id})
+	if err != nil {
+		http.NotFound(w, req)
+		return
+	}
+	d.renderResource(w, "Profile", id, profile)
+}
+
+// machines lists machines whose id, state, group, or labels contain the
+// query, if any.
+func (d *Dashboard) machines(w http.ResponseWriter, req *http.Request) {
+	machines, err := d.core.MachineList(req.Context(), &pb.MachineListRequest{})
+	if err != nil {
+		d.fail(w, err)
+		return
+	}
+	query := req.URL.Query().Get("q")
+	matched := make([]*storagepb.Machine, 0, len(machines))
+	for _, machine := range machines {
+		if query == "" || machineContains(machine, query) {
+			matched = append(matched, d.redactMachine(machine))
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].Id < matched[j].Id })
+	total := len(matched)
+	if total > maxMachines {
+		matched = matched[:maxMachines]
+	}
+	d.render(w, machinesPage, map[string]interface{}{
+		"Machines": matched,
+		"Query":    query,
+		"Total":    total,
+	})
+}
+
+// machine shows a Machine's labels, the Group and Profile it matches, its
+// install attempts and progress, and its boot history.
+func (d *Dashboard) machine(w http.ResponseWriter, req *http.Request) {
+	id := strings.TrimPrefix(req.URL.Path, "/ui/machines/")
+	resp, err := d.core.MachineDescribe(req.Context(), &pb.MachineDescribeRequest{Id: id})
+	if err != nil {
+		http.NotFound(w, req)
+		return
+	}
+	machine := d.redactMachine(resp.Machine)
+	labels := resp.Labels
+	if d.redactor != nil {
+		labels = d.redactor.Labels(labels)
+	}
+	data, err := json.MarshalIndent(machine, "", "  ")
+	if err != nil {
+		d.fail(w, err)
+		return
+	}
+	var lastHash string
+	for _, boot := range machine.Boots {
+		if boot.ConfigHash != "" {
+			lastHash = boot.ConfigHash
+		}
+	}
+	d.render(w, machinePage, map[string]interface{}{
+		"Machine":  machine,
+		"Labels":   labels,
+		"Group":    resp.Group,
+		"Match":    resp.Match,
+		"Profile":  resp.Profile,
+		"LastHash": lastHash,
+		"JSON":     string(data),
+	})
+}
+
+// events lists recent boot events.
+func (d *Dashboard) events(w http.ResponseWriter, req *http.Request) {
+	d.render(w, eventsPage, map[string]interface{}{
+		"Events":  d.recentEvents(),
+		"Enabled": d.hub != nil,
+	})
+}
+
+// preview renders the config a machine with the given labels would
+// receive.
+func (d *Dashboard) preview(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	data := map[string]interface{}{
+		"Enabled": d.renderer != nil,
+		"Config":  query.Get("config"),
+		"Labels":  query.Get("labels"),
+		"Profile": query.Get("profile"),
+	}
+	if d.renderer != nil && query.Get("labels") != "" {
+		resp, err := d.renderer.Render(req.Context(), &pb.RenderRequest{
+			Config:  query.Get("config"),
+			Labels:  parseLabels(query.Get("labels")),
+			Profile: query.Get("profile"),
+		})
+		if err != nil {
+			data["Error"] = err.Error()
+		} else {
+			data["Result"] = resp
+			data["Rendered"] = string(resp.Config)
+		}
+	}
+	d.render(w, previewPage, data)
+}
+
+// redactMachine returns a copy of the Machine with sensitive labels and
+// facts redacted.
+func (d *Dashboard) redactMachine(machine *storagepb.Machine) *storagepb.Machine {
+	if d.redactor == nil {
+		return machine
+	}
+	machine = machine.Copy()
+	machine.Labels = d.redactor.Labels(machine.Labels)
+	machine.Facts = d.redactor.Labels(machine.Facts)
+	return machine
+}
+
+// renderResource renders a resource as indented JSON.
+func (d *Dashboard) renderResource(w http.ResponseWriter, kind, id string, resource interface{}) {
+	data, err := json.MarshalIndent(resource, "", "  ")
+	if err != nil {
+		d.fail(w, err)
+		return
+	}
+	d.render(w, resourcePage, map[string]interface{}{
+		"Kind": kind,
+		"Id":   id,
+		"JSON": string(data),
+	})
+}
+
+// render executes a page template and writes it, or an error.
+func (d *Dashboard) render(w http.ResponseWriter, page *template.Template, data interface{}) {
+	var buf bytes.Buffer
+	if err := page.Execute(&buf, data); err != nil {
+		d.fail(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	buf.WriteTo(w)
+}
+
+// fail logs an error and responds with an internal server error.
+func (d *Dashboard) fail(w http.ResponseWriter, err error) {
+	d.logger.Errorf("ui: %v", err)
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// machineContains returns true if the Machine's id, state, group, or a
+// label value contains the query.
+func machineContains(machine *storagepb.Machine, query string) bool {
+	if strings.Contains(machine.Id, query) || strings.Contains(machine.State, query) || strings.Contains(machine.Group, query) {
+		return true
+	}
+	for _, value := range machine.Labels {
+		if strings.Contains(value, query) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLabels parses comma separated key=value labels.
+func parseLabels(value string) map[string]string {
+	labels := make(map[string]string)
+	for _, field := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(parts) == 2 && parts[0] != "" {
+			labels[parts[0]] = parts[1]
+		}
+	}
+	return labels
+}
@@ -0,0 +1,308 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/oidc"
+	"github.com/coreos/matchbox/matchbox/redact"
+	"github.com/coreos/matchbox/matchbox/server"
+	pb "github.com/coreos/matchbox/matchbox/server/serverpb"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+	fake "github.com/coreos/matchbox/matchbox/storage/testfakes"
+)
+
+// fakeRenderer renders the requested config name and labels.
+type fakeRenderer struct{}
+
+func (fakeRenderer) Render(ctx context.Context, req *pb.RenderRequest) (*pb.RenderResponse, error) {
+	if req.Config == "broken" {
+		return nil, fmt.Errorf("render failed")
+	}
+	return &pb.RenderResponse{
+		Group:   "test-group",
+		Profile: "g1h2i3j4",
+		Config:  []byte(req.Config + " for " + req.Labels["mac"] + " <script>"),
+	}, nil
+}
+
+func (fakeRenderer) RenderTemplate(ctx context.Context, req *pb.RenderTemplateRequest) (*pb.RenderTemplateResponse, error) {
+	if strings.Contains(string(req.Template), "{{bad") {
+		return &pb.RenderTemplateResponse{
+			Problems: []*pb.TemplateProblem{{Kind: "error", Line: 1, Message: "function \"bad\" not defined"}},
+		}, nil
+	}
+	return &pb.RenderTemplateResponse{
+		Config: []byte(fmt.Sprintf("%s rendered with %q", req.Template, req.Group)),
+	}, nil
+}
+
+func newTestDashboard(t *testing.T, store *fake.FixedStore, redactor *redact.Redactor) http.Handler {
+	logger, _ := logtest.NewNullLogger()
+	return New(&Config{
+		Core:     server.NewServer(&server.Config{Store: store}),
+		Logger:   logger,
+		Renderer: fakeRenderer{},
+		Redactor: redactor,
+	}).Handler()
+}
+
+func get(h http.Handler, url string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", url, nil)
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestDashboard_Overview(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups[fake.Group.Id] = fake.Group
+	store.Profiles[fake.Profile.Id] = fake.Profile
+	h := newTestDashboard(t, store, nil)
+	// assert that:
+	// - the overview lists groups and profiles with links
+	// - other paths redirect to the overview
+	w := get(h, "/ui/")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `<a href="/ui/groups/test-group">test-group</a>`)
+	assert.Contains(t, w.Body.String(), `<a href="/ui/profiles/g1h2i3j4">g1h2i3j4</a>`)
+	w = get(h, "/")
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/ui/", w.Header().Get("Location"))
+	w = get(h, "/ui/unknown")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDashboard_Group(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups[fake.Group.Id] = fake.Group
+	redactor, err := redact.NewRedactor([]string{"pod_network"})
+	assert.Nil(t, err)
+	h := newTestDashboard(t, store, redactor)
+	// assert that:
+	// - groups are shown as JSON with sensitive metadata redacted
+	// - the stored group is unchanged
+	// - unknown groups are not found
+	w := get(h, "/ui/groups/test-group")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "service_name")
+	assert.NotContains(t, w.Body.String(), "10.2.0.0/16")
+	assert.Contains(t, string(store.Groups[fake.Group.Id].Metadata), "10.2.0.0/16")
+	w = get(h, "/ui/groups/missing")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDashboard_Machines(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Machines["a1"] = &storagepb.Machine{
+		Id:     "a1",
+		State:  storagepb.MachineInstalled,
+		Labels: map[string]string{"mac": "52:54:00:a1:00:01"},
+		Boots:  []*storagepb.MachineBoot{{Endpoint: "/ipxe", Status: 200, ConfigHash: "sha256:abc123"}},
+	}
+	store.Machines["b2"] = &storagepb.Machine{Id: "b2", Labels: map[string]string{"mac": "52:54:00:b2:00:02"}}
+	h := newTestDashboard(t, store, nil)
+	// assert that:
+	// - machines are listed
+	// - machines are filtered by the query
+	// - machines are shown with their boots and last config hash
+	w := get(h, "/ui/machines")
+	assert.Contains(t, w.Body.String(), "Machines (2)")
+	assert.Contains(t, w.Body.String(), "/ui/machines/a1")
+	assert.Contains(t, w.Body.String(), "/ui/machines/b2")
+	w = get(h, "/ui/machines?q=b2:00")
+	assert.Contains(t, w.Body.String(), "Machines (1)")
+	assert.NotContains(t, w.Body.String(), "/ui/machines/a1")
+	w = get(h, "/ui/machines/a1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), storagepb.MachineInstalled)
+	assert.Contains(t, w.Body.String(), "<td>/ipxe</td>")
+	assert.Contains(t, w.Body.String(), "<tr><th>Last config hash</th><td>sha256:abc123</td></tr>")
+	w = get(h, "/ui/machines/missing")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDashboard_Preview(t *testing.T) {
+	h := newTestDashboard(t, fake.NewFixedStore(), nil)
+	// assert that:
+	// - configs are rendered for the labels and escaped
+	// - render errors are shown
+	w := get(h, "/ui/preview?config=ipxe&labels=mac%3D52:54:00:a1:00:01")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "ipxe for 52:54:00:a1:00:01 &lt;script&gt;")
+	assert.Contains(t, w.Body.String(), `<a href="/ui/groups/test-group">test-group</a>`)
+	w = get(h, "/ui/preview?config=broken&labels=mac%3D52:54:00:a1:00:01")
+	assert.Contains(t, w.Body.String(), "render failed")
+}
+
+func TestDashboard_RecentEvents(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	d := New(&Config{Logger: logger, RecentEvents: 2})
+	for _, id := range []string{"a", "b", "c"} {
+		d.record(&pb.BootEvent{MachineId: id})
+	}
+	// assert that:
+	// - the most recent events are kept, newest first
+	events := d.recentEvents()
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, "c", events[0].MachineId)
+		assert.Equal(t, "b", events[1].MachineId)
+	}
+}
+
+func TestParseLabels(t *testing.T) {
+	assert.Equal(t, map[string]string{"mac": "52:54:00:a1:00:01", "os": "installed"}, parseLabels("mac=52:54:00:a1:00:01, os=installed,=x,bad"))
+}
+
+func TestDashboard_Templates(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.GenericConfigs["worker.tmpl"] = "{{.mac}} <b>"
+	h := newTestDashboard(t, store, nil)
+	// assert that:
+	// - templates are listed by kind with links to the editor
+	// - the new template form redirects to the editor
+	// - templates are shown escaped in the editor
+	w := get(h, "/ui/templates")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `<a href="/ui/templates/generic/worker.tmpl">worker.tmpl</a>`)
+	w = get(h, "/ui/templates?kind=ignition&name=new.yaml")
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/ui/templates/ignition/new.yaml", w.Header().Get("Location"))
+	w = get(h, "/ui/templates/generic/worker.tmpl")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "{{.mac}} &lt;b&gt;</textarea>")
+	assert.NotContains(t, w.Body.String(), "(new)")
+	w = get(h, "/ui/templates/ipxe/worker.tmpl")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDashboard_Editor(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.Groups[fake.Group.Id] = fake.Group
+	store.GenericConfigs["worker.tmpl"] = "{{.mac}}"
+	logger, _ := logtest.NewNullLogger()
+	config := &Config{
+		Core:     server.NewServer(&server.Config{Store: store}),
+		Logger:   logger,
+		Renderer: fakeRenderer{},
+	}
+	post := func(h http.Handler, action, template string) *httptest.ResponseRecorder {
+		form := url.Values{"template": {template}, "group": {fake.Group.Id}, "action": {action}}
+		req := httptest.NewRequest("POST", "/ui/templates/generic/worker.tmpl", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	// assert that:
+	// - previews render the posted template with the group, without saving
+	// - problems are shown with their line
+	// - templates can't be saved unless editing is enabled
+	h := New(config).Handler()
+	w := post(h, "preview", "{{.uuid}}")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "{{.uuid}} rendered with &#34;test-group&#34;")
+	assert.Equal(t, "{{.mac}}", store.GenericConfigs["worker.tmpl"])
+	assert.NotContains(t, w.Body.String(), `value="save"`)
+	w = post(h, "preview", "{{bad}}")
+	assert.Contains(t, w.Body.String(), `<tr class="error">`)
+	assert.Contains(t, w.Body.String(), "<td>1</td>")
+	w = post(h, "save", "{{.uuid}}")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "{{.mac}}", store.GenericConfigs["worker.tmpl"])
+
+	// assert that:
+	// - templates with errors aren't saved
+	// - valid templates are saved, with LF line endings
+	// - cross-origin posts are rejected
+	config.Edit = true
+	h = New(config).Handler()
+	w = post(h, "save", "{{bad}}")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "template has errors, not saved")
+	assert.Equal(t, "{{.mac}}", store.GenericConfigs["worker.tmpl"])
+	w = post(h, "save", "{{.uuid}}\r\n{{.mac}}\r\n")
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/ui/templates/generic/worker.tmpl?saved=1", w.Header().Get("Location"))
+	assert.Equal(t, "{{.uuid}}\n{{.mac}}\n", store.GenericConfigs["worker.tmpl"])
+
+	req := httptest.NewRequest("POST", "/ui/templates/generic/worker.tmpl", strings.NewReader("action=save&template=x"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Sec-Fetch-Site", "cross-site")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "{{.uuid}}\n{{.mac}}\n", store.GenericConfigs["worker.tmpl"])
+	req = httptest.NewRequest("POST", "/ui/templates/generic/worker.tmpl", strings.NewReader("action=save&template=x"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Origin", "https://evil.example.com")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "{{.uuid}}\n{{.mac}}\n", store.GenericConfigs["worker.tmpl"])
+}
+
+// fakeVerifier verifies tokens named after the user's group.
+type fakeVerifier struct{}
+
+func (fakeVerifier) Verify(ctx context.Context, token string) (*oidc.Identity, error) {
+	if token == "invalid" {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return &oidc.Identity{Subject: token + "@example.com", Groups: []string{token}}, nil
+}
+
+func TestDashboard_EditorRoles(t *testing.T) {
+	store := fake.NewFixedStore()
+	store.GenericConfigs["worker.tmpl"] = "{{.mac}}"
+	logger, _ := logtest.NewNullLogger()
+	h := New(&Config{
+		Core:     server.NewServer(&server.Config{Store: store}),
+		Logger:   logger,
+		Renderer: fakeRenderer{},
+		Edit:     true,
+		Verifier: fakeVerifier{},
+		Roles:    oidc.RoleMap{"sre": oidc.RoleEditor, "eng": oidc.RoleViewer},
+	}).Handler()
+	save := func(token, bearer string) *httptest.ResponseRecorder {
+		form := url.Values{"template": {"{{.uuid}}"}, "action": {"save"}, "token": {token}}
+		req := httptest.NewRequest("POST", "/ui/templates/generic/worker.tmpl", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Origin", "https://"+req.Host)
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	// assert that:
+	// - the editor asks for an ID token
+	// - saves without a valid token of an editor are rejected
+	// - saves with an editor's token (from the form or a proxy) are saved
+	w := get(h, "/ui/templates/generic/worker.tmpl")
+	assert.Contains(t, w.Body.String(), `name="token"`)
+	for _, token := range []string{"", "invalid", "eng"} {
+		w = save(token, "")
+		assert.Equal(t, http.StatusForbidden, w.Code, token)
+		assert.Equal(t, "{{.mac}}", store.GenericConfigs["worker.tmpl"])
+	}
+	w = save("sre", "")
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "{{.uuid}}", store.GenericConfigs["worker.tmpl"])
+	store.GenericConfigs["worker.tmpl"] = "{{.mac}}"
+	w = save("", "sre")
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "{{.uuid}}", store.GenericConfigs["worker.tmpl"])
+}
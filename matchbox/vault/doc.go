@@ -0,0 +1,3 @@
+// Package vault issues machine certificates from a HashiCorp Vault PKI
+// secrets engine and decrypts values with the Transit secrets engine.
+package vault
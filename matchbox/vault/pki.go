@@ -0,0 +1,154 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"context"
+)
+
+// Possible PKI errors
+var (
+	ErrCommonNameRequired = errors.New("vault: certificate common name is required")
+)
+
+const (
+	// defaultTimeout bounds each request to Vault.
+	defaultTimeout = 30 * time.Second
+	// defaultMount is the default path of the PKI secrets engine.
+	defaultMount = "pki"
+)
+
+// PKIConfig configures a PKI.
+type PKIConfig struct {
+	// Vault address (e.g. https://vault.example.com:8200)
+	Address string
+	// Vault token with permission to issue from the role
+	Token string
+	// Path where the PKI secrets engine is mounted (default "pki")
+	Mount string
+	// Role to issue certificates from
+	Role string
+	// (optional) certificate TTL, defaults to the role's TTL
+	TTL time.Duration
+	// (optional) HTTP client, for testing
+	Client *http.Client
+}
+
+// PKI issues certificates from a Vault PKI secrets engine role.
+type PKI struct {
+	address string
+	token   string
+	mount   string
+	role    string
+	ttl     time.Duration
+	client  *http.Client
+}
+
+// NewPKI returns a new PKI.
+func NewPKI(config *PKIConfig) *PKI {
+	client := config.Client
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+	mount := strings.Trim(config.Mount, "/")
+	if mount == "" {
+		mount = defaultMount
+	}
+	return &PKI{
+		address: strings.TrimSuffix(config.Address, "/"),
+		token:   config.Token,
+		mount:   mount,
+		role:    config.Role,
+		ttl:     config.TTL,
+		client:  client,
+	}
+}
+
+// IssueRequest requests a certificate.
+type IssueRequest struct {
+	CommonName string
+	AltNames   []string
+	IPSANs     []string
+}
+
+// Certificate is an issued certificate and its private key, PEM encoded.
+type Certificate struct {
+	Certificate  string   `json:"certificate"`
+	PrivateKey   string   `json:"private_key"`
+	CAChain      []string `json:"ca_chain"`
+	SerialNumber string   `json:"serial_number"`
+	Expiration   int64    `json:"expiration"`
+}
+
+// Issue issues a new certificate and private key.
+func (p *PKI) Issue(ctx context.Context, req *IssueRequest) (*Certificate, error) {
+	if req.CommonName == "" {
+		return nil, ErrCommonNameRequired
+	}
+	params := map[string]string{
+		"common_name": req.CommonName,
+	}
+	if len(req.AltNames) > 0 {
+		params["alt_names"] = strings.Join(req.AltNames, ",")
+	}
+	if len(req.IPSANs) > 0 {
+		params["ip_sans"] = strings.Join(req.IPSANs, ",")
+	}
+	if p.ttl > 0 {
+		params["ttl"] = p.ttl.String()
+	}
+	body, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	var secret struct {
+		Data struct {
+			Certificate
+			IssuingCA string `json:"issuing_ca"`
+		} `json:"data"`
+	}
+	url := fmt.Sprintf("%s/v1/%s/issue/%s", p.address, p.mount, p.role)
+	if err := p.do(ctx, "POST", url, body, &secret); err != nil {
+		return nil, err
+	}
+	cert := secret.Data.Certificate
+	if len(cert.CAChain) == 0 && secret.Data.IssuingCA != "" {
+		cert.CAChain = []string{secret.Data.IssuingCA}
+	}
+	return &cert, nil
+}
+
+// do sends a request to Vault and decodes the JSON response into v.
+func (p *PKI) do(ctx context.Context, method, url string, body []byte, v interface{}) error {
+	return do(ctx, p.client, p.token, method, url, body, v)
+}
+
+// do sends a request to Vault with the token and decodes the JSON response
+// into v.
+func do(ctx context.Context, client *http.Client, token, method, url string, body []byte, v interface{}) error {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("X-Vault-Token", token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("vault: %s %s: %s: %s", method, url, resp.Status, bytes.TrimSpace(msg))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,72 @@
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"context"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssue(t *testing.T) {
+	var path string
+	var params map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("X-Vault-Token") != "s.secret" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		path = req.URL.Path
+		json.NewDecoder(req.Body).Decode(&params)
+		w.Write([]byte(`{"data": {"certificate": "CERT", "private_key": "KEY", "issuing_ca": "CA", "serial_number": "39:dd", "expiration": 1700000000}}`))
+	}))
+	defer ts.Close()
+	pki := NewPKI(&PKIConfig{
+		Address: ts.URL + "/",
+		Token:   "s.secret",
+		Mount:   "/pki_int/",
+		Role:    "node",
+		TTL:     72 * time.Hour,
+	})
+
+	cert, err := pki.Issue(context.Background(), &IssueRequest{
+		CommonName: "node1.example.com",
+		AltNames:   []string{"node1", "etcd.example.com"},
+		IPSANs:     []string{"10.0.0.21"},
+	})
+	// assert that:
+	// - the role's issue endpoint is called with the names and TTL
+	// - the issuing CA is used as the CA chain if no chain is returned
+	assert.Nil(t, err)
+	assert.Equal(t, "/v1/pki_int/issue/node", path)
+	assert.Equal(t, map[string]string{
+		"common_name": "node1.example.com",
+		"alt_names":   "node1,etcd.example.com",
+		"ip_sans":     "10.0.0.21",
+		"ttl":         "72h0m0s",
+	}, params)
+	assert.Equal(t, &Certificate{
+		Certificate:  "CERT",
+		PrivateKey:   "KEY",
+		CAChain:      []string{"CA"},
+		SerialNumber: "39:dd",
+		Expiration:   1700000000,
+	}, cert)
+}
+
+func TestIssue_Errors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+	}))
+	defer ts.Close()
+	pki := NewPKI(&PKIConfig{Address: ts.URL, Token: "s.wrong", Role: "node"})
+
+	_, err := pki.Issue(context.Background(), &IssueRequest{})
+	assert.Equal(t, ErrCommonNameRequired, err)
+	_, err = pki.Issue(context.Background(), &IssueRequest{CommonName: "node1.example.com"})
+	assert.Contains(t, err.Error(), "403 Forbidden")
+	assert.Contains(t, err.Error(), "/v1/pki/issue/node")
+}
@@ -0,0 +1,81 @@
+package vault
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"context"
+)
+
+// Possible Transit errors
+var (
+	ErrKeyRequired = errors.New("vault: transit key name is required")
+)
+
+// defaultTransitMount is the default path of the Transit secrets engine.
+const defaultTransitMount = "transit"
+
+// TransitConfig configures a Transit.
+type TransitConfig struct {
+	// Vault address (e.g. https://vault.example.com:8200)
+	Address string
+	// Vault token with permission to decrypt with the keys
+	Token string
+	// Path where the Transit secrets engine is mounted (default "transit")
+	Mount string
+	// (optional) HTTP client, for testing
+	Client *http.Client
+}
+
+// Transit decrypts ciphertexts with Vault Transit secrets engine keys,
+// including managed keys backed by a cloud KMS.
+type Transit struct {
+	address string
+	token   string
+	mount   string
+	client  *http.Client
+}
+
+// NewTransit returns a new Transit.
+func NewTransit(config *TransitConfig) *Transit {
+	client := config.Client
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+	mount := strings.Trim(config.Mount, "/")
+	if mount == "" {
+		mount = defaultTransitMount
+	}
+	return &Transit{
+		address: strings.TrimSuffix(config.Address, "/"),
+		token:   config.Token,
+		mount:   mount,
+		client:  client,
+	}
+}
+
+// Decrypt decrypts a ciphertext (e.g. "vault:v1:...") with the named key.
+func (t *Transit) Decrypt(ctx context.Context, key, ciphertext string) ([]byte, error) {
+	if key == "" {
+		return nil, ErrKeyRequired
+	}
+	body, err := json.Marshal(map[string]string{"ciphertext": ciphertext})
+	if err != nil {
+		return nil, err
+	}
+	var secret struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	endpoint := fmt.Sprintf("%s/v1/%s/decrypt/%s", t.address, t.mount, url.PathEscape(key))
+	if err := do(ctx, t.client, t.token, "POST", endpoint, body, &secret); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(secret.Data.Plaintext)
+}
@@ -0,0 +1,50 @@
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"context"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecrypt(t *testing.T) {
+	var path string
+	var params map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("X-Vault-Token") != "s.secret" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		path = req.URL.Path
+		json.NewDecoder(req.Body).Decode(&params)
+		// base64 of "hunter2"
+		w.Write([]byte(`{"data": {"plaintext": "aHVudGVyMg=="}}`))
+	}))
+	defer ts.Close()
+	transit := NewTransit(&TransitConfig{
+		Address: ts.URL,
+		Token:   "s.secret",
+	})
+
+	plaintext, err := transit.Decrypt(context.Background(), "matchbox", "vault:v1:abcd")
+	// assert that:
+	// - the key of the default mount decrypts the ciphertext
+	// - the plaintext is base64 decoded
+	assert.Nil(t, err)
+	assert.Equal(t, "/v1/transit/decrypt/matchbox", path)
+	assert.Equal(t, "vault:v1:abcd", params["ciphertext"])
+	assert.Equal(t, "hunter2", string(plaintext))
+
+	// key is required
+	_, err = transit.Decrypt(context.Background(), "", "vault:v1:abcd")
+	assert.Equal(t, ErrKeyRequired, err)
+	// Vault errors are returned
+	transit = NewTransit(&TransitConfig{Address: ts.URL, Token: "s.wrong", Mount: "kms"})
+	_, err = transit.Decrypt(context.Background(), "matchbox", "vault:v1:abcd")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "403 Forbidden")
+	}
+}
@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// commandTimeout limits how long a Hook command may run.
+const commandTimeout = time.Minute
+
+// parseTemplate parses a Hook URL or command argument template. Fields
+// missing from an event are errors, rather than rendering empty values.
+func parseTemplate(text string) (*template.Template, error) {
+	return template.New("hook").Option("missingkey=error").Parse(text)
+}
+
+// render renders a Hook URL or command argument template with the fields
+// of an event.
+func render(text string, fields map[string]interface{}) (string, error) {
+	tmpl, err := parseTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, fields); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// renderURL renders a Hook URL template with the fields of an event, with
+// string values escaped so a value (e.g. a label sent by a machine) can't
+// change the URL's path or query.
+func renderURL(text string, fields map[string]interface{}) (string, error) {
+	return render(text, escapeFields(fields).(map[string]interface{}))
+}
+
+// escapeFields returns a copy of decoded JSON fields with strings escaped
+// for use in URL paths and query values.
+func escapeFields(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return strings.Replace(url.QueryEscape(v), "+", "%20", -1)
+	case map[string]interface{}:
+		escaped := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			escaped[key] = escapeFields(value)
+		}
+		return escaped
+	case []interface{}:
+		escaped := make([]interface{}, len(v))
+		for i, value := range v {
+			escaped[i] = escapeFields(value)
+		}
+		return escaped
+	}
+	return v
+}
+
+// renderAll renders each of a Hook's command arguments.
+func renderAll(texts []string, fields map[string]interface{}) ([]string, error) {
+	rendered := make([]string, len(texts))
+	for i, text := range texts {
+		var err error
+		if rendered[i], err = render(text, fields); err != nil {
+			return nil, err
+		}
+	}
+	return rendered, nil
+}
+
+// run runs a Hook command with the event JSON as standard input and the
+// event type in the MATCHBOX_EVENT environment variable. Commands are run
+// directly, not by a shell.
+func (n *Notifier) run(args []string, eventType, requestID string, data []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(), "MATCHBOX_EVENT="+eventType)
+	if requestID != "" {
+		cmd.Env = append(cmd.Env, "MATCHBOX_REQUEST_ID="+requestID)
+	}
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		n.logger.Errorf("webhook: command %s failed for %s event: %v: %s", args[0], eventType, err, bytes.TrimSpace(output))
+	}
+}
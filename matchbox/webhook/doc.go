@@ -0,0 +1,3 @@
+// Package webhook notifies external systems of machine provisioning events
+// with signed HTTP callbacks.
+package webhook
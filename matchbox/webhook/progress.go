@@ -0,0 +1,119 @@
+package webhook
+
+import (
+	"time"
+)
+
+// DefaultBootLoopThreshold is the number of network boots without an
+// Ignition fetch after which a machine.boot_loop event is sent.
+const DefaultBootLoopThreshold = 5
+
+// maxCheckInterval bounds how often provisioning timeouts are checked.
+const maxCheckInterval = time.Minute
+
+// progress tracks a provisioning machine.
+type progress struct {
+	// latest boot event, with the machine's labels and matched Profile
+	event Event
+	// network boots since the last Ignition fetch
+	boots int
+	// time of the first boot
+	booted time.Time
+}
+
+// Watches returns true if any Hook subscribes to the events of machines
+// which fail to progress (machine.boot_loop or machine.timeout).
+func (n *Notifier) Watches() bool {
+	return n.Wants(EventBootLoop) || (n.Wants(EventTimeout) && n.completeTimeout > 0)
+}
+
+// Boot records a network boot of a provisioning machine and sends a
+// machine.boot_loop event when the machine boots the threshold number of
+// times without fetching its Ignition config.
+func (n *Notifier) Boot(event *Event) {
+	if n == nil || event.MachineID == "" {
+		return
+	}
+	n.mu.Lock()
+	p, ok := n.machines[event.MachineID]
+	if !ok {
+		p = &progress{booted: n.now()}
+		n.machines[event.MachineID] = p
+	}
+	p.event = *event
+	p.boots++
+	boots := p.boots
+	n.mu.Unlock()
+	if boots == n.bootLoop {
+		loop := *event
+		loop.Type = EventBootLoop
+		loop.Boots = boots
+		n.Notify(&loop)
+	}
+}
+
+// Ignition records a machine fetched its Ignition config, which resets its
+// boot count.
+func (n *Notifier) Ignition(machineID string) {
+	if n == nil || machineID == "" {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if p, ok := n.machines[machineID]; ok {
+		p.boots = 0
+	}
+}
+
+// Completed records a machine completed provisioning and stops tracking it.
+func (n *Notifier) Completed(machineID string) {
+	if n == nil || machineID == "" {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.machines, machineID)
+}
+
+// Run sends machine.timeout events for machines which do not complete
+// provisioning within the timeout until the stop channel is closed. Run
+// returns immediately if no timeout is configured.
+func (n *Notifier) Run(stop <-chan struct{}) {
+	if n == nil || n.completeTimeout <= 0 {
+		return
+	}
+	interval := n.completeTimeout / 10
+	if interval > maxCheckInterval {
+		interval = maxCheckInterval
+	}
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+			n.checkTimeouts()
+		}
+	}
+}
+
+// checkTimeouts sends machine.timeout events for machines which booted
+// longer than the timeout ago and stops tracking them.
+func (n *Notifier) checkTimeouts() {
+	now := n.now()
+	var timedOut []*progress
+	n.mu.Lock()
+	for id, p := range n.machines {
+		if now.Sub(p.booted) >= n.completeTimeout {
+			timedOut = append(timedOut, p)
+			delete(n.machines, id)
+		}
+	}
+	n.mu.Unlock()
+	for _, p := range timedOut {
+		event := p.event
+		event.Type = EventTimeout
+		event.Time = ""
+		event.Booted = p.booted.UTC().Format(time.RFC3339)
+		n.Notify(&event)
+	}
+}
@@ -0,0 +1,96 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// eventServer returns a test server which receives Events.
+func eventServer(t *testing.T) (*httptest.Server, chan *Event) {
+	received := make(chan *Event, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		event := new(Event)
+		assert.Nil(t, json.Unmarshal(body, event))
+		received <- event
+	}))
+	return srv, received
+}
+
+func TestBootLoop(t *testing.T) {
+	srv, received := eventServer(t)
+	defer srv.Close()
+	logger, _ := logtest.NewNullLogger()
+	n := NewNotifier(&Config{Hooks: []Hook{{URL: srv.URL}}, BootLoopThreshold: 3, Logger: logger})
+	event := &Event{MachineID: "a1b2c3d4", Labels: map[string]string{"uuid": "a1b2c3d4"}, Profile: "etcd3"}
+
+	// assert that:
+	// - Ignition fetches reset the boot count
+	// - machine.boot_loop is sent once the threshold is reached, with the
+	//   machine's labels and Profile
+	n.Boot(event)
+	n.Boot(event)
+	n.Ignition("a1b2c3d4")
+	n.Boot(event)
+	n.Boot(event)
+	assert.Len(t, received, 0)
+	n.Boot(event)
+	loop := <-received
+	assert.Equal(t, EventBootLoop, loop.Type)
+	assert.Equal(t, 3, loop.Boots)
+	assert.Equal(t, "etcd3", loop.Profile)
+	assert.Equal(t, event.Labels, loop.Labels)
+	n.Boot(event)
+	assert.Len(t, received, 0)
+	// the caller's event is unchanged
+	assert.Equal(t, "", event.Type)
+}
+
+func TestCompleteTimeout(t *testing.T) {
+	srv, received := eventServer(t)
+	defer srv.Close()
+	logger, _ := logtest.NewNullLogger()
+	n := NewNotifier(&Config{
+		Hooks:           []Hook{{URL: srv.URL, Events: []string{EventTimeout}}},
+		CompleteTimeout: 30 * time.Minute,
+		Logger:          logger,
+	})
+	booted := time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+	n.now = func() time.Time { return booted }
+	n.Boot(&Event{MachineID: "a1b2c3d4", Profile: "etcd3"})
+	n.Boot(&Event{MachineID: "e5f6a7b8", Profile: "etcd3"})
+	n.Completed("e5f6a7b8")
+
+	// assert that:
+	// - machines within the timeout are not reported
+	// - machines past the timeout are reported once
+	// - completed machines are not reported
+	n.now = func() time.Time { return booted.Add(10 * time.Minute) }
+	n.checkTimeouts()
+	assert.Len(t, received, 0)
+	n.now = func() time.Time { return booted.Add(30 * time.Minute) }
+	n.checkTimeouts()
+	timeout := <-received
+	assert.Equal(t, EventTimeout, timeout.Type)
+	assert.Equal(t, "a1b2c3d4", timeout.MachineID)
+	assert.Equal(t, "etcd3", timeout.Profile)
+	assert.Equal(t, "2017-03-01T12:00:00Z", timeout.Booted)
+	n.checkTimeouts()
+	assert.Len(t, received, 0)
+	assert.True(t, n.Watches())
+
+	// nil Notifiers track nothing
+	var none *Notifier
+	none.Boot(&Event{MachineID: "a1b2c3d4"})
+	none.Ignition("a1b2c3d4")
+	none.Completed("a1b2c3d4")
+	none.Run(nil)
+	assert.False(t, none.Watches())
+}
@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// slackMessage returns a Slack incoming webhook message body describing an
+// Event with the machine's labels and matched Group and Profile.
+func slackMessage(event *Event) ([]byte, error) {
+	var text bytes.Buffer
+	fmt.Fprintf(&text, "*%s* machine `%s`", event.Type, event.MachineID)
+	if event.Group != "" || event.Profile != "" {
+		fmt.Fprintf(&text, " (group `%s`, profile `%s`)", event.Group, event.Profile)
+	}
+	fmt.Fprintf(&text, " %s", describe(event))
+	if len(event.Labels) > 0 {
+		keys := make([]string, 0, len(event.Labels))
+		for key := range event.Labels {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		labels := make([]string, len(keys))
+		for i, key := range keys {
+			labels[i] = key + "=" + event.Labels[key]
+		}
+		fmt.Fprintf(&text, "\nlabels: %s", strings.Join(labels, ", "))
+	}
+	return json.Marshal(map[string]string{"text": text.String()})
+}
+
+// describe returns what happened to the machine in an Event.
+func describe(event *Event) string {
+	switch event.Type {
+	case EventBoot:
+		return "network booted for the first time"
+	case EventIgnition:
+		return "fetched its Ignition config"
+	case EventComplete:
+		return "completed provisioning"
+	case EventFailed:
+		return fmt.Sprintf("failed %d boot requests in a row", event.Failures)
+	case EventBootLoop:
+		return fmt.Sprintf("network booted %d times without fetching its Ignition config", event.Boots)
+	case EventTimeout:
+		return fmt.Sprintf("has not completed provisioning since booting at %s", event.Booted)
+	case EventDecommissioned:
+		return "was decommissioned and archived"
+	case EventInstallFailed:
+		return fmt.Sprintf("network booted the installer %d times without completing, serving the rescue profile", event.Attempts)
+	}
+	return ""
+}
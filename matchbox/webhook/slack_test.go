@@ -0,0 +1,23 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlackMessage(t *testing.T) {
+	event := &Event{
+		Type:      EventBootLoop,
+		MachineID: "a1b2c3d4",
+		Labels:    map[string]string{"uuid": "a1b2c3d4", "mac": "52:54:00:89:d8:10"},
+		Group:     "node1",
+		Profile:   "etcd3",
+		Boots:     5,
+	}
+	// assert that the message describes the event with sorted labels
+	body, err := slackMessage(event)
+	assert.Nil(t, err)
+	expected := `{"text":"*machine.boot_loop* machine ` + "`a1b2c3d4`" + ` (group ` + "`node1`" + `, profile ` + "`etcd3`" + `) network booted 5 times without fetching its Ignition config\nlabels: mac=52:54:00:89:d8:10, uuid=a1b2c3d4"}`
+	assert.Equal(t, expected, string(body))
+}
@@ -0,0 +1,356 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/coreos/matchbox/matchbox/requestid"
+	"github.com/coreos/matchbox/matchbox/storage/storagepb"
+)
+
+// Provisioning event types
+const (
+	// EventBoot is sent when a machine network boots for the first time.
+	EventBoot = "machine.boot"
+	// EventIgnition is sent when a machine fetches its Ignition config.
+	EventIgnition = "machine.ignition"
+	// EventComplete is sent when a machine reports provisioning is complete.
+	EventComplete = "machine.complete"
+	// EventFailed is sent when a machine's boot requests fail repeatedly.
+	EventFailed = "machine.failed"
+	// EventBootLoop is sent when a machine network boots repeatedly without
+	// fetching its Ignition config.
+	EventBootLoop = "machine.boot_loop"
+	// EventTimeout is sent when a machine does not report provisioning is
+	// complete within a timeout of booting.
+	EventTimeout = "machine.timeout"
+	// EventDecommissioned is sent when a machine being decommissioned
+	// reports completion and is archived.
+	EventDecommissioned = "machine.decommissioned"
+	// EventInstallFailed is sent when a machine network boots the installer
+	// the maximum number of times without completing, and is served the
+	// rescue profile instead.
+	EventInstallFailed = "machine.install_failed"
+)
+
+// Webhook body formats
+const (
+	// FormatJSON sends the Event as JSON (default).
+	FormatJSON = "json"
+	// FormatSlack sends a Slack incoming webhook message.
+	FormatSlack = "slack"
+)
+
+// Webhook request headers
+const (
+	EventHeader     = "X-Matchbox-Event"
+	SignatureHeader = "X-Matchbox-Signature"
+)
+
+// DefaultFailureThreshold is the number of consecutive failed boot requests
+// after which a machine.failed event is sent.
+const DefaultFailureThreshold = 3
+
+// Possible configuration errors
+var (
+	ErrURLRequired   = errors.New("webhook: url or command is required")
+	ErrURLAndCommand = errors.New("webhook: only one of url or command may be set")
+	ErrInvalidEvent  = errors.New("webhook: unknown event type")
+	ErrInvalidFormat = errors.New("webhook: format must be json or slack")
+)
+
+// Hook is a URL which is sent events of the given types, or a command which
+// is run for them. The URL and command arguments are templates rendered
+// with the fields of the event (e.g. {{.machine_id}}), which are escaped in
+// URLs. Command arguments are not escaped and may begin with "-".
+type Hook struct {
+	URL string `json:"url,omitempty"`
+	// command and arguments run with the event JSON as standard input,
+	// instead of sending a request
+	Command []string `json:"command,omitempty"`
+	// (optional) key used to sign request bodies with HMAC-SHA256
+	Secret string `json:"secret,omitempty"`
+	// event types to send (all if empty)
+	Events []string `json:"events,omitempty"`
+	// body format, json or slack (defaults to json)
+	Format string `json:"format,omitempty"`
+}
+
+// AssertValid validates a Hook.
+func (h *Hook) AssertValid() error {
+	if h.URL == "" && len(h.Command) == 0 {
+		return ErrURLRequired
+	}
+	if h.URL != "" && len(h.Command) > 0 {
+		return ErrURLAndCommand
+	}
+	for _, text := range append([]string{h.URL}, h.Command...) {
+		if _, err := parseTemplate(text); err != nil {
+			return err
+		}
+	}
+	for _, event := range h.Events {
+		switch event {
+		case EventBoot, EventIgnition, EventComplete, EventFailed, EventBootLoop, EventTimeout, EventDecommissioned, EventInstallFailed:
+		default:
+			return ErrInvalidEvent
+		}
+	}
+	switch h.Format {
+	case "", FormatJSON, FormatSlack:
+	default:
+		return ErrInvalidFormat
+	}
+	return nil
+}
+
+// wants returns true if the Hook subscribes to the event type.
+func (h *Hook) wants(eventType string) bool {
+	if len(h.Events) == 0 {
+		return true
+	}
+	for _, event := range h.Events {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadHooks reads a JSON file listing Hooks.
+func LoadHooks(path string) ([]Hook, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Webhooks []Hook `json:"webhooks"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	for i := range file.Webhooks {
+		if err := file.Webhooks[i].AssertValid(); err != nil {
+			return nil, err
+		}
+	}
+	return file.Webhooks, nil
+}
+
+// Event describes a machine provisioning event.
+type Event struct {
+	Type string `json:"type"`
+	Time string `json:"time"`
+	// machine UUID, or MAC address if the UUID is unknown
+	MachineID string            `json:"machine_id"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Group     string            `json:"group,omitempty"`
+	Profile   string            `json:"profile,omitempty"`
+	// ID of the request which triggered the event, as in matchbox's logs
+	RequestID string `json:"request_id,omitempty"`
+	// consecutive failed requests (machine.failed only)
+	Failures int `json:"failures,omitempty"`
+	// network boots without fetching Ignition (machine.boot_loop only)
+	Boots int `json:"boots,omitempty"`
+	// network boots without completing (machine.install_failed only)
+	Attempts int `json:"attempts,omitempty"`
+	// time of the machine's first tracked boot (machine.timeout only)
+	Booted string `json:"booted,omitempty"`
+	// Machine record (machine.complete only)
+	Machine *storagepb.Machine `json:"machine,omitempty"`
+	// metadata of the machine's Group (machine.complete only)
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+}
+
+// Config configures a Notifier.
+type Config struct {
+	Hooks []Hook
+	// consecutive failures before machine.failed (defaults to 3)
+	FailureThreshold int
+	// network boots without an Ignition fetch before machine.boot_loop
+	// (defaults to 5)
+	BootLoopThreshold int
+	// time from boot to completion before machine.timeout (0 disables)
+	CompleteTimeout time.Duration
+	// HTTP client (defaults to http.DefaultClient)
+	Client *http.Client
+	Logger *logrus.Logger
+}
+
+// Notifier sends events to the Hooks subscribed to them. All methods are
+// safe to call on a nil Notifier, which sends nothing.
+type Notifier struct {
+	hooks           []Hook
+	threshold       int
+	bootLoop        int
+	completeTimeout time.Duration
+	client          *http.Client
+	logger          *logrus.Logger
+
+	mu       sync.Mutex
+	failures map[string]int
+	// provisioning machines, by machine id
+	machines map[string]*progress
+	now      func() time.Time
+}
+
+// NewNotifier returns a new Notifier.
+func NewNotifier(config *Config) *Notifier {
+	n := &Notifier{
+		hooks:           config.Hooks,
+		threshold:       config.FailureThreshold,
+		bootLoop:        config.BootLoopThreshold,
+		completeTimeout: config.CompleteTimeout,
+		client:          config.Client,
+		logger:          config.Logger,
+		failures:        make(map[string]int),
+		machines:        make(map[string]*progress),
+		now:             time.Now,
+	}
+	if n.threshold < 1 {
+		n.threshold = DefaultFailureThreshold
+	}
+	if n.bootLoop < 1 {
+		n.bootLoop = DefaultBootLoopThreshold
+	}
+	if n.client == nil {
+		n.client = http.DefaultClient
+	}
+	if n.logger == nil {
+		n.logger = logrus.New()
+	}
+	return n
+}
+
+// Wants returns true if any Hook subscribes to the event type.
+func (n *Notifier) Wants(eventType string) bool {
+	if n == nil {
+		return false
+	}
+	for i := range n.hooks {
+		if n.hooks[i].wants(eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Notify sends the event to subscribed Hooks in the background.
+func (n *Notifier) Notify(event *Event) {
+	if !n.Wants(event.Type) {
+		return
+	}
+	if event.Time == "" {
+		event.Time = n.now().UTC().Format(time.RFC3339)
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		n.logger.Errorf("webhook: error encoding %s event: %v", event.Type, err)
+		return
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		n.logger.Errorf("webhook: error decoding %s event: %v", event.Type, err)
+		return
+	}
+	for i := range n.hooks {
+		hook := &n.hooks[i]
+		if !hook.wants(event.Type) {
+			continue
+		}
+		if len(hook.Command) > 0 {
+			args, err := renderAll(hook.Command, fields)
+			if err != nil {
+				n.logger.Errorf("webhook: error rendering command for %s event: %v", event.Type, err)
+				continue
+			}
+			go n.run(args, event.Type, event.RequestID, data)
+			continue
+		}
+		url, err := renderURL(hook.URL, fields)
+		if err != nil {
+			n.logger.Errorf("webhook: error rendering url for %s event: %v", event.Type, err)
+			continue
+		}
+		body := data
+		if hook.Format == FormatSlack {
+			if body, err = slackMessage(event); err != nil {
+				n.logger.Errorf("webhook: error encoding %s event: %v", event.Type, err)
+				continue
+			}
+		}
+		go n.send(hook, url, event.Type, event.RequestID, body)
+	}
+}
+
+// Failure counts a failed request by the event's machine and sends a
+// machine.failed event when the failure threshold is reached.
+func (n *Notifier) Failure(event *Event) {
+	if n == nil || event.MachineID == "" {
+		return
+	}
+	n.mu.Lock()
+	n.failures[event.MachineID]++
+	count := n.failures[event.MachineID]
+	n.mu.Unlock()
+	if count == n.threshold {
+		event.Type = EventFailed
+		event.Failures = count
+		n.Notify(event)
+	}
+}
+
+// Success resets the failure count of a machine.
+func (n *Notifier) Success(machineID string) {
+	if n == nil || machineID == "" {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.failures, machineID)
+}
+
+// send POSTs the event body to the Hook's (rendered) URL, signed with the
+// Hook secret, with the ID of the request which triggered the event.
+func (n *Notifier) send(hook *Hook, url, eventType, requestID string, data []byte) {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(data))
+	if err != nil {
+		n.logger.Errorf("webhook: invalid request to %s: %v", hook.URL, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, eventType)
+	if requestID != "" {
+		req.Header.Set(requestid.Header, requestID)
+	}
+	if hook.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(hook.Secret, data))
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		n.logger.Errorf("webhook: error sending %s event to %s: %v", eventType, hook.URL, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		n.logger.Warningf("webhook: %s returned %s for %s event", hook.URL, resp.Status, eventType)
+	}
+}
+
+// Sign returns the signature header value of a body, "sha256=" followed
+// by the hex HMAC-SHA256 of the body keyed by the secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
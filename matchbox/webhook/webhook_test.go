@@ -0,0 +1,152 @@
+package webhook
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	logtest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/matchbox/matchbox/requestid"
+)
+
+func TestHookAssertValid(t *testing.T) {
+	cases := []struct {
+		hook Hook
+		err  error
+	}{
+		{Hook{URL: "https://hooks.example.com"}, nil},
+		{Hook{URL: "https://hooks.example.com", Events: []string{EventBoot, EventFailed}}, nil},
+		{Hook{}, ErrURLRequired},
+		{Hook{URL: "https://hooks.example.com", Events: []string{"machine.unknown"}}, ErrInvalidEvent},
+		{Hook{URL: "https://hooks.slack.com/services/T0/B0/x", Events: []string{EventBootLoop, EventTimeout}, Format: FormatSlack}, nil},
+		{Hook{URL: "https://hooks.example.com", Format: "xml"}, ErrInvalidFormat},
+		{Hook{Command: []string{"/usr/local/bin/add-dns", "{{.labels.hostname}}"}, Events: []string{EventComplete}}, nil},
+		{Hook{URL: "https://hooks.example.com", Command: []string{"true"}}, ErrURLAndCommand},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.err, c.hook.AssertValid())
+	}
+	invalid := Hook{URL: "https://hooks.example.com/{{.machine_id"}
+	assert.Error(t, invalid.AssertValid())
+}
+
+func TestLoadHooks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "matchbox-webhook")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "webhooks.json")
+	err = ioutil.WriteFile(path, []byte(`{"webhooks":[{"url":"https://hooks.example.com","secret":"s3cr3t","events":["machine.complete"]}]}`), 0644)
+	assert.Nil(t, err)
+
+	hooks, err := LoadHooks(path)
+	assert.Nil(t, err)
+	expected := []Hook{{URL: "https://hooks.example.com", Secret: "s3cr3t", Events: []string{EventComplete}}}
+	assert.Equal(t, expected, hooks)
+}
+
+func TestNotify(t *testing.T) {
+	type delivery struct {
+		header http.Header
+		body   []byte
+	}
+	received := make(chan delivery, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		received <- delivery{req.Header, body}
+	}))
+	defer srv.Close()
+
+	logger, _ := logtest.NewNullLogger()
+	n := NewNotifier(&Config{
+		Hooks:  []Hook{{URL: srv.URL, Secret: "s3cr3t", Events: []string{EventIgnition}}},
+		Logger: logger,
+	})
+	// assert that:
+	// - only subscribed event types are sent
+	// - requests are signed with the hook secret
+	// - requests carry the ID of the request which triggered the event
+	assert.True(t, n.Wants(EventIgnition))
+	assert.False(t, n.Wants(EventBoot))
+	n.Notify(&Event{Type: EventBoot, MachineID: "a1b2c3d4"})
+	n.Notify(&Event{Type: EventIgnition, MachineID: "a1b2c3d4", RequestID: "5e8f1a2b3c4d6e7f"})
+	d := <-received
+	assert.Equal(t, EventIgnition, d.header.Get(EventHeader))
+	assert.Equal(t, Sign("s3cr3t", d.body), d.header.Get(SignatureHeader))
+	assert.Equal(t, "5e8f1a2b3c4d6e7f", d.header.Get(requestid.Header))
+	assert.Contains(t, string(d.body), `"machine_id":"a1b2c3d4"`)
+	assert.Contains(t, string(d.body), `"request_id":"5e8f1a2b3c4d6e7f"`)
+}
+
+func TestNotify_Templates(t *testing.T) {
+	paths := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		paths <- req.RequestURI
+	}))
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "matchbox-webhook")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	logger, _ := logtest.NewNullLogger()
+	n := NewNotifier(&Config{
+		Hooks: []Hook{
+			{URL: srv.URL + "/machines/{{.machine_id}}", Events: []string{EventComplete}},
+			{URL: srv.URL + "/racks/{{.labels.rack}}?serial={{.labels.serial}}", Events: []string{EventBoot}},
+			{Command: []string{"cp", "/dev/stdin", filepath.Join(dir, "{{.metadata.hostname}}")}, Events: []string{EventComplete}},
+		},
+		Logger: logger,
+	})
+	// assert that:
+	// - URLs are rendered with the event's fields
+	// - commands are run with arguments rendered with the event's fields,
+	// including Group metadata, and the event JSON as input
+	n.Notify(&Event{Type: EventComplete, MachineID: "a1b2c3d4", Metadata: []byte(`{"hostname":"node1"}`)})
+	assert.Equal(t, "/machines/a1b2c3d4", <-paths)
+	path := filepath.Join(dir, "node1")
+	var data []byte
+	for i := 0; i < 100; i++ {
+		if data, err = ioutil.ReadFile(path); err == nil && len(data) > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Contains(t, string(data), `"machine_id":"a1b2c3d4"`)
+
+	// - values are escaped in URLs
+	n.Notify(&Event{Type: EventBoot, MachineID: "a1b2c3d4", Labels: map[string]string{"rack": "r1/../admin", "serial": "a b&c=d"}})
+	assert.Equal(t, "/racks/r1%2F..%2Fadmin?serial=a%20b%26c%3Dd", <-paths)
+}
+
+func TestFailure(t *testing.T) {
+	received := make(chan string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		received <- req.Header.Get(EventHeader)
+	}))
+	defer srv.Close()
+
+	logger, _ := logtest.NewNullLogger()
+	n := NewNotifier(&Config{Hooks: []Hook{{URL: srv.URL}}, Logger: logger})
+	// assert that:
+	// - machine.failed is sent once the threshold is reached
+	// - successes reset the failure count
+	n.Failure(&Event{MachineID: "a1b2c3d4"})
+	n.Success("a1b2c3d4")
+	for i := 0; i < DefaultFailureThreshold; i++ {
+		n.Failure(&Event{MachineID: "a1b2c3d4"})
+	}
+	assert.Equal(t, EventFailed, <-received)
+	n.Failure(&Event{MachineID: "a1b2c3d4"})
+	assert.Len(t, received, 0)
+
+	// nil Notifiers send nothing
+	var none *Notifier
+	assert.False(t, none.Wants(EventBoot))
+	none.Notify(&Event{Type: EventBoot})
+	none.Failure(&Event{MachineID: "a1b2c3d4"})
+}